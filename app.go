@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"sync"
 
+	"netvisionmonitor/internal/auth"
 	"netvisionmonitor/internal/config"
 	"netvisionmonitor/internal/database"
 	"netvisionmonitor/internal/encryption"
 	"netvisionmonitor/internal/logger"
+	"netvisionmonitor/internal/models"
 	"netvisionmonitor/internal/monitoring"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -20,11 +24,38 @@ type App struct {
 	db      *database.Database
 	cfg     *config.Config
 	monitor *monitoring.Monitor
+
+	complianceMu   sync.Mutex
+	complianceStop chan struct{}
+	slaStop        chan struct{}
+	rollupStop     chan struct{}
+	incidentStop   chan struct{}
+	warrantyStop   chan struct{}
+	autoBackupStop chan struct{}
+	sessionStop    chan struct{}
+
+	backupMu sync.Mutex // Serializes automatic backups and restores
+
+	// secretsMu is held for reading while encrypted values are stored and for
+	// writing while the master key or its passphrase changes, so no value is
+	// stored with a key that is being replaced
+	secretsMu sync.RWMutex
+
+	envMu     sync.Mutex
+	envAlarms map[int64]map[string]bool // Raised environment alarms of switches
+
+	poeMu    sync.Mutex
+	poePorts map[poePortKey]*poePortState // Power tracking of PoE ports
+
+	ptzPaths sync.Map // PTZ service path by camera device ID
+
+	auditMu sync.Mutex
+	session *auth.Session
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	return &App{session: auth.NewSession()}
 }
 
 // startup is called when the app starts
@@ -62,7 +93,9 @@ func (a *App) startup(ctx context.Context) {
 	logger.Info("Portable mode: %v", cfg.IsPortable)
 
 	// Initialize encryption
-	if err := encryption.Initialize(cfg.DataDir); err != nil {
+	if err := encryption.Initialize(cfg.DataDir); errors.Is(err, encryption.ErrPassphraseRequired) {
+		logger.Info("Master key is protected by a passphrase, waiting for unlock")
+	} else if err != nil {
 		logger.Error("Failed to initialize encryption: %v", err)
 		runtime.MessageDialog(ctx, runtime.MessageDialogOptions{
 			Type:    runtime.ErrorDialog,
@@ -70,25 +103,47 @@ func (a *App) startup(ctx context.Context) {
 			Message: fmt.Sprintf("Не удалось инициализировать шифрование: %v", err),
 		})
 		return
+	} else {
+		logger.Info("Encryption initialized")
 	}
-	logger.Info("Encryption initialized")
 
 	// Initialize database
 	db, err := database.Initialize(cfg.DataDir)
 	if err != nil {
 		logger.Error("Failed to initialize database: %v", err)
+		message := fmt.Sprintf("Не удалось инициализировать базу данных: %v", err)
+		if errors.Is(err, database.ErrSchemaTooNew) {
+			message = "База данных создана более новой версией NetVisionMonitor. Установите новую версию программы."
+		}
 		runtime.MessageDialog(ctx, runtime.MessageDialogOptions{
 			Type:    runtime.ErrorDialog,
 			Title:   "Ошибка инициализации",
-			Message: fmt.Sprintf("Не удалось инициализировать базу данных: %v", err),
+			Message: message,
 		})
 		return
 	}
 	a.db = db
 	logger.Info("Database initialized")
 
+	// Services need the master key to read credentials, so with a protected key
+	// they start once the passphrase is entered
+	if encryption.IsInitialized() {
+		a.startServices()
+	}
+
+	// Initialize system tray
+	InitTray(a)
+
+	logger.Info("Application started successfully")
+}
+
+// startServices starts monitoring and the background schedulers
+func (a *App) startServices() {
+	// Finish a key rotation interrupted by a crash
+	a.resolvePendingKeyRotation()
+
 	// Fix existing port types based on sfp_port_count
-	if err := db.FixExistingPortTypes(); err != nil {
+	if err := a.db.FixExistingPortTypes(); err != nil {
 		logger.Warn("Failed to fix existing port types: %v", err)
 	} else {
 		logger.Info("Port types verified")
@@ -97,14 +152,33 @@ func (a *App) startup(ctx context.Context) {
 	// Initialize monitoring
 	a.initMonitoring()
 
+	// Build status periods from history recorded before they were tracked
+	a.backfillStatusPeriods()
+
 	// Auto-start monitoring
 	a.monitor.Start()
 	logger.Info("Monitoring started")
 
-	// Initialize system tray
-	InitTray(a)
+	// Start periodic compliance audits
+	a.startComplianceScheduler()
 
-	logger.Info("Application started successfully")
+	// Start scheduled SLA reports
+	a.startSLAScheduler()
+
+	// Start status history rollups
+	a.startHistoryRollup()
+
+	// Start reminders of unacknowledged incidents
+	a.startIncidentReminders()
+
+	// Start warranty expiry reminders
+	a.startWarrantyReminders()
+
+	// Start automatic backups
+	a.startAutoBackupScheduler()
+
+	// Lock idle sessions
+	a.startSessionWatcher()
 }
 
 // beforeClose is called when the user tries to close the window
@@ -124,6 +198,27 @@ func (a *App) shutdown(ctx context.Context) {
 	// Stop system tray
 	StopTray()
 
+	// Stop compliance audits
+	a.stopComplianceScheduler()
+
+	// Stop SLA reports
+	a.stopSLAScheduler()
+
+	// Stop status history rollups
+	a.stopHistoryRollup()
+
+	// Stop incident reminders
+	a.stopIncidentReminders()
+
+	// Stop warranty reminders
+	a.stopWarrantyReminders()
+
+	// Stop automatic backups
+	a.stopAutoBackupScheduler()
+
+	// Stop session watcher
+	a.stopSessionWatcher()
+
 	// Stop monitoring
 	if a.monitor != nil {
 		a.monitor.Stop()
@@ -157,6 +252,10 @@ func (a *App) GetLogPath() string {
 
 // OpenLogFolder opens the log folder in file explorer
 func (a *App) OpenLogFolder() error {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return err
+	}
+
 	if a.cfg == nil {
 		return fmt.Errorf("config not initialized")
 	}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// AssetFieldInput is used to create or update a custom asset field
+type AssetFieldInput struct {
+	ID         int64  `json:"id,omitempty"`
+	Key        string `json:"key"`
+	Label      string `json:"label"`
+	DeviceType string `json:"device_type"` // Empty for all device types
+	FieldType  string `json:"field_type"`
+	Position   int    `json:"position"`
+}
+
+// DeviceAssetInput is used to save asset data of a device
+type DeviceAssetInput struct {
+	SerialNumber string            `json:"serial_number"`
+	MACAddress   string            `json:"mac_address"`
+	InstallDate  string            `json:"install_date"` // "2006-01-02"
+	WarrantyEnd  string            `json:"warranty_end"` // "2006-01-02"
+	Room         string            `json:"room"`
+	Rack         string            `json:"rack"`
+	RackUnit     string            `json:"rack_unit"`
+	Notes        string            `json:"notes"`
+	Custom       map[string]string `json:"custom"`
+}
+
+// WarrantyInfo describes a device whose warranty ends soon or has ended
+type WarrantyInfo struct {
+	DeviceID    int64  `json:"device_id"`
+	DeviceName  string `json:"device_name"`
+	DeviceType  string `json:"device_type"`
+	IPAddress   string `json:"ip_address"`
+	WarrantyEnd string `json:"warranty_end"`
+	DaysLeft    int    `json:"days_left"` // Negative if the warranty has ended
+}
+
+// assetFieldKeyPattern restricts keys of custom fields
+var assetFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// warrantyCheckInterval is how often warranty reminders are checked
+const warrantyCheckInterval = time.Hour
+
+// GetAssetFields returns all custom asset fields
+func (a *App) GetAssetFields() ([]models.AssetField, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewAssetRepository(a.db.DB())
+	return repo.GetFields()
+}
+
+// CreateAssetField defines a new custom asset field
+func (a *App) CreateAssetField(input AssetFieldInput) (_ *models.AssetField, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("asset_field.create", models.AuditTarget{Type: "asset_field", Name: input.Key}, nil)
+	defer func() { rec.finish(err) }()
+
+	field, err := assetFieldFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if !assetFieldKeyPattern.MatchString(field.Key) {
+		return nil, fmt.Errorf("key must start with a letter and contain only lowercase letters, digits and underscores")
+	}
+	if input.DeviceType != "" && !models.DeviceType(input.DeviceType).Valid() {
+		return nil, fmt.Errorf("invalid device type: %s", input.DeviceType)
+	}
+	field.DeviceType = models.DeviceType(input.DeviceType)
+
+	repo := database.NewAssetRepository(a.db.DB())
+	existing, err := repo.GetFieldByKey(field.Key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("field with key %s already exists", field.Key)
+	}
+
+	if err := repo.CreateField(field); err != nil {
+		return nil, err
+	}
+	rec.track(field.ID, field.Key, a.rowAuditState("asset_fields", "id", field.ID))
+	return field, nil
+}
+
+// UpdateAssetField updates label, type and position of a custom asset field
+func (a *App) UpdateAssetField(input AssetFieldInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("asset_field.update", auditTarget("asset_field", input.ID, input.Key), a.rowAuditState("asset_fields", "id", input.ID))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewAssetRepository(a.db.DB())
+	existing, err := repo.GetFieldByID(input.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("asset field not found")
+	}
+
+	input.Key = existing.Key
+	field, err := assetFieldFromInput(input)
+	if err != nil {
+		return err
+	}
+	field.ID = existing.ID
+	return repo.UpdateField(field)
+}
+
+// DeleteAssetField removes a custom asset field and its values on all devices
+func (a *App) DeleteAssetField(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("asset_field.delete", auditTarget("asset_field", id, ""), a.rowAuditState("asset_fields", "id", id))
+	defer func() { rec.finish(err) }()
+
+	return a.db.WithTx(func(tx *sql.Tx) error {
+		return database.NewAssetRepository(tx).DeleteField(id)
+	})
+}
+
+func assetFieldFromInput(input AssetFieldInput) (*models.AssetField, error) {
+	label := strings.TrimSpace(input.Label)
+	if label == "" {
+		return nil, fmt.Errorf("label is required")
+	}
+
+	fieldType := models.AssetFieldType(input.FieldType)
+	switch fieldType {
+	case "":
+		fieldType = models.AssetFieldText
+	case models.AssetFieldText, models.AssetFieldNumber, models.AssetFieldDate:
+	default:
+		return nil, fmt.Errorf("invalid field type: %s", input.FieldType)
+	}
+
+	return &models.AssetField{
+		Key:       strings.TrimSpace(input.Key),
+		Label:     label,
+		FieldType: fieldType,
+		Position:  input.Position,
+	}, nil
+}
+
+// GetDeviceAsset returns asset data of a device. Devices without saved data get
+// an empty record.
+func (a *App) GetDeviceAsset(deviceID int64) (*models.DeviceAsset, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	asset, err := database.NewAssetRepository(a.db.DB()).Get(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil {
+		asset = &models.DeviceAsset{DeviceID: deviceID, Custom: map[string]string{}}
+	}
+	return asset, nil
+}
+
+// SaveDeviceAsset saves asset data of a device
+func (a *App) SaveDeviceAsset(deviceID int64, input DeviceAssetInput) (_ *models.DeviceAsset, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("device.asset", a.deviceAuditTarget(deviceID), a.deviceAuditState(deviceID))
+	defer func() { rec.finish(err) }()
+
+	db := a.db.DB()
+	device, err := database.NewDeviceRepository(db).GetByID(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	fields, err := database.NewAssetRepository(db).GetFields()
+	if err != nil {
+		return nil, err
+	}
+	asset, err := deviceAssetFromInput(deviceID, device.Type, input, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.db.WithTx(func(tx *sql.Tx) error {
+		return database.NewAssetRepository(tx).Save(asset)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Asset data of device %s saved", device.Name)
+	return asset, nil
+}
+
+// deviceAssetFromInput validates asset data against the custom fields defined
+// for the device type
+func deviceAssetFromInput(deviceID int64, deviceType models.DeviceType, input DeviceAssetInput, fields []models.AssetField) (*models.DeviceAsset, error) {
+	asset := &models.DeviceAsset{
+		DeviceID:     deviceID,
+		SerialNumber: strings.TrimSpace(input.SerialNumber),
+		Room:         strings.TrimSpace(input.Room),
+		Rack:         strings.TrimSpace(input.Rack),
+		RackUnit:     strings.TrimSpace(input.RackUnit),
+		Notes:        strings.TrimSpace(input.Notes),
+		Custom:       map[string]string{},
+	}
+
+	var err error
+	if asset.MACAddress, err = normalizeMAC(input.MACAddress); err != nil {
+		return nil, err
+	}
+	if asset.InstallDate, err = normalizeAssetDate(input.InstallDate); err != nil {
+		return nil, fmt.Errorf("invalid install date: %w", err)
+	}
+	if asset.WarrantyEnd, err = normalizeAssetDate(input.WarrantyEnd); err != nil {
+		return nil, fmt.Errorf("invalid warranty end: %w", err)
+	}
+
+	defined := make(map[string]models.AssetField, len(fields))
+	for _, f := range fields {
+		if f.AppliesTo(deviceType) {
+			defined[f.Key] = f
+		}
+	}
+	for key, value := range input.Custom {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		field, ok := defined[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %s", key)
+		}
+		switch field.FieldType {
+		case models.AssetFieldNumber:
+			if _, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64); err != nil {
+				return nil, fmt.Errorf("%s must be a number", field.Label)
+			}
+		case models.AssetFieldDate:
+			if value, err = normalizeAssetDate(value); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", field.Label, err)
+			}
+		}
+		asset.Custom[key] = value
+	}
+
+	return asset, nil
+}
+
+// normalizeMAC formats a MAC address as upper-case colon-separated octets
+func normalizeMAC(mac string) (string, error) {
+	mac = strings.TrimSpace(mac)
+	if mac == "" {
+		return "", nil
+	}
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return "", fmt.Errorf("invalid MAC address: %s", mac)
+	}
+	return strings.ToUpper(hw.String()), nil
+}
+
+// normalizeAssetDate accepts "2006-01-02" and "02.01.2006" dates
+func normalizeAssetDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	for _, layout := range []string{models.AssetDateLayout, "02.01.2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(models.AssetDateLayout), nil
+		}
+	}
+	return "", fmt.Errorf("expected YYYY-MM-DD, got %s", value)
+}
+
+// GetExpiringWarranties returns devices whose warranty ends within the given number
+// of days, including those whose warranty has already ended
+func (a *App) GetExpiringWarranties(days int) ([]WarrantyInfo, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	return a.getExpiringWarranties(days)
+}
+
+// getExpiringWarranties is GetExpiringWarranties without the permission check, for background tasks
+func (a *App) getExpiringWarranties(days int) ([]WarrantyInfo, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if days < 0 {
+		return nil, fmt.Errorf("days must not be negative")
+	}
+
+	db := a.db.DB()
+	now := time.Now()
+	assets, err := database.NewAssetRepository(db).GetWarrantyEndingBy(now.AddDate(0, 0, days).Format(models.AssetDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	deviceRepo := database.NewDeviceRepository(db)
+	result := make([]WarrantyInfo, 0, len(assets))
+	for _, asset := range assets {
+		daysLeft, ok := asset.WarrantyDaysLeft(now)
+		if !ok {
+			continue
+		}
+		device, err := deviceRepo.GetByID(asset.DeviceID)
+		if err != nil || device == nil {
+			continue
+		}
+		result = append(result, WarrantyInfo{
+			DeviceID:    device.ID,
+			DeviceName:  device.Name,
+			DeviceType:  string(device.Type),
+			IPAddress:   device.IPAddress,
+			WarrantyEnd: asset.WarrantyEnd,
+			DaysLeft:    daysLeft,
+		})
+	}
+	return result, nil
+}
+
+// checkWarranties creates an event once when the warranty of a device is about to
+// end and once more when it has ended. Changing the warranty end date re-arms the
+// reminders.
+func (a *App) checkWarranties() error {
+	settings := a.appSettings()
+	if settings.WarrantyReminderDays <= 0 {
+		return nil
+	}
+
+	warranties, err := a.getExpiringWarranties(settings.WarrantyReminderDays)
+	if err != nil {
+		return err
+	}
+
+	repo := database.NewAssetRepository(a.db.DB())
+	for _, w := range warranties {
+		eventType := models.EventTypeWarrantyExpiring
+		level := models.EventLevelWarn
+		message := fmt.Sprintf("Warranty of %s ends on %s (%d days left)", w.DeviceName, w.WarrantyEnd, w.DaysLeft)
+		if w.DaysLeft < 0 {
+			eventType = models.EventTypeWarrantyExpired
+			level = models.EventLevelError
+			message = fmt.Sprintf("Warranty of %s ended on %s", w.DeviceName, w.WarrantyEnd)
+		}
+
+		notice := string(eventType) + ":" + w.WarrantyEnd
+		last, err := repo.GetWarrantyNotice(w.DeviceID)
+		if err != nil {
+			return err
+		}
+		if last == notice {
+			continue
+		}
+
+		deviceID := w.DeviceID
+		if err := a.createEvent(&deviceID, eventType, level, message); err != nil {
+			log.Printf("Failed to create warranty event: %v", err)
+		}
+		if err := repo.SetWarrantyNotice(w.DeviceID, notice); err != nil {
+			return err
+		}
+		runtime.EventsEmit(a.ctx, "asset:warranty", w)
+	}
+	return nil
+}
+
+// startWarrantyReminders checks warranties shortly after startup and then every hour
+func (a *App) startWarrantyReminders() {
+	a.warrantyStop = make(chan struct{})
+	stop := a.warrantyStop
+
+	go func() {
+		timer := time.NewTimer(time.Minute)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C:
+				if err := a.checkWarranties(); err != nil {
+					log.Printf("Warranty check failed: %v", err)
+				}
+				timer.Reset(warrantyCheckInterval)
+			}
+		}
+	}()
+}
+
+// stopWarrantyReminders stops the warranty reminder scheduler
+func (a *App) stopWarrantyReminders() {
+	if a.warrantyStop != nil {
+		close(a.warrantyStop)
+		a.warrantyStop = nil
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/audit"
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+)
+
+// AuditFilterInput is used for filtering the audit log from frontend
+type AuditFilterInput struct {
+	Actor      string  `json:"actor,omitempty"`
+	Action     string  `json:"action,omitempty"` // Exact action or prefix ending with "."
+	TargetType string  `json:"target_type,omitempty"`
+	TargetID   *int64  `json:"target_id,omitempty"`
+	Result     string  `json:"result,omitempty"`
+	StartTime  *string `json:"start_time,omitempty"` // ISO 8601 format
+	EndTime    *string `json:"end_time,omitempty"`   // ISO 8601 format
+	Page       int     `json:"page"`
+	PageSize   int     `json:"page_size"`
+}
+
+// AuditLogResult contains a page of audit entries with the total count
+type AuditLogResult struct {
+	Entries    []models.AuditEntry `json:"entries"`
+	Total      int                 `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// auditState loads the current state of an audited object, nil if it does not exist
+type auditState func() interface{}
+
+// auditRecord collects an audited action from its start until its result is known
+type auditRecord struct {
+	app    *App
+	entry  models.AuditEntry
+	state  auditState
+	before interface{}
+}
+
+// beginAudit starts recording an action and captures the state of its target
+// before the change. state may be nil for actions without stored state.
+func (a *App) beginAudit(action string, target models.AuditTarget, state auditState) *auditRecord {
+	r := &auditRecord{
+		app:   a,
+		state: state,
+		entry: models.AuditEntry{
+			Timestamp: time.Now(),
+			Actor:     a.auditActor(),
+			Action:    action,
+			Target:    target,
+		},
+	}
+	if state != nil {
+		r.before = state()
+	}
+	return r
+}
+
+// track sets the target of an object created by the action. The object had no
+// state before the action.
+func (r *auditRecord) track(id int64, name string, state auditState) {
+	r.entry.Target.ID = &id
+	r.entry.Target.Name = name
+	r.state = state
+}
+
+// detail records a parameter of the action. Secret values are masked.
+func (r *auditRecord) detail(key string, value interface{}) *auditRecord {
+	if r.entry.Details == nil {
+		r.entry.Details = make(map[string]interface{})
+	}
+	r.entry.Details[key] = value
+	return r
+}
+
+// finish captures the state after the action and appends the entry to the log.
+// Failures to write the log are logged and do not affect the action.
+func (r *auditRecord) finish(err error) {
+	var after interface{}
+	if r.state != nil {
+		after = r.state()
+	}
+
+	changes, diffErr := audit.Diff(r.before, after)
+	if diffErr != nil {
+		log.Printf("Audit: %v", diffErr)
+		changes = []models.AuditChange{}
+	}
+	r.entry.Changes = changes
+	r.entry.Details = audit.MaskDetails(r.entry.Details)
+
+	r.entry.Result = models.AuditResultSuccess
+	if err != nil {
+		r.entry.Result = models.AuditResultError
+		r.entry.Error = err.Error()
+	}
+
+	if err := r.app.appendAudit(&r.entry); err != nil {
+		log.Printf("Failed to write audit entry %s: %v", r.entry.Action, err)
+	}
+}
+
+// appendAudit links an entry to the end of the hash chain and stores it
+func (a *App) appendAudit(e *models.AuditEntry) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+
+	return a.db.WithTx(func(tx *sql.Tx) error {
+		repo := database.NewAuditRepository(tx)
+		prev, err := repo.LastHash()
+		if err != nil {
+			return err
+		}
+		e.PrevHash = prev
+		if e.Hash, err = audit.Hash(e); err != nil {
+			return err
+		}
+		return repo.Create(e)
+	})
+}
+
+// auditActor returns the name recorded as the actor of audited actions: the logged
+// in user, or "system" for actions of background tasks
+func (a *App) auditActor() string {
+	if u := a.session.User(); u != nil {
+		return u.Username
+	}
+	return "system"
+}
+
+// auditTarget builds the target of an audited action
+func auditTarget(targetType string, id int64, name string) models.AuditTarget {
+	return models.AuditTarget{Type: targetType, ID: &id, Name: name}
+}
+
+// deviceAuditTarget builds the target of an action on a device, named after it
+func (a *App) deviceAuditTarget(id int64) models.AuditTarget {
+	target := auditTarget("device", id, "")
+	if device, err := database.NewDeviceRepository(a.db.DB()).GetByID(id); err == nil && device != nil {
+		target.Name = device.Name
+	}
+	return target
+}
+
+// deviceAuditState captures a device with its type-specific settings, tags and
+// asset data
+func (a *App) deviceAuditState(id int64) auditState {
+	return func() interface{} {
+		db := a.db.DB()
+		device, err := database.NewDeviceRepository(db).GetByID(id)
+		if err != nil || device == nil {
+			return nil
+		}
+
+		state := map[string]interface{}{"device": device}
+		switch device.Type {
+		case models.DeviceTypeSwitch:
+			if sw, err := database.NewSwitchRepository(db).GetByDeviceID(id); err == nil && sw != nil {
+				state["switch"] = sw
+			}
+		case models.DeviceTypeCamera:
+			if cam, err := database.NewCameraRepository(db).GetByDeviceID(id); err == nil && cam != nil {
+				state["camera"] = cam
+			}
+		case models.DeviceTypeServer:
+			if srv, err := database.NewServerRepository(db).GetByDeviceID(id); err == nil && srv != nil {
+				state["server"] = srv
+			}
+		case models.DeviceTypeRouter, models.DeviceTypeAccessPoint, models.DeviceTypeUPS,
+			models.DeviceTypeNVR, models.DeviceTypePDU:
+			if settings, err := database.NewSNMPDeviceRepository(db).GetByDeviceID(id); err == nil && settings != nil {
+				state["snmp"] = settings
+			}
+			if nvr, err := database.NewNVRRepository(db).GetByDeviceID(id); err == nil && nvr != nil {
+				state["nvr"] = nvr
+			}
+			if ups, err := database.NewUPSRepository(db).GetByDeviceID(id); err == nil && ups != nil {
+				state["ups"] = ups
+			}
+		}
+		if asset, err := database.NewAssetRepository(db).Get(id); err == nil && asset != nil {
+			state["asset"] = asset
+		}
+		return state
+	}
+}
+
+// rowAuditState captures a row of a table by its primary key
+func (a *App) rowAuditState(table, keyColumn string, key interface{}) auditState {
+	return func() interface{} {
+		rows, err := a.db.DB().Query("SELECT * FROM "+table+" WHERE "+keyColumn+" = ?", key)
+		if err != nil {
+			return nil
+		}
+		defer rows.Close()
+
+		columns, err := rows.Columns()
+		if err != nil || !rows.Next() {
+			return nil
+		}
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil
+		}
+
+		state := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			state[column] = values[i]
+		}
+		return state
+	}
+}
+
+// valueAuditState captures a value returned by a loader, e.g. application settings
+func valueAuditState(load func() (interface{}, error)) auditState {
+	return func() interface{} {
+		v, err := load()
+		if err != nil {
+			return nil
+		}
+		return v
+	}
+}
+
+// GetAuditLog returns a page of the audit log, newest first
+func (a *App) GetAuditLog(filter AuditFilterInput) (*AuditLogResult, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	page := filter.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := filter.PageSize
+	if pageSize <= 0 || pageSize > 500 {
+		pageSize = 50
+	}
+
+	f := auditFilterFromInput(filter)
+	f.Limit = pageSize
+	f.Offset = (page - 1) * pageSize
+
+	entries, total, err := database.NewAuditRepository(a.db.DB()).GetFiltered(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditLogResult{
+		Entries:    entries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+func auditFilterFromInput(filter AuditFilterInput) models.AuditFilter {
+	f := models.AuditFilter{
+		Actor:      filter.Actor,
+		Action:     filter.Action,
+		TargetType: filter.TargetType,
+		TargetID:   filter.TargetID,
+		Result:     filter.Result,
+	}
+	if filter.StartTime != nil {
+		if t, err := time.Parse(time.RFC3339, *filter.StartTime); err == nil {
+			f.StartTime = &t
+		}
+	}
+	if filter.EndTime != nil {
+		if t, err := time.Parse(time.RFC3339, *filter.EndTime); err == nil {
+			f.EndTime = &t
+		}
+	}
+	return f
+}
+
+// VerifyAuditLog checks that no entry of the audit log was modified or removed
+// other than by retention
+func (a *App) VerifyAuditLog() (*models.AuditVerification, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+
+	repo := database.NewAuditRepository(a.db.DB())
+	anchor, err := repo.Anchor()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := repo.GetChain()
+	if err != nil {
+		return nil, err
+	}
+
+	result := audit.Verify(entries, anchor)
+	if !result.Valid {
+		log.Printf("Audit log verification failed at entry %d: %s", *result.BrokenAt, result.Reason)
+	}
+	return &result, nil
+}
+
+// ExportAuditLog asks for a file and writes the filtered audit log in the given
+// format. Hashes are included so the export can be checked against the chain.
+func (a *App) ExportAuditLog(filter AuditFilterInput, format string) (string, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return "", err
+	}
+	if a.db == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+	if err := validateReportFormat(format); err != nil {
+		return "", err
+	}
+
+	savePath, err := a.askReportPath("Экспорт журнала аудита", "netvision_audit", format)
+	if err != nil || savePath == "" {
+		return "", err
+	}
+
+	entries, _, err := database.NewAuditRepository(a.db.DB()).GetFiltered(auditFilterFromInput(filter))
+	if err != nil {
+		return "", err
+	}
+
+	table := reportTable{
+		Title:    "Журнал аудита",
+		Subtitle: fmt.Sprintf("Сформирован %s, записей: %d", time.Now().Format("02.01.2006 15:04"), len(entries)),
+		Sheet:    "Аудит",
+		Columns:  []string{"№", "Время", "Пользователь", "Действие", "Объект", "Изменения", "Результат", "Хеш"},
+		Widths:   []float64{0.7, 1.8, 1.4, 1.8, 2, 4.5, 1.6, 2.2},
+	}
+	for _, e := range entries {
+		result := "Успешно"
+		if e.Result == models.AuditResultError {
+			result = "Ошибка: " + e.Error
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			e.ID, e.Timestamp.Format("02.01.2006 15:04:05"), e.Actor, e.Action,
+			auditTargetText(e.Target), auditChangesText(e), result, e.Hash,
+		})
+	}
+
+	if err := writeReport(savePath, format, []reportTable{table}); err != nil {
+		return "", err
+	}
+
+	log.Printf("Audit log exported to %s", savePath)
+	return savePath, nil
+}
+
+func auditTargetText(t models.AuditTarget) string {
+	text := t.Type
+	if t.ID != nil {
+		text += fmt.Sprintf(" #%d", *t.ID)
+	}
+	if t.Name != "" {
+		text += " " + t.Name
+	}
+	return text
+}
+
+// auditChangesText formats changes and parameters of an entry as lines of text
+func auditChangesText(e models.AuditEntry) string {
+	var lines []string
+	for _, c := range e.Changes {
+		lines = append(lines, fmt.Sprintf("%s: %s → %s", c.Field, auditValueText(c.Before), auditValueText(c.After)))
+	}
+	keys := make([]string, 0, len(e.Details))
+	for k := range e.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s = %s", k, auditValueText(e.Details[k])))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func auditValueText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "—"
+	case string:
+		return v
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// applyAuditRetention removes audit entries past their retention
+func (a *App) applyAuditRetention(now time.Time) error {
+	settings := a.appSettings()
+	if settings.AuditRetentionDays <= 0 {
+		return nil
+	}
+
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+
+	var deleted int64
+	err := a.db.WithTx(func(tx *sql.Tx) error {
+		var err error
+		deleted, err = database.NewAuditRepository(tx).DeleteOlderThan(now.AddDate(0, 0, -settings.AuditRetentionDays))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d audit log entries", deleted)
+	}
+	return nil
+}
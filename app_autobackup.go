@@ -0,0 +1,571 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"netvisionmonitor/internal/backup"
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// settingsKeyLastAutoBackup holds the time of the last successful automatic backup
+const settingsKeyLastAutoBackup = "auto_backup_last"
+
+// Files of an automatic backup
+const (
+	autoBackupDatabaseFile = "netvision.db"      // Snapshot of the whole database
+	autoBackupConfigFile   = "configuration.enc" // Configuration backup encrypted with the master key
+	autoBackupSchemasDir   = "schemas"           // Schema background images
+)
+
+// Reasons an automatic backup was made
+const (
+	AutoBackupScheduled  = "scheduled"
+	AutoBackupManual     = "manual"
+	AutoBackupPreRestore = "pre-restore"
+)
+
+// Restore modes of RestoreAutomaticBackup
+const (
+	RestoreModeConfiguration = "configuration" // Replace the configuration, keep history
+	RestoreModeDatabase      = "database"      // Replace the whole database
+)
+
+// autoBackupRetryDelay is the wait after a failed scheduled backup
+const autoBackupRetryDelay = time.Hour
+
+// AutoBackupInfo describes an automatic backup
+type AutoBackupInfo struct {
+	Name             string    `json:"name"`
+	Created          time.Time `json:"created"`
+	Reason           string    `json:"reason"`
+	AppVersion       string    `json:"app_version"`
+	Size             int64     `json:"size"`
+	HasDatabase      bool      `json:"has_database"`
+	HasConfiguration bool      `json:"has_configuration"`
+	Problem          string    `json:"problem,omitempty"` // Set if the backup cannot be used
+}
+
+// AutoBackupList is the result of ListAutomaticBackups
+type AutoBackupList struct {
+	Dir     string           `json:"dir"`
+	Backups []AutoBackupInfo `json:"backups"`
+}
+
+// autoBackupDir returns the directory automatic backups are written to
+func (a *App) autoBackupDir(settings AppSettings) string {
+	if settings.AutoBackupDir != "" {
+		return settings.AutoBackupDir
+	}
+	return filepath.Join(a.cfg.DataDir, "backups")
+}
+
+// autoBackupPolicy returns the rotation policy of the settings
+func autoBackupPolicy(settings AppSettings) backup.Policy {
+	return backup.Policy{
+		KeepLast:   settings.AutoBackupKeepLast,
+		KeepDaily:  settings.AutoBackupKeepDaily,
+		KeepWeekly: settings.AutoBackupKeepWeekly,
+	}
+}
+
+// ListAutomaticBackups returns the automatic backups, newest first
+func (a *App) ListAutomaticBackups() (*AutoBackupList, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.cfg == nil {
+		return nil, fmt.Errorf("config not initialized")
+	}
+
+	settings := a.appSettings()
+	dir := a.autoBackupDir(settings)
+	backups, err := backup.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &AutoBackupList{Dir: dir, Backups: make([]AutoBackupInfo, 0, len(backups))}
+	for i := range backups {
+		list.Backups = append(list.Backups, autoBackupInfo(&backups[i]))
+	}
+	return list, nil
+}
+
+func autoBackupInfo(b *backup.Backup) AutoBackupInfo {
+	info := AutoBackupInfo{
+		Name:             b.Name,
+		Created:          b.Created(),
+		Size:             b.Size,
+		HasDatabase:      b.HasFile(autoBackupDatabaseFile),
+		HasConfiguration: b.HasFile(autoBackupConfigFile),
+		Problem:          b.Problem,
+	}
+	if b.Manifest != nil {
+		info.Reason = b.Manifest.Reason
+		info.AppVersion = b.Manifest.AppVersion
+	}
+	return info
+}
+
+// RunAutomaticBackup makes an automatic backup now and applies the rotation
+func (a *App) RunAutomaticBackup() (_ *AutoBackupInfo, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	// Backups are sealed with the master key
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	rec := a.beginAudit("backup.run", models.AuditTarget{Type: "system"}, nil)
+	defer func() { rec.finish(err) }()
+
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	b, err := a.createAutomaticBackup(AutoBackupManual)
+	if err != nil {
+		return nil, err
+	}
+	rec.detail("backup", b.Name)
+
+	info := autoBackupInfo(b)
+	return &info, nil
+}
+
+// VerifyAutomaticBackup checks the checksums of a backup and the integrity of its
+// database snapshot
+func (a *App) VerifyAutomaticBackup(name string) error {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.cfg == nil {
+		return fmt.Errorf("config not initialized")
+	}
+
+	settings := a.appSettings()
+	b, err := backup.Find(a.autoBackupDir(settings), name)
+	if err != nil {
+		return err
+	}
+	return verifyAutomaticBackup(b)
+}
+
+// verifyAutomaticBackup checks the checksums of a backup and the integrity of its
+// database snapshot
+func verifyAutomaticBackup(b *backup.Backup) error {
+	if b.Problem != "" {
+		return fmt.Errorf("backup %s is unusable: %s", b.Name, b.Problem)
+	}
+	if err := backup.Verify(b.Path); err != nil {
+		return fmt.Errorf("backup %s is damaged: %w", b.Name, err)
+	}
+	if b.HasFile(autoBackupDatabaseFile) {
+		if err := checkDatabaseSnapshot(filepath.Join(b.Path, autoBackupDatabaseFile)); err != nil {
+			return fmt.Errorf("backup %s: %w", b.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkDatabaseSnapshot runs the integrity check on a database snapshot and checks
+// that this version can open it and its secrets were encrypted with the current
+// master key
+func checkDatabaseSnapshot(path string) error {
+	snapshot, err := database.OpenSnapshot(path)
+	if err != nil {
+		return err
+	}
+	defer snapshot.Close()
+
+	if err := snapshot.CheckIntegrity(); err != nil {
+		return err
+	}
+	version, err := snapshot.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > database.LatestSchemaVersion() {
+		return fmt.Errorf("%w (schema version %d)", database.ErrSchemaTooNew, version)
+	}
+	err = database.CheckSecrets(snapshot.DB(), append([]string{settingsKeyKeyCheck}, secretSettingKeys...), func(value string) error {
+		_, err := encryption.Decrypt(value)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("snapshot secrets cannot be decrypted with the current master key: %w", err)
+	}
+	return nil
+}
+
+// RestoreAutomaticBackup restores an automatic backup after confirmation. Mode
+// "configuration" replaces the configuration and keeps the history, "database"
+// replaces the whole database with the snapshot. The current state is backed up
+// first, so a restore can be undone.
+func (a *App) RestoreAutomaticBackup(name, mode string) (restored bool, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return false, err
+	}
+	if a.db == nil {
+		return false, fmt.Errorf("database not initialized")
+	}
+	if mode != RestoreModeConfiguration && mode != RestoreModeDatabase {
+		return false, fmt.Errorf("invalid restore mode: %s", mode)
+	}
+
+	settings := a.appSettings()
+	b, err := backup.Find(a.autoBackupDir(settings), name)
+	if err != nil {
+		return false, err
+	}
+	if err := verifyAutomaticBackup(b); err != nil {
+		return false, err
+	}
+
+	var restoreBackup *BackupData
+	message := "Конфигурация будет заменена данными из резервной копии, история событий сохранится."
+	if mode == RestoreModeDatabase {
+		if !b.HasFile(autoBackupDatabaseFile) {
+			return false, fmt.Errorf("backup %s has no database snapshot", b.Name)
+		}
+		message = "Вся база данных, включая историю и журнал аудита, будет заменена снимком из резервной копии."
+	} else {
+		if restoreBackup, err = readAutomaticConfiguration(b); err != nil {
+			return false, err
+		}
+	}
+
+	// Confirm restore
+	result, err := runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+		Type:          runtime.QuestionDialog,
+		Title:         "Восстановление из резервной копии",
+		Message:       fmt.Sprintf("%s\n\nРезервная копия: %s\n\nПродолжить?", message, b.Created().Local().Format("02.01.2006 15:04:05")),
+		Buttons:       []string{"Да", "Нет"},
+		DefaultButton: "Нет",
+	})
+	if err != nil || result != "Да" {
+		return false, nil
+	}
+
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	// After a database restore the entry is appended to the restored audit log
+	rec := a.beginAudit("backup.restore", models.AuditTarget{Type: "system"}, nil).
+		detail("backup", b.Name).
+		detail("mode", mode)
+	defer func() { rec.finish(err) }()
+
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	safety, err := a.createAutomaticBackup(AutoBackupPreRestore)
+	if err != nil {
+		return false, fmt.Errorf("failed to back up current data before restore: %w", err)
+	}
+	rec.detail("pre_restore_backup", safety.Name)
+
+	if mode == RestoreModeDatabase {
+		err = a.restoreDatabaseSnapshot(filepath.Join(b.Path, autoBackupDatabaseFile))
+	} else {
+		err = a.replaceConfiguration(restoreBackup)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := a.restoreSchemaImages(filepath.Join(b.Path, autoBackupSchemasDir)); err != nil {
+		log.Printf("Failed to restore schema images: %v", err)
+	}
+
+	log.Printf("Restored %s from automatic backup %s", mode, b.Name)
+	return true, nil
+}
+
+// SelectAutoBackupDirectory opens a dialog to choose the automatic backup directory
+func (a *App) SelectAutoBackupDirectory() (string, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return "", err
+	}
+
+	settings := a.appSettings()
+	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "Папка автоматических резервных копий",
+		DefaultDirectory:     a.autoBackupDir(settings),
+		CanCreateDirectories: true,
+	})
+}
+
+// createAutomaticBackup writes a backup with a database snapshot, the encrypted
+// configuration and the schema images, then applies the rotation, except before a
+// restore. The caller must hold backupMu.
+func (a *App) createAutomaticBackup(reason string) (*backup.Backup, error) {
+	if !encryption.IsInitialized() {
+		return nil, fmt.Errorf("master key is locked")
+	}
+
+	settings := a.appSettings()
+	dir := a.autoBackupDir(settings)
+
+	b, err := backup.Create(dir, reason, "1.1.0", func(tmp string) error {
+		snapshot := filepath.Join(tmp, autoBackupDatabaseFile)
+		if err := a.db.Snapshot(snapshot); err != nil {
+			return err
+		}
+		if err := checkDatabaseSnapshot(snapshot); err != nil {
+			return err
+		}
+
+		// The configuration contains decrypted secrets, so it is stored encrypted
+		// with the master key like the snapshot
+		dataJSON, err := a.marshalBackup(false)
+		if err != nil {
+			return err
+		}
+		sealed, err := encryption.Encrypt(string(dataJSON))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt configuration: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(tmp, autoBackupConfigFile), []byte(sealed), 0600); err != nil {
+			return fmt.Errorf("failed to write configuration: %w", err)
+		}
+
+		return copyDir(filepath.Join(a.cfg.DataDir, autoBackupSchemasDir), filepath.Join(tmp, autoBackupSchemasDir))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	repo := database.NewSettingsRepository(a.db.DB())
+	if err := repo.Set(settingsKeyLastAutoBackup, b.Created().Format(time.RFC3339)); err != nil {
+		log.Printf("Failed to save automatic backup state: %v", err)
+	}
+
+	// Rotation could remove the backup about to be restored
+	if reason == AutoBackupPreRestore {
+		log.Printf("Automatic backup %s created", b.Name)
+		return b, nil
+	}
+	removed, err := backup.Rotate(dir, autoBackupPolicy(settings))
+	if err != nil {
+		log.Printf("Automatic backup rotation failed: %v", err)
+	}
+	log.Printf("Automatic backup %s created, %d old backups removed", b.Name, len(removed))
+	return b, nil
+}
+
+// readAutomaticConfiguration decrypts and parses the configuration of a backup
+func readAutomaticConfiguration(b *backup.Backup) (*BackupData, error) {
+	if !b.HasFile(autoBackupConfigFile) {
+		return nil, fmt.Errorf("backup %s has no configuration", b.Name)
+	}
+	sealed, err := os.ReadFile(filepath.Join(b.Path, autoBackupConfigFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	dataJSON, err := encryption.Decrypt(string(sealed))
+	if err != nil {
+		return nil, fmt.Errorf("configuration cannot be decrypted with the current master key: %w", err)
+	}
+	return parseBackup([]byte(dataJSON))
+}
+
+// restoreDatabaseSnapshot replaces the database file with a snapshot and reopens it
+func (a *App) restoreDatabaseSnapshot(snapshot string) error {
+	dbPath := filepath.Join(a.cfg.DataDir, "netvision.db")
+
+	// Copy next to the database first, so the swap is a rename
+	tmp := dbPath + ".restore"
+	if err := copyFile(snapshot, tmp); err != nil {
+		return fmt.Errorf("failed to copy snapshot: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	// Stop monitoring
+	if a.monitor != nil {
+		a.monitor.Stop()
+	}
+
+	// Close database
+	a.db.Close()
+
+	// The journal of the old database must not be applied to the snapshot
+	os.Remove(dbPath + "-wal")
+	os.Remove(dbPath + "-shm")
+	replaceErr := os.Rename(tmp, dbPath)
+
+	// Reinitialize database, the old one if it could not be replaced
+	db, err := database.Initialize(a.cfg.DataDir)
+	if err != nil {
+		return fmt.Errorf("failed to reinitialize database: %w", err)
+	}
+	a.db = db
+
+	// Fix port types of older snapshots
+	a.db.FixExistingPortTypes()
+
+	// Restart monitoring
+	a.initMonitoring()
+	a.monitor.Start()
+
+	if replaceErr != nil {
+		return fmt.Errorf("failed to replace database: %w", replaceErr)
+	}
+	return nil
+}
+
+// replaceConfiguration replaces the configuration with a backup while monitoring
+// is stopped
+func (a *App) replaceConfiguration(data *BackupData) error {
+	// Stop monitoring
+	if a.monitor != nil {
+		a.monitor.Stop()
+	}
+
+	// Import data
+	err := a.db.WithTx(func(tx *sql.Tx) error {
+		return importBackupData(tx, data)
+	})
+	if err != nil {
+		// Restart monitoring on error
+		a.monitor.Start()
+		return fmt.Errorf("failed to import data: %w", err)
+	}
+
+	// Fix port types after import
+	a.db.FixExistingPortTypes()
+
+	// Restart monitoring
+	a.initMonitoring()
+	a.monitor.Start()
+
+	return nil
+}
+
+// restoreSchemaImages copies schema images of a backup back into the data
+// directory. Images added since the backup are kept.
+func (a *App) restoreSchemaImages(src string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	return copyDir(src, filepath.Join(a.cfg.DataDir, autoBackupSchemasDir))
+}
+
+// startAutoBackupScheduler makes automatic backups at the configured interval
+func (a *App) startAutoBackupScheduler() {
+	a.autoBackupStop = make(chan struct{})
+	stop := a.autoBackupStop
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+
+		var retryAt time.Time
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if now.Before(retryAt) {
+					continue
+				}
+				if err := a.runScheduledBackup(now); err != nil {
+					retryAt = now.Add(autoBackupRetryDelay)
+				}
+			}
+		}
+	}()
+}
+
+// runScheduledBackup makes a backup once the interval since the last one has passed
+func (a *App) runScheduledBackup(now time.Time) error {
+	settings := a.appSettings()
+	if !settings.AutoBackupEnabled || a.db == nil {
+		return nil
+	}
+
+	interval := time.Duration(max(settings.AutoBackupInterval, 1)) * time.Hour
+	last, _ := database.NewSettingsRepository(a.db.DB()).Get(settingsKeyLastAutoBackup)
+	if t, err := time.Parse(time.RFC3339, last); err == nil && now.Sub(t) < interval {
+		return nil
+	}
+
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	a.backupMu.Lock()
+	defer a.backupMu.Unlock()
+
+	if _, err := a.createAutomaticBackup(AutoBackupScheduled); err != nil {
+		log.Printf("Scheduled backup failed: %v", err)
+		message := fmt.Sprintf("Automatic backup failed: %v", err)
+		if err := a.createEvent(nil, models.EventTypeBackupFailed, models.EventLevelError, message); err != nil {
+			log.Printf("Failed to create backup event: %v", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// stopAutoBackupScheduler stops the automatic backup scheduler
+func (a *App) stopAutoBackupScheduler() {
+	if a.autoBackupStop != nil {
+		close(a.autoBackupStop)
+		a.autoBackupStop = nil
+	}
+}
+
+// copyDir copies the files of a directory tree. A missing source is not an error.
+func copyDir(src, dst string) error {
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// copyFile copies a file, replacing the destination
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -4,31 +4,58 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
 	"time"
+	"unicode/utf8"
 
 	"netvisionmonitor/internal/database"
 	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/models"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+const (
+	// backupEnvelopeKind identifies configuration backups in encrypted envelopes
+	backupEnvelopeKind  = "configuration-backup"
+	encryptedBackupExt  = ".nvbak"
+	minBackupPassphrase = 8
+)
+
 // BackupData represents the exported data structure
 type BackupData struct {
-	Version     string    `json:"version"`
+	Version     int       `json:"version"` // backupFormatVersion of the application that wrote the file
 	AppVersion  string    `json:"app_version"`
 	ExportDate  time.Time `json:"export_date"`
 	Description string    `json:"description"`
 
 	// Configuration data
-	Credentials []CredentialExport `json:"credentials"`
-	Devices     []DeviceExport     `json:"devices"`
-	Switches    []SwitchExport     `json:"switches"`
-	SwitchPorts []SwitchPortExport `json:"switch_ports"`
-	Cameras     []CameraExport     `json:"cameras"`
-	Servers     []ServerExport     `json:"servers"`
-	Schemas     []SchemaExport     `json:"schemas"`
-	SchemaItems []SchemaItemExport `json:"schema_items"`
-	Settings    map[string]string  `json:"settings"`
+	Credentials         []CredentialExport         `json:"credentials"`
+	Sites               []SiteExport               `json:"sites"`
+	DeviceGroups        []DeviceGroupExport        `json:"device_groups"`
+	Devices             []DeviceExport             `json:"devices"`
+	Switches            []SwitchExport             `json:"switches"`
+	SwitchPorts         []SwitchPortExport         `json:"switch_ports"`
+	Cameras             []CameraExport             `json:"cameras"`
+	Servers             []ServerExport             `json:"servers"`
+	SNMPDevices         []SNMPDeviceExport         `json:"snmp_devices"`
+	NVRs                []NVRExport                `json:"nvrs"`
+	UPSDevices          []UPSExport                `json:"ups_devices"`
+	Schemas             []SchemaExport             `json:"schemas"`
+	SchemaItems         []SchemaItemExport         `json:"schema_items"`
+	AssetFields         []AssetFieldExport         `json:"asset_fields"`
+	Assets              []AssetExport              `json:"assets"`
+	ComplianceBaselines []ComplianceBaselineExport `json:"compliance_baselines"`
+	MaintenanceWindows  []MaintenanceWindowExport  `json:"maintenance_windows"`
+	OncallSchedules     []OncallScheduleExport     `json:"oncall_schedules"`
+	OncallOverrides     []OncallOverrideExport     `json:"oncall_overrides"`
+	EscalationPolicies  []EscalationPolicyExport   `json:"escalation_policies"`
+	Settings            map[string]string          `json:"settings"`
+
+	// Events, status history and incidents by table, only in backups exported
+	// with history
+	History map[string]*BackupTable `json:"history,omitempty"`
 }
 
 // Export structures (with decrypted sensitive data for portability)
@@ -40,30 +67,63 @@ type CredentialExport struct {
 	Password  string    `json:"password"`
 	Note      string    `json:"note"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SiteExport struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Address     string    `json:"address"`
+	Latitude    *float64  `json:"latitude,omitempty"`
+	Longitude   *float64  `json:"longitude,omitempty"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type DeviceGroupExport struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	ParentID    *int64    `json:"parent_id,omitempty"`
+	Description string    `json:"description"`
+	Settings    string    `json:"settings"` // JSON as stored
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type DeviceExport struct {
-	ID           int64  `json:"id"`
-	Name         string `json:"name"`
-	IPAddress    string `json:"ip_address"`
-	Type         string `json:"type"`
-	Manufacturer string `json:"manufacturer"`
-	Model        string `json:"model"`
-	CredentialID *int64 `json:"credential_id,omitempty"`
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	IPAddress    string     `json:"ip_address"`
+	Type         string     `json:"type"`
+	Manufacturer string     `json:"manufacturer"`
+	Model        string     `json:"model"`
+	CredentialID *int64     `json:"credential_id,omitempty"`
+	SiteID       *int64     `json:"site_id,omitempty"`
+	GroupID      *int64     `json:"group_id,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Status       string     `json:"status"`
+	LastCheck    *time.Time `json:"last_check,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 type SwitchExport struct {
-	DeviceID        int64  `json:"device_id"`
-	SNMPCommunity   string `json:"snmp_community"`
-	SNMPVersion     string `json:"snmp_version"`
-	PortCount       int    `json:"port_count"`
-	SFPPortCount    int    `json:"sfp_port_count"`
-	SNMPv3User      string `json:"snmpv3_user,omitempty"`
-	SNMPv3Security  string `json:"snmpv3_security,omitempty"`
-	SNMPv3AuthProto string `json:"snmpv3_auth_proto,omitempty"`
-	SNMPv3AuthPass  string `json:"snmpv3_auth_pass,omitempty"`
-	SNMPv3PrivProto string `json:"snmpv3_priv_proto,omitempty"`
-	SNMPv3PrivPass  string `json:"snmpv3_priv_pass,omitempty"`
+	DeviceID           int64   `json:"device_id"`
+	SNMPCommunity      string  `json:"snmp_community"`
+	SNMPWriteCommunity string  `json:"snmp_write_community,omitempty"`
+	SNMPVersion        string  `json:"snmp_version"`
+	PortCount          int     `json:"port_count"`
+	SFPPortCount       int     `json:"sfp_port_count"`
+	PoEBudgetW         float64 `json:"poe_budget_w,omitempty"`
+	SNMPv3User         string  `json:"snmpv3_user,omitempty"`
+	SNMPv3Security     string  `json:"snmpv3_security,omitempty"`
+	SNMPv3AuthProto    string  `json:"snmpv3_auth_proto,omitempty"`
+	SNMPv3AuthPass     string  `json:"snmpv3_auth_pass,omitempty"`
+	SNMPv3PrivProto    string  `json:"snmpv3_priv_proto,omitempty"`
+	SNMPv3PrivPass     string  `json:"snmpv3_priv_pass,omitempty"`
+	UplinkSwitchID     *int64  `json:"uplink_switch_id,omitempty"`
+	UplinkPortID       *int64  `json:"uplink_port_id,omitempty"`
 }
 
 type SwitchPortExport struct {
@@ -71,6 +131,11 @@ type SwitchPortExport struct {
 	SwitchID       int64  `json:"switch_id"`
 	PortNumber     int    `json:"port_number"`
 	Name           string `json:"name"`
+	Status         string `json:"status"`
+	Speed          string `json:"speed"`
+	Duplex         string `json:"duplex,omitempty"`
+	AdminStatus    string `json:"admin_status,omitempty"`
+	PoEStatus      string `json:"poe_status,omitempty"`
 	PortType       string `json:"port_type"`
 	LinkedCameraID *int64 `json:"linked_camera_id,omitempty"`
 	LinkedSwitchID *int64 `json:"linked_switch_id,omitempty"`
@@ -85,9 +150,38 @@ type CameraExport struct {
 }
 
 type ServerExport struct {
-	DeviceID int64  `json:"device_id"`
-	TCPPorts string `json:"tcp_ports"`
-	UseSNMP  bool   `json:"use_snmp"`
+	DeviceID       int64  `json:"device_id"`
+	TCPPorts       string `json:"tcp_ports"`
+	UseSNMP        bool   `json:"use_snmp"`
+	UplinkSwitchID *int64 `json:"uplink_switch_id,omitempty"`
+	UplinkPortID   *int64 `json:"uplink_port_id,omitempty"`
+}
+
+type SNMPDeviceExport struct {
+	DeviceID        int64  `json:"device_id"`
+	SNMPCommunity   string `json:"snmp_community"`
+	SNMPVersion     string `json:"snmp_version"`
+	SNMPv3User      string `json:"snmpv3_user,omitempty"`
+	SNMPv3Security  string `json:"snmpv3_security,omitempty"`
+	SNMPv3AuthProto string `json:"snmpv3_auth_proto,omitempty"`
+	SNMPv3AuthPass  string `json:"snmpv3_auth_pass,omitempty"`
+	SNMPv3PrivProto string `json:"snmpv3_priv_proto,omitempty"`
+	SNMPv3PrivPass  string `json:"snmpv3_priv_pass,omitempty"`
+	UplinkSwitchID  *int64 `json:"uplink_switch_id,omitempty"`
+	UplinkPortID    *int64 `json:"uplink_port_id,omitempty"`
+}
+
+type NVRExport struct {
+	DeviceID  int64 `json:"device_id"`
+	CheckHTTP bool  `json:"check_http"`
+	HTTPPort  int   `json:"http_port"`
+}
+
+type UPSExport struct {
+	DeviceID   int64  `json:"device_id"`
+	Protocol   string `json:"protocol"`
+	NUTPort    int    `json:"nut_port"`
+	NUTUPSName string `json:"nut_ups_name"`
 }
 
 type SchemaExport struct {
@@ -107,18 +201,103 @@ type SchemaItemExport struct {
 	Height   float64 `json:"height"`
 }
 
-// ExportConfiguration exports all configuration data to a JSON file
-func (a *App) ExportConfiguration() (string, error) {
+type AssetFieldExport struct {
+	ID         int64     `json:"id"`
+	Key        string    `json:"key"`
+	Label      string    `json:"label"`
+	DeviceType string    `json:"device_type"`
+	FieldType  string    `json:"field_type"`
+	Position   int       `json:"position"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AssetExport struct {
+	DeviceID       int64             `json:"device_id"`
+	SerialNumber   string            `json:"serial_number"`
+	MACAddress     string            `json:"mac_address"`
+	InstallDate    string            `json:"install_date"`
+	WarrantyEnd    string            `json:"warranty_end"`
+	Room           string            `json:"room"`
+	Rack           string            `json:"rack"`
+	RackUnit       string            `json:"rack_unit"`
+	Notes          string            `json:"notes"`
+	Custom         map[string]string `json:"custom,omitempty"`
+	WarrantyNotice string            `json:"warranty_notice,omitempty"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+type ComplianceBaselineExport struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	DeviceType string    `json:"device_type"`
+	Enabled    bool      `json:"enabled"`
+	Rules      string    `json:"rules"` // JSON as stored
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type MaintenanceWindowExport struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	DeviceID    *int64    `json:"device_id,omitempty"`
+	DeviceType  string    `json:"device_type"`
+	StartAt     time.Time `json:"start_at"`
+	EndAt       time.Time `json:"end_at"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type OncallScheduleExport struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	RotationStart time.Time `json:"rotation_start"`
+	Members       string    `json:"members"` // JSON as stored
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type OncallOverrideExport struct {
+	ID         int64     `json:"id"`
+	ScheduleID int64     `json:"schedule_id"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	StartAt    time.Time `json:"start_at"`
+	EndAt      time.Time `json:"end_at"`
+	Reason     string    `json:"reason"`
+}
+
+type EscalationPolicyExport struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	DeviceID   *int64    `json:"device_id,omitempty"`
+	DeviceType string    `json:"device_type"`
+	Enabled    bool      `json:"enabled"`
+	Steps      string    `json:"steps"` // JSON as stored
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ExportConfiguration exports all configuration data to a file encrypted with the
+// passphrase. The backup contains decrypted credentials, so it is never written in
+// plain text by this method. With includeHistory, events, status history and
+// incidents are exported too.
+func (a *App) ExportConfiguration(passphrase string, includeHistory bool) (_ string, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return "", err
+	}
 	if a.db == nil {
 		return "", fmt.Errorf("database not initialized")
 	}
+	if utf8.RuneCountInString(passphrase) < minBackupPassphrase {
+		return "", fmt.Errorf("passphrase must be at least %d characters", minBackupPassphrase)
+	}
 
 	// Ask user for save location
 	savePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
 		Title:           "Экспорт конфигурации",
-		DefaultFilename: fmt.Sprintf("netvision_config_%s.json", time.Now().Format("2006-01-02")),
+		DefaultFilename: fmt.Sprintf("netvision_config_%s%s", time.Now().Format("2006-01-02"), encryptedBackupExt),
 		Filters: []runtime.FileFilter{
-			{DisplayName: "JSON Files", Pattern: "*.json"},
+			{DisplayName: "Encrypted Backups", Pattern: "*" + encryptedBackupExt},
 		},
 	})
 	if err != nil {
@@ -128,107 +307,215 @@ func (a *App) ExportConfiguration() (string, error) {
 		return "", nil // User cancelled
 	}
 
-	// Collect all data
-	backup, err := a.collectBackupData()
+	rec := a.beginAudit("config.export", models.AuditTarget{Type: "system"}, nil).
+		detail("file", savePath).
+		detail("encrypted", true).
+		detail("history", includeHistory)
+	defer func() { rec.finish(err) }()
+
+	dataJSON, err := a.marshalBackup(includeHistory)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := encryption.Seal(dataJSON, backupEnvelopeKind, passphrase)
 	if err != nil {
-		return "", fmt.Errorf("failed to collect backup data: %w", err)
+		return "", fmt.Errorf("failed to encrypt backup: %w", err)
 	}
 
-	// Write JSON file
-	dataJSON, err := json.MarshalIndent(backup, "", "  ")
+	if err := os.WriteFile(savePath, sealed, 0600); err != nil {
+		return "", fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	return savePath, nil
+}
+
+// ExportConfigurationPlain exports all configuration data to an unencrypted JSON
+// file after a warning that passwords and SNMP communities are readable in it
+func (a *App) ExportConfigurationPlain(includeHistory bool) (_ string, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return "", err
+	}
+	if a.db == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+
+	result, err := runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+		Type:  runtime.WarningDialog,
+		Title: "Экспорт без шифрования",
+		Message: "Файл будет содержать пароли, SNMP community и другие секреты в открытом виде. " +
+			"Любой, кто получит к нему доступ, сможет их прочитать.\n\nПродолжить?",
+		Buttons:       []string{"Да", "Нет"},
+		DefaultButton: "Нет",
+	})
+	if err != nil || result != "Да" {
+		return "", nil
+	}
+
+	// Ask user for save location
+	savePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Экспорт конфигурации",
+		DefaultFilename: fmt.Sprintf("netvision_config_%s.json", time.Now().Format("2006-01-02")),
+		Filters: []runtime.FileFilter{
+			{DisplayName: "JSON Files", Pattern: "*.json"},
+		},
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal backup data: %w", err)
+		return "", err
+	}
+	if savePath == "" {
+		return "", nil // User cancelled
 	}
 
-	if err := os.WriteFile(savePath, dataJSON, 0644); err != nil {
+	rec := a.beginAudit("config.export", models.AuditTarget{Type: "system"}, nil).
+		detail("file", savePath).
+		detail("encrypted", false).
+		detail("history", includeHistory)
+	defer func() { rec.finish(err) }()
+
+	dataJSON, err := a.marshalBackup(includeHistory)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(savePath, dataJSON, 0600); err != nil {
 		return "", fmt.Errorf("failed to write JSON file: %w", err)
 	}
 
 	return savePath, nil
 }
 
+// marshalBackup collects all configuration data as JSON
+func (a *App) marshalBackup(includeHistory bool) ([]byte, error) {
+	backup, err := collectBackupData(a.db.DB(), includeHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect backup data: %w", err)
+	}
+	dataJSON, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup data: %w", err)
+	}
+	return dataJSON, nil
+}
+
 // collectBackupData gathers all configuration data from the database
-func (a *App) collectBackupData() (*BackupData, error) {
+func collectBackupData(q database.Querier, includeHistory bool) (*BackupData, error) {
 	backup := &BackupData{
-		Version:     "1.0",
+		Version:     backupFormatVersion,
 		AppVersion:  "1.1.0",
 		ExportDate:  time.Now(),
 		Description: "NetVisionMonitor configuration backup",
 		Settings:    make(map[string]string),
 	}
 
-	db := a.db.DB()
+	var err error
 
 	// Export credentials with decrypted passwords
-	credRepo := database.NewCredentialRepository(db)
-	creds, err := a.getAllCredentialsWithPasswords(credRepo)
-	if err != nil {
+	if backup.Credentials, err = exportCredentials(q); err != nil {
 		return nil, fmt.Errorf("failed to export credentials: %w", err)
 	}
-	backup.Credentials = creds
 
-	// Export devices
-	devices, err := a.exportDevices()
-	if err != nil {
+	// Export sites and device groups
+	if backup.Sites, err = exportSites(q); err != nil {
+		return nil, fmt.Errorf("failed to export sites: %w", err)
+	}
+	if backup.DeviceGroups, err = exportDeviceGroups(q); err != nil {
+		return nil, fmt.Errorf("failed to export device groups: %w", err)
+	}
+
+	// Export devices with tags
+	if backup.Devices, err = exportDevices(q); err != nil {
 		return nil, fmt.Errorf("failed to export devices: %w", err)
 	}
-	backup.Devices = devices
 
 	// Export switches with decrypted SNMP data
-	switches, err := a.exportSwitches()
-	if err != nil {
+	if backup.Switches, err = exportSwitches(q); err != nil {
 		return nil, fmt.Errorf("failed to export switches: %w", err)
 	}
-	backup.Switches = switches
 
 	// Export switch ports
-	ports, err := a.exportSwitchPorts()
-	if err != nil {
+	if backup.SwitchPorts, err = exportSwitchPorts(q); err != nil {
 		return nil, fmt.Errorf("failed to export switch ports: %w", err)
 	}
-	backup.SwitchPorts = ports
 
 	// Export cameras with decrypted URLs
-	cameras, err := a.exportCameras()
-	if err != nil {
+	if backup.Cameras, err = exportCameras(q); err != nil {
 		return nil, fmt.Errorf("failed to export cameras: %w", err)
 	}
-	backup.Cameras = cameras
 
 	// Export servers
-	servers, err := a.exportServers()
-	if err != nil {
+	if backup.Servers, err = exportServers(q); err != nil {
 		return nil, fmt.Errorf("failed to export servers: %w", err)
 	}
-	backup.Servers = servers
+
+	// Export SNMP settings of routers, access points, UPS units, NVRs and PDUs
+	if backup.SNMPDevices, err = exportSNMPDevices(q); err != nil {
+		return nil, fmt.Errorf("failed to export SNMP devices: %w", err)
+	}
+	if backup.NVRs, err = exportNVRs(q); err != nil {
+		return nil, fmt.Errorf("failed to export NVRs: %w", err)
+	}
+	if backup.UPSDevices, err = exportUPSDevices(q); err != nil {
+		return nil, fmt.Errorf("failed to export UPS units: %w", err)
+	}
 
 	// Export schemas
-	schemas, err := a.exportSchemas()
-	if err != nil {
+	if backup.Schemas, err = exportSchemas(q); err != nil {
 		return nil, fmt.Errorf("failed to export schemas: %w", err)
 	}
-	backup.Schemas = schemas
 
 	// Export schema items
-	schemaItems, err := a.exportSchemaItems()
-	if err != nil {
+	if backup.SchemaItems, err = exportSchemaItems(q); err != nil {
 		return nil, fmt.Errorf("failed to export schema items: %w", err)
 	}
-	backup.SchemaItems = schemaItems
 
-	// Export settings
-	settingsRepo := database.NewSettingsRepository(db)
-	settings, err := settingsRepo.GetAll()
-	if err == nil {
-		backup.Settings = settings
+	// Export asset data with custom fields
+	if backup.AssetFields, backup.Assets, err = exportAssets(q); err != nil {
+		return nil, fmt.Errorf("failed to export asset data: %w", err)
+	}
+
+	// Export compliance baselines, maintenance windows and escalation
+	if backup.ComplianceBaselines, err = exportComplianceBaselines(q); err != nil {
+		return nil, fmt.Errorf("failed to export compliance baselines: %w", err)
+	}
+	if backup.MaintenanceWindows, err = exportMaintenanceWindows(q); err != nil {
+		return nil, fmt.Errorf("failed to export maintenance windows: %w", err)
+	}
+	if backup.OncallSchedules, backup.OncallOverrides, err = exportOncall(q); err != nil {
+		return nil, fmt.Errorf("failed to export on-call schedules: %w", err)
+	}
+	if backup.EscalationPolicies, err = exportEscalationPolicies(q); err != nil {
+		return nil, fmt.Errorf("failed to export escalation policies: %w", err)
+	}
+
+	// Export settings, except those tied to this database
+	settings, err := database.NewSettingsRepository(q).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export settings: %w", err)
+	}
+	for key, value := range settings {
+		if !isPortableSetting(key) {
+			continue
+		}
+		// Secrets are exported in clear like credentials, the key may differ on import
+		if slices.Contains(secretSettingKeys, key) {
+			if value, err = encryption.DecryptIfNotEmpty(value); err != nil {
+				return nil, fmt.Errorf("failed to decrypt setting %s: %w", key, err)
+			}
+		}
+		backup.Settings[key] = value
+	}
+
+	if includeHistory {
+		if backup.History, err = exportHistory(q); err != nil {
+			return nil, fmt.Errorf("failed to export history: %w", err)
+		}
 	}
 
 	return backup, nil
 }
 
-func (a *App) getAllCredentialsWithPasswords(repo *database.CredentialRepository) ([]CredentialExport, error) {
-	rows, err := a.db.DB().Query(`
-		SELECT id, name, type, username, password, note, created_at
+func exportCredentials(q database.Querier) ([]CredentialExport, error) {
+	rows, err := q.Query(`
+		SELECT id, name, type, username, password, COALESCE(note, ''), created_at, updated_at
 		FROM credentials ORDER BY id`)
 	if err != nil {
 		return nil, err
@@ -239,20 +526,67 @@ func (a *App) getAllCredentialsWithPasswords(repo *database.CredentialRepository
 	for rows.Next() {
 		var c CredentialExport
 		var encUser, encPass string
-		err := rows.Scan(&c.ID, &c.Name, &c.Type, &encUser, &encPass, &c.Note, &c.CreatedAt)
+		err := rows.Scan(&c.ID, &c.Name, &c.Type, &encUser, &encPass, &c.Note, &c.CreatedAt, &c.UpdatedAt)
 		if err != nil {
-			continue
+			return nil, err
+		}
+		if c.Username, err = encryption.DecryptIfNotEmpty(encUser); err != nil {
+			return nil, fmt.Errorf("failed to decrypt credential %s: %w", c.Name, err)
+		}
+		if c.Password, err = encryption.DecryptIfNotEmpty(encPass); err != nil {
+			return nil, fmt.Errorf("failed to decrypt credential %s: %w", c.Name, err)
 		}
-		c.Username, _ = encryption.DecryptIfNotEmpty(encUser)
-		c.Password, _ = encryption.DecryptIfNotEmpty(encPass)
 		creds = append(creds, c)
 	}
-	return creds, nil
+	return creds, rows.Err()
+}
+
+func exportSites(q database.Querier) ([]SiteExport, error) {
+	rows, err := q.Query(`
+		SELECT id, name, COALESCE(address, ''), latitude, longitude, COALESCE(description, ''), created_at, updated_at
+		FROM sites ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sites []SiteExport
+	for rows.Next() {
+		var s SiteExport
+		err := rows.Scan(&s.ID, &s.Name, &s.Address, &s.Latitude, &s.Longitude, &s.Description, &s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		sites = append(sites, s)
+	}
+	return sites, rows.Err()
+}
+
+func exportDeviceGroups(q database.Querier) ([]DeviceGroupExport, error) {
+	rows, err := q.Query(`
+		SELECT id, name, parent_id, COALESCE(description, ''), settings, created_at, updated_at
+		FROM device_groups ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []DeviceGroupExport
+	for rows.Next() {
+		var g DeviceGroupExport
+		err := rows.Scan(&g.ID, &g.Name, &g.ParentID, &g.Description, &g.Settings, &g.CreatedAt, &g.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
 }
 
-func (a *App) exportDevices() ([]DeviceExport, error) {
-	rows, err := a.db.DB().Query(`
-		SELECT id, name, ip_address, type, COALESCE(manufacturer, ''), COALESCE(model, ''), credential_id
+func exportDevices(q database.Querier) ([]DeviceExport, error) {
+	rows, err := q.Query(`
+		SELECT id, name, ip_address, type, COALESCE(manufacturer, ''), COALESCE(model, ''), credential_id,
+			site_id, group_id, COALESCE(status, 'unknown'), last_check, created_at, updated_at
 		FROM devices ORDER BY id`)
 	if err != nil {
 		return nil, err
@@ -260,25 +594,49 @@ func (a *App) exportDevices() ([]DeviceExport, error) {
 	defer rows.Close()
 
 	var devices []DeviceExport
+	index := make(map[int64]int)
 	for rows.Next() {
 		var d DeviceExport
-		var credID *int64
-		err := rows.Scan(&d.ID, &d.Name, &d.IPAddress, &d.Type, &d.Manufacturer, &d.Model, &credID)
+		err := rows.Scan(&d.ID, &d.Name, &d.IPAddress, &d.Type, &d.Manufacturer, &d.Model, &d.CredentialID,
+			&d.SiteID, &d.GroupID, &d.Status, &d.LastCheck, &d.CreatedAt, &d.UpdatedAt)
 		if err != nil {
-			continue
+			return nil, err
 		}
-		d.CredentialID = credID
+		index[d.ID] = len(devices)
 		devices = append(devices, d)
 	}
-	return devices, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tagRows, err := q.Query("SELECT device_id, tag FROM device_tags ORDER BY device_id, tag")
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var deviceID int64
+		var tag string
+		if err := tagRows.Scan(&deviceID, &tag); err != nil {
+			return nil, err
+		}
+		if i, ok := index[deviceID]; ok {
+			devices[i].Tags = append(devices[i].Tags, tag)
+		}
+	}
+	return devices, tagRows.Err()
 }
 
-func (a *App) exportSwitches() ([]SwitchExport, error) {
-	rows, err := a.db.DB().Query(`
-		SELECT device_id, snmp_community, snmp_version, port_count, COALESCE(sfp_port_count, 0),
+func exportSwitches(q database.Querier) ([]SwitchExport, error) {
+	rows, err := q.Query(`
+		SELECT device_id, COALESCE(snmp_community, ''), COALESCE(snmp_write_community, ''), snmp_version,
+			port_count, COALESCE(sfp_port_count, 0),
 			COALESCE(snmpv3_user, ''), COALESCE(snmpv3_security, ''),
 			COALESCE(snmpv3_auth_proto, ''), COALESCE(snmpv3_auth_pass, ''),
-			COALESCE(snmpv3_priv_proto, ''), COALESCE(snmpv3_priv_pass, '')
+			COALESCE(snmpv3_priv_proto, ''), COALESCE(snmpv3_priv_pass, ''),
+			uplink_switch_id, uplink_port_id, COALESCE(poe_budget_w, 0)
 		FROM switches ORDER BY device_id`)
 	if err != nil {
 		return nil, err
@@ -288,23 +646,36 @@ func (a *App) exportSwitches() ([]SwitchExport, error) {
 	var switches []SwitchExport
 	for rows.Next() {
 		var s SwitchExport
-		var encCommunity, encAuthPass, encPrivPass string
-		err := rows.Scan(&s.DeviceID, &encCommunity, &s.SNMPVersion, &s.PortCount, &s.SFPPortCount,
-			&s.SNMPv3User, &s.SNMPv3Security, &s.SNMPv3AuthProto, &encAuthPass, &s.SNMPv3PrivProto, &encPrivPass)
+		var encCommunity, encWriteCommunity, encAuthPass, encPrivPass string
+		err := rows.Scan(&s.DeviceID, &encCommunity, &encWriteCommunity, &s.SNMPVersion, &s.PortCount, &s.SFPPortCount,
+			&s.SNMPv3User, &s.SNMPv3Security, &s.SNMPv3AuthProto, &encAuthPass, &s.SNMPv3PrivProto, &encPrivPass,
+			&s.UplinkSwitchID, &s.UplinkPortID, &s.PoEBudgetW)
 		if err != nil {
-			continue
+			return nil, err
+		}
+		for _, secret := range []struct {
+			enc string
+			dst *string
+		}{
+			{encCommunity, &s.SNMPCommunity},
+			{encWriteCommunity, &s.SNMPWriteCommunity},
+			{encAuthPass, &s.SNMPv3AuthPass},
+			{encPrivPass, &s.SNMPv3PrivPass},
+		} {
+			if *secret.dst, err = encryption.DecryptIfNotEmpty(secret.enc); err != nil {
+				return nil, fmt.Errorf("failed to decrypt SNMP settings of switch %d: %w", s.DeviceID, err)
+			}
 		}
-		s.SNMPCommunity, _ = encryption.DecryptIfNotEmpty(encCommunity)
-		s.SNMPv3AuthPass, _ = encryption.DecryptIfNotEmpty(encAuthPass)
-		s.SNMPv3PrivPass, _ = encryption.DecryptIfNotEmpty(encPrivPass)
 		switches = append(switches, s)
 	}
-	return switches, nil
+	return switches, rows.Err()
 }
 
-func (a *App) exportSwitchPorts() ([]SwitchPortExport, error) {
-	rows, err := a.db.DB().Query(`
-		SELECT id, switch_id, port_number, name, COALESCE(port_type, 'copper'), linked_camera_id, linked_switch_id
+func exportSwitchPorts(q database.Querier) ([]SwitchPortExport, error) {
+	rows, err := q.Query(`
+		SELECT id, switch_id, port_number, COALESCE(name, ''), COALESCE(status, 'unknown'), COALESCE(speed, ''),
+			COALESCE(duplex, ''), COALESCE(admin_status, ''), COALESCE(poe_status, ''),
+			COALESCE(port_type, 'copper'), linked_camera_id, linked_switch_id
 		FROM switch_ports ORDER BY switch_id, port_number`)
 	if err != nil {
 		return nil, err
@@ -314,17 +685,18 @@ func (a *App) exportSwitchPorts() ([]SwitchPortExport, error) {
 	var ports []SwitchPortExport
 	for rows.Next() {
 		var p SwitchPortExport
-		err := rows.Scan(&p.ID, &p.SwitchID, &p.PortNumber, &p.Name, &p.PortType, &p.LinkedCameraID, &p.LinkedSwitchID)
+		err := rows.Scan(&p.ID, &p.SwitchID, &p.PortNumber, &p.Name, &p.Status, &p.Speed,
+			&p.Duplex, &p.AdminStatus, &p.PoEStatus, &p.PortType, &p.LinkedCameraID, &p.LinkedSwitchID)
 		if err != nil {
-			continue
+			return nil, err
 		}
 		ports = append(ports, p)
 	}
-	return ports, nil
+	return ports, rows.Err()
 }
 
-func (a *App) exportCameras() ([]CameraExport, error) {
-	rows, err := a.db.DB().Query(`
+func exportCameras(q database.Querier) ([]CameraExport, error) {
+	rows, err := q.Query(`
 		SELECT device_id, COALESCE(rtsp_url, ''), COALESCE(onvif_port, 80),
 			COALESCE(snapshot_url, ''), COALESCE(stream_type, 'jpeg')
 		FROM cameras ORDER BY device_id`)
@@ -339,17 +711,19 @@ func (a *App) exportCameras() ([]CameraExport, error) {
 		var encRTSP string
 		err := rows.Scan(&c.DeviceID, &encRTSP, &c.ONVIFPort, &c.SnapshotURL, &c.StreamType)
 		if err != nil {
-			continue
+			return nil, err
+		}
+		if c.RTSPURL, err = encryption.DecryptIfNotEmpty(encRTSP); err != nil {
+			return nil, fmt.Errorf("failed to decrypt RTSP URL of camera %d: %w", c.DeviceID, err)
 		}
-		c.RTSPURL, _ = encryption.DecryptIfNotEmpty(encRTSP)
 		cameras = append(cameras, c)
 	}
-	return cameras, nil
+	return cameras, rows.Err()
 }
 
-func (a *App) exportServers() ([]ServerExport, error) {
-	rows, err := a.db.DB().Query(`
-		SELECT device_id, COALESCE(tcp_ports, '[]'), COALESCE(use_snmp, 0)
+func exportServers(q database.Querier) ([]ServerExport, error) {
+	rows, err := q.Query(`
+		SELECT device_id, COALESCE(tcp_ports, '[]'), COALESCE(use_snmp, 0), uplink_switch_id, uplink_port_id
 		FROM servers ORDER BY device_id`)
 	if err != nil {
 		return nil, err
@@ -360,18 +734,90 @@ func (a *App) exportServers() ([]ServerExport, error) {
 	for rows.Next() {
 		var s ServerExport
 		var useSNMP int
-		err := rows.Scan(&s.DeviceID, &s.TCPPorts, &useSNMP)
+		err := rows.Scan(&s.DeviceID, &s.TCPPorts, &useSNMP, &s.UplinkSwitchID, &s.UplinkPortID)
 		if err != nil {
-			continue
+			return nil, err
 		}
 		s.UseSNMP = useSNMP == 1
 		servers = append(servers, s)
 	}
-	return servers, nil
+	return servers, rows.Err()
+}
+
+func exportSNMPDevices(q database.Querier) ([]SNMPDeviceExport, error) {
+	rows, err := q.Query(`
+		SELECT device_id, COALESCE(snmp_community, ''), COALESCE(snmp_version, 'v2c'),
+			COALESCE(snmpv3_user, ''), COALESCE(snmpv3_security, 'noAuthNoPriv'),
+			COALESCE(snmpv3_auth_proto, ''), COALESCE(snmpv3_auth_pass, ''),
+			COALESCE(snmpv3_priv_proto, ''), COALESCE(snmpv3_priv_pass, ''),
+			uplink_switch_id, uplink_port_id
+		FROM snmp_devices ORDER BY device_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []SNMPDeviceExport
+	for rows.Next() {
+		var d SNMPDeviceExport
+		var enc [3]string
+		err := rows.Scan(&d.DeviceID, &enc[0], &d.SNMPVersion, &d.SNMPv3User, &d.SNMPv3Security,
+			&d.SNMPv3AuthProto, &enc[1], &d.SNMPv3PrivProto, &enc[2], &d.UplinkSwitchID, &d.UplinkPortID)
+		if err != nil {
+			return nil, err
+		}
+		for i, target := range []*string{&d.SNMPCommunity, &d.SNMPv3AuthPass, &d.SNMPv3PrivPass} {
+			if *target, err = encryption.DecryptIfNotEmpty(enc[i]); err != nil {
+				return nil, fmt.Errorf("failed to decrypt SNMP settings of device %d: %w", d.DeviceID, err)
+			}
+		}
+		devices = append(devices, d)
+	}
+	return devices, rows.Err()
+}
+
+func exportNVRs(q database.Querier) ([]NVRExport, error) {
+	rows, err := q.Query(`
+		SELECT device_id, COALESCE(check_http, 0), COALESCE(http_port, 80)
+		FROM nvrs ORDER BY device_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nvrs []NVRExport
+	for rows.Next() {
+		var n NVRExport
+		if err := rows.Scan(&n.DeviceID, &n.CheckHTTP, &n.HTTPPort); err != nil {
+			return nil, err
+		}
+		nvrs = append(nvrs, n)
+	}
+	return nvrs, rows.Err()
 }
 
-func (a *App) exportSchemas() ([]SchemaExport, error) {
-	rows, err := a.db.DB().Query(`
+func exportUPSDevices(q database.Querier) ([]UPSExport, error) {
+	rows, err := q.Query(`
+		SELECT device_id, COALESCE(protocol, 'snmp'), COALESCE(nut_port, 3493), COALESCE(nut_ups_name, 'ups')
+		FROM ups_devices ORDER BY device_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var units []UPSExport
+	for rows.Next() {
+		var u UPSExport
+		if err := rows.Scan(&u.DeviceID, &u.Protocol, &u.NUTPort, &u.NUTUPSName); err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+func exportSchemas(q database.Querier) ([]SchemaExport, error) {
+	rows, err := q.Query(`
 		SELECT id, name, COALESCE(background_image, ''), created_at
 		FROM schemas ORDER BY id`)
 	if err != nil {
@@ -384,15 +830,15 @@ func (a *App) exportSchemas() ([]SchemaExport, error) {
 		var s SchemaExport
 		err := rows.Scan(&s.ID, &s.Name, &s.BackgroundImage, &s.CreatedAt)
 		if err != nil {
-			continue
+			return nil, err
 		}
 		schemas = append(schemas, s)
 	}
-	return schemas, nil
+	return schemas, rows.Err()
 }
 
-func (a *App) exportSchemaItems() ([]SchemaItemExport, error) {
-	rows, err := a.db.DB().Query(`
+func exportSchemaItems(q database.Querier) ([]SchemaItemExport, error) {
+	rows, err := q.Query(`
 		SELECT id, device_id, schema_id, x, y, width, height
 		FROM schema_items ORDER BY schema_id, id`)
 	if err != nil {
@@ -405,15 +851,178 @@ func (a *App) exportSchemaItems() ([]SchemaItemExport, error) {
 		var i SchemaItemExport
 		err := rows.Scan(&i.ID, &i.DeviceID, &i.SchemaID, &i.X, &i.Y, &i.Width, &i.Height)
 		if err != nil {
-			continue
+			return nil, err
 		}
 		items = append(items, i)
 	}
-	return items, nil
+	return items, rows.Err()
+}
+
+func exportAssets(q database.Querier) ([]AssetFieldExport, []AssetExport, error) {
+	repo := database.NewAssetRepository(q)
+	fields, err := repo.GetFields()
+	if err != nil {
+		return nil, nil, err
+	}
+	all, err := repo.GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fieldExports []AssetFieldExport
+	for _, f := range fields {
+		fieldExports = append(fieldExports, AssetFieldExport{
+			ID:         f.ID,
+			Key:        f.Key,
+			Label:      f.Label,
+			DeviceType: string(f.DeviceType),
+			FieldType:  string(f.FieldType),
+			Position:   f.Position,
+			CreatedAt:  f.CreatedAt,
+		})
+	}
+
+	var assets []AssetExport
+	for _, asset := range all {
+		notice, err := repo.GetWarrantyNotice(asset.DeviceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		assets = append(assets, AssetExport{
+			DeviceID:       asset.DeviceID,
+			SerialNumber:   asset.SerialNumber,
+			MACAddress:     asset.MACAddress,
+			InstallDate:    asset.InstallDate,
+			WarrantyEnd:    asset.WarrantyEnd,
+			Room:           asset.Room,
+			Rack:           asset.Rack,
+			RackUnit:       asset.RackUnit,
+			Notes:          asset.Notes,
+			Custom:         asset.Custom,
+			WarrantyNotice: notice,
+			UpdatedAt:      asset.UpdatedAt,
+		})
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].DeviceID < assets[j].DeviceID })
+	return fieldExports, assets, nil
 }
 
-// ImportConfiguration imports configuration from a JSON file
-func (a *App) ImportConfiguration() (bool, error) {
+func exportComplianceBaselines(q database.Querier) ([]ComplianceBaselineExport, error) {
+	rows, err := q.Query(`
+		SELECT id, name, device_type, COALESCE(enabled, 1), rules, created_at, updated_at
+		FROM compliance_baselines ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var baselines []ComplianceBaselineExport
+	for rows.Next() {
+		var b ComplianceBaselineExport
+		var enabled int
+		err := rows.Scan(&b.ID, &b.Name, &b.DeviceType, &enabled, &b.Rules, &b.CreatedAt, &b.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		b.Enabled = enabled == 1
+		baselines = append(baselines, b)
+	}
+	return baselines, rows.Err()
+}
+
+func exportMaintenanceWindows(q database.Querier) ([]MaintenanceWindowExport, error) {
+	rows, err := q.Query(`
+		SELECT id, name, device_id, COALESCE(device_type, ''), start_at, end_at, COALESCE(description, ''), created_at
+		FROM maintenance_windows ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []MaintenanceWindowExport
+	for rows.Next() {
+		var w MaintenanceWindowExport
+		err := rows.Scan(&w.ID, &w.Name, &w.DeviceID, &w.DeviceType, &w.StartAt, &w.EndAt, &w.Description, &w.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+func exportOncall(q database.Querier) ([]OncallScheduleExport, []OncallOverrideExport, error) {
+	rows, err := q.Query(`
+		SELECT id, name, rotation_start, members, created_at, updated_at
+		FROM oncall_schedules ORDER BY id`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var schedules []OncallScheduleExport
+	for rows.Next() {
+		var s OncallScheduleExport
+		err := rows.Scan(&s.ID, &s.Name, &s.RotationStart, &s.Members, &s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return nil, nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows.Close()
+
+	overrideRows, err := q.Query(`
+		SELECT id, schedule_id, name, COALESCE(email, ''), start_at, end_at, COALESCE(reason, '')
+		FROM oncall_overrides ORDER BY id`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer overrideRows.Close()
+
+	var overrides []OncallOverrideExport
+	for overrideRows.Next() {
+		var o OncallOverrideExport
+		err := overrideRows.Scan(&o.ID, &o.ScheduleID, &o.Name, &o.Email, &o.StartAt, &o.EndAt, &o.Reason)
+		if err != nil {
+			return nil, nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return schedules, overrides, overrideRows.Err()
+}
+
+func exportEscalationPolicies(q database.Querier) ([]EscalationPolicyExport, error) {
+	rows, err := q.Query(`
+		SELECT id, name, device_id, COALESCE(device_type, ''), COALESCE(enabled, 1), steps, created_at, updated_at
+		FROM escalation_policies ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []EscalationPolicyExport
+	for rows.Next() {
+		var p EscalationPolicyExport
+		var enabled int
+		err := rows.Scan(&p.ID, &p.Name, &p.DeviceID, &p.DeviceType, &enabled, &p.Steps, &p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		p.Enabled = enabled == 1
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// ImportConfiguration imports configuration from a backup file. Encrypted backups
+// are detected and opened with the passphrase, plain JSON backups ignore it.
+func (a *App) ImportConfiguration(passphrase string) (imported bool, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return false, err
+	}
 	if a.db == nil {
 		return false, fmt.Errorf("database not initialized")
 	}
@@ -422,7 +1031,7 @@ func (a *App) ImportConfiguration() (bool, error) {
 	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
 		Title: "Импорт конфигурации",
 		Filters: []runtime.FileFilter{
-			{DisplayName: "JSON Files", Pattern: "*.json"},
+			{DisplayName: "Backups", Pattern: "*" + encryptedBackupExt + ";*.json"},
 		},
 	})
 	if err != nil {
@@ -432,15 +1041,9 @@ func (a *App) ImportConfiguration() (bool, error) {
 		return false, nil // User cancelled
 	}
 
-	// Read JSON file
-	configData, err := os.ReadFile(filePath)
+	backup, _, err := readBackupFile(filePath, passphrase)
 	if err != nil {
-		return false, fmt.Errorf("failed to read JSON file: %w", err)
-	}
-
-	var backup BackupData
-	if err := json.Unmarshal(configData, &backup); err != nil {
-		return false, fmt.Errorf("failed to parse JSON: %w", err)
+		return false, err
 	}
 
 	// Confirm import
@@ -455,38 +1058,76 @@ func (a *App) ImportConfiguration() (bool, error) {
 		return false, nil
 	}
 
-	// Stop monitoring
-	if a.monitor != nil {
-		a.monitor.Stop()
-	}
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	rec := a.beginAudit("config.import", models.AuditTarget{Type: "system"}, nil).
+		detail("file", filePath).
+		detail("devices", len(backup.Devices)).
+		detail("credentials", len(backup.Credentials)).
+		detail("history", backup.History != nil)
+	defer func() { rec.finish(err) }()
 
-	// Import data
-	if err := a.importBackupData(&backup); err != nil {
-		// Restart monitoring on error
-		a.monitor.Start()
-		return false, fmt.Errorf("failed to import data: %w", err)
+	if err := a.replaceConfiguration(backup); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	// Fix port types after import
-	a.db.FixExistingPortTypes()
+// readBackupFile reads a configuration backup, decrypting it with the passphrase
+// if it is encrypted. Plain JSON backups ignore the passphrase.
+func readBackupFile(filePath, passphrase string) (_ *BackupData, encrypted bool, err error) {
+	configData, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read backup file: %w", err)
+	}
 
-	// Restart monitoring
-	a.initMonitoring()
-	a.monitor.Start()
+	if encryption.IsSealed(configData) {
+		encrypted = true
+		if passphrase == "" {
+			return nil, true, fmt.Errorf("backup is encrypted, passphrase required")
+		}
+		if configData, err = encryption.Open(configData, backupEnvelopeKind, passphrase); err != nil {
+			return nil, true, err
+		}
+	}
 
-	return true, nil
+	backup, err := parseBackup(configData)
+	if err != nil {
+		return nil, encrypted, err
+	}
+	return backup, encrypted, nil
 }
 
-// importBackupData imports all data from backup into the database
-func (a *App) importBackupData(backup *BackupData) error {
-	db := a.db.DB()
+// isPortableSetting reports whether a setting is restored from a backup. Settings
+// tied to this database or its master key are kept.
+func isPortableSetting(key string) bool {
+	switch key {
+	case database.AuditAnchorKey, settingsKeyKeyCheck:
+		return false
+	}
+	return true
+}
 
+// importBackupData replaces the configuration with the backup. History tables
+// are replaced only if the backup contains them. Run it in a transaction.
+func importBackupData(q database.Querier, backup *BackupData) error {
 	// Clear existing data (in reverse dependency order)
 	tables := []string{
-		"schema_items", "schemas", "switch_ports", "cameras", "servers", "switches", "devices", "credentials",
+		"asset_field_values", "device_assets", "asset_fields", "device_tags",
+		"schema_items", "schemas", "maintenance_windows", "escalation_policies",
+		"oncall_overrides", "oncall_schedules", "compliance_baselines",
+		"switch_ports", "cameras", "servers", "ups_devices", "nvrs", "snmp_devices", "switches", "devices",
+		"device_groups", "sites", "credentials",
+	}
+	if backup.History != nil {
+		// History refers to the configuration, so it is cleared first
+		history := slices.Clone(historyTables)
+		slices.Reverse(history)
+		tables = append(history, tables...)
 	}
 	for _, table := range tables {
-		_, err := db.Exec("DELETE FROM " + table)
+		_, err := q.Exec("DELETE FROM " + table)
 		if err != nil {
 			return fmt.Errorf("failed to clear %s: %w", table, err)
 		}
@@ -494,39 +1135,94 @@ func (a *App) importBackupData(backup *BackupData) error {
 
 	// Import credentials
 	for _, c := range backup.Credentials {
-		encUser, _ := encryption.EncryptIfNotEmpty(c.Username)
-		encPass, _ := encryption.EncryptIfNotEmpty(c.Password)
-		_, err := db.Exec(`
+		if !models.CredentialType(c.Type).Valid() {
+			return fmt.Errorf("credential %s has invalid type %s", c.Name, c.Type)
+		}
+		encUser, err := encryption.EncryptIfNotEmpty(c.Username)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt credential %s: %w", c.Name, err)
+		}
+		encPass, err := encryption.EncryptIfNotEmpty(c.Password)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt credential %s: %w", c.Name, err)
+		}
+		_, err = q.Exec(`
 			INSERT INTO credentials (id, name, type, username, password, note, created_at, updated_at)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-			c.ID, c.Name, c.Type, encUser, encPass, c.Note, c.CreatedAt, time.Now())
+			c.ID, c.Name, c.Type, encUser, encPass, c.Note, c.CreatedAt, c.UpdatedAt)
 		if err != nil {
 			return fmt.Errorf("failed to import credential %s: %w", c.Name, err)
 		}
 	}
 
-	// Import devices
+	// Import sites
+	for _, s := range backup.Sites {
+		_, err := q.Exec(`
+			INSERT INTO sites (id, name, address, latitude, longitude, description, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.ID, s.Name, s.Address, s.Latitude, s.Longitude, s.Description, s.CreatedAt, s.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to import site %s: %w", s.Name, err)
+		}
+	}
+
+	// Import device groups, parents are set once all groups exist
+	for _, g := range backup.DeviceGroups {
+		_, err := q.Exec(`
+			INSERT INTO device_groups (id, name, description, settings, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			g.ID, g.Name, g.Description, g.Settings, g.CreatedAt, g.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to import device group %s: %w", g.Name, err)
+		}
+	}
+	for _, g := range backup.DeviceGroups {
+		if g.ParentID == nil {
+			continue
+		}
+		if _, err := q.Exec("UPDATE device_groups SET parent_id = ? WHERE id = ?", g.ParentID, g.ID); err != nil {
+			return fmt.Errorf("failed to import parent of device group %s: %w", g.Name, err)
+		}
+	}
+
+	// Import devices with tags
 	for _, d := range backup.Devices {
-		_, err := db.Exec(`
-			INSERT INTO devices (id, name, ip_address, type, manufacturer, model, credential_id, status, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, 'unknown', ?, ?)`,
-			d.ID, d.Name, d.IPAddress, d.Type, d.Manufacturer, d.Model, d.CredentialID, time.Now(), time.Now())
+		if !models.DeviceType(d.Type).Valid() {
+			return fmt.Errorf("device %s has invalid type %s", d.Name, d.Type)
+		}
+		_, err := q.Exec(`
+			INSERT INTO devices (id, name, ip_address, type, manufacturer, model, credential_id, site_id, group_id,
+				status, last_check, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.ID, d.Name, d.IPAddress, d.Type, d.Manufacturer, d.Model, d.CredentialID, d.SiteID, d.GroupID,
+			d.Status, d.LastCheck, d.CreatedAt, d.UpdatedAt)
 		if err != nil {
 			return fmt.Errorf("failed to import device %s: %w", d.Name, err)
 		}
+		for _, tag := range d.Tags {
+			if _, err := q.Exec("INSERT INTO device_tags (device_id, tag) VALUES (?, ?)", d.ID, tag); err != nil {
+				return fmt.Errorf("failed to import tag %s of device %s: %w", tag, d.Name, err)
+			}
+		}
 	}
 
 	// Import switches
 	for _, s := range backup.Switches {
-		encCommunity, _ := encryption.EncryptIfNotEmpty(s.SNMPCommunity)
-		encAuthPass, _ := encryption.EncryptIfNotEmpty(s.SNMPv3AuthPass)
-		encPrivPass, _ := encryption.EncryptIfNotEmpty(s.SNMPv3PrivPass)
-		_, err := db.Exec(`
-			INSERT INTO switches (device_id, snmp_community, snmp_version, port_count, sfp_port_count,
-				snmpv3_user, snmpv3_security, snmpv3_auth_proto, snmpv3_auth_pass, snmpv3_priv_proto, snmpv3_priv_pass)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			s.DeviceID, encCommunity, s.SNMPVersion, s.PortCount, s.SFPPortCount,
-			s.SNMPv3User, s.SNMPv3Security, s.SNMPv3AuthProto, encAuthPass, s.SNMPv3PrivProto, encPrivPass)
+		var enc [4]string
+		for i, value := range []string{s.SNMPCommunity, s.SNMPWriteCommunity, s.SNMPv3AuthPass, s.SNMPv3PrivPass} {
+			var err error
+			if enc[i], err = encryption.EncryptIfNotEmpty(value); err != nil {
+				return fmt.Errorf("failed to encrypt SNMP settings of switch %d: %w", s.DeviceID, err)
+			}
+		}
+		_, err := q.Exec(`
+			INSERT INTO switches (device_id, snmp_community, snmp_write_community, snmp_version, port_count, sfp_port_count,
+				snmpv3_user, snmpv3_security, snmpv3_auth_proto, snmpv3_auth_pass, snmpv3_priv_proto, snmpv3_priv_pass,
+				poe_budget_w)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.DeviceID, enc[0], enc[1], s.SNMPVersion, s.PortCount, s.SFPPortCount,
+			s.SNMPv3User, s.SNMPv3Security, s.SNMPv3AuthProto, enc[2], s.SNMPv3PrivProto, enc[3],
+			s.PoEBudgetW)
 		if err != nil {
 			return fmt.Errorf("failed to import switch %d: %w", s.DeviceID, err)
 		}
@@ -534,8 +1230,11 @@ func (a *App) importBackupData(backup *BackupData) error {
 
 	// Import cameras
 	for _, c := range backup.Cameras {
-		encRTSP, _ := encryption.EncryptIfNotEmpty(c.RTSPURL)
-		_, err := db.Exec(`
+		encRTSP, err := encryption.EncryptIfNotEmpty(c.RTSPURL)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt RTSP URL of camera %d: %w", c.DeviceID, err)
+		}
+		_, err = q.Exec(`
 			INSERT INTO cameras (device_id, rtsp_url, onvif_port, snapshot_url, stream_type)
 			VALUES (?, ?, ?, ?, ?)`,
 			c.DeviceID, encRTSP, c.ONVIFPort, c.SnapshotURL, c.StreamType)
@@ -550,7 +1249,7 @@ func (a *App) importBackupData(backup *BackupData) error {
 		if s.UseSNMP {
 			useSNMP = 1
 		}
-		_, err := db.Exec(`
+		_, err := q.Exec(`
 			INSERT INTO servers (device_id, tcp_ports, use_snmp)
 			VALUES (?, ?, ?)`,
 			s.DeviceID, s.TCPPorts, useSNMP)
@@ -559,20 +1258,88 @@ func (a *App) importBackupData(backup *BackupData) error {
 		}
 	}
 
+	// Import SNMP settings of routers, access points, UPS units, NVRs and PDUs
+	for _, d := range backup.SNMPDevices {
+		var enc [3]string
+		for i, value := range []string{d.SNMPCommunity, d.SNMPv3AuthPass, d.SNMPv3PrivPass} {
+			var err error
+			if enc[i], err = encryption.EncryptIfNotEmpty(value); err != nil {
+				return fmt.Errorf("failed to encrypt SNMP settings of device %d: %w", d.DeviceID, err)
+			}
+		}
+		_, err := q.Exec(`
+			INSERT INTO snmp_devices (device_id, snmp_community, snmp_version, snmpv3_user, snmpv3_security,
+				snmpv3_auth_proto, snmpv3_auth_pass, snmpv3_priv_proto, snmpv3_priv_pass)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.DeviceID, enc[0], d.SNMPVersion, d.SNMPv3User, d.SNMPv3Security,
+			d.SNMPv3AuthProto, enc[1], d.SNMPv3PrivProto, enc[2])
+		if err != nil {
+			return fmt.Errorf("failed to import SNMP settings of device %d: %w", d.DeviceID, err)
+		}
+	}
+	for _, n := range backup.NVRs {
+		_, err := q.Exec("INSERT INTO nvrs (device_id, check_http, http_port) VALUES (?, ?, ?)",
+			n.DeviceID, n.CheckHTTP, n.HTTPPort)
+		if err != nil {
+			return fmt.Errorf("failed to import NVR %d: %w", n.DeviceID, err)
+		}
+	}
+	for _, u := range backup.UPSDevices {
+		_, err := q.Exec("INSERT INTO ups_devices (device_id, protocol, nut_port, nut_ups_name) VALUES (?, ?, ?, ?)",
+			u.DeviceID, u.Protocol, u.NUTPort, u.NUTUPSName)
+		if err != nil {
+			return fmt.Errorf("failed to import UPS %d: %w", u.DeviceID, err)
+		}
+	}
+
 	// Import switch ports
 	for _, p := range backup.SwitchPorts {
-		_, err := db.Exec(`
-			INSERT INTO switch_ports (id, switch_id, port_number, name, status, port_type, linked_camera_id, linked_switch_id)
-			VALUES (?, ?, ?, ?, 'unknown', ?, ?, ?)`,
-			p.ID, p.SwitchID, p.PortNumber, p.Name, p.PortType, p.LinkedCameraID, p.LinkedSwitchID)
+		_, err := q.Exec(`
+			INSERT INTO switch_ports (id, switch_id, port_number, name, status, speed, duplex, admin_status, poe_status,
+				port_type, linked_camera_id, linked_switch_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			p.ID, p.SwitchID, p.PortNumber, p.Name, p.Status, p.Speed, p.Duplex, p.AdminStatus, p.PoEStatus,
+			p.PortType, p.LinkedCameraID, p.LinkedSwitchID)
 		if err != nil {
 			return fmt.Errorf("failed to import port %d: %w", p.ID, err)
 		}
 	}
 
+	// Uplinks refer to ports, so they are set once all ports exist
+	for _, s := range backup.Switches {
+		if s.UplinkSwitchID == nil && s.UplinkPortID == nil {
+			continue
+		}
+		_, err := q.Exec("UPDATE switches SET uplink_switch_id = ?, uplink_port_id = ? WHERE device_id = ?",
+			s.UplinkSwitchID, s.UplinkPortID, s.DeviceID)
+		if err != nil {
+			return fmt.Errorf("failed to import uplink of switch %d: %w", s.DeviceID, err)
+		}
+	}
+	for _, s := range backup.Servers {
+		if s.UplinkSwitchID == nil && s.UplinkPortID == nil {
+			continue
+		}
+		_, err := q.Exec("UPDATE servers SET uplink_switch_id = ?, uplink_port_id = ? WHERE device_id = ?",
+			s.UplinkSwitchID, s.UplinkPortID, s.DeviceID)
+		if err != nil {
+			return fmt.Errorf("failed to import uplink of server %d: %w", s.DeviceID, err)
+		}
+	}
+	for _, d := range backup.SNMPDevices {
+		if d.UplinkSwitchID == nil && d.UplinkPortID == nil {
+			continue
+		}
+		_, err := q.Exec("UPDATE snmp_devices SET uplink_switch_id = ?, uplink_port_id = ? WHERE device_id = ?",
+			d.UplinkSwitchID, d.UplinkPortID, d.DeviceID)
+		if err != nil {
+			return fmt.Errorf("failed to import uplink of device %d: %w", d.DeviceID, err)
+		}
+	}
+
 	// Import schemas
 	for _, s := range backup.Schemas {
-		_, err := db.Exec(`
+		_, err := q.Exec(`
 			INSERT INTO schemas (id, name, background_image, created_at)
 			VALUES (?, ?, ?, ?)`,
 			s.ID, s.Name, s.BackgroundImage, s.CreatedAt)
@@ -583,7 +1350,7 @@ func (a *App) importBackupData(backup *BackupData) error {
 
 	// Import schema items
 	for _, i := range backup.SchemaItems {
-		_, err := db.Exec(`
+		_, err := q.Exec(`
 			INSERT INTO schema_items (id, device_id, schema_id, x, y, width, height)
 			VALUES (?, ?, ?, ?, ?, ?, ?)`,
 			i.ID, i.DeviceID, i.SchemaID, i.X, i.Y, i.Width, i.Height)
@@ -592,10 +1359,115 @@ func (a *App) importBackupData(backup *BackupData) error {
 		}
 	}
 
+	// Import asset fields, then asset data which refers to fields by key
+	for _, f := range backup.AssetFields {
+		_, err := q.Exec(`
+			INSERT INTO asset_fields (id, key, label, device_type, field_type, position, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			f.ID, f.Key, f.Label, f.DeviceType, f.FieldType, f.Position, f.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to import asset field %s: %w", f.Key, err)
+		}
+	}
+
+	assetRepo := database.NewAssetRepository(q)
+	for _, asset := range backup.Assets {
+		err := assetRepo.Save(&models.DeviceAsset{
+			DeviceID:     asset.DeviceID,
+			SerialNumber: asset.SerialNumber,
+			MACAddress:   asset.MACAddress,
+			InstallDate:  asset.InstallDate,
+			WarrantyEnd:  asset.WarrantyEnd,
+			Room:         asset.Room,
+			Rack:         asset.Rack,
+			RackUnit:     asset.RackUnit,
+			Notes:        asset.Notes,
+			Custom:       asset.Custom,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import asset data of device %d: %w", asset.DeviceID, err)
+		}
+		_, err = q.Exec("UPDATE device_assets SET warranty_notice = ?, updated_at = ? WHERE device_id = ?",
+			asset.WarrantyNotice, asset.UpdatedAt, asset.DeviceID)
+		if err != nil {
+			return fmt.Errorf("failed to import asset data of device %d: %w", asset.DeviceID, err)
+		}
+	}
+
+	// Import compliance baselines
+	for _, b := range backup.ComplianceBaselines {
+		_, err := q.Exec(`
+			INSERT INTO compliance_baselines (id, name, device_type, enabled, rules, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			b.ID, b.Name, b.DeviceType, b.Enabled, b.Rules, b.CreatedAt, b.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to import compliance baseline %s: %w", b.Name, err)
+		}
+	}
+
+	// Import maintenance windows
+	for _, w := range backup.MaintenanceWindows {
+		_, err := q.Exec(`
+			INSERT INTO maintenance_windows (id, name, device_id, device_type, start_at, end_at, description, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			w.ID, w.Name, w.DeviceID, w.DeviceType, w.StartAt, w.EndAt, w.Description, w.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to import maintenance window %s: %w", w.Name, err)
+		}
+	}
+
+	// Import on-call schedules with overrides
+	for _, s := range backup.OncallSchedules {
+		_, err := q.Exec(`
+			INSERT INTO oncall_schedules (id, name, rotation_start, members, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			s.ID, s.Name, s.RotationStart, s.Members, s.CreatedAt, s.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to import on-call schedule %s: %w", s.Name, err)
+		}
+	}
+	for _, o := range backup.OncallOverrides {
+		_, err := q.Exec(`
+			INSERT INTO oncall_overrides (id, schedule_id, name, email, start_at, end_at, reason)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			o.ID, o.ScheduleID, o.Name, o.Email, o.StartAt, o.EndAt, o.Reason)
+		if err != nil {
+			return fmt.Errorf("failed to import on-call override %s: %w", o.Name, err)
+		}
+	}
+
+	// Import escalation policies
+	for _, p := range backup.EscalationPolicies {
+		_, err := q.Exec(`
+			INSERT INTO escalation_policies (id, name, device_id, device_type, enabled, steps, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			p.ID, p.Name, p.DeviceID, p.DeviceType, p.Enabled, p.Steps, p.CreatedAt, p.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to import escalation policy %s: %w", p.Name, err)
+		}
+	}
+
 	// Import settings
-	settingsRepo := database.NewSettingsRepository(db)
+	settingsRepo := database.NewSettingsRepository(q)
 	for key, value := range backup.Settings {
-		settingsRepo.Set(key, value)
+		if !isPortableSetting(key) {
+			continue
+		}
+		if slices.Contains(secretSettingKeys, key) {
+			var err error
+			if value, err = encryption.EncryptIfNotEmpty(value); err != nil {
+				return fmt.Errorf("failed to encrypt setting %s: %w", key, err)
+			}
+		}
+		if err := settingsRepo.Set(key, value); err != nil {
+			return err
+		}
+	}
+
+	if backup.History != nil {
+		if err := importHistory(q, backup.History); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// backupFormatVersion is the version of the backup file layout. Increase it when
+// the layout changes in a way an older reader would misread, and add the upgrade
+// from the previous version to backupUpgrades.
+const backupFormatVersion = 2
+
+// backupUpgrades converts a backup of version i+1 to version i+2. They work on the
+// decoded JSON, so they keep working when the export structures change later.
+var backupUpgrades = []func(backup map[string]interface{}) error{
+	upgradeBackupV1,
+}
+
+// parseBackup decodes a backup file, upgrading older versions to the current
+// layout. Backups of newer versions are refused.
+func parseBackup(data []byte) (*BackupData, error) {
+	var header struct {
+		Version interface{} `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	version, err := backupVersion(header.Version)
+	if err != nil {
+		return nil, err
+	}
+	if version > backupFormatVersion {
+		return nil, fmt.Errorf("backup was created by a newer version (format %d)", version)
+	}
+
+	if version < backupFormatVersion {
+		var raw map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		for v := version; v < backupFormatVersion; v++ {
+			if err := backupUpgrades[v-1](raw); err != nil {
+				return nil, fmt.Errorf("failed to upgrade backup from format %d: %w", v, err)
+			}
+		}
+		raw["version"] = backupFormatVersion
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("failed to upgrade backup: %w", err)
+		}
+	}
+
+	var backup BackupData
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return &backup, nil
+}
+
+// backupVersion reads the format version of a backup. Version 1 wrote it as the
+// string "1.0", files without a version are treated as version 1 too.
+func backupVersion(v interface{}) (int, error) {
+	switch version := v.(type) {
+	case nil:
+		return 1, nil
+	case float64:
+		if version >= 1 && version == math.Trunc(version) {
+			return int(version), nil
+		}
+	case string:
+		major, _, _ := strings.Cut(version, ".")
+		if n, err := strconv.Atoi(major); err == nil && n >= 1 {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid backup version %v", v)
+}
+
+// upgradeBackupV1 fills the columns version 1 did not export with the values the
+// database gives them by default. Missing timestamps are set to the export date.
+func upgradeBackupV1(backup map[string]interface{}) error {
+	exported := backup["export_date"]
+
+	forEachBackupItem(backup, "credentials", func(item map[string]interface{}) {
+		setBackupDefault(item, "updated_at", item["created_at"])
+	})
+	forEachBackupItem(backup, "devices", func(item map[string]interface{}) {
+		setBackupDefault(item, "status", "unknown")
+		setBackupDefault(item, "created_at", exported)
+		setBackupDefault(item, "updated_at", exported)
+	})
+	forEachBackupItem(backup, "switch_ports", func(item map[string]interface{}) {
+		setBackupDefault(item, "status", "unknown")
+	})
+	forEachBackupItem(backup, "asset_fields", func(item map[string]interface{}) {
+		setBackupDefault(item, "created_at", exported)
+	})
+	forEachBackupItem(backup, "assets", func(item map[string]interface{}) {
+		setBackupDefault(item, "updated_at", exported)
+	})
+	return nil
+}
+
+// forEachBackupItem calls fn for every object of a list in a decoded backup
+func forEachBackupItem(backup map[string]interface{}, key string, fn func(item map[string]interface{})) {
+	list, _ := backup[key].([]interface{})
+	for _, v := range list {
+		if item, ok := v.(map[string]interface{}); ok {
+			fn(item)
+		}
+	}
+}
+
+// setBackupDefault sets a missing field of a decoded backup object
+func setBackupDefault(item map[string]interface{}, key string, value interface{}) {
+	if _, ok := item[key]; !ok && value != nil {
+		item[key] = value
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/database"
+)
+
+// historyTables lists the tables exported with history, parents first. Their rows
+// are copied as is, they hold no secrets and keep their IDs.
+var historyTables = []string{
+	"events",
+	"status_history", "status_periods", "status_history_hourly", "status_history_daily", "rollup_state",
+	"incidents", "incident_notes", "incident_escalations",
+	"compliance_state", "compliance_reports",
+	"switch_environment", "poe_power", "poe_energy_daily", "port_history",
+}
+
+// BackupTable holds the rows of a table with their column names
+type BackupTable struct {
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// UnmarshalJSON keeps numbers exact, so integer IDs are not read as floats
+func (t *BackupTable) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Columns []string        `json:"columns"`
+		Rows    [][]interface{} `json:"rows"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	t.Columns, t.Rows = raw.Columns, raw.Rows
+	return nil
+}
+
+// exportHistory copies all history tables
+func exportHistory(q database.Querier) (map[string]*BackupTable, error) {
+	history := make(map[string]*BackupTable, len(historyTables))
+	for _, table := range historyTables {
+		t, err := exportTable(q, table)
+		if err != nil {
+			return nil, err
+		}
+		history[table] = t
+	}
+	return history, nil
+}
+
+// exportTable copies all rows of a table in insertion order
+func exportTable(q database.Querier, table string) (*BackupTable, error) {
+	rows, err := q.Query("SELECT * FROM " + table + " ORDER BY rowid")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", table, err)
+	}
+
+	t := &BackupTable{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		t.Rows = append(t.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	return t, nil
+}
+
+// importHistory inserts the rows of the history tables into emptied tables
+func importHistory(q database.Querier, history map[string]*BackupTable) error {
+	for table := range history {
+		if !slices.Contains(historyTables, table) {
+			return fmt.Errorf("backup contains unknown table %s", table)
+		}
+	}
+	for _, table := range historyTables {
+		t := history[table]
+		if t == nil {
+			continue
+		}
+		if err := importTable(q, table, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importTable inserts the rows of a backup table. Columns must exist in the
+// table, timestamps are converted back from their JSON form.
+func importTable(q database.Querier, table string, t *BackupTable) error {
+	types, err := tableColumns(q, table)
+	if err != nil {
+		return err
+	}
+	for _, column := range t.Columns {
+		if _, ok := types[column]; !ok {
+			return fmt.Errorf("backup table %s has unknown column %s", table, column)
+		}
+	}
+	if len(t.Columns) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table,
+		strings.Join(t.Columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(t.Columns)), ", "))
+	for n, row := range t.Rows {
+		if len(row) != len(t.Columns) {
+			return fmt.Errorf("backup table %s: row %d has %d values, expected %d", table, n+1, len(row), len(t.Columns))
+		}
+		args := make([]interface{}, len(row))
+		for i, v := range row {
+			if args[i], err = backupValue(v, types[t.Columns[i]]); err != nil {
+				return fmt.Errorf("backup table %s: row %d, %s: %w", table, n+1, t.Columns[i], err)
+			}
+		}
+		if _, err := q.Exec(query, args...); err != nil {
+			return fmt.Errorf("failed to import %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// backupValue converts a value decoded from JSON for a column of the given type
+func backupValue(v interface{}, columnType string) (interface{}, error) {
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i, nil
+		}
+		return value.Float64()
+	case string:
+		// Timestamps the driver could not parse were exported as text and stay text
+		if strings.EqualFold(columnType, "DATETIME") {
+			if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				return t, nil
+			}
+		}
+		return value, nil
+	case nil, bool, float64:
+		return value, nil
+	}
+	return nil, fmt.Errorf("unsupported value %T", v)
+}
+
+// tableColumns returns the declared types of the columns of a table by name
+func tableColumns(q database.Querier, table string) (map[string]string, error) {
+	rows, err := q.Query("SELECT name, type FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]string)
+	for rows.Next() {
+		var name, columnType string
+		if err := rows.Scan(&name, &columnType); err != nil {
+			return nil, fmt.Errorf("failed to get columns of %s: %w", table, err)
+		}
+		columns[name] = columnType
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", table, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s does not exist", table)
+	}
+	return columns, nil
+}
@@ -0,0 +1,1023 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/audit"
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Parts of a backup that can be merged separately
+const (
+	MergeSectionCredentials = "credentials"
+	MergeSectionDevices     = "devices" // Devices with switch, camera and server settings and ports
+	MergeSectionSchemas     = "schemas"
+	MergeSectionAssets      = "assets" // Asset fields and asset data of merged devices
+	MergeSectionSettings    = "settings"
+)
+
+var mergeSections = []string{
+	MergeSectionCredentials, MergeSectionDevices, MergeSectionSchemas, MergeSectionAssets, MergeSectionSettings,
+}
+
+// What to do with a backup object that matches an existing one
+const (
+	MergeConflictSkip      = "skip"      // Keep the existing object and link to it
+	MergeConflictOverwrite = "overwrite" // Update the existing object from the backup
+	MergeConflictDuplicate = "duplicate" // Create a new object next to the existing one
+)
+
+// Actions reported for merged objects
+const (
+	MergeActionCreate    = "create"
+	MergeActionOverwrite = "overwrite"
+	MergeActionDuplicate = "duplicate"
+	MergeActionSkip      = "skip"
+)
+
+// errMergeDryRun rolls back the transaction of a merge preview
+var errMergeDryRun = errors.New("dry run")
+
+// ConfigurationBackupInfo describes a backup file selected for merging
+type ConfigurationBackupInfo struct {
+	Path        string         `json:"path"`
+	FileName    string         `json:"file_name"`
+	Encrypted   bool           `json:"encrypted"`
+	ExportDate  time.Time      `json:"export_date"`
+	AppVersion  string         `json:"app_version"`
+	Credentials int            `json:"credentials"`
+	Devices     int            `json:"devices"`
+	Schemas     []SchemaExport `json:"schemas"` // For choosing which schemas to merge
+}
+
+// ConfigurationMergeRequest describes how to merge a backup into the current
+// configuration
+type ConfigurationMergeRequest struct {
+	Path       string   `json:"path"`
+	Passphrase string   `json:"passphrase,omitempty"`
+	Conflict   string   `json:"conflict"`   // skip, overwrite or duplicate
+	Sections   []string `json:"sections"`   // Parts to merge, empty for all
+	SchemaIDs  []int64  `json:"schema_ids"` // Backup schemas to merge, empty for all
+	DryRun     bool     `json:"dry_run"`
+}
+
+// ConfigurationMergeItem is the planned or applied action for one backup object
+type ConfigurationMergeItem struct {
+	Kind       string               `json:"kind"` // credential, device, schema, asset_field, setting
+	Name       string               `json:"name"`
+	Action     string               `json:"action"`
+	ExistingID int64                `json:"existing_id,omitempty"` // Matched object of this configuration
+	Reason     string               `json:"reason,omitempty"`
+	Changes    []models.AuditChange `json:"changes,omitempty"` // Differences for overwritten objects
+}
+
+// ConfigurationMergeReport contains the result of a merge or its preview
+type ConfigurationMergeReport struct {
+	FileName  string                   `json:"file_name"`
+	DryRun    bool                     `json:"dry_run"`
+	Committed bool                     `json:"committed"`
+	Created   int                      `json:"created"`
+	Updated   int                      `json:"updated"`
+	Skipped   int                      `json:"skipped"`
+	Message   string                   `json:"message,omitempty"`
+	Items     []ConfigurationMergeItem `json:"items"`
+}
+
+// SelectConfigurationBackup asks for a backup file and describes its content for
+// MergeConfiguration. Encrypted backups need the passphrase. Returns nil if
+// cancelled.
+func (a *App) SelectConfigurationBackup(passphrase string) (*ConfigurationBackupInfo, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Объединение конфигурации",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Backups", Pattern: "*" + encryptedBackupExt + ";*.json"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if filePath == "" {
+		return nil, nil // User cancelled
+	}
+
+	backup, encrypted, err := readBackupFile(filePath, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	schemas := backup.Schemas
+	if schemas == nil {
+		schemas = []SchemaExport{}
+	}
+	for i := range schemas {
+		schemas[i].BackgroundImage = "" // Not needed for selection
+	}
+
+	return &ConfigurationBackupInfo{
+		Path:        filePath,
+		FileName:    filepath.Base(filePath),
+		Encrypted:   encrypted,
+		ExportDate:  backup.ExportDate,
+		AppVersion:  backup.AppVersion,
+		Credentials: len(backup.Credentials),
+		Devices:     len(backup.Devices),
+		Schemas:     schemas,
+	}, nil
+}
+
+// MergeConfiguration merges a backup into the current configuration instead of
+// replacing it. Backup IDs are remapped; devices are matched by IP address, then
+// by name, credentials by name and type, schemas by name and settings by key.
+// With DryRun the merge runs in a transaction that is rolled back, so the report
+// shows exactly what would change.
+func (a *App) MergeConfiguration(req ConfigurationMergeRequest) (report *ConfigurationMergeReport, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	switch req.Conflict {
+	case MergeConflictSkip, MergeConflictOverwrite, MergeConflictDuplicate:
+	case "":
+		req.Conflict = MergeConflictSkip
+	default:
+		return nil, fmt.Errorf("unknown conflict strategy %q", req.Conflict)
+	}
+	sections := make(map[string]bool)
+	for _, s := range req.Sections {
+		if !slices.Contains(mergeSections, s) {
+			return nil, fmt.Errorf("unknown section %q", s)
+		}
+		sections[s] = true
+	}
+	if len(sections) == 0 {
+		for _, s := range mergeSections {
+			sections[s] = true
+		}
+	}
+
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	if !req.DryRun {
+		rec := a.beginAudit("config.merge", models.AuditTarget{Type: "system"}, nil).
+			detail("file", filepath.Base(req.Path)).
+			detail("conflict", req.Conflict).
+			detail("sections", req.Sections)
+		defer func() {
+			if report != nil {
+				rec.detail("created", report.Created).detail("updated", report.Updated).detail("skipped", report.Skipped)
+			}
+			rec.finish(err)
+		}()
+	}
+
+	backup, _, err := readBackupFile(req.Path, req.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	report = &ConfigurationMergeReport{
+		FileName: filepath.Base(req.Path),
+		DryRun:   req.DryRun,
+		Items:    []ConfigurationMergeItem{},
+	}
+	err = a.db.WithTx(func(tx *sql.Tx) error {
+		m, err := newBackupMerge(tx, backup, req, sections, report)
+		if err != nil {
+			return err
+		}
+		if err := m.run(); err != nil {
+			return err
+		}
+		if req.DryRun {
+			return errMergeDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errMergeDryRun {
+		log.Printf("Configuration merge from %s failed: %v", report.FileName, err)
+		return nil, fmt.Errorf("merge failed, nothing was changed: %w", err)
+	}
+
+	if req.DryRun {
+		report.Message = fmt.Sprintf("Будет создано: %d, обновлено: %d, пропущено: %d",
+			report.Created, report.Updated, report.Skipped)
+		return report, nil
+	}
+
+	report.Committed = true
+	report.Message = fmt.Sprintf("Создано: %d, обновлено: %d, пропущено: %d",
+		report.Created, report.Updated, report.Skipped)
+	log.Printf("Merged configuration from %s: %d created, %d updated, %d skipped",
+		report.FileName, report.Created, report.Updated, report.Skipped)
+	return report, nil
+}
+
+// existingDevice is a device of the current configuration that backup devices are
+// matched against
+type existingDevice struct {
+	id           int64
+	name         string
+	ipAddress    string
+	deviceType   string
+	manufacturer string
+	model        string
+}
+
+func (d *existingDevice) state() map[string]interface{} {
+	return map[string]interface{}{
+		"name": d.name, "ip_address": d.ipAddress, "manufacturer": d.manufacturer, "model": d.model,
+	}
+}
+
+// backupMerge applies a backup to the current configuration inside a transaction.
+// The maps translate backup IDs to IDs of this database.
+type backupMerge struct {
+	tx       *sql.Tx
+	backup   *BackupData
+	req      ConfigurationMergeRequest
+	sections map[string]bool
+	report   *ConfigurationMergeReport
+
+	credentials map[int64]int64
+	devices     map[int64]int64
+	written     map[int64]bool // Backup devices created or overwritten by this merge
+	ports       map[int64]int64
+
+	devicesByIP   map[string]*existingDevice
+	devicesByName map[string]*existingDevice
+}
+
+func newBackupMerge(tx *sql.Tx, backup *BackupData, req ConfigurationMergeRequest, sections map[string]bool, report *ConfigurationMergeReport) (*backupMerge, error) {
+	m := &backupMerge{
+		tx:            tx,
+		backup:        backup,
+		req:           req,
+		sections:      sections,
+		report:        report,
+		credentials:   make(map[int64]int64),
+		devices:       make(map[int64]int64),
+		written:       make(map[int64]bool),
+		ports:         make(map[int64]int64),
+		devicesByIP:   make(map[string]*existingDevice),
+		devicesByName: make(map[string]*existingDevice),
+	}
+
+	// Index the devices that exist before the merge, so devices created by the merge
+	// are never matched
+	rows, err := tx.Query(`
+		SELECT id, name, ip_address, type, COALESCE(manufacturer, ''), COALESCE(model, '')
+		FROM devices ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query devices: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		d := &existingDevice{}
+		if err := rows.Scan(&d.id, &d.name, &d.ipAddress, &d.deviceType, &d.manufacturer, &d.model); err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		if _, ok := m.devicesByIP[d.ipAddress]; !ok {
+			m.devicesByIP[d.ipAddress] = d
+		}
+		if key := strings.ToLower(d.name); m.devicesByName[key] == nil {
+			m.devicesByName[key] = d
+		}
+	}
+	return m, rows.Err()
+}
+
+func (m *backupMerge) run() error {
+	steps := []func() error{
+		m.mergeCredentials, m.mergeDevices, m.mergeLinks, m.mergeSchemas, m.mergeAssets, m.mergeSettings,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *backupMerge) add(item ConfigurationMergeItem) {
+	switch item.Action {
+	case MergeActionCreate, MergeActionDuplicate:
+		m.report.Created++
+	case MergeActionOverwrite:
+		m.report.Updated++
+	case MergeActionSkip:
+		m.report.Skipped++
+	}
+	m.report.Items = append(m.report.Items, item)
+}
+
+// conflictAction returns the action for an object matching an existing one
+func (m *backupMerge) conflictAction() string {
+	switch m.req.Conflict {
+	case MergeConflictOverwrite:
+		return MergeActionOverwrite
+	case MergeConflictDuplicate:
+		return MergeActionDuplicate
+	}
+	return MergeActionSkip
+}
+
+func (m *backupMerge) credentialRef(id *int64) *int64 {
+	if id == nil {
+		return nil
+	}
+	if mapped, ok := m.credentials[*id]; ok {
+		return &mapped
+	}
+	return nil
+}
+
+func (m *backupMerge) deviceRef(id *int64) *int64 {
+	if id == nil {
+		return nil
+	}
+	if mapped, ok := m.devices[*id]; ok {
+		return &mapped
+	}
+	return nil
+}
+
+func (m *backupMerge) portRef(id *int64) *int64 {
+	if id == nil {
+		return nil
+	}
+	if mapped, ok := m.ports[*id]; ok {
+		return &mapped
+	}
+	return nil
+}
+
+func (m *backupMerge) mergeCredentials() error {
+	type existingCredential struct {
+		id       int64
+		username string
+		password string
+		note     string
+	}
+	existing := make(map[string]*existingCredential)
+	rows, err := m.tx.Query("SELECT id, name, type, username, password, COALESCE(note, '') FROM credentials ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("failed to query credentials: %w", err)
+	}
+	for rows.Next() {
+		var c existingCredential
+		var name, credType string
+		if err := rows.Scan(&c.id, &name, &credType, &c.username, &c.password, &c.note); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan credential: %w", err)
+		}
+		if key := strings.ToLower(name) + "|" + credType; existing[key] == nil {
+			existing[key] = &c
+		}
+	}
+	rows.Close()
+
+	for _, c := range m.backup.Credentials {
+		match := existing[strings.ToLower(c.Name)+"|"+c.Type]
+		if !m.sections[MergeSectionCredentials] {
+			// Devices still refer to matching credentials of this configuration
+			if match != nil {
+				m.credentials[c.ID] = match.id
+			}
+			continue
+		}
+
+		if !models.CredentialType(c.Type).Valid() {
+			return fmt.Errorf("credential %s has invalid type %s", c.Name, c.Type)
+		}
+
+		item := ConfigurationMergeItem{Kind: "credential", Name: c.Name, Action: MergeActionCreate}
+		if match != nil {
+			item.ExistingID = match.id
+			item.Action = m.conflictAction()
+		}
+
+		encUser, err := encryption.EncryptIfNotEmpty(c.Username)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt username: %w", err)
+		}
+		encPass, err := encryption.EncryptIfNotEmpty(c.Password)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt password: %w", err)
+		}
+
+		switch item.Action {
+		case MergeActionSkip:
+			m.credentials[c.ID] = match.id
+		case MergeActionOverwrite:
+			username, err := encryption.DecryptIfNotEmpty(match.username)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt username of credential %s: %w", c.Name, err)
+			}
+			password, err := encryption.DecryptIfNotEmpty(match.password)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt password of credential %s: %w", c.Name, err)
+			}
+			item.Changes, err = audit.Diff(
+				map[string]interface{}{"username": username, "password": password, "note": match.note},
+				map[string]interface{}{"username": c.Username, "password": c.Password, "note": c.Note},
+			)
+			if err != nil {
+				return err
+			}
+			_, err = m.tx.Exec("UPDATE credentials SET username = ?, password = ?, note = ?, updated_at = ? WHERE id = ?",
+				encUser, encPass, c.Note, time.Now(), match.id)
+			if err != nil {
+				return fmt.Errorf("failed to update credential %s: %w", c.Name, err)
+			}
+			m.credentials[c.ID] = match.id
+		default:
+			result, err := m.tx.Exec(`
+				INSERT INTO credentials (name, type, username, password, note, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				c.Name, c.Type, encUser, encPass, c.Note, time.Now(), time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to create credential %s: %w", c.Name, err)
+			}
+			id, err := result.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to get last insert id: %w", err)
+			}
+			m.credentials[c.ID] = id
+		}
+		m.add(item)
+	}
+	return nil
+}
+
+// matchDevice finds the existing device a backup device corresponds to
+func (m *backupMerge) matchDevice(d DeviceExport) *existingDevice {
+	if match := m.devicesByIP[d.IPAddress]; match != nil {
+		return match
+	}
+	return m.devicesByName[strings.ToLower(d.Name)]
+}
+
+func (m *backupMerge) mergeDevices() error {
+	for _, d := range m.backup.Devices {
+		match := m.matchDevice(d)
+		if !m.sections[MergeSectionDevices] {
+			// Schemas and assets still refer to matching devices of this configuration
+			if match != nil {
+				m.devices[d.ID] = match.id
+				if err := m.mapExistingPorts(d.ID, match.id); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if !models.DeviceType(d.Type).Valid() {
+			return fmt.Errorf("device %s has invalid type %s", d.Name, d.Type)
+		}
+
+		item := ConfigurationMergeItem{Kind: "device", Name: fmt.Sprintf("%s (%s)", d.Name, d.IPAddress), Action: MergeActionCreate}
+		if match != nil {
+			item.ExistingID = match.id
+			item.Action = m.conflictAction()
+			if item.Action == MergeActionOverwrite && match.deviceType != d.Type {
+				item.Action = MergeActionSkip
+				item.Reason = fmt.Sprintf("type differs: %s in this configuration, %s in the backup", match.deviceType, d.Type)
+			}
+		}
+
+		switch item.Action {
+		case MergeActionSkip:
+			m.devices[d.ID] = match.id
+			if err := m.mapExistingPorts(d.ID, match.id); err != nil {
+				return err
+			}
+		case MergeActionOverwrite:
+			after := &existingDevice{name: d.Name, ipAddress: d.IPAddress, manufacturer: d.Manufacturer, model: d.Model}
+			changes, err := audit.Diff(match.state(), after.state())
+			if err != nil {
+				return err
+			}
+			item.Changes = changes
+			_, err = m.tx.Exec(`
+				UPDATE devices SET name = ?, ip_address = ?, manufacturer = ?, model = ?, credential_id = ?, updated_at = ?
+				WHERE id = ?`,
+				d.Name, d.IPAddress, d.Manufacturer, d.Model, m.credentialRef(d.CredentialID), time.Now(), match.id)
+			if err != nil {
+				return fmt.Errorf("failed to update device %s: %w", d.Name, err)
+			}
+			m.devices[d.ID] = match.id
+			m.written[d.ID] = true
+			if err := m.saveDeviceDetails(d, match.id); err != nil {
+				return err
+			}
+		default:
+			result, err := m.tx.Exec(`
+				INSERT INTO devices (name, ip_address, type, manufacturer, model, credential_id, status, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, 'unknown', ?, ?)`,
+				d.Name, d.IPAddress, d.Type, d.Manufacturer, d.Model, m.credentialRef(d.CredentialID), time.Now(), time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to create device %s: %w", d.Name, err)
+			}
+			id, err := result.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to get last insert id: %w", err)
+			}
+			m.devices[d.ID] = id
+			m.written[d.ID] = true
+			if err := m.saveDeviceDetails(d, id); err != nil {
+				return err
+			}
+		}
+		m.add(item)
+	}
+	return nil
+}
+
+// mapExistingPorts maps the backup ports of a switch to the ports with the same
+// numbers on the matching switch of this configuration
+func (m *backupMerge) mapExistingPorts(backupID, deviceID int64) error {
+	existing := make(map[int]int64)
+	rows, err := m.tx.Query("SELECT id, port_number FROM switch_ports WHERE switch_id = ?", deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to query switch ports: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var number int
+		if err := rows.Scan(&id, &number); err != nil {
+			return fmt.Errorf("failed to scan switch port: %w", err)
+		}
+		existing[number] = id
+	}
+
+	for _, p := range m.backup.SwitchPorts {
+		if p.SwitchID != backupID {
+			continue
+		}
+		if id, ok := existing[p.PortNumber]; ok {
+			m.ports[p.ID] = id
+		}
+	}
+	return rows.Err()
+}
+
+// saveDeviceDetails creates or updates the type-specific settings and switch
+// ports of a merged device. Links and uplinks are set by mergeLinks.
+func (m *backupMerge) saveDeviceDetails(d DeviceExport, deviceID int64) error {
+	switch models.DeviceType(d.Type) {
+	case models.DeviceTypeSwitch:
+		for _, s := range m.backup.Switches {
+			if s.DeviceID != d.ID {
+				continue
+			}
+			encCommunity, err := encryption.EncryptIfNotEmpty(s.SNMPCommunity)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt SNMP community: %w", err)
+			}
+			encWriteCommunity, err := encryption.EncryptIfNotEmpty(s.SNMPWriteCommunity)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt SNMP write community: %w", err)
+			}
+			encAuthPass, err := encryption.EncryptIfNotEmpty(s.SNMPv3AuthPass)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt SNMPv3 auth password: %w", err)
+			}
+			encPrivPass, err := encryption.EncryptIfNotEmpty(s.SNMPv3PrivPass)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt SNMPv3 priv password: %w", err)
+			}
+			_, err = m.tx.Exec(`
+				INSERT INTO switches (device_id, snmp_community, snmp_write_community, snmp_version, port_count, sfp_port_count,
+					snmpv3_user, snmpv3_security, snmpv3_auth_proto, snmpv3_auth_pass, snmpv3_priv_proto, snmpv3_priv_pass,
+					poe_budget_w)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT(device_id) DO UPDATE SET
+					snmp_community = excluded.snmp_community, snmp_write_community = excluded.snmp_write_community,
+					snmp_version = excluded.snmp_version,
+					port_count = excluded.port_count, sfp_port_count = excluded.sfp_port_count,
+					snmpv3_user = excluded.snmpv3_user, snmpv3_security = excluded.snmpv3_security,
+					snmpv3_auth_proto = excluded.snmpv3_auth_proto, snmpv3_auth_pass = excluded.snmpv3_auth_pass,
+					snmpv3_priv_proto = excluded.snmpv3_priv_proto, snmpv3_priv_pass = excluded.snmpv3_priv_pass,
+					poe_budget_w = excluded.poe_budget_w`,
+				deviceID, encCommunity, encWriteCommunity, s.SNMPVersion, s.PortCount, s.SFPPortCount,
+				s.SNMPv3User, s.SNMPv3Security, s.SNMPv3AuthProto, encAuthPass, s.SNMPv3PrivProto, encPrivPass,
+				s.PoEBudgetW)
+			if err != nil {
+				return fmt.Errorf("failed to save switch %s: %w", d.Name, err)
+			}
+		}
+
+		for _, p := range m.backup.SwitchPorts {
+			if p.SwitchID != d.ID {
+				continue
+			}
+			_, err := m.tx.Exec(`
+				INSERT INTO switch_ports (switch_id, port_number, name, status, port_type)
+				VALUES (?, ?, ?, 'unknown', ?)
+				ON CONFLICT(switch_id, port_number) DO UPDATE SET name = excluded.name, port_type = excluded.port_type`,
+				deviceID, p.PortNumber, p.Name, p.PortType)
+			if err != nil {
+				return fmt.Errorf("failed to save port %d of %s: %w", p.PortNumber, d.Name, err)
+			}
+			var id int64
+			err = m.tx.QueryRow("SELECT id FROM switch_ports WHERE switch_id = ? AND port_number = ?",
+				deviceID, p.PortNumber).Scan(&id)
+			if err != nil {
+				return fmt.Errorf("failed to get port %d of %s: %w", p.PortNumber, d.Name, err)
+			}
+			m.ports[p.ID] = id
+		}
+
+	case models.DeviceTypeCamera:
+		for _, c := range m.backup.Cameras {
+			if c.DeviceID != d.ID {
+				continue
+			}
+			encRTSP, err := encryption.EncryptIfNotEmpty(c.RTSPURL)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt RTSP URL: %w", err)
+			}
+			_, err = m.tx.Exec(`
+				INSERT INTO cameras (device_id, rtsp_url, onvif_port, snapshot_url, stream_type)
+				VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT(device_id) DO UPDATE SET
+					rtsp_url = excluded.rtsp_url, onvif_port = excluded.onvif_port,
+					snapshot_url = excluded.snapshot_url, stream_type = excluded.stream_type`,
+				deviceID, encRTSP, c.ONVIFPort, c.SnapshotURL, c.StreamType)
+			if err != nil {
+				return fmt.Errorf("failed to save camera %s: %w", d.Name, err)
+			}
+		}
+
+	case models.DeviceTypeServer:
+		for _, s := range m.backup.Servers {
+			if s.DeviceID != d.ID {
+				continue
+			}
+			_, err := m.tx.Exec(`
+				INSERT INTO servers (device_id, tcp_ports, use_snmp) VALUES (?, ?, ?)
+				ON CONFLICT(device_id) DO UPDATE SET tcp_ports = excluded.tcp_ports, use_snmp = excluded.use_snmp`,
+				deviceID, s.TCPPorts, s.UseSNMP)
+			if err != nil {
+				return fmt.Errorf("failed to save server %s: %w", d.Name, err)
+			}
+		}
+
+	case models.DeviceTypeRouter, models.DeviceTypeAccessPoint, models.DeviceTypeUPS,
+		models.DeviceTypeNVR, models.DeviceTypePDU:
+		for _, s := range m.backup.SNMPDevices {
+			if s.DeviceID != d.ID {
+				continue
+			}
+			var enc [3]string
+			for i, value := range []string{s.SNMPCommunity, s.SNMPv3AuthPass, s.SNMPv3PrivPass} {
+				var err error
+				if enc[i], err = encryption.EncryptIfNotEmpty(value); err != nil {
+					return fmt.Errorf("failed to encrypt SNMP settings: %w", err)
+				}
+			}
+			_, err := m.tx.Exec(`
+				INSERT INTO snmp_devices (device_id, snmp_community, snmp_version, snmpv3_user, snmpv3_security,
+					snmpv3_auth_proto, snmpv3_auth_pass, snmpv3_priv_proto, snmpv3_priv_pass)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT(device_id) DO UPDATE SET
+					snmp_community = excluded.snmp_community, snmp_version = excluded.snmp_version,
+					snmpv3_user = excluded.snmpv3_user, snmpv3_security = excluded.snmpv3_security,
+					snmpv3_auth_proto = excluded.snmpv3_auth_proto, snmpv3_auth_pass = excluded.snmpv3_auth_pass,
+					snmpv3_priv_proto = excluded.snmpv3_priv_proto, snmpv3_priv_pass = excluded.snmpv3_priv_pass`,
+				deviceID, enc[0], s.SNMPVersion, s.SNMPv3User, s.SNMPv3Security,
+				s.SNMPv3AuthProto, enc[1], s.SNMPv3PrivProto, enc[2])
+			if err != nil {
+				return fmt.Errorf("failed to save SNMP settings of %s: %w", d.Name, err)
+			}
+		}
+		for _, n := range m.backup.NVRs {
+			if n.DeviceID != d.ID {
+				continue
+			}
+			_, err := m.tx.Exec(`
+				INSERT INTO nvrs (device_id, check_http, http_port) VALUES (?, ?, ?)
+				ON CONFLICT(device_id) DO UPDATE SET check_http = excluded.check_http, http_port = excluded.http_port`,
+				deviceID, n.CheckHTTP, n.HTTPPort)
+			if err != nil {
+				return fmt.Errorf("failed to save NVR %s: %w", d.Name, err)
+			}
+		}
+		for _, u := range m.backup.UPSDevices {
+			if u.DeviceID != d.ID {
+				continue
+			}
+			_, err := m.tx.Exec(`
+				INSERT INTO ups_devices (device_id, protocol, nut_port, nut_ups_name) VALUES (?, ?, ?, ?)
+				ON CONFLICT(device_id) DO UPDATE SET protocol = excluded.protocol,
+					nut_port = excluded.nut_port, nut_ups_name = excluded.nut_ups_name`,
+				deviceID, u.Protocol, u.NUTPort, u.NUTUPSName)
+			if err != nil {
+				return fmt.Errorf("failed to save UPS %s: %w", d.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeLinks sets uplinks and port links of merged devices once all devices and
+// ports have their new IDs. Links to devices that are not part of the merge are
+// cleared. Ports of switches kept unchanged only receive links to merged devices
+// if they have no link yet.
+func (m *backupMerge) mergeLinks() error {
+	for _, s := range m.backup.Switches {
+		if !m.written[s.DeviceID] {
+			continue
+		}
+		_, err := m.tx.Exec("UPDATE switches SET uplink_switch_id = ?, uplink_port_id = ? WHERE device_id = ?",
+			m.deviceRef(s.UplinkSwitchID), m.portRef(s.UplinkPortID), m.devices[s.DeviceID])
+		if err != nil {
+			return fmt.Errorf("failed to set uplink of switch: %w", err)
+		}
+	}
+	for _, s := range m.backup.Servers {
+		if !m.written[s.DeviceID] {
+			continue
+		}
+		_, err := m.tx.Exec("UPDATE servers SET uplink_switch_id = ?, uplink_port_id = ? WHERE device_id = ?",
+			m.deviceRef(s.UplinkSwitchID), m.portRef(s.UplinkPortID), m.devices[s.DeviceID])
+		if err != nil {
+			return fmt.Errorf("failed to set uplink of server: %w", err)
+		}
+	}
+	for _, s := range m.backup.SNMPDevices {
+		if !m.written[s.DeviceID] {
+			continue
+		}
+		_, err := m.tx.Exec("UPDATE snmp_devices SET uplink_switch_id = ?, uplink_port_id = ? WHERE device_id = ?",
+			m.deviceRef(s.UplinkSwitchID), m.portRef(s.UplinkPortID), m.devices[s.DeviceID])
+		if err != nil {
+			return fmt.Errorf("failed to set uplink of device: %w", err)
+		}
+	}
+
+	for _, p := range m.backup.SwitchPorts {
+		portID, ok := m.ports[p.ID]
+		if !ok {
+			continue
+		}
+		camera, sw := m.deviceRef(p.LinkedCameraID), m.deviceRef(p.LinkedSwitchID)
+
+		var err error
+		if m.written[p.SwitchID] {
+			_, err = m.tx.Exec("UPDATE switch_ports SET linked_camera_id = ?, linked_switch_id = ? WHERE id = ?",
+				camera, sw, portID)
+		} else if p.LinkedCameraID != nil && m.written[*p.LinkedCameraID] {
+			_, err = m.tx.Exec("UPDATE switch_ports SET linked_camera_id = ? WHERE id = ? AND linked_camera_id IS NULL",
+				camera, portID)
+		} else if p.LinkedSwitchID != nil && m.written[*p.LinkedSwitchID] {
+			_, err = m.tx.Exec("UPDATE switch_ports SET linked_switch_id = ? WHERE id = ? AND linked_switch_id IS NULL",
+				sw, portID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to link port: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *backupMerge) mergeSchemas() error {
+	if !m.sections[MergeSectionSchemas] {
+		return nil
+	}
+
+	existing := make(map[string]int64)
+	rows, err := m.tx.Query("SELECT id, name FROM schemas ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("failed to query schemas: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan schema: %w", err)
+		}
+		if _, ok := existing[strings.ToLower(name)]; !ok {
+			existing[strings.ToLower(name)] = id
+		}
+	}
+	rows.Close()
+
+	for _, s := range m.backup.Schemas {
+		if len(m.req.SchemaIDs) > 0 && !slices.Contains(m.req.SchemaIDs, s.ID) {
+			continue
+		}
+
+		item := ConfigurationMergeItem{Kind: "schema", Name: s.Name, Action: MergeActionCreate}
+		matchID, matched := existing[strings.ToLower(s.Name)]
+		if matched {
+			item.ExistingID = matchID
+			item.Action = m.conflictAction()
+		}
+
+		var schemaID int64
+		switch item.Action {
+		case MergeActionSkip:
+			m.add(item)
+			continue
+		case MergeActionOverwrite:
+			if _, err := m.tx.Exec("UPDATE schemas SET background_image = ? WHERE id = ?", s.BackgroundImage, matchID); err != nil {
+				return fmt.Errorf("failed to update schema %s: %w", s.Name, err)
+			}
+			if _, err := m.tx.Exec("DELETE FROM schema_items WHERE schema_id = ?", matchID); err != nil {
+				return fmt.Errorf("failed to clear schema %s: %w", s.Name, err)
+			}
+			schemaID = matchID
+		default:
+			result, err := m.tx.Exec("INSERT INTO schemas (name, background_image, created_at) VALUES (?, ?, ?)",
+				s.Name, s.BackgroundImage, time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to create schema %s: %w", s.Name, err)
+			}
+			if schemaID, err = result.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to get last insert id: %w", err)
+			}
+		}
+
+		missing := 0
+		for _, i := range m.backup.SchemaItems {
+			if i.SchemaID != s.ID {
+				continue
+			}
+			deviceID, ok := m.devices[i.DeviceID]
+			if !ok {
+				missing++
+				continue
+			}
+			_, err := m.tx.Exec(`
+				INSERT INTO schema_items (device_id, schema_id, x, y, width, height)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT(device_id, schema_id) DO UPDATE SET
+					x = excluded.x, y = excluded.y, width = excluded.width, height = excluded.height`,
+				deviceID, schemaID, i.X, i.Y, i.Width, i.Height)
+			if err != nil {
+				return fmt.Errorf("failed to add device to schema %s: %w", s.Name, err)
+			}
+		}
+		if missing > 0 {
+			item.Reason = fmt.Sprintf("%d devices not found in this configuration were left out", missing)
+		}
+		m.add(item)
+	}
+	return nil
+}
+
+func (m *backupMerge) mergeAssets() error {
+	if !m.sections[MergeSectionAssets] {
+		return nil
+	}
+
+	repo := database.NewAssetRepository(m.tx)
+	for _, f := range m.backup.AssetFields {
+		item := ConfigurationMergeItem{Kind: "asset_field", Name: f.Label, Action: MergeActionCreate}
+		existing, err := repo.GetFieldByKey(f.Key)
+		if err != nil {
+			return err
+		}
+		field := &models.AssetField{
+			Key:        f.Key,
+			Label:      f.Label,
+			DeviceType: models.DeviceType(f.DeviceType),
+			FieldType:  models.AssetFieldType(f.FieldType),
+			Position:   f.Position,
+		}
+
+		switch {
+		case existing == nil:
+			if err := repo.CreateField(field); err != nil {
+				return err
+			}
+		case m.req.Conflict == MergeConflictOverwrite:
+			item.ExistingID = existing.ID
+			item.Action = MergeActionOverwrite
+			changes, err := audit.Diff(
+				map[string]interface{}{"label": existing.Label, "field_type": existing.FieldType, "position": existing.Position},
+				map[string]interface{}{"label": field.Label, "field_type": field.FieldType, "position": field.Position},
+			)
+			if err != nil {
+				return err
+			}
+			item.Changes = changes
+			field.ID = existing.ID
+			if err := repo.UpdateField(field); err != nil {
+				return err
+			}
+		default:
+			// Field keys are unique, so a field is never duplicated
+			item.ExistingID = existing.ID
+			item.Action = MergeActionSkip
+		}
+		m.add(item)
+	}
+
+	// Asset data goes to devices written by this merge, and to matched devices only
+	// when overwriting
+	for _, asset := range m.backup.Assets {
+		deviceID, ok := m.devices[asset.DeviceID]
+		if !ok || !m.written[asset.DeviceID] && m.req.Conflict != MergeConflictOverwrite {
+			continue
+		}
+		err := repo.Save(&models.DeviceAsset{
+			DeviceID:     deviceID,
+			SerialNumber: asset.SerialNumber,
+			MACAddress:   asset.MACAddress,
+			InstallDate:  asset.InstallDate,
+			WarrantyEnd:  asset.WarrantyEnd,
+			Room:         asset.Room,
+			Rack:         asset.Rack,
+			RackUnit:     asset.RackUnit,
+			Notes:        asset.Notes,
+			Custom:       asset.Custom,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *backupMerge) mergeSettings() error {
+	if !m.sections[MergeSectionSettings] {
+		return nil
+	}
+
+	repo := database.NewSettingsRepository(m.tx)
+	current, err := repo.GetAll()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(m.backup.Settings))
+	for key := range m.backup.Settings {
+		if isPortableSetting(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := m.backup.Settings[key]
+		old, exists := current[key]
+		secret := slices.Contains(secretSettingKeys, key)
+		if exists && secret {
+			// Backups hold secrets in clear, compare with the decrypted value
+			if old, err = encryption.DecryptIfNotEmpty(old); err != nil {
+				return fmt.Errorf("failed to decrypt setting %s: %w", key, err)
+			}
+		}
+		if exists && old == value {
+			continue
+		}
+
+		item := ConfigurationMergeItem{Kind: "setting", Name: key, Action: MergeActionCreate}
+		if exists {
+			// A setting cannot be duplicated, so only overwrite replaces it
+			item.Action = MergeActionSkip
+			if m.req.Conflict == MergeConflictOverwrite {
+				item.Action = MergeActionOverwrite
+			}
+			changes, err := audit.Diff(map[string]interface{}{key: old}, map[string]interface{}{key: value})
+			if err != nil {
+				return err
+			}
+			item.Changes = changes
+		}
+		if item.Action != MergeActionSkip {
+			if secret {
+				if value, err = encryption.EncryptIfNotEmpty(value); err != nil {
+					return fmt.Errorf("failed to encrypt setting %s: %w", key, err)
+				}
+			}
+			if err := repo.Set(key, value); err != nil {
+				return err
+			}
+		}
+		m.add(item)
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/models"
+)
+
+// configTables lists the tables of the configuration, they are always in backups.
+// Tables exported with history are listed in historyTables.
+var configTables = []string{
+	"credentials", "sites", "device_groups", "devices", "device_tags",
+	"switches", "switch_ports", "cameras", "servers", "snmp_devices", "nvrs", "ups_devices",
+	"schemas", "schema_items",
+	"asset_fields", "device_assets", "asset_field_values",
+	"compliance_baselines", "maintenance_windows",
+	"oncall_schedules", "oncall_overrides", "escalation_policies",
+	"settings",
+}
+
+// excludedTables lists the tables that are never in backups, with the reason
+var excludedTables = map[string]string{
+	"audit_log":         "the hash chain is anchored to this database",
+	"users":             "accounts and password hashes belong to this installation",
+	"schema_migrations": "describes the schema of this database",
+}
+
+// BackupVerifyReport is the result of VerifyConfigurationBackup
+type BackupVerifyReport struct {
+	OK       bool     `json:"ok"`
+	Tables   int      `json:"tables"` // Tables compared row by row
+	Rows     int      `json:"rows"`
+	Problems []string `json:"problems"`
+}
+
+// VerifyConfigurationBackup checks that a backup restores the data without loss.
+// The current data is exported, imported into a scratch database and exported
+// again. Both exports and the rows of every exported table must be identical, and
+// every table of the database must be either exported or explicitly excluded.
+func (a *App) VerifyConfigurationBackup(includeHistory bool) (*BackupVerifyReport, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return verifyBackupRoundTrip(a.db.DB(), includeHistory)
+}
+
+// verifyBackupRoundTrip runs export, import and export again against a scratch
+// database and compares the results with the source
+func verifyBackupRoundTrip(src database.Querier, includeHistory bool) (*BackupVerifyReport, error) {
+	report := &BackupVerifyReport{Problems: []string{}}
+
+	first, err := collectBackupData(src, includeHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect backup data: %w", err)
+	}
+	firstJSON, err := json.MarshalIndent(first, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup data: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "netvision-verify-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	scratch, err := database.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch database: %w", err)
+	}
+	defer scratch.Close()
+
+	restored, err := parseBackup(firstJSON)
+	if err != nil {
+		return nil, err
+	}
+	err = scratch.WithTx(func(tx *sql.Tx) error {
+		return importBackupData(tx, restored)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import backup: %w", err)
+	}
+
+	second, err := collectBackupData(scratch.DB(), includeHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect restored data: %w", err)
+	}
+	second.ExportDate = first.ExportDate
+	secondJSON, err := json.MarshalIndent(second, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal restored data: %w", err)
+	}
+	if !bytes.Equal(firstJSON, secondJSON) {
+		report.Problems = append(report.Problems, diffBackupSections(firstJSON, secondJSON)...)
+	}
+
+	tables, err := databaseTables(src)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		switch {
+		case slices.Contains(configTables, table):
+		case slices.Contains(historyTables, table):
+			if !includeHistory {
+				continue
+			}
+		case excludedTables[table] != "":
+			continue
+		default:
+			report.Problems = append(report.Problems, fmt.Sprintf("table %s is not covered by backups", table))
+			continue
+		}
+
+		rows, problems, err := compareTableRows(src, scratch.DB(), table)
+		if err != nil {
+			return nil, err
+		}
+		report.Tables++
+		report.Rows += rows
+		report.Problems = append(report.Problems, problems...)
+	}
+
+	report.OK = len(report.Problems) == 0
+	return report, nil
+}
+
+// diffBackupSections names the top level sections that differ between two backups
+func diffBackupSections(a, b []byte) []string {
+	var first, second map[string]json.RawMessage
+	if json.Unmarshal(a, &first) != nil || json.Unmarshal(b, &second) != nil {
+		return []string{"backup differs after restore"}
+	}
+
+	keys := make(map[string]bool)
+	for key := range first {
+		keys[key] = true
+	}
+	for key := range second {
+		keys[key] = true
+	}
+
+	var problems []string
+	for key := range keys {
+		if !bytes.Equal(first[key], second[key]) {
+			problems = append(problems, fmt.Sprintf("backup section %s differs after restore", key))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// databaseTables returns the names of all tables of a database
+func databaseTables(q database.Querier) ([]string, error) {
+	rows, err := q.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// compareTableRows compares all rows of a table in two databases, ignoring row
+// order. Encrypted values are compared decrypted, settings tied to the database
+// are skipped. Returns the number of source rows.
+func compareTableRows(src, dst database.Querier, table string) (int, []string, error) {
+	srcRows, columns, err := tableRowKeys(src, table, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	dstColumns, err := tableColumns(dst, table)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var problems []string
+	var common []string
+	for _, column := range columns {
+		if _, ok := dstColumns[column]; ok {
+			common = append(common, column)
+		} else {
+			problems = append(problems, fmt.Sprintf("table %s: column %s is missing after restore", table, column))
+		}
+	}
+	if len(problems) > 0 {
+		srcRows, _, err = tableRowKeys(src, table, common)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	dstRows, _, err := tableRowKeys(dst, table, common)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Both lists are sorted, so a merge walk finds the rows present on one side only
+	var missing, extra []string
+	i, j := 0, 0
+	for i < len(srcRows) || j < len(dstRows) {
+		switch {
+		case j == len(dstRows) || i < len(srcRows) && srcRows[i] < dstRows[j]:
+			missing = append(missing, srcRows[i])
+			i++
+		case i == len(srcRows) || dstRows[j] < srcRows[i]:
+			extra = append(extra, dstRows[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("table %s: %d rows not restored, e.g. %s", table, len(missing), missing[0]))
+	}
+	if len(extra) > 0 {
+		problems = append(problems, fmt.Sprintf("table %s: %d unexpected rows after restore, e.g. %s", table, len(extra), extra[0]))
+	}
+	return len(srcRows), problems, nil
+}
+
+// tableRowKeys returns every row of a table as comparable text, sorted. Only the
+// given columns are used, all columns if nil. Also returns the column names.
+func tableRowKeys(q database.Querier, table string, columns []string) ([]string, []string, error) {
+	selected := "*"
+	if columns != nil {
+		selected = strings.Join(columns, ", ")
+	}
+	rows, err := q.Query("SELECT " + selected + " FROM " + table)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	if columns, err = rows.Columns(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get columns of %s: %w", table, err)
+	}
+
+	var keys []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+
+		parts := make([]string, len(columns))
+		for i, v := range values {
+			parts[i] = columns[i] + "=" + comparableValue(table, columns[i], v)
+		}
+		if table == "settings" && !isPortableSetting(fmt.Sprint(values[0])) {
+			continue
+		}
+		keys = append(keys, strings.Join(parts, "; "))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	sort.Strings(keys)
+	return keys, columns, nil
+}
+
+// comparableValue formats a column value so that equal values of two databases
+// give the same text
+func comparableValue(table, column string, v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "NULL"
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		v = string(value)
+	}
+	if s, ok := v.(string); ok && s != "" && database.IsSecretColumn(table, column) {
+		// Compared by hash, so problem messages do not reveal secrets
+		plain, err := encryption.Decrypt(s)
+		if err != nil {
+			return "<undecryptable>"
+		}
+		sum := sha256.Sum256([]byte(plain))
+		return "secret:" + hex.EncodeToString(sum[:8])
+	}
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
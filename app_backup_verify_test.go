@@ -0,0 +1,161 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/models"
+)
+
+// openTestDatabase opens a migrated database in a temporary directory
+func openTestDatabase(t *testing.T) *database.Database {
+	t.Helper()
+	db, err := database.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// seedConfiguration fills every configuration table with at least one row
+func seedConfiguration(t *testing.T, q database.Querier) {
+	t.Helper()
+	exec := func(query string, args ...interface{}) {
+		t.Helper()
+		if _, err := q.Exec(query, args...); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	create := func(input DeviceInput) *models.Device {
+		t.Helper()
+		device, err := createDevice(q, input)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", input.Name, err)
+		}
+		return device
+	}
+
+	cred := &models.Credential{Name: "cameras", Type: "rtsp", Username: "viewer", Password: "secret"}
+	if err := database.NewCredentialRepository(q).Create(cred); err != nil {
+		t.Fatal(err)
+	}
+	exec("INSERT INTO sites (name, latitude, longitude) VALUES ('HQ', 55.75, 37.62)")
+	exec("INSERT INTO device_groups (name, settings) VALUES ('Cameras', '{\"monitoring_interval\":60}')")
+
+	sw := create(DeviceInput{Name: "sw1", IPAddress: "10.0.0.1", Type: "switch", PortCount: 4, SFPPortCount: 1, SNMPCommunity: "public"})
+	ports, err := database.NewSwitchRepository(q).GetPorts(sw.ID)
+	if err != nil || len(ports) < 4 {
+		t.Fatalf("switch ports: %v", err)
+	}
+	cam := create(DeviceInput{Name: "cam1", IPAddress: "10.0.0.2", Type: "camera", CredentialID: &cred.ID,
+		SwitchPortID: &ports[0].ID, RTSPURL: "rtsp://10.0.0.2/stream"})
+	create(DeviceInput{Name: "srv1", IPAddress: "10.0.0.3", Type: "server", TCPPorts: "[22,80]",
+		UplinkSwitchID: &sw.ID, UplinkPortID: &ports[1].ID})
+	create(DeviceInput{Name: "router1", IPAddress: "10.0.0.4", Type: "router", SNMPVersion: "v3",
+		SNMPv3User: "monitor", SNMPv3Security: "authPriv", SNMPv3AuthProto: "SHA", SNMPv3AuthPass: "authpass",
+		SNMPv3PrivProto: "AES", SNMPv3PrivPass: "privpass"})
+	create(DeviceInput{Name: "nvr1", IPAddress: "10.0.0.5", Type: "nvr", CheckHTTP: true, HTTPPort: 8080, SNMPCommunity: "public"})
+	create(DeviceInput{Name: "ups1", IPAddress: "10.0.0.6", Type: "ups", UPSProtocol: "nut", NUTPort: 3493, NUTUPSName: "rack"})
+
+	exec("UPDATE devices SET site_id = 1, group_id = 1 WHERE id = ?", cam.ID)
+	exec("INSERT INTO device_tags (device_id, tag) VALUES (?, 'lobby')", cam.ID)
+	exec("UPDATE switch_ports SET status = 'up', speed = '1G', duplex = 'full' WHERE id = ?", ports[0].ID)
+	exec("INSERT INTO schemas (name) VALUES ('Floor 1')")
+	exec("INSERT INTO schema_items (device_id, schema_id, x, y) VALUES (?, 1, 10, 20)", cam.ID)
+	exec("INSERT INTO asset_fields (key, label) VALUES ('rack', 'Rack')")
+	if err := database.NewAssetRepository(q).Save(&models.DeviceAsset{DeviceID: cam.ID, SerialNumber: "SN-1",
+		Custom: map[string]string{"rack": "A1"}}); err != nil {
+		t.Fatal(err)
+	}
+	exec("INSERT INTO compliance_baselines (name, device_type, rules) VALUES ('Cameras', 'camera', '[]')")
+	exec("INSERT INTO maintenance_windows (name, start_at, end_at) VALUES ('Upgrade', ?, ?)",
+		time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 4, 0, 0, 0, time.UTC))
+	exec("INSERT INTO oncall_schedules (name, rotation_start, members) VALUES ('Duty', ?, '[]')",
+		time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC))
+	exec("INSERT INTO oncall_overrides (schedule_id, name, start_at, end_at) VALUES (1, 'Holiday', ?, ?)",
+		time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC))
+	exec("INSERT INTO escalation_policies (name, device_id, steps) VALUES ('Camera down', ?, '[]')", cam.ID)
+	exec("INSERT INTO settings (key, value) VALUES ('theme', 'dark')")
+	smtpPassword, err := encryption.EncryptIfNotEmpty("smtp-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec("INSERT INTO settings (key, value) VALUES (?, ?)", settingsKeySMTPPassword, smtpPassword)
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	if err := encryption.Initialize(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	src := openTestDatabase(t)
+	seedConfiguration(t, src.DB())
+
+	for _, table := range configTables {
+		var count int
+		if err := src.DB().QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %q", table)).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count == 0 {
+			t.Errorf("table %s is not seeded", table)
+		}
+	}
+
+	first, err := collectBackupData(src.DB(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restore on another installation with its own master key
+	if err := encryption.Initialize(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	dst := openTestDatabase(t)
+	err = dst.WithTx(func(tx *sql.Tx) error {
+		return importBackupData(tx, first)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := collectBackupData(dst.DB(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.ExportDate = first.ExportDate
+
+	stored, err := database.NewSettingsRepository(dst.DB()).Get(settingsKeySMTPPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password, err := encryption.DecryptIfNotEmpty(stored); err != nil || password != "smtp-secret" {
+		t.Errorf("SMTP password after restore: %q, %v", password, err)
+	}
+
+	if !reflect.DeepEqual(first, second) {
+		firstJSON, _ := json.MarshalIndent(first, "", "  ")
+		secondJSON, _ := json.MarshalIndent(second, "", "  ")
+		t.Errorf("restored backup differs: %v", diffBackupSections(firstJSON, secondJSON))
+	}
+}
+
+func TestBackupCoversAllTables(t *testing.T) {
+	db := openTestDatabase(t)
+
+	tables, err := databaseTables(db.DB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range tables {
+		if !slices.Contains(configTables, table) && !slices.Contains(historyTables, table) && excludedTables[table] == "" {
+			t.Errorf("table %s is neither in configTables, historyTables nor excludedTables", table)
+		}
+	}
+}
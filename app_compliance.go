@@ -0,0 +1,607 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"netvisionmonitor/internal/compliance"
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/onvif"
+	"netvisionmonitor/internal/snmp"
+)
+
+// complianceReportsToKeep limits the number of stored compliance reports
+const complianceReportsToKeep = 100
+
+// ComplianceCheckInfo describes a check available for baseline rules
+type ComplianceCheckInfo struct {
+	Check       string            `json:"check"`
+	DeviceType  models.DeviceType `json:"device_type"`
+	Description string            `json:"description"`
+}
+
+// GetComplianceChecks returns checks that can be used in baseline rules
+func (a *App) GetComplianceChecks() ([]ComplianceCheckInfo, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	camera, sw := models.DeviceTypeCamera, models.DeviceTypeSwitch
+	return []ComplianceCheckInfo{
+		{models.ComplianceCheckManufacturer, "", "Manufacturer"},
+		{models.ComplianceCheckModel, "", "Model"},
+		{models.ComplianceCheckFirmware, camera, "Firmware version"},
+		{models.ComplianceCheckMainEncoding, camera, "Main stream codec (H264, H265)"},
+		{models.ComplianceCheckMainBitrate, camera, "Main stream bitrate, kbps"},
+		{models.ComplianceCheckMainFrameRate, camera, "Main stream frame rate"},
+		{models.ComplianceCheckMainResolution, camera, "Main stream resolution (1920x1080)"},
+		{models.ComplianceCheckMainGovLength, camera, "Main stream GOP length"},
+		{models.ComplianceCheckSubEncoding, camera, "Substream codec"},
+		{models.ComplianceCheckSubBitrate, camera, "Substream bitrate, kbps"},
+		{models.ComplianceCheckSubFrameRate, camera, "Substream frame rate"},
+		{models.ComplianceCheckSubResolution, camera, "Substream resolution"},
+		{models.ComplianceCheckNTPServer, camera, "NTP server"},
+		{models.ComplianceCheckNTPFromDHCP, camera, "NTP from DHCP (true/false)"},
+		{models.ComplianceCheckDateTimeType, camera, "Time mode (NTP, Manual)"},
+		{models.ComplianceCheckClockDrift, camera, "Clock drift, seconds"},
+		{models.ComplianceCheckFirmware, sw, "Firmware version"},
+		{models.ComplianceCheckSNMPVersion, sw, "SNMP version (v1, v2c, v3)"},
+		{models.ComplianceCheckAutoRestartMode, sw, "AutoRestart mode on copper ports (disabled, always, link, ping, speed)"},
+		{models.ComplianceCheckAutoRestartPing, sw, "AutoRestart ping IP on copper ports"},
+		{models.ComplianceCheckUplinkConfigured, sw, "Uplink configured (true/false)"},
+	}, nil
+}
+
+// GetComplianceBaselines returns all compliance baselines
+func (a *App) GetComplianceBaselines() ([]models.ComplianceBaseline, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewComplianceRepository(a.db.DB())
+	return repo.GetAllBaselines()
+}
+
+// CreateComplianceBaseline creates a new compliance baseline
+func (a *App) CreateComplianceBaseline(baseline models.ComplianceBaseline) (_ *models.ComplianceBaseline, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("compliance.baseline_create", models.AuditTarget{Type: "compliance_baseline", Name: baseline.Name}, nil)
+	defer func() { rec.finish(err) }()
+
+	if err := validateComplianceBaseline(&baseline); err != nil {
+		return nil, err
+	}
+
+	repo := database.NewComplianceRepository(a.db.DB())
+	if err := repo.CreateBaseline(&baseline); err != nil {
+		return nil, err
+	}
+	rec.track(baseline.ID, baseline.Name, a.rowAuditState("compliance_baselines", "id", baseline.ID))
+
+	log.Printf("Compliance baseline created: %s (%s, %d rules)", baseline.Name, baseline.DeviceType, len(baseline.Rules))
+	return &baseline, nil
+}
+
+// UpdateComplianceBaseline updates an existing compliance baseline
+func (a *App) UpdateComplianceBaseline(baseline models.ComplianceBaseline) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("compliance.baseline_update", auditTarget("compliance_baseline", baseline.ID, baseline.Name), a.rowAuditState("compliance_baselines", "id", baseline.ID))
+	defer func() { rec.finish(err) }()
+
+	if err := validateComplianceBaseline(&baseline); err != nil {
+		return err
+	}
+
+	repo := database.NewComplianceRepository(a.db.DB())
+	existing, err := repo.GetBaselineByID(baseline.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("baseline not found")
+	}
+
+	return repo.UpdateBaseline(&baseline)
+}
+
+// DeleteComplianceBaseline deletes a compliance baseline
+func (a *App) DeleteComplianceBaseline(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("compliance.baseline_delete", auditTarget("compliance_baseline", id, ""), a.rowAuditState("compliance_baselines", "id", id))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewComplianceRepository(a.db.DB())
+	return repo.DeleteBaseline(id)
+}
+
+// GetComplianceReports returns recent compliance report summaries
+func (a *App) GetComplianceReports(limit int) ([]models.ComplianceReport, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewComplianceRepository(a.db.DB())
+	return repo.GetReports(limit)
+}
+
+// GetComplianceReport returns a full compliance report
+func (a *App) GetComplianceReport(id int64) (*models.ComplianceReport, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewComplianceRepository(a.db.DB())
+	return repo.GetReportByID(id)
+}
+
+// GetLatestComplianceReport returns the most recent compliance report
+func (a *App) GetLatestComplianceReport() (*models.ComplianceReport, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewComplianceRepository(a.db.DB())
+	return repo.GetLatestReport()
+}
+
+// RunComplianceAudit audits all devices against enabled baselines,
+// stores the report and raises events for rules that changed state
+func (a *App) RunComplianceAudit() (*models.ComplianceReport, error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return nil, err
+	}
+	return a.runComplianceAudit()
+}
+
+// runComplianceAudit is RunComplianceAudit without the permission check, for background tasks
+func (a *App) runComplianceAudit() (*models.ComplianceReport, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	if !a.complianceMu.TryLock() {
+		return nil, fmt.Errorf("compliance audit already running")
+	}
+	defer a.complianceMu.Unlock()
+
+	repo := database.NewComplianceRepository(a.db.DB())
+	baselines, err := repo.GetAllBaselines()
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[models.DeviceType][]models.ComplianceBaseline)
+	for _, b := range baselines {
+		if b.Enabled && len(b.Rules) > 0 {
+			byType[b.DeviceType] = append(byType[b.DeviceType], b)
+		}
+	}
+
+	deviceRepo := database.NewDeviceRepository(a.db.DB())
+	devices, err := deviceRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+
+	report := &models.ComplianceReport{StartedAt: time.Now()}
+
+	type job struct {
+		device    models.Device
+		baselines []models.ComplianceBaseline
+	}
+	var jobs []job
+	for _, d := range devices {
+		if bs := byType[d.Type]; len(bs) > 0 {
+			jobs = append(jobs, job{device: d, baselines: bs})
+		}
+	}
+
+	results := make([][]models.DeviceComplianceResult, len(jobs))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentCameraChecks)
+
+	for i, j := range jobs {
+		wg.Add(1)
+		go func(i int, j job) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = a.auditDevice(j.device, j.baselines)
+		}(i, j)
+	}
+	wg.Wait()
+
+	for _, deviceResults := range results {
+		for _, r := range deviceResults {
+			report.Results = append(report.Results, r)
+			report.Total++
+			switch {
+			case r.Error != "":
+				report.Errors++
+			case r.Passed:
+				report.Passed++
+			default:
+				report.Failed++
+			}
+			a.recordComplianceDrift(repo, r)
+		}
+	}
+
+	report.FinishedAt = time.Now()
+
+	if err := repo.SaveReport(report); err != nil {
+		return nil, err
+	}
+	repo.DeleteOldReports(complianceReportsToKeep)
+
+	log.Printf("Compliance audit: %d checks, %d passed, %d failed, %d errors",
+		report.Total, report.Passed, report.Failed, report.Errors)
+	return report, nil
+}
+
+// recordComplianceDrift stores rule states and creates events on pass/fail transitions
+func (a *App) recordComplianceDrift(repo *database.ComplianceRepository, result models.DeviceComplianceResult) {
+	if result.Error != "" {
+		return
+	}
+
+	deviceID := result.DeviceID
+	for _, rule := range result.Rules {
+		if rule.Error != "" && rule.Actual == "" {
+			continue // Value not collected, state unknown
+		}
+
+		key := ruleKey(rule.ComplianceRule)
+		previous, err := repo.UpdateRuleState(result.DeviceID, result.BaselineID, key, rule.Passed, rule.Actual)
+		if err != nil {
+			log.Printf("Failed to update compliance state for device %d: %v", result.DeviceID, err)
+			continue
+		}
+
+		switch {
+		case !rule.Passed && (previous == nil || *previous):
+			a.onMonitoringEvent(&models.Event{
+				DeviceID: &deviceID,
+				Type:     models.EventTypeComplianceDrift,
+				Level:    models.EventLevelWarn,
+				Message: fmt.Sprintf("%s violates baseline '%s': %s %s %s (actual: %s)",
+					result.DeviceName, result.BaselineName, rule.Check, rule.Operator, rule.Value, rule.Actual),
+			})
+		case rule.Passed && previous != nil && !*previous:
+			a.onMonitoringEvent(&models.Event{
+				DeviceID: &deviceID,
+				Type:     models.EventTypeComplianceOK,
+				Level:    models.EventLevelInfo,
+				Message: fmt.Sprintf("%s complies with baseline '%s' again: %s",
+					result.DeviceName, result.BaselineName, rule.Check),
+			})
+		}
+	}
+}
+
+// auditDevice collects device facts once and evaluates all matching baselines
+func (a *App) auditDevice(device models.Device, baselines []models.ComplianceBaseline) []models.DeviceComplianceResult {
+	var checks []string
+	for _, b := range baselines {
+		for _, r := range b.Rules {
+			checks = append(checks, r.Check)
+		}
+	}
+
+	facts, err := a.collectComplianceFacts(device, checks)
+
+	results := make([]models.DeviceComplianceResult, len(baselines))
+	for i, b := range baselines {
+		result := models.DeviceComplianceResult{
+			DeviceID:     device.ID,
+			DeviceName:   device.Name,
+			IPAddress:    device.IPAddress,
+			DeviceType:   device.Type,
+			BaselineID:   b.ID,
+			BaselineName: b.Name,
+		}
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Rules, result.Passed = compliance.Evaluate(b.Rules, facts)
+		}
+		results[i] = result
+	}
+
+	return results
+}
+
+// collectComplianceFacts gathers values for the requested checks from a device
+func (a *App) collectComplianceFacts(device models.Device, checks []string) (compliance.Facts, error) {
+	facts := compliance.Facts{}
+	facts.Set(models.ComplianceCheckManufacturer, device.Manufacturer)
+	facts.Set(models.ComplianceCheckModel, device.Model)
+
+	needs := func(prefixes ...string) bool {
+		for _, c := range checks {
+			for _, p := range prefixes {
+				if strings.HasPrefix(c, p) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch device.Type {
+	case models.DeviceTypeCamera:
+		return facts, a.collectCameraFacts(device, facts, needs)
+	case models.DeviceTypeSwitch:
+		return facts, a.collectSwitchFacts(device, facts, needs)
+	}
+
+	return facts, nil
+}
+
+// collectCameraFacts gathers camera values via ONVIF
+func (a *App) collectCameraFacts(device models.Device, facts compliance.Facts, needs func(...string) bool) error {
+	client, err := a.newCameraONVIFClient(device.ID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if needs(models.ComplianceCheckFirmware) {
+		info, err := client.GetDeviceInformation(ctx)
+		if err != nil {
+			return fmt.Errorf("ONVIF unavailable: %w", err)
+		}
+		facts.Set(models.ComplianceCheckFirmware, info.FirmwareVersion)
+		if info.Manufacturer != "" {
+			facts.Set(models.ComplianceCheckManufacturer, info.Manufacturer)
+		}
+		if info.Model != "" {
+			facts.Set(models.ComplianceCheckModel, info.Model)
+		}
+	}
+
+	if needs("main_stream.") {
+		if cfg, err := streamEncoderConfiguration(ctx, client, StreamMain); err == nil {
+			setEncoderFacts(facts, "main_stream.", cfg)
+		}
+	}
+	if needs("sub_stream.") {
+		if cfg, err := streamEncoderConfiguration(ctx, client, StreamSub); err == nil {
+			setEncoderFacts(facts, "sub_stream.", cfg)
+		}
+	}
+
+	if needs("ntp.") {
+		if ntp, err := client.GetNTP(ctx); err == nil {
+			facts.Set(models.ComplianceCheckNTPFromDHCP, strconv.FormatBool(ntp.FromDHCP))
+			servers := ntp.Servers
+			if ntp.FromDHCP {
+				servers = ntp.ServersFromDHCP
+			}
+			for _, s := range servers {
+				facts.Add(models.ComplianceCheckNTPServer, s)
+			}
+		}
+	}
+
+	if needs("time.") {
+		before := time.Now()
+		dt, err := client.GetSystemDateAndTime(ctx)
+		after := time.Now()
+		if err == nil {
+			hostTime := before.Add(after.Sub(before) / 2)
+			drift := math.Abs(dt.UTCTime.Sub(hostTime).Seconds())
+			facts.Set(models.ComplianceCheckDateTimeType, dt.DateTimeType)
+			facts.Set(models.ComplianceCheckClockDrift, strconv.FormatFloat(math.Round(drift), 'f', 0, 64))
+		}
+	}
+
+	return nil
+}
+
+// setEncoderFacts stores encoder values under the given stream prefix
+func setEncoderFacts(facts compliance.Facts, prefix string, cfg *onvif.VideoEncoderConfiguration) {
+	facts.Set(prefix+"encoding", cfg.Encoding)
+	facts.Set(prefix+"bitrate", strconv.Itoa(cfg.BitrateLimit))
+	facts.Set(prefix+"frame_rate", strconv.Itoa(cfg.FrameRate))
+	facts.Set(prefix+"resolution", fmt.Sprintf("%dx%d", cfg.Width, cfg.Height))
+	facts.Set(prefix+"gov_length", strconv.Itoa(cfg.GovLength))
+}
+
+// collectSwitchFacts gathers switch values from the database and via SNMP
+func (a *App) collectSwitchFacts(device models.Device, facts compliance.Facts, needs func(...string) bool) error {
+	switchRepo := database.NewSwitchRepository(a.db.DB())
+	sw, err := switchRepo.GetByDeviceID(device.ID)
+	if err != nil || sw == nil {
+		return fmt.Errorf("switch configuration not found")
+	}
+
+	version := sw.SNMPVersion
+	if version == "" {
+		version = "v2c"
+	}
+	facts.Set(models.ComplianceCheckSNMPVersion, version)
+	facts.Set(models.ComplianceCheckUplinkConfigured, strconv.FormatBool(sw.UplinkSwitchID != nil))
+
+	if !needs(models.ComplianceCheckFirmware, "autorestart.") {
+		return nil
+	}
+
+	client := createSNMPClient(device.IPAddress, sw)
+
+	if needs(models.ComplianceCheckFirmware) {
+		info, err := client.GetSystemInfo()
+		if err != nil {
+			return fmt.Errorf("SNMP unavailable: %w", err)
+		}
+		facts.Set(models.ComplianceCheckFirmware, info.FirmwareVersion)
+	}
+
+	if needs("autorestart.") {
+		copperPorts := sw.PortCount - sw.SFPPortCount
+		for port := 1; port <= copperPorts; port++ {
+			info, err := client.GetAutoRestartInfo(port)
+			if err != nil {
+				continue
+			}
+			facts.Add(models.ComplianceCheckAutoRestartMode, autoRestartModeKey(info.Mode))
+			facts.Add(models.ComplianceCheckAutoRestartPing, info.PingIP)
+		}
+	}
+
+	return nil
+}
+
+// autoRestartModeKey returns a stable identifier for a TFortis AutoRestart mode
+func autoRestartModeKey(mode int) string {
+	switch mode {
+	case snmp.AutoRestartDisabled:
+		return "disabled"
+	case snmp.AutoRestartAlways:
+		return "always"
+	case snmp.AutoRestartOnLink:
+		return "link"
+	case snmp.AutoRestartOnPing:
+		return "ping"
+	case snmp.AutoRestartLinkSpeed:
+		return "speed"
+	}
+	return strconv.Itoa(mode)
+}
+
+// ruleKey identifies a rule within a baseline for drift tracking
+func ruleKey(rule models.ComplianceRule) string {
+	return fmt.Sprintf("%s|%s|%s", rule.Check, rule.Operator, rule.Value)
+}
+
+// validateComplianceBaseline checks baseline fields before saving
+func validateComplianceBaseline(baseline *models.ComplianceBaseline) error {
+	baseline.Name = strings.TrimSpace(baseline.Name)
+	if baseline.Name == "" {
+		return fmt.Errorf("baseline name is required")
+	}
+
+	if !baseline.DeviceType.Valid() {
+		return fmt.Errorf("invalid device type: %s", baseline.DeviceType)
+	}
+
+	for i, rule := range baseline.Rules {
+		if strings.TrimSpace(rule.Check) == "" {
+			return fmt.Errorf("rule %d: check is required", i+1)
+		}
+		if rule.Operator == "" {
+			baseline.Rules[i].Operator = models.ComplianceOpEqual
+		}
+		if _, err := compliance.Compare("", baseline.Rules[i].Operator, rule.Value); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// startComplianceScheduler runs compliance audits at the configured interval
+func (a *App) startComplianceScheduler() {
+	a.complianceStop = make(chan struct{})
+	stop := a.complianceStop
+
+	go func() {
+		// Check once per minute whether an audit is due
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		var lastRun time.Time
+		if a.db != nil {
+			if latest, err := database.NewComplianceRepository(a.db.DB()).GetReports(1); err == nil && len(latest) > 0 {
+				lastRun = latest[0].StartedAt
+			}
+		}
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				settings := a.appSettings()
+				if settings.ComplianceInterval <= 0 || !a.hasEnabledBaselines() {
+					continue
+				}
+				if time.Since(lastRun) < time.Duration(settings.ComplianceInterval)*time.Hour {
+					continue
+				}
+
+				lastRun = time.Now()
+				if _, err := a.runComplianceAudit(); err != nil {
+					log.Printf("Scheduled compliance audit failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// hasEnabledBaselines reports whether any compliance baseline is enabled
+func (a *App) hasEnabledBaselines() bool {
+	if a.db == nil {
+		return false
+	}
+
+	baselines, err := database.NewComplianceRepository(a.db.DB()).GetAllBaselines()
+	if err != nil {
+		return false
+	}
+	for _, b := range baselines {
+		if b.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// stopComplianceScheduler stops the compliance audit scheduler
+func (a *App) stopComplianceScheduler() {
+	if a.complianceStop != nil {
+		close(a.complianceStop)
+		a.complianceStop = nil
+	}
+}
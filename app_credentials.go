@@ -19,6 +19,9 @@ type CredentialInput struct {
 
 // GetCredentials returns all credentials (without passwords)
 func (a *App) GetCredentials() ([]models.Credential, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -28,6 +31,9 @@ func (a *App) GetCredentials() ([]models.Credential, error) {
 
 // GetCredentialsByType returns credentials of a specific type
 func (a *App) GetCredentialsByType(credType string) ([]models.Credential, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -37,6 +43,9 @@ func (a *App) GetCredentialsByType(credType string) ([]models.Credential, error)
 
 // GetCredential returns a credential by ID (without password)
 func (a *App) GetCredential(id int64) (*models.Credential, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -45,11 +54,20 @@ func (a *App) GetCredential(id int64) (*models.Credential, error) {
 }
 
 // CreateCredential creates a new credential
-func (a *App) CreateCredential(input CredentialInput) (*models.Credential, error) {
+func (a *App) CreateCredential(input CredentialInput) (cred *models.Credential, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
 
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	rec := a.beginAudit("credential.create", models.AuditTarget{Type: "credential", Name: input.Name}, nil)
+	defer func() { rec.finish(err) }()
+
 	// Validate input
 	if input.Name == "" {
 		return nil, fmt.Errorf("name is required")
@@ -58,7 +76,7 @@ func (a *App) CreateCredential(input CredentialInput) (*models.Credential, error
 		return nil, fmt.Errorf("type is required")
 	}
 
-	cred := &models.Credential{
+	cred = &models.Credential{
 		Name:     input.Name,
 		Type:     models.CredentialType(input.Type),
 		Username: input.Username,
@@ -70,6 +88,7 @@ func (a *App) CreateCredential(input CredentialInput) (*models.Credential, error
 	if err := repo.Create(cred); err != nil {
 		return nil, err
 	}
+	rec.track(cred.ID, cred.Name, a.credentialAuditState(cred.ID))
 
 	// Don't return password
 	cred.Password = ""
@@ -77,11 +96,20 @@ func (a *App) CreateCredential(input CredentialInput) (*models.Credential, error
 }
 
 // UpdateCredential updates an existing credential
-func (a *App) UpdateCredential(input CredentialInput) error {
+func (a *App) UpdateCredential(input CredentialInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	rec := a.beginAudit("credential.update", auditTarget("credential", input.ID, input.Name), a.credentialAuditState(input.ID))
+	defer func() { rec.finish(err) }()
+
 	if input.ID == 0 {
 		return fmt.Errorf("credential ID is required")
 	}
@@ -100,11 +128,33 @@ func (a *App) UpdateCredential(input CredentialInput) error {
 }
 
 // DeleteCredential deletes a credential by ID
-func (a *App) DeleteCredential(id int64) error {
+func (a *App) DeleteCredential(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	state := a.credentialAuditState(id)
+	target := auditTarget("credential", id, "")
+	if cred, ok := state().(*models.Credential); ok {
+		target.Name = cred.Name
+	}
+	rec := a.beginAudit("credential.delete", target, state)
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewCredentialRepository(a.db.DB())
 	return repo.Delete(id)
 }
+
+// credentialAuditState captures a credential. Its password is masked in the log.
+func (a *App) credentialAuditState(id int64) auditState {
+	return func() interface{} {
+		cred, err := database.NewCredentialRepository(a.db.DB()).GetByID(id)
+		if err != nil || cred == nil {
+			return nil
+		}
+		return cred
+	}
+}
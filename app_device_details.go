@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"netvisionmonitor/internal/database"
 	"netvisionmonitor/internal/models"
@@ -10,6 +12,9 @@ import (
 
 // GetDeviceMonitoringStats returns monitoring statistics for a specific device
 func (a *App) GetDeviceMonitoringStats(deviceID int64) (*models.DeviceStats, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, nil
 	}
@@ -20,6 +25,9 @@ func (a *App) GetDeviceMonitoringStats(deviceID int64) (*models.DeviceStats, err
 
 // GetDeviceLatencyHistory returns latency data points for graphing
 func (a *App) GetDeviceLatencyHistory(deviceID int64, hours int) ([]models.LatencyPoint, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, nil
 	}
@@ -30,6 +38,9 @@ func (a *App) GetDeviceLatencyHistory(deviceID int64, hours int) ([]models.Laten
 
 // GetDeviceUptimeHistory returns uptime data grouped by period
 func (a *App) GetDeviceUptimeHistory(deviceID int64, period string, count int) ([]models.UptimePoint, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, nil
 	}
@@ -40,6 +51,9 @@ func (a *App) GetDeviceUptimeHistory(deviceID int64, period string, count int) (
 
 // GetDeviceStatusHistory returns raw status history
 func (a *App) GetDeviceStatusHistory(deviceID int64, limit int) ([]models.StatusHistory, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, nil
 	}
@@ -50,6 +64,9 @@ func (a *App) GetDeviceStatusHistory(deviceID int64, limit int) ([]models.Status
 
 // GetDeviceStatusChanges returns status change events
 func (a *App) GetDeviceStatusChanges(deviceID int64, limit int) ([]models.StatusHistory, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, nil
 	}
@@ -60,6 +77,9 @@ func (a *App) GetDeviceStatusChanges(deviceID int64, limit int) ([]models.Status
 
 // GetSwitchPorts returns port information for a switch
 func (a *App) GetSwitchPorts(deviceID int64) ([]models.SwitchPort, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, nil
 	}
@@ -68,28 +88,80 @@ func (a *App) GetSwitchPorts(deviceID int64) ([]models.SwitchPort, error) {
 	return repo.GetSwitchPorts(deviceID)
 }
 
+// GetDeviceHealth reads the type-specific state of a router, access point, UPS,
+// NVR or PDU. Read failures are returned in the Error field.
+func (a *App) GetDeviceHealth(deviceID int64) (*models.DeviceHealth, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.monitor == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	device, err := database.NewDeviceRepository(a.db.DB()).GetByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, fmt.Errorf("device not found")
+	}
+	if !device.Type.UsesSNMPDevice() {
+		return nil, fmt.Errorf("device type %s has no health data", device.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, 30*time.Second)
+	defer cancel()
+
+	health, err := a.monitor.ReadHealth(ctx, *device)
+	if err != nil {
+		log.Printf("GetDeviceHealth(%d): %v", deviceID, err)
+		return &models.DeviceHealth{
+			DeviceID:  deviceID,
+			Type:      device.Type,
+			CheckedAt: time.Now(),
+			Error:     err.Error(),
+		}, nil
+	}
+	return health, nil
+}
+
 // UpdateSwitchPort updates a switch port
-func (a *App) UpdateSwitchPort(port models.SwitchPort) error {
+func (a *App) UpdateSwitchPort(port models.SwitchPort) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return nil
 	}
 
+	rec := a.beginAudit("switch.port_update", auditTarget("switch_port", port.ID, port.Name), a.rowAuditState("switch_ports", "id", port.ID))
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewDeviceRepository(a.db.DB())
 	return repo.UpdateSwitchPort(&port)
 }
 
 // LinkCameraToPort links a camera device to a switch port
-func (a *App) LinkCameraToPort(portID int64, cameraID *int64) error {
+func (a *App) LinkCameraToPort(portID int64, cameraID *int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return nil
 	}
 
+	rec := a.beginAudit("switch.port_link", auditTarget("switch_port", portID, ""), a.rowAuditState("switch_ports", "id", portID))
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewDeviceRepository(a.db.DB())
 	return repo.LinkCameraToPort(portID, cameraID)
 }
 
 // GetCameraSnapshot returns the snapshot URL for a camera, auto-discovering via ONVIF if needed
 func (a *App) GetCameraSnapshot(deviceID int64) (string, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return "", err
+	}
 	if a.db == nil {
 		return "", fmt.Errorf("database not initialized")
 	}
@@ -110,7 +182,7 @@ func (a *App) GetCameraSnapshot(deviceID int64) (string, error) {
 
 	// If no snapshot URL, try to get via ONVIF
 	if cam.SnapshotURL == "" {
-		err := a.RefreshCameraStreams(deviceID)
+		err := a.refreshCameraStreams(deviceID)
 		if err != nil {
 			return "", fmt.Errorf("no snapshot URL configured and ONVIF refresh failed: %w", err)
 		}
@@ -133,6 +205,9 @@ func (a *App) GetCameraSnapshot(deviceID int64) (string, error) {
 
 // GetCameraStreamURL returns the RTSP stream URL for a camera, auto-discovering via ONVIF if needed
 func (a *App) GetCameraStreamURL(deviceID int64) (string, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return "", err
+	}
 	if a.db == nil {
 		return "", fmt.Errorf("database not initialized")
 	}
@@ -146,7 +221,7 @@ func (a *App) GetCameraStreamURL(deviceID int64) (string, error) {
 
 	// If no RTSP URL, try to get via ONVIF
 	if cam.RTSPURL == "" {
-		err := a.RefreshCameraStreams(deviceID)
+		err := a.refreshCameraStreams(deviceID)
 		if err != nil {
 			return "", fmt.Errorf("no RTSP URL configured and ONVIF refresh failed: %w", err)
 		}
@@ -173,6 +248,9 @@ type SwitchWithPorts struct {
 
 // GetCameraPort returns the switch port ID that a camera is linked to
 func (a *App) GetCameraPort(cameraDeviceID int64) (*int64, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, nil
 	}
@@ -191,6 +269,10 @@ func (a *App) GetCameraPort(cameraDeviceID int64) (*int64, error) {
 
 // GetSwitchesWithPorts returns all switches with their ports for camera linking
 func (a *App) GetSwitchesWithPorts() ([]SwitchWithPorts, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
 	log.Printf("GetSwitchesWithPorts: called")
 	if a.db == nil {
 		log.Printf("GetSwitchesWithPorts: db is nil!")
@@ -252,6 +334,9 @@ type UplinkConnection struct {
 
 // GetAllUplinkConnections returns all uplink connections (switches and servers to their parent switches)
 func (a *App) GetAllUplinkConnections() ([]UplinkConnection, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return []UplinkConnection{}, nil
 	}
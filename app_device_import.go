@@ -0,0 +1,878 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/xlsx"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Device import fields that can be mapped to file columns
+const (
+	ImportFieldName          = "name"
+	ImportFieldIPAddress     = "ip_address"
+	ImportFieldType          = "type"
+	ImportFieldManufacturer  = "manufacturer"
+	ImportFieldModel         = "model"
+	ImportFieldCredential    = "credential"
+	ImportFieldSwitch        = "switch"
+	ImportFieldPort          = "port"
+	ImportFieldSNMPCommunity = "snmp_community"
+	ImportFieldSNMPVersion   = "snmp_version"
+	ImportFieldPortCount     = "port_count"
+	ImportFieldSFPPortCount  = "sfp_port_count"
+	ImportFieldRTSPURL       = "rtsp_url"
+	ImportFieldONVIFPort     = "onvif_port"
+	ImportFieldSnapshotURL   = "snapshot_url"
+	ImportFieldTCPPorts      = "tcp_ports"
+	ImportFieldUplinkSwitch  = "uplink_switch"
+	ImportFieldUplinkPort    = "uplink_port"
+)
+
+// importPreviewRows is the number of data rows returned for file preview
+const importPreviewRows = 10
+
+// DeviceImportField describes a field that can be mapped to a file column
+type DeviceImportField struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label"`
+	Required bool     `json:"required"`
+	Aliases  []string `json:"-"` // Header names recognised automatically
+}
+
+var deviceImportFields = []DeviceImportField{
+	{ImportFieldName, "Название", true, []string{"name", "название", "имя", "наименование"}},
+	{ImportFieldIPAddress, "IP адрес", true, []string{"ip", "ip_address", "ip address", "ip адрес", "ip-адрес", "адрес"}},
+	{ImportFieldType, "Тип", false, []string{"type", "тип", "device type", "тип устройства"}},
+	{ImportFieldManufacturer, "Производитель", false, []string{"manufacturer", "vendor", "производитель"}},
+	{ImportFieldModel, "Модель", false, []string{"model", "модель"}},
+	{ImportFieldCredential, "Учётные данные", false, []string{"credential", "credentials", "учетные данные", "учётные данные", "учетная запись"}},
+	{ImportFieldSwitch, "Коммутатор", false, []string{"switch", "switch name", "коммутатор"}},
+	{ImportFieldPort, "Порт", false, []string{"port", "switch port", "порт", "порт коммутатора"}},
+	{ImportFieldSNMPCommunity, "SNMP community", false, []string{"snmp_community", "community", "snmp community"}},
+	{ImportFieldSNMPVersion, "Версия SNMP", false, []string{"snmp_version", "snmp version", "версия snmp"}},
+	{ImportFieldPortCount, "Количество портов", false, []string{"port_count", "ports", "количество портов", "портов"}},
+	{ImportFieldSFPPortCount, "Количество SFP портов", false, []string{"sfp_port_count", "sfp ports", "sfp", "количество sfp"}},
+	{ImportFieldRTSPURL, "RTSP URL", false, []string{"rtsp_url", "rtsp", "rtsp url"}},
+	{ImportFieldONVIFPort, "ONVIF порт", false, []string{"onvif_port", "onvif port", "onvif порт"}},
+	{ImportFieldSnapshotURL, "Snapshot URL", false, []string{"snapshot_url", "snapshot", "snapshot url"}},
+	{ImportFieldTCPPorts, "TCP порты", false, []string{"tcp_ports", "tcp ports", "tcp порты"}},
+	{ImportFieldUplinkSwitch, "Uplink коммутатор", false, []string{"uplink_switch", "uplink switch", "uplink коммутатор"}},
+	{ImportFieldUplinkPort, "Uplink порт", false, []string{"uplink_port", "uplink port", "uplink порт"}},
+}
+
+// DeviceImportFile contains parsed file headers and a preview of its rows
+type DeviceImportFile struct {
+	Path     string            `json:"path"`
+	FileName string            `json:"file_name"`
+	Headers  []string          `json:"headers"`
+	RowCount int               `json:"row_count"`
+	Preview  [][]string        `json:"preview"`
+	Mapping  map[string]string `json:"mapping"` // Suggested field -> column header
+}
+
+// DeviceImportRequest describes how to import a file
+type DeviceImportRequest struct {
+	Path        string            `json:"path"`
+	Mapping     map[string]string `json:"mapping"`      // Field key -> column header
+	DefaultType string            `json:"default_type"` // Used when the type column is empty or unmapped
+	DryRun      bool              `json:"dry_run"`
+	SkipInvalid bool              `json:"skip_invalid"` // Import valid rows even if some rows have errors
+}
+
+// DeviceImportRowResult contains validation and import result for a single row
+type DeviceImportRowResult struct {
+	Row       int      `json:"row"` // Row number in the file (header is row 1)
+	Name      string   `json:"name"`
+	IPAddress string   `json:"ip_address"`
+	Type      string   `json:"type"`
+	Valid     bool     `json:"valid"`
+	Errors    []string `json:"errors,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	DeviceID  int64    `json:"device_id,omitempty"`
+}
+
+// DeviceImportReport contains the result of an import or dry run
+type DeviceImportReport struct {
+	FileName  string                  `json:"file_name"`
+	DryRun    bool                    `json:"dry_run"`
+	Committed bool                    `json:"committed"`
+	Total     int                     `json:"total"`
+	Valid     int                     `json:"valid"`
+	Invalid   int                     `json:"invalid"`
+	Imported  int                     `json:"imported"`
+	Message   string                  `json:"message,omitempty"`
+	Rows      []DeviceImportRowResult `json:"rows"`
+}
+
+// GetDeviceImportFields returns fields that can be mapped to file columns
+func (a *App) GetDeviceImportFields() ([]DeviceImportField, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	return deviceImportFields, nil
+}
+
+// SelectDeviceImportFile asks for a CSV/XLSX file and returns its headers,
+// a preview and a suggested column mapping. Returns nil if cancelled.
+func (a *App) SelectDeviceImportFile() (*DeviceImportFile, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Импорт устройств",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Таблицы (*.csv, *.xlsx)", Pattern: "*.csv;*.xlsx"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if filePath == "" {
+		return nil, nil // User cancelled
+	}
+
+	return a.ReadDeviceImportFile(filePath)
+}
+
+// ReadDeviceImportFile parses a CSV/XLSX file and suggests a column mapping
+func (a *App) ReadDeviceImportFile(filePath string) (*DeviceImportFile, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	headers, rows, err := readImportTable(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := rows
+	if len(preview) > importPreviewRows {
+		preview = preview[:importPreviewRows]
+	}
+
+	return &DeviceImportFile{
+		Path:     filePath,
+		FileName: filepath.Base(filePath),
+		Headers:  headers,
+		RowCount: len(rows),
+		Preview:  preview,
+		Mapping:  suggestImportMapping(headers),
+	}, nil
+}
+
+// ImportDevices validates every row of a file and, unless DryRun is set,
+// creates all valid devices in a single transaction
+func (a *App) ImportDevices(req DeviceImportRequest) (report *DeviceImportReport, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	if !req.DryRun {
+		rec := a.beginAudit("device.import", models.AuditTarget{Type: "device"}, nil).detail("file", filepath.Base(req.Path))
+		defer func() {
+			if report != nil {
+				rec.detail("imported", report.Imported).detail("invalid", report.Invalid)
+			}
+			rec.finish(err)
+		}()
+	}
+
+	headers, rows, err := readImportTable(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for field, header := range req.Mapping {
+		if header == "" {
+			continue
+		}
+		idx := indexOfHeader(headers, header)
+		if idx < 0 {
+			return nil, fmt.Errorf("column %q not found in file", header)
+		}
+		columns[field] = idx
+	}
+	for _, f := range deviceImportFields {
+		if _, ok := columns[f.Key]; f.Required && !ok {
+			return nil, fmt.Errorf("column for field %q is not mapped", f.Label)
+		}
+	}
+
+	plan, err := newImportPlan(a.db.DB())
+	if err != nil {
+		return nil, err
+	}
+
+	report = &DeviceImportReport{
+		FileName: filepath.Base(req.Path),
+		DryRun:   req.DryRun,
+	}
+
+	// Switches are processed first so that cameras in the same file can be linked to them
+	rowsData := make([]importRow, 0, len(rows))
+	for i, values := range rows {
+		row := newImportRow(i+2, values, columns, req.DefaultType)
+		if row.isEmpty() {
+			continue
+		}
+		rowsData = append(rowsData, row)
+	}
+	sort.SliceStable(rowsData, func(i, j int) bool {
+		return rowsData[i].deviceType == models.DeviceTypeSwitch && rowsData[j].deviceType != models.DeviceTypeSwitch
+	})
+
+	for i := range rowsData {
+		plan.validate(&rowsData[i])
+	}
+
+	// Report rows in file order
+	sort.SliceStable(rowsData, func(i, j int) bool { return rowsData[i].result.Row < rowsData[j].result.Row })
+	for _, r := range rowsData {
+		report.Rows = append(report.Rows, r.result)
+		report.Total++
+		if r.result.Valid {
+			report.Valid++
+		} else {
+			report.Invalid++
+		}
+	}
+
+	if req.DryRun {
+		report.Message = fmt.Sprintf("Проверка завершена: %d из %d строк готовы к импорту", report.Valid, report.Total)
+		return report, nil
+	}
+	if report.Invalid > 0 && !req.SkipInvalid {
+		report.Message = fmt.Sprintf("Импорт отменён: %d строк содержат ошибки", report.Invalid)
+		return report, nil
+	}
+	if report.Valid == 0 {
+		report.Message = "Нет строк для импорта"
+		return report, nil
+	}
+
+	// Create devices in the same order they were validated
+	sort.SliceStable(rowsData, func(i, j int) bool {
+		return rowsData[i].deviceType == models.DeviceTypeSwitch && rowsData[j].deviceType != models.DeviceTypeSwitch
+	})
+
+	created := make(map[int]int64) // file row -> device ID
+	err = a.db.WithTx(func(tx *sql.Tx) error {
+		switchRepo := database.NewSwitchRepository(tx)
+		for i := range rowsData {
+			r := &rowsData[i]
+			if !r.result.Valid {
+				continue
+			}
+
+			input, err := plan.deviceInput(r, switchRepo)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", r.result.Row, err)
+			}
+
+			device, err := createDevice(tx, input)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", r.result.Row, err)
+			}
+			created[r.result.Row] = device.ID
+			if r.deviceType == models.DeviceTypeSwitch {
+				plan.newSwitchIDs[strings.ToLower(r.name)] = device.ID
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Device import from %s failed: %v", report.FileName, err)
+		return nil, fmt.Errorf("import failed, no devices were created: %w", err)
+	}
+
+	for i := range report.Rows {
+		if id, ok := created[report.Rows[i].Row]; ok {
+			report.Rows[i].DeviceID = id
+			report.Imported++
+		}
+	}
+	report.Committed = true
+	report.Message = fmt.Sprintf("Импортировано устройств: %d", report.Imported)
+
+	message := fmt.Sprintf("Imported %d devices from %s", report.Imported, report.FileName)
+	if report.Invalid > 0 {
+		message += fmt.Sprintf(" (%d rows skipped)", report.Invalid)
+	}
+	a.createEvent(nil, models.EventTypeDevicesImported, models.EventLevelInfo, message)
+	log.Print(message)
+
+	return report, nil
+}
+
+// importRow holds raw values and validation state of a single file row
+type importRow struct {
+	values     map[string]string
+	name       string
+	deviceType models.DeviceType
+	result     DeviceImportRowResult
+
+	// Resolved during validation
+	credentialID *int64
+	port         *importPortRef
+	uplink       *importPortRef
+}
+
+// importPortRef references a port of an existing switch or of a switch created by the import
+type importPortRef struct {
+	switchID   int64  // Existing switch, 0 for a switch from the same file
+	switchName string // Switch from the same file
+	portNumber int
+	portID     int64 // Known only for existing switches
+}
+
+func newImportRow(rowNumber int, values []string, columns map[string]int, defaultType string) importRow {
+	row := importRow{values: make(map[string]string)}
+	for field, idx := range columns {
+		if idx < len(values) {
+			row.values[field] = strings.TrimSpace(values[idx])
+		}
+	}
+
+	row.name = row.values[ImportFieldName]
+	typeValue := row.values[ImportFieldType]
+	if typeValue == "" {
+		typeValue = defaultType
+	}
+	row.deviceType = parseImportDeviceType(typeValue)
+
+	row.result = DeviceImportRowResult{
+		Row:       rowNumber,
+		Name:      row.name,
+		IPAddress: row.values[ImportFieldIPAddress],
+		Type:      string(row.deviceType),
+	}
+	if row.deviceType == "" {
+		row.result.Type = typeValue
+	}
+	return row
+}
+
+func (r *importRow) isEmpty() bool {
+	for _, v := range r.values {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *importRow) errorf(format string, args ...interface{}) {
+	r.result.Errors = append(r.result.Errors, fmt.Sprintf(format, args...))
+}
+
+func (r *importRow) warnf(format string, args ...interface{}) {
+	r.result.Warnings = append(r.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+// importSwitch describes a switch known to the import plan
+type importSwitch struct {
+	id        int64
+	portCount int
+	sfpCount  int
+	ports     map[int]models.SwitchPort // Existing switches only
+}
+
+// importPlan tracks existing data and data claimed by earlier rows
+type importPlan struct {
+	ips          map[string]string // IP -> device name
+	credentials  map[string][]int64
+	switches     map[string][]importSwitch // Lowercase name -> switches
+	newSwitches  map[string]importSwitch   // Switches created by this import
+	newSwitchIDs map[string]int64          // Filled while creating devices
+	usedPorts    map[string]bool           // "switch|port" claimed by earlier rows
+}
+
+func newImportPlan(db database.Querier) (*importPlan, error) {
+	plan := &importPlan{
+		ips:          make(map[string]string),
+		credentials:  make(map[string][]int64),
+		switches:     make(map[string][]importSwitch),
+		newSwitches:  make(map[string]importSwitch),
+		newSwitchIDs: make(map[string]int64),
+		usedPorts:    make(map[string]bool),
+	}
+
+	devices, err := database.NewDeviceRepository(db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	switchRepo := database.NewSwitchRepository(db)
+	for _, d := range devices {
+		plan.ips[d.IPAddress] = d.Name
+		if d.Type != models.DeviceTypeSwitch {
+			continue
+		}
+
+		sw, err := switchRepo.GetByDeviceID(d.ID)
+		if err != nil || sw == nil {
+			continue
+		}
+		ports, err := switchRepo.GetPorts(d.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		info := importSwitch{
+			id:        d.ID,
+			portCount: sw.PortCount,
+			sfpCount:  sw.SFPPortCount,
+			ports:     make(map[int]models.SwitchPort, len(ports)),
+		}
+		for _, p := range ports {
+			info.ports[p.PortNumber] = p
+		}
+		key := strings.ToLower(d.Name)
+		plan.switches[key] = append(plan.switches[key], info)
+	}
+
+	creds, err := database.NewCredentialRepository(db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range creds {
+		key := strings.ToLower(c.Name)
+		plan.credentials[key] = append(plan.credentials[key], c.ID)
+	}
+
+	return plan, nil
+}
+
+// validate checks a row and claims its IP and switch port for later rows
+func (p *importPlan) validate(r *importRow) {
+	if r.name == "" {
+		r.errorf("не указано название")
+	}
+
+	ip := r.values[ImportFieldIPAddress]
+	switch {
+	case ip == "":
+		r.errorf("не указан IP адрес")
+	case net.ParseIP(ip) == nil || strings.Contains(ip, ":"):
+		r.errorf("некорректный IP адрес: %s", ip)
+	default:
+		if existing, ok := p.ips[ip]; ok {
+			r.errorf("IP %s уже используется устройством «%s»", ip, existing)
+		}
+	}
+
+	if r.deviceType == "" {
+		r.errorf("неизвестный тип устройства: %q", r.result.Type)
+	}
+
+	if name := r.values[ImportFieldCredential]; name != "" {
+		ids := p.credentials[strings.ToLower(name)]
+		switch len(ids) {
+		case 0:
+			r.errorf("учётные данные «%s» не найдены", name)
+		case 1:
+			r.credentialID = &ids[0]
+		default:
+			r.errorf("найдено несколько учётных данных с именем «%s»", name)
+		}
+	}
+
+	for _, field := range []string{ImportFieldPortCount, ImportFieldSFPPortCount, ImportFieldONVIFPort} {
+		if v := r.values[field]; v != "" {
+			if n, err := strconv.Atoi(v); err != nil || n < 0 {
+				r.errorf("некорректное число в поле %s: %s", field, v)
+			}
+		}
+	}
+
+	switch r.deviceType {
+	case models.DeviceTypeSwitch:
+		p.validateSwitch(r)
+		r.uplink = p.resolvePort(r, ImportFieldUplinkSwitch, ImportFieldUplinkPort, "sfp")
+	case models.DeviceTypeCamera:
+		r.port = p.resolvePort(r, ImportFieldSwitch, ImportFieldPort, "copper")
+		if r.port == nil && len(r.result.Errors) == 0 {
+			r.errorf("камера должна быть подключена к порту коммутатора")
+		}
+	case models.DeviceTypeServer, models.DeviceTypeRouter, models.DeviceTypeAccessPoint,
+		models.DeviceTypeUPS, models.DeviceTypeNVR, models.DeviceTypePDU:
+		r.uplink = p.resolvePort(r, ImportFieldUplinkSwitch, ImportFieldUplinkPort, "sfp")
+	}
+
+	r.result.Valid = len(r.result.Errors) == 0
+	if !r.result.Valid {
+		return
+	}
+
+	// Claim resources for subsequent rows
+	p.ips[ip] = r.name
+	for _, ref := range []*importPortRef{r.port, r.uplink} {
+		if ref != nil {
+			p.usedPorts[ref.key()] = true
+		}
+	}
+}
+
+// validateSwitch checks switch-specific fields and registers the switch for later rows
+func (p *importPlan) validateSwitch(r *importRow) {
+	key := strings.ToLower(r.name)
+	if len(p.switches[key]) > 0 {
+		r.warnf("коммутатор с именем «%s» уже существует", r.name)
+	}
+	if _, ok := p.newSwitches[key]; ok {
+		r.errorf("коммутатор «%s» уже есть в файле", r.name)
+		return
+	}
+
+	if v := r.values[ImportFieldSNMPVersion]; v != "" && v != "v1" && v != "v2c" && v != "v3" {
+		r.errorf("некорректная версия SNMP: %s", v)
+	}
+
+	portCount, _ := strconv.Atoi(r.values[ImportFieldPortCount])
+	if portCount <= 0 {
+		portCount = 8
+	}
+	sfpCount, _ := strconv.Atoi(r.values[ImportFieldSFPPortCount])
+	if sfpCount > portCount {
+		r.warnf("SFP портов больше, чем портов всего — SFP порты не будут созданы")
+		sfpCount = 0
+	}
+
+	if len(r.result.Errors) == 0 {
+		p.newSwitches[key] = importSwitch{portCount: portCount, sfpCount: sfpCount}
+	}
+}
+
+// resolvePort finds the referenced switch port and checks that it is free
+func (p *importPlan) resolvePort(r *importRow, switchField, portField, portType string) *importPortRef {
+	switchName := r.values[switchField]
+	portValue := r.values[portField]
+	if switchName == "" && portValue == "" {
+		return nil
+	}
+	if switchName == "" || portValue == "" {
+		r.errorf("для подключения нужно указать и коммутатор, и номер порта")
+		return nil
+	}
+
+	portNumber, err := strconv.Atoi(portValue)
+	if err != nil || portNumber <= 0 {
+		r.errorf("некорректный номер порта: %s", portValue)
+		return nil
+	}
+
+	key := strings.ToLower(switchName)
+	if r.deviceType == models.DeviceTypeSwitch && key == strings.ToLower(r.name) {
+		r.errorf("коммутатор не может быть подключён к самому себе")
+		return nil
+	}
+	ref := &importPortRef{portNumber: portNumber}
+
+	var sw importSwitch
+	if existing := p.switches[key]; len(existing) > 0 {
+		if len(existing) > 1 {
+			r.errorf("найдено несколько коммутаторов с именем «%s»", switchName)
+			return nil
+		}
+		sw = existing[0]
+		ref.switchID = sw.id
+	} else if planned, ok := p.newSwitches[key]; ok {
+		sw = planned
+		ref.switchName = key
+	} else {
+		r.errorf("коммутатор «%s» не найден", switchName)
+		return nil
+	}
+
+	if portNumber > sw.portCount {
+		r.errorf("у коммутатора «%s» нет порта %d", switchName, portNumber)
+		return nil
+	}
+
+	isSFP := portNumber > sw.portCount-sw.sfpCount
+	if portType == "copper" && isSFP {
+		r.errorf("порт %d коммутатора «%s» является SFP портом", portNumber, switchName)
+		return nil
+	}
+	if portType == "sfp" && !isSFP {
+		r.warnf("порт %d коммутатора «%s» не является SFP портом", portNumber, switchName)
+	}
+
+	if ref.switchID != 0 {
+		port, ok := sw.ports[portNumber]
+		if !ok {
+			r.errorf("порт %d коммутатора «%s» не найден", portNumber, switchName)
+			return nil
+		}
+		if port.LinkedCameraID != nil || port.LinkedSwitchID != nil {
+			r.errorf("порт %d коммутатора «%s» уже занят", portNumber, switchName)
+			return nil
+		}
+		ref.portID = port.ID
+	}
+
+	if p.usedPorts[ref.key()] {
+		r.errorf("порт %d коммутатора «%s» уже указан в другой строке", portNumber, switchName)
+		return nil
+	}
+
+	return ref
+}
+
+func (ref *importPortRef) key() string {
+	if ref.switchID != 0 {
+		return fmt.Sprintf("%d|%d", ref.switchID, ref.portNumber)
+	}
+	return fmt.Sprintf("new:%s|%d", ref.switchName, ref.portNumber)
+}
+
+// resolve returns switch and port IDs, looking up ports of switches created in this import
+func (p *importPlan) resolve(ref *importPortRef, switchRepo *database.SwitchRepository) (int64, int64, error) {
+	if ref.switchID != 0 {
+		return ref.switchID, ref.portID, nil
+	}
+
+	switchID, ok := p.newSwitchIDs[ref.switchName]
+	if !ok {
+		return 0, 0, fmt.Errorf("switch %q was not created", ref.switchName)
+	}
+	ports, err := switchRepo.GetPorts(switchID)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, port := range ports {
+		if port.PortNumber == ref.portNumber {
+			return switchID, port.ID, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("port %d not found", ref.portNumber)
+}
+
+// deviceInput builds the input for createDevice from a validated row
+func (p *importPlan) deviceInput(r *importRow, switchRepo *database.SwitchRepository) (DeviceInput, error) {
+	v := r.values
+	input := DeviceInput{
+		Name:          r.name,
+		IPAddress:     v[ImportFieldIPAddress],
+		Type:          string(r.deviceType),
+		Manufacturer:  v[ImportFieldManufacturer],
+		Model:         v[ImportFieldModel],
+		CredentialID:  r.credentialID,
+		SNMPCommunity: v[ImportFieldSNMPCommunity],
+		SNMPVersion:   v[ImportFieldSNMPVersion],
+		RTSPURL:       v[ImportFieldRTSPURL],
+		SnapshotURL:   v[ImportFieldSnapshotURL],
+		TCPPorts:      v[ImportFieldTCPPorts],
+	}
+	input.PortCount, _ = strconv.Atoi(v[ImportFieldPortCount])
+	input.SFPPortCount, _ = strconv.Atoi(v[ImportFieldSFPPortCount])
+	input.ONVIFPort, _ = strconv.Atoi(v[ImportFieldONVIFPort])
+
+	if r.port != nil {
+		_, portID, err := p.resolve(r.port, switchRepo)
+		if err != nil {
+			return input, err
+		}
+		input.SwitchPortID = &portID
+	}
+	if r.uplink != nil {
+		switchID, portID, err := p.resolve(r.uplink, switchRepo)
+		if err != nil {
+			return input, err
+		}
+		input.UplinkSwitchID = &switchID
+		input.UplinkPortID = &portID
+	}
+
+	return input, nil
+}
+
+// parseImportDeviceType normalises device type names, returning "" if unknown
+func parseImportDeviceType(value string) models.DeviceType {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "camera", "cam", "камера", "ip камера", "ip-камера":
+		return models.DeviceTypeCamera
+	case "switch", "коммутатор", "свитч":
+		return models.DeviceTypeSwitch
+	case "server", "сервер":
+		return models.DeviceTypeServer
+	case "router", "маршрутизатор", "роутер":
+		return models.DeviceTypeRouter
+	case "access_point", "access point", "ap", "точка доступа":
+		return models.DeviceTypeAccessPoint
+	case "ups", "ибп":
+		return models.DeviceTypeUPS
+	case "nvr", "dvr", "регистратор", "видеорегистратор":
+		return models.DeviceTypeNVR
+	case "pdu", "блок розеток":
+		return models.DeviceTypePDU
+	case "host", "хост":
+		return models.DeviceTypeHost
+	}
+	return ""
+}
+
+// suggestImportMapping maps fields to columns by known header aliases
+func suggestImportMapping(headers []string) map[string]string {
+	mapping := make(map[string]string)
+	for _, field := range deviceImportFields {
+		for _, h := range headers {
+			normalized := strings.ToLower(strings.TrimSpace(h))
+			for _, alias := range field.Aliases {
+				if normalized == alias {
+					mapping[field.Key] = h
+					break
+				}
+			}
+			if _, ok := mapping[field.Key]; ok {
+				break
+			}
+		}
+	}
+	return mapping
+}
+
+func indexOfHeader(headers []string, header string) int {
+	for i, h := range headers {
+		if h == header {
+			return i
+		}
+	}
+	for i, h := range headers {
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(header)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// readImportTable reads headers and data rows from a CSV or XLSX file
+func readImportTable(filePath string) ([]string, [][]string, error) {
+	var rows [][]string
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".xlsx":
+		data, err := xlsx.ReadFile(filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = data
+	case ".csv", ".txt":
+		data, err := readCSVFile(filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = data
+	default:
+		return nil, nil, fmt.Errorf("unsupported file format: %s", filepath.Ext(filePath))
+	}
+
+	// Skip leading empty rows
+	for len(rows) > 0 && isEmptyRow(rows[0]) {
+		rows = rows[1:]
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+
+	headers := make([]string, len(rows[0]))
+	for i, h := range rows[0] {
+		headers[i] = strings.TrimSpace(h)
+	}
+
+	return headers, rows[1:], nil
+}
+
+// readCSVFile reads a CSV file, detecting the delimiter and Windows-1251 encoding
+func readCSVFile(filePath string) ([][]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	if !utf8.Valid(data) {
+		data = decodeWindows1251(data)
+	}
+
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	delimiter := ','
+	best := bytes.Count(firstLine, []byte(","))
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(firstLine, []byte(string(d))); n > best {
+			delimiter, best = d, n
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	return rows, nil
+}
+
+func isEmptyRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// windows1251High maps bytes 0x80-0xBF of Windows-1251 to Unicode
+var windows1251High = [64]rune{
+	0x0402, 0x0403, 0x201A, 0x0453, 0x201E, 0x2026, 0x2020, 0x2021, 0x20AC, 0x2030, 0x0409, 0x2039, 0x040A, 0x040C, 0x040B, 0x040F,
+	0x0452, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, 0xFFFD, 0x2122, 0x0459, 0x203A, 0x045A, 0x045C, 0x045B, 0x045F,
+	0x00A0, 0x040E, 0x045E, 0x0408, 0x00A4, 0x0490, 0x00A6, 0x00A7, 0x0401, 0x00A9, 0x0404, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x0407,
+	0x00B0, 0x00B1, 0x0406, 0x0456, 0x0491, 0x00B5, 0x00B6, 0x00B7, 0x0451, 0x2116, 0x0454, 0x00BB, 0x0458, 0x0405, 0x0455, 0x0457,
+}
+
+// decodeWindows1251 converts Windows-1251 text (Excel's default CSV encoding for Russian locale) to UTF-8
+func decodeWindows1251(data []byte) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(data) * 2)
+	for _, b := range data {
+		switch {
+		case b < 0x80:
+			buf.WriteByte(b)
+		case b < 0xC0:
+			buf.WriteRune(windows1251High[b-0x80])
+		default:
+			buf.WriteRune(rune(b-0xC0) + 0x0410) // А..я
+		}
+	}
+	return buf.Bytes()
+}
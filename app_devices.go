@@ -1,8 +1,10 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 
 	"netvisionmonitor/internal/database"
 	"netvisionmonitor/internal/models"
@@ -21,10 +23,11 @@ type DeviceInput struct {
 	CredentialID *int64 `json:"credential_id,omitempty"`
 
 	// Switch-specific
-	SNMPCommunity string `json:"snmp_community,omitempty"`
-	SNMPVersion   string `json:"snmp_version,omitempty"`
-	PortCount     int    `json:"port_count,omitempty"`
-	SFPPortCount  int    `json:"sfp_port_count,omitempty"`
+	SNMPCommunity string  `json:"snmp_community,omitempty"`
+	SNMPVersion   string  `json:"snmp_version,omitempty"`
+	PortCount     int     `json:"port_count,omitempty"`
+	SFPPortCount  int     `json:"sfp_port_count,omitempty"`
+	PoEBudgetW    float64 `json:"poe_budget_w,omitempty"` // 0 = reported by the switch
 
 	// SNMPv3-specific
 	SNMPv3User      string `json:"snmpv3_user,omitempty"`
@@ -45,13 +48,25 @@ type DeviceInput struct {
 	TCPPorts string `json:"tcp_ports,omitempty"`
 	UseSNMP  bool   `json:"use_snmp,omitempty"`
 
-	// Uplink settings (for switches and servers)
+	// NVR-specific, SNMP settings are shared with switches
+	CheckHTTP bool `json:"check_http,omitempty"`
+	HTTPPort  int  `json:"http_port,omitempty"`
+
+	// UPS-specific: "snmp" (UPS-MIB) or "nut" (Network UPS Tools upsd)
+	UPSProtocol string `json:"ups_protocol,omitempty"`
+	NUTPort     int    `json:"nut_port,omitempty"`
+	NUTUPSName  string `json:"nut_ups_name,omitempty"`
+
+	// Uplink settings (for switches, servers and SNMP devices)
 	UplinkSwitchID *int64 `json:"uplink_switch_id,omitempty"` // Parent switch ID
 	UplinkPortID   *int64 `json:"uplink_port_id,omitempty"`   // SFP port ID on parent switch
 }
 
 // GetDevices returns all devices
 func (a *App) GetDevices() ([]models.Device, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -64,6 +79,9 @@ type DeviceFilterInput struct {
 	Type      string `json:"type"`
 	Status    string `json:"status"`
 	Search    string `json:"search"`
+	SiteID    *int64 `json:"site_id,omitempty"`
+	GroupID   *int64 `json:"group_id,omitempty"` // Includes subgroups
+	Tag       string `json:"tag,omitempty"`
 	Page      int    `json:"page"`
 	PageSize  int    `json:"page_size"`
 	SortBy    string `json:"sort_by"`
@@ -72,6 +90,9 @@ type DeviceFilterInput struct {
 
 // GetDevicesPaginated returns devices with pagination and filtering
 func (a *App) GetDevicesPaginated(filter DeviceFilterInput) (*database.DeviceListResult, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -96,6 +117,9 @@ func (a *App) GetDevicesPaginated(filter DeviceFilterInput) (*database.DeviceLis
 		Type:      filter.Type,
 		Status:    filter.Status,
 		Search:    filter.Search,
+		SiteID:    filter.SiteID,
+		GroupID:   filter.GroupID,
+		Tag:       filter.Tag,
 		Limit:     pageSize,
 		Offset:    offset,
 		SortBy:    filter.SortBy,
@@ -105,6 +129,9 @@ func (a *App) GetDevicesPaginated(filter DeviceFilterInput) (*database.DeviceLis
 
 // GetDevicesByType returns devices of a specific type
 func (a *App) GetDevicesByType(deviceType string) ([]models.Device, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -114,6 +141,9 @@ func (a *App) GetDevicesByType(deviceType string) ([]models.Device, error) {
 
 // GetDevice returns a device by ID with type-specific details
 func (a *App) GetDevice(id int64) (*models.DeviceWithDetails, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		log.Printf("GetDevice(%d): database not initialized", id)
 		return nil, fmt.Errorf("database not initialized")
@@ -166,19 +196,53 @@ func (a *App) GetDevice(id int64) (*models.DeviceWithDetails, error) {
 			return nil, err
 		}
 		result.Server = srv
+
+	case models.DeviceTypeRouter, models.DeviceTypeAccessPoint, models.DeviceTypeUPS,
+		models.DeviceTypeNVR, models.DeviceTypePDU:
+		snmpRepo := database.NewSNMPDeviceRepository(a.db.DB())
+		settings, err := snmpRepo.GetByDeviceID(id)
+		if err != nil {
+			return nil, err
+		}
+		result.SNMP = settings
+
+		if device.Type == models.DeviceTypeNVR {
+			nvr, err := database.NewNVRRepository(a.db.DB()).GetByDeviceID(id)
+			if err != nil {
+				return nil, err
+			}
+			result.NVR = nvr
+		}
+		if device.Type == models.DeviceTypeUPS {
+			ups, err := database.NewUPSRepository(a.db.DB()).GetByDeviceID(id)
+			if err != nil {
+				return nil, err
+			}
+			result.UPS = ups
+		}
 	}
 
 	return result, nil
 }
 
 // CreateDevice creates a new device
-func (a *App) CreateDevice(input DeviceInput) (*models.Device, error) {
+func (a *App) CreateDevice(input DeviceInput) (device *models.Device, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
 	log.Printf("CreateDevice called with input: %+v", input)
 
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
 
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	rec := a.beginAudit("device.create", models.AuditTarget{Type: "device", Name: input.Name}, nil)
+	defer func() { rec.finish(err) }()
+
 	// Validate input
 	if input.Name == "" {
 		return nil, fmt.Errorf("name is required")
@@ -190,6 +254,26 @@ func (a *App) CreateDevice(input DeviceInput) (*models.Device, error) {
 		return nil, fmt.Errorf("type is required")
 	}
 
+	// Camera must be linked to a switch port
+	if models.DeviceType(input.Type) == models.DeviceTypeCamera && input.SwitchPortID == nil {
+		return nil, fmt.Errorf("camera must be linked to a switch port")
+	}
+
+	err = a.db.WithTx(func(tx *sql.Tx) error {
+		var err error
+		device, err = createDevice(tx, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rec.track(device.ID, device.Name, a.deviceAuditState(device.ID))
+	return device, nil
+}
+
+// createDevice inserts a device with its type-specific data and port links
+func createDevice(q database.Querier, input DeviceInput) (*models.Device, error) {
 	device := &models.Device{
 		Name:         input.Name,
 		IPAddress:    input.IPAddress,
@@ -200,7 +284,7 @@ func (a *App) CreateDevice(input DeviceInput) (*models.Device, error) {
 		Status:       models.DeviceStatusUnknown,
 	}
 
-	deviceRepo := database.NewDeviceRepository(a.db.DB())
+	deviceRepo := database.NewDeviceRepository(q)
 	if err := deviceRepo.Create(device); err != nil {
 		return nil, err
 	}
@@ -234,6 +318,7 @@ func (a *App) CreateDevice(input DeviceInput) (*models.Device, error) {
 			SNMPVersion:     snmpVersion,
 			PortCount:       portCount,
 			SFPPortCount:    sfpPortCount,
+			PoEBudgetW:      max(input.PoEBudgetW, 0),
 			SNMPv3User:      input.SNMPv3User,
 			SNMPv3Security:  snmpv3Security,
 			SNMPv3AuthProto: input.SNMPv3AuthProto,
@@ -243,10 +328,8 @@ func (a *App) CreateDevice(input DeviceInput) (*models.Device, error) {
 			UplinkSwitchID:  input.UplinkSwitchID,
 			UplinkPortID:    input.UplinkPortID,
 		}
-		switchRepo := database.NewSwitchRepository(a.db.DB())
+		switchRepo := database.NewSwitchRepository(q)
 		if err := switchRepo.Create(sw); err != nil {
-			// Rollback device creation
-			deviceRepo.Delete(device.ID)
 			return nil, err
 		}
 
@@ -258,9 +341,7 @@ func (a *App) CreateDevice(input DeviceInput) (*models.Device, error) {
 		}
 
 	case models.DeviceTypeCamera:
-		// Camera must be linked to a switch port
 		if input.SwitchPortID == nil {
-			deviceRepo.Delete(device.ID)
 			return nil, fmt.Errorf("camera must be linked to a switch port")
 		}
 
@@ -280,18 +361,14 @@ func (a *App) CreateDevice(input DeviceInput) (*models.Device, error) {
 			SnapshotURL: input.SnapshotURL,
 			StreamType:  streamType,
 		}
-		cameraRepo := database.NewCameraRepository(a.db.DB())
+		cameraRepo := database.NewCameraRepository(q)
 		if err := cameraRepo.Create(cam); err != nil {
-			deviceRepo.Delete(device.ID)
 			return nil, fmt.Errorf("failed to create camera: %w", err)
 		}
 
 		// Link camera to switch port
-		switchRepo := database.NewSwitchRepository(a.db.DB())
+		switchRepo := database.NewSwitchRepository(q)
 		if err := switchRepo.LinkCamera(*input.SwitchPortID, device.ID); err != nil {
-			// Rollback in correct order: camera first, then device
-			cameraRepo.Delete(device.ID)
-			deviceRepo.Delete(device.ID)
 			return nil, fmt.Errorf("failed to link camera to port: %w", err)
 		}
 
@@ -308,30 +385,127 @@ func (a *App) CreateDevice(input DeviceInput) (*models.Device, error) {
 			UplinkSwitchID: input.UplinkSwitchID,
 			UplinkPortID:   input.UplinkPortID,
 		}
-		serverRepo := database.NewServerRepository(a.db.DB())
+		serverRepo := database.NewServerRepository(q)
 		if err := serverRepo.Create(srv); err != nil {
-			deviceRepo.Delete(device.ID)
 			return nil, err
 		}
 
 		// Link to parent switch port if uplink is set
 		if input.UplinkPortID != nil {
-			switchRepo := database.NewSwitchRepository(a.db.DB())
+			switchRepo := database.NewSwitchRepository(q)
 			if err := switchRepo.LinkSwitch(*input.UplinkPortID, device.ID); err != nil {
 				log.Printf("Warning: failed to link server to uplink port: %v", err)
 			}
 		}
+
+	case models.DeviceTypeRouter, models.DeviceTypeAccessPoint, models.DeviceTypeUPS,
+		models.DeviceTypeNVR, models.DeviceTypePDU:
+		snmpRepo := database.NewSNMPDeviceRepository(q)
+		if err := snmpRepo.Create(snmpDeviceFromInput(device.ID, input)); err != nil {
+			return nil, err
+		}
+
+		if device.Type == models.DeviceTypeNVR {
+			nvrRepo := database.NewNVRRepository(q)
+			if err := nvrRepo.Create(nvrFromInput(device.ID, input)); err != nil {
+				return nil, err
+			}
+		}
+		if device.Type == models.DeviceTypeUPS {
+			upsRepo := database.NewUPSRepository(q)
+			if err := upsRepo.Create(upsFromInput(device.ID, input)); err != nil {
+				return nil, err
+			}
+		}
+
+		// Link to parent switch port if uplink is set
+		if input.UplinkPortID != nil {
+			switchRepo := database.NewSwitchRepository(q)
+			if err := switchRepo.LinkSwitch(*input.UplinkPortID, device.ID); err != nil {
+				log.Printf("Warning: failed to link %s to uplink port: %v", device.Type, err)
+			}
+		}
 	}
 
 	return device, nil
 }
 
+// snmpDeviceFromInput returns SNMP settings of a router, access point, UPS, NVR or PDU
+func snmpDeviceFromInput(deviceID int64, input DeviceInput) *models.SNMPDevice {
+	snmpVersion := input.SNMPVersion
+	if snmpVersion == "" {
+		snmpVersion = "v2c"
+	}
+	snmpv3Security := input.SNMPv3Security
+	if snmpv3Security == "" {
+		snmpv3Security = "noAuthNoPriv"
+	}
+
+	return &models.SNMPDevice{
+		DeviceID:        deviceID,
+		SNMPCommunity:   input.SNMPCommunity,
+		SNMPVersion:     snmpVersion,
+		SNMPv3User:      input.SNMPv3User,
+		SNMPv3Security:  snmpv3Security,
+		SNMPv3AuthProto: input.SNMPv3AuthProto,
+		SNMPv3AuthPass:  input.SNMPv3AuthPass,
+		SNMPv3PrivProto: input.SNMPv3PrivProto,
+		SNMPv3PrivPass:  input.SNMPv3PrivPass,
+		UplinkSwitchID:  input.UplinkSwitchID,
+		UplinkPortID:    input.UplinkPortID,
+	}
+}
+
+// nvrFromInput returns NVR settings
+func nvrFromInput(deviceID int64, input DeviceInput) *models.NVR {
+	httpPort := input.HTTPPort
+	if httpPort <= 0 {
+		httpPort = 80
+	}
+	return &models.NVR{
+		DeviceID:  deviceID,
+		CheckHTTP: input.CheckHTTP,
+		HTTPPort:  httpPort,
+	}
+}
+
+// upsFromInput returns UPS settings
+func upsFromInput(deviceID int64, input DeviceInput) *models.UPS {
+	protocol := input.UPSProtocol
+	if protocol != "nut" {
+		protocol = "snmp"
+	}
+	nutPort := input.NUTPort
+	if nutPort <= 0 {
+		nutPort = 3493
+	}
+	nutUPSName := strings.TrimSpace(input.NUTUPSName)
+	if nutUPSName == "" {
+		nutUPSName = "ups"
+	}
+	return &models.UPS{
+		DeviceID:   deviceID,
+		Protocol:   protocol,
+		NUTPort:    nutPort,
+		NUTUPSName: nutUPSName,
+	}
+}
+
 // UpdateDevice updates an existing device
-func (a *App) UpdateDevice(input DeviceInput) error {
+func (a *App) UpdateDevice(input DeviceInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	rec := a.beginAudit("device.update", a.deviceAuditTarget(input.ID), a.deviceAuditState(input.ID))
+	defer func() { rec.finish(err) }()
+
 	if input.ID == 0 {
 		return fmt.Errorf("device ID is required")
 	}
@@ -384,6 +558,7 @@ func (a *App) UpdateDevice(input DeviceInput) error {
 			SNMPVersion:     input.SNMPVersion,
 			PortCount:       input.PortCount,
 			SFPPortCount:    sfpPortCount,
+			PoEBudgetW:      max(input.PoEBudgetW, 0),
 			SNMPv3User:      input.SNMPv3User,
 			SNMPv3Security:  snmpv3Security,
 			SNMPv3AuthProto: input.SNMPv3AuthProto,
@@ -464,17 +639,68 @@ func (a *App) UpdateDevice(input DeviceInput) error {
 			// Link to new port
 			switchRepo.LinkSwitch(*input.UplinkPortID, existing.ID)
 		}
+
+	case models.DeviceTypeRouter, models.DeviceTypeAccessPoint, models.DeviceTypeUPS,
+		models.DeviceTypeNVR, models.DeviceTypePDU:
+		// Get old uplink to manage port links
+		snmpRepo := database.NewSNMPDeviceRepository(a.db.DB())
+		oldSettings, _ := snmpRepo.GetByDeviceID(existing.ID)
+		var oldUplinkPortID *int64
+		if oldSettings != nil {
+			oldUplinkPortID = oldSettings.UplinkPortID
+		}
+
+		if err := snmpRepo.Update(snmpDeviceFromInput(existing.ID, input)); err != nil {
+			return err
+		}
+		if existing.Type == models.DeviceTypeNVR {
+			nvrRepo := database.NewNVRRepository(a.db.DB())
+			if err := nvrRepo.Update(nvrFromInput(existing.ID, input)); err != nil {
+				return err
+			}
+		}
+		if existing.Type == models.DeviceTypeUPS {
+			// UPS units added before NUT support have no UPS settings yet
+			upsRepo := database.NewUPSRepository(a.db.DB())
+			current, err := upsRepo.GetByDeviceID(existing.ID)
+			if err != nil {
+				return err
+			}
+			if current == nil {
+				err = upsRepo.Create(upsFromInput(existing.ID, input))
+			} else {
+				err = upsRepo.Update(upsFromInput(existing.ID, input))
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		// Update port links if uplink changed
+		switchRepo := database.NewSwitchRepository(a.db.DB())
+		if oldUplinkPortID != nil && (input.UplinkPortID == nil || *oldUplinkPortID != *input.UplinkPortID) {
+			switchRepo.UnlinkSwitch(*oldUplinkPortID)
+		}
+		if input.UplinkPortID != nil && (oldUplinkPortID == nil || *oldUplinkPortID != *input.UplinkPortID) {
+			switchRepo.LinkSwitch(*input.UplinkPortID, existing.ID)
+		}
 	}
 
 	return nil
 }
 
 // DeleteDevice deletes a device by ID
-func (a *App) DeleteDevice(id int64) error {
+func (a *App) DeleteDevice(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("device.delete", a.deviceAuditTarget(id), a.deviceAuditState(id))
+	defer func() { rec.finish(err) }()
+
 	// Get device to check type
 	deviceRepo := database.NewDeviceRepository(a.db.DB())
 	device, err := deviceRepo.GetByID(id)
@@ -509,6 +735,9 @@ func (a *App) DeleteDevice(id int64) error {
 
 // GetDeviceStats returns device statistics
 func (a *App) GetDeviceStats() (map[string]int, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
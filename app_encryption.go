@@ -0,0 +1,211 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/models"
+)
+
+// settingsKeyKeyCheck holds a known value encrypted with the master key. It is
+// re-encrypted together with the secrets, so after a crash during key rotation it
+// shows which key the stored values belong to.
+const settingsKeyKeyCheck = "encryption_key_check"
+
+const (
+	keyCheckValue       = "netvisionmonitor"
+	minMasterPassphrase = 8
+)
+
+// secretSettingKeys lists the settings stored encrypted with the master key
+var secretSettingKeys = []string{settingsKeySMTPPassword}
+
+// EncryptionStatus describes how the master key is stored
+type EncryptionStatus struct {
+	Protected bool `json:"protected"` // The key file is sealed with a master passphrase
+}
+
+// UnlockMasterKey loads the master key protected by a passphrase and starts the
+// services waiting for it. Available without login, the passphrase is the check.
+func (a *App) UnlockMasterKey(passphrase string) (_ *models.SessionInfo, err error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if encryption.IsInitialized() {
+		return a.emitSession(), nil
+	}
+
+	rec := a.beginAudit("encryption.unlock", models.AuditTarget{Type: "system"}, nil)
+	defer func() { rec.finish(err) }()
+
+	now := time.Now()
+	if err := a.session.AllowLogin(now); err != nil {
+		return nil, err
+	}
+	if err := encryption.Unlock(a.cfg.DataDir, passphrase); err != nil {
+		if err == encryption.ErrWrongPassphrase {
+			a.session.LoginFailed(now)
+		}
+		return nil, err
+	}
+
+	log.Println("Master key unlocked")
+	a.startServices()
+	return a.emitSession(), nil
+}
+
+// GetEncryptionStatus returns how the master key is stored
+func (a *App) GetEncryptionStatus() (*EncryptionStatus, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	return &EncryptionStatus{Protected: encryption.IsProtected()}, nil
+}
+
+// SetMasterPassphrase protects the master key with a passphrase that must be
+// entered at every start. If the key is already protected, the current passphrase
+// is required. The key itself does not change.
+func (a *App) SetMasterPassphrase(currentPassphrase, passphrase string) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(passphrase) < minMasterPassphrase {
+		return fmt.Errorf("passphrase must be at least %d characters", minMasterPassphrase)
+	}
+
+	a.secretsMu.Lock()
+	defer a.secretsMu.Unlock()
+
+	rec := a.beginAudit("encryption.passphrase", models.AuditTarget{Type: "system"}, nil).
+		detail("protected", true)
+	defer func() { rec.finish(err) }()
+
+	if err := a.checkMasterPassphrase(currentPassphrase); err != nil {
+		return err
+	}
+	if err := encryption.SetPassphrase(a.cfg.DataDir, passphrase); err != nil {
+		return err
+	}
+	log.Println("Master key protected by a passphrase")
+	return nil
+}
+
+// RemoveMasterPassphrase stores the master key unprotected again, so the
+// application starts without asking for the passphrase
+func (a *App) RemoveMasterPassphrase(currentPassphrase string) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if !encryption.IsProtected() {
+		return nil
+	}
+
+	a.secretsMu.Lock()
+	defer a.secretsMu.Unlock()
+
+	rec := a.beginAudit("encryption.passphrase", models.AuditTarget{Type: "system"}, nil).
+		detail("protected", false)
+	defer func() { rec.finish(err) }()
+
+	if err := a.checkMasterPassphrase(currentPassphrase); err != nil {
+		return err
+	}
+	if err := encryption.SetPassphrase(a.cfg.DataDir, ""); err != nil {
+		return err
+	}
+	log.Println("Master passphrase removed")
+	return nil
+}
+
+// checkMasterPassphrase verifies the passphrase of a protected master key
+func (a *App) checkMasterPassphrase(passphrase string) error {
+	if !encryption.IsProtected() {
+		return nil
+	}
+	now := time.Now()
+	if err := a.session.AllowLogin(now); err != nil {
+		return err
+	}
+	err := encryption.VerifyPassphrase(a.cfg.DataDir, passphrase)
+	if err == encryption.ErrWrongPassphrase {
+		a.session.LoginFailed(now)
+	}
+	return err
+}
+
+// RotateEncryptionKey replaces the master key and re-encrypts all credentials,
+// switch SNMP secrets, camera stream URLs and notification secrets in one
+// transaction. If anything fails, the old key and values stay in place. Values
+// saved meanwhile wait until the new key is current.
+func (a *App) RotateEncryptionKey() (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	// Writers wait until the new key is current, values they store in between
+	// would be encrypted with the old one
+	a.secretsMu.Lock()
+	defer a.secretsMu.Unlock()
+
+	rec := a.beginAudit("encryption.rotate", models.AuditTarget{Type: "system"}, nil)
+	defer func() { rec.finish(err) }()
+
+	rotation, err := encryption.BeginRotation(a.cfg.DataDir)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = a.db.WithTx(func(tx *sql.Tx) error {
+		var err error
+		count, err = database.ReencryptSecrets(tx, secretSettingKeys, rotation.Reencrypt)
+		if err != nil {
+			return err
+		}
+		check, err := rotation.Encrypt(keyCheckValue)
+		if err != nil {
+			return err
+		}
+		return database.NewSettingsRepository(tx).Set(settingsKeyKeyCheck, check)
+	})
+	if err != nil {
+		rotation.Abort()
+		return fmt.Errorf("failed to re-encrypt secrets: %w", err)
+	}
+	rec.detail("values", count)
+
+	// The values are stored with the new key now. If the key file cannot be
+	// replaced, the pending key is picked up again at the next start.
+	if err := rotation.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Master key rotated, %d values re-encrypted", count)
+	return nil
+}
+
+// resolvePendingKeyRotation finishes or discards a key rotation interrupted by a
+// crash, depending on which key the stored values were encrypted with
+func (a *App) resolvePendingKeyRotation() {
+	check, err := database.NewSettingsRepository(a.db.DB()).Get(settingsKeyKeyCheck)
+	if err != nil {
+		log.Printf("Failed to check pending key rotation: %v", err)
+		return
+	}
+	promoted, err := encryption.ResolvePendingRotation(a.cfg.DataDir, check)
+	if err != nil {
+		log.Printf("Failed to resolve pending key rotation: %v", err)
+		return
+	}
+	if promoted {
+		log.Println("Finished interrupted master key rotation")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"netvisionmonitor/internal/auth"
+	"netvisionmonitor/internal/config"
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/models"
+)
+
+// storedSMTPPassword decrypts the stored SMTP password with the current master key
+func storedSMTPPassword(t *testing.T, a *App) string {
+	t.Helper()
+	stored, err := database.NewSettingsRepository(a.db.DB()).Get(settingsKeySMTPPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := encryption.DecryptIfNotEmpty(stored)
+	if err != nil {
+		t.Fatalf("SMTP password does not decrypt with the current key: %v", err)
+	}
+	return password
+}
+
+func TestRotateEncryptionKeyWaitsForWriters(t *testing.T) {
+	dir := t.TempDir()
+	if err := encryption.Initialize(dir); err != nil {
+		t.Fatal(err)
+	}
+	a := &App{db: openTestDatabase(t), cfg: &config.Config{DataDir: dir}, session: auth.NewSession()}
+	a.session.Start(&models.User{ID: 1, Username: "admin", Role: models.RoleAdmin}, time.Now())
+
+	if err := a.SetSMTPPassword("before"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A writer is storing a value encrypted with the current key
+	a.secretsMu.RLock()
+
+	rotated := make(chan error, 1)
+	go func() { rotated <- a.RotateEncryptionKey() }()
+
+	// New readers are refused once the rotation waits for the lock
+	deadline := time.Now().Add(5 * time.Second)
+	for a.secretsMu.TryRLock() {
+		a.secretsMu.RUnlock()
+		select {
+		case err := <-rotated:
+			a.secretsMu.RUnlock()
+			t.Fatalf("rotation finished while a writer was storing a value: %v", err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			a.secretsMu.RUnlock()
+			t.Fatal("rotation does not wait for writers")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A value saved during the rotation is stored once the new key is current
+	written := make(chan error, 1)
+	go func() { written <- a.SetSMTPPassword("during") }()
+
+	a.secretsMu.RUnlock()
+	if err := <-rotated; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-written; err != nil {
+		t.Fatal(err)
+	}
+
+	if password := storedSMTPPassword(t, a); password != "during" {
+		t.Errorf("SMTP password %q, want %q", password, "during")
+	}
+}
@@ -0,0 +1,601 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/mailer"
+	"netvisionmonitor/internal/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// OnCallScheduleInput is used to create or update an on-call schedule
+type OnCallScheduleInput struct {
+	ID            int64                 `json:"id,omitempty"`
+	Name          string                `json:"name"`
+	RotationStart string                `json:"rotation_start"` // RFC 3339, first handoff
+	Members       []models.OnCallMember `json:"members"`
+}
+
+// OnCallOverrideInput is used to add an on-call override
+type OnCallOverrideInput struct {
+	ScheduleID int64  `json:"schedule_id"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	StartAt    string `json:"start_at"` // RFC 3339
+	EndAt      string `json:"end_at"`   // RFC 3339
+	Reason     string `json:"reason"`
+}
+
+// EscalationPolicyInput is used to create or update an escalation policy
+type EscalationPolicyInput struct {
+	ID         int64                   `json:"id,omitempty"`
+	Name       string                  `json:"name"`
+	DeviceID   *int64                  `json:"device_id,omitempty"`
+	DeviceType string                  `json:"device_type,omitempty"`
+	Enabled    bool                    `json:"enabled"`
+	Steps      []models.EscalationStep `json:"steps"`
+}
+
+// GetOnCallSchedules returns all on-call schedules with their overrides
+func (a *App) GetOnCallSchedules() ([]models.OnCallSchedule, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	return repo.GetAllSchedules()
+}
+
+// CreateOnCallSchedule creates an on-call schedule
+func (a *App) CreateOnCallSchedule(input OnCallScheduleInput) (_ *models.OnCallSchedule, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("oncall.schedule_create", models.AuditTarget{Type: "oncall_schedule", Name: input.Name}, nil)
+	defer func() { rec.finish(err) }()
+
+	schedule, err := onCallScheduleFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	if err := repo.CreateSchedule(schedule); err != nil {
+		return nil, err
+	}
+	rec.track(schedule.ID, schedule.Name, a.rowAuditState("oncall_schedules", "id", schedule.ID))
+	schedule.Overrides = []models.OnCallOverride{}
+	return schedule, nil
+}
+
+// UpdateOnCallSchedule updates an on-call schedule
+func (a *App) UpdateOnCallSchedule(input OnCallScheduleInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("oncall.schedule_update", auditTarget("oncall_schedule", input.ID, input.Name), a.rowAuditState("oncall_schedules", "id", input.ID))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	existing, err := repo.GetScheduleByID(input.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("on-call schedule not found")
+	}
+
+	schedule, err := onCallScheduleFromInput(input)
+	if err != nil {
+		return err
+	}
+	schedule.ID = input.ID
+	return repo.UpdateSchedule(schedule)
+}
+
+// DeleteOnCallSchedule removes an on-call schedule. Escalation steps notifying it
+// keep their other contacts.
+func (a *App) DeleteOnCallSchedule(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("oncall.schedule_delete", auditTarget("oncall_schedule", id, ""), a.rowAuditState("oncall_schedules", "id", id))
+	defer func() { rec.finish(err) }()
+
+	return a.db.WithTx(func(tx *sql.Tx) error {
+		return database.NewEscalationRepository(tx).DeleteSchedule(id)
+	})
+}
+
+// AddOnCallOverride puts someone else on call for a period
+func (a *App) AddOnCallOverride(input OnCallOverrideInput) (_ *models.OnCallOverride, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("oncall.override_add", models.AuditTarget{Type: "oncall_override", Name: input.Name}, nil).detail("schedule_id", input.ScheduleID)
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	schedule, err := repo.GetScheduleByID(input.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("on-call schedule not found")
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	start, err := time.Parse(time.RFC3339, input.StartAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, input.EndAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end time: %w", err)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+
+	override := &models.OnCallOverride{
+		ScheduleID: input.ScheduleID,
+		Name:       name,
+		Email:      strings.TrimSpace(input.Email),
+		StartAt:    start.Local(),
+		EndAt:      end.Local(),
+		Reason:     input.Reason,
+	}
+	if err := repo.CreateOverride(override); err != nil {
+		return nil, err
+	}
+	rec.track(override.ID, override.Name, a.rowAuditState("oncall_overrides", "id", override.ID))
+	return override, nil
+}
+
+// DeleteOnCallOverride removes an on-call override
+func (a *App) DeleteOnCallOverride(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("oncall.override_delete", auditTarget("oncall_override", id, ""), a.rowAuditState("oncall_overrides", "id", id))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	return repo.DeleteOverride(id)
+}
+
+// GetOnCallNow returns who is on call for a schedule right now, nil if nobody
+func (a *App) GetOnCallNow(scheduleID int64) (*models.OnCallMember, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	schedule, err := repo.GetScheduleByID(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("on-call schedule not found")
+	}
+	return schedule.OnCallAt(time.Now()), nil
+}
+
+func onCallScheduleFromInput(input OnCallScheduleInput) (*models.OnCallSchedule, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	start, err := time.Parse(time.RFC3339, input.RotationStart)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rotation start: %w", err)
+	}
+
+	members := make([]models.OnCallMember, 0, len(input.Members))
+	for i, m := range input.Members {
+		m.Name = strings.TrimSpace(m.Name)
+		m.Email = strings.TrimSpace(m.Email)
+		if m.Name == "" {
+			return nil, fmt.Errorf("member %d: name is required", i+1)
+		}
+		members = append(members, m)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("at least one member is required")
+	}
+
+	return &models.OnCallSchedule{
+		Name:          name,
+		RotationStart: start.Local(),
+		Members:       members,
+	}, nil
+}
+
+// GetEscalationPolicies returns all escalation policies
+func (a *App) GetEscalationPolicies() ([]models.EscalationPolicy, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	return repo.GetAllPolicies()
+}
+
+// CreateEscalationPolicy creates an escalation policy
+func (a *App) CreateEscalationPolicy(input EscalationPolicyInput) (_ *models.EscalationPolicy, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("escalation.policy_create", models.AuditTarget{Type: "escalation_policy", Name: input.Name}, nil)
+	defer func() { rec.finish(err) }()
+
+	policy, err := a.escalationPolicyFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	if err := repo.CreatePolicy(policy); err != nil {
+		return nil, err
+	}
+	rec.track(policy.ID, policy.Name, a.rowAuditState("escalation_policies", "id", policy.ID))
+	return policy, nil
+}
+
+// UpdateEscalationPolicy updates an escalation policy
+func (a *App) UpdateEscalationPolicy(input EscalationPolicyInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("escalation.policy_update", auditTarget("escalation_policy", input.ID, input.Name), a.rowAuditState("escalation_policies", "id", input.ID))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	existing, err := repo.GetPolicyByID(input.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("escalation policy not found")
+	}
+
+	policy, err := a.escalationPolicyFromInput(input)
+	if err != nil {
+		return err
+	}
+	policy.ID = input.ID
+	return repo.UpdatePolicy(policy)
+}
+
+// DeleteEscalationPolicy removes an escalation policy
+func (a *App) DeleteEscalationPolicy(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("escalation.policy_delete", auditTarget("escalation_policy", id, ""), a.rowAuditState("escalation_policies", "id", id))
+	defer func() { rec.finish(err) }()
+
+	return a.db.WithTx(func(tx *sql.Tx) error {
+		return database.NewEscalationRepository(tx).DeletePolicy(id)
+	})
+}
+
+// GetIncidentEscalations returns the tiers notified about an incident
+func (a *App) GetIncidentEscalations(incidentID int64) ([]models.IncidentEscalation, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	return repo.GetEscalations(incidentID)
+}
+
+func (a *App) escalationPolicyFromInput(input EscalationPolicyInput) (*models.EscalationPolicy, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(input.Steps) == 0 {
+		return nil, fmt.Errorf("at least one escalation step is required")
+	}
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	steps := make([]models.EscalationStep, 0, len(input.Steps))
+	for i, step := range input.Steps {
+		if step.DelayMinutes < 0 {
+			return nil, fmt.Errorf("step %d: delay must not be negative", i+1)
+		}
+		if i > 0 && step.DelayMinutes <= steps[i-1].DelayMinutes {
+			return nil, fmt.Errorf("step %d: delay must be longer than the previous step", i+1)
+		}
+		step.Contacts = strings.Join(mailer.ParseAddresses(step.Contacts), ", ")
+		if step.ScheduleID == nil && step.Contacts == "" {
+			return nil, fmt.Errorf("step %d: on-call schedule or contacts are required", i+1)
+		}
+		if step.ScheduleID != nil {
+			schedule, err := repo.GetScheduleByID(*step.ScheduleID)
+			if err != nil {
+				return nil, err
+			}
+			if schedule == nil {
+				return nil, fmt.Errorf("step %d: on-call schedule not found", i+1)
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	policy := &models.EscalationPolicy{
+		Name:    name,
+		Enabled: input.Enabled,
+		Steps:   steps,
+	}
+
+	if input.DeviceID != nil {
+		device, err := database.NewDeviceRepository(a.db.DB()).GetByID(*input.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		if device == nil {
+			return nil, fmt.Errorf("device not found")
+		}
+		policy.DeviceID = input.DeviceID
+	} else if input.DeviceType != "" {
+		deviceType := models.DeviceType(input.DeviceType)
+		if !deviceType.Valid() {
+			return nil, fmt.Errorf("invalid device type: %s", input.DeviceType)
+		}
+		policy.DeviceType = deviceType
+	}
+
+	return policy, nil
+}
+
+// runEscalations notifies the next tiers about offline incidents that stay
+// unacknowledged. Notified tiers are persisted, so a restart neither repeats
+// nor skips them.
+func (a *App) runEscalations() {
+	if a.db == nil {
+		return
+	}
+
+	repo := database.NewEscalationRepository(a.db.DB())
+	policies, err := repo.GetAllPolicies()
+	if err != nil {
+		log.Printf("Failed to get escalation policies: %v", err)
+		return
+	}
+	if len(policies) == 0 {
+		return
+	}
+
+	incidents, err := database.NewIncidentRepository(a.db.DB()).GetOpen()
+	if err != nil {
+		log.Printf("Failed to get open incidents: %v", err)
+		return
+	}
+
+	groups, err := a.loadGroups()
+	if err != nil {
+		log.Printf("Failed to load device groups: %v", err)
+		return
+	}
+
+	deviceRepo := database.NewDeviceRepository(a.db.DB())
+	now := time.Now()
+	for i := range incidents {
+		inc := &incidents[i]
+		if inc.Acknowledged() || inc.Kind != models.IncidentKindOffline {
+			continue
+		}
+
+		device, err := deviceRepo.GetByID(inc.DeviceID)
+		if err != nil || device == nil {
+			continue
+		}
+
+		groupPolicyID := resolveGroupSettings(groups, device.GroupID).EscalationPolicyID
+		policy := selectEscalationPolicy(policies, device, groupPolicyID)
+		if policy == nil {
+			continue
+		}
+		a.escalateIncident(repo, inc, device, policy, now)
+	}
+}
+
+// selectEscalationPolicy returns the most specific enabled policy for a device:
+// one for the device itself, then the one set for its group, then one for its
+// type, then a global one
+func selectEscalationPolicy(policies []models.EscalationPolicy, device *models.Device, groupPolicyID *int64) *models.EscalationPolicy {
+	var byGroup, byType, global *models.EscalationPolicy
+	for i := range policies {
+		p := &policies[i]
+		if !p.Enabled {
+			continue
+		}
+		if groupPolicyID != nil && p.ID == *groupPolicyID {
+			byGroup = p
+		}
+		if !p.AppliesTo(device.ID, device.Type) {
+			continue
+		}
+		switch {
+		case p.DeviceID != nil:
+			return p
+		case p.DeviceType != "":
+			if byType == nil {
+				byType = p
+			}
+		default:
+			if global == nil {
+				global = p
+			}
+		}
+	}
+	if byGroup != nil {
+		return byGroup
+	}
+	if byType != nil {
+		return byType
+	}
+	return global
+}
+
+// escalateIncident notifies every tier of a policy whose delay has passed and
+// which has not been notified yet
+func (a *App) escalateIncident(repo *database.EscalationRepository, inc *models.Incident, device *models.Device, policy *models.EscalationPolicy, now time.Time) {
+	for i, step := range policy.Steps {
+		if now.Sub(inc.StartedAt) < time.Duration(step.DelayMinutes)*time.Minute {
+			return
+		}
+
+		// Record first so that a failing delivery is not retried every tick
+		escalation := &models.IncidentEscalation{
+			IncidentID: inc.ID,
+			PolicyID:   policy.ID,
+			Level:      i + 1,
+			NotifiedAt: now,
+		}
+		recorded, err := repo.RecordEscalation(escalation)
+		if err != nil {
+			log.Printf("Failed to record escalation of incident %d: %v", inc.ID, err)
+			return
+		}
+		if !recorded {
+			continue
+		}
+
+		recipients, names := a.escalationRecipients(repo, step, now)
+		var deliveryErr string
+		if len(recipients) > 0 {
+			if err := a.sendEscalationEmail(inc, device, step, recipients); err != nil {
+				deliveryErr = err.Error()
+				log.Printf("Failed to send escalation of incident %d: %v", inc.ID, err)
+			}
+		} else {
+			deliveryErr = "no recipients"
+		}
+		escalation.Recipients = strings.Join(names, ", ")
+		escalation.Error = deliveryErr
+		if err := repo.UpdateEscalationResult(escalation.ID, escalation.Recipients, deliveryErr); err != nil {
+			log.Printf("Failed to update escalation of incident %d: %v", inc.ID, err)
+		}
+
+		message := fmt.Sprintf("%s offline incident escalated to tier %d (%s)", device.Name, i+1, policy.Name)
+		if escalation.Recipients != "" {
+			message += ": " + escalation.Recipients
+		}
+		a.createEvent(&device.ID, models.EventTypeIncidentEscalated, models.EventLevelWarn, message)
+
+		runtime.EventsEmit(a.ctx, "incident:escalated", map[string]interface{}{
+			"incident":   inc,
+			"escalation": escalation,
+		})
+	}
+}
+
+// escalationRecipients returns e-mail addresses and display names of a step's
+// contacts and of whoever is on call
+func (a *App) escalationRecipients(repo *database.EscalationRepository, step models.EscalationStep, at time.Time) ([]string, []string) {
+	var emails, names []string
+	seen := make(map[string]bool)
+	add := func(name, email string) {
+		key := strings.ToLower(email)
+		if email != "" && seen[key] {
+			return
+		}
+		if email != "" {
+			seen[key] = true
+			emails = append(emails, email)
+		}
+		if name != "" && email != "" {
+			names = append(names, fmt.Sprintf("%s <%s>", name, email))
+		} else if name != "" {
+			names = append(names, name)
+		} else {
+			names = append(names, email)
+		}
+	}
+
+	if step.ScheduleID != nil {
+		schedule, err := repo.GetScheduleByID(*step.ScheduleID)
+		if err != nil {
+			log.Printf("Failed to get on-call schedule %d: %v", *step.ScheduleID, err)
+		} else if schedule != nil {
+			if member := schedule.OnCallAt(at); member != nil {
+				add(member.Name, member.Email)
+			}
+		}
+	}
+	for _, addr := range mailer.ParseAddresses(step.Contacts) {
+		add("", addr)
+	}
+	return emails, names
+}
+
+func (a *App) sendEscalationEmail(inc *models.Incident, device *models.Device, step models.EscalationStep, recipients []string) error {
+	settings := a.appSettings()
+
+	body := fmt.Sprintf(
+		"Устройство %s (%s) недоступно с %s.\nИнцидент не подтверждён в течение %d мин.\nПричина: %s\n",
+		device.Name, device.IPAddress, inc.StartedAt.Format("2006-01-02 15:04:05"), step.DelayMinutes, inc.Message,
+	)
+	return a.sendEmail(settings, mailer.Message{
+		To:      recipients,
+		Subject: fmt.Sprintf("NetVisionMonitor: %s недоступно", device.Name),
+		Body:    body,
+	})
+}
@@ -15,6 +15,9 @@ type EventFilterInput struct {
 	Level     *string `json:"level,omitempty"`
 	StartTime *string `json:"start_time,omitempty"` // ISO 8601 format
 	EndTime   *string `json:"end_time,omitempty"`   // ISO 8601 format
+	SiteID    *int64  `json:"site_id,omitempty"`
+	GroupID   *int64  `json:"group_id,omitempty"` // Includes subgroups
+	Tag       string  `json:"tag,omitempty"`
 	Page      int     `json:"page"`
 	PageSize  int     `json:"page_size"`
 	Limit     int     `json:"limit"`
@@ -23,6 +26,9 @@ type EventFilterInput struct {
 
 // GetEvents returns events with simple limit/offset
 func (a *App) GetEvents(limit, offset int) ([]models.Event, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -40,11 +46,18 @@ func (a *App) GetEvents(limit, offset int) ([]models.Event, error) {
 
 // GetEventsPaginated returns paginated events with filters
 func (a *App) GetEventsPaginated(filter EventFilterInput) (*database.EventListResult, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
 
-	f := models.EventFilter{}
+	f := models.EventFilter{
+		SiteID:  filter.SiteID,
+		GroupID: filter.GroupID,
+		Tag:     filter.Tag,
+	}
 
 	if filter.DeviceID != nil {
 		f.DeviceID = filter.DeviceID
@@ -89,12 +102,18 @@ func (a *App) GetEventsPaginated(filter EventFilterInput) (*database.EventListRe
 
 // GetEventsFiltered returns filtered events
 func (a *App) GetEventsFiltered(filter EventFilterInput) ([]models.Event, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
 
 	f := models.EventFilter{
 		DeviceID: filter.DeviceID,
+		SiteID:   filter.SiteID,
+		GroupID:  filter.GroupID,
+		Tag:      filter.Tag,
 		Limit:    filter.Limit,
 		Offset:   filter.Offset,
 	}
@@ -129,6 +148,9 @@ func (a *App) GetEventsFiltered(filter EventFilterInput) ([]models.Event, error)
 
 // GetRecentEvents returns recent events
 func (a *App) GetRecentEvents(limit int) ([]models.Event, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -143,6 +165,9 @@ func (a *App) GetRecentEvents(limit int) ([]models.Event, error) {
 
 // GetDeviceEvents returns events for a specific device
 func (a *App) GetDeviceEvents(deviceID int64, limit int) ([]models.Event, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -157,6 +182,9 @@ func (a *App) GetDeviceEvents(deviceID int64, limit int) ([]models.Event, error)
 
 // SearchEvents searches events by message
 func (a *App) SearchEvents(query string, limit int) ([]models.Event, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -170,21 +198,33 @@ func (a *App) SearchEvents(query string, limit int) ([]models.Event, error) {
 }
 
 // DeleteEvent deletes an event by ID
-func (a *App) DeleteEvent(id int64) error {
+func (a *App) DeleteEvent(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("event.delete", auditTarget("event", id, ""), a.rowAuditState("events", "id", id))
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewEventRepository(a.db.DB())
 	return repo.Delete(id)
 }
 
 // ClearOldEvents removes events older than specified days
-func (a *App) ClearOldEvents(days int) (int64, error) {
+func (a *App) ClearOldEvents(days int) (deleted int64, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return 0, err
+	}
 	if a.db == nil {
 		return 0, fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("events.clear_old", models.AuditTarget{Type: "events"}, nil).detail("days", days)
+	defer func() { rec.detail("deleted", deleted).finish(err) }()
+
 	if days <= 0 {
 		days = 30
 	}
@@ -195,17 +235,26 @@ func (a *App) ClearOldEvents(days int) (int64, error) {
 }
 
 // ClearEvents removes all events
-func (a *App) ClearEvents() error {
+func (a *App) ClearEvents() (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("events.clear", models.AuditTarget{Type: "events"}, nil)
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewEventRepository(a.db.DB())
 	return repo.DeleteAll()
 }
 
 // GetEventStats returns event statistics
 func (a *App) GetEventStats() (map[string]int, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -0,0 +1,609 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/mailer"
+	"netvisionmonitor/internal/models"
+)
+
+// SiteInput is used to create or update a site
+type SiteInput struct {
+	ID          int64    `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Address     string   `json:"address"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	Description string   `json:"description"`
+}
+
+// GroupInput is used to create or update a device group
+type GroupInput struct {
+	ID          int64                `json:"id,omitempty"`
+	Name        string               `json:"name"`
+	ParentID    *int64               `json:"parent_id,omitempty"`
+	Description string               `json:"description"`
+	Settings    models.GroupSettings `json:"settings"`
+}
+
+// TagCount is a tag with the number of devices carrying it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// maxTagLength limits the length of a device tag
+const maxTagLength = 64
+
+// GetSites returns all sites
+func (a *App) GetSites() ([]models.Site, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewSiteRepository(a.db.DB())
+	return repo.GetAll()
+}
+
+// CreateSite creates a site
+func (a *App) CreateSite(input SiteInput) (_ *models.Site, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("site.create", models.AuditTarget{Type: "site", Name: input.Name}, nil)
+	defer func() { rec.finish(err) }()
+
+	site, err := siteFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := database.NewSiteRepository(a.db.DB())
+	if err := repo.Create(site); err != nil {
+		return nil, err
+	}
+	rec.track(site.ID, site.Name, a.rowAuditState("sites", "id", site.ID))
+	return site, nil
+}
+
+// UpdateSite updates a site
+func (a *App) UpdateSite(input SiteInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("site.update", auditTarget("site", input.ID, input.Name), a.rowAuditState("sites", "id", input.ID))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewSiteRepository(a.db.DB())
+	existing, err := repo.GetByID(input.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("site not found")
+	}
+
+	site, err := siteFromInput(input)
+	if err != nil {
+		return err
+	}
+	site.ID = input.ID
+	return repo.Update(site)
+}
+
+// DeleteSite removes a site. Its devices stay, without a site.
+func (a *App) DeleteSite(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("site.delete", auditTarget("site", id, ""), a.rowAuditState("sites", "id", id))
+	defer func() { rec.finish(err) }()
+
+	return a.db.WithTx(func(tx *sql.Tx) error {
+		return database.NewSiteRepository(tx).Delete(id)
+	})
+}
+
+func siteFromInput(input SiteInput) (*models.Site, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if (input.Latitude == nil) != (input.Longitude == nil) {
+		return nil, fmt.Errorf("both latitude and longitude are required")
+	}
+	if input.Latitude != nil && (*input.Latitude < -90 || *input.Latitude > 90) {
+		return nil, fmt.Errorf("latitude must be between -90 and 90")
+	}
+	if input.Longitude != nil && (*input.Longitude < -180 || *input.Longitude > 180) {
+		return nil, fmt.Errorf("longitude must be between -180 and 180")
+	}
+
+	return &models.Site{
+		Name:        name,
+		Address:     strings.TrimSpace(input.Address),
+		Latitude:    input.Latitude,
+		Longitude:   input.Longitude,
+		Description: input.Description,
+	}, nil
+}
+
+// GetGroups returns all device groups. The tree is formed by parent IDs.
+func (a *App) GetGroups() ([]models.DeviceGroup, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewGroupRepository(a.db.DB())
+	return repo.GetAll()
+}
+
+// CreateGroup creates a device group
+func (a *App) CreateGroup(input GroupInput) (_ *models.DeviceGroup, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("group.create", models.AuditTarget{Type: "group", Name: input.Name}, nil)
+	defer func() { rec.finish(err) }()
+
+	group, err := a.groupFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := database.NewGroupRepository(a.db.DB())
+	if err := repo.Create(group); err != nil {
+		return nil, err
+	}
+	rec.track(group.ID, group.Name, a.rowAuditState("device_groups", "id", group.ID))
+	return group, nil
+}
+
+// UpdateGroup updates a device group
+func (a *App) UpdateGroup(input GroupInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("group.update", auditTarget("group", input.ID, input.Name), a.rowAuditState("device_groups", "id", input.ID))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewGroupRepository(a.db.DB())
+	existing, err := repo.GetByID(input.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("group not found")
+	}
+
+	group, err := a.groupFromInput(input)
+	if err != nil {
+		return err
+	}
+	group.ID = input.ID
+	return repo.Update(group)
+}
+
+// DeleteGroup removes a device group. Its subgroups and devices move to the parent group.
+func (a *App) DeleteGroup(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("group.delete", auditTarget("group", id, ""), a.rowAuditState("device_groups", "id", id))
+	defer func() { rec.finish(err) }()
+
+	return a.db.WithTx(func(tx *sql.Tx) error {
+		return database.NewGroupRepository(tx).Delete(id)
+	})
+}
+
+func (a *App) groupFromInput(input GroupInput) (*models.DeviceGroup, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	settings := input.Settings
+	if settings.MonitoringInterval < 0 {
+		return nil, fmt.Errorf("monitoring interval must not be negative")
+	}
+	if settings.MonitoringInterval > 0 && settings.MonitoringInterval < 5 {
+		return nil, fmt.Errorf("monitoring interval must be at least 5 seconds")
+	}
+	settings.NotifyEmails = strings.Join(mailer.ParseAddresses(settings.NotifyEmails), ", ")
+	if settings.EscalationPolicyID != nil {
+		policy, err := database.NewEscalationRepository(a.db.DB()).GetPolicyByID(*settings.EscalationPolicyID)
+		if err != nil {
+			return nil, err
+		}
+		if policy == nil {
+			return nil, fmt.Errorf("escalation policy not found")
+		}
+	}
+
+	if input.ParentID != nil {
+		groups, err := a.loadGroups()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := groups[*input.ParentID]; !ok {
+			return nil, fmt.Errorf("parent group not found")
+		}
+		// A group must not become its own ancestor
+		for _, ancestor := range groupAncestors(groups, input.ParentID) {
+			if input.ID != 0 && ancestor.ID == input.ID {
+				return nil, fmt.Errorf("group cannot be moved into its own subgroup")
+			}
+		}
+	}
+
+	return &models.DeviceGroup{
+		Name:        name,
+		ParentID:    input.ParentID,
+		Description: input.Description,
+		Settings:    settings,
+	}, nil
+}
+
+// SetDeviceLocation assigns a device to a site and a group, nil to clear
+func (a *App) SetDeviceLocation(deviceID int64, siteID, groupID *int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("device.location", a.deviceAuditTarget(deviceID), a.deviceAuditState(deviceID))
+	defer func() { rec.finish(err) }()
+
+	db := a.db.DB()
+	device, err := database.NewDeviceRepository(db).GetByID(deviceID)
+	if err != nil {
+		return err
+	}
+	if device == nil {
+		return fmt.Errorf("device not found")
+	}
+	if siteID != nil {
+		site, err := database.NewSiteRepository(db).GetByID(*siteID)
+		if err != nil {
+			return err
+		}
+		if site == nil {
+			return fmt.Errorf("site not found")
+		}
+	}
+	if groupID != nil {
+		group, err := database.NewGroupRepository(db).GetByID(*groupID)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			return fmt.Errorf("group not found")
+		}
+	}
+
+	return database.NewDeviceRepository(db).SetLocation(deviceID, siteID, groupID)
+}
+
+// SetDeviceTags replaces the tags of a device
+func (a *App) SetDeviceTags(deviceID int64, tags []string) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("device.tags", a.deviceAuditTarget(deviceID), a.deviceAuditState(deviceID))
+	defer func() { rec.finish(err) }()
+
+	device, err := database.NewDeviceRepository(a.db.DB()).GetByID(deviceID)
+	if err != nil {
+		return err
+	}
+	if device == nil {
+		return fmt.Errorf("device not found")
+	}
+
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	return a.db.WithTx(func(tx *sql.Tx) error {
+		return database.NewDeviceRepository(tx).SetTags(deviceID, normalized)
+	})
+}
+
+// GetTags returns all tags in use with their device counts
+func (a *App) GetTags() ([]TagCount, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	counts, err := database.NewDeviceRepository(a.db.DB()).GetAllTags()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return strings.ToLower(tags[i].Tag) < strings.ToLower(tags[j].Tag) })
+	return tags, nil
+}
+
+// normalizeTags trims tags and drops empty and duplicate ones. Tags compare
+// case-insensitively.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+		key := strings.ToLower(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return result, nil
+}
+
+// GetGroupStatuses returns the aggregate device status of every group including
+// its subgroups
+func (a *App) GetGroupStatuses() ([]models.GroupStatus, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	groups, err := a.loadGroups()
+	if err != nil {
+		return nil, err
+	}
+	devices, err := database.NewDeviceRepository(a.db.DB()).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[int64]*models.GroupStatus, len(groups))
+	for id, g := range groups {
+		statuses[id] = &models.GroupStatus{GroupID: id, Name: g.Name, ParentID: g.ParentID}
+	}
+
+	for _, d := range devices {
+		for _, g := range groupAncestors(groups, d.GroupID) {
+			s := statuses[g.ID]
+			s.Total++
+			switch d.Status {
+			case models.DeviceStatusOnline:
+				s.Online++
+			case models.DeviceStatusOffline:
+				s.Offline++
+			default:
+				s.Unknown++
+			}
+		}
+	}
+
+	result := make([]models.GroupStatus, 0, len(statuses))
+	for _, s := range statuses {
+		switch {
+		case s.Total == 0 || s.Unknown == s.Total:
+			s.Status = string(models.DeviceStatusUnknown)
+		case s.Offline == 0:
+			s.Status = string(models.DeviceStatusOnline)
+		case s.Online == 0:
+			s.Status = string(models.DeviceStatusOffline)
+		default:
+			s.Status = "degraded"
+		}
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// GetEffectiveGroupSettings returns the group settings applying to a device after
+// inheritance through its group's ancestors
+func (a *App) GetEffectiveGroupSettings(deviceID int64) (models.GroupSettings, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return models.GroupSettings{}, err
+	}
+	if a.db == nil {
+		return models.GroupSettings{}, fmt.Errorf("database not initialized")
+	}
+
+	device, err := database.NewDeviceRepository(a.db.DB()).GetByID(deviceID)
+	if err != nil {
+		return models.GroupSettings{}, err
+	}
+	if device == nil {
+		return models.GroupSettings{}, fmt.Errorf("device not found")
+	}
+
+	groups, err := a.loadGroups()
+	if err != nil {
+		return models.GroupSettings{}, err
+	}
+	return resolveGroupSettings(groups, device.GroupID), nil
+}
+
+// loadGroups returns all groups keyed by ID
+func (a *App) loadGroups() (map[int64]models.DeviceGroup, error) {
+	list, err := database.NewGroupRepository(a.db.DB()).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[int64]models.DeviceGroup, len(list))
+	for _, g := range list {
+		groups[g.ID] = g
+	}
+	return groups, nil
+}
+
+// groupAncestors returns a group followed by its ancestors up to the root
+func groupAncestors(groups map[int64]models.DeviceGroup, groupID *int64) []models.DeviceGroup {
+	var chain []models.DeviceGroup
+	seen := make(map[int64]bool)
+	for id := groupID; id != nil && !seen[*id]; {
+		g, ok := groups[*id]
+		if !ok {
+			break
+		}
+		seen[*id] = true
+		chain = append(chain, g)
+		id = g.ParentID
+	}
+	return chain
+}
+
+// resolveGroupSettings merges settings of a group and its ancestors; the nearest
+// group defining a value wins
+func resolveGroupSettings(groups map[int64]models.DeviceGroup, groupID *int64) models.GroupSettings {
+	var result models.GroupSettings
+	for _, g := range groupAncestors(groups, groupID) {
+		s := g.Settings
+		if result.MonitoringInterval == 0 {
+			result.MonitoringInterval = s.MonitoringInterval
+		}
+		if result.NotifyOnOffline == nil {
+			result.NotifyOnOffline = s.NotifyOnOffline
+		}
+		if result.NotifyOnOnline == nil {
+			result.NotifyOnOnline = s.NotifyOnOnline
+		}
+		if result.NotifyEmails == "" {
+			result.NotifyEmails = s.NotifyEmails
+		}
+		if result.EscalationPolicyID == nil {
+			result.EscalationPolicyID = s.EscalationPolicyID
+		}
+	}
+	return result
+}
+
+// deviceIntervals returns check intervals of devices in groups that set their own
+func (a *App) deviceIntervals(devices []models.Device) map[int64]time.Duration {
+	groups, err := a.loadGroups()
+	if err != nil {
+		log.Printf("Failed to load device groups: %v", err)
+		return nil
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	intervals := make(map[int64]time.Duration)
+	for _, d := range devices {
+		if d.GroupID == nil {
+			continue
+		}
+		if s := resolveGroupSettings(groups, d.GroupID); s.MonitoringInterval > 0 {
+			intervals[d.ID] = time.Duration(s.MonitoringInterval) * time.Second
+		}
+	}
+	return intervals
+}
+
+// statusNotification decides whether a status change of a device is notified
+// and where it is mailed, applying group settings over application settings
+func (a *App) statusNotification(device *models.Device, newStatus string) (bool, []string) {
+	settings := a.appSettings()
+	notify := false
+	switch newStatus {
+	case string(models.DeviceStatusOffline):
+		notify = settings.NotifyOnOffline
+	case string(models.DeviceStatusOnline):
+		notify = settings.NotifyOnOnline
+	}
+	if device.GroupID == nil {
+		return notify, nil
+	}
+
+	groups, err := a.loadGroups()
+	if err != nil {
+		return notify, nil
+	}
+	group := resolveGroupSettings(groups, device.GroupID)
+	switch {
+	case newStatus == string(models.DeviceStatusOffline) && group.NotifyOnOffline != nil:
+		notify = *group.NotifyOnOffline
+	case newStatus == string(models.DeviceStatusOnline) && group.NotifyOnOnline != nil:
+		notify = *group.NotifyOnOnline
+	}
+	if !notify {
+		return false, nil
+	}
+	return true, mailer.ParseAddresses(group.NotifyEmails)
+}
+
+// mailStatusChange sends a status change notification to group recipients
+func (a *App) mailStatusChange(device *models.Device, newStatus string, recipients []string) {
+	settings := a.appSettings()
+
+	state := "доступно"
+	if newStatus == string(models.DeviceStatusOffline) {
+		state = "недоступно"
+	}
+	err := a.sendEmail(settings, mailer.Message{
+		To:      recipients,
+		Subject: fmt.Sprintf("NetVisionMonitor: %s %s", device.Name, state),
+		Body: fmt.Sprintf("Устройство %s (%s) %s с %s.\n",
+			device.Name, device.IPAddress, state, time.Now().Format("2006-01-02 15:04:05")),
+	})
+	if err != nil {
+		log.Printf("Failed to mail status change of %s: %v", device.Name, err)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+)
+
+const (
+	// minHistoryRetentionDays keeps enough raw history for the finest graphs
+	minHistoryRetentionDays = 2
+	// minStatusPeriodRetentionDays keeps status periods for a year of monthly SLA reports
+	minStatusPeriodRetentionDays = 366
+	// minSwitchEnvironmentRetentionDays keeps the day shown in switch environment graphs
+	minSwitchEnvironmentRetentionDays = 1
+	// minPoEPowerRetentionDays keeps the day shown in PoE power graphs
+	minPoEPowerRetentionDays = 1
+	// minPoEEnergyRetentionDays keeps the current and the previous month for energy reports
+	minPoEEnergyRetentionDays = 62
+	// minPortHistoryRetentionDays keeps the week port flaps are counted over
+	minPortHistoryRetentionDays = 7
+)
+
+// RunHistoryRollup rolls up completed periods of status history into hourly and daily
+// aggregates and applies retention of every tier
+func (a *App) RunHistoryRollup() error {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	return a.runHistoryRollup()
+}
+
+// runHistoryRollup is RunHistoryRollup without the permission check, for background tasks
+func (a *App) runHistoryRollup() error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	now := time.Now()
+	for _, tier := range []database.RollupTier{database.RollupHourly, database.RollupDaily} {
+		for {
+			var more bool
+			err := a.db.WithTx(func(tx *sql.Tx) error {
+				repo := database.NewStatusHistoryRepository(tx)
+				start, ok, err := repo.NextRollupPeriod(tier, now)
+				if err != nil || !ok {
+					return err
+				}
+				more = true
+				_, err = repo.RollupPeriod(tier, start)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to roll up %s status history: %w", tier, err)
+			}
+			if !more {
+				break
+			}
+		}
+	}
+
+	return a.applyHistoryRetention(now)
+}
+
+// applyHistoryRetention removes raw history, rollups and switch data past their
+// retention. Raw history that has not been rolled up yet is kept.
+func (a *App) applyHistoryRetention(now time.Time) error {
+	settings := a.appSettings()
+	repo := database.NewStatusHistoryRepository(a.db.DB())
+
+	rawDays := settings.HistoryRetentionDays
+	if rawDays < minHistoryRetentionDays {
+		rawDays = minHistoryRetentionDays
+	}
+	before := now.AddDate(0, 0, -rawDays)
+	envDays := max(settings.SwitchEnvironmentRetentionDays, minSwitchEnvironmentRetentionDays)
+	if deleted, err := database.NewSwitchEnvironmentRepository(a.db.DB()).DeleteOlderThan(now.AddDate(0, 0, -envDays)); err != nil {
+		return fmt.Errorf("failed to delete old switch environment readings: %w", err)
+	} else if deleted > 0 {
+		log.Printf("Deleted %d switch environment readings", deleted)
+	}
+	poe := database.NewPoEPowerRepository(a.db.DB())
+	poeDays := max(settings.PoEPowerRetentionDays, minPoEPowerRetentionDays)
+	if deleted, err := poe.DeleteOlderThan(now.AddDate(0, 0, -poeDays)); err != nil {
+		return fmt.Errorf("failed to delete old PoE power readings: %w", err)
+	} else if deleted > 0 {
+		log.Printf("Deleted %d PoE power readings", deleted)
+	}
+	rolled, err := repo.RolledUntil(database.RollupHourly)
+	if err != nil {
+		return err
+	}
+	if daily, err := repo.RolledUntil(database.RollupDaily); err != nil {
+		return err
+	} else if daily.Before(rolled) {
+		rolled = daily
+	}
+	if rolled.Before(before) {
+		before = rolled
+	}
+	if deleted, err := repo.DeleteOlderThan(before); err != nil {
+		return fmt.Errorf("failed to delete old status history: %w", err)
+	} else if deleted > 0 {
+		log.Printf("Deleted %d raw status history records", deleted)
+	}
+
+	if settings.HourlyRetentionDays > 0 {
+		if _, err := repo.DeleteRollupsOlderThan(database.RollupHourly, now.AddDate(0, 0, -settings.HourlyRetentionDays)); err != nil {
+			return err
+		}
+	}
+	if settings.DailyRetentionDays > 0 {
+		if _, err := repo.DeleteRollupsOlderThan(database.RollupDaily, now.AddDate(0, 0, -settings.DailyRetentionDays)); err != nil {
+			return err
+		}
+		// Status periods back SLA reports and uptime graphs, they are kept as long
+		// as daily rollups
+		days := max(settings.DailyRetentionDays, minStatusPeriodRetentionDays)
+		if deleted, err := database.NewStatusPeriodRepository(a.db.DB()).DeleteOlderThan(now.AddDate(0, 0, -days)); err != nil {
+			return err
+		} else if deleted > 0 {
+			log.Printf("Deleted %d status periods", deleted)
+		}
+	}
+	if settings.PortHistoryRetentionDays > 0 {
+		days := max(settings.PortHistoryRetentionDays, minPortHistoryRetentionDays)
+		if _, err := database.NewPortHistoryRepository(a.db.DB()).DeleteOlderThan(now.AddDate(0, 0, -days)); err != nil {
+			return err
+		}
+	}
+	if settings.PoEEnergyRetentionDays > 0 {
+		days := max(settings.PoEEnergyRetentionDays, minPoEEnergyRetentionDays)
+		if _, err := poe.DeleteEnergyBefore(now.AddDate(0, 0, -days).Format(energyDayLayout)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startHistoryRollup rolls up status history shortly after startup and then every hour.
+// Audit log retention is applied on the same schedule.
+func (a *App) startHistoryRollup() {
+	a.rollupStop = make(chan struct{})
+	stop := a.rollupStop
+
+	go func() {
+		timer := time.NewTimer(time.Minute)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C:
+				if err := a.runHistoryRollup(); err != nil {
+					log.Printf("Status history rollup failed: %v", err)
+				}
+				if err := a.applyAuditRetention(time.Now()); err != nil {
+					log.Printf("Audit log retention failed: %v", err)
+				}
+				// Run just after the next hour completes
+				next := database.RollupHourly.Next(database.RollupHourly.Start(time.Now()))
+				timer.Reset(time.Until(next) + time.Minute)
+			}
+		}
+	}()
+}
+
+// stopHistoryRollup stops the status history rollup scheduler
+func (a *App) stopHistoryRollup() {
+	if a.rollupStop != nil {
+		close(a.rollupStop)
+		a.rollupStop = nil
+	}
+}
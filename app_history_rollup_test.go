@@ -0,0 +1,212 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/encryption"
+)
+
+// retentionTestApp returns an app with the given settings and one device
+func retentionTestApp(t *testing.T, settings AppSettings) (*App, int64) {
+	t.Helper()
+	if err := encryption.Initialize(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	db := openTestDatabase(t)
+	if err := database.NewSettingsRepository(db.DB()).SetJSON("app_settings", settings); err != nil {
+		t.Fatal(err)
+	}
+	device, err := createDevice(db.DB(), DeviceInput{Name: "sw1", IPAddress: "10.0.0.1", Type: "switch", PortCount: 2, SNMPCommunity: "public"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &App{db: db}, device.ID
+}
+
+// countRows returns the number of rows in a table
+func countRows(t *testing.T, a *App, table string) int {
+	t.Helper()
+	var count int
+	if err := a.db.DB().QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestHistoryRetentionStatusPeriods(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name      string
+		dailyDays int
+		want      int
+	}{
+		{"default", DefaultAppSettings().DailyRetentionDays, 3},
+		// Short rollup retention still keeps a year for SLA reports
+		{"short", 30, 2},
+		{"forever", 0, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := DefaultAppSettings()
+			settings.DailyRetentionDays = tc.dailyDays
+			a, deviceID := retentionTestApp(t, settings)
+
+			for _, daysAgo := range []int{1000, 500, 300, 1} {
+				end := now.AddDate(0, 0, -daysAgo)
+				_, err := a.db.DB().Exec("INSERT INTO status_periods (device_id, status, started_at, ended_at) VALUES (?, 'online', ?, ?)",
+					deviceID, end.Add(-time.Hour), end)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := a.applyHistoryRetention(now); err != nil {
+				t.Fatal(err)
+			}
+			if count := countRows(t, a, "status_periods"); count != tc.want {
+				t.Errorf("%d status periods left, want %d", count, tc.want)
+			}
+		})
+	}
+}
+
+func TestHistoryRetentionSwitchEnvironment(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name string
+		days int
+		want int
+	}{
+		{"default", DefaultAppSettings().SwitchEnvironmentRetentionDays, 3},
+		{"short", 10, 2},
+		// The graph of the last day is always kept
+		{"zero", 0, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := DefaultAppSettings()
+			settings.SwitchEnvironmentRetentionDays = tc.days
+			a, deviceID := retentionTestApp(t, settings)
+
+			for _, age := range []time.Duration{40 * 24 * time.Hour, 20 * 24 * time.Hour, 30 * time.Hour, 2 * time.Hour} {
+				_, err := a.db.DB().Exec("INSERT INTO switch_environment (device_id, temperature, checked_at) VALUES (?, 45, ?)",
+					deviceID, now.Add(-age))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := a.applyHistoryRetention(now); err != nil {
+				t.Fatal(err)
+			}
+			if count := countRows(t, a, "switch_environment"); count != tc.want {
+				t.Errorf("%d switch environment readings left, want %d", count, tc.want)
+			}
+		})
+	}
+}
+
+func TestHistoryRetentionPoEPower(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name string
+		days int
+		want int
+	}{
+		{"default", DefaultAppSettings().PoEPowerRetentionDays, 3},
+		{"short", 10, 2},
+		// The graph of the last day is always kept
+		{"zero", 0, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := DefaultAppSettings()
+			settings.PoEPowerRetentionDays = tc.days
+			a, switchID := retentionTestApp(t, settings)
+
+			for _, age := range []time.Duration{40 * 24 * time.Hour, 20 * 24 * time.Hour, 30 * time.Hour, 2 * time.Hour} {
+				_, err := a.db.DB().Exec("INSERT INTO poe_power (switch_id, port_number, power_w, checked_at) VALUES (?, 1, 6.5, ?)",
+					switchID, now.Add(-age))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := a.applyHistoryRetention(now); err != nil {
+				t.Fatal(err)
+			}
+			if count := countRows(t, a, "poe_power"); count != tc.want {
+				t.Errorf("%d PoE power readings left, want %d", count, tc.want)
+			}
+		})
+	}
+}
+
+func TestHistoryRetentionPoEEnergy(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name string
+		days int
+		want int
+	}{
+		{"default", DefaultAppSettings().PoEEnergyRetentionDays, 3},
+		// The previous month is always kept for its report
+		{"short", 30, 2},
+		{"forever", 0, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := DefaultAppSettings()
+			settings.PoEEnergyRetentionDays = tc.days
+			a, switchID := retentionTestApp(t, settings)
+
+			for _, daysAgo := range []int{800, 100, 40, 0} {
+				_, err := a.db.DB().Exec("INSERT INTO poe_energy_daily (switch_id, port_number, day, energy_wh) VALUES (?, 1, ?, 150)",
+					switchID, now.AddDate(0, 0, -daysAgo).Format(energyDayLayout))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := a.applyHistoryRetention(now); err != nil {
+				t.Fatal(err)
+			}
+			if count := countRows(t, a, "poe_energy_daily"); count != tc.want {
+				t.Errorf("%d days of PoE energy left, want %d", count, tc.want)
+			}
+		})
+	}
+}
+
+func TestHistoryRetentionPortHistory(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name string
+		days int
+		want int
+	}{
+		{"default", DefaultAppSettings().PortHistoryRetentionDays, 3},
+		// Flaps of the last week are always kept for the counters
+		{"short", 2, 2},
+		{"forever", 0, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := DefaultAppSettings()
+			settings.PortHistoryRetentionDays = tc.days
+			a, switchID := retentionTestApp(t, settings)
+
+			for _, daysAgo := range []int{200, 60, 5, 0} {
+				_, err := a.db.DB().Exec(`INSERT INTO port_history (switch_id, port_number, field, old_value, new_value, flap, source, changed_at)
+					VALUES (?, 1, 'status', 'up', 'down', 1, 'monitoring', ?)`, switchID, now.AddDate(0, 0, -daysAgo))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := a.applyHistoryRetention(now); err != nil {
+				t.Fatal(err)
+			}
+			if count := countRows(t, a, "port_history"); count != tc.want {
+				t.Errorf("%d port changes left, want %d", count, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/monitoring"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// GetOpenIncidents returns all open incidents, newest first
+func (a *App) GetOpenIncidents() ([]models.Incident, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewIncidentRepository(a.db.DB())
+	return repo.GetOpen()
+}
+
+// GetClosedIncidents returns closed incidents, most recently closed first
+func (a *App) GetClosedIncidents(limit, offset int) ([]models.Incident, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewIncidentRepository(a.db.DB())
+	return repo.GetClosed(limit, offset)
+}
+
+// GetIncident returns an incident by ID
+func (a *App) GetIncident(id int64) (*models.Incident, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewIncidentRepository(a.db.DB())
+	return repo.GetByID(id)
+}
+
+// GetIncidentNotes returns notes of an incident, oldest first
+func (a *App) GetIncidentNotes(id int64) ([]models.IncidentNote, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewIncidentRepository(a.db.DB())
+	return repo.GetNotes(id)
+}
+
+// AcknowledgeIncident marks an incident as being handled by the logged in user.
+// Acknowledged incidents no longer repeat sounds and notifications.
+func (a *App) AcknowledgeIncident(id int64, comment string) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("incident.acknowledge", auditTarget("incident", id, ""), a.rowAuditState("incidents", "id", id))
+	defer func() { rec.finish(err) }()
+
+	user := a.auditActor()
+
+	repo := database.NewIncidentRepository(a.db.DB())
+	inc, err := a.getIncident(repo, id)
+	if err != nil {
+		return err
+	}
+	if inc.Acknowledged() {
+		return fmt.Errorf("incident already acknowledged by %s", inc.AcknowledgedBy)
+	}
+
+	if err := repo.Acknowledge(id, user, strings.TrimSpace(comment), time.Now()); err != nil {
+		return err
+	}
+
+	log.Printf("Incident %d acknowledged by %s", id, user)
+	a.emitIncident("incident:updated", repo, id)
+	return nil
+}
+
+// AddIncidentNote attaches a note of the logged in user to an incident
+func (a *App) AddIncidentNote(id int64, text string) (_ *models.IncidentNote, err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	author := a.auditActor()
+
+	rec := a.beginAudit("incident.note", auditTarget("incident", id, ""), nil).detail("author", author)
+	defer func() { rec.finish(err) }()
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("note text is required")
+	}
+
+	repo := database.NewIncidentRepository(a.db.DB())
+	if _, err := a.getIncident(repo, id); err != nil {
+		return nil, err
+	}
+
+	note := &models.IncidentNote{
+		IncidentID: id,
+		Author:     author,
+		Text:       text,
+	}
+	if err := repo.AddNote(note); err != nil {
+		return nil, err
+	}
+
+	a.emitIncident("incident:updated", repo, id)
+	return note, nil
+}
+
+// AssignIncident sets the operator responsible for an incident, empty to unassign
+func (a *App) AssignIncident(id int64, user string) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("incident.assign", auditTarget("incident", id, ""), a.rowAuditState("incidents", "id", id))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewIncidentRepository(a.db.DB())
+	if _, err := a.getIncident(repo, id); err != nil {
+		return err
+	}
+
+	if err := repo.Assign(id, strings.TrimSpace(user)); err != nil {
+		return err
+	}
+
+	a.emitIncident("incident:updated", repo, id)
+	return nil
+}
+
+func (a *App) getIncident(repo *database.IncidentRepository, id int64) (*models.Incident, error) {
+	inc, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if inc == nil {
+		return nil, fmt.Errorf("incident not found")
+	}
+	return inc, nil
+}
+
+// emitIncident sends the current state of an incident to the frontend
+func (a *App) emitIncident(name string, repo *database.IncidentRepository, id int64) {
+	if inc, err := repo.GetByID(id); err == nil && inc != nil {
+		runtime.EventsEmit(a.ctx, name, inc)
+	}
+}
+
+// onMonitoringResult opens an incident when a device goes offline or degraded,
+// updates it while the problem persists and closes it on recovery
+func (a *App) onMonitoringResult(device models.Device, result monitoring.Result) {
+	if result.Status == string(models.DeviceStatusUnknown) {
+		return
+	}
+
+	settings := a.appSettings()
+	kind, message := incidentKind(result, settings.DegradedLatency)
+
+	repo := database.NewIncidentRepository(a.db.DB())
+	open, err := repo.GetOpenByDevice(device.ID)
+	if err != nil {
+		log.Printf("Failed to get open incident of device %d: %v", device.ID, err)
+		return
+	}
+
+	now := time.Now()
+	switch {
+	case open == nil && kind != "":
+		inc := &models.Incident{
+			DeviceID:  device.ID,
+			Kind:      kind,
+			Message:   message,
+			StartedAt: now,
+		}
+		if err := repo.Open(inc); err != nil {
+			log.Printf("Failed to open incident for device %d: %v", device.ID, err)
+			return
+		}
+		// Opening is notified by the status change itself; reminders count from here
+		repo.SetNotified(inc.ID, now)
+
+		// Offline devices already get a status event
+		if kind == models.IncidentKindDegraded {
+			a.createEvent(&device.ID, models.EventTypeHighLatency, models.EventLevelWarn,
+				fmt.Sprintf("%s is degraded: %s", device.Name, message))
+		}
+		a.emitIncident("incident:opened", repo, inc.ID)
+
+	case open != nil && kind != "":
+		if err := repo.Touch(open.ID, kind, message, now); err != nil {
+			log.Printf("Failed to update incident %d: %v", open.ID, err)
+			return
+		}
+		if kind != open.Kind {
+			a.emitIncident("incident:updated", repo, open.ID)
+		}
+
+	case open != nil:
+		if err := repo.Close(open.ID, now); err != nil {
+			log.Printf("Failed to close incident %d: %v", open.ID, err)
+			return
+		}
+		if open.Kind == models.IncidentKindDegraded {
+			a.createEvent(&device.ID, models.EventTypeLatencyNormal, models.EventLevelInfo,
+				fmt.Sprintf("%s latency is back to normal after %s", device.Name, now.Sub(open.StartedAt).Round(time.Second)))
+		}
+		a.emitIncident("incident:closed", repo, open.ID)
+	}
+}
+
+// incidentKind classifies a check result. Returns "" when the device is healthy.
+func incidentKind(result monitoring.Result, degradedLatency int) (models.IncidentKind, string) {
+	if result.Status == string(models.DeviceStatusOffline) {
+		if result.Error != nil {
+			return models.IncidentKindOffline, result.Error.Error()
+		}
+		return models.IncidentKindOffline, "device is offline"
+	}
+
+	latency := result.Latency.Milliseconds()
+	if degradedLatency > 0 && latency >= int64(degradedLatency) {
+		return models.IncidentKindDegraded, fmt.Sprintf("latency %d ms exceeds %d ms", latency, degradedLatency)
+	}
+	return "", ""
+}
+
+// sendIncidentReminders repeats notifications of open incidents nobody has acknowledged
+func (a *App) sendIncidentReminders() {
+	if a.db == nil {
+		return
+	}
+
+	settings := a.appSettings()
+	if settings.IncidentRepeatInterval <= 0 {
+		return
+	}
+	interval := time.Duration(settings.IncidentRepeatInterval) * time.Minute
+
+	repo := database.NewIncidentRepository(a.db.DB())
+	incidents, err := repo.GetOpen()
+	if err != nil {
+		log.Printf("Failed to get open incidents: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range incidents {
+		inc := &incidents[i]
+		if inc.Acknowledged() {
+			continue
+		}
+
+		last := inc.StartedAt
+		if inc.LastNotifiedAt != nil {
+			last = *inc.LastNotifiedAt
+		}
+		if now.Sub(last) < interval {
+			continue
+		}
+
+		if err := repo.SetNotified(inc.ID, now); err != nil {
+			log.Printf("Failed to update incident %d: %v", inc.ID, err)
+			continue
+		}
+		inc.LastNotifiedAt = &now
+		runtime.EventsEmit(a.ctx, "incident:reminder", inc)
+	}
+}
+
+// startIncidentReminders periodically repeats notifications of unacknowledged
+// incidents and escalates them
+func (a *App) startIncidentReminders() {
+	a.incidentStop = make(chan struct{})
+	stop := a.incidentStop
+
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				a.sendIncidentReminders()
+				a.runEscalations()
+			}
+		}
+	}()
+}
+
+// stopIncidentReminders stops incident reminders
+func (a *App) stopIncidentReminders() {
+	if a.incidentStop != nil {
+		close(a.incidentStop)
+		a.incidentStop = nil
+	}
+}
@@ -24,15 +24,30 @@ func (a *App) initMonitoring() {
 	// Set up event handlers
 	a.monitor.SetStatusChangeHandler(a.onDeviceStatusChange)
 	a.monitor.SetEventHandler(a.onMonitoringEvent)
+	a.monitor.SetResultHandler(a.onMonitoringResult)
+	a.monitor.SetEnvironmentHandler(a.onSwitchEnvironment)
+	a.monitor.SetPortChangesHandler(a.onPortChanges)
+	a.monitor.SetIntervalFunc(a.deviceIntervals)
 }
 
 // onDeviceStatusChange handles device status changes
 func (a *App) onDeviceStatusChange(deviceID int64, oldStatus, newStatus string) {
+	// Group settings decide whether the change is notified and who gets mail
+	notify := true
+	if device, err := database.NewDeviceRepository(a.db.DB()).GetByID(deviceID); err == nil && device != nil {
+		var recipients []string
+		notify, recipients = a.statusNotification(device, newStatus)
+		if len(recipients) > 0 {
+			go a.mailStatusChange(device, newStatus, recipients)
+		}
+	}
+
 	// Emit event to frontend
 	runtime.EventsEmit(a.ctx, "device:status", map[string]interface{}{
 		"device_id":  deviceID,
 		"old_status": oldStatus,
 		"new_status": newStatus,
+		"notify":     notify,
 	})
 }
 
@@ -54,7 +69,14 @@ func (a *App) onMonitoringEvent(event *models.Event) {
 }
 
 // StartMonitoring starts the monitoring cycle
-func (a *App) StartMonitoring() error {
+func (a *App) StartMonitoring() (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+
+	rec := a.beginAudit("monitoring.start", models.AuditTarget{Type: "monitoring"}, nil)
+	defer func() { rec.finish(err) }()
+
 	if a.monitor == nil {
 		a.initMonitoring()
 	}
@@ -64,7 +86,14 @@ func (a *App) StartMonitoring() error {
 }
 
 // StopMonitoring stops the monitoring cycle
-func (a *App) StopMonitoring() error {
+func (a *App) StopMonitoring() (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+
+	rec := a.beginAudit("monitoring.stop", models.AuditTarget{Type: "monitoring"}, nil)
+	defer func() { rec.finish(err) }()
+
 	if a.monitor != nil {
 		a.monitor.Stop()
 		runtime.EventsEmit(a.ctx, "monitoring:stopped", nil)
@@ -73,24 +102,35 @@ func (a *App) StopMonitoring() error {
 }
 
 // GetMonitoringStatus returns current monitoring status
-func (a *App) GetMonitoringStatus() MonitoringStatus {
+func (a *App) GetMonitoringStatus() (MonitoringStatus, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return MonitoringStatus{}, err
+	}
+
 	if a.monitor == nil {
 		return MonitoringStatus{
 			Running:  false,
 			Interval: 30,
 			Workers:  10,
-		}
+		}, nil
 	}
 
 	return MonitoringStatus{
 		Running:  a.monitor.IsRunning(),
 		Interval: 30, // TODO: get from config
 		Workers:  10,
-	}
+	}, nil
 }
 
 // SetMonitoringInterval updates the monitoring interval
-func (a *App) SetMonitoringInterval(seconds int) error {
+func (a *App) SetMonitoringInterval(seconds int) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+
+	rec := a.beginAudit("monitoring.interval", models.AuditTarget{Type: "monitoring"}, nil).detail("seconds", seconds)
+	defer func() { rec.finish(err) }()
+
 	if a.monitor != nil {
 		a.monitor.SetInterval(time.Duration(seconds) * time.Second)
 	}
@@ -99,6 +139,10 @@ func (a *App) SetMonitoringInterval(seconds int) error {
 
 // RunMonitoringOnce performs a single monitoring cycle
 func (a *App) RunMonitoringOnce() error {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+
 	if a.monitor == nil {
 		a.initMonitoring()
 	}
@@ -12,13 +12,17 @@ import (
 	"time"
 
 	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
 	"netvisionmonitor/internal/onvif"
 )
 
 // ONVIFProfile contains ONVIF media profile info
 type ONVIFProfile struct {
-	Token string `json:"token"`
-	Name  string `json:"name"`
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Encoding string `json:"encoding,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
 }
 
 // ONVIFDiscoveryResult contains the result of ONVIF camera discovery
@@ -36,6 +40,14 @@ type ONVIFDiscoveryResult struct {
 
 // DiscoverONVIFCamera discovers camera info via ONVIF protocol
 func (a *App) DiscoverONVIFCamera(ipAddress string, port int, username, password string) *ONVIFDiscoveryResult {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return &ONVIFDiscoveryResult{Success: false, Error: err.Error()}
+	}
+	return a.discoverONVIFCamera(ipAddress, port, username, password)
+}
+
+// discoverONVIFCamera is DiscoverONVIFCamera without the permission check
+func (a *App) discoverONVIFCamera(ipAddress string, port int, username, password string) *ONVIFDiscoveryResult {
 	log.Printf("DiscoverONVIFCamera: ip=%s, port=%d, user=%s", ipAddress, port, username)
 
 	if ipAddress == "" {
@@ -78,8 +90,11 @@ func (a *App) DiscoverONVIFCamera(ipAddress string, port int, username, password
 
 	for _, p := range info.Profiles {
 		result.Profiles = append(result.Profiles, ONVIFProfile{
-			Token: p.Token,
-			Name:  p.Name,
+			Token:    p.Token,
+			Name:     p.Name,
+			Encoding: p.Encoding,
+			Width:    p.Width,
+			Height:   p.Height,
 		})
 	}
 
@@ -91,6 +106,9 @@ func (a *App) DiscoverONVIFCamera(ipAddress string, port int, username, password
 
 // TestONVIFConnection tests ONVIF connectivity for a camera
 func (a *App) TestONVIFConnection(ipAddress string, port int, username, password string) (bool, string) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return false, err.Error()
+	}
 	if ipAddress == "" {
 		return false, "IP address is required"
 	}
@@ -113,6 +131,9 @@ func (a *App) TestONVIFConnection(ipAddress string, port int, username, password
 
 // GetCameraONVIFInfo retrieves ONVIF info for an existing camera device
 func (a *App) GetCameraONVIFInfo(deviceID int64) *ONVIFDiscoveryResult {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return &ONVIFDiscoveryResult{Success: false, Error: err.Error()}
+	}
 	if a.db == nil {
 		return &ONVIFDiscoveryResult{
 			Success: false,
@@ -151,11 +172,20 @@ func (a *App) GetCameraONVIFInfo(deviceID int64) *ONVIFDiscoveryResult {
 		}
 	}
 
-	return a.DiscoverONVIFCamera(device.IPAddress, cam.ONVIFPort, username, password)
+	return a.discoverONVIFCamera(device.IPAddress, cam.ONVIFPort, username, password)
 }
 
 // RefreshCameraStreams updates camera RTSP and snapshot URLs from ONVIF
 func (a *App) RefreshCameraStreams(deviceID int64) error {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+	return a.refreshCameraStreams(deviceID)
+}
+
+// refreshCameraStreams is RefreshCameraStreams without the permission check, used when
+// viewers open a camera that has no stream URLs yet
+func (a *App) refreshCameraStreams(deviceID int64) error {
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
@@ -186,7 +216,7 @@ func (a *App) RefreshCameraStreams(deviceID int64) error {
 	}
 
 	// Discover via ONVIF
-	result := a.DiscoverONVIFCamera(device.IPAddress, cam.ONVIFPort, username, password)
+	result := a.discoverONVIFCamera(device.IPAddress, cam.ONVIFPort, username, password)
 	if !result.Success {
 		return fmt.Errorf("ONVIF discovery failed: %s", result.Error)
 	}
@@ -200,7 +230,10 @@ func (a *App) RefreshCameraStreams(deviceID int64) error {
 	}
 
 	// Update in database
-	if err := cameraRepo.Update(cam); err != nil {
+	a.secretsMu.RLock()
+	err = cameraRepo.Update(cam)
+	a.secretsMu.RUnlock()
+	if err != nil {
 		return fmt.Errorf("failed to update camera: %w", err)
 	}
 
@@ -223,6 +256,10 @@ func (a *App) RefreshCameraStreams(deviceID int64) error {
 
 // FetchCameraSnapshotBase64 fetches camera snapshot and returns as base64 data URI
 func (a *App) FetchCameraSnapshotBase64(deviceID int64) (string, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return "", err
+	}
+
 	log.Printf("FetchCameraSnapshotBase64: deviceID=%d", deviceID)
 
 	if a.db == nil {
@@ -256,7 +293,7 @@ func (a *App) FetchCameraSnapshotBase64(deviceID int64) (string, error) {
 	// If no snapshot URL, try to get via ONVIF
 	if cam.SnapshotURL == "" {
 		log.Printf("No snapshot URL, trying ONVIF discovery for device %d", deviceID)
-		err := a.RefreshCameraStreams(deviceID)
+		err := a.refreshCameraStreams(deviceID)
 		if err != nil {
 			log.Printf("FetchCameraSnapshotBase64: ONVIF refresh failed: %v", err)
 			return "", fmt.Errorf("Нет snapshot URL. ONVIF ошибка: %v", err)
@@ -346,6 +383,10 @@ func (a *App) FetchCameraSnapshotBase64(deviceID int64) (string, error) {
 
 // FetchSnapshotFromURL fetches snapshot from a specific URL with optional auth
 func (a *App) FetchSnapshotFromURL(snapshotURL, username, password string) (string, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return "", err
+	}
+
 	if snapshotURL == "" {
 		return "", fmt.Errorf("snapshot URL is required")
 	}
@@ -409,6 +450,38 @@ func (a *App) FetchSnapshotFromURL(snapshotURL, username, password string) (stri
 	return dataURI, nil
 }
 
+// newCameraONVIFClient creates an ONVIF client for an existing camera device
+// using its configured ONVIF port and credentials
+func (a *App) newCameraONVIFClient(deviceID int64) (*onvif.Client, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	deviceRepo := database.NewDeviceRepository(a.db.DB())
+	device, err := deviceRepo.GetByID(deviceID)
+	if err != nil || device == nil {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	cameraRepo := database.NewCameraRepository(a.db.DB())
+	cam, err := cameraRepo.GetByDeviceID(deviceID)
+	if err != nil || cam == nil {
+		return nil, fmt.Errorf("camera configuration not found")
+	}
+
+	var username, password string
+	if device.CredentialID != nil {
+		credRepo := database.NewCredentialRepository(a.db.DB())
+		cred, err := credRepo.GetByIDWithPassword(*device.CredentialID)
+		if err == nil && cred != nil {
+			username = cred.Username
+			password = cred.Password
+		}
+	}
+
+	return onvif.NewClient(device.IPAddress, cam.ONVIFPort, username, password), nil
+}
+
 // Helper functions for URL processing
 func hasScheme(rawURL string) bool {
 	parsed, err := url.Parse(rawURL)
@@ -508,6 +581,13 @@ func (a *App) GetCameraPreset(manufacturer, series, ip string, port int, usernam
 
 // TrySnapshotURLs tries multiple snapshot URLs and returns the first working one
 func (a *App) TrySnapshotURLs(urls []string, username, password string) map[string]interface{} {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		}
+	}
+
 	for _, snapshotURL := range urls {
 		log.Printf("Trying snapshot URL: %s", snapshotURL)
 		dataURI, err := a.FetchSnapshotFromURL(snapshotURL, username, password)
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/onvif"
+)
+
+// maxConcurrentCameraChecks limits parallel ONVIF requests during fleet operations
+const maxConcurrentCameraChecks = 10
+
+// CameraClockDrift contains the clock comparison result for a single camera
+type CameraClockDrift struct {
+	DeviceID     int64     `json:"device_id"`
+	Name         string    `json:"name"`
+	IPAddress    string    `json:"ip_address"`
+	CameraTime   time.Time `json:"camera_time,omitempty"`
+	HostTime     time.Time `json:"host_time"`
+	DriftSeconds float64   `json:"drift_seconds"` // Positive = camera ahead of host
+	DateTimeType string    `json:"date_time_type,omitempty"`
+	Exceeded     bool      `json:"exceeded"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// ClockDriftReport contains fleet-wide clock drift results
+type ClockDriftReport struct {
+	CheckedAt        time.Time          `json:"checked_at"`
+	ThresholdSeconds int                `json:"threshold_seconds"`
+	Total            int                `json:"total"`
+	Exceeded         int                `json:"exceeded"`
+	Failed           int                `json:"failed"`
+	Cameras          []CameraClockDrift `json:"cameras"`
+}
+
+// CameraClockSyncResult contains the result of a clock sync for a single camera
+type CameraClockSyncResult struct {
+	DeviceID int64  `json:"device_id"`
+	Name     string `json:"name"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// GetCameraDateTime returns the camera clock settings and current time
+func (a *App) GetCameraDateTime(deviceID int64) (*onvif.SystemDateTime, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dt, err := client.GetSystemDateAndTime(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get camera time: %w", err)
+	}
+
+	return dt, nil
+}
+
+// SetCameraDateTime sets the camera clock mode. In "Manual" mode the camera is
+// set to the current host time; in "NTP" mode the camera uses its NTP servers.
+func (a *App) SetCameraDateTime(deviceID int64, dateTimeType string, timeZone string) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("camera.datetime", a.deviceAuditTarget(deviceID), nil).detail("type", dateTimeType).detail("time_zone", timeZone)
+	defer func() { rec.finish(err) }()
+
+	if dateTimeType != onvif.DateTimeTypeNTP && dateTimeType != onvif.DateTimeTypeManual {
+		return fmt.Errorf("invalid date/time type: %s", dateTimeType)
+	}
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	daylightSavings := false
+	// Reading the clock first also aligns the WS-Security timestamp with the camera
+	if current, err := client.GetSystemDateAndTime(ctx); err == nil {
+		daylightSavings = current.DaylightSavings
+		if timeZone == "" {
+			timeZone = current.TimeZone
+		}
+	}
+
+	if err := client.SetSystemDateAndTime(ctx, dateTimeType, timeZone, daylightSavings, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to set camera time: %w", err)
+	}
+
+	log.Printf("Camera %d date/time set: mode=%s, tz=%s", deviceID, dateTimeType, timeZone)
+	return nil
+}
+
+// RebootCamera reboots a camera via ONVIF
+func (a *App) RebootCamera(deviceID int64) (_ string, err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return "", err
+	}
+	if a.db == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("camera.reboot", a.deviceAuditTarget(deviceID), nil)
+	defer func() { rec.finish(err) }()
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	message, err := client.SystemReboot(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to reboot camera: %w", err)
+	}
+
+	log.Printf("Camera %d reboot requested: %s", deviceID, message)
+
+	return message, nil
+}
+
+// GetCameraNetworkInterfaces returns camera network interface configuration
+func (a *App) GetCameraNetworkInterfaces(deviceID int64) ([]onvif.NetworkInterface, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	interfaces, err := client.GetNetworkInterfaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network interfaces: %w", err)
+	}
+
+	return interfaces, nil
+}
+
+// GetCameraNTP returns camera NTP configuration
+func (a *App) GetCameraNTP(deviceID int64) (*onvif.NTPInfo, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := client.GetNTP(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NTP configuration: %w", err)
+	}
+
+	return info, nil
+}
+
+// SetCameraNTP sets camera NTP servers
+func (a *App) SetCameraNTP(deviceID int64, fromDHCP bool, servers []string) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("camera.ntp", a.deviceAuditTarget(deviceID), nil).detail("from_dhcp", fromDHCP).detail("servers", servers)
+	defer func() { rec.finish(err) }()
+
+	if !fromDHCP && len(servers) == 0 {
+		return fmt.Errorf("at least one NTP server is required")
+	}
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := client.SetNTP(ctx, fromDHCP, servers); err != nil {
+		return fmt.Errorf("failed to set NTP configuration: %w", err)
+	}
+
+	log.Printf("Camera %d NTP configured: dhcp=%t, servers=%v", deviceID, fromDHCP, servers)
+	return nil
+}
+
+// GetCameraUsers returns user accounts configured on the camera
+func (a *App) GetCameraUsers(deviceID int64) ([]onvif.User, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	users, err := client.GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get camera users: %w", err)
+	}
+
+	return users, nil
+}
+
+// CheckCameraClockDrift compares each camera's clock with the host clock.
+// Cameras drifting more than thresholdSeconds raise a clock drift event.
+// A threshold of zero uses the value from application settings.
+func (a *App) CheckCameraClockDrift(thresholdSeconds int) (*ClockDriftReport, error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	if thresholdSeconds <= 0 {
+		settings := a.appSettings()
+		thresholdSeconds = settings.ClockDriftThreshold
+	}
+	if thresholdSeconds <= 0 {
+		thresholdSeconds = DefaultAppSettings().ClockDriftThreshold
+	}
+
+	deviceRepo := database.NewDeviceRepository(a.db.DB())
+	cameras, err := deviceRepo.GetByType(models.DeviceTypeCamera)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cameras: %w", err)
+	}
+
+	report := &ClockDriftReport{
+		CheckedAt:        time.Now(),
+		ThresholdSeconds: thresholdSeconds,
+		Total:            len(cameras),
+		Cameras:          make([]CameraClockDrift, len(cameras)),
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentCameraChecks)
+
+	for i, device := range cameras {
+		wg.Add(1)
+		go func(i int, device models.Device) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			report.Cameras[i] = a.checkCameraClock(device, thresholdSeconds)
+		}(i, device)
+	}
+	wg.Wait()
+
+	for i := range report.Cameras {
+		result := &report.Cameras[i]
+		if result.Error != "" {
+			report.Failed++
+			continue
+		}
+		if !result.Exceeded {
+			continue
+		}
+
+		report.Exceeded++
+		deviceID := result.DeviceID
+		a.onMonitoringEvent(&models.Event{
+			DeviceID:  &deviceID,
+			Type:      models.EventTypeClockDrift,
+			Level:     models.EventLevelWarn,
+			Message:   fmt.Sprintf("Camera %s clock drift %.0fs exceeds %ds", result.Name, result.DriftSeconds, thresholdSeconds),
+			CreatedAt: report.CheckedAt,
+		})
+	}
+
+	log.Printf("Clock drift check: %d cameras, %d exceeded, %d failed", report.Total, report.Exceeded, report.Failed)
+	return report, nil
+}
+
+// checkCameraClock measures the clock drift of a single camera
+func (a *App) checkCameraClock(device models.Device, thresholdSeconds int) CameraClockDrift {
+	result := CameraClockDrift{
+		DeviceID:  device.ID,
+		Name:      device.Name,
+		IPAddress: device.IPAddress,
+		HostTime:  time.Now().UTC(),
+	}
+
+	client, err := a.newCameraONVIFClient(device.ID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	before := time.Now()
+	dt, err := client.GetSystemDateAndTime(ctx)
+	after := time.Now()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// Compare against the midpoint of the request to compensate for network latency
+	hostTime := before.Add(after.Sub(before) / 2).UTC()
+
+	result.HostTime = hostTime
+	result.CameraTime = dt.UTCTime
+	result.DateTimeType = dt.DateTimeType
+	result.DriftSeconds = math.Round(dt.UTCTime.Sub(hostTime).Seconds()*10) / 10
+	result.Exceeded = math.Abs(result.DriftSeconds) > float64(thresholdSeconds)
+
+	return result
+}
+
+// SyncCameraClock synchronizes a single camera clock.
+// If ntpServer is set, the camera is switched to NTP with that server;
+// otherwise the camera is set manually to the current host time.
+func (a *App) SyncCameraClock(deviceID int64, ntpServer string) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("camera.clock_sync", a.deviceAuditTarget(deviceID), nil).detail("ntp_server", ntpServer)
+	defer func() { rec.finish(err) }()
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return syncCameraClock(ctx, client, ntpServer)
+}
+
+// SyncAllCameraClocks synchronizes clocks of all cameras.
+// See SyncCameraClock for ntpServer semantics.
+func (a *App) SyncAllCameraClocks(ntpServer string) ([]CameraClockSyncResult, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	deviceRepo := database.NewDeviceRepository(a.db.DB())
+	cameras, err := deviceRepo.GetByType(models.DeviceTypeCamera)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cameras: %w", err)
+	}
+
+	results := make([]CameraClockSyncResult, len(cameras))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentCameraChecks)
+
+	for i, device := range cameras {
+		wg.Add(1)
+		go func(i int, device models.Device) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = CameraClockSyncResult{DeviceID: device.ID, Name: device.Name}
+			if err := a.SyncCameraClock(device.ID, ntpServer); err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Success = true
+		}(i, device)
+	}
+	wg.Wait()
+
+	synced := 0
+	for _, r := range results {
+		if r.Success {
+			synced++
+		}
+	}
+
+	log.Printf("Camera clock sync: %d/%d synchronized", synced, len(results))
+	a.createEvent(nil, models.EventTypeClockDrift, models.EventLevelInfo,
+		fmt.Sprintf("Camera clocks synchronized: %d/%d", synced, len(results)))
+
+	return results, nil
+}
+
+// syncCameraClock switches a camera to NTP or sets its clock to host time
+func syncCameraClock(ctx context.Context, client *onvif.Client, ntpServer string) error {
+	timeZone := ""
+	daylightSavings := false
+	// Reading the clock first also aligns the WS-Security timestamp with the
+	// camera, which otherwise rejects the request when its clock drifted
+	if current, err := client.GetSystemDateAndTime(ctx); err == nil {
+		timeZone = current.TimeZone
+		daylightSavings = current.DaylightSavings
+	}
+
+	if ntpServer != "" {
+		if err := client.SetNTP(ctx, false, []string{ntpServer}); err != nil {
+			return fmt.Errorf("failed to set NTP server: %w", err)
+		}
+		if err := client.SetSystemDateAndTime(ctx, onvif.DateTimeTypeNTP, timeZone, daylightSavings, time.Time{}); err != nil {
+			return fmt.Errorf("failed to enable NTP: %w", err)
+		}
+		return nil
+	}
+
+	if err := client.SetSystemDateAndTime(ctx, onvif.DateTimeTypeManual, timeZone, daylightSavings, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to set camera time: %w", err)
+	}
+	return nil
+}
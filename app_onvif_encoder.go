@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/onvif"
+)
+
+// Stream selectors for encoder settings
+const (
+	StreamMain = "main"
+	StreamSub  = "sub"
+)
+
+// EncoderSettingsInput describes encoder settings to apply to a set of cameras.
+// Zero and nil values leave the corresponding camera setting unchanged.
+type EncoderSettingsInput struct {
+	DeviceIDs       []int64 `json:"device_ids"` // Empty = all cameras
+	Stream          string  `json:"stream"`     // "main" or "sub"
+	Encoding        string  `json:"encoding,omitempty"`
+	Width           int     `json:"width,omitempty"`
+	Height          int     `json:"height,omitempty"`
+	FrameRate       int     `json:"frame_rate,omitempty"`
+	BitrateLimit    int     `json:"bitrate_limit,omitempty"` // kbps
+	GovLength       int     `json:"gov_length,omitempty"`
+	Quality         float64 `json:"quality,omitempty"`
+	Profile         string  `json:"profile,omitempty"`
+	ConstantBitRate *bool   `json:"constant_bit_rate,omitempty"`
+}
+
+// EncoderApplyResult contains the result of applying encoder settings to a camera
+type EncoderApplyResult struct {
+	DeviceID int64                            `json:"device_id"`
+	Name     string                           `json:"name"`
+	Success  bool                             `json:"success"`
+	Error    string                           `json:"error,omitempty"`
+	Before   *onvif.VideoEncoderConfiguration `json:"before,omitempty"`
+	After    *onvif.VideoEncoderConfiguration `json:"after,omitempty"`
+}
+
+// GetCameraMediaProfiles returns camera media profiles with their encoder settings
+func (a *App) GetCameraMediaProfiles(deviceID int64) ([]onvif.MediaProfile, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	profiles, err := client.GetProfiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// GetCameraEncoderConfigurations returns all video encoder configurations of a camera
+func (a *App) GetCameraEncoderConfigurations(deviceID int64) ([]onvif.VideoEncoderConfiguration, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	configs, err := client.GetVideoEncoderConfigurations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encoder configurations: %w", err)
+	}
+
+	return configs, nil
+}
+
+// SetCameraEncoderConfiguration updates a single video encoder configuration.
+// The current configuration is read from the camera first so that settings
+// not exposed to the frontend are preserved.
+func (a *App) SetCameraEncoderConfiguration(deviceID int64, config onvif.VideoEncoderConfiguration) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("camera.encoder", a.deviceAuditTarget(deviceID), nil).detail("configuration", config)
+	defer func() { rec.finish(err) }()
+
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	configs, err := client.GetVideoEncoderConfigurations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get encoder configurations: %w", err)
+	}
+
+	var current *onvif.VideoEncoderConfiguration
+	for i := range configs {
+		if configs[i].Token == config.Token {
+			current = &configs[i]
+			break
+		}
+	}
+	if current == nil {
+		return fmt.Errorf("encoder configuration %s not found", config.Token)
+	}
+
+	updated := mergeEncoderConfiguration(*current, EncoderSettingsInput{
+		Encoding:        config.Encoding,
+		Width:           config.Width,
+		Height:          config.Height,
+		FrameRate:       config.FrameRate,
+		BitrateLimit:    config.BitrateLimit,
+		GovLength:       config.GovLength,
+		Quality:         config.Quality,
+		Profile:         config.Profile,
+		ConstantBitRate: config.ConstantBitRate,
+	})
+	if config.Name != "" {
+		updated.Name = config.Name
+	}
+
+	if err := client.SetVideoEncoderConfiguration(ctx, updated); err != nil {
+		return fmt.Errorf("failed to set encoder configuration: %w", err)
+	}
+
+	log.Printf("Camera %d encoder %s updated: %s %dx%d %dfps %dkbps",
+		deviceID, updated.Token, updated.Encoding, updated.Width, updated.Height, updated.FrameRate, updated.BitrateLimit)
+	return nil
+}
+
+// ApplyEncoderSettings applies the same encoder settings to the main or sub
+// stream of several cameras, e.g. to standardise substreams across a group.
+func (a *App) ApplyEncoderSettings(input EncoderSettingsInput) (_ []EncoderApplyResult, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("camera.encoder_bulk", models.AuditTarget{Type: "camera"}, nil).detail("settings", input)
+	defer func() { rec.finish(err) }()
+
+	if input.Stream == "" {
+		input.Stream = StreamSub
+	}
+	if input.Stream != StreamMain && input.Stream != StreamSub {
+		return nil, fmt.Errorf("invalid stream: %s", input.Stream)
+	}
+
+	deviceRepo := database.NewDeviceRepository(a.db.DB())
+
+	var cameras []models.Device
+	if len(input.DeviceIDs) == 0 {
+		all, err := deviceRepo.GetByType(models.DeviceTypeCamera)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cameras: %w", err)
+		}
+		cameras = all
+	} else {
+		for _, id := range input.DeviceIDs {
+			device, err := deviceRepo.GetByID(id)
+			if err != nil || device == nil || device.Type != models.DeviceTypeCamera {
+				continue
+			}
+			cameras = append(cameras, *device)
+		}
+	}
+
+	results := make([]EncoderApplyResult, len(cameras))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentCameraChecks)
+
+	for i, device := range cameras {
+		wg.Add(1)
+		go func(i int, device models.Device) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = a.applyEncoderSettings(device, input)
+		}(i, device)
+	}
+	wg.Wait()
+
+	applied := 0
+	for _, r := range results {
+		if r.Success {
+			applied++
+		}
+	}
+
+	log.Printf("Encoder settings (%s stream) applied to %d/%d cameras", input.Stream, applied, len(results))
+	rec.detail("applied", applied).detail("total", len(results))
+	return results, nil
+}
+
+// applyEncoderSettings applies encoder settings to a single camera
+func (a *App) applyEncoderSettings(device models.Device, input EncoderSettingsInput) EncoderApplyResult {
+	result := EncoderApplyResult{DeviceID: device.ID, Name: device.Name}
+
+	client, err := a.newCameraONVIFClient(device.ID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	current, err := streamEncoderConfiguration(ctx, client, input.Stream)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	updated := mergeEncoderConfiguration(*current, input)
+	result.Before = current
+	result.After = &updated
+
+	if err := client.SetVideoEncoderConfiguration(ctx, updated); err != nil {
+		result.Error = fmt.Sprintf("failed to set encoder configuration: %v", err)
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// streamEncoderConfiguration finds the encoder configuration used by the main
+// (first profile) or sub (second profile) stream
+func streamEncoderConfiguration(ctx context.Context, client *onvif.Client, stream string) (*onvif.VideoEncoderConfiguration, error) {
+	index := 0
+	if stream == StreamSub {
+		index = 1
+	}
+
+	configs, err := client.GetVideoEncoderConfigurations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encoder configurations: %w", err)
+	}
+
+	profiles, err := client.GetProfiles(ctx)
+	if err == nil && index < len(profiles) && profiles[index].VideoEncoderToken != "" {
+		for i := range configs {
+			if configs[i].Token == profiles[index].VideoEncoderToken {
+				return &configs[i], nil
+			}
+		}
+	}
+
+	// Fall back to configuration order
+	if index < len(configs) {
+		return &configs[index], nil
+	}
+
+	return nil, fmt.Errorf("camera has no %s stream encoder", stream)
+}
+
+// mergeEncoderConfiguration applies non-zero settings on top of a configuration
+func mergeEncoderConfiguration(cfg onvif.VideoEncoderConfiguration, input EncoderSettingsInput) onvif.VideoEncoderConfiguration {
+	if input.Encoding != "" && input.Encoding != cfg.Encoding {
+		cfg.Encoding = input.Encoding
+		// Codec profiles are not interchangeable between encodings
+		cfg.Profile = ""
+	}
+	if input.Width > 0 && input.Height > 0 {
+		cfg.Width = input.Width
+		cfg.Height = input.Height
+	}
+	if input.FrameRate > 0 {
+		cfg.FrameRate = input.FrameRate
+	}
+	if input.BitrateLimit > 0 {
+		cfg.BitrateLimit = input.BitrateLimit
+	}
+	if input.GovLength > 0 {
+		cfg.GovLength = input.GovLength
+	}
+	if input.Quality > 0 {
+		cfg.Quality = input.Quality
+	}
+	if input.Profile != "" {
+		cfg.Profile = input.Profile
+	}
+	if input.ConstantBitRate != nil {
+		cbr := *input.ConstantBitRate
+		cfg.ConstantBitRate = &cbr
+	}
+	return cfg
+}
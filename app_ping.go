@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"time"
 
+	"netvisionmonitor/internal/models"
 	"netvisionmonitor/internal/monitoring/ping"
 )
 
@@ -23,6 +24,10 @@ type PingResult struct {
 
 // PingDevice performs an internal ping to the specified IP address
 func (a *App) PingDevice(ipAddress string) (*PingResult, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
 	if ipAddress == "" {
 		return nil, fmt.Errorf("IP address is required")
 	}
@@ -55,6 +60,10 @@ func (a *App) PingDevice(ipAddress string) (*PingResult, error) {
 
 // OpenPingCmd opens Windows command prompt with ping command
 func (a *App) OpenPingCmd(ipAddress string) error {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return err
+	}
+
 	if ipAddress == "" {
 		return fmt.Errorf("IP address is required")
 	}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+)
+
+// energyDayLayout formats the local day PoE energy is accounted to
+const energyDayLayout = "2006-01-02"
+
+// poeMaxGap is the longest gap between two readings of a port that is counted
+// as energy, longer gaps mean monitoring was stopped
+const poeMaxGap = 10 * time.Minute
+
+// PoE power anomaly detection. The usual power of a port is a moving average of
+// its normal readings, a reading far enough from it raises an anomaly.
+const (
+	poeBaselineWeight  = 0.1 // Weight of a new reading in the moving average
+	poeBaselineSamples = 10  // Readings before the usual power is trusted
+	poeMinBaselineW    = 1.0 // Ports drawing less are idle
+	poeMinChangeW      = 1.0 // Smaller changes are noise
+)
+
+// PoE power anomalies
+const (
+	poeAnomalyDrop  = "drop"
+	poeAnomalySpike = "spike"
+)
+
+type poePortKey struct {
+	switchID int64
+	port     int
+}
+
+// poePortState tracks the power of a PoE port between readings
+type poePortState struct {
+	last     time.Time
+	lastW    float64
+	baseline float64 // Usual power, W
+	samples  int     // Readings averaged into the baseline
+	anomaly  string  // Raised anomaly, empty if none
+}
+
+// check compares a reading with the usual power of the port and returns the
+// anomaly raised, "normal" if a raised anomaly cleared, or empty. The usual power
+// follows normal readings only, so an anomaly stays raised until power returns.
+func (s *poePortState) check(powerW float64, dropPercent, spikePercent int) string {
+	trusted := s.samples >= poeBaselineSamples && s.baseline >= poeMinBaselineW
+	switch {
+	case trusted && dropPercent > 0 && powerW < s.baseline*(1-float64(dropPercent)/100) && s.baseline-powerW >= poeMinChangeW:
+		if s.anomaly != poeAnomalyDrop {
+			s.anomaly = poeAnomalyDrop
+			return poeAnomalyDrop
+		}
+	case trusted && spikePercent > 0 && powerW > s.baseline*(1+float64(spikePercent)/100) && powerW-s.baseline >= poeMinChangeW:
+		if s.anomaly != poeAnomalySpike {
+			s.anomaly = poeAnomalySpike
+			return poeAnomalySpike
+		}
+	default:
+		if s.samples == 0 {
+			s.baseline = powerW
+		} else {
+			s.baseline += poeBaselineWeight * (powerW - s.baseline)
+		}
+		s.samples++
+		if s.anomaly != "" {
+			s.anomaly = ""
+			return "normal"
+		}
+	}
+	return ""
+}
+
+// recordPoEPower stores the power of the PoE ports of a switch, adds the energy
+// delivered since the previous reading to the day and emits events when the power
+// of a port drops or spikes
+func (a *App) recordPoEPower(device models.Device, env *models.SwitchEnvironment) {
+	if len(env.PoEPorts) == 0 {
+		return
+	}
+
+	ports, err := database.NewSwitchRepository(a.db.DB()).GetPorts(device.ID)
+	if err != nil {
+		log.Printf("Failed to get ports of switch %d: %v", device.ID, err)
+		return
+	}
+	cameras := make(map[int]*int64, len(ports))
+	for _, p := range ports {
+		cameras[p.PortNumber] = p.LinkedCameraID
+	}
+
+	settings := a.appSettings()
+
+	type anomaly struct {
+		port     models.PortPower
+		cameraID *int64
+		kind     string
+		baseline float64
+	}
+	var anomalies []anomaly
+
+	a.poeMu.Lock()
+	if a.poePorts == nil {
+		a.poePorts = make(map[poePortKey]*poePortState)
+	}
+	err = a.db.WithTx(func(tx *sql.Tx) error {
+		repo := database.NewPoEPowerRepository(tx)
+		for _, p := range env.PoEPorts {
+			cameraID := cameras[p.Port]
+			if err := repo.Record(&models.PoEPowerSample{
+				SwitchID:   device.ID,
+				PortNumber: p.Port,
+				CameraID:   cameraID,
+				PowerW:     p.PowerW,
+				CheckedAt:  env.CheckedAt,
+			}); err != nil {
+				return err
+			}
+
+			key := poePortKey{switchID: device.ID, port: p.Port}
+			state := a.poePorts[key]
+			if state == nil {
+				state = &poePortState{}
+				a.poePorts[key] = state
+			}
+
+			// Energy of the interval by the trapezoid rule, accounted to the day it ends
+			var energyWh float64
+			if gap := env.CheckedAt.Sub(state.last); !state.last.IsZero() && gap > 0 && gap <= poeMaxGap {
+				energyWh = (state.lastW + p.PowerW) / 2 * gap.Hours()
+			}
+			if err := repo.AddEnergy(device.ID, p.Port, cameraID, env.CheckedAt.Format(energyDayLayout), energyWh, p.PowerW); err != nil {
+				return err
+			}
+			state.last, state.lastW = env.CheckedAt, p.PowerW
+
+			baseline := state.baseline
+			if kind := state.check(p.PowerW, settings.PoEPowerDropPercent, settings.PoEPowerSpikePercent); kind != "" {
+				anomalies = append(anomalies, anomaly{port: p, cameraID: cameraID, kind: kind, baseline: baseline})
+			}
+		}
+		return nil
+	})
+	a.poeMu.Unlock()
+	if err != nil {
+		log.Printf("Failed to record PoE power of switch %d: %v", device.ID, err)
+	}
+
+	devices := database.NewDeviceRepository(a.db.DB())
+	for _, an := range anomalies {
+		deviceID := device.ID
+		subject := fmt.Sprintf("Port %d of switch %s", an.port.Port, device.Name)
+		if an.cameraID != nil {
+			if camera, err := devices.GetByID(*an.cameraID); err == nil && camera != nil {
+				deviceID = camera.ID
+				subject = fmt.Sprintf("Camera %s (switch %s, port %d)", camera.Name, device.Name, an.port.Port)
+			}
+		}
+
+		event := &models.Event{DeviceID: &deviceID}
+		switch an.kind {
+		case poeAnomalyDrop:
+			event.Type, event.Level = models.EventTypePoEPowerDrop, models.EventLevelWarn
+			event.Message = fmt.Sprintf("%s power dropped from %.1f W to %.1f W", subject, an.baseline, an.port.PowerW)
+		case poeAnomalySpike:
+			event.Type, event.Level = models.EventTypePoEPowerSpike, models.EventLevelWarn
+			event.Message = fmt.Sprintf("%s power rose from %.1f W to %.1f W", subject, an.baseline, an.port.PowerW)
+		default:
+			event.Type, event.Level = models.EventTypePoEPowerOK, models.EventLevelInfo
+			event.Message = fmt.Sprintf("%s power is back to %.1f W", subject, an.port.PowerW)
+		}
+		a.onMonitoringEvent(event)
+	}
+}
+
+// GetPoEPowerHistory returns the power readings of a switch port for the last hours
+func (a *App) GetPoEPowerHistory(switchID int64, port int, hours int) ([]models.PoEPowerSample, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if hours <= 0 {
+		hours = 24
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	return database.NewPoEPowerRepository(a.db.DB()).GetPortHistory(switchID, port, since)
+}
+
+// GetCameraPowerHistory returns the PoE power readings of a camera for the last hours
+func (a *App) GetCameraPowerHistory(cameraID int64, hours int) ([]models.PoEPowerSample, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if hours <= 0 {
+		hours = 24
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	return database.NewPoEPowerRepository(a.db.DB()).GetCameraHistory(cameraID, since)
+}
+
+// GetEnergyUsage returns the daily PoE energy of cameras, switches and sites for
+// the last days, today included
+func (a *App) GetEnergyUsage(days int) (*models.EnergyReport, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if days <= 0 {
+		days = 30
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	start := today.AddDate(0, 0, -(days - 1))
+	return a.buildEnergyReport(start, now, fmt.Sprintf("%s – %s", start.Format(energyDayLayout), today.Format(energyDayLayout)))
+}
+
+// GetEnergyReport returns the PoE energy report of a month
+func (a *App) GetEnergyReport(year, month int) (*models.EnergyReport, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("invalid month: %d", month)
+	}
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+	if start.After(time.Now()) {
+		return nil, fmt.Errorf("period is in the future")
+	}
+	if end.After(time.Now()) {
+		end = time.Now()
+	}
+
+	return a.buildEnergyReport(start, end, start.Format("2006-01"))
+}
+
+// ExportEnergyReport asks for a file and saves the PoE energy report of a month to it
+func (a *App) ExportEnergyReport(year, month int, format string) (string, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return "", err
+	}
+
+	if err := validateReportFormat(format); err != nil {
+		return "", err
+	}
+
+	report, err := a.GetEnergyReport(year, month)
+	if err != nil {
+		return "", err
+	}
+
+	savePath, err := a.askReportPath("Экспорт отчёта по энергопотреблению", "netvision_energy_"+report.Period, format)
+	if err != nil || savePath == "" {
+		return "", err
+	}
+
+	if err := writeReport(savePath, format, energyReportTables(report)); err != nil {
+		return "", err
+	}
+
+	log.Printf("Energy report for %s exported to %s", report.Period, savePath)
+	return savePath, nil
+}
+
+// buildEnergyReport sums the daily PoE energy of ports per camera, switch and site
+func (a *App) buildEnergyReport(start, end time.Time, period string) (*models.EnergyReport, error) {
+	db := a.db.DB()
+	energy, err := database.NewPoEPowerRepository(db).GetDailyEnergy(
+		start.Format(energyDayLayout), end.Add(-time.Nanosecond).Format(energyDayLayout))
+	if err != nil {
+		return nil, err
+	}
+	devices, err := database.NewDeviceRepository(db).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+	sites, err := database.NewSiteRepository(db).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sites: %w", err)
+	}
+
+	byID := make(map[int64]models.Device, len(devices))
+	for _, d := range devices {
+		byID[d.ID] = d
+	}
+	siteNames := make(map[int64]string, len(sites))
+	for _, s := range sites {
+		siteNames[s.ID] = s.Name
+	}
+
+	report := &models.EnergyReport{
+		Period:      period,
+		Start:       start,
+		End:         end,
+		GeneratedAt: time.Now(),
+		Cameras:     []models.EnergyUsage{},
+		Switches:    []models.EnergyUsage{},
+		Sites:       []models.EnergyUsage{},
+	}
+
+	usages := make(map[string]*models.EnergyUsage)
+	var order []string
+	add := func(kind string, id int64, name string, day models.PortEnergyDay) {
+		key := fmt.Sprintf("%s:%d", kind, id)
+		u := usages[key]
+		if u == nil {
+			u = &models.EnergyUsage{Key: key, Kind: kind, ID: id, Name: name, Days: []models.EnergyDay{}}
+			usages[key] = u
+			order = append(order, key)
+		}
+		kwh := day.EnergyWh / 1000
+		if n := len(u.Days); n > 0 && u.Days[n-1].Day == day.Day {
+			u.Days[n-1].EnergyKWh += kwh
+		} else {
+			u.Days = append(u.Days, models.EnergyDay{Day: day.Day, EnergyKWh: kwh})
+		}
+		u.TotalKWh += kwh
+		if kind == "camera" {
+			u.MaxPowerW = max(u.MaxPowerW, day.MaxPowerW)
+		}
+	}
+
+	// Rows come ordered by day, so days of a usage stay in order
+	for _, day := range energy {
+		report.TotalKWh += day.EnergyWh / 1000
+
+		sw, ok := byID[day.SwitchID]
+		name := sw.Name
+		if !ok {
+			name = fmt.Sprintf("Коммутатор %d", day.SwitchID)
+		}
+		add("switch", day.SwitchID, name, day)
+		if ok && sw.SiteID != nil {
+			add("site", *sw.SiteID, siteNames[*sw.SiteID], day)
+		}
+		if day.CameraID != nil {
+			name := fmt.Sprintf("Камера %d", *day.CameraID)
+			if camera, ok := byID[*day.CameraID]; ok {
+				name = camera.Name
+			}
+			add("camera", *day.CameraID, name, day)
+		}
+	}
+
+	for _, key := range order {
+		u := *usages[key]
+		switch u.Kind {
+		case "camera":
+			report.Cameras = append(report.Cameras, u)
+		case "switch":
+			report.Switches = append(report.Switches, u)
+		case "site":
+			report.Sites = append(report.Sites, u)
+		}
+	}
+	for _, list := range [][]models.EnergyUsage{report.Cameras, report.Switches, report.Sites} {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].TotalKWh > list[j].TotalKWh })
+	}
+	return report, nil
+}
+
+func energyReportTables(report *models.EnergyReport) []reportTable {
+	subtitle := fmt.Sprintf("Период %s – %s. Сформирован %s",
+		report.Start.Format("02.01.2006"), report.End.Format("02.01.2006 15:04"),
+		report.GeneratedAt.Format("02.01.2006 15:04"))
+
+	// Average power over the period, W
+	hours := report.End.Sub(report.Start).Hours()
+	average := func(kwh float64) interface{} {
+		if hours <= 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.1f", kwh*1000/hours)
+	}
+	kwh := func(v float64) string { return fmt.Sprintf("%.3f", v) }
+
+	summary := reportTable{
+		Title:    "Энергопотребление PoE за " + report.Period,
+		Subtitle: subtitle,
+		Sheet:    "Сводка",
+		Columns:  []string{"Тип", "Название", "кВт·ч", "Средняя мощность, Вт"},
+		Widths:   []float64{1.2, 3, 1.2, 1.6},
+	}
+	summary.Rows = append(summary.Rows, []interface{}{"Всего", "", kwh(report.TotalKWh), average(report.TotalKWh)})
+	for _, u := range report.Sites {
+		summary.Rows = append(summary.Rows, []interface{}{"Объект", u.Name, kwh(u.TotalKWh), average(u.TotalKWh)})
+	}
+	for _, u := range report.Switches {
+		summary.Rows = append(summary.Rows, []interface{}{"Коммутатор", u.Name, kwh(u.TotalKWh), average(u.TotalKWh)})
+	}
+
+	cameras := reportTable{
+		Title:    "Камеры",
+		Subtitle: subtitle,
+		Sheet:    "Камеры",
+		Columns:  []string{"Камера", "кВт·ч", "Средняя мощность, Вт", "Максимальная мощность, Вт"},
+		Widths:   []float64{3, 1.2, 1.6, 1.8},
+	}
+	for _, u := range report.Cameras {
+		cameras.Rows = append(cameras.Rows, []interface{}{u.Name, kwh(u.TotalKWh), average(u.TotalKWh), fmt.Sprintf("%.1f", u.MaxPowerW)})
+	}
+
+	daily := reportTable{
+		Title:    "Потребление по дням",
+		Subtitle: subtitle,
+		Sheet:    "По дням",
+		Columns:  []string{"День", "Коммутатор", "кВт·ч"},
+		Widths:   []float64{1.3, 3, 1.2},
+	}
+	for _, u := range report.Switches {
+		for _, d := range u.Days {
+			daily.Rows = append(daily.Rows, []interface{}{d.Day, u.Name, kwh(d.EnergyKWh)})
+		}
+	}
+	sort.SliceStable(daily.Rows, func(i, j int) bool {
+		return daily.Rows[i][0].(string) < daily.Rows[j][0].(string)
+	})
+
+	return []reportTable{summary, cameras, daily}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+)
+
+// portHistoryLimit is the number of latest changes returned for a port
+const portHistoryLimit = 500
+
+// onPortChanges emits an event when a port of a switch lost its link as often
+// within the last hour as the flap limit. The event is raised as the count
+// reaches the limit, and again only after it fell below.
+func (a *App) onPortChanges(device models.Device, changes []models.PortStateChange) {
+	settings := a.appSettings()
+	if settings.PortFlapLimit <= 0 {
+		return
+	}
+
+	repo := database.NewPortHistoryRepository(a.db.DB())
+	for _, c := range changes {
+		if !c.Flap {
+			continue
+		}
+		count, err := repo.CountFlaps(device.ID, c.PortNumber, c.ChangedAt.Add(-time.Hour))
+		if err != nil {
+			log.Printf("Failed to count flaps of switch %d port %d: %v", device.ID, c.PortNumber, err)
+			continue
+		}
+		if count != settings.PortFlapLimit {
+			continue
+		}
+
+		deviceID := device.ID
+		a.onMonitoringEvent(&models.Event{
+			DeviceID: &deviceID,
+			Type:     models.EventTypePortFlapping,
+			Level:    models.EventLevelWarn,
+			Message:  fmt.Sprintf("Port %d of switch %s lost its link %d times within an hour", c.PortNumber, device.Name, count),
+		})
+	}
+}
+
+// recordPortAction stores the admin or PoE state of a port changed by the user
+// and records the change in the port history, so monitoring does not report it
+// as its own
+func (a *App) recordPortAction(switchID int64, portNumber int, field, value string) {
+	ports, err := database.NewSwitchRepository(a.db.DB()).GetPorts(switchID)
+	if err != nil {
+		log.Printf("Failed to record port change: %v", err)
+		return
+	}
+
+	for _, port := range ports {
+		if port.PortNumber != portNumber {
+			continue
+		}
+
+		change := models.PortStateChange{
+			SwitchID:   switchID,
+			PortNumber: portNumber,
+			Field:      field,
+			NewValue:   value,
+			Source:     models.PortChangeUser,
+			Actor:      a.auditActor(),
+			ChangedAt:  time.Now(),
+		}
+		switch field {
+		case models.PortFieldAdmin:
+			change.OldValue, port.AdminStatus = port.AdminStatus, value
+		case models.PortFieldPoE:
+			change.OldValue, port.PoEStatus = port.PoEStatus, value
+		}
+
+		err := a.db.WithTx(func(tx *sql.Tx) error {
+			return database.NewSwitchRepository(tx).UpdatePortState(&port, []models.PortStateChange{change})
+		})
+		if err != nil {
+			log.Printf("Failed to record port change: %v", err)
+		}
+		return
+	}
+}
+
+// GetPortHistory returns the latest state changes of a switch port, newest first
+func (a *App) GetPortHistory(switchID int64, port int) ([]models.PortStateChange, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	return database.NewPortHistoryRepository(a.db.DB()).GetPortHistory(switchID, port, portHistoryLimit)
+}
+
+// GetPortFlapCounters returns the link losses of the ports of a switch over the
+// last hour, day and week
+func (a *App) GetPortFlapCounters(switchID int64) ([]models.PortFlapCounter, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	return database.NewPortHistoryRepository(a.db.DB()).GetFlapCounters(switchID, time.Now())
+}
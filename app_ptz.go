@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/onvif"
+)
+
+// PTZMoveInput describes a PTZ movement request from frontend
+type PTZMoveInput struct {
+	DeviceID     int64   `json:"device_id"`
+	ProfileToken string  `json:"profile_token,omitempty"` // Empty = first media profile
+	Pan          float64 `json:"pan"`
+	Tilt         float64 `json:"tilt"`
+	Zoom         float64 `json:"zoom"`
+	Speed        float64 `json:"speed,omitempty"`   // Optional speed for relative/absolute moves (0 = camera default)
+	Timeout      float64 `json:"timeout,omitempty"` // Seconds, continuous move only (0 = until Stop)
+}
+
+// newPTZClient creates an ONVIF client for a camera and resolves the profile token
+func (a *App) newPTZClient(ctx context.Context, deviceID int64, profileToken string) (*onvif.Client, string, error) {
+	client, err := a.newCameraONVIFClient(deviceID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The PTZ service path is resolved once per camera
+	if path, ok := a.ptzPaths.Load(deviceID); ok {
+		client.SetPTZPath(path.(string))
+	} else if path := client.ResolvePTZPath(ctx); path != "" {
+		a.ptzPaths.Store(deviceID, path)
+	}
+
+	if profileToken != "" {
+		return client, profileToken, nil
+	}
+
+	profiles, err := client.GetProfiles(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get media profiles: %w", err)
+	}
+	if len(profiles) == 0 {
+		return nil, "", fmt.Errorf("camera has no media profiles")
+	}
+
+	return client, profiles[0].Token, nil
+}
+
+// ptzSpeed converts an optional scalar speed into a PTZ speed vector
+func ptzSpeed(speed float64) *onvif.PTZVector {
+	if speed <= 0 {
+		return nil
+	}
+	return &onvif.PTZVector{Pan: speed, Tilt: speed, Zoom: speed}
+}
+
+// beginPTZMoveAudit starts the audit record of a camera movement
+func (a *App) beginPTZMoveAudit(action string, input PTZMoveInput) *auditRecord {
+	rec := a.beginAudit(action, a.deviceAuditTarget(input.DeviceID), nil).
+		detail("pan", input.Pan).detail("tilt", input.Tilt).detail("zoom", input.Zoom)
+	if input.ProfileToken != "" {
+		rec.detail("profile", input.ProfileToken)
+	}
+	if input.Speed > 0 {
+		rec.detail("speed", input.Speed)
+	}
+	if input.Timeout > 0 {
+		rec.detail("timeout", input.Timeout)
+	}
+	return rec
+}
+
+// PTZContinuousMove starts continuous camera movement with the given velocity
+func (a *App) PTZContinuousMove(input PTZMoveInput) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginPTZMoveAudit("camera.ptz_continuous_move", input)
+	defer func() { rec.finish(err) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, token, err := a.newPTZClient(ctx, input.DeviceID, input.ProfileToken)
+	if err != nil {
+		return err
+	}
+
+	velocity := onvif.PTZVector{Pan: input.Pan, Tilt: input.Tilt, Zoom: input.Zoom}
+	if err := client.ContinuousMove(ctx, token, velocity, input.Timeout); err != nil {
+		log.Printf("PTZContinuousMove failed for device %d: %v", input.DeviceID, err)
+		return fmt.Errorf("failed to move camera: %w", err)
+	}
+
+	return nil
+}
+
+// PTZRelativeMove moves the camera relative to its current position
+func (a *App) PTZRelativeMove(input PTZMoveInput) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginPTZMoveAudit("camera.ptz_relative_move", input)
+	defer func() { rec.finish(err) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, token, err := a.newPTZClient(ctx, input.DeviceID, input.ProfileToken)
+	if err != nil {
+		return err
+	}
+
+	translation := onvif.PTZVector{Pan: input.Pan, Tilt: input.Tilt, Zoom: input.Zoom}
+	if err := client.RelativeMove(ctx, token, translation, ptzSpeed(input.Speed)); err != nil {
+		log.Printf("PTZRelativeMove failed for device %d: %v", input.DeviceID, err)
+		return fmt.Errorf("failed to move camera: %w", err)
+	}
+
+	return nil
+}
+
+// PTZAbsoluteMove moves the camera to an absolute position
+func (a *App) PTZAbsoluteMove(input PTZMoveInput) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginPTZMoveAudit("camera.ptz_absolute_move", input)
+	defer func() { rec.finish(err) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, token, err := a.newPTZClient(ctx, input.DeviceID, input.ProfileToken)
+	if err != nil {
+		return err
+	}
+
+	position := onvif.PTZVector{Pan: input.Pan, Tilt: input.Tilt, Zoom: input.Zoom}
+	if err := client.AbsoluteMove(ctx, token, position, ptzSpeed(input.Speed)); err != nil {
+		log.Printf("PTZAbsoluteMove failed for device %d: %v", input.DeviceID, err)
+		return fmt.Errorf("failed to move camera: %w", err)
+	}
+
+	return nil
+}
+
+// PTZStop stops all camera movement
+func (a *App) PTZStop(deviceID int64, profileToken string) error {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, token, err := a.newPTZClient(ctx, deviceID, profileToken)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Stop(ctx, token, true, true); err != nil {
+		log.Printf("PTZStop failed for device %d: %v", deviceID, err)
+		return fmt.Errorf("failed to stop camera: %w", err)
+	}
+
+	return nil
+}
+
+// GetPTZPresets returns all PTZ presets for a camera
+func (a *App) GetPTZPresets(deviceID int64, profileToken string) ([]onvif.PTZPreset, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	client, token, err := a.newPTZClient(ctx, deviceID, profileToken)
+	if err != nil {
+		return nil, err
+	}
+
+	presets, err := client.GetPresets(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get presets: %w", err)
+	}
+
+	return presets, nil
+}
+
+// GotoPTZPreset moves the camera to a stored preset
+func (a *App) GotoPTZPreset(deviceID int64, profileToken, presetToken string) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("camera.ptz_preset_goto", a.deviceAuditTarget(deviceID), nil).detail("token", presetToken)
+	defer func() { rec.finish(err) }()
+
+	if presetToken == "" {
+		return fmt.Errorf("preset token is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, token, err := a.newPTZClient(ctx, deviceID, profileToken)
+	if err != nil {
+		return err
+	}
+
+	if err := client.GotoPreset(ctx, token, presetToken, nil); err != nil {
+		return fmt.Errorf("failed to go to preset: %w", err)
+	}
+
+	log.Printf("Camera %d moved to preset %s", deviceID, presetToken)
+	return nil
+}
+
+// SetPTZPreset saves the current camera position as a preset and returns its token.
+// Pass an existing presetToken to overwrite it.
+func (a *App) SetPTZPreset(deviceID int64, profileToken, presetName, presetToken string) (_ string, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return "", err
+	}
+	if a.db == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("camera.ptz_preset_set", a.deviceAuditTarget(deviceID), nil).detail("name", presetName).detail("token", presetToken)
+	defer func() { rec.finish(err) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, token, err := a.newPTZClient(ctx, deviceID, profileToken)
+	if err != nil {
+		return "", err
+	}
+
+	newToken, err := client.SetPreset(ctx, token, presetName, presetToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to save preset: %w", err)
+	}
+
+	log.Printf("Camera %d preset saved: name=%s, token=%s", deviceID, presetName, newToken)
+	return newToken, nil
+}
+
+// RemovePTZPreset deletes a stored preset
+func (a *App) RemovePTZPreset(deviceID int64, profileToken, presetToken string) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("camera.ptz_preset_remove", a.deviceAuditTarget(deviceID), nil).detail("token", presetToken)
+	defer func() { rec.finish(err) }()
+
+	if presetToken == "" {
+		return fmt.Errorf("preset token is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, token, err := a.newPTZClient(ctx, deviceID, profileToken)
+	if err != nil {
+		return err
+	}
+
+	if err := client.RemovePreset(ctx, token, presetToken); err != nil {
+		return fmt.Errorf("failed to remove preset: %w", err)
+	}
+
+	log.Printf("Camera %d preset %s removed", deviceID, presetToken)
+	return nil
+}
+
+// GetPTZStatus returns current PTZ position and movement state
+func (a *App) GetPTZStatus(deviceID int64, profileToken string) (*onvif.PTZStatus, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, token, err := a.newPTZClient(ctx, deviceID, profileToken)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := client.GetStatus(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PTZ status: %w", err)
+	}
+
+	return status, nil
+}
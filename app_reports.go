@@ -0,0 +1,716 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/pdf"
+	"netvisionmonitor/internal/xlsx"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Report export formats
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatXLSX = "xlsx"
+	ReportFormatPDF  = "pdf"
+)
+
+// reportUptimeDays is the period used for uptime in inventory reports
+const reportUptimeDays = 30
+
+// InventoryReportRow describes a device in the inventory report
+type InventoryReportRow struct {
+	DeviceID      int64      `json:"device_id"`
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	IPAddress     string     `json:"ip_address"`
+	Manufacturer  string     `json:"manufacturer"`
+	Model         string     `json:"model"`
+	SwitchID      *int64     `json:"switch_id,omitempty"`
+	SwitchName    string     `json:"switch_name,omitempty"`
+	SwitchPort    int        `json:"switch_port,omitempty"`
+	Status        string     `json:"status"`
+	UptimePercent float64    `json:"uptime_percent"` // Over the last 30 days
+	TotalChecks   int64      `json:"total_checks"`
+	LastCheck     *time.Time `json:"last_check,omitempty"`
+
+	// Asset data
+	SerialNumber string            `json:"serial_number"`
+	MACAddress   string            `json:"mac_address"`
+	InstallDate  string            `json:"install_date"`
+	WarrantyEnd  string            `json:"warranty_end"`
+	Room         string            `json:"room"`
+	Rack         string            `json:"rack"`
+	RackUnit     string            `json:"rack_unit"`
+	Notes        string            `json:"notes"`
+	Custom       map[string]string `json:"custom"`
+}
+
+// SwitchPortMapRow describes a single switch port in the port map report
+type SwitchPortMapRow struct {
+	PortNumber   int     `json:"port_number"`
+	PortType     string  `json:"port_type"`
+	Status       string  `json:"status"`
+	Speed        string  `json:"speed"`
+	CameraID     *int64  `json:"camera_id,omitempty"`
+	CameraName   string  `json:"camera_name,omitempty"`
+	CameraIP     string  `json:"camera_ip,omitempty"`
+	CameraStatus string  `json:"camera_status,omitempty"`
+	LinkedSwitch string  `json:"linked_switch,omitempty"`
+	PoEStatus    string  `json:"poe_status,omitempty"`
+	PoEPowerW    float64 `json:"poe_power_w"`
+}
+
+// SwitchPortMapReport contains the port map of a switch with PoE consumption
+type SwitchPortMapReport struct {
+	SwitchID   int64              `json:"switch_id"`
+	SwitchName string             `json:"switch_name"`
+	IPAddress  string             `json:"ip_address"`
+	Model      string             `json:"model"`
+	Status     string             `json:"status"`
+	PoETotalW  float64            `json:"poe_total_w"`
+	PoEError   string             `json:"poe_error,omitempty"` // PoE data could not be read via SNMP
+	Ports      []SwitchPortMapRow `json:"ports"`
+}
+
+// GetInventoryReport returns all devices with their connection and 30-day uptime
+func (a *App) GetInventoryReport() ([]InventoryReportRow, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	db := a.db.DB()
+	devices, err := database.NewDeviceRepository(db).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+
+	names := make(map[int64]string, len(devices))
+	for _, d := range devices {
+		names[d.ID] = d.Name
+	}
+
+	// Build device -> switch port links from camera ports and uplinks
+	type portLink struct {
+		switchID   int64
+		portNumber int
+	}
+	links := make(map[int64]portLink)
+	switchRepo := database.NewSwitchRepository(db)
+	for _, d := range devices {
+		if d.Type != models.DeviceTypeSwitch {
+			continue
+		}
+		ports, err := switchRepo.GetPorts(d.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ports of %s: %w", d.Name, err)
+		}
+		for _, p := range ports {
+			if p.LinkedCameraID != nil {
+				links[*p.LinkedCameraID] = portLink{d.ID, p.PortNumber}
+			}
+			if p.LinkedSwitchID != nil {
+				links[*p.LinkedSwitchID] = portLink{d.ID, p.PortNumber}
+			}
+		}
+	}
+
+	// Servers store their uplink separately from the port table
+	serverRepo := database.NewServerRepository(db)
+	for _, d := range devices {
+		if d.Type != models.DeviceTypeServer {
+			continue
+		}
+		if _, ok := links[d.ID]; ok {
+			continue
+		}
+		srv, err := serverRepo.GetByDeviceID(d.ID)
+		if err != nil || srv == nil || srv.UplinkSwitchID == nil || srv.UplinkPortID == nil {
+			continue
+		}
+		ports, err := switchRepo.GetPorts(*srv.UplinkSwitchID)
+		if err != nil {
+			continue
+		}
+		for _, p := range ports {
+			if p.ID == *srv.UplinkPortID {
+				links[d.ID] = portLink{*srv.UplinkSwitchID, p.PortNumber}
+			}
+		}
+	}
+
+	assets, err := database.NewAssetRepository(db).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset data: %w", err)
+	}
+
+	historyRepo := database.NewStatusHistoryRepository(db)
+	since := time.Now().AddDate(0, 0, -reportUptimeDays)
+
+	rows := make([]InventoryReportRow, 0, len(devices))
+	for _, d := range devices {
+		row := InventoryReportRow{
+			DeviceID:     d.ID,
+			Name:         d.Name,
+			Type:         string(d.Type),
+			IPAddress:    d.IPAddress,
+			Manufacturer: d.Manufacturer,
+			Model:        d.Model,
+			Status:       string(d.Status),
+			LastCheck:    d.LastCheck,
+			Custom:       map[string]string{},
+		}
+
+		if asset, ok := assets[d.ID]; ok {
+			row.SerialNumber = asset.SerialNumber
+			row.MACAddress = asset.MACAddress
+			row.InstallDate = asset.InstallDate
+			row.WarrantyEnd = asset.WarrantyEnd
+			row.Room = asset.Room
+			row.Rack = asset.Rack
+			row.RackUnit = asset.RackUnit
+			row.Notes = asset.Notes
+			row.Custom = asset.Custom
+		}
+
+		if link, ok := links[d.ID]; ok {
+			switchID := link.switchID
+			row.SwitchID = &switchID
+			row.SwitchName = names[link.switchID]
+			row.SwitchPort = link.portNumber
+		}
+
+		stats, err := historyRepo.GetStatsSince(d.ID, since)
+		if err != nil {
+			log.Printf("Failed to get stats for device %d: %v", d.ID, err)
+		} else {
+			row.UptimePercent = math.Round(stats.UptimePercent*100) / 100
+			row.TotalChecks = stats.TotalChecks
+		}
+
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Type != rows[j].Type {
+			return deviceTypeOrder(rows[i].Type) < deviceTypeOrder(rows[j].Type)
+		}
+		return rows[i].Name < rows[j].Name
+	})
+
+	return rows, nil
+}
+
+// GetSwitchPortMapReport returns the port map of a switch with live PoE consumption
+func (a *App) GetSwitchPortMapReport(switchID int64) (*SwitchPortMapReport, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	db := a.db.DB()
+	deviceRepo := database.NewDeviceRepository(db)
+	device, err := deviceRepo.GetByID(switchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device == nil || device.Type != models.DeviceTypeSwitch {
+		return nil, fmt.Errorf("switch not found")
+	}
+
+	return a.buildSwitchPortMap(deviceRepo, device)
+}
+
+// ExportInventoryReport asks for a file and writes the inventory report in the given format
+func (a *App) ExportInventoryReport(format string) (string, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return "", err
+	}
+	if a.db == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+	if err := validateReportFormat(format); err != nil {
+		return "", err
+	}
+
+	savePath, err := a.askReportPath("Экспорт отчёта об оборудовании", "netvision_inventory", format)
+	if err != nil || savePath == "" {
+		return "", err
+	}
+
+	rows, err := a.GetInventoryReport()
+	if err != nil {
+		return "", err
+	}
+
+	table := reportTable{
+		Title:    "Отчёт об оборудовании",
+		Subtitle: fmt.Sprintf("Сформирован %s, доступность за %d дней", time.Now().Format("02.01.2006 15:04"), reportUptimeDays),
+		Sheet:    "Оборудование",
+		Columns:  []string{"Название", "Тип", "IP адрес", "Производитель", "Модель", "Коммутатор", "Порт", "Статус", "Доступность, %"},
+		Widths:   []float64{3, 1.4, 1.6, 1.8, 2, 2.5, 0.8, 1.2, 1.4},
+	}
+	for _, r := range rows {
+		var port interface{}
+		if r.SwitchPort > 0 {
+			port = r.SwitchPort
+		}
+		var uptime interface{}
+		if r.TotalChecks > 0 {
+			uptime = r.UptimePercent
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			r.Name, deviceTypeLabel(r.Type), r.IPAddress, r.Manufacturer, r.Model,
+			r.SwitchName, port, deviceStatusLabel(r.Status), uptime,
+		})
+	}
+
+	fields, err := database.NewAssetRepository(a.db.DB()).GetFields()
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeReport(savePath, format, []reportTable{table, assetReportTable(rows, fields)}); err != nil {
+		return "", err
+	}
+
+	log.Printf("Inventory report exported to %s", savePath)
+	return savePath, nil
+}
+
+// assetReportTable lists asset data of devices with a column per custom field
+func assetReportTable(rows []InventoryReportRow, fields []models.AssetField) reportTable {
+	table := reportTable{
+		Title:    "Учёт оборудования",
+		Subtitle: fmt.Sprintf("Сформирован %s", time.Now().Format("02.01.2006 15:04")),
+		Sheet:    "Учёт",
+		Columns:  []string{"Название", "Тип", "Серийный номер", "MAC адрес", "Установлено", "Гарантия до", "Помещение", "Шкаф", "Юнит", "Примечание"},
+		Widths:   []float64{3, 1.4, 2, 2, 1.4, 1.4, 1.6, 1.2, 0.8, 3},
+	}
+	for _, f := range fields {
+		table.Columns = append(table.Columns, f.Label)
+		table.Widths = append(table.Widths, 1.6)
+	}
+
+	for _, r := range rows {
+		row := []interface{}{
+			r.Name, deviceTypeLabel(r.Type), r.SerialNumber, r.MACAddress,
+			reportDate(r.InstallDate), reportDate(r.WarrantyEnd), r.Room, r.Rack, r.RackUnit, r.Notes,
+		}
+		for _, f := range fields {
+			value := r.Custom[f.Key]
+			if f.FieldType == models.AssetFieldDate {
+				value = reportDate(value)
+			}
+			row = append(row, value)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// reportDate formats an asset date for reports
+func reportDate(value string) string {
+	t, err := time.Parse(models.AssetDateLayout, value)
+	if err != nil {
+		return value
+	}
+	return t.Format("02.01.2006")
+}
+
+// ExportSwitchPortMapReport asks for a file and writes the port map of a switch,
+// or of all switches if switchID is 0
+func (a *App) ExportSwitchPortMapReport(switchID int64, format string) (string, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return "", err
+	}
+	if a.db == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+	if err := validateReportFormat(format); err != nil {
+		return "", err
+	}
+
+	deviceRepo := database.NewDeviceRepository(a.db.DB())
+	var switches []models.Device
+	if switchID != 0 {
+		device, err := deviceRepo.GetByID(switchID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get device: %w", err)
+		}
+		if device == nil || device.Type != models.DeviceTypeSwitch {
+			return "", fmt.Errorf("switch not found")
+		}
+		switches = append(switches, *device)
+	} else {
+		all, err := deviceRepo.GetByType(models.DeviceTypeSwitch)
+		if err != nil {
+			return "", fmt.Errorf("failed to get switches: %w", err)
+		}
+		if len(all) == 0 {
+			return "", fmt.Errorf("no switches configured")
+		}
+		switches = all
+	}
+
+	baseName := "netvision_port_map"
+	if len(switches) == 1 {
+		baseName += "_" + sanitizeFileName(switches[0].Name)
+	}
+	savePath, err := a.askReportPath("Экспорт карты портов", baseName, format)
+	if err != nil || savePath == "" {
+		return "", err
+	}
+
+	// SNMP queries are slow, so read switches in parallel
+	reports := make([]*SwitchPortMapReport, len(switches))
+	errs := make([]error, len(switches))
+	sem := make(chan struct{}, maxConcurrentCameraChecks)
+	var wg sync.WaitGroup
+	for i := range switches {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			reports[i], errs[i] = a.buildSwitchPortMap(deviceRepo, &switches[i])
+		}(i)
+	}
+	wg.Wait()
+
+	generated := time.Now().Format("02.01.2006 15:04")
+	var tables []reportTable
+	for i, rep := range reports {
+		if errs[i] != nil {
+			return "", errs[i]
+		}
+
+		subtitle := fmt.Sprintf("%s, %s. Сформирован %s", rep.IPAddress, rep.Model, generated)
+		if rep.PoEError != "" {
+			subtitle += ". Данные PoE недоступны: " + rep.PoEError
+		} else {
+			subtitle += fmt.Sprintf(". Потребление PoE: %.1f Вт", rep.PoETotalW)
+		}
+
+		table := reportTable{
+			Title:    "Карта портов: " + rep.SwitchName,
+			Subtitle: subtitle,
+			Sheet:    rep.SwitchName,
+			Columns:  []string{"Порт", "Тип", "Состояние", "Скорость", "Подключено", "IP адрес", "Статус", "PoE", "Мощность, Вт"},
+			Widths:   []float64{0.7, 0.9, 1.2, 1.1, 3, 1.6, 1.2, 0.9, 1.2},
+		}
+		for _, p := range rep.Ports {
+			linked := p.CameraName
+			if linked == "" && p.LinkedSwitch != "" {
+				linked = "Коммутатор: " + p.LinkedSwitch
+			}
+			var power interface{}
+			if p.PoEStatus != "" {
+				power = p.PoEPowerW
+			}
+			table.Rows = append(table.Rows, []interface{}{
+				p.PortNumber, strings.ToUpper(p.PortType), p.Status, p.Speed,
+				linked, p.CameraIP, deviceStatusLabel(p.CameraStatus), p.PoEStatus, power,
+			})
+		}
+		tables = append(tables, table)
+	}
+
+	if err := writeReport(savePath, format, tables); err != nil {
+		return "", err
+	}
+
+	log.Printf("Port map report for %d switches exported to %s", len(tables), savePath)
+	return savePath, nil
+}
+
+// buildSwitchPortMap collects ports, linked devices and PoE consumption of a switch
+func (a *App) buildSwitchPortMap(deviceRepo *database.DeviceRepository, device *models.Device) (*SwitchPortMapReport, error) {
+	switchRepo := database.NewSwitchRepository(a.db.DB())
+	sw, err := switchRepo.GetByDeviceID(device.ID)
+	if err != nil || sw == nil {
+		return nil, fmt.Errorf("switch configuration not found for %s", device.Name)
+	}
+	ports, err := switchRepo.GetPorts(device.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ports of %s: %w", device.Name, err)
+	}
+
+	report := &SwitchPortMapReport{
+		SwitchID:   device.ID,
+		SwitchName: device.Name,
+		IPAddress:  device.IPAddress,
+		Model:      strings.TrimSpace(device.Manufacturer + " " + device.Model),
+		Status:     string(device.Status),
+	}
+
+	poe := make(map[int]models.SNMPPoEInfo)
+	copperPorts := sw.PortCount - sw.SFPPortCount
+	if copperPorts > 0 {
+		client := createSNMPClient(device.IPAddress, sw)
+		infos, err := client.GetAllPoEInfo(copperPorts)
+		if err != nil {
+			report.PoEError = err.Error()
+		} else {
+			for _, p := range infos {
+				poe[p.PortNumber] = models.SNMPPoEInfo{
+					PortNumber: p.PortNumber,
+					Enabled:    p.Enabled,
+					Active:     p.Active,
+					Status:     p.Status,
+					PowerMW:    p.PowerMW,
+					PowerW:     p.PowerW,
+				}
+			}
+		}
+	}
+
+	for _, p := range ports {
+		row := SwitchPortMapRow{
+			PortNumber: p.PortNumber,
+			PortType:   p.PortType,
+			Status:     p.Status,
+			Speed:      p.Speed,
+			CameraID:   p.LinkedCameraID,
+		}
+
+		if p.LinkedCameraID != nil {
+			if cam, err := deviceRepo.GetByID(*p.LinkedCameraID); err == nil && cam != nil {
+				row.CameraName = cam.Name
+				row.CameraIP = cam.IPAddress
+				row.CameraStatus = string(cam.Status)
+			}
+		}
+		if p.LinkedSwitchID != nil {
+			if linked, err := deviceRepo.GetByID(*p.LinkedSwitchID); err == nil && linked != nil {
+				row.LinkedSwitch = linked.Name
+			}
+		}
+
+		if info, ok := poe[p.PortNumber]; ok {
+			row.PoEStatus = info.Status
+			row.PoEPowerW = math.Round(info.PowerW*10) / 10
+			report.PoETotalW += info.PowerW
+		}
+
+		report.Ports = append(report.Ports, row)
+	}
+	report.PoETotalW = math.Round(report.PoETotalW*10) / 10
+
+	return report, nil
+}
+
+// askReportPath shows a save dialog for a report file
+func (a *App) askReportPath(title, baseName, format string) (string, error) {
+	filters := map[string]runtime.FileFilter{
+		ReportFormatCSV:  {DisplayName: "CSV (*.csv)", Pattern: "*.csv"},
+		ReportFormatXLSX: {DisplayName: "Excel (*.xlsx)", Pattern: "*.xlsx"},
+		ReportFormatPDF:  {DisplayName: "PDF (*.pdf)", Pattern: "*.pdf"},
+	}
+
+	savePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           title,
+		DefaultFilename: fmt.Sprintf("%s_%s.%s", baseName, time.Now().Format("2006-01-02"), format),
+		Filters:         []runtime.FileFilter{filters[format]},
+	})
+	if err != nil {
+		return "", err
+	}
+	if savePath == "" {
+		return "", nil // User cancelled
+	}
+	if !strings.EqualFold(filepath.Ext(savePath), "."+format) {
+		savePath += "." + format
+	}
+	return savePath, nil
+}
+
+// reportTable is a titled table written to one of the report formats
+type reportTable struct {
+	Title    string
+	Subtitle string
+	Sheet    string // XLSX worksheet name
+	Columns  []string
+	Widths   []float64 // Relative PDF column widths
+	Rows     [][]interface{}
+}
+
+// writeReport writes tables to a CSV, XLSX or PDF file
+func writeReport(path, format string, tables []reportTable) error {
+	switch format {
+	case ReportFormatCSV:
+		return writeReportCSV(path, tables)
+	case ReportFormatXLSX:
+		return writeReportXLSX(path, tables)
+	case ReportFormatPDF:
+		return writeReportPDF(path, tables)
+	}
+	return validateReportFormat(format)
+}
+
+func validateReportFormat(format string) error {
+	switch format {
+	case ReportFormatCSV, ReportFormatXLSX, ReportFormatPDF:
+		return nil
+	}
+	return fmt.Errorf("unsupported report format: %s", format)
+}
+
+// writeReportCSV writes a semicolon-separated CSV with a BOM so that Excel opens it correctly
+func writeReportCSV(path string, tables []reportTable) error {
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+
+	w := csv.NewWriter(&buf)
+	w.Comma = ';'
+	for i, t := range tables {
+		if len(tables) > 1 {
+			if i > 0 {
+				w.Write(nil)
+			}
+			w.Write([]string{t.Title})
+		}
+		w.Write(t.Columns)
+		for _, row := range t.Rows {
+			record := make([]string, len(row))
+			for j, v := range row {
+				record[j] = reportCellText(v)
+			}
+			w.Write(record)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return nil
+}
+
+func writeReportXLSX(path string, tables []reportTable) error {
+	sheets := make([]xlsx.Sheet, len(tables))
+	for i, t := range tables {
+		header := make([]interface{}, len(t.Columns))
+		for j, c := range t.Columns {
+			header[j] = c
+		}
+		sheets[i] = xlsx.Sheet{Name: t.Sheet, Rows: append([][]interface{}{header}, t.Rows...)}
+	}
+	return xlsx.WriteFile(path, sheets...)
+}
+
+func writeReportPDF(path string, tables []reportTable) error {
+	doc := pdf.New(true)
+	if err := doc.LoadSystemFont(); err != nil {
+		log.Printf("PDF report: %v, falling back to Helvetica", err)
+	}
+	doc.SetFooter("NetVisionMonitor")
+
+	for _, t := range tables {
+		doc.AddPage()
+		doc.Heading(t.Title, 14)
+		if t.Subtitle != "" {
+			doc.Heading(t.Subtitle, 9)
+		}
+		doc.Space(4)
+
+		rows := make([][]string, len(t.Rows))
+		for i, row := range t.Rows {
+			rows[i] = make([]string, len(row))
+			for j, v := range row {
+				rows[i][j] = reportCellText(v)
+			}
+		}
+		doc.Table(t.Columns, t.Widths, rows)
+	}
+
+	return doc.WriteFile(path)
+}
+
+func reportCellText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strings.Replace(fmt.Sprintf("%.2f", val), ".", ",", 1)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func deviceTypeLabel(t string) string {
+	switch models.DeviceType(t) {
+	case models.DeviceTypeSwitch:
+		return "Коммутатор"
+	case models.DeviceTypeCamera:
+		return "Камера"
+	case models.DeviceTypeServer:
+		return "Сервер"
+	case models.DeviceTypeRouter:
+		return "Маршрутизатор"
+	case models.DeviceTypeAccessPoint:
+		return "Точка доступа"
+	case models.DeviceTypeUPS:
+		return "ИБП"
+	case models.DeviceTypeNVR:
+		return "Видеорегистратор"
+	case models.DeviceTypePDU:
+		return "PDU"
+	case models.DeviceTypeHost:
+		return "Хост"
+	}
+	return t
+}
+
+func deviceTypeOrder(t string) int {
+	if i := slices.Index(models.DeviceTypes, models.DeviceType(t)); i >= 0 {
+		return i
+	}
+	return len(models.DeviceTypes)
+}
+
+func deviceStatusLabel(s string) string {
+	switch models.DeviceStatus(s) {
+	case models.DeviceStatusOnline:
+		return "В сети"
+	case models.DeviceStatusOffline:
+		return "Не в сети"
+	case models.DeviceStatusUnknown:
+		return "Неизвестно"
+	}
+	return s
+}
+
+// sanitizeFileName replaces characters that are not allowed in file names
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+}
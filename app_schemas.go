@@ -34,6 +34,9 @@ type SchemaItemInput struct {
 
 // GetSchemas returns all schemas
 func (a *App) GetSchemas() ([]models.Schema, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -44,6 +47,9 @@ func (a *App) GetSchemas() ([]models.Schema, error) {
 
 // GetSchema returns a schema by ID with its items
 func (a *App) GetSchema(id int64) (*models.Schema, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -54,6 +60,9 @@ func (a *App) GetSchema(id int64) (*models.Schema, error) {
 
 // GetSchemaItems returns all items for a schema
 func (a *App) GetSchemaItems(schemaID int64) ([]models.SchemaItem, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -63,11 +72,17 @@ func (a *App) GetSchemaItems(schemaID int64) ([]models.SchemaItem, error) {
 }
 
 // CreateSchema creates a new schema
-func (a *App) CreateSchema(input SchemaInput) (*models.Schema, error) {
+func (a *App) CreateSchema(input SchemaInput) (_ *models.Schema, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("schema.create", models.AuditTarget{Type: "schema", Name: input.Name}, nil)
+	defer func() { rec.finish(err) }()
+
 	if input.Name == "" {
 		return nil, fmt.Errorf("name is required")
 	}
@@ -93,16 +108,23 @@ func (a *App) CreateSchema(input SchemaInput) (*models.Schema, error) {
 	if err := repo.Create(schema); err != nil {
 		return nil, err
 	}
+	rec.track(schema.ID, schema.Name, a.rowAuditState("schemas", "id", schema.ID))
 
 	return schema, nil
 }
 
 // UpdateSchema updates an existing schema
-func (a *App) UpdateSchema(input SchemaInput) error {
+func (a *App) UpdateSchema(input SchemaInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("schema.update", auditTarget("schema", input.ID, input.Name), a.rowAuditState("schemas", "id", input.ID))
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewSchemaRepository(a.db.DB())
 	existing, err := repo.GetByID(input.ID)
 	if err != nil {
@@ -135,11 +157,17 @@ func (a *App) UpdateSchema(input SchemaInput) error {
 }
 
 // DeleteSchema deletes a schema
-func (a *App) DeleteSchema(id int64) error {
+func (a *App) DeleteSchema(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("schema.delete", auditTarget("schema", id, ""), a.rowAuditState("schemas", "id", id))
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewSchemaRepository(a.db.DB())
 	schema, err := repo.GetByID(id)
 	if err != nil {
@@ -155,11 +183,17 @@ func (a *App) DeleteSchema(id int64) error {
 }
 
 // AddDeviceToSchema adds a device to a schema
-func (a *App) AddDeviceToSchema(input SchemaItemInput) (*models.SchemaItem, error) {
+func (a *App) AddDeviceToSchema(input SchemaItemInput) (_ *models.SchemaItem, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("schema.item_add", a.deviceAuditTarget(input.DeviceID), nil).detail("schema_id", input.SchemaID)
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewSchemaItemRepository(a.db.DB())
 
 	// Check if device already exists on schema
@@ -190,26 +224,39 @@ func (a *App) AddDeviceToSchema(input SchemaItemInput) (*models.SchemaItem, erro
 	if err := repo.Create(item); err != nil {
 		return nil, err
 	}
+	rec.state = a.rowAuditState("schema_items", "id", item.ID)
 
 	return item, nil
 }
 
 // UpdateSchemaItemPosition updates a schema item position
-func (a *App) UpdateSchemaItemPosition(id int64, x, y float64) error {
+func (a *App) UpdateSchemaItemPosition(id int64, x, y float64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("schema.item_move", auditTarget("schema_item", id, ""), a.rowAuditState("schema_items", "id", id))
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewSchemaItemRepository(a.db.DB())
 	return repo.UpdatePosition(id, x, y)
 }
 
 // UpdateSchemaItem updates a schema item
-func (a *App) UpdateSchemaItem(input SchemaItemInput) error {
+func (a *App) UpdateSchemaItem(input SchemaItemInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("schema.item_update", auditTarget("schema_item", input.ID, ""), a.rowAuditState("schema_items", "id", input.ID))
+	defer func() { rec.finish(err) }()
+
 	item := &models.SchemaItem{
 		ID:     input.ID,
 		X:      input.X,
@@ -223,17 +270,27 @@ func (a *App) UpdateSchemaItem(input SchemaItemInput) error {
 }
 
 // RemoveDeviceFromSchema removes a device from a schema
-func (a *App) RemoveDeviceFromSchema(itemID int64) error {
+func (a *App) RemoveDeviceFromSchema(itemID int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("schema.item_remove", auditTarget("schema_item", itemID, ""), a.rowAuditState("schema_items", "id", itemID))
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewSchemaItemRepository(a.db.DB())
 	return repo.Delete(itemID)
 }
 
 // SelectBackgroundImage opens a file dialog to select background image
 func (a *App) SelectBackgroundImage() (string, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return "", err
+	}
+
 	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
 		Title: "Выберите изображение",
 		Filters: []runtime.FileFilter{
@@ -312,6 +369,10 @@ func (a *App) saveBackgroundImage(base64Data string) (string, error) {
 
 // GetBackgroundImage returns background image as base64
 func (a *App) GetBackgroundImage(path string) (string, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return "", err
+	}
+
 	if path == "" {
 		return "", nil
 	}
@@ -35,12 +35,65 @@ type AppSettings struct {
 	NotifyOnOnline     bool    `json:"notify_on_online"`
 	NotifyOnPortChange bool    `json:"notify_on_port_change"`
 
+	// Incident settings
+	DegradedLatency        int `json:"degraded_latency"`         // ms, online devices responding slower are degraded, 0 = disabled
+	IncidentRepeatInterval int `json:"incident_repeat_interval"` // minutes between reminders of unacknowledged incidents, 0 = disabled
+
+	// Switch environment settings, 0 = disabled
+	SwitchTemperatureLimit int `json:"switch_temperature_limit"` // °C
+	PoEBudgetLimit         int `json:"poe_budget_limit"`         // Percent of the PoE budget in use
+	SwitchCPULimit         int `json:"switch_cpu_limit"`         // Percent
+	SwitchMemoryLimit      int `json:"switch_memory_limit"`      // Percent
+	PoEPowerDropPercent    int `json:"poe_power_drop_percent"`   // Drop of port power below its usual level
+	PoEPowerSpikePercent   int `json:"poe_power_spike_percent"`  // Rise of port power above its usual level
+	PortFlapLimit          int `json:"port_flap_limit"`          // Link losses of a port within an hour
+
 	// Data settings
-	EventRetentionDays int `json:"event_retention_days"`
+	EventRetentionDays             int `json:"event_retention_days"`
+	HistoryRetentionDays           int `json:"history_retention_days"`            // Raw status checks
+	HourlyRetentionDays            int `json:"hourly_retention_days"`             // Hourly status rollups
+	DailyRetentionDays             int `json:"daily_retention_days"`              // Daily status rollups
+	AuditRetentionDays             int `json:"audit_retention_days"`              // Audit log, 0 = keep forever
+	SwitchEnvironmentRetentionDays int `json:"switch_environment_retention_days"` // Switch temperature, fans, PSUs and load, at least a day
+	PoEPowerRetentionDays          int `json:"poe_power_retention_days"`          // PoE power readings of ports, at least a day
+	PoEEnergyRetentionDays         int `json:"poe_energy_retention_days"`         // Daily PoE energy, at least two months, 0 = keep forever
+	PortHistoryRetentionDays       int `json:"port_history_retention_days"`       // Switch port changes and flaps, at least a week, 0 = keep forever
+
+	// Automatic backup settings
+	AutoBackupEnabled    bool   `json:"auto_backup_enabled"`
+	AutoBackupInterval   int    `json:"auto_backup_interval"`    // hours
+	AutoBackupDir        string `json:"auto_backup_dir"`         // Empty = "backups" in the data directory
+	AutoBackupKeepLast   int    `json:"auto_backup_keep_last"`   // Newest backups kept
+	AutoBackupKeepDaily  int    `json:"auto_backup_keep_daily"`  // Days a backup is kept for
+	AutoBackupKeepWeekly int    `json:"auto_backup_keep_weekly"` // Weeks a backup is kept for
 
 	// Camera settings
 	CameraSnapshotInterval int    `json:"camera_snapshot_interval"` // seconds
 	CameraStreamType       string `json:"camera_stream_type"`       // "jpeg", "mjpeg", "hls"
+	ClockDriftThreshold    int    `json:"clock_drift_threshold"`    // seconds
+
+	// Compliance settings
+	ComplianceInterval int `json:"compliance_interval"` // hours, 0 = disabled
+
+	// Asset settings
+	WarrantyReminderDays int `json:"warranty_reminder_days"` // Days before warranty end to remind, 0 = disabled
+
+	// SLA report settings
+	SLATarget           float64 `json:"sla_target"` // percent
+	SLAReportEnabled    bool    `json:"sla_report_enabled"`
+	SLAReportDay        int     `json:"sla_report_day"`        // Day of month the previous month is reported
+	SLAReportFormat     string  `json:"sla_report_format"`     // "pdf", "xlsx", "csv"
+	SLAReportDelivery   string  `json:"sla_report_delivery"`   // "file" (export directory) or "email"
+	SLAReportRecipients string  `json:"sla_report_recipients"` // Comma-separated e-mail addresses
+
+	// E-mail settings (password is stored separately, see SetSMTPPassword)
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPFrom     string `json:"smtp_from"`
+
+	// Security settings
+	SessionTimeoutMinutes int `json:"session_timeout_minutes"` // Idle time before the session locks, 0 = never
 
 	// System settings
 	MinimizeToTray bool `json:"minimize_to_tray"` // Minimize to tray on close
@@ -49,46 +102,94 @@ type AppSettings struct {
 // DefaultAppSettings returns default settings
 func DefaultAppSettings() AppSettings {
 	return AppSettings{
-		Theme:                  "light",
-		MonitoringInterval:     30,
-		PingTimeout:            3,
-		SNMPTimeout:            5,
-		MonitoringWorkers:      10,
-		AutoStartMonitor:       true,
-		SoundEnabled:           true,
-		SoundVolume:            0.5,
-		NotifyOnOffline:        true,
-		NotifyOnOnline:         true,
-		NotifyOnPortChange:     false,
-		EventRetentionDays:     30,
-		CameraSnapshotInterval: 60,
-		CameraStreamType:       "jpeg",
-		MinimizeToTray:         true,
+		Theme:                          "light",
+		MonitoringInterval:             30,
+		PingTimeout:                    3,
+		SNMPTimeout:                    5,
+		MonitoringWorkers:              10,
+		AutoStartMonitor:               true,
+		SoundEnabled:                   true,
+		SoundVolume:                    0.5,
+		NotifyOnOffline:                true,
+		NotifyOnOnline:                 true,
+		NotifyOnPortChange:             false,
+		DegradedLatency:                1000,
+		IncidentRepeatInterval:         5,
+		SwitchTemperatureLimit:         60,
+		PoEBudgetLimit:                 90,
+		SwitchCPULimit:                 90,
+		SwitchMemoryLimit:              90,
+		PoEPowerDropPercent:            50,
+		PoEPowerSpikePercent:           50,
+		PortFlapLimit:                  5,
+		EventRetentionDays:             30,
+		HistoryRetentionDays:           7,
+		HourlyRetentionDays:            90,
+		DailyRetentionDays:             730,
+		AuditRetentionDays:             365,
+		SwitchEnvironmentRetentionDays: 30,
+		PoEPowerRetentionDays:          30,
+		PoEEnergyRetentionDays:         730,
+		PortHistoryRetentionDays:       90,
+		AutoBackupEnabled:              true,
+		AutoBackupInterval:             24,
+		AutoBackupKeepLast:             7,
+		AutoBackupKeepDaily:            7,
+		AutoBackupKeepWeekly:           4,
+		CameraSnapshotInterval:         60,
+		CameraStreamType:               "jpeg",
+		ClockDriftThreshold:            10,
+		ComplianceInterval:             24,
+		WarrantyReminderDays:           30,
+		SLATarget:                      99.5,
+		SLAReportEnabled:               false,
+		SLAReportDay:                   1,
+		SLAReportFormat:                ReportFormatPDF,
+		SLAReportDelivery:              SLADeliveryFile,
+		SMTPPort:                       587,
+		SessionTimeoutMinutes:          15,
+		MinimizeToTray:                 true,
 	}
 }
 
 // GetAppSettings returns current application settings
 func (a *App) GetAppSettings() (AppSettings, error) {
-	if a.db == nil {
-		return DefaultAppSettings(), nil
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return AppSettings{}, err
 	}
 
-	repo := database.NewSettingsRepository(a.db.DB())
+	return a.appSettings(), nil
+}
+
+// appSettings is GetAppSettings without the permission check, for background tasks
+func (a *App) appSettings() AppSettings {
 	settings := DefaultAppSettings()
+	if a.db == nil {
+		return settings
+	}
 
+	repo := database.NewSettingsRepository(a.db.DB())
 	if err := repo.GetJSON("app_settings", &settings); err != nil {
-		return settings, nil // Return defaults on error
+		return settings // Return defaults on error
 	}
 
-	return settings, nil
+	return settings
 }
 
 // SaveAppSettings saves application settings
-func (a *App) SaveAppSettings(settings AppSettings) error {
+func (a *App) SaveAppSettings(settings AppSettings) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("settings.update", models.AuditTarget{Type: "settings"}, valueAuditState(func() (interface{}, error) {
+		return a.appSettings(), nil
+	}))
+	defer func() { rec.finish(err) }()
+
 	repo := database.NewSettingsRepository(a.db.DB())
 	if err := repo.SetJSON("app_settings", settings); err != nil {
 		return err
@@ -98,6 +199,7 @@ func (a *App) SaveAppSettings(settings AppSettings) error {
 	if a.monitor != nil {
 		a.monitor.SetInterval(time.Duration(settings.MonitoringInterval) * time.Second)
 	}
+	a.applySessionTimeout(settings)
 
 	// Emit settings changed event
 	runtime.EventsEmit(a.ctx, "settings:changed", settings)
@@ -107,6 +209,9 @@ func (a *App) SaveAppSettings(settings AppSettings) error {
 
 // ExportData exports all data to a ZIP file
 func (a *App) ExportData() (string, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return "", err
+	}
 	if a.db == nil {
 		return "", fmt.Errorf("database not initialized")
 	}
@@ -136,9 +241,18 @@ func (a *App) ExportData() (string, error) {
 	zipWriter := zip.NewWriter(zipFile)
 	defer zipWriter.Close()
 
-	// Export database
-	dbPath := filepath.Join(a.cfg.DataDir, "netvision.db")
-	if err := addFileToZip(zipWriter, dbPath, "netvision.db"); err != nil {
+	// Export a snapshot of the database, the live file may be written meanwhile
+	snapshotDir, err := os.MkdirTemp("", "netvision-export-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	defer os.RemoveAll(snapshotDir)
+
+	snapshot := filepath.Join(snapshotDir, "netvision.db")
+	if err := a.db.Snapshot(snapshot); err != nil {
+		return "", err
+	}
+	if err := addFileToZip(zipWriter, snapshot, "netvision.db"); err != nil {
 		return "", fmt.Errorf("failed to add database to zip: %w", err)
 	}
 
@@ -171,7 +285,10 @@ func (a *App) ExportData() (string, error) {
 }
 
 // ImportData imports data from a ZIP file
-func (a *App) ImportData() (bool, error) {
+func (a *App) ImportData() (imported bool, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return false, err
+	}
 	if a.db == nil {
 		return false, fmt.Errorf("database not initialized")
 	}
@@ -202,6 +319,10 @@ func (a *App) ImportData() (bool, error) {
 		return false, nil
 	}
 
+	// The database is replaced, so the entry is appended to the restored audit log
+	rec := a.beginAudit("data.import", models.AuditTarget{Type: "system"}, nil).detail("file", filePath)
+	defer func() { rec.finish(err) }()
+
 	// Stop monitoring
 	if a.monitor != nil {
 		a.monitor.Stop()
@@ -271,6 +392,10 @@ func (a *App) GetDataPath() string {
 
 // OpenDataFolder opens the data folder in file explorer
 func (a *App) OpenDataFolder() error {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+
 	if a.cfg == nil {
 		return fmt.Errorf("config not initialized")
 	}
@@ -279,7 +404,10 @@ func (a *App) OpenDataFolder() error {
 }
 
 // ClearOldData removes old events and cache
-func (a *App) ClearOldData(daysToKeep int) (int64, error) {
+func (a *App) ClearOldData(daysToKeep int) (deleted int64, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return 0, err
+	}
 	if a.db == nil {
 		return 0, fmt.Errorf("database not initialized")
 	}
@@ -288,6 +416,9 @@ func (a *App) ClearOldData(daysToKeep int) (int64, error) {
 		daysToKeep = 30
 	}
 
+	rec := a.beginAudit("events.clear_old", models.AuditTarget{Type: "events"}, nil).detail("days_to_keep", daysToKeep)
+	defer func() { rec.detail("deleted", deleted).finish(err) }()
+
 	repo := database.NewEventRepository(a.db.DB())
 	before := time.Now().AddDate(0, 0, -daysToKeep)
 	return repo.DeleteOlderThan(before)
@@ -323,20 +454,25 @@ func addFileToZip(zipWriter *zip.Writer, filePath, zipPath string) error {
 }
 
 // GetSettings returns current settings (legacy compatibility)
-func (a *App) GetSettings() models.Settings {
-	settings, _ := a.GetAppSettings()
+func (a *App) GetSettings() (models.Settings, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return models.Settings{}, err
+	}
+
+	settings := a.appSettings()
 	return models.Settings{
 		Theme:              settings.Theme,
 		MonitoringInterval: settings.MonitoringInterval,
 		PingTimeout:        settings.PingTimeout * 1000, // Convert to ms
 		SNMPTimeout:        settings.SNMPTimeout * 1000, // Convert to ms
 		StreamType:         settings.CameraStreamType,
-	}
+		MinimizeToTray:     settings.MinimizeToTray,
+	}, nil
 }
 
 // SaveSettings saves settings (legacy compatibility)
 func (a *App) SaveSettings(settings models.Settings) error {
-	appSettings, _ := a.GetAppSettings()
+	appSettings := a.appSettings()
 	appSettings.Theme = settings.Theme
 	appSettings.MonitoringInterval = settings.MonitoringInterval
 	if settings.PingTimeout > 0 {
@@ -356,7 +492,14 @@ func (a *App) GetAutostartEnabled() bool {
 }
 
 // SetAutostartEnabled enables or disables autostart
-func (a *App) SetAutostartEnabled(enabled bool) error {
+func (a *App) SetAutostartEnabled(enabled bool) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+
+	rec := a.beginAudit("autostart.set", models.AuditTarget{Type: "system"}, nil).detail("enabled", enabled)
+	defer func() { rec.finish(err) }()
+
 	if enabled {
 		return autostart.Enable()
 	}
@@ -364,6 +507,13 @@ func (a *App) SetAutostartEnabled(enabled bool) error {
 }
 
 // ToggleAutostart toggles autostart state and returns new state
-func (a *App) ToggleAutostart() (bool, error) {
+func (a *App) ToggleAutostart() (enabled bool, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return false, err
+	}
+
+	rec := a.beginAudit("autostart.set", models.AuditTarget{Type: "system"}, nil)
+	defer func() { rec.detail("enabled", enabled).finish(err) }()
+
 	return autostart.Toggle()
 }
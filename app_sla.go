@@ -0,0 +1,734 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/mailer"
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/sla"
+)
+
+// SLA report delivery methods
+const (
+	SLADeliveryFile  = "file"
+	SLADeliveryEmail = "email"
+)
+
+// Settings keys used by SLA reports
+const (
+	settingsKeySMTPPassword  = "smtp_password"
+	settingsKeyLastSLAReport = "sla_last_report"
+)
+
+// MaintenanceWindowInput is used to create or update a maintenance window
+type MaintenanceWindowInput struct {
+	ID          int64  `json:"id,omitempty"`
+	Name        string `json:"name"`
+	DeviceID    *int64 `json:"device_id,omitempty"`
+	DeviceType  string `json:"device_type,omitempty"`
+	StartAt     string `json:"start_at"` // RFC 3339
+	EndAt       string `json:"end_at"`   // RFC 3339
+	Description string `json:"description"`
+}
+
+// GetMaintenanceWindows returns all maintenance windows
+func (a *App) GetMaintenanceWindows() ([]models.MaintenanceWindow, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewMaintenanceRepository(a.db.DB())
+	return repo.GetAll()
+}
+
+// CreateMaintenanceWindow creates a maintenance window
+func (a *App) CreateMaintenanceWindow(input MaintenanceWindowInput) (_ *models.MaintenanceWindow, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("maintenance.create", models.AuditTarget{Type: "maintenance_window", Name: input.Name}, nil)
+	defer func() { rec.finish(err) }()
+
+	window, err := a.maintenanceWindowFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := database.NewMaintenanceRepository(a.db.DB())
+	if err := repo.Create(window); err != nil {
+		return nil, err
+	}
+	rec.track(window.ID, window.Name, a.rowAuditState("maintenance_windows", "id", window.ID))
+	return window, nil
+}
+
+// UpdateMaintenanceWindow updates a maintenance window
+func (a *App) UpdateMaintenanceWindow(input MaintenanceWindowInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("maintenance.update", auditTarget("maintenance_window", input.ID, input.Name), a.rowAuditState("maintenance_windows", "id", input.ID))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewMaintenanceRepository(a.db.DB())
+	existing, err := repo.GetByID(input.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("maintenance window not found")
+	}
+
+	window, err := a.maintenanceWindowFromInput(input)
+	if err != nil {
+		return err
+	}
+	window.ID = existing.ID
+	return repo.Update(window)
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window
+func (a *App) DeleteMaintenanceWindow(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("maintenance.delete", auditTarget("maintenance_window", id, ""), a.rowAuditState("maintenance_windows", "id", id))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewMaintenanceRepository(a.db.DB())
+	return repo.Delete(id)
+}
+
+func (a *App) maintenanceWindowFromInput(input MaintenanceWindowInput) (*models.MaintenanceWindow, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	start, err := time.Parse(time.RFC3339, input.StartAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, input.EndAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end time: %w", err)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+
+	window := &models.MaintenanceWindow{
+		Name:        name,
+		StartAt:     start.Local(),
+		EndAt:       end.Local(),
+		Description: input.Description,
+	}
+
+	if input.DeviceID != nil {
+		device, err := database.NewDeviceRepository(a.db.DB()).GetByID(*input.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		if device == nil {
+			return nil, fmt.Errorf("device not found")
+		}
+		window.DeviceID = input.DeviceID
+	} else if input.DeviceType != "" {
+		deviceType := models.DeviceType(input.DeviceType)
+		if !deviceType.Valid() {
+			return nil, fmt.Errorf("invalid device type: %s", input.DeviceType)
+		}
+		window.DeviceType = deviceType
+	}
+
+	return window, nil
+}
+
+// GetDeviceAvailability returns time-weighted availability of a device for the last days
+func (a *App) GetDeviceAvailability(deviceID int64, days int) (*models.SLADeviceReport, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if days <= 0 {
+		days = 30
+	}
+
+	device, err := database.NewDeviceRepository(a.db.DB()).GetByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -days)
+	windows, err := database.NewMaintenanceRepository(a.db.DB()).GetOverlapping(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := a.appSettings()
+	return a.deviceSLA(database.NewStatusPeriodRepository(a.db.DB()), *device, start, end, windows, settings.SLATarget)
+}
+
+// GetSLAReport builds the SLA report for a calendar month
+func (a *App) GetSLAReport(year, month int) (*models.SLAReport, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	return a.getSLAReport(year, month)
+}
+
+// getSLAReport is GetSLAReport without the permission check, for background tasks
+func (a *App) getSLAReport(year, month int) (*models.SLAReport, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("invalid month: %d", month)
+	}
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+	if start.After(time.Now()) {
+		return nil, fmt.Errorf("period is in the future")
+	}
+	if end.After(time.Now()) {
+		end = time.Now()
+	}
+
+	return a.buildSLAReport(start, end, start.Format("2006-01"))
+}
+
+// ExportSLAReport asks for a file and writes the SLA report for a month
+func (a *App) ExportSLAReport(year, month int, format string) (string, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return "", err
+	}
+
+	if err := validateReportFormat(format); err != nil {
+		return "", err
+	}
+
+	report, err := a.GetSLAReport(year, month)
+	if err != nil {
+		return "", err
+	}
+
+	savePath, err := a.askReportPath("Экспорт отчёта SLA", "netvision_sla_"+report.Period, format)
+	if err != nil || savePath == "" {
+		return "", err
+	}
+
+	if err := writeReport(savePath, format, slaReportTables(report)); err != nil {
+		return "", err
+	}
+
+	log.Printf("SLA report for %s exported to %s", report.Period, savePath)
+	return savePath, nil
+}
+
+// DeliverSLAReport generates the SLA report for a month and delivers it as configured
+// in settings: saved to the export directory or sent by e-mail. Returns the file path.
+func (a *App) DeliverSLAReport(year, month int) (string, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return "", err
+	}
+	return a.deliverSLAReport(year, month)
+}
+
+// deliverSLAReport is DeliverSLAReport without the permission check, for background tasks
+func (a *App) deliverSLAReport(year, month int) (string, error) {
+	settings := a.appSettings()
+
+	format := settings.SLAReportFormat
+	if validateReportFormat(format) != nil {
+		format = ReportFormatPDF
+	}
+
+	report, err := a.getSLAReport(year, month)
+	if err != nil {
+		return "", err
+	}
+
+	if a.cfg == nil {
+		return "", fmt.Errorf("configuration not initialized")
+	}
+	if err := os.MkdirAll(a.cfg.ExportDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+	fileName := fmt.Sprintf("netvision_sla_%s.%s", report.Period, format)
+	path := filepath.Join(a.cfg.ExportDir, fileName)
+	if err := writeReport(path, format, slaReportTables(report)); err != nil {
+		return "", err
+	}
+
+	if settings.SLAReportDelivery == SLADeliveryEmail {
+		recipients := mailer.ParseAddresses(settings.SLAReportRecipients)
+		if len(recipients) == 0 {
+			return path, fmt.Errorf("no SLA report recipients configured")
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return path, fmt.Errorf("failed to read report: %w", err)
+		}
+
+		body := fmt.Sprintf("Отчёт о доступности оборудования за %s.\n\nОбщая доступность: %.3f%% (цель %.2f%%)\nАварий: %d, MTTR: %s\n",
+			report.Period, report.Overall.AvailabilityPercent, report.Target,
+			report.Overall.OutageCount, formatSLADuration(report.Overall.MTTR))
+
+		err = a.sendEmail(settings, mailer.Message{
+			To:          recipients,
+			Subject:     "NetVisionMonitor: отчёт SLA за " + report.Period,
+			Body:        body,
+			Attachments: []mailer.Attachment{{FileName: fileName, Data: data}},
+		})
+		if err != nil {
+			return path, err
+		}
+		log.Printf("SLA report for %s sent to %s", report.Period, strings.Join(recipients, ", "))
+	} else {
+		log.Printf("SLA report for %s saved to %s", report.Period, path)
+	}
+
+	return path, nil
+}
+
+// SetSMTPPassword stores the SMTP password encrypted
+func (a *App) SetSMTPPassword(password string) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+
+	rec := a.beginAudit("settings.smtp_password", models.AuditTarget{Type: "settings"}, nil).detail("password_set", password != "")
+	defer func() { rec.finish(err) }()
+
+	encrypted, err := encryption.EncryptIfNotEmpty(password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt password: %w", err)
+	}
+	return database.NewSettingsRepository(a.db.DB()).Set(settingsKeySMTPPassword, encrypted)
+}
+
+// SendTestEmail sends a test message using the saved e-mail settings
+func (a *App) SendTestEmail(to string) error {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+
+	settings := a.appSettings()
+
+	recipients := mailer.ParseAddresses(to)
+	if len(recipients) == 0 {
+		recipients = mailer.ParseAddresses(settings.SLAReportRecipients)
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+
+	return a.sendEmail(settings, mailer.Message{
+		To:      recipients,
+		Subject: "NetVisionMonitor: проверка почты",
+		Body:    "Это тестовое сообщение NetVisionMonitor.",
+	})
+}
+
+func (a *App) sendEmail(settings AppSettings, msg mailer.Message) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	encrypted, err := database.NewSettingsRepository(a.db.DB()).Get(settingsKeySMTPPassword)
+	if err != nil {
+		return err
+	}
+	password, err := encryption.DecryptIfNotEmpty(encrypted)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt SMTP password: %w", err)
+	}
+
+	return mailer.Send(mailer.Config{
+		Host:     settings.SMTPHost,
+		Port:     settings.SMTPPort,
+		Username: settings.SMTPUsername,
+		Password: password,
+		From:     settings.SMTPFrom,
+	}, msg)
+}
+
+// buildSLAReport calculates availability of all devices for [start, end)
+func (a *App) buildSLAReport(start, end time.Time, period string) (*models.SLAReport, error) {
+	db := a.db.DB()
+	settings := a.appSettings()
+
+	devices, err := database.NewDeviceRepository(db).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+	windows, err := database.NewMaintenanceRepository(db).GetOverlapping(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SLAReport{
+		Period:      period,
+		Start:       start,
+		End:         end,
+		Target:      settings.SLATarget,
+		GeneratedAt: time.Now(),
+	}
+
+	periodRepo := database.NewStatusPeriodRepository(db)
+	byDevice := make(map[int64]models.SLAAvailability, len(devices))
+	for _, d := range devices {
+		devReport, err := a.deviceSLA(periodRepo, d, start, end, windows, settings.SLATarget)
+		if err != nil {
+			return nil, err
+		}
+		report.Devices = append(report.Devices, *devReport)
+		byDevice[d.ID] = devReport.SLAAvailability
+	}
+	sort.SliceStable(report.Devices, func(i, j int) bool {
+		return report.Devices[i].AvailabilityPercent < report.Devices[j].AvailabilityPercent
+	})
+
+	all := make([]int64, 0, len(devices))
+	types := make(map[models.DeviceType][]int64)
+	for _, d := range devices {
+		all = append(all, d.ID)
+		types[d.Type] = append(types[d.Type], d.ID)
+	}
+
+	report.Overall = slaGroup("all", "Все устройства", all, byDevice, settings.SLATarget)
+	for _, t := range models.DeviceTypes {
+		if ids := types[t]; len(ids) > 0 {
+			report.ByType = append(report.ByType, slaGroup(string(t), deviceTypeLabel(string(t)), ids, byDevice, settings.SLATarget))
+		}
+	}
+
+	groups, err := a.slaDeviceGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		report.ByGroup = append(report.ByGroup, slaGroup(g.key, g.name, g.deviceIDs, byDevice, settings.SLATarget))
+	}
+
+	return report, nil
+}
+
+// deviceSLA calculates availability of a single device
+func (a *App) deviceSLA(repo *database.StatusPeriodRepository, device models.Device, start, end time.Time, windows []models.MaintenanceWindow, target float64) (*models.SLADeviceReport, error) {
+	periods, err := repo.GetPeriods(device.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var maintenance []sla.Interval
+	for _, w := range windows {
+		if w.AppliesTo(device.ID, device.Type) {
+			maintenance = append(maintenance, sla.Interval{Start: w.StartAt, End: w.EndAt})
+		}
+	}
+
+	availability, outages := sla.Compute(periods, start, end, maintenance)
+	return &models.SLADeviceReport{
+		SLAAvailability: availability,
+		DeviceID:        device.ID,
+		DeviceName:      device.Name,
+		DeviceType:      device.Type,
+		IPAddress:       device.IPAddress,
+		MeetsSLA:        availability.Monitored == 0 || availability.AvailabilityPercent >= target,
+		Outages:         outages,
+	}, nil
+}
+
+// slaDeviceGroup is a named set of devices reported together
+type slaDeviceGroup struct {
+	key       string
+	name      string
+	deviceIDs []int64
+}
+
+// slaDeviceGroups returns device groups for SLA reports: every device group with
+// its subgroups, then every site
+func (a *App) slaDeviceGroups() ([]slaDeviceGroup, error) {
+	db := a.db.DB()
+	groups, err := a.loadGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	sites, err := database.NewSiteRepository(db).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sites: %w", err)
+	}
+	devices, err := database.NewDeviceRepository(db).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+
+	byGroup := make(map[int64][]int64)
+	bySite := make(map[int64][]int64)
+	for _, d := range devices {
+		for _, g := range groupAncestors(groups, d.GroupID) {
+			byGroup[g.ID] = append(byGroup[g.ID], d.ID)
+		}
+		if d.SiteID != nil {
+			bySite[*d.SiteID] = append(bySite[*d.SiteID], d.ID)
+		}
+	}
+
+	var result []slaDeviceGroup
+	for id, g := range groups {
+		if len(byGroup[id]) == 0 {
+			continue
+		}
+		result = append(result, slaDeviceGroup{key: fmt.Sprintf("group:%d", id), name: "Группа: " + g.Name, deviceIDs: byGroup[id]})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+
+	for _, s := range sites {
+		if len(bySite[s.ID]) == 0 {
+			continue
+		}
+		result = append(result, slaDeviceGroup{key: fmt.Sprintf("site:%d", s.ID), name: "Объект: " + s.Name, deviceIDs: bySite[s.ID]})
+	}
+	return result, nil
+}
+
+func slaGroup(key, name string, deviceIDs []int64, byDevice map[int64]models.SLAAvailability, target float64) models.SLAGroupReport {
+	items := make([]models.SLAAvailability, 0, len(deviceIDs))
+	for _, id := range deviceIDs {
+		if a, ok := byDevice[id]; ok {
+			items = append(items, a)
+		}
+	}
+
+	combined := sla.Combine(items)
+	return models.SLAGroupReport{
+		SLAAvailability: combined,
+		Key:             key,
+		Name:            name,
+		DeviceCount:     len(items),
+		MeetsSLA:        combined.Monitored == 0 || combined.AvailabilityPercent >= target,
+	}
+}
+
+// slaReportTables converts an SLA report into summary, device and outage tables
+func slaReportTables(report *models.SLAReport) []reportTable {
+	subtitle := fmt.Sprintf("Период %s – %s, цель SLA %.2f%%. Сформирован %s",
+		report.Start.Format("02.01.2006"), report.End.Format("02.01.2006 15:04"), report.Target,
+		report.GeneratedAt.Format("02.01.2006 15:04"))
+
+	yesNo := func(ok bool) string {
+		if ok {
+			return "Да"
+		}
+		return "Нет"
+	}
+
+	summary := reportTable{
+		Title:    "Отчёт SLA за " + report.Period,
+		Subtitle: subtitle,
+		Sheet:    "Сводка",
+		Columns:  []string{"Группа", "Устройств", "Доступность, %", "Простой", "Обслуживание", "Нет данных", "Аварий", "MTTR", "MTBF", "SLA выполнен"},
+		Widths:   []float64{3, 1, 1.3, 1.2, 1.3, 1.2, 0.9, 1.1, 1.1, 1.1},
+	}
+	groups := append([]models.SLAGroupReport{report.Overall}, report.ByType...)
+	groups = append(groups, report.ByGroup...)
+	for _, g := range groups {
+		summary.Rows = append(summary.Rows, []interface{}{
+			g.Name, g.DeviceCount, slaPercent(g.SLAAvailability), formatSLADuration(g.Downtime),
+			formatSLADuration(g.Maintenance), formatSLADuration(g.NoData), g.OutageCount,
+			formatSLADuration(g.MTTR), formatSLADuration(g.MTBF), yesNo(g.MeetsSLA),
+		})
+	}
+
+	devices := reportTable{
+		Title:    "Доступность устройств",
+		Subtitle: subtitle,
+		Sheet:    "Устройства",
+		Columns:  []string{"Название", "Тип", "IP адрес", "Доступность, %", "Простой", "Обслуживание", "Нет данных", "Аварий", "MTTR", "MTBF", "SLA"},
+		Widths:   []float64{3, 1.2, 1.4, 1.3, 1.1, 1.3, 1.1, 0.9, 1, 1, 0.7},
+	}
+	outages := reportTable{
+		Title:    "Аварии",
+		Subtitle: subtitle,
+		Sheet:    "Аварии",
+		Columns:  []string{"Устройство", "IP адрес", "Начало", "Окончание", "Длительность"},
+		Widths:   []float64{3, 1.5, 1.6, 1.6, 1.3},
+	}
+	for _, d := range report.Devices {
+		devices.Rows = append(devices.Rows, []interface{}{
+			d.DeviceName, deviceTypeLabel(string(d.DeviceType)), d.IPAddress, slaPercent(d.SLAAvailability),
+			formatSLADuration(d.Downtime), formatSLADuration(d.Maintenance), formatSLADuration(d.NoData),
+			d.OutageCount, formatSLADuration(d.MTTR), formatSLADuration(d.MTBF), yesNo(d.MeetsSLA),
+		})
+		for _, o := range d.Outages {
+			outages.Rows = append(outages.Rows, []interface{}{
+				d.DeviceName, d.IPAddress, o.Start.Format("02.01.2006 15:04:05"), o.End.Format("02.01.2006 15:04:05"),
+				formatSLADuration(o.Duration),
+			})
+		}
+	}
+	sort.SliceStable(outages.Rows, func(i, j int) bool {
+		return outages.Rows[i][2].(string) < outages.Rows[j][2].(string)
+	})
+
+	return []reportTable{summary, devices, outages}
+}
+
+// slaPercent returns availability rounded to three decimals, or nil if there is no data
+func slaPercent(a models.SLAAvailability) interface{} {
+	if a.Monitored == 0 {
+		return nil
+	}
+	return math.Floor(a.AvailabilityPercent*1000) / 1000
+}
+
+// formatSLADuration formats seconds as "1д 2ч 3м"
+func formatSLADuration(seconds int64) string {
+	if seconds <= 0 {
+		return "—"
+	}
+	d := seconds / 86400
+	h := seconds % 86400 / 3600
+	m := seconds % 3600 / 60
+	s := seconds % 60
+
+	var parts []string
+	if d > 0 {
+		parts = append(parts, fmt.Sprintf("%dд", d))
+	}
+	if h > 0 {
+		parts = append(parts, fmt.Sprintf("%dч", h))
+	}
+	if m > 0 && d == 0 {
+		parts = append(parts, fmt.Sprintf("%dм", m))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dс", s))
+	}
+	return strings.Join(parts, " ")
+}
+
+// backfillStatusPeriods builds status periods from history recorded before periods were tracked
+func (a *App) backfillStatusPeriods() {
+	if a.db == nil || a.monitor == nil {
+		return
+	}
+
+	var created int
+	err := a.db.WithTx(func(tx *sql.Tx) error {
+		var err error
+		created, err = database.NewStatusPeriodRepository(tx).Backfill(a.monitor.PeriodGap())
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to build status periods from history: %v", err)
+		return
+	}
+	if created > 0 {
+		log.Printf("Built %d status periods from history", created)
+	}
+}
+
+// startSLAScheduler generates the previous month's SLA report on the configured day
+func (a *App) startSLAScheduler() {
+	a.slaStop = make(chan struct{})
+	stop := a.slaStop
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				a.runScheduledSLAReport()
+			}
+		}
+	}()
+}
+
+// runScheduledSLAReport delivers the previous month's report once it is due
+func (a *App) runScheduledSLAReport() {
+	settings := a.appSettings()
+	if !settings.SLAReportEnabled || a.db == nil {
+		return
+	}
+
+	now := time.Now()
+	day := settings.SLAReportDay
+	if day < 1 || day > 28 {
+		day = 1
+	}
+	if now.Day() < day {
+		return
+	}
+
+	previous := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -1, 0)
+	period := previous.Format("2006-01")
+
+	repo := database.NewSettingsRepository(a.db.DB())
+	if last, _ := repo.Get(settingsKeyLastSLAReport); last == period {
+		return
+	}
+
+	path, err := a.deliverSLAReport(previous.Year(), int(previous.Month()))
+	if err != nil {
+		log.Printf("Scheduled SLA report for %s failed: %v", period, err)
+		if path == "" {
+			return // Retry on the next tick
+		}
+	}
+	if err := repo.Set(settingsKeyLastSLAReport, period); err != nil {
+		log.Printf("Failed to save SLA report state: %v", err)
+	}
+}
+
+// stopSLAScheduler stops the SLA report scheduler
+func (a *App) stopSLAScheduler() {
+	if a.slaStop != nil {
+		close(a.slaStop)
+		a.slaStop = nil
+	}
+}
@@ -58,6 +58,9 @@ func createSNMPWriteClient(ipAddress string, sw *models.Switch) *snmp.TFortisCli
 
 // GetSwitchSNMPData retrieves all SNMP data for a switch
 func (a *App) GetSwitchSNMPData(deviceID int64) (*models.SwitchSNMPData, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -167,6 +170,9 @@ func (a *App) GetSwitchSNMPData(deviceID int64) (*models.SwitchSNMPData, error)
 
 // GetSwitchPortSNMP retrieves SNMP data for a specific port
 func (a *App) GetSwitchPortSNMP(deviceID int64, portNumber int) (*models.SNMPPortInfo, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -202,6 +208,9 @@ func (a *App) GetSwitchPortSNMP(deviceID int64, portNumber int) (*models.SNMPPor
 
 // GetSwitchPoESNMP retrieves PoE status for all ports
 func (a *App) GetSwitchPoESNMP(deviceID int64) ([]models.SNMPPoEInfo, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -240,13 +249,20 @@ func (a *App) GetSwitchPoESNMP(deviceID int64) ([]models.SNMPPoEInfo, error) {
 }
 
 // SetPoEEnabled enables or disables PoE on a port
-func (a *App) SetPoEEnabled(deviceID int64, portNumber int, enabled bool) error {
+func (a *App) SetPoEEnabled(deviceID int64, portNumber int, enabled bool) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+
 	log.Printf("SetPoEEnabled called: device=%d, port=%d, enabled=%v", deviceID, portNumber, enabled)
 
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("switch.poe", a.deviceAuditTarget(deviceID), nil).detail("port", portNumber).detail("enabled", enabled)
+	defer func() { rec.finish(err) }()
+
 	deviceRepo := database.NewDeviceRepository(a.db.DB())
 	device, err := deviceRepo.GetByID(deviceID)
 	if err != nil {
@@ -269,22 +285,32 @@ func (a *App) SetPoEEnabled(deviceID int64, portNumber int, enabled bool) error
 	}
 
 	action := "disabled"
+	poe := "off"
 	if enabled {
 		action = "enabled"
+		poe = "on"
 	}
 	log.Printf("PoE %s on device %d port %d - SUCCESS", action, deviceID, portNumber)
+	a.recordPortAction(deviceID, portNumber, models.PortFieldPoE, poe)
 
 	return nil
 }
 
 // RestartPoEPort restarts PoE on a port (turns off, waits, turns on)
-func (a *App) RestartPoEPort(deviceID int64, portNumber int) error {
+func (a *App) RestartPoEPort(deviceID int64, portNumber int) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+
 	log.Printf("RestartPoEPort called: device=%d, port=%d", deviceID, portNumber)
 
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("switch.poe_restart", a.deviceAuditTarget(deviceID), nil).detail("port", portNumber)
+	defer func() { rec.finish(err) }()
+
 	deviceRepo := database.NewDeviceRepository(a.db.DB())
 	device, err := deviceRepo.GetByID(deviceID)
 	if err != nil {
@@ -312,6 +338,7 @@ func (a *App) RestartPoEPort(deviceID int64, portNumber int) error {
 	if err != nil {
 		return fmt.Errorf("failed to disable PoE: %w", err)
 	}
+	a.recordPortAction(deviceID, portNumber, models.PortFieldPoE, "off")
 
 	log.Printf("PoE disabled on device %d port %d, waiting 3 seconds...", deviceID, portNumber)
 
@@ -325,18 +352,26 @@ func (a *App) RestartPoEPort(deviceID int64, portNumber int) error {
 	}
 
 	log.Printf("PoE enabled on device %d port %d", deviceID, portNumber)
+	a.recordPortAction(deviceID, portNumber, models.PortFieldPoE, "on")
 
 	return nil
 }
 
 // SetPortEnabled enables or disables a port (via ifAdminStatus)
-func (a *App) SetPortEnabled(deviceID int64, portNumber int, enabled bool) error {
+func (a *App) SetPortEnabled(deviceID int64, portNumber int, enabled bool) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
+
 	log.Printf("SetPortEnabled called: device=%d, port=%d, enabled=%v", deviceID, portNumber, enabled)
 
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("switch.port", a.deviceAuditTarget(deviceID), nil).detail("port", portNumber).detail("enabled", enabled)
+	defer func() { rec.finish(err) }()
+
 	deviceRepo := database.NewDeviceRepository(a.db.DB())
 	device, err := deviceRepo.GetByID(deviceID)
 	if err != nil {
@@ -359,20 +394,29 @@ func (a *App) SetPortEnabled(deviceID int64, portNumber int, enabled bool) error
 	}
 
 	action := "disabled"
+	admin := "down"
 	if enabled {
 		action = "enabled"
+		admin = "up"
 	}
 	log.Printf("Port %s on device %d port %d - SUCCESS", action, deviceID, portNumber)
+	a.recordPortAction(deviceID, portNumber, models.PortFieldAdmin, admin)
 
 	return nil
 }
 
 // RestartPort restarts a port (turns off, waits, turns on)
-func (a *App) RestartPort(deviceID int64, portNumber int) error {
+func (a *App) RestartPort(deviceID int64, portNumber int) (err error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("switch.port_restart", a.deviceAuditTarget(deviceID), nil).detail("port", portNumber)
+	defer func() { rec.finish(err) }()
+
 	deviceRepo := database.NewDeviceRepository(a.db.DB())
 	device, err := deviceRepo.GetByID(deviceID)
 	if err != nil {
@@ -392,6 +436,7 @@ func (a *App) RestartPort(deviceID int64, portNumber int) error {
 	if err != nil {
 		return fmt.Errorf("failed to disable port: %w", err)
 	}
+	a.recordPortAction(deviceID, portNumber, models.PortFieldAdmin, "down")
 
 	log.Printf("Port disabled on device %d port %d, waiting 3 seconds...", deviceID, portNumber)
 
@@ -405,12 +450,16 @@ func (a *App) RestartPort(deviceID int64, portNumber int) error {
 	}
 
 	log.Printf("Port enabled on device %d port %d", deviceID, portNumber)
+	a.recordPortAction(deviceID, portNumber, models.PortFieldAdmin, "up")
 
 	return nil
 }
 
 // TestSNMPConnection tests SNMP connection to a switch
 func (a *App) TestSNMPConnection(deviceID int64) (bool, error) {
+	if err := a.authorize(models.RoleOperator); err != nil {
+		return false, err
+	}
 	if a.db == nil {
 		return false, fmt.Errorf("database not initialized")
 	}
@@ -449,6 +498,9 @@ func (a *App) TestSNMPConnection(deviceID int64) (bool, error) {
 
 // GetSwitchAutoRestartSettings retrieves AutoRestart settings for all ports
 func (a *App) GetSwitchAutoRestartSettings(deviceID int64) ([]models.SNMPAutoRestartInfo, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
 	if a.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -487,11 +539,17 @@ func (a *App) GetSwitchAutoRestartSettings(deviceID int64) ([]models.SNMPAutoRes
 }
 
 // SetAutoRestartMode sets AutoRestart mode for a port
-func (a *App) SetAutoRestartMode(deviceID int64, portNumber int, mode int) error {
+func (a *App) SetAutoRestartMode(deviceID int64, portNumber int, mode int) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	rec := a.beginAudit("switch.autorestart", a.deviceAuditTarget(deviceID), nil).detail("port", portNumber).detail("mode", mode)
+	defer func() { rec.finish(err) }()
+
 	deviceRepo := database.NewDeviceRepository(a.db.DB())
 	device, err := deviceRepo.GetByID(deviceID)
 	if err != nil {
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+)
+
+// Hysteresis of environment alarms, an alarm clears this far below its limit so
+// a reading hovering around the limit does not flood the event log
+const (
+	temperatureHysteresis = 3.0 // °C
+	percentHysteresis     = 5.0 // Percentage points
+)
+
+// onSwitchEnvironment stores the environment of a switch and emits events when a
+// reading crosses its limit, a fan or a power supply fails or they recover
+func (a *App) onSwitchEnvironment(device models.Device, env *models.SwitchEnvironment) {
+	if err := database.NewSwitchEnvironmentRepository(a.db.DB()).Record(env.Sample()); err != nil {
+		log.Printf("Failed to record environment of switch %d: %v", device.ID, err)
+	}
+	a.recordPoEPower(device, env)
+
+	settings := a.appSettings()
+
+	a.envMu.Lock()
+	if a.envAlarms == nil {
+		a.envAlarms = make(map[int64]map[string]bool)
+	}
+	alarms := a.envAlarms[device.ID]
+	if alarms == nil {
+		alarms = make(map[string]bool)
+		a.envAlarms[device.ID] = alarms
+	}
+	events := environmentEvents(device, env, settings, alarms)
+	a.envMu.Unlock()
+
+	for _, event := range events {
+		a.onMonitoringEvent(event)
+	}
+}
+
+// environmentEvents compares readings with their limits and returns events for
+// alarms raised or cleared. alarms holds the raised alarms and is updated.
+func environmentEvents(device models.Device, env *models.SwitchEnvironment, settings AppSettings, alarms map[string]bool) []*models.Event {
+	var events []*models.Event
+	add := func(eventType models.EventType, level models.EventLevel, format string, args ...interface{}) {
+		deviceID := device.ID
+		events = append(events, &models.Event{
+			DeviceID: &deviceID,
+			Type:     eventType,
+			Level:    level,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// threshold raises an alarm at the limit and clears it below the hysteresis
+	threshold := func(key string, value *float64, limit int, hysteresis float64) (raised, cleared bool) {
+		if value == nil || limit <= 0 {
+			if limit <= 0 {
+				delete(alarms, key)
+			}
+			return false, false
+		}
+		switch {
+		case !alarms[key] && *value >= float64(limit):
+			alarms[key] = true
+			return true, false
+		case alarms[key] && *value < float64(limit)-hysteresis:
+			delete(alarms, key)
+			return false, true
+		}
+		return false, false
+	}
+
+	if raised, cleared := threshold("temperature", env.Temperature, settings.SwitchTemperatureLimit, temperatureHysteresis); raised {
+		add(models.EventTypeTemperatureHigh, models.EventLevelWarn, "Temperature %.0f °C on switch %s exceeds %d °C",
+			*env.Temperature, device.Name, settings.SwitchTemperatureLimit)
+	} else if cleared {
+		add(models.EventTypeTemperatureOK, models.EventLevelInfo, "Temperature on switch %s is back to %.0f °C",
+			device.Name, *env.Temperature)
+	}
+
+	percent := env.PoEUsedPercent()
+	if raised, cleared := threshold("poe", percent, settings.PoEBudgetLimit, percentHysteresis); raised {
+		add(models.EventTypePoEBudgetHigh, models.EventLevelWarn, "PoE budget %.0f%% used on switch %s (%.1f of %.0f W)",
+			*percent, device.Name, *env.PoEUsedW, *env.PoEBudgetW)
+	} else if cleared {
+		add(models.EventTypePoEBudgetOK, models.EventLevelInfo, "PoE budget %.0f%% used on switch %s",
+			*percent, device.Name)
+	}
+
+	if raised, cleared := threshold("cpu", intPercent(env.CPUPercent), settings.SwitchCPULimit, percentHysteresis); raised {
+		add(models.EventTypeCPUHigh, models.EventLevelWarn, "CPU load %d%% on switch %s", *env.CPUPercent, device.Name)
+	} else if cleared {
+		add(models.EventTypeCPUOK, models.EventLevelInfo, "CPU load on switch %s is back to %d%%", device.Name, *env.CPUPercent)
+	}
+
+	if raised, cleared := threshold("memory", intPercent(env.MemoryPercent), settings.SwitchMemoryLimit, percentHysteresis); raised {
+		add(models.EventTypeMemoryHigh, models.EventLevelWarn, "Memory %d%% used on switch %s", *env.MemoryPercent, device.Name)
+	} else if cleared {
+		add(models.EventTypeMemoryOK, models.EventLevelInfo, "Memory use on switch %s is back to %d%%", device.Name, *env.MemoryPercent)
+	}
+
+	// Components are tracked by name, a component that disappears keeps its alarm
+	components := func(kind string, list []models.ComponentStatus, failed, ok models.EventType) {
+		for _, c := range list {
+			key := kind + ":" + c.Name
+			switch {
+			case c.Failed() && !alarms[key]:
+				alarms[key] = true
+				if c.Status == "warning" {
+					add(failed, models.EventLevelWarn, "%s on switch %s reports a warning", c.Name, device.Name)
+				} else {
+					add(failed, models.EventLevelError, "%s on switch %s has failed", c.Name, device.Name)
+				}
+			case !c.Failed() && c.Status != "unknown" && alarms[key]:
+				delete(alarms, key)
+				add(ok, models.EventLevelInfo, "%s on switch %s is working again", c.Name, device.Name)
+			}
+		}
+	}
+	components("fan", env.Fans, models.EventTypeFanFailed, models.EventTypeFanOK)
+	components("psu", env.PSUs, models.EventTypePSUFailed, models.EventTypePSUOK)
+
+	return events
+}
+
+func intPercent(value *int) *float64 {
+	if value == nil {
+		return nil
+	}
+	v := float64(*value)
+	return &v
+}
+
+// GetSwitchEnvironment reads the current environment of a switch
+func (a *App) GetSwitchEnvironment(deviceID int64) (*models.SwitchEnvironment, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil || a.monitor == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	device, err := database.NewDeviceRepository(a.db.DB()).GetByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, 30*time.Second)
+	defer cancel()
+
+	env, err := a.monitor.ReadSwitchEnvironment(ctx, *device)
+	if err != nil {
+		log.Printf("GetSwitchEnvironment(%d): %v", deviceID, err)
+		return &models.SwitchEnvironment{
+			DeviceID:  deviceID,
+			Sensors:   []models.SensorReading{},
+			Fans:      []models.ComponentStatus{},
+			PSUs:      []models.ComponentStatus{},
+			CheckedAt: time.Now(),
+			Error:     err.Error(),
+		}, nil
+	}
+	return env, nil
+}
+
+// GetSwitchEnvironmentHistory returns the stored environment readings of a
+// switch for the last hours
+func (a *App) GetSwitchEnvironmentHistory(deviceID int64, hours int) ([]models.EnvironmentSample, error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if hours <= 0 {
+		hours = 24
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	return database.NewSwitchEnvironmentRepository(a.db.DB()).GetSince(deviceID, since)
+}
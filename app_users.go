@@ -0,0 +1,494 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/auth"
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// sessionCheckInterval is how often an idle session is checked for its timeout
+const sessionCheckInterval = 15 * time.Second
+
+// UserInput is used for creating/updating users from frontend
+type UserInput struct {
+	ID          int64  `json:"id,omitempty"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Role        string `json:"role"`
+	Password    string `json:"password,omitempty"` // Required for new users, ignored on update
+	Disabled    bool   `json:"disabled"`
+}
+
+// authorize checks that the logged in user may perform an action requiring role.
+// Every bound method that reads or changes monitored data calls it first; actions
+// above viewer level also count as user activity for the session timeout.
+func (a *App) authorize(role models.Role) error {
+	now := time.Now()
+	if _, err := a.session.Require(role, now); err != nil {
+		return err
+	}
+	if role != models.RoleViewer {
+		a.session.Touch(now)
+	}
+	return nil
+}
+
+// GetSession returns the state of the current session. Available without login.
+func (a *App) GetSession() models.SessionInfo {
+	info := a.session.Info(time.Now())
+	info.KeyLocked = a.db != nil && !encryption.IsInitialized()
+	if a.db != nil && !info.LoggedIn {
+		if count, err := database.NewUserRepository(a.db.DB()).Count(); err == nil {
+			info.SetupRequired = count == 0
+		}
+	}
+	return info
+}
+
+// SetupAdmin creates the first admin account and logs it in. Only possible while
+// no users exist.
+func (a *App) SetupAdmin(input UserInput) (_ *models.SessionInfo, err error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("user.setup", models.AuditTarget{Type: "user", Name: input.Username}, nil)
+	rec.entry.Actor = strings.TrimSpace(input.Username)
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewUserRepository(a.db.DB())
+	count, err := repo.Count()
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("users already exist")
+	}
+
+	input.Role = string(models.RoleAdmin)
+	input.Disabled = false
+	user, err := userFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := setUserPassword(user, input.Password); err != nil {
+		return nil, err
+	}
+	if err := repo.Create(user); err != nil {
+		return nil, err
+	}
+	rec.track(user.ID, user.Username, a.rowAuditState("users", "id", user.ID))
+
+	log.Printf("Initial admin account created: %s", user.Username)
+	return a.startSession(repo, user), nil
+}
+
+// Login checks a username and password and starts a session for the user
+func (a *App) Login(username, password string) (_ *models.SessionInfo, err error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	username = strings.TrimSpace(username)
+	rec := a.beginAudit("session.login", models.AuditTarget{Type: "user", Name: username}, nil)
+	rec.entry.Actor = username
+	defer func() { rec.finish(err) }()
+
+	now := time.Now()
+	if err := a.session.AllowLogin(now); err != nil {
+		return nil, err
+	}
+
+	repo := database.NewUserRepository(a.db.DB())
+	user, err := repo.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !auth.CheckPassword(user.PasswordHash, password) {
+		a.session.LoginFailed(now)
+		return nil, auth.ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return nil, fmt.Errorf("account is disabled")
+	}
+	rec.track(user.ID, user.Username, nil)
+
+	log.Printf("User %s logged in (%s)", user.Username, user.Role)
+	return a.startSession(repo, user), nil
+}
+
+// startSession logs a verified user in and notifies the frontend
+func (a *App) startSession(repo *database.UserRepository, user *models.User) *models.SessionInfo {
+	now := time.Now()
+	if err := repo.SetLastLogin(user.ID, now); err != nil {
+		log.Printf("Failed to record login of %s: %v", user.Username, err)
+	}
+	user.LastLoginAt = &now
+	a.session.Start(user, now)
+	return a.emitSession()
+}
+
+// Logout ends the current session
+func (a *App) Logout() (err error) {
+	user := a.session.User()
+	if user == nil {
+		return nil
+	}
+
+	rec := a.beginAudit("session.logout", auditTarget("user", user.ID, user.Username), nil)
+	defer func() { rec.finish(err) }()
+
+	a.session.End()
+	a.emitSession()
+	log.Printf("User %s logged out", user.Username)
+	return nil
+}
+
+// LockSession locks the session, e.g. when the operator leaves the PC. The same
+// user unlocks it with their password, another user can log in instead.
+func (a *App) LockSession() error {
+	a.session.Lock()
+	a.emitSession()
+	return nil
+}
+
+// UnlockSession unlocks a locked session with the password of its user
+func (a *App) UnlockSession(password string) (_ *models.SessionInfo, err error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	current := a.session.User()
+	if current == nil {
+		return nil, auth.ErrNotLoggedIn
+	}
+
+	rec := a.beginAudit("session.unlock", auditTarget("user", current.ID, current.Username), nil)
+	defer func() { rec.finish(err) }()
+
+	now := time.Now()
+	if err := a.session.AllowLogin(now); err != nil {
+		return nil, err
+	}
+
+	user, err := database.NewUserRepository(a.db.DB()).GetByID(current.ID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Disabled {
+		a.session.End()
+		a.emitSession()
+		return nil, fmt.Errorf("account is no longer available")
+	}
+	if !auth.CheckPassword(user.PasswordHash, password) {
+		a.session.LoginFailed(now)
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	a.session.Refresh(user)
+	a.session.Unlock(now)
+	return a.emitSession(), nil
+}
+
+// ReportActivity postpones the session lock. Called by the frontend on user input.
+func (a *App) ReportActivity() {
+	a.session.Touch(time.Now())
+}
+
+// ChangePassword changes the password of the logged in user
+func (a *App) ChangePassword(currentPassword, newPassword string) (err error) {
+	if err := a.authorize(models.RoleViewer); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	current := a.session.User()
+	rec := a.beginAudit("user.password", auditTarget("user", current.ID, current.Username), nil)
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewUserRepository(a.db.DB())
+	user, err := repo.GetByID(current.ID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user not found")
+	}
+	if !auth.CheckPassword(user.PasswordHash, currentPassword) {
+		return auth.ErrInvalidCredentials
+	}
+	if err := setUserPassword(user, newPassword); err != nil {
+		return err
+	}
+	return repo.SetPassword(user.ID, user.PasswordHash)
+}
+
+// GetUsers returns all user accounts
+func (a *App) GetUsers() ([]models.User, error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	repo := database.NewUserRepository(a.db.DB())
+	return repo.GetAll()
+}
+
+// CreateUser creates a user account
+func (a *App) CreateUser(input UserInput) (_ *models.User, err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("user.create", models.AuditTarget{Type: "user", Name: input.Username}, nil)
+	defer func() { rec.finish(err) }()
+
+	user, err := userFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := setUserPassword(user, input.Password); err != nil {
+		return nil, err
+	}
+
+	repo := database.NewUserRepository(a.db.DB())
+	existing, err := repo.GetByUsername(user.Username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("user %s already exists", user.Username)
+	}
+
+	if err := repo.Create(user); err != nil {
+		return nil, err
+	}
+	rec.track(user.ID, user.Username, a.rowAuditState("users", "id", user.ID))
+
+	log.Printf("User created: %s (%s)", user.Username, user.Role)
+	return user, nil
+}
+
+// UpdateUser updates the name, role and state of a user account. The last
+// enabled admin cannot be demoted or disabled.
+func (a *App) UpdateUser(input UserInput) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("user.update", auditTarget("user", input.ID, input.Username), a.rowAuditState("users", "id", input.ID))
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewUserRepository(a.db.DB())
+	existing, err := repo.GetByID(input.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("user not found")
+	}
+
+	user, err := userFromInput(input)
+	if err != nil {
+		return err
+	}
+	user.ID = existing.ID
+
+	if other, err := repo.GetByUsername(user.Username); err != nil {
+		return err
+	} else if other != nil && other.ID != user.ID {
+		return fmt.Errorf("user %s already exists", user.Username)
+	}
+
+	wasAdmin := existing.Role == models.RoleAdmin && !existing.Disabled
+	isAdmin := user.Role == models.RoleAdmin && !user.Disabled
+	if wasAdmin && !isAdmin {
+		if err := checkNotLastAdmin(repo); err != nil {
+			return err
+		}
+	}
+
+	if err := repo.Update(user); err != nil {
+		return err
+	}
+
+	a.session.Refresh(user)
+	a.emitSession()
+	return nil
+}
+
+// SetUserPassword sets a new password for a user account
+func (a *App) SetUserPassword(id int64, password string) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("user.password", auditTarget("user", id, ""), nil)
+	defer func() { rec.finish(err) }()
+
+	repo := database.NewUserRepository(a.db.DB())
+	user, err := repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user not found")
+	}
+	rec.entry.Target.Name = user.Username
+
+	if err := setUserPassword(user, password); err != nil {
+		return err
+	}
+	return repo.SetPassword(user.ID, user.PasswordHash)
+}
+
+// DeleteUser deletes a user account. Users cannot delete themselves or the last
+// enabled admin.
+func (a *App) DeleteUser(id int64) (err error) {
+	if err := a.authorize(models.RoleAdmin); err != nil {
+		return err
+	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	rec := a.beginAudit("user.delete", auditTarget("user", id, ""), a.rowAuditState("users", "id", id))
+	defer func() { rec.finish(err) }()
+
+	if current := a.session.User(); current != nil && current.ID == id {
+		return fmt.Errorf("cannot delete the logged in user")
+	}
+
+	repo := database.NewUserRepository(a.db.DB())
+	user, err := repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user not found")
+	}
+	rec.entry.Target.Name = user.Username
+
+	if user.Role == models.RoleAdmin && !user.Disabled {
+		if err := checkNotLastAdmin(repo); err != nil {
+			return err
+		}
+	}
+	return repo.Delete(id)
+}
+
+// checkNotLastAdmin returns an error if only one enabled admin is left
+func checkNotLastAdmin(repo *database.UserRepository) error {
+	admins, err := repo.CountActiveAdmins()
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return fmt.Errorf("at least one enabled admin is required")
+	}
+	return nil
+}
+
+func userFromInput(input UserInput) (*models.User, error) {
+	username := strings.TrimSpace(input.Username)
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+	if strings.ContainsAny(username, " \t") {
+		return nil, fmt.Errorf("username must not contain spaces")
+	}
+	role := models.Role(input.Role)
+	if !role.Valid() {
+		return nil, fmt.Errorf("invalid role: %s", input.Role)
+	}
+	return &models.User{
+		Username:    username,
+		DisplayName: strings.TrimSpace(input.DisplayName),
+		Role:        role,
+		Disabled:    input.Disabled,
+	}, nil
+}
+
+func setUserPassword(user *models.User, password string) error {
+	if err := auth.ValidatePassword(password); err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	return nil
+}
+
+// emitSession notifies the frontend of the session state and returns it
+func (a *App) emitSession() *models.SessionInfo {
+	info := a.GetSession()
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "session:changed", info)
+	}
+	return &info
+}
+
+// applySessionTimeout sets the idle timeout of the session from settings
+func (a *App) applySessionTimeout(settings AppSettings) {
+	a.session.SetTimeout(time.Duration(settings.SessionTimeoutMinutes) * time.Minute)
+}
+
+// startSessionWatcher locks the session once it has been idle for the timeout, so
+// the frontend shows the lock screen even if nobody interacts with it
+func (a *App) startSessionWatcher() {
+	settings := a.appSettings()
+	a.applySessionTimeout(settings)
+
+	a.sessionStop = make(chan struct{})
+	stop := a.sessionStop
+
+	go func() {
+		ticker := time.NewTicker(sessionCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if a.session.Expire(time.Now()) {
+					if user := a.session.User(); user != nil {
+						log.Printf("Session of %s locked after inactivity", user.Username)
+					}
+					a.emitSession()
+				}
+			}
+		}
+	}()
+}
+
+// stopSessionWatcher stops the session watcher
+func (a *App) stopSessionWatcher() {
+	if a.sessionStop != nil {
+		close(a.sessionStop)
+		a.sessionStop = nil
+	}
+}
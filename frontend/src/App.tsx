@@ -8,6 +8,7 @@ import { SettingsPage } from '@/pages/SettingsPage'
 import { AboutPage } from '@/pages/AboutPage'
 import { ThemeProvider } from '@/hooks/useTheme'
 import { ErrorBoundary } from '@/components/ErrorBoundary'
+import { AuthGate } from '@/components/auth/AuthGate'
 import { useNotificationSound } from '@/hooks/useNotificationSound'
 
 const pageTitles: Record<string, string> = {
@@ -46,15 +47,17 @@ function App() {
 
   return (
     <ThemeProvider>
-      <Layout
-        currentPage={currentPage}
-        pageTitle={pageTitles[currentPage] || 'NetVisionMonitor'}
-        onNavigate={setCurrentPage}
-      >
-        <ErrorBoundary key={currentPage}>
-          {renderPage()}
-        </ErrorBoundary>
-      </Layout>
+      <AuthGate>
+        <Layout
+          currentPage={currentPage}
+          pageTitle={pageTitles[currentPage] || 'NetVisionMonitor'}
+          onNavigate={setCurrentPage}
+        >
+          <ErrorBoundary key={currentPage}>
+            {renderPage()}
+          </ErrorBoundary>
+        </Layout>
+      </AuthGate>
     </ThemeProvider>
   )
 }
@@ -0,0 +1,204 @@
+import { useState, useEffect, useRef, FormEvent } from 'react'
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
+import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import { Network, Lock, KeyRound } from "lucide-react"
+import { GetSession, SetupAdmin, Login, UnlockSession, UnlockMasterKey, Logout, ReportActivity } from '../../../wailsjs/go/main/App'
+import { EventsOn } from '../../../wailsjs/runtime/runtime'
+import { useTranslation } from '@/i18n'
+import type { SessionInfo } from '@/types'
+
+// How often user input is reported to postpone the session lock
+const ACTIVITY_REPORT_INTERVAL = 30_000
+
+interface AuthGateProps {
+  children: React.ReactNode
+}
+
+// AuthGate shows the master passphrase prompt, first-run setup, login or lock
+// screen until the session is unlocked, then renders the application
+export function AuthGate({ children }: AuthGateProps) {
+  const [session, setSession] = useState<SessionInfo | null>(null)
+
+  useEffect(() => {
+    GetSession().then((info) => setSession(info as unknown as SessionInfo))
+    return EventsOn('session:changed', (info: SessionInfo) => setSession(info))
+  }, [])
+
+  const active = !!session && !session.key_locked && session.logged_in && !session.locked
+  useActivityReporting(active)
+
+  if (!session) {
+    return null
+  }
+  if (active) {
+    return <>{children}</>
+  }
+  return <AuthScreen session={session} onSession={setSession} />
+}
+
+// Reports user input to the backend at most once per interval while active
+function useActivityReporting(active: boolean) {
+  const lastReport = useRef(0)
+
+  useEffect(() => {
+    if (!active) {
+      return
+    }
+    const onActivity = () => {
+      const now = Date.now()
+      if (now - lastReport.current >= ACTIVITY_REPORT_INTERVAL) {
+        lastReport.current = now
+        ReportActivity()
+      }
+    }
+    const events = ['mousemove', 'mousedown', 'keydown', 'wheel', 'touchstart']
+    events.forEach((e) => window.addEventListener(e, onActivity, { passive: true }))
+    return () => events.forEach((e) => window.removeEventListener(e, onActivity))
+  }, [active])
+}
+
+interface AuthScreenProps {
+  session: SessionInfo
+  onSession: (session: SessionInfo) => void
+}
+
+function AuthScreen({ session, onSession }: AuthScreenProps) {
+  const { t } = useTranslation()
+  const [username, setUsername] = useState('')
+  const [displayName, setDisplayName] = useState('')
+  const [password, setPassword] = useState('')
+  const [confirm, setConfirm] = useState('')
+  const [error, setError] = useState('')
+  const [busy, setBusy] = useState(false)
+
+  const mode = session.key_locked ? 'key'
+    : session.setup_required ? 'setup'
+    : session.locked ? 'locked'
+    : 'login'
+  const lockedName = session.user?.display_name || session.user?.username || ''
+
+  const submit = async (e: FormEvent) => {
+    e.preventDefault()
+    setError('')
+    if (mode === 'setup' && password !== confirm) {
+      setError(t('auth.passwordMismatch'))
+      return
+    }
+
+    setBusy(true)
+    try {
+      let info
+      if (mode === 'key') {
+        info = await UnlockMasterKey(password)
+      } else if (mode === 'setup') {
+        info = await SetupAdmin({ username, display_name: displayName, role: 'admin', password, disabled: false } as never)
+      } else if (mode === 'locked') {
+        info = await UnlockSession(password)
+      } else {
+        info = await Login(username, password)
+      }
+      setPassword('')
+      setConfirm('')
+      onSession(info as unknown as SessionInfo)
+    } catch (err) {
+      setError(String(err))
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  const switchUser = async () => {
+    setError('')
+    setPassword('')
+    await Logout()
+    const info = await GetSession()
+    onSession(info as unknown as SessionInfo)
+  }
+
+  const title = mode === 'key' ? t('auth.keyTitle')
+    : mode === 'setup' ? t('auth.setupTitle')
+    : mode === 'locked' ? t('auth.lockedTitle')
+    : t('auth.loginTitle')
+
+  return (
+    <div className="flex items-center justify-center h-screen bg-background">
+      <Card className="w-full max-w-sm">
+        <CardHeader className="text-center">
+          <div className="flex justify-center mb-2">
+            {mode === 'key'
+              ? <KeyRound className="h-8 w-8 text-primary" />
+              : mode === 'locked'
+                ? <Lock className="h-8 w-8 text-primary" />
+                : <Network className="h-8 w-8 text-primary" />}
+          </div>
+          <CardTitle>{title}</CardTitle>
+          {mode === 'key' && <CardDescription>{t('auth.keyDescription')}</CardDescription>}
+          {mode === 'setup' && <CardDescription>{t('auth.setupDescription')}</CardDescription>}
+          {mode === 'locked' && (
+            <CardDescription>{t('auth.lockedDescription').replace('{{name}}', lockedName)}</CardDescription>
+          )}
+        </CardHeader>
+        <CardContent>
+          <form onSubmit={submit} className="space-y-4">
+            {(mode === 'setup' || mode === 'login') && (
+              <div className="space-y-2">
+                <Label htmlFor="auth-username">{t('auth.username')}</Label>
+                <Input
+                  id="auth-username"
+                  value={username}
+                  onChange={(e) => setUsername(e.target.value)}
+                  autoFocus
+                  autoComplete="username"
+                />
+              </div>
+            )}
+            {mode === 'setup' && (
+              <div className="space-y-2">
+                <Label htmlFor="auth-display-name">{t('auth.displayName')}</Label>
+                <Input
+                  id="auth-display-name"
+                  value={displayName}
+                  onChange={(e) => setDisplayName(e.target.value)}
+                />
+              </div>
+            )}
+            <div className="space-y-2">
+              <Label htmlFor="auth-password">{mode === 'key' ? t('auth.passphrase') : t('auth.password')}</Label>
+              <Input
+                id="auth-password"
+                type="password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+                autoFocus={mode === 'locked' || mode === 'key'}
+                autoComplete={mode === 'setup' ? 'new-password' : 'current-password'}
+              />
+            </div>
+            {mode === 'setup' && (
+              <div className="space-y-2">
+                <Label htmlFor="auth-confirm">{t('auth.confirmPassword')}</Label>
+                <Input
+                  id="auth-confirm"
+                  type="password"
+                  value={confirm}
+                  onChange={(e) => setConfirm(e.target.value)}
+                  autoComplete="new-password"
+                />
+              </div>
+            )}
+            {error && <p className="text-sm text-destructive">{error}</p>}
+            <Button type="submit" className="w-full" disabled={busy}>
+              {mode === 'setup' ? t('auth.create') : mode === 'locked' || mode === 'key' ? t('auth.unlock') : t('auth.login')}
+            </Button>
+            {mode === 'locked' && (
+              <Button type="button" variant="ghost" className="w-full" onClick={switchUser}>
+                {t('auth.switchUser')}
+              </Button>
+            )}
+          </form>
+        </CardContent>
+      </Card>
+    </div>
+  )
+}
@@ -47,6 +47,11 @@ import {
   Link2,
   Play,
   Square,
+  Router,
+  HardDrive,
+  BatteryCharging,
+  PlugZap,
+  Gauge,
 } from 'lucide-react'
 import {
   GetDevice,
@@ -63,6 +68,13 @@ import {
   GetCameraStreamURL,
   FetchCameraSnapshotBase64,
 } from '../../../wailsjs/go/main/App'
+import { DeviceHealthPanel } from './DeviceHealthPanel'
+import { SwitchEnvironmentPanel } from './SwitchEnvironmentPanel'
+import { PoEPowerChart } from './PoEPowerChart'
+import { PortHistoryPanel } from './PortHistoryPanel'
+
+// Device types with type-specific health read over SNMP or HTTP
+const healthDeviceTypes = ['router', 'access_point', 'ups', 'nvr', 'pdu']
 
 interface DeviceStats {
   device_id: number
@@ -92,6 +104,9 @@ interface SwitchPort {
   name: string
   status: string
   speed: string
+  duplex?: string
+  admin_status?: string
+  poe_status?: string
   port_type: string  // "copper" or "sfp"
   linked_camera_id?: number
   linked_switch_id?: number
@@ -148,7 +163,7 @@ export function DeviceDetailsPanel({ deviceId, onBack }: DeviceDetailsPanelProps
   const [isLoading, setIsLoading] = useState(true)
   const [isSNMPLoading, setIsSNMPLoading] = useState(false)
   const [restartingPort, setRestartingPort] = useState<number | null>(null)
-  const [activeTab, setActiveTab] = useState<'overview' | 'ports' | 'events' | 'preview'>('overview')
+  const [activeTab, setActiveTab] = useState<'overview' | 'ports' | 'health' | 'events' | 'preview'>('overview')
   const [previewUrl, setPreviewUrl] = useState<string | null>(null)
   const [previewLoading, setPreviewLoading] = useState(false)
   const [previewError, setPreviewError] = useState<string | null>(null)
@@ -286,6 +301,16 @@ export function DeviceDetailsPanel({ deviceId, onBack }: DeviceDetailsPanelProps
         return <Server className="h-5 w-5" />
       case 'camera':
         return <Camera className="h-5 w-5" />
+      case 'router':
+        return <Router className="h-5 w-5" />
+      case 'access_point':
+        return <Wifi className="h-5 w-5" />
+      case 'ups':
+        return <BatteryCharging className="h-5 w-5" />
+      case 'nvr':
+        return <HardDrive className="h-5 w-5" />
+      case 'pdu':
+        return <PlugZap className="h-5 w-5" />
       default:
         return <Monitor className="h-5 w-5" />
     }
@@ -299,6 +324,18 @@ export function DeviceDetailsPanel({ deviceId, onBack }: DeviceDetailsPanelProps
         return 'Сервер'
       case 'camera':
         return 'Камера'
+      case 'router':
+        return 'Маршрутизатор'
+      case 'access_point':
+        return 'Точка доступа'
+      case 'ups':
+        return 'ИБП'
+      case 'nvr':
+        return 'Видеорегистратор'
+      case 'pdu':
+        return 'PDU'
+      case 'host':
+        return 'Хост'
       default:
         return 'Устройство'
     }
@@ -421,6 +458,15 @@ export function DeviceDetailsPanel({ deviceId, onBack }: DeviceDetailsPanelProps
             Порты ({ports.length})
           </Button>
         )}
+        {(healthDeviceTypes.includes(device.type) || device.type === 'switch') && (
+          <Button
+            variant={activeTab === 'health' ? 'default' : 'outline'}
+            onClick={() => setActiveTab('health')}
+          >
+            <Gauge className="h-4 w-4 mr-2" />
+            Состояние
+          </Button>
+        )}
         {device.type === 'camera' && (
           <Button
             variant={activeTab === 'preview' ? 'default' : 'outline'}
@@ -556,6 +602,18 @@ export function DeviceDetailsPanel({ deviceId, onBack }: DeviceDetailsPanelProps
         </Card>
       )}
 
+      {activeTab === 'overview' && device.type === 'camera' && (
+        <PoEPowerChart cameraId={deviceId} hideEmpty />
+      )}
+
+      {/* Health Tab */}
+      {activeTab === 'health' && healthDeviceTypes.includes(device.type) && (
+        <DeviceHealthPanel deviceId={deviceId} />
+      )}
+      {activeTab === 'health' && device.type === 'switch' && (
+        <SwitchEnvironmentPanel deviceId={deviceId} />
+      )}
+
       {/* Ports Tab */}
       {activeTab === 'ports' && device.type === 'switch' && (
         <Card>
@@ -898,6 +956,9 @@ export function DeviceDetailsPanel({ deviceId, onBack }: DeviceDetailsPanelProps
           </CardContent>
         </Card>
       )}
+      {activeTab === 'ports' && device.type === 'switch' && ports.length > 0 && (
+        <PortHistoryPanel switchId={deviceId} portNumbers={ports.map((p) => p.port_number)} />
+      )}
 
       {/* Events Tab */}
       {activeTab === 'events' && (
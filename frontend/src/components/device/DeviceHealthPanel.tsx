@@ -0,0 +1,378 @@
+import { useState, useEffect, useCallback } from 'react'
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from '@/components/ui/card'
+import { Button } from '@/components/ui/button'
+import { Badge } from '@/components/ui/badge'
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from '@/components/ui/table'
+import { Loader2, RefreshCw } from 'lucide-react'
+import { GetDeviceHealth } from '../../../wailsjs/go/main/App'
+
+interface InterfaceStatus {
+  index: number
+  name: string
+  admin_status: string
+  oper_status: string
+  speed_mbps: number
+  wireless?: boolean
+}
+
+interface BGPPeer {
+  address: string
+  remote_as: number
+  state: string
+}
+
+interface UPSStatus {
+  battery_status: string
+  output_source: string
+  on_battery: boolean
+  battery_low: boolean
+  charge: number
+  runtime_minutes: number
+  seconds_on_battery: number
+  load: number
+  input_voltage?: number
+  self_test?: string
+  temperature?: number
+  shutdown_at?: string
+  source: string
+}
+
+interface DiskStatus {
+  id: string
+  name: string
+  status: string
+  detail?: string
+  capacity_mb: number
+  free_mb: number
+}
+
+interface OutletStatus {
+  number: number
+  name: string
+  state: string
+}
+
+interface DeviceHealth {
+  device_id: number
+  type: string
+  router?: { interfaces: InterfaceStatus[]; bgp_peers: BGPPeer[] }
+  access_point?: { interfaces: InterfaceStatus[]; clients?: number }
+  ups?: UPSStatus
+  nvr?: { disks: DiskStatus[]; source: string }
+  pdu?: { outlets: OutletStatus[]; current_a?: number }
+  checked_at: string
+  error?: string
+}
+
+interface DeviceHealthPanelProps {
+  deviceId: number
+}
+
+const stateBadge = (state: string) => {
+  switch (state) {
+    case 'up':
+    case 'on':
+    case 'ok':
+    case 'established':
+    case 'normal':
+      return <Badge variant="success">{state}</Badge>
+    case 'down':
+    case 'off':
+    case 'failed':
+    case 'low':
+    case 'depleted':
+      return <Badge variant="destructive">{state}</Badge>
+    case 'warning':
+      return <Badge variant="warning">{state}</Badge>
+    default:
+      return <Badge variant="secondary">{state || '—'}</Badge>
+  }
+}
+
+const selfTestLabels: Record<string, string> = {
+  passed: 'Пройден',
+  warning: 'Предупреждение',
+  failed: 'Ошибка',
+  aborted: 'Прерван',
+  in_progress: 'Выполняется',
+  none: 'Не проводился',
+}
+
+const formatSpeed = (mbps: number) => {
+  if (!mbps) return '—'
+  if (mbps >= 1000) return `${mbps / 1000} Гбит/с`
+  return `${mbps} Мбит/с`
+}
+
+const formatSize = (mb: number) => {
+  if (!mb) return '—'
+  if (mb >= 1024 * 1024) return `${(mb / 1024 / 1024).toFixed(1)} ТБ`
+  return `${(mb / 1024).toFixed(0)} ГБ`
+}
+
+function InterfacesTable({ interfaces }: { interfaces: InterfaceStatus[] }) {
+  if (interfaces.length === 0) {
+    return <div className="text-sm text-muted-foreground">Интерфейсы не найдены</div>
+  }
+  return (
+    <Table>
+      <TableHeader>
+        <TableRow>
+          <TableHead>Интерфейс</TableHead>
+          <TableHead>Состояние</TableHead>
+          <TableHead>Админ.</TableHead>
+          <TableHead>Скорость</TableHead>
+        </TableRow>
+      </TableHeader>
+      <TableBody>
+        {interfaces.map((iface) => (
+          <TableRow key={iface.index}>
+            <TableCell className="font-medium">
+              {iface.name}
+              {iface.wireless && <span className="text-muted-foreground ml-2">(Wi-Fi)</span>}
+            </TableCell>
+            <TableCell>{stateBadge(iface.oper_status)}</TableCell>
+            <TableCell>{iface.admin_status}</TableCell>
+            <TableCell>{formatSpeed(iface.speed_mbps)}</TableCell>
+          </TableRow>
+        ))}
+      </TableBody>
+    </Table>
+  )
+}
+
+export function DeviceHealthPanel({ deviceId }: DeviceHealthPanelProps) {
+  const [health, setHealth] = useState<DeviceHealth | null>(null)
+  const [isLoading, setIsLoading] = useState(false)
+
+  const loadHealth = useCallback(async () => {
+    setIsLoading(true)
+    try {
+      const data = await GetDeviceHealth(deviceId)
+      setHealth(data as unknown as DeviceHealth)
+    } catch (err) {
+      console.error('Failed to load device health:', err)
+      setHealth({ device_id: deviceId, type: '', checked_at: '', error: String(err) })
+    } finally {
+      setIsLoading(false)
+    }
+  }, [deviceId])
+
+  useEffect(() => {
+    loadHealth()
+  }, [loadHealth])
+
+  return (
+    <Card>
+      <CardHeader>
+        <div className="flex items-center justify-between">
+          <div>
+            <CardTitle>Состояние устройства</CardTitle>
+            <CardDescription>
+              {health?.checked_at && !health.error
+                ? `Опрошено ${new Date(health.checked_at).toLocaleString('ru-RU')}`
+                : 'Данные SNMP'}
+              {health?.error && (
+                <span className="text-destructive ml-2">• {health.error}</span>
+              )}
+            </CardDescription>
+          </div>
+          <Button variant="outline" size="sm" onClick={loadHealth} disabled={isLoading}>
+            {isLoading ? (
+              <Loader2 className="h-4 w-4 mr-2 animate-spin" />
+            ) : (
+              <RefreshCw className="h-4 w-4 mr-2" />
+            )}
+            Обновить
+          </Button>
+        </div>
+      </CardHeader>
+      <CardContent className="space-y-6">
+        {health?.router && (
+          <>
+            <InterfacesTable interfaces={health.router.interfaces || []} />
+            {(health.router.bgp_peers || []).length > 0 && (
+              <Table>
+                <TableHeader>
+                  <TableRow>
+                    <TableHead>BGP сосед</TableHead>
+                    <TableHead>AS</TableHead>
+                    <TableHead>Состояние</TableHead>
+                  </TableRow>
+                </TableHeader>
+                <TableBody>
+                  {health.router.bgp_peers.map((peer) => (
+                    <TableRow key={peer.address}>
+                      <TableCell className="font-mono">{peer.address}</TableCell>
+                      <TableCell>{peer.remote_as}</TableCell>
+                      <TableCell>{stateBadge(peer.state)}</TableCell>
+                    </TableRow>
+                  ))}
+                </TableBody>
+              </Table>
+            )}
+          </>
+        )}
+
+        {health?.access_point && (
+          <>
+            {health.access_point.clients !== undefined && health.access_point.clients !== null && (
+              <div className="text-sm">
+                <span className="text-muted-foreground">Подключено клиентов: </span>
+                <span className="font-medium">{health.access_point.clients}</span>
+              </div>
+            )}
+            <InterfacesTable interfaces={health.access_point.interfaces || []} />
+          </>
+        )}
+
+        {health?.ups && (
+          <div className="grid grid-cols-2 md:grid-cols-4 gap-4 text-sm">
+            <div>
+              <div className="text-muted-foreground">Питание</div>
+              <div className="font-medium">
+                {health.ups.on_battery ? (
+                  <Badge variant="destructive">От батареи</Badge>
+                ) : (
+                  <Badge variant="success">От сети</Badge>
+                )}
+              </div>
+            </div>
+            <div>
+              <div className="text-muted-foreground">Батарея</div>
+              <div className="font-medium">{stateBadge(health.ups.battery_status)}</div>
+            </div>
+            <div>
+              <div className="text-muted-foreground">Заряд</div>
+              <div className="font-medium">{health.ups.charge}%</div>
+            </div>
+            <div>
+              <div className="text-muted-foreground">Время работы</div>
+              <div className="font-medium">{health.ups.runtime_minutes} мин</div>
+            </div>
+            <div>
+              <div className="text-muted-foreground">Нагрузка</div>
+              <div className="font-medium">{health.ups.load}%</div>
+            </div>
+            {health.ups.input_voltage !== undefined && health.ups.input_voltage !== null && (
+              <div>
+                <div className="text-muted-foreground">Входное напряжение</div>
+                <div className="font-medium">{health.ups.input_voltage.toFixed(0)} В</div>
+              </div>
+            )}
+            {health.ups.self_test && (
+              <div>
+                <div className="text-muted-foreground">Самотестирование</div>
+                <div className="font-medium">
+                  {health.ups.self_test === 'failed' ? (
+                    <Badge variant="destructive">{selfTestLabels.failed}</Badge>
+                  ) : (
+                    selfTestLabels[health.ups.self_test] || health.ups.self_test
+                  )}
+                </div>
+              </div>
+            )}
+            {health.ups.temperature !== undefined && health.ups.temperature !== null && (
+              <div>
+                <div className="text-muted-foreground">Температура</div>
+                <div className="font-medium">{health.ups.temperature} °C</div>
+              </div>
+            )}
+            {health.ups.on_battery && health.ups.seconds_on_battery > 0 && (
+              <div>
+                <div className="text-muted-foreground">На батарее</div>
+                <div className="font-medium">{Math.round(health.ups.seconds_on_battery / 60)} мин</div>
+              </div>
+            )}
+            {health.ups.shutdown_at && (
+              <div>
+                <div className="text-muted-foreground">Ожидаемое отключение</div>
+                <div className="font-medium text-destructive">
+                  {new Date(health.ups.shutdown_at).toLocaleTimeString('ru-RU', { hour: '2-digit', minute: '2-digit' })}
+                </div>
+              </div>
+            )}
+            <div>
+              <div className="text-muted-foreground">Источник данных</div>
+              <div className="font-medium">{health.ups.source === 'nut' ? 'NUT (upsd)' : 'SNMP (UPS-MIB)'}</div>
+            </div>
+          </div>
+        )}
+
+        {health?.nvr && (
+          (health.nvr.disks || []).length === 0 ? (
+            <div className="text-sm text-muted-foreground">Диски не найдены</div>
+          ) : (
+            <Table>
+              <TableHeader>
+                <TableRow>
+                  <TableHead>Диск</TableHead>
+                  <TableHead>Состояние</TableHead>
+                  <TableHead>Ёмкость</TableHead>
+                  <TableHead>Свободно</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {health.nvr.disks.map((disk) => (
+                  <TableRow key={disk.id}>
+                    <TableCell className="font-medium">{disk.name}</TableCell>
+                    <TableCell>
+                      {stateBadge(disk.status)}
+                      {disk.detail && disk.detail !== disk.status && (
+                        <span className="text-muted-foreground ml-2">{disk.detail}</span>
+                      )}
+                    </TableCell>
+                    <TableCell>{formatSize(disk.capacity_mb)}</TableCell>
+                    <TableCell>{formatSize(disk.free_mb)}</TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          )
+        )}
+
+        {health?.pdu && (
+          <>
+            {health.pdu.current_a !== undefined && health.pdu.current_a !== null && (
+              <div className="text-sm">
+                <span className="text-muted-foreground">Ток нагрузки: </span>
+                <span className="font-medium">{health.pdu.current_a.toFixed(1)} А</span>
+              </div>
+            )}
+            <Table>
+              <TableHeader>
+                <TableRow>
+                  <TableHead>Розетка</TableHead>
+                  <TableHead>Название</TableHead>
+                  <TableHead>Состояние</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {(health.pdu.outlets || []).map((outlet) => (
+                  <TableRow key={outlet.number}>
+                    <TableCell>{outlet.number}</TableCell>
+                    <TableCell>{outlet.name || '—'}</TableCell>
+                    <TableCell>{stateBadge(outlet.state)}</TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          </>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
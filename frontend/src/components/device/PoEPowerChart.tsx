@@ -0,0 +1,91 @@
+import { useState, useEffect } from 'react'
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from '@/components/ui/card'
+import {
+  GetCameraPowerHistory,
+  GetPoEPowerHistory,
+} from '../../../wailsjs/go/main/App'
+
+interface PoEPowerSample {
+  switch_id: number
+  port_number: number
+  camera_id?: number
+  power_w: number
+  checked_at: string
+}
+
+type PoEPowerChartProps =
+  | { cameraId: number; switchId?: never; port?: never; title?: string; hideEmpty?: boolean }
+  | { cameraId?: never; switchId: number; port: number; title?: string; hideEmpty?: boolean }
+
+export function PoEPowerChart({ cameraId, switchId, port, title, hideEmpty }: PoEPowerChartProps) {
+  const [samples, setSamples] = useState<PoEPowerSample[] | null>(null)
+
+  useEffect(() => {
+    let cancelled = false
+    const load = async () => {
+      try {
+        const data =
+          cameraId !== undefined
+            ? await GetCameraPowerHistory(cameraId, 24)
+            : await GetPoEPowerHistory(switchId as number, port as number, 24)
+        if (!cancelled) setSamples((data || []) as unknown as PoEPowerSample[])
+      } catch (err) {
+        console.error('Failed to load PoE power history:', err)
+        if (!cancelled) setSamples([])
+      }
+    }
+    load()
+    return () => {
+      cancelled = true
+    }
+  }, [cameraId, switchId, port])
+
+  if (samples === null || (hideEmpty && samples.length === 0)) {
+    return null
+  }
+
+  const points = samples.slice(-100)
+  const max = Math.max(...points.map((s) => s.power_w), 1)
+  const current = samples.length > 0 ? samples[samples.length - 1].power_w : undefined
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>{title || 'Потребление PoE (последние 24ч)'}</CardTitle>
+        {current !== undefined && (
+          <CardDescription>
+            Сейчас {current.toFixed(1)} Вт, максимум {Math.max(...samples.map((s) => s.power_w)).toFixed(1)} Вт
+          </CardDescription>
+        )}
+      </CardHeader>
+      <CardContent>
+        {points.length === 0 ? (
+          <div className="text-sm text-muted-foreground">Нет данных</div>
+        ) : (
+          <>
+            <div className="h-24 flex items-end gap-px">
+              {points.map((sample, i) => (
+                <div
+                  key={i}
+                  className="flex-1 min-w-[2px] rounded-t bg-primary/70"
+                  style={{ height: `${Math.max((sample.power_w / max) * 100, 2)}%` }}
+                  title={`${sample.power_w.toFixed(1)} Вт — ${new Date(sample.checked_at).toLocaleTimeString('ru-RU')}`}
+                />
+              ))}
+            </div>
+            <div className="flex justify-between text-xs text-muted-foreground mt-1">
+              <span>24ч назад</span>
+              <span>Сейчас</span>
+            </div>
+          </>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
@@ -0,0 +1,211 @@
+import { useState, useEffect, useCallback } from 'react'
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from '@/components/ui/card'
+import { Badge } from '@/components/ui/badge'
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from '@/components/ui/table'
+import {
+  GetPortFlapCounters,
+  GetPortHistory,
+} from '../../../wailsjs/go/main/App'
+
+interface PortStateChange {
+  id: number
+  port_number: number
+  field: string
+  old_value: string
+  new_value: string
+  flap: boolean
+  source: string
+  actor?: string
+  changed_at: string
+}
+
+interface PortFlapCounter {
+  port_number: number
+  last_hour: number
+  last_day: number
+  last_week: number
+  total: number
+  last_flap_at?: string
+}
+
+interface PortHistoryPanelProps {
+  switchId: number
+  portNumbers: number[]
+}
+
+const fieldLabels: Record<string, string> = {
+  status: 'Линк',
+  speed: 'Скорость',
+  duplex: 'Дуплекс',
+  admin: 'Порт',
+  poe: 'PoE',
+}
+
+const valueLabels: Record<string, string> = {
+  up: 'Вкл',
+  down: 'Выкл',
+  full: 'Полный',
+  half: 'Полу',
+  on: 'Вкл',
+  off: 'Выкл',
+  fault: 'Ошибка',
+}
+
+const formatValue = (value: string) => (value ? valueLabels[value] || value : '—')
+
+export function PortHistoryPanel({ switchId, portNumbers }: PortHistoryPanelProps) {
+  const [counters, setCounters] = useState<PortFlapCounter[]>([])
+  const [selectedPort, setSelectedPort] = useState<number | null>(null)
+  const [history, setHistory] = useState<PortStateChange[]>([])
+
+  const loadCounters = useCallback(async () => {
+    try {
+      const data = await GetPortFlapCounters(switchId)
+      setCounters((data || []) as unknown as PortFlapCounter[])
+    } catch (err) {
+      console.error('Failed to load port flap counters:', err)
+    }
+  }, [switchId])
+
+  useEffect(() => {
+    loadCounters()
+  }, [loadCounters])
+
+  useEffect(() => {
+    if (selectedPort === null) {
+      setHistory([])
+      return
+    }
+    GetPortHistory(switchId, selectedPort)
+      .then((data) => setHistory((data || []) as unknown as PortStateChange[]))
+      .catch((err) => console.error('Failed to load port history:', err))
+  }, [switchId, selectedPort])
+
+  return (
+    <Card>
+      <CardHeader>
+        <div className="flex items-center justify-between gap-4">
+          <div>
+            <CardTitle>История портов</CardTitle>
+            <CardDescription>
+              Изменения линка, скорости, дуплекса, PoE и включения портов. Потеря линка на включённом порту считается обрывом
+            </CardDescription>
+          </div>
+          <div className="w-40">
+            <Select
+              value={selectedPort?.toString() || 'none'}
+              onValueChange={(v) => setSelectedPort(v === 'none' ? null : parseInt(v))}
+            >
+              <SelectTrigger>
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="none">Выберите порт</SelectItem>
+                {portNumbers.map((n) => (
+                  <SelectItem key={n} value={n.toString()}>
+                    Порт {n}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+          </div>
+        </div>
+      </CardHeader>
+      <CardContent className="space-y-6">
+        {counters.length === 0 ? (
+          <div className="text-sm text-muted-foreground">Обрывов линка не зафиксировано</div>
+        ) : (
+          <Table>
+            <TableHeader>
+              <TableRow>
+                <TableHead>Порт</TableHead>
+                <TableHead>Обрывов за час</TableHead>
+                <TableHead>За сутки</TableHead>
+                <TableHead>За неделю</TableHead>
+                <TableHead>Всего</TableHead>
+                <TableHead>Последний</TableHead>
+              </TableRow>
+            </TableHeader>
+            <TableBody>
+              {counters.map((c) => (
+                <TableRow
+                  key={c.port_number}
+                  className="cursor-pointer"
+                  onClick={() => setSelectedPort(c.port_number)}
+                >
+                  <TableCell className="font-medium">Порт {c.port_number}</TableCell>
+                  <TableCell>
+                    {c.last_hour > 0 ? <Badge variant="warning">{c.last_hour}</Badge> : 0}
+                  </TableCell>
+                  <TableCell>{c.last_day}</TableCell>
+                  <TableCell>{c.last_week}</TableCell>
+                  <TableCell>{c.total}</TableCell>
+                  <TableCell>
+                    {c.last_flap_at ? new Date(c.last_flap_at).toLocaleString('ru-RU') : '—'}
+                  </TableCell>
+                </TableRow>
+              ))}
+            </TableBody>
+          </Table>
+        )}
+
+        {selectedPort !== null &&
+          (history.length === 0 ? (
+            <div className="text-sm text-muted-foreground">Изменений порта {selectedPort} нет</div>
+          ) : (
+            <Table>
+              <TableHeader>
+                <TableRow>
+                  <TableHead>Время</TableHead>
+                  <TableHead>Параметр</TableHead>
+                  <TableHead>Было</TableHead>
+                  <TableHead>Стало</TableHead>
+                  <TableHead>Источник</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {history.map((c) => (
+                  <TableRow key={c.id}>
+                    <TableCell>{new Date(c.changed_at).toLocaleString('ru-RU')}</TableCell>
+                    <TableCell>
+                      {fieldLabels[c.field] || c.field}
+                      {c.flap && (
+                        <Badge variant="destructive" className="ml-2">
+                          Обрыв
+                        </Badge>
+                      )}
+                    </TableCell>
+                    <TableCell>{formatValue(c.old_value)}</TableCell>
+                    <TableCell>{formatValue(c.new_value)}</TableCell>
+                    <TableCell>
+                      {c.source === 'user' ? `Пользователь ${c.actor || ''}`.trim() : 'Мониторинг'}
+                    </TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          ))}
+      </CardContent>
+    </Card>
+  )
+}
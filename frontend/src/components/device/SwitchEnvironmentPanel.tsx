@@ -0,0 +1,334 @@
+import { useState, useEffect, useCallback } from 'react'
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from '@/components/ui/card'
+import { Button } from '@/components/ui/button'
+import { Badge } from '@/components/ui/badge'
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from '@/components/ui/table'
+import { Loader2, RefreshCw } from 'lucide-react'
+import { PoEPowerChart } from './PoEPowerChart'
+import {
+  GetSwitchEnvironment,
+  GetSwitchEnvironmentHistory,
+} from '../../../wailsjs/go/main/App'
+
+interface SensorReading {
+  name: string
+  type: string
+  value: number
+  unit: string
+  status: string
+}
+
+interface ComponentStatus {
+  name: string
+  status: string
+}
+
+interface PortPower {
+  port: number
+  power_w: number
+}
+
+interface SwitchEnvironment {
+  device_id: number
+  temperature?: number
+  sensors: SensorReading[]
+  fans: ComponentStatus[]
+  psus: ComponentStatus[]
+  poe_budget_w?: number
+  poe_used_w?: number
+  poe_ports?: PortPower[]
+  cpu_percent?: number
+  memory_percent?: number
+  checked_at: string
+  error?: string
+}
+
+interface EnvironmentSample {
+  temperature?: number
+  poe_budget_w?: number
+  poe_used_w?: number
+  checked_at: string
+}
+
+interface SwitchEnvironmentPanelProps {
+  deviceId: number
+}
+
+const componentBadge = (status: string) => {
+  switch (status) {
+    case 'ok':
+      return <Badge variant="success">Норма</Badge>
+    case 'warning':
+      return <Badge variant="warning">Предупреждение</Badge>
+    case 'failed':
+      return <Badge variant="destructive">Неисправен</Badge>
+    case 'not_present':
+      return <Badge variant="secondary">Не установлен</Badge>
+    default:
+      return <Badge variant="secondary">Неизвестно</Badge>
+  }
+}
+
+const sensorTypeLabels: Record<string, string> = {
+  temperature: 'Температура',
+  fan: 'Вентилятор',
+  voltage: 'Напряжение',
+  current: 'Ток',
+  power: 'Мощность',
+  other: 'Прочее',
+}
+
+const formatValue = (sensor: SensorReading) => {
+  const unit = sensor.unit === 'C' ? '°C' : sensor.unit === 'rpm' ? 'об/мин' : sensor.unit
+  const value = Number.isInteger(sensor.value) ? sensor.value : sensor.value.toFixed(1)
+  return `${value} ${unit}`.trim()
+}
+
+const hasValue = (value?: number | null): value is number => value !== undefined && value !== null
+
+function HistoryBars({
+  samples,
+  value,
+  format,
+}: {
+  samples: EnvironmentSample[]
+  value: (sample: EnvironmentSample) => number | undefined
+  format: (v: number) => string
+}) {
+  const points = samples.filter((s) => hasValue(value(s))).slice(-100)
+  if (points.length === 0) {
+    return <div className="text-sm text-muted-foreground">Нет данных</div>
+  }
+  const max = Math.max(...points.map((s) => value(s) as number), 1)
+  return (
+    <>
+      <div className="h-24 flex items-end gap-px">
+        {points.map((sample, i) => {
+          const v = value(sample) as number
+          return (
+            <div
+              key={i}
+              className="flex-1 min-w-[2px] rounded-t bg-primary/70"
+              style={{ height: `${Math.max((v / max) * 100, 2)}%` }}
+              title={`${format(v)} — ${new Date(sample.checked_at).toLocaleTimeString('ru-RU')}`}
+            />
+          )
+        })}
+      </div>
+      <div className="flex justify-between text-xs text-muted-foreground mt-1">
+        <span>24ч назад</span>
+        <span>Сейчас</span>
+      </div>
+    </>
+  )
+}
+
+export function SwitchEnvironmentPanel({ deviceId }: SwitchEnvironmentPanelProps) {
+  const [env, setEnv] = useState<SwitchEnvironment | null>(null)
+  const [history, setHistory] = useState<EnvironmentSample[]>([])
+  const [isLoading, setIsLoading] = useState(false)
+  const [selectedPort, setSelectedPort] = useState<number | null>(null)
+
+  const loadEnvironment = useCallback(async () => {
+    setIsLoading(true)
+    try {
+      const [data, samples] = await Promise.all([
+        GetSwitchEnvironment(deviceId),
+        GetSwitchEnvironmentHistory(deviceId, 24),
+      ])
+      setEnv(data as unknown as SwitchEnvironment)
+      setHistory((samples || []) as unknown as EnvironmentSample[])
+    } catch (err) {
+      console.error('Failed to load switch environment:', err)
+      setEnv({ device_id: deviceId, sensors: [], fans: [], psus: [], checked_at: '', error: String(err) })
+    } finally {
+      setIsLoading(false)
+    }
+  }, [deviceId])
+
+  useEffect(() => {
+    loadEnvironment()
+  }, [loadEnvironment])
+
+  const poePercent =
+    env && hasValue(env.poe_budget_w) && hasValue(env.poe_used_w) && env.poe_budget_w > 0
+      ? (env.poe_used_w / env.poe_budget_w) * 100
+      : undefined
+
+  return (
+    <div className="space-y-4">
+      <Card>
+        <CardHeader>
+          <div className="flex items-center justify-between">
+            <div>
+              <CardTitle>Состояние коммутатора</CardTitle>
+              <CardDescription>
+                {env?.checked_at && !env.error
+                  ? `Опрошено ${new Date(env.checked_at).toLocaleString('ru-RU')}`
+                  : 'Данные SNMP'}
+                {env?.error && <span className="text-destructive ml-2">• {env.error}</span>}
+              </CardDescription>
+            </div>
+            <Button variant="outline" size="sm" onClick={loadEnvironment} disabled={isLoading}>
+              {isLoading ? (
+                <Loader2 className="h-4 w-4 mr-2 animate-spin" />
+              ) : (
+                <RefreshCw className="h-4 w-4 mr-2" />
+              )}
+              Обновить
+            </Button>
+          </div>
+        </CardHeader>
+        <CardContent className="space-y-6">
+          {env && !env.error && (
+            <div className="grid grid-cols-2 md:grid-cols-4 gap-4 text-sm">
+              <div>
+                <div className="text-muted-foreground">Температура</div>
+                <div className="font-medium">
+                  {hasValue(env.temperature) ? `${env.temperature.toFixed(0)} °C` : '—'}
+                </div>
+              </div>
+              <div>
+                <div className="text-muted-foreground">PoE</div>
+                <div className="font-medium">
+                  {hasValue(env.poe_used_w) ? `${env.poe_used_w.toFixed(1)} Вт` : '—'}
+                  {hasValue(env.poe_budget_w) && ` из ${env.poe_budget_w.toFixed(0)} Вт`}
+                  {poePercent !== undefined && (
+                    <span className="text-muted-foreground ml-2">
+                      ({poePercent.toFixed(0)}%)
+                    </span>
+                  )}
+                </div>
+              </div>
+              <div>
+                <div className="text-muted-foreground">Загрузка CPU</div>
+                <div className="font-medium">{hasValue(env.cpu_percent) ? `${env.cpu_percent}%` : '—'}</div>
+              </div>
+              <div>
+                <div className="text-muted-foreground">Память</div>
+                <div className="font-medium">
+                  {hasValue(env.memory_percent) ? `${env.memory_percent}%` : '—'}
+                </div>
+              </div>
+            </div>
+          )}
+
+          {env && (env.fans.length > 0 || env.psus.length > 0) && (
+            <Table>
+              <TableHeader>
+                <TableRow>
+                  <TableHead>Компонент</TableHead>
+                  <TableHead>Тип</TableHead>
+                  <TableHead>Состояние</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {env.fans.map((fan) => (
+                  <TableRow key={`fan-${fan.name}`}>
+                    <TableCell className="font-medium">{fan.name}</TableCell>
+                    <TableCell>Вентилятор</TableCell>
+                    <TableCell>{componentBadge(fan.status)}</TableCell>
+                  </TableRow>
+                ))}
+                {env.psus.map((psu) => (
+                  <TableRow key={`psu-${psu.name}`}>
+                    <TableCell className="font-medium">{psu.name}</TableCell>
+                    <TableCell>Блок питания</TableCell>
+                    <TableCell>{componentBadge(psu.status)}</TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          )}
+
+          {env && env.sensors.length > 0 && (
+            <Table>
+              <TableHeader>
+                <TableRow>
+                  <TableHead>Датчик</TableHead>
+                  <TableHead>Тип</TableHead>
+                  <TableHead>Значение</TableHead>
+                  <TableHead>Состояние</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {env.sensors.map((sensor, i) => (
+                  <TableRow key={i}>
+                    <TableCell className="font-medium">{sensor.name}</TableCell>
+                    <TableCell>{sensorTypeLabels[sensor.type] || sensor.type}</TableCell>
+                    <TableCell>{formatValue(sensor)}</TableCell>
+                    <TableCell>{componentBadge(sensor.status === 'unavailable' ? 'unknown' : sensor.status)}</TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          )}
+          {env && env.poe_ports && env.poe_ports.length > 0 && (
+            <Table>
+              <TableHeader>
+                <TableRow>
+                  <TableHead>Порт</TableHead>
+                  <TableHead>Мощность PoE</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {env.poe_ports.map((p) => (
+                  <TableRow
+                    key={p.port}
+                    className={`cursor-pointer ${selectedPort === p.port ? 'bg-muted' : ''}`}
+                    onClick={() => setSelectedPort(selectedPort === p.port ? null : p.port)}
+                  >
+                    <TableCell className="font-medium">Порт {p.port}</TableCell>
+                    <TableCell>{p.power_w.toFixed(1)} Вт</TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          )}
+        </CardContent>
+      </Card>
+
+      {selectedPort !== null && (
+        <PoEPowerChart
+          switchId={deviceId}
+          port={selectedPort}
+          title={`Потребление PoE порта ${selectedPort} (последние 24ч)`}
+        />
+      )}
+
+      {history.length > 0 && (
+        <div className="grid md:grid-cols-2 gap-4">
+          <Card>
+            <CardHeader>
+              <CardTitle>Температура (последние 24ч)</CardTitle>
+            </CardHeader>
+            <CardContent>
+              <HistoryBars samples={history} value={(s) => s.temperature} format={(v) => `${v.toFixed(0)} °C`} />
+            </CardContent>
+          </Card>
+          <Card>
+            <CardHeader>
+              <CardTitle>Потребление PoE (последние 24ч)</CardTitle>
+            </CardHeader>
+            <CardContent>
+              <HistoryBars samples={history} value={(s) => s.poe_used_w} format={(v) => `${v.toFixed(1)} Вт`} />
+            </CardContent>
+          </Card>
+        </div>
+      )}
+    </div>
+  )
+}
@@ -18,9 +18,13 @@ import {
   DialogTitle,
 } from '@/components/ui/dialog'
 import { Separator } from '@/components/ui/separator'
+import { Checkbox } from '@/components/ui/checkbox'
 import { GetSwitchesWithPorts, GetCameraManufacturers, GetCameraPreset } from '../../../wailsjs/go/main/App'
 import { switchManufacturers, getModelsByManufacturer, getPortCountForModel, getSfpPortCountForModel } from '@/data/switchModels'
 
+// Device types polled over SNMP with shared settings (snmp_devices table)
+const snmpDeviceTypes = ['router', 'access_point', 'ups', 'nvr', 'pdu']
+
 interface CameraSeriesPreset {
   series: string
   description: string
@@ -50,6 +54,7 @@ interface DeviceFormData {
   snmp_version: string
   port_count: number
   sfp_port_count: number
+  poe_budget_w: number
   // SNMPv3 settings
   snmpv3_user: string
   snmpv3_security: string
@@ -66,9 +71,16 @@ interface DeviceFormData {
   // Server
   tcp_ports: string
   use_snmp: boolean
-  // Uplink (for switches and servers)
+  // Uplink (for switches, servers and SNMP devices)
   uplink_switch_id?: number
   uplink_port_id?: number
+  // NVR
+  check_http: boolean
+  http_port: number
+  // UPS
+  ups_protocol: string
+  nut_port: number
+  nut_ups_name: string
 }
 
 interface Credential {
@@ -116,6 +128,7 @@ const defaultFormData: DeviceFormData = {
   snmp_version: 'v2c',
   port_count: 8,
   sfp_port_count: 2,
+  poe_budget_w: 0,
   snmpv3_user: '',
   snmpv3_security: 'noAuthNoPriv',
   snmpv3_auth_proto: '',
@@ -128,6 +141,11 @@ const defaultFormData: DeviceFormData = {
   stream_type: 'jpeg',
   tcp_ports: '[]',
   use_snmp: false,
+  check_http: false,
+  http_port: 80,
+  ups_protocol: 'snmp',
+  nut_port: 3493,
+  nut_ups_name: 'ups',
 }
 
 export function DeviceForm({
@@ -196,8 +214,8 @@ export function DeviceForm({
           }
         }
 
-        // If editing a switch/server with uplink_port_id, find which switch owns that port
-        if ((initialData.type === 'switch' || initialData.type === 'server') && initialData.uplink_port_id) {
+        // If editing a device with uplink_port_id, find which switch owns that port
+        if (initialData.type !== 'camera' && initialData.uplink_port_id) {
           for (const sw of switchesData) {
             if (sw.ports) {
               const port = sw.ports.find(p => p.id === initialData.uplink_port_id)
@@ -392,6 +410,10 @@ export function DeviceForm({
     updateField('uplink_port_id', portIdNum)
   }
 
+  const isSNMPDevice = snmpDeviceTypes.includes(formData.type)
+  // UPS units read over NUT have no SNMP settings
+  const usesSNMP = formData.type === 'switch' || (isSNMPDevice && !(formData.type === 'ups' && formData.ups_protocol === 'nut'))
+
   const isEditing = !!initialData?.id
 
   return (
@@ -430,6 +452,12 @@ export function DeviceForm({
                   <SelectItem value="switch">Коммутатор</SelectItem>
                   <SelectItem value="server">Сервер</SelectItem>
                   <SelectItem value="camera">Камера</SelectItem>
+                  <SelectItem value="router">Маршрутизатор</SelectItem>
+                  <SelectItem value="access_point">Точка доступа</SelectItem>
+                  <SelectItem value="ups">ИБП</SelectItem>
+                  <SelectItem value="nvr">Видеорегистратор</SelectItem>
+                  <SelectItem value="pdu">PDU</SelectItem>
+                  <SelectItem value="host">Хост (только ping)</SelectItem>
                 </SelectContent>
               </Select>
             </div>
@@ -616,15 +644,15 @@ export function DeviceForm({
               </div>
             )}
 
-            {/* Model input for servers only */}
-            {formData.type === 'server' && (
+            {/* Model input for servers, SNMP devices and hosts */}
+            {(formData.type === 'server' || formData.type === 'host' || isSNMPDevice) && (
               <div className="grid gap-2">
                 <Label htmlFor="model">Модель</Label>
                 <Input
                   id="model"
                   value={formData.model}
                   onChange={(e) => updateField('model', e.target.value)}
-                  placeholder="Dell PowerEdge"
+                  placeholder={formData.type === 'server' ? 'Dell PowerEdge' : ''}
                 />
               </div>
             )}
@@ -651,15 +679,67 @@ export function DeviceForm({
               </Select>
             </div>
 
-            {/* Switch-specific fields */}
-            {formData.type === 'switch' && (
+            {/* SNMP fields of switches, routers, access points, UPS units, NVRs and PDUs */}
+            {(formData.type === 'switch' || isSNMPDevice) && (
               <>
                 <Separator />
-                <div className="text-sm font-medium text-muted-foreground">
-                  Параметры SNMP
-                </div>
+                {/* UPS protocol: UPS-MIB over SNMP or Network UPS Tools */}
+                {formData.type === 'ups' && (
+                  <>
+                    <div className="grid gap-2">
+                      <Label htmlFor="ups_protocol">Протокол опроса ИБП</Label>
+                      <Select
+                        value={formData.ups_protocol}
+                        onValueChange={(v) => updateField('ups_protocol', v)}
+                      >
+                        <SelectTrigger>
+                          <SelectValue />
+                        </SelectTrigger>
+                        <SelectContent>
+                          <SelectItem value="snmp">SNMP (UPS-MIB)</SelectItem>
+                          <SelectItem value="nut">NUT (upsd)</SelectItem>
+                        </SelectContent>
+                      </Select>
+                    </div>
+                    {formData.ups_protocol === 'nut' && (
+                      <>
+                        <div className="grid grid-cols-2 gap-4">
+                          <div className="grid gap-2">
+                            <Label htmlFor="nut_ups_name">Имя ИБП в upsd</Label>
+                            <Input
+                              id="nut_ups_name"
+                              value={formData.nut_ups_name}
+                              onChange={(e) => updateField('nut_ups_name', e.target.value)}
+                              placeholder="ups"
+                            />
+                          </div>
+                          <div className="grid gap-2">
+                            <Label htmlFor="nut_port">Порт upsd</Label>
+                            <Input
+                              id="nut_port"
+                              type="number"
+                              value={formData.nut_port}
+                              onChange={(e) =>
+                                updateField('nut_port', parseInt(e.target.value) || 3493)
+                              }
+                              placeholder="3493"
+                            />
+                          </div>
+                        </div>
+                        <p className="text-xs text-muted-foreground">
+                          Если выбраны учётные данные, они передаются upsd при подключении.
+                        </p>
+                      </>
+                    )}
+                  </>
+                )}
+                {usesSNMP && (
+                  <div className="text-sm font-medium text-muted-foreground">
+                    Параметры SNMP
+                  </div>
+                )}
                 {/* Show port configuration info based on selected model */}
-                {formData.model && (
+                {formData.type === 'switch' && formData.model && (
                   <div className="text-sm bg-muted/50 p-3 rounded-md">
                     <div className="font-medium mb-1">Конфигурация портов:</div>
                     <div className="text-muted-foreground">
@@ -672,27 +752,47 @@ export function DeviceForm({
                     </div>
                   </div>
                 )}
+                {formData.type === 'switch' && (
+                  <div className="grid gap-2">
+                    <Label htmlFor="poe_budget_w">Бюджет PoE, Вт</Label>
+                    <Input
+                      id="poe_budget_w"
+                      type="number"
+                      min={0}
+                      value={formData.poe_budget_w}
+                      onChange={(e) =>
+                        updateField('poe_budget_w', parseFloat(e.target.value) || 0)
+                      }
+                      placeholder="0"
+                    />
+                    <p className="text-xs text-muted-foreground">
+                      0 — использовать значение, которое сообщает коммутатор.
+                    </p>
+                  </div>
+                )}
 
-                <div className="grid gap-2">
-                  <Label htmlFor="snmp_version">Версия SNMP</Label>
-                  <Select
-                    value={formData.snmp_version}
-                    onValueChange={(v) => updateField('snmp_version', v)}
-                  >
-                    <SelectTrigger>
-                      <SelectValue />
-                    </SelectTrigger>
-                    <SelectContent>
-                      <SelectItem value="v1">SNMPv1</SelectItem>
-                      <SelectItem value="v2c">SNMPv2c</SelectItem>
-                      <SelectItem value="v3">SNMPv3</SelectItem>
-                    </SelectContent>
-                  </Select>
-                </div>
+                {usesSNMP && (
+                  <div className="grid gap-2">
+                    <Label htmlFor="snmp_version">Версия SNMP</Label>
+                    <Select
+                      value={formData.snmp_version}
+                      onValueChange={(v) => updateField('snmp_version', v)}
+                    >
+                      <SelectTrigger>
+                        <SelectValue />
+                      </SelectTrigger>
+                      <SelectContent>
+                        <SelectItem value="v1">SNMPv1</SelectItem>
+                        <SelectItem value="v2c">SNMPv2c</SelectItem>
+                        <SelectItem value="v3">SNMPv3</SelectItem>
+                      </SelectContent>
+                    </Select>
+                  </div>
+                )}
 
                 {/* SNMPv1/v2c settings */}
-                {(formData.snmp_version === 'v1' || formData.snmp_version === 'v2c') && (
-                  <div className="grid grid-cols-2 gap-4">
+                {usesSNMP && (formData.snmp_version === 'v1' || formData.snmp_version === 'v2c') && (
+                  <div className={formData.type === 'switch' ? 'grid grid-cols-2 gap-4' : 'grid gap-4'}>
                     <div className="grid gap-2">
                       <Label htmlFor="snmp_community">Community (чтение)</Label>
                       <Input
@@ -702,20 +802,22 @@ export function DeviceForm({
                         placeholder="public"
                       />
                     </div>
-                    <div className="grid gap-2">
-                      <Label htmlFor="snmp_write_community">Community (запись)</Label>
-                      <Input
-                        id="snmp_write_community"
-                        value={formData.snmp_write_community}
-                        onChange={(e) => updateField('snmp_write_community', e.target.value)}
-                        placeholder="private"
-                      />
-                    </div>
+                    {formData.type === 'switch' && (
+                      <div className="grid gap-2">
+                        <Label htmlFor="snmp_write_community">Community (запись)</Label>
+                        <Input
+                          id="snmp_write_community"
+                          value={formData.snmp_write_community}
+                          onChange={(e) => updateField('snmp_write_community', e.target.value)}
+                          placeholder="private"
+                        />
+                      </div>
+                    )}
                   </div>
                 )}
 
                 {/* SNMPv3 settings */}
-                {formData.snmp_version === 'v3' && (
+                {usesSNMP && formData.snmp_version === 'v3' && (
                   <>
                     <div className="grid grid-cols-2 gap-4">
                       <div className="grid gap-2">
@@ -818,7 +920,37 @@ export function DeviceForm({
                   </>
                 )}
 
-                {/* Uplink settings for switches */}
+                {/* NVR disk status over HTTP */}
+                {formData.type === 'nvr' && (
+                  <>
+                    <label className="flex items-center gap-2 text-sm">
+                      <Checkbox
+                        checked={formData.check_http}
+                        onCheckedChange={(checked) => updateField('check_http', checked === true)}
+                      />
+                      Читать состояние дисков по HTTP (ISAPI)
+                    </label>
+                    {formData.check_http && (
+                      <div className="grid gap-2">
+                        <Label htmlFor="http_port">HTTP порт</Label>
+                        <Input
+                          id="http_port"
+                          type="number"
+                          value={formData.http_port}
+                          onChange={(e) =>
+                            updateField('http_port', parseInt(e.target.value) || 80)
+                          }
+                          placeholder="80"
+                        />
+                        <p className="text-xs text-muted-foreground">
+                          Используются выбранные учётные данные устройства.
+                        </p>
+                      </div>
+                    )}
+                  </>
+                )}
+
+                {/* Uplink settings for switches and SNMP devices */}
                 {getAvailableUplinkSwitches().length > 0 && (
                   <>
                     <Separator />
@@ -35,6 +35,12 @@ import {
   Loader2,
   CheckCircle,
   XCircle,
+  Router,
+  Wifi,
+  BatteryCharging,
+  HardDrive,
+  PlugZap,
+  Monitor,
 } from 'lucide-react'
 import { PingDevice, OpenPingCmd } from '../../../wailsjs/go/main/App'
 import { useTranslation } from '@/i18n'
@@ -62,6 +68,12 @@ const deviceTypeIcons: Record<string, React.ReactNode> = {
   switch: <Network className="h-5 w-5" />,
   server: <Server className="h-5 w-5" />,
   camera: <Camera className="h-5 w-5" />,
+  router: <Router className="h-5 w-5" />,
+  access_point: <Wifi className="h-5 w-5" />,
+  ups: <BatteryCharging className="h-5 w-5" />,
+  nvr: <HardDrive className="h-5 w-5" />,
+  pdu: <PlugZap className="h-5 w-5" />,
+  host: <Monitor className="h-5 w-5" />,
 }
 
 const statusColors: Record<string, string> = {
@@ -86,6 +98,12 @@ export function DeviceList({
     switch: t('devices.types.switch') as string,
     server: t('devices.types.server') as string,
     camera: t('devices.types.camera') as string,
+    router: t('devices.types.router') as string,
+    access_point: t('devices.types.access_point') as string,
+    ups: t('devices.types.ups') as string,
+    nvr: t('devices.types.nvr') as string,
+    pdu: t('devices.types.pdu') as string,
+    host: t('devices.types.host') as string,
   }
 
   const statusLabels: Record<string, string> = {
@@ -129,6 +147,9 @@ export function DeviceList({
             <SelectItem value="switch">{t('devices.stats.switches')}</SelectItem>
             <SelectItem value="server">{t('devices.stats.servers')}</SelectItem>
             <SelectItem value="camera">{t('devices.stats.cameras')}</SelectItem>
+            {['router', 'access_point', 'ups', 'nvr', 'pdu', 'host'].map((type) => (
+              <SelectItem key={type} value={type}>{deviceTypeLabels[type]}</SelectItem>
+            ))}
           </SelectContent>
         </Select>
         <Select value={statusFilter} onValueChange={setStatusFilter}>
@@ -18,8 +18,10 @@ import {
   Info,
   Minimize2,
   LogOut,
+  Lock,
+  UserX,
 } from "lucide-react"
-import { MinimizeToTray, QuitApp } from "../../../wailsjs/go/main/App"
+import { MinimizeToTray, QuitApp, LockSession, Logout } from "../../../wailsjs/go/main/App"
 import { useTranslation } from "@/i18n"
 
 interface NavItem {
@@ -99,8 +101,20 @@ export function Sidebar({ currentPage, onNavigate, collapsed = false }: SidebarP
 
         <Separator />
 
-        {/* Tray & Exit */}
+        {/* Session, Tray & Exit */}
         <div className="py-2 px-2 space-y-1">
+          <ActionButton
+            icon={<Lock className="h-5 w-5" />}
+            label={t('auth.lock')}
+            collapsed={collapsed}
+            onClick={() => LockSession()}
+          />
+          <ActionButton
+            icon={<UserX className="h-5 w-5" />}
+            label={t('auth.logout')}
+            collapsed={collapsed}
+            onClick={() => Logout()}
+          />
           <ActionButton
             icon={<Minimize2 className="h-5 w-5" />}
             label={t('tray.minimize')}
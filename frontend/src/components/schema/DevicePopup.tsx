@@ -8,7 +8,7 @@ import {
   CardHeader,
   CardTitle,
 } from '@/components/ui/card'
-import { Monitor, Server, Camera, Network, X, Radio, ExternalLink, Loader2, CheckCircle, XCircle } from 'lucide-react'
+import { Monitor, Server, Camera, Network, X, Radio, ExternalLink, Loader2, CheckCircle, XCircle, Router, Wifi, BatteryCharging, HardDrive, PlugZap } from 'lucide-react'
 import { PingDevice } from '../../../wailsjs/go/main/App'
 import type { SchemaItem } from '@/types'
 
@@ -46,6 +46,16 @@ export function DevicePopup({ item, onClose, onViewDetails, linkedCameras = [] }
         return <Server className="h-5 w-5" />
       case 'camera':
         return <Camera className="h-5 w-5" />
+      case 'router':
+        return <Router className="h-5 w-5" />
+      case 'access_point':
+        return <Wifi className="h-5 w-5" />
+      case 'ups':
+        return <BatteryCharging className="h-5 w-5" />
+      case 'nvr':
+        return <HardDrive className="h-5 w-5" />
+      case 'pdu':
+        return <PlugZap className="h-5 w-5" />
       default:
         return <Monitor className="h-5 w-5" />
     }
@@ -59,6 +69,18 @@ export function DevicePopup({ item, onClose, onViewDetails, linkedCameras = [] }
         return 'Сервер'
       case 'camera':
         return 'Камера'
+      case 'router':
+        return 'Маршрутизатор'
+      case 'access_point':
+        return 'Точка доступа'
+      case 'ups':
+        return 'ИБП'
+      case 'nvr':
+        return 'Видеорегистратор'
+      case 'pdu':
+        return 'PDU'
+      case 'host':
+        return 'Хост'
       default:
         return 'Устройство'
     }
@@ -1,6 +1,6 @@
 import { useState, useRef, useEffect, useCallback } from 'react'
 import { cn } from '@/lib/utils'
-import { Network, Server, Camera, Monitor } from 'lucide-react'
+import { Network, Server, Camera, Monitor, Router, Wifi, BatteryCharging, HardDrive, PlugZap } from 'lucide-react'
 import type { SchemaItem } from '@/types'
 
 interface ConnectionLine {
@@ -178,6 +178,16 @@ export function SchemaCanvas({
         return <Server className={iconClass} />
       case 'camera':
         return <Camera className={iconClass} />
+      case 'router':
+        return <Router className={iconClass} />
+      case 'access_point':
+        return <Wifi className={iconClass} />
+      case 'ups':
+        return <BatteryCharging className={iconClass} />
+      case 'nvr':
+        return <HardDrive className={iconClass} />
+      case 'pdu':
+        return <PlugZap className={iconClass} />
       default:
         return <Monitor className={iconClass} />
     }
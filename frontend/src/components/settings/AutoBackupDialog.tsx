@@ -0,0 +1,193 @@
+import { useCallback, useEffect, useState } from 'react'
+import { Button } from '@/components/ui/button'
+import { Badge } from '@/components/ui/badge'
+import { ScrollArea } from '@/components/ui/scroll-area'
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from '@/components/ui/dialog'
+import { Database, FileCog, Play, ShieldCheck } from 'lucide-react'
+import {
+  ListAutomaticBackups,
+  RunAutomaticBackup,
+  VerifyAutomaticBackup,
+  RestoreAutomaticBackup,
+} from '../../../wailsjs/go/main/App'
+import { main } from '../../../wailsjs/go/models'
+import { useTranslation } from '@/i18n'
+
+interface AutoBackupDialogProps {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  onRestored: () => void
+}
+
+const formatBytes = (bytes: number) => {
+  if (bytes < 1024) return `${bytes} B`
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`
+  if (bytes < 1024 * 1024 * 1024) return `${(bytes / 1024 / 1024).toFixed(1)} MB`
+  return `${(bytes / 1024 / 1024 / 1024).toFixed(2)} GB`
+}
+
+export function AutoBackupDialog({ open, onOpenChange, onRestored }: AutoBackupDialogProps) {
+  const { t } = useTranslation()
+  const [list, setList] = useState<main.AutoBackupList | null>(null)
+  const [verified, setVerified] = useState<Record<string, string>>({})
+  const [isBusy, setIsBusy] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+  const [message, setMessage] = useState<string | null>(null)
+
+  const load = useCallback(async () => {
+    try {
+      setList(await ListAutomaticBackups())
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err))
+    }
+  }, [])
+
+  useEffect(() => {
+    if (open) {
+      setVerified({})
+      setError(null)
+      setMessage(null)
+      load()
+    }
+  }, [open, load])
+
+  const run = async (action: () => Promise<void>) => {
+    setIsBusy(true)
+    setError(null)
+    setMessage(null)
+    try {
+      await action()
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err))
+    } finally {
+      setIsBusy(false)
+    }
+  }
+
+  const handleRun = () =>
+    run(async () => {
+      await RunAutomaticBackup()
+      await load()
+    })
+
+  const handleVerify = (name: string) =>
+    run(async () => {
+      try {
+        await VerifyAutomaticBackup(name)
+        setVerified((prev) => ({ ...prev, [name]: 'ok' }))
+      } catch (err) {
+        setVerified((prev) => ({ ...prev, [name]: 'failed' }))
+        throw err
+      }
+    })
+
+  const handleRestore = (name: string, mode: string) =>
+    run(async () => {
+      const restored = await RestoreAutomaticBackup(name, mode)
+      if (restored) {
+        setMessage(t('settings.data.autoBackup.restored'))
+        onRestored()
+        await load()
+      }
+    })
+
+  const backups = list?.backups || []
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-3xl">
+        <DialogHeader>
+          <DialogTitle>{t('settings.data.autoBackup.list')}</DialogTitle>
+          <DialogDescription>{t('settings.data.autoBackup.listHint')}</DialogDescription>
+        </DialogHeader>
+
+        <div className="py-2 space-y-3">
+          {list && <p className="text-xs text-muted-foreground font-mono">{list.dir}</p>}
+
+          <ScrollArea className="h-80 rounded-md border">
+            {backups.length === 0 ? (
+              <p className="p-4 text-sm text-muted-foreground">{t('settings.data.autoBackup.empty')}</p>
+            ) : (
+              <div className="divide-y">
+                {backups.map((backup) => (
+                  <div key={backup.name} className="px-3 py-2 flex items-center justify-between gap-3">
+                    <div className="min-w-0 space-y-1">
+                      <div className="flex items-center gap-2 text-sm">
+                        <span className="font-medium">
+                          {backup.problem ? backup.name : new Date(backup.created).toLocaleString()}
+                        </span>
+                        {backup.reason && (
+                          <Badge variant="secondary">
+                            {t(`settings.data.autoBackup.reasons.${backup.reason}`)}
+                          </Badge>
+                        )}
+                        {verified[backup.name] === 'ok' && (
+                          <Badge variant="success">{t('settings.data.autoBackup.verified')}</Badge>
+                        )}
+                        {(backup.problem || verified[backup.name] === 'failed') && (
+                          <Badge variant="destructive">{t('settings.data.autoBackup.damaged')}</Badge>
+                        )}
+                      </div>
+                      <div className="text-xs text-muted-foreground truncate">
+                        {backup.problem || formatBytes(backup.size)}
+                      </div>
+                    </div>
+                    <div className="flex gap-1 shrink-0">
+                      <Button
+                        variant="ghost"
+                        size="sm"
+                        title={t('settings.data.autoBackup.verify')}
+                        onClick={() => handleVerify(backup.name)}
+                        disabled={isBusy || !!backup.problem}
+                      >
+                        <ShieldCheck className="h-4 w-4" />
+                      </Button>
+                      <Button
+                        variant="outline"
+                        size="sm"
+                        onClick={() => handleRestore(backup.name, 'configuration')}
+                        disabled={isBusy || !backup.has_configuration}
+                      >
+                        <FileCog className="h-4 w-4 mr-1" />
+                        {t('settings.data.autoBackup.restoreConfiguration')}
+                      </Button>
+                      <Button
+                        variant="outline"
+                        size="sm"
+                        onClick={() => handleRestore(backup.name, 'database')}
+                        disabled={isBusy || !backup.has_database}
+                      >
+                        <Database className="h-4 w-4 mr-1" />
+                        {t('settings.data.autoBackup.restoreDatabase')}
+                      </Button>
+                    </div>
+                  </div>
+                ))}
+              </div>
+            )}
+          </ScrollArea>
+
+          {message && <p className="text-sm text-green-500">{message}</p>}
+          {error && <p className="text-sm text-destructive">{error}</p>}
+        </div>
+
+        <DialogFooter>
+          <Button variant="outline" onClick={() => onOpenChange(false)}>
+            {t('common.close')}
+          </Button>
+          <Button onClick={handleRun} disabled={isBusy}>
+            <Play className="h-4 w-4 mr-2" />
+            {t('settings.data.autoBackup.runNow')}
+          </Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
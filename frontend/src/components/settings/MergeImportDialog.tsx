@@ -0,0 +1,274 @@
+import { useState } from 'react'
+import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Badge } from '@/components/ui/badge'
+import { Checkbox } from '@/components/ui/checkbox'
+import { ScrollArea } from '@/components/ui/scroll-area'
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from '@/components/ui/dialog'
+import {
+  SelectConfigurationBackup,
+  MergeConfiguration,
+} from '../../../wailsjs/go/main/App'
+import { main } from '../../../wailsjs/go/models'
+import { useTranslation } from '@/i18n'
+
+const sections = ['credentials', 'devices', 'schemas', 'assets', 'settings'] as const
+
+interface MergeImportDialogProps {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  onMerged: () => void
+}
+
+export function MergeImportDialog({ open, onOpenChange, onMerged }: MergeImportDialogProps) {
+  const { t } = useTranslation()
+  const [passphrase, setPassphrase] = useState('')
+  const [backup, setBackup] = useState<main.ConfigurationBackupInfo | null>(null)
+  const [conflict, setConflict] = useState('skip')
+  const [selected, setSelected] = useState<string[]>([...sections])
+  const [schemaIds, setSchemaIds] = useState<number[]>([])
+  const [report, setReport] = useState<main.ConfigurationMergeReport | null>(null)
+  const [isBusy, setIsBusy] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  const reset = () => {
+    setPassphrase('')
+    setBackup(null)
+    setConflict('skip')
+    setSelected([...sections])
+    setSchemaIds([])
+    setReport(null)
+    setError(null)
+  }
+
+  const close = (value: boolean) => {
+    if (!value) reset()
+    onOpenChange(value)
+  }
+
+  const run = async (action: () => Promise<void>) => {
+    setIsBusy(true)
+    setError(null)
+    try {
+      await action()
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err))
+    } finally {
+      setIsBusy(false)
+    }
+  }
+
+  const handleSelectFile = () =>
+    run(async () => {
+      const info = await SelectConfigurationBackup(passphrase)
+      if (info) {
+        setBackup(info)
+        setSchemaIds((info.schemas || []).map((s) => s.id))
+        setReport(null)
+      }
+    })
+
+  const merge = (dryRun: boolean) =>
+    run(async () => {
+      if (!backup) return
+      const result = await MergeConfiguration(
+        main.ConfigurationMergeRequest.createFrom({
+          path: backup.path,
+          passphrase,
+          conflict,
+          sections: selected,
+          schema_ids: schemaIds,
+          dry_run: dryRun,
+        })
+      )
+      setReport(result)
+      if (!dryRun) {
+        onMerged()
+      }
+    })
+
+  const toggleSection = (section: string, checked: boolean) => {
+    setSelected((prev) => (checked ? [...prev, section] : prev.filter((s) => s !== section)))
+    setReport(null)
+  }
+
+  const toggleSchema = (id: number, checked: boolean) => {
+    setSchemaIds((prev) => (checked ? [...prev, id] : prev.filter((s) => s !== id)))
+    setReport(null)
+  }
+
+  const actionVariant = (action: string) => {
+    switch (action) {
+      case 'create':
+        return 'default'
+      case 'skip':
+        return 'secondary'
+      default:
+        return 'outline'
+    }
+  }
+
+  const schemas = backup?.schemas || []
+  const applied = report !== null && !report.dry_run
+
+  return (
+    <Dialog open={open} onOpenChange={close}>
+      <DialogContent className="max-w-2xl">
+        <DialogHeader>
+          <DialogTitle>{t('settings.data.merge')}</DialogTitle>
+          <DialogDescription>{t('settings.data.mergeHint')}</DialogDescription>
+        </DialogHeader>
+
+        <div className="py-2 space-y-4">
+          {!backup ? (
+            <div className="space-y-2">
+              <Label htmlFor="merge-passphrase">{t('settings.data.backupPassphrase')}</Label>
+              <Input
+                id="merge-passphrase"
+                type="password"
+                value={passphrase}
+                onChange={(e) => setPassphrase(e.target.value)}
+                autoComplete="off"
+                autoFocus
+              />
+              <p className="text-xs text-muted-foreground">{t('settings.data.importHint')}</p>
+            </div>
+          ) : (
+            <>
+              <div className="text-sm text-muted-foreground">
+                {backup.file_name} · {t('settings.data.sections.devices')}: {backup.devices},{' '}
+                {t('settings.data.sections.credentials')}: {backup.credentials},{' '}
+                {t('settings.data.sections.schemas')}: {schemas.length}
+              </div>
+
+              <div className="space-y-2">
+                <Label>{t('settings.data.mergeConflict')}</Label>
+                <Select
+                  value={conflict}
+                  onValueChange={(v) => {
+                    setConflict(v)
+                    setReport(null)
+                  }}
+                  disabled={applied}
+                >
+                  <SelectTrigger>
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="skip">{t('settings.data.conflictSkip')}</SelectItem>
+                    <SelectItem value="overwrite">{t('settings.data.conflictOverwrite')}</SelectItem>
+                    <SelectItem value="duplicate">{t('settings.data.conflictDuplicate')}</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+
+              <div className="space-y-2">
+                <Label>{t('settings.data.mergeSections')}</Label>
+                <div className="flex flex-wrap gap-4">
+                  {sections.map((section) => (
+                    <label key={section} className="flex items-center gap-2 text-sm">
+                      <Checkbox
+                        checked={selected.includes(section)}
+                        onCheckedChange={(checked) => toggleSection(section, checked === true)}
+                        disabled={applied}
+                      />
+                      {t(`settings.data.sections.${section}`)}
+                    </label>
+                  ))}
+                </div>
+              </div>
+
+              {selected.includes('schemas') && schemas.length > 0 && (
+                <div className="space-y-2">
+                  <Label>{t('settings.data.mergeSchemas')}</Label>
+                  <div className="flex flex-wrap gap-4">
+                    {schemas.map((schema) => (
+                      <label key={schema.id} className="flex items-center gap-2 text-sm">
+                        <Checkbox
+                          checked={schemaIds.includes(schema.id)}
+                          onCheckedChange={(checked) => toggleSchema(schema.id, checked === true)}
+                          disabled={applied}
+                        />
+                        {schema.name}
+                      </label>
+                    ))}
+                  </div>
+                </div>
+              )}
+
+              {report && (
+                <div className="space-y-2">
+                  <div className="text-sm font-medium">{report.message}</div>
+                  <ScrollArea className="h-64 rounded-md border">
+                    <div className="divide-y">
+                      {(report.items || []).map((item, index) => (
+                        <div key={index} className="px-3 py-2 text-sm space-y-1">
+                          <div className="flex items-center gap-2">
+                            <Badge variant={actionVariant(item.action)}>
+                              {t(`settings.data.actions.${item.action}`)}
+                            </Badge>
+                            <span className="text-muted-foreground">
+                              {t(`settings.data.kinds.${item.kind}`)}
+                            </span>
+                            <span className="font-medium truncate">{item.name}</span>
+                          </div>
+                          {item.reason && (
+                            <div className="text-xs text-muted-foreground">{item.reason}</div>
+                          )}
+                          {(item.changes || []).map((change) => (
+                            <div key={change.field} className="text-xs font-mono text-muted-foreground">
+                              {change.field}: {String(change.before ?? '')} → {String(change.after ?? '')}
+                            </div>
+                          ))}
+                        </div>
+                      ))}
+                    </div>
+                  </ScrollArea>
+                </div>
+              )}
+            </>
+          )}
+
+          {error && <p className="text-sm text-destructive">{error}</p>}
+        </div>
+
+        <DialogFooter>
+          <Button variant="outline" onClick={() => close(false)}>
+            {applied ? t('common.close') : t('common.cancel')}
+          </Button>
+          {!backup ? (
+            <Button onClick={handleSelectFile} disabled={isBusy}>
+              {t('settings.data.chooseFile')}
+            </Button>
+          ) : (
+            !applied && (
+              <>
+                <Button variant="outline" onClick={() => merge(true)} disabled={isBusy || selected.length === 0}>
+                  {t('settings.data.preview')}
+                </Button>
+                <Button onClick={() => merge(false)} disabled={isBusy || !report}>
+                  {t('settings.data.apply')}
+                </Button>
+              </>
+            )
+          )}
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
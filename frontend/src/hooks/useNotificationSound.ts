@@ -6,6 +6,7 @@ interface DeviceStatusEvent {
   device_id: number
   old_status: string
   new_status: string
+  notify?: boolean // false when group settings mute the change
 }
 
 interface SoundSettings {
@@ -101,6 +102,8 @@ export function useNotificationSound() {
   // Listen for device status changes
   useEffect(() => {
     const handleStatusChange = (event: DeviceStatusEvent) => {
+      if (event.notify === false) return
+
       if (event.new_status === 'online' && event.old_status !== 'online') {
         playSound('online')
       } else if (event.new_status === 'offline' && event.old_status !== 'offline') {
@@ -114,5 +117,17 @@ export function useNotificationSound() {
     }
   }, [playSound])
 
+  // Repeat the alert for incidents nobody has acknowledged yet
+  useEffect(() => {
+    const handleReminder = () => {
+      playSound('offline')
+    }
+
+    EventsOn('incident:reminder', handleReminder)
+    return () => {
+      EventsOff('incident:reminder')
+    }
+  }, [playSound])
+
   return { playSound, loadSettings }
 }
@@ -210,6 +210,7 @@ export function DevicesPage({ filterType }: DevicesPageProps) {
           formData.snmp_version = fullDevice.switch.snmp_version
           formData.port_count = fullDevice.switch.port_count
           formData.sfp_port_count = fullDevice.switch.sfp_port_count || 0
+          formData.poe_budget_w = fullDevice.switch.poe_budget_w || 0
           // SNMPv3 fields
           formData.snmpv3_user = fullDevice.switch.snmpv3_user || ''
           formData.snmpv3_security = fullDevice.switch.snmpv3_security || 'noAuthNoPriv'
@@ -233,6 +234,27 @@ export function DevicesPage({ filterType }: DevicesPageProps) {
           formData.tcp_ports = fullDevice.server.tcp_ports
           formData.use_snmp = fullDevice.server.use_snmp
         }
+        if (fullDevice.snmp) {
+          formData.snmp_community = fullDevice.snmp.snmp_community
+          formData.snmp_version = fullDevice.snmp.snmp_version
+          formData.snmpv3_user = fullDevice.snmp.snmpv3_user || ''
+          formData.snmpv3_security = fullDevice.snmp.snmpv3_security || 'noAuthNoPriv'
+          formData.snmpv3_auth_proto = fullDevice.snmp.snmpv3_auth_proto || ''
+          formData.snmpv3_auth_pass = fullDevice.snmp.snmpv3_auth_pass || ''
+          formData.snmpv3_priv_proto = fullDevice.snmp.snmpv3_priv_proto || ''
+          formData.snmpv3_priv_pass = fullDevice.snmp.snmpv3_priv_pass || ''
+          formData.uplink_switch_id = fullDevice.snmp.uplink_switch_id
+          formData.uplink_port_id = fullDevice.snmp.uplink_port_id
+        }
+        if (fullDevice.nvr) {
+          formData.check_http = fullDevice.nvr.check_http
+          formData.http_port = fullDevice.nvr.http_port
+        }
+        if (fullDevice.ups) {
+          formData.ups_protocol = fullDevice.ups.protocol
+          formData.nut_port = fullDevice.ups.nut_port
+          formData.nut_ups_name = fullDevice.ups.nut_ups_name
+        }
 
         setEditingDevice(formData as Partial<Device>)
       } else {
@@ -258,6 +280,7 @@ export function DevicesPage({ filterType }: DevicesPageProps) {
     snmp_version: string
     port_count: number
     sfp_port_count: number
+    poe_budget_w: number
     // SNMPv3 settings
     snmpv3_user: string
     snmpv3_security: string
@@ -272,6 +295,13 @@ export function DevicesPage({ filterType }: DevicesPageProps) {
     switch_port_id?: number
     tcp_ports: string
     use_snmp: boolean
+    uplink_switch_id?: number
+    uplink_port_id?: number
+    check_http?: boolean
+    http_port?: number
+    ups_protocol?: string
+    nut_port?: number
+    nut_ups_name?: string
   }
 
   const handleViewDetails = (device: Device) => {
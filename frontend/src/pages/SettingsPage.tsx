@@ -11,6 +11,7 @@ import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
 import { Separator } from '@/components/ui/separator'
 import { Switch } from '@/components/ui/switch'
+import { Checkbox } from '@/components/ui/checkbox'
 import {
   Select,
   SelectContent,
@@ -47,11 +48,16 @@ import {
   Power,
   Play,
   Languages,
+  GitMerge,
+  ShieldCheck,
+  History,
 } from 'lucide-react'
 import {
   GetAppSettings,
   SaveAppSettings,
   ExportConfiguration,
+  ExportConfigurationPlain,
+  VerifyConfigurationBackup,
   ImportConfiguration,
   GetDataPath,
   OpenDataFolder,
@@ -64,8 +70,11 @@ import {
   SetAutostartEnabled,
   GetMinimizeToTray,
   SetMinimizeToTray,
+  SelectAutoBackupDirectory,
 } from '../../wailsjs/go/main/App'
 import { main } from '../../wailsjs/go/models'
+import { MergeImportDialog } from '@/components/settings/MergeImportDialog'
+import { AutoBackupDialog } from '@/components/settings/AutoBackupDialog'
 import { useTheme } from '@/hooks/useTheme'
 import { useTranslation } from '@/i18n'
 import { changeLanguage, languages } from '@/i18n'
@@ -130,6 +139,15 @@ export function SettingsPage() {
 
   // Clear data dialog
   const [clearDataDialogOpen, setClearDataDialogOpen] = useState(false)
+
+  // Backup passphrase dialog
+  const [backupDialog, setBackupDialog] = useState<'export' | 'import' | null>(null)
+  const [mergeDialogOpen, setMergeDialogOpen] = useState(false)
+  const [autoBackupDialogOpen, setAutoBackupDialogOpen] = useState(false)
+  const [backupPassphrase, setBackupPassphrase] = useState('')
+  const [backupConfirm, setBackupConfirm] = useState('')
+  const [backupHistory, setBackupHistory] = useState(false)
+  const [verifyMessage, setVerifyMessage] = useState<string | null>(null)
   const [daysToKeep, setDaysToKeep] = useState(30)
 
   // Autostart
@@ -224,27 +242,73 @@ export function SettingsPage() {
     }
   }
 
-  const handleExport = async () => {
+  const openBackupDialog = (mode: 'export' | 'import') => {
+    setBackupPassphrase('')
+    setBackupConfirm('')
+    setBackupHistory(false)
+    setBackupDialog(mode)
+  }
+
+  const handleExport = async (encrypted: boolean) => {
+    if (encrypted && backupPassphrase !== backupConfirm) {
+      setError(t('auth.passwordMismatch'))
+      return
+    }
     try {
-      const path = await ExportConfiguration()
+      const path = encrypted
+        ? await ExportConfiguration(backupPassphrase, backupHistory)
+        : await ExportConfigurationPlain(backupHistory)
+      setBackupDialog(null)
+      setBackupPassphrase('')
+      setBackupConfirm('')
       if (path) {
+        setError(null)
         setSaveSuccess(true)
         setTimeout(() => setSaveSuccess(false), 3000)
       }
     } catch (err) {
-      setError(err instanceof Error ? err.message : 'Ошибка экспорта')
+      setError(err instanceof Error ? err.message : String(err))
+    }
+  }
+
+  const handleVerifyBackup = async () => {
+    try {
+      const report = await VerifyConfigurationBackup(true)
+      if (report.ok) {
+        setError(null)
+        setVerifyMessage(`${t('settings.data.verifyOk')}: ${report.tables} / ${report.rows}`)
+        setTimeout(() => setVerifyMessage(null), 5000)
+      } else {
+        setError(`${t('settings.data.verifyFailed')}: ${report.problems.join('; ')}`)
+      }
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err))
     }
   }
 
   const handleImport = async () => {
     try {
-      const success = await ImportConfiguration()
+      const success = await ImportConfiguration(backupPassphrase)
+      setBackupDialog(null)
+      setBackupPassphrase('')
       if (success) {
+        setError(null)
         loadSettings()
         loadCredentials()
       }
     } catch (err) {
-      setError(err instanceof Error ? err.message : 'Ошибка импорта')
+      setError(err instanceof Error ? err.message : String(err))
+    }
+  }
+
+  const handleSelectAutoBackupDir = async () => {
+    try {
+      const dir = await SelectAutoBackupDirectory()
+      if (dir) {
+        updateSetting('auto_backup_dir', dir)
+      }
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err))
     }
   }
 
@@ -326,6 +390,12 @@ export function SettingsPage() {
           <span>{t('settings.saved')}</span>
         </div>
       )}
+      {verifyMessage && (
+        <div className="flex items-center gap-2 p-4 text-green-500 bg-green-500/10 rounded-lg">
+          <CheckCircle className="h-5 w-5" />
+          <span>{verifyMessage}</span>
+        </div>
+      )}
 
       {/* Interface Settings */}
       <Card>
@@ -575,6 +645,99 @@ export function SettingsPage() {
               />
             </div>
           </div>
+          <Separator />
+          <div>
+            <p className="font-medium">{t('settings.monitoring.switchLimits')}</p>
+            <p className="text-sm text-muted-foreground">
+              {t('settings.monitoring.switchLimitsHint')}
+            </p>
+          </div>
+          <div className="grid grid-cols-2 gap-4">
+            <div className="space-y-2">
+              <Label>{t('settings.monitoring.temperatureLimit')}</Label>
+              <Input
+                type="number"
+                min={0}
+                max={150}
+                value={settings.switch_temperature_limit}
+                onChange={(e) =>
+                  updateSetting('switch_temperature_limit', parseInt(e.target.value) || 0)
+                }
+              />
+            </div>
+            <div className="space-y-2">
+              <Label>{t('settings.monitoring.poeBudgetLimit')}</Label>
+              <Input
+                type="number"
+                min={0}
+                max={100}
+                value={settings.poe_budget_limit}
+                onChange={(e) =>
+                  updateSetting('poe_budget_limit', parseInt(e.target.value) || 0)
+                }
+              />
+            </div>
+            <div className="space-y-2">
+              <Label>{t('settings.monitoring.cpuLimit')}</Label>
+              <Input
+                type="number"
+                min={0}
+                max={100}
+                value={settings.switch_cpu_limit}
+                onChange={(e) =>
+                  updateSetting('switch_cpu_limit', parseInt(e.target.value) || 0)
+                }
+              />
+            </div>
+            <div className="space-y-2">
+              <Label>{t('settings.monitoring.memoryLimit')}</Label>
+              <Input
+                type="number"
+                min={0}
+                max={100}
+                value={settings.switch_memory_limit}
+                onChange={(e) =>
+                  updateSetting('switch_memory_limit', parseInt(e.target.value) || 0)
+                }
+              />
+            </div>
+            <div className="space-y-2">
+              <Label>{t('settings.monitoring.poePowerDrop')}</Label>
+              <Input
+                type="number"
+                min={0}
+                max={100}
+                value={settings.poe_power_drop_percent}
+                onChange={(e) =>
+                  updateSetting('poe_power_drop_percent', parseInt(e.target.value) || 0)
+                }
+              />
+            </div>
+            <div className="space-y-2">
+              <Label>{t('settings.monitoring.poePowerSpike')}</Label>
+              <Input
+                type="number"
+                min={0}
+                max={100}
+                value={settings.poe_power_spike_percent}
+                onChange={(e) =>
+                  updateSetting('poe_power_spike_percent', parseInt(e.target.value) || 0)
+                }
+              />
+            </div>
+            <div className="space-y-2">
+              <Label>{t('settings.monitoring.portFlapLimit')}</Label>
+              <Input
+                type="number"
+                min={0}
+                max={100}
+                value={settings.port_flap_limit}
+                onChange={(e) =>
+                  updateSetting('port_flap_limit', parseInt(e.target.value) || 0)
+                }
+              />
+            </div>
+          </div>
         </CardContent>
       </Card>
 
@@ -736,15 +899,111 @@ export function SettingsPage() {
             </Select>
           </div>
           <Separator />
+          <div className="flex items-center justify-between">
+            <div>
+              <p className="font-medium">{t('settings.data.autoBackup.title')}</p>
+              <p className="text-sm text-muted-foreground">
+                {t('settings.data.autoBackup.hint')}
+              </p>
+            </div>
+            <Switch
+              checked={settings.auto_backup_enabled}
+              onCheckedChange={(v) => updateSetting('auto_backup_enabled', v)}
+            />
+          </div>
+          {settings.auto_backup_enabled && (
+            <div className="grid gap-4 md:grid-cols-4">
+              <div className="space-y-2">
+                <Label>{t('settings.data.autoBackup.interval')}</Label>
+                <Select
+                  value={settings.auto_backup_interval.toString()}
+                  onValueChange={(v) => updateSetting('auto_backup_interval', parseInt(v))}
+                >
+                  <SelectTrigger>
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="1">1 {t('settings.data.autoBackup.hours')}</SelectItem>
+                    <SelectItem value="6">6 {t('settings.data.autoBackup.hours')}</SelectItem>
+                    <SelectItem value="12">12 {t('settings.data.autoBackup.hours')}</SelectItem>
+                    <SelectItem value="24">24 {t('settings.data.autoBackup.hours')}</SelectItem>
+                    <SelectItem value="168">168 {t('settings.data.autoBackup.hours')}</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+              <div className="space-y-2">
+                <Label>{t('settings.data.autoBackup.keepLast')}</Label>
+                <Input
+                  type="number"
+                  min={1}
+                  max={100}
+                  value={settings.auto_backup_keep_last}
+                  onChange={(e) => updateSetting('auto_backup_keep_last', parseInt(e.target.value) || 1)}
+                />
+              </div>
+              <div className="space-y-2">
+                <Label>{t('settings.data.autoBackup.keepDaily')}</Label>
+                <Input
+                  type="number"
+                  min={0}
+                  max={365}
+                  value={settings.auto_backup_keep_daily}
+                  onChange={(e) => updateSetting('auto_backup_keep_daily', parseInt(e.target.value) || 0)}
+                />
+              </div>
+              <div className="space-y-2">
+                <Label>{t('settings.data.autoBackup.keepWeekly')}</Label>
+                <Input
+                  type="number"
+                  min={0}
+                  max={104}
+                  value={settings.auto_backup_keep_weekly}
+                  onChange={(e) => updateSetting('auto_backup_keep_weekly', parseInt(e.target.value) || 0)}
+                />
+              </div>
+            </div>
+          )}
+          <div className="flex items-center justify-between gap-4">
+            <div className="min-w-0">
+              <p className="font-medium">{t('settings.data.autoBackup.directory')}</p>
+              <p className="text-sm text-muted-foreground font-mono truncate">
+                {settings.auto_backup_dir || t('settings.data.autoBackup.defaultDirectory')}
+              </p>
+            </div>
+            <div className="flex gap-2 shrink-0">
+              {settings.auto_backup_dir && (
+                <Button variant="ghost" size="sm" onClick={() => updateSetting('auto_backup_dir', '')}>
+                  {t('settings.data.autoBackup.resetDirectory')}
+                </Button>
+              )}
+              <Button variant="outline" size="sm" onClick={handleSelectAutoBackupDir}>
+                <FolderOpen className="h-4 w-4 mr-2" />
+                {t('settings.data.autoBackup.chooseDirectory')}
+              </Button>
+              <Button variant="outline" size="sm" onClick={() => setAutoBackupDialogOpen(true)}>
+                <History className="h-4 w-4 mr-2" />
+                {t('settings.data.autoBackup.list')}
+              </Button>
+            </div>
+          </div>
+          <Separator />
           <div className="flex gap-4">
-            <Button variant="outline" onClick={handleExport}>
+            <Button variant="outline" onClick={() => openBackupDialog('export')}>
               <Download className="h-4 w-4 mr-2" />
               {t('settings.data.export')}
             </Button>
-            <Button variant="outline" onClick={handleImport}>
+            <Button variant="outline" onClick={() => openBackupDialog('import')}>
               <Upload className="h-4 w-4 mr-2" />
               {t('settings.data.import')}
             </Button>
+            <Button variant="outline" onClick={() => setMergeDialogOpen(true)}>
+              <GitMerge className="h-4 w-4 mr-2" />
+              {t('settings.data.merge')}
+            </Button>
+            <Button variant="outline" onClick={handleVerifyBackup}>
+              <ShieldCheck className="h-4 w-4 mr-2" />
+              {t('settings.data.verify')}
+            </Button>
             <Button
               variant="outline"
               onClick={() => setClearDataDialogOpen(true)}
@@ -874,6 +1133,88 @@ export function SettingsPage() {
       </Dialog>
 
       {/* Clear Data Dialog */}
+      <Dialog open={backupDialog !== null} onOpenChange={(open) => !open && setBackupDialog(null)}>
+        <DialogContent>
+          <DialogHeader>
+            <DialogTitle>
+              {backupDialog === 'export' ? t('settings.data.export') : t('settings.data.import')}
+            </DialogTitle>
+            <DialogDescription>
+              {backupDialog === 'export' ? t('settings.data.exportHint') : t('settings.data.importHint')}
+            </DialogDescription>
+          </DialogHeader>
+          <div className="py-4 space-y-4">
+            <div className="space-y-2">
+              <Label htmlFor="backup-passphrase">{t('settings.data.backupPassphrase')}</Label>
+              <Input
+                id="backup-passphrase"
+                type="password"
+                value={backupPassphrase}
+                onChange={(e) => setBackupPassphrase(e.target.value)}
+                autoComplete="new-password"
+                autoFocus
+              />
+            </div>
+            {backupDialog === 'export' && (
+              <div className="space-y-2">
+                <Label htmlFor="backup-confirm">{t('settings.data.backupPassphraseConfirm')}</Label>
+                <Input
+                  id="backup-confirm"
+                  type="password"
+                  value={backupConfirm}
+                  onChange={(e) => setBackupConfirm(e.target.value)}
+                  autoComplete="new-password"
+                />
+              </div>
+            )}
+            {backupDialog === 'export' && (
+              <label className="flex items-center gap-2 text-sm">
+                <Checkbox
+                  checked={backupHistory}
+                  onCheckedChange={(checked) => setBackupHistory(checked === true)}
+                />
+                {t('settings.data.includeHistory')}
+              </label>
+            )}
+          </div>
+          <DialogFooter>
+            {backupDialog === 'export' && (
+              <Button variant="ghost" className="mr-auto text-destructive" onClick={() => handleExport(false)}>
+                {t('settings.data.exportPlain')}
+              </Button>
+            )}
+            <Button variant="outline" onClick={() => setBackupDialog(null)}>
+              {t('common.cancel')}
+            </Button>
+            {backupDialog === 'export' ? (
+              <Button onClick={() => handleExport(true)} disabled={!backupPassphrase}>
+                {t('settings.data.export')}
+              </Button>
+            ) : (
+              <Button onClick={handleImport}>{t('settings.data.import')}</Button>
+            )}
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+
+      <AutoBackupDialog
+        open={autoBackupDialogOpen}
+        onOpenChange={setAutoBackupDialogOpen}
+        onRestored={() => {
+          loadSettings()
+          loadCredentials()
+        }}
+      />
+
+      <MergeImportDialog
+        open={mergeDialogOpen}
+        onOpenChange={setMergeDialogOpen}
+        onMerged={() => {
+          loadSettings()
+          loadCredentials()
+        }}
+      />
+
       <Dialog open={clearDataDialogOpen} onOpenChange={setClearDataDialogOpen}>
         <DialogContent>
           <DialogHeader>
@@ -127,3 +127,27 @@ export interface AppSettings {
   snmp_timeout: number
   camera_stream_type: string
 }
+
+// Auth types
+export type UserRole = 'viewer' | 'operator' | 'admin'
+
+export interface User {
+  id: number
+  username: string
+  display_name: string
+  role: UserRole
+  disabled: boolean
+  last_login_at?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface SessionInfo {
+  key_locked: boolean
+  setup_required: boolean
+  logged_in: boolean
+  locked: boolean
+  user?: User
+  timeout_minutes: number
+  expires_at?: string
+}
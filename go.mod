@@ -8,6 +8,7 @@ require (
 	github.com/gosnmp/gosnmp v1.42.1
 	github.com/prometheus-community/pro-bing v0.7.0
 	github.com/wailsapp/wails/v2 v2.11.0
+	golang.org/x/crypto v0.36.0
 	golang.org/x/image v0.33.0
 	golang.org/x/sys v0.36.0
 	modernc.org/sqlite v1.40.1
@@ -40,7 +41,6 @@ require (
 	github.com/valyala/fasttemplate v1.2.2 // indirect
 	github.com/wailsapp/go-webview2 v1.0.22 // indirect
 	github.com/wailsapp/mimetype v1.4.1 // indirect
-	golang.org/x/crypto v0.36.0 // indirect
 	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
 	golang.org/x/net v0.38.0 // indirect
 	golang.org/x/sync v0.18.0 // indirect
@@ -0,0 +1,267 @@
+// Package audit computes field-level diffs and the hash chain of the audit log.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// Masked replaces values of secret fields in diffs
+const Masked = "***"
+
+// secretMarkers identify fields whose values must not be written to the log
+var secretMarkers = []string{"password", "pass", "community", "secret", "token", "rtsp_url"}
+
+// ignoredFields change on their own and carry no information about the action
+var ignoredFields = map[string]bool{"updated_at": true, "last_check": true}
+
+// Diff returns the fields that differ between two states. States are compared by
+// their JSON representation, nested objects are flattened into dotted paths.
+// Either state may be nil, e.g. for created or deleted objects.
+func Diff(before, after interface{}) ([]models.AuditChange, error) {
+	b, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(b)+len(a))
+	for k := range b {
+		keys[k] = true
+	}
+	for k := range a {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	changes := []models.AuditChange{}
+	for _, k := range sorted {
+		bv, bok := b[k]
+		av, aok := a[k]
+		if bok && aok && reflect.DeepEqual(bv, av) {
+			continue
+		}
+		if IsSecret(k) {
+			bv, av = mask(bv), mask(av)
+		}
+		changes = append(changes, models.AuditChange{Field: k, Before: bv, After: av})
+	}
+	return changes, nil
+}
+
+// IsSecret reports whether a field holds a password or another secret
+func IsSecret(field string) bool {
+	name := strings.ToLower(field)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, marker := range secretMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskDetails replaces secret values of action parameters, including those of
+// nested objects such as credentials or SNMPv3 settings
+func MaskDetails(details map[string]interface{}) map[string]interface{} {
+	for k, v := range details {
+		if IsSecret(k) {
+			details[k] = mask(v)
+		} else {
+			details[k] = maskNested(v)
+		}
+	}
+	return details
+}
+
+// maskNested masks secret fields of objects within a value. Structs and typed
+// maps or slices are converted to their JSON representation first.
+func maskNested(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		return MaskDetails(val)
+	case []interface{}:
+		for i, child := range val {
+			val[i] = maskNested(child)
+		}
+		return val
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return v
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return v
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return v
+	}
+	switch generic.(type) {
+	case map[string]interface{}, []interface{}:
+		return maskNested(generic)
+	}
+	return generic
+}
+
+func mask(v interface{}) interface{} {
+	if v == nil || v == "" {
+		return v
+	}
+	return Masked
+}
+
+// flatten converts a value to a map of dotted paths to JSON scalars or arrays
+func flatten(v interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return result, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit state: %w", err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal audit state: %w", err)
+	}
+
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		if obj, ok := v.(map[string]interface{}); ok {
+			for k, child := range obj {
+				path := k
+				if prefix != "" {
+					path = prefix + "." + k
+				}
+				walk(path, child)
+			}
+			return
+		}
+		if prefix == "" {
+			prefix = "value"
+		}
+		if ignoredFields[prefix[strings.LastIndex(prefix, ".")+1:]] {
+			return
+		}
+		result[prefix] = v
+	}
+	walk("", generic)
+	return result, nil
+}
+
+// Hash computes the chain hash of an entry from its content and the hash of the
+// previous entry
+func Hash(e *models.AuditEntry) (string, error) {
+	changes, err := canonicalJSON(e.Changes)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit changes: %w", err)
+	}
+	details, err := canonicalJSON(e.Details)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+	var targetID string
+	if e.Target.ID != nil {
+		targetID = fmt.Sprint(*e.Target.ID)
+	}
+
+	h := sha256.New()
+	for _, part := range []string{
+		e.PrevHash,
+		e.Timestamp.UTC().Format(time.RFC3339Nano),
+		e.Actor,
+		e.Action,
+		e.Target.Type,
+		targetID,
+		e.Target.Name,
+		string(changes),
+		string(details),
+		string(e.Result),
+		e.Error,
+	} {
+		// Length prefixes keep field boundaries unambiguous
+		fmt.Fprintf(h, "%d:%s|", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalJSON encodes a value the same way before and after it was stored:
+// structs become objects with sorted keys and numbers lose their Go type
+func canonicalJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return "", err
+	}
+	data, err = json.Marshal(generic)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Verify checks that entries, ordered by ID, form an unbroken chain starting at
+// anchor, the hash of the last entry removed by retention ("" if none was removed)
+func Verify(entries []models.AuditEntry, anchor string) models.AuditVerification {
+	result := models.AuditVerification{Valid: true}
+	prev := anchor
+	for i := range entries {
+		e := &entries[i]
+		result.Checked++
+
+		if e.PrevHash != prev {
+			return broken(result, e.ID, "entry does not follow the previous one")
+		}
+		hash, err := Hash(e)
+		if err != nil {
+			return broken(result, e.ID, err.Error())
+		}
+		if hash != e.Hash {
+			return broken(result, e.ID, "entry content was modified")
+		}
+		prev = e.Hash
+	}
+	return result
+}
+
+func broken(result models.AuditVerification, id int64, reason string) models.AuditVerification {
+	result.Valid = false
+	result.BrokenAt = &id
+	result.Reason = reason
+	return result
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+var (
+	ErrNotLoggedIn        = errors.New("not logged in")
+	ErrLocked             = errors.New("session is locked")
+	ErrForbidden          = errors.New("permission denied")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+)
+
+const (
+	maxLoginFailures = 5                // Failed attempts before logins are paused
+	loginPause       = 30 * time.Second // Pause after too many failed attempts
+)
+
+// Session holds the logged in user of the application. It locks after a period
+// without activity; a locked session keeps its user, but denies every action until
+// the password is entered again. Safe for concurrent use.
+type Session struct {
+	mu           sync.Mutex
+	user         *models.User
+	locked       bool
+	lastActivity time.Time
+	timeout      time.Duration
+
+	failures    int
+	pausedUntil time.Time
+}
+
+// NewSession creates a session with nobody logged in
+func NewSession() *Session {
+	return &Session{}
+}
+
+// SetTimeout sets the idle time after which the session locks, 0 to never lock
+func (s *Session) SetTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeout = timeout
+}
+
+// Start logs a user in
+func (s *Session) Start(user *models.User, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := *user
+	s.user = &u
+	s.locked = false
+	s.lastActivity = now
+	s.failures = 0
+}
+
+// End logs the current user out
+func (s *Session) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = nil
+	s.locked = false
+}
+
+// Refresh replaces the stored user after the account was changed. A disabled
+// account or one without a valid role ends the session.
+func (s *Session) Refresh(user *models.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user == nil || s.user.ID != user.ID {
+		return
+	}
+	if user.Disabled || !user.Role.Valid() {
+		s.user = nil
+		s.locked = false
+		return
+	}
+	u := *user
+	s.user = &u
+}
+
+// User returns a copy of the logged in user, nil if nobody is logged in. The user
+// of a locked session is returned as well.
+func (s *Session) User() *models.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user == nil {
+		return nil
+	}
+	u := *s.user
+	return &u
+}
+
+// Touch records user activity, postponing the lock
+func (s *Session) Touch(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user != nil && !s.locked {
+		s.lastActivity = now
+	}
+}
+
+// Lock locks the session until the password is entered again
+func (s *Session) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user != nil {
+		s.locked = true
+	}
+}
+
+// Unlock unlocks the session after the password of its user was verified
+func (s *Session) Unlock(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user != nil {
+		s.locked = false
+		s.lastActivity = now
+		s.failures = 0
+	}
+}
+
+// Expire locks the session if it has been idle longer than the timeout. Returns
+// true if the session was locked by this call.
+func (s *Session) Expire(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expire(now)
+}
+
+func (s *Session) expire(now time.Time) bool {
+	if s.user == nil || s.locked || s.timeout <= 0 {
+		return false
+	}
+	if now.Sub(s.lastActivity) < s.timeout {
+		return false
+	}
+	s.locked = true
+	return true
+}
+
+// Require returns the logged in user if the session is unlocked and the user's
+// role grants the required role
+func (s *Session) Require(role models.Role, now time.Time) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.user == nil {
+		return nil, ErrNotLoggedIn
+	}
+	s.expire(now)
+	if s.locked {
+		return nil, ErrLocked
+	}
+	if !s.user.Role.Allows(role) {
+		return nil, fmt.Errorf("%w: %s role required", ErrForbidden, role)
+	}
+	u := *s.user
+	return &u, nil
+}
+
+// Info describes the session state. SetupRequired is left to the caller.
+func (s *Session) Info(now time.Time) models.SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now)
+	info := models.SessionInfo{TimeoutMinutes: int(s.timeout / time.Minute)}
+	if s.user == nil {
+		return info
+	}
+	u := *s.user
+	info.LoggedIn = true
+	info.Locked = s.locked
+	info.User = &u
+	if s.timeout > 0 && !s.locked {
+		expires := s.lastActivity.Add(s.timeout)
+		info.ExpiresAt = &expires
+	}
+	return info
+}
+
+// AllowLogin returns an error while logins are paused after repeated failures
+func (s *Session) AllowLogin(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Before(s.pausedUntil) {
+		return fmt.Errorf("too many failed attempts, try again in %d seconds",
+			int(math.Ceil(s.pausedUntil.Sub(now).Seconds())))
+	}
+	return nil
+}
+
+// LoginFailed records a failed password check and pauses logins after too many
+func (s *Session) LoginFailed(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures++
+	if s.failures >= maxLoginFailures {
+		s.failures = 0
+		s.pausedUntil = now.Add(loginPause)
+	}
+}
@@ -0,0 +1,271 @@
+// Package backup stores automatic backups as directories with a manifest of
+// checksums, and decides which of them a rotation policy keeps.
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestName is the file describing a backup, it is not listed in itself
+const ManifestName = "manifest.json"
+
+// manifestFormat is the version of the manifest layout
+const manifestFormat = 1
+
+const (
+	namePrefix = "netvision-"
+	tempPrefix = ".tmp-"
+	nameLayout = "20060102-150405"
+)
+
+// Manifest describes the files of a backup
+type Manifest struct {
+	Format     int       `json:"format"`
+	Created    time.Time `json:"created"`
+	Reason     string    `json:"reason"` // e.g. "scheduled", "manual", "pre-restore"
+	AppVersion string    `json:"app_version"`
+	Files      []File    `json:"files"`
+}
+
+// File is a file of a backup with its checksum. Name is relative to the backup
+// directory and uses forward slashes.
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Backup is a backup directory found by List
+type Backup struct {
+	Name     string
+	Path     string
+	Manifest *Manifest // nil if the manifest is missing or unreadable
+	Size     int64
+	Problem  string // Why the manifest could not be read
+}
+
+// Created returns when the backup was made, zero if unknown
+func (b *Backup) Created() time.Time {
+	if b.Manifest == nil {
+		return time.Time{}
+	}
+	return b.Manifest.Created
+}
+
+// HasFile reports whether the manifest lists a file
+func (b *Backup) HasFile(name string) bool {
+	if b.Manifest == nil {
+		return false
+	}
+	for _, f := range b.Manifest.Files {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Create makes a new backup in root. write fills the given directory with the
+// backup files. The backup is written to a temporary directory and renamed once
+// its manifest is complete, so an interrupted backup never looks like a valid one.
+func Create(root, reason, appVersion string, write func(dir string) error) (*Backup, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	now := time.Now()
+	name := namePrefix + now.Format(nameLayout)
+	for n := 2; exists(filepath.Join(root, name)); n++ {
+		name = fmt.Sprintf("%s%s-%d", namePrefix, now.Format(nameLayout), n)
+	}
+
+	tmp := filepath.Join(root, tempPrefix+name)
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	done := false
+	defer func() {
+		if !done {
+			os.RemoveAll(tmp)
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		Format:     manifestFormat,
+		Created:    now,
+		Reason:     reason,
+		AppVersion: appVersion,
+		Files:      []File{},
+	}
+	var size int64
+	err := filepath.WalkDir(tmp, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(tmp, path)
+		if err != nil {
+			return err
+		}
+		f, err := checksum(path)
+		if err != nil {
+			return err
+		}
+		f.Name = filepath.ToSlash(rel)
+		manifest.Files = append(manifest.Files, *f)
+		size += f.Size
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum backup files: %w", err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, ManifestName), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	path := filepath.Join(root, name)
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, fmt.Errorf("failed to finish backup: %w", err)
+	}
+	done = true
+
+	return &Backup{Name: name, Path: path, Manifest: manifest, Size: size}, nil
+}
+
+// List returns the backups in root, newest first. Backups without a readable
+// manifest are listed last with the problem set. A missing root gives no backups.
+func List(root string) ([]Backup, error) {
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), namePrefix) {
+			continue
+		}
+		b := Backup{Name: entry.Name(), Path: filepath.Join(root, entry.Name())}
+		if b.Manifest, err = readManifest(b.Path); err != nil {
+			b.Problem = err.Error()
+		} else {
+			for _, f := range b.Manifest.Files {
+				b.Size += f.Size
+			}
+		}
+		backups = append(backups, b)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		ci, cj := backups[i].Created(), backups[j].Created()
+		if ci.Equal(cj) {
+			return backups[i].Name > backups[j].Name
+		}
+		return ci.After(cj)
+	})
+	return backups, nil
+}
+
+// Find returns the backup with the given name in root
+func Find(root, name string) (*Backup, error) {
+	backups, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups {
+		if backups[i].Name == name {
+			return &backups[i], nil
+		}
+	}
+	return nil, fmt.Errorf("backup %s not found", name)
+}
+
+// Verify checks that every file listed in the manifest of a backup exists with
+// the recorded size and checksum
+func Verify(path string) error {
+	manifest, err := readManifest(path)
+	if err != nil {
+		return err
+	}
+	for _, f := range manifest.Files {
+		actual, err := checksum(filepath.Join(path, filepath.FromSlash(f.Name)))
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("file %s is missing", f.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		if actual.Size != f.Size || actual.SHA256 != f.SHA256 {
+			return fmt.Errorf("file %s is damaged: checksum mismatch", f.Name)
+		}
+	}
+	return nil
+}
+
+// readManifest reads and validates the manifest of a backup directory
+func readManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(path, ManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("manifest is missing")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.Format > manifestFormat {
+		return nil, fmt.Errorf("backup was created by a newer version (manifest format %d)", manifest.Format)
+	}
+	for _, f := range manifest.Files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Name)) || f.Name == ManifestName {
+			return nil, fmt.Errorf("manifest lists invalid file %q", f.Name)
+		}
+	}
+	return &manifest, nil
+}
+
+// checksum returns the size and SHA-256 of a file
+func checksum(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
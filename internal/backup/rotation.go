@@ -0,0 +1,86 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Policy decides which backups rotation keeps. A backup is kept if any rule
+// keeps it: the KeepLast newest backups, the newest backup of each of the last
+// KeepDaily days and the newest backup of each of the last KeepWeekly weeks that
+// have backups. The newest backup is always kept.
+type Policy struct {
+	KeepLast   int
+	KeepDaily  int
+	KeepWeekly int
+}
+
+// Plan splits backups, sorted newest first as returned by List, into those the
+// policy keeps and those it removes. Backups without a manifest are neither, they
+// are left for the user to look at.
+func (p Policy) Plan(backups []Backup) (keep, remove []Backup) {
+	keepLast := max(p.KeepLast, 1)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+
+	n := 0
+	for _, b := range backups {
+		if b.Manifest == nil {
+			continue
+		}
+		created := b.Created().Local()
+		day := created.Format("2006-01-02")
+		year, week := created.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+
+		kept := n < keepLast
+		if !days[day] && len(days) < p.KeepDaily {
+			days[day] = true
+			kept = true
+		}
+		if !weeks[weekKey] && len(weeks) < p.KeepWeekly {
+			weeks[weekKey] = true
+			kept = true
+		}
+		n++
+
+		if kept {
+			keep = append(keep, b)
+		} else {
+			remove = append(remove, b)
+		}
+	}
+	return keep, remove
+}
+
+// Rotate removes the backups in root the policy does not keep, together with
+// temporary directories left by interrupted backups. Must not run while a backup
+// is being created. Returns the names of the removed backups.
+func Rotate(root string, policy Policy) ([]string, error) {
+	backups, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	_, remove := policy.Plan(backups)
+	for _, b := range remove {
+		if err := os.RemoveAll(b.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", b.Name, err)
+		}
+		removed = append(removed, b.Name)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return removed, fmt.Errorf("failed to list backups: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), tempPrefix+namePrefix) {
+			os.RemoveAll(filepath.Join(root, entry.Name()))
+		}
+	}
+	return removed, nil
+}
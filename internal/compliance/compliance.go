@@ -0,0 +1,167 @@
+package compliance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"netvisionmonitor/internal/models"
+)
+
+// Facts contains values collected from a device, keyed by compliance check
+type Facts map[string][]string
+
+// Set stores a single value for a check
+func (f Facts) Set(check, value string) {
+	f[check] = []string{value}
+}
+
+// Add appends a value for a check that has several instances (e.g. per port)
+func (f Facts) Add(check, value string) {
+	f[check] = append(f[check], value)
+}
+
+// Evaluate checks all rules against the collected facts.
+// A check with several values passes only if every value satisfies the rule.
+func Evaluate(rules []models.ComplianceRule, facts Facts) ([]models.ComplianceRuleResult, bool) {
+	results := make([]models.ComplianceRuleResult, len(rules))
+	allPassed := true
+
+	for i, rule := range rules {
+		result := models.ComplianceRuleResult{ComplianceRule: rule}
+
+		values, ok := facts[rule.Check]
+		if !ok || len(values) == 0 {
+			result.Error = "value not available"
+		} else {
+			result.Actual = strings.Join(uniqueValues(values), ", ")
+			result.Passed = true
+			for _, v := range values {
+				passed, err := Compare(v, rule.Operator, rule.Value)
+				if err != nil {
+					result.Error = err.Error()
+					result.Passed = false
+					break
+				}
+				if !passed {
+					result.Passed = false
+					break
+				}
+			}
+		}
+
+		if !result.Passed {
+			allPassed = false
+		}
+		results[i] = result
+	}
+
+	return results, allPassed
+}
+
+// Compare applies an operator to actual and expected values.
+// Numbers are compared numerically, other values as dotted versions
+// (e.g. firmware "2.3.10" > "2.3.9"); equality is case-insensitive.
+func Compare(actual string, op models.ComplianceOperator, expected string) (bool, error) {
+	actual = strings.TrimSpace(actual)
+	expected = strings.TrimSpace(expected)
+
+	switch op {
+	case models.ComplianceOpEqual, "":
+		return strings.EqualFold(actual, expected), nil
+	case models.ComplianceOpNotEqual:
+		return !strings.EqualFold(actual, expected), nil
+	case models.ComplianceOpContains:
+		return strings.Contains(strings.ToLower(actual), strings.ToLower(expected)), nil
+	case models.ComplianceOpIn:
+		for _, v := range strings.Split(expected, ",") {
+			if strings.EqualFold(actual, strings.TrimSpace(v)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case models.ComplianceOpLess, models.ComplianceOpLessEqual,
+		models.ComplianceOpGreater, models.ComplianceOpGreaterEqual:
+		cmp := compareOrdered(actual, expected)
+		switch op {
+		case models.ComplianceOpLess:
+			return cmp < 0, nil
+		case models.ComplianceOpLessEqual:
+			return cmp <= 0, nil
+		case models.ComplianceOpGreater:
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	}
+
+	return false, fmt.Errorf("unknown operator: %s", op)
+}
+
+// compareOrdered compares two values numerically if possible, otherwise as versions
+func compareOrdered(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return CompareVersions(a, b)
+}
+
+// CompareVersions compares version strings by their numeric components.
+// Non-numeric characters are treated as separators.
+func CompareVersions(a, b string) int {
+	pa := versionParts(a)
+	pb := versionParts(b)
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x < y {
+			return -1
+		}
+		if x > y {
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	fields := strings.FieldsFunc(v, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	parts := make([]int, 0, len(fields))
+	for _, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+func uniqueValues(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		unique = append(unique, v)
+	}
+	return unique
+}
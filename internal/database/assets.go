@@ -0,0 +1,309 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// AssetRepository handles asset data of devices and custom asset fields
+type AssetRepository struct {
+	db Querier
+}
+
+// NewAssetRepository creates a new asset repository
+func NewAssetRepository(db Querier) *AssetRepository {
+	return &AssetRepository{db: db}
+}
+
+// CreateField inserts a new custom field
+func (r *AssetRepository) CreateField(f *models.AssetField) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO asset_fields (key, label, device_type, field_type, position, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		f.Key, f.Label, string(f.DeviceType), string(f.FieldType), f.Position, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create asset field: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	f.ID = id
+	f.CreatedAt = now
+	return nil
+}
+
+// GetFieldByID retrieves a custom field by ID
+func (r *AssetRepository) GetFieldByID(id int64) (*models.AssetField, error) {
+	f, err := scanAssetField(r.db.QueryRow(`
+		SELECT id, key, label, device_type, field_type, position, created_at
+		FROM asset_fields WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset field: %w", err)
+	}
+	return f, nil
+}
+
+// GetFieldByKey retrieves a custom field by key
+func (r *AssetRepository) GetFieldByKey(key string) (*models.AssetField, error) {
+	f, err := scanAssetField(r.db.QueryRow(`
+		SELECT id, key, label, device_type, field_type, position, created_at
+		FROM asset_fields WHERE key = ?`, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset field: %w", err)
+	}
+	return f, nil
+}
+
+// GetFields retrieves all custom fields in display order
+func (r *AssetRepository) GetFields() ([]models.AssetField, error) {
+	rows, err := r.db.Query(`
+		SELECT id, key, label, device_type, field_type, position, created_at
+		FROM asset_fields ORDER BY position, label`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query asset fields: %w", err)
+	}
+	defer rows.Close()
+
+	var fields []models.AssetField
+	for rows.Next() {
+		f, err := scanAssetField(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan asset field: %w", err)
+		}
+		fields = append(fields, *f)
+	}
+	return fields, nil
+}
+
+// UpdateField updates label, type and position of a custom field. The key and
+// the device type cannot be changed as values are bound to them.
+func (r *AssetRepository) UpdateField(f *models.AssetField) error {
+	_, err := r.db.Exec(`
+		UPDATE asset_fields SET label = ?, field_type = ?, position = ? WHERE id = ?`,
+		f.Label, string(f.FieldType), f.Position, f.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update asset field: %w", err)
+	}
+	return nil
+}
+
+// DeleteField removes a custom field with all its values
+func (r *AssetRepository) DeleteField(id int64) error {
+	if _, err := r.db.Exec("DELETE FROM asset_field_values WHERE field_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete asset field values: %w", err)
+	}
+	if _, err := r.db.Exec("DELETE FROM asset_fields WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete asset field: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves asset data of a device, nil if none was saved
+func (r *AssetRepository) Get(deviceID int64) (*models.DeviceAsset, error) {
+	a, err := scanDeviceAsset(r.db.QueryRow(`
+		SELECT `+assetColumns+` FROM device_assets WHERE device_id = ?`, deviceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device asset: %w", err)
+	}
+
+	custom, err := r.customValues("WHERE v.device_id = ?", deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if values, ok := custom[deviceID]; ok {
+		a.Custom = values
+	}
+	return a, nil
+}
+
+// GetAll retrieves asset data of all devices keyed by device ID. Devices that only
+// have custom field values are included as well.
+func (r *AssetRepository) GetAll() (map[int64]*models.DeviceAsset, error) {
+	rows, err := r.db.Query(`SELECT ` + assetColumns + ` FROM device_assets`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query device assets: %w", err)
+	}
+	defer rows.Close()
+
+	assets := make(map[int64]*models.DeviceAsset)
+	for rows.Next() {
+		a, err := scanDeviceAsset(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan device asset: %w", err)
+		}
+		assets[a.DeviceID] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query device assets: %w", err)
+	}
+
+	custom, err := r.customValues("")
+	if err != nil {
+		return nil, err
+	}
+	for deviceID, values := range custom {
+		a, ok := assets[deviceID]
+		if !ok {
+			a = &models.DeviceAsset{DeviceID: deviceID}
+			assets[deviceID] = a
+		}
+		a.Custom = values
+	}
+	return assets, nil
+}
+
+// Save stores asset data of a device and replaces its custom field values.
+// Values of unknown keys are ignored.
+func (r *AssetRepository) Save(a *models.DeviceAsset) error {
+	a.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO device_assets (device_id, serial_number, mac_address, install_date, warranty_end,
+			room, rack, rack_unit, notes, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(device_id) DO UPDATE SET
+			serial_number = excluded.serial_number, mac_address = excluded.mac_address,
+			install_date = excluded.install_date, warranty_end = excluded.warranty_end,
+			room = excluded.room, rack = excluded.rack, rack_unit = excluded.rack_unit,
+			notes = excluded.notes, updated_at = excluded.updated_at`,
+		a.DeviceID, a.SerialNumber, a.MACAddress, a.InstallDate, a.WarrantyEnd,
+		a.Room, a.Rack, a.RackUnit, a.Notes, a.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save device asset: %w", err)
+	}
+
+	if _, err := r.db.Exec("DELETE FROM asset_field_values WHERE device_id = ?", a.DeviceID); err != nil {
+		return fmt.Errorf("failed to clear asset field values: %w", err)
+	}
+	for key, value := range a.Custom {
+		if value == "" {
+			continue
+		}
+		_, err := r.db.Exec(`
+			INSERT INTO asset_field_values (device_id, field_id, value)
+			SELECT ?, id, ? FROM asset_fields WHERE key = ?`,
+			a.DeviceID, value, key,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save asset field %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// GetWarrantyEndingBy retrieves assets whose warranty ends on or before a date
+func (r *AssetRepository) GetWarrantyEndingBy(date string) ([]models.DeviceAsset, error) {
+	rows, err := r.db.Query(`
+		SELECT `+assetColumns+` FROM device_assets
+		WHERE warranty_end != '' AND warranty_end <= ?
+		ORDER BY warranty_end`, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query warranties: %w", err)
+	}
+	defer rows.Close()
+
+	var assets []models.DeviceAsset
+	for rows.Next() {
+		a, err := scanDeviceAsset(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan device asset: %w", err)
+		}
+		assets = append(assets, *a)
+	}
+	return assets, nil
+}
+
+// GetWarrantyNotice returns the last warranty reminder sent for a device
+func (r *AssetRepository) GetWarrantyNotice(deviceID int64) (string, error) {
+	var notice sql.NullString
+	err := r.db.QueryRow("SELECT warranty_notice FROM device_assets WHERE device_id = ?", deviceID).Scan(&notice)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get warranty notice: %w", err)
+	}
+	return notice.String, nil
+}
+
+// SetWarrantyNotice records the last warranty reminder sent for a device
+func (r *AssetRepository) SetWarrantyNotice(deviceID int64, notice string) error {
+	_, err := r.db.Exec("UPDATE device_assets SET warranty_notice = ? WHERE device_id = ?", notice, deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to set warranty notice: %w", err)
+	}
+	return nil
+}
+
+const assetColumns = `device_id, serial_number, mac_address, install_date, warranty_end,
+	room, rack, rack_unit, notes, updated_at`
+
+// customValues loads custom field values keyed by device ID and field key
+func (r *AssetRepository) customValues(where string, args ...interface{}) (map[int64]map[string]string, error) {
+	rows, err := r.db.Query(`
+		SELECT v.device_id, f.key, v.value
+		FROM asset_field_values v JOIN asset_fields f ON f.id = v.field_id `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query asset field values: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[int64]map[string]string)
+	for rows.Next() {
+		var deviceID int64
+		var key, value string
+		if err := rows.Scan(&deviceID, &key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan asset field value: %w", err)
+		}
+		if values[deviceID] == nil {
+			values[deviceID] = make(map[string]string)
+		}
+		values[deviceID][key] = value
+	}
+	return values, nil
+}
+
+func scanAssetField(row rowScanner) (*models.AssetField, error) {
+	f := &models.AssetField{}
+	var deviceType, fieldType string
+	if err := row.Scan(&f.ID, &f.Key, &f.Label, &deviceType, &fieldType, &f.Position, &f.CreatedAt); err != nil {
+		return nil, err
+	}
+	f.DeviceType = models.DeviceType(deviceType)
+	f.FieldType = models.AssetFieldType(fieldType)
+	return f, nil
+}
+
+func scanDeviceAsset(row rowScanner) (*models.DeviceAsset, error) {
+	a := &models.DeviceAsset{}
+	var serial, mac, installDate, warrantyEnd, room, rack, rackUnit, notes sql.NullString
+	err := row.Scan(&a.DeviceID, &serial, &mac, &installDate, &warrantyEnd,
+		&room, &rack, &rackUnit, &notes, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	a.SerialNumber = serial.String
+	a.MACAddress = mac.String
+	a.InstallDate = installDate.String
+	a.WarrantyEnd = warrantyEnd.String
+	a.Room = room.String
+	a.Rack = rack.String
+	a.RackUnit = rackUnit.String
+	a.Notes = notes.String
+	a.Custom = map[string]string{}
+	return a, nil
+}
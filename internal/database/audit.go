@@ -0,0 +1,210 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// AuditAnchorKey is the settings key holding the hash of the last entry removed by
+// retention, where the remaining chain starts
+const AuditAnchorKey = "audit_anchor"
+
+// AuditRepository handles the audit log
+type AuditRepository struct {
+	db Querier
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db Querier) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// LastHash returns the hash of the newest entry, or the anchor if the log is empty
+func (r *AuditRepository) LastHash() (string, error) {
+	var hash string
+	err := r.db.QueryRow("SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1").Scan(&hash)
+	if err == sql.ErrNoRows {
+		return r.Anchor()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get last audit hash: %w", err)
+	}
+	return hash, nil
+}
+
+// Anchor returns the hash the oldest remaining entry follows
+func (r *AuditRepository) Anchor() (string, error) {
+	anchor, err := NewSettingsRepository(r.db).Get(AuditAnchorKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to get audit anchor: %w", err)
+	}
+	return anchor, nil
+}
+
+// Create inserts an entry. PrevHash and Hash must already be set.
+func (r *AuditRepository) Create(e *models.AuditEntry) error {
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit changes: %w", err)
+	}
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO audit_log (timestamp, actor, action, target_type, target_id, target_name,
+			changes, details, result, error, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Timestamp, e.Actor, e.Action, e.Target.Type, e.Target.ID, e.Target.Name,
+		string(changes), string(details), string(e.Result), e.Error, e.PrevHash, e.Hash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	e.ID = id
+	return nil
+}
+
+// GetFiltered retrieves entries matching the filter, newest first, with the total count
+func (r *AuditRepository) GetFiltered(filter models.AuditFilter) ([]models.AuditEntry, int, error) {
+	where := "1=1"
+	var args []interface{}
+
+	if filter.Actor != "" {
+		where += " AND actor = ?"
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		if strings.HasSuffix(filter.Action, ".") {
+			where += " AND action LIKE ?"
+			args = append(args, filter.Action+"%")
+		} else {
+			where += " AND action = ?"
+			args = append(args, filter.Action)
+		}
+	}
+	if filter.TargetType != "" {
+		where += " AND target_type = ?"
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		where += " AND target_id = ?"
+		args = append(args, *filter.TargetID)
+	}
+	if filter.Result != "" {
+		where += " AND result = ?"
+		args = append(args, filter.Result)
+	}
+	if filter.StartTime != nil {
+		where += " AND timestamp >= ?"
+		args = append(args, *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		where += " AND timestamp <= ?"
+		args = append(args, *filter.EndTime)
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM audit_log WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_log WHERE " + where + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", filter.Offset)
+	}
+
+	entries, err := r.query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// GetChain retrieves all entries in chain order
+func (r *AuditRepository) GetChain() ([]models.AuditEntry, error) {
+	return r.query("SELECT " + auditColumns + " FROM audit_log ORDER BY id")
+}
+
+// DeleteOlderThan removes entries created before a time and moves the anchor to
+// the hash of the last removed entry, so the remaining chain still verifies
+func (r *AuditRepository) DeleteOlderThan(before time.Time) (int64, error) {
+	var lastID int64
+	var lastHash string
+	err := r.db.QueryRow(`
+		SELECT id, hash FROM audit_log WHERE timestamp < ? ORDER BY id DESC LIMIT 1`, before,
+	).Scan(&lastID, &lastHash)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find old audit entries: %w", err)
+	}
+
+	result, err := r.db.Exec("DELETE FROM audit_log WHERE id <= ?", lastID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old audit entries: %w", err)
+	}
+	if err := NewSettingsRepository(r.db).Set(AuditAnchorKey, lastHash); err != nil {
+		return 0, fmt.Errorf("failed to move audit anchor: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+const auditColumns = `id, timestamp, actor, action, target_type, target_id, target_name,
+	changes, details, result, error, prev_hash, hash`
+
+func (r *AuditRepository) query(query string, args ...interface{}) ([]models.AuditEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func scanAuditEntry(row rowScanner) (*models.AuditEntry, error) {
+	e := &models.AuditEntry{}
+	var targetType, targetName, errText sql.NullString
+	var changes, details, result string
+	err := row.Scan(&e.ID, &e.Timestamp, &e.Actor, &e.Action, &targetType, &e.Target.ID, &targetName,
+		&changes, &details, &result, &errText, &e.PrevHash, &e.Hash)
+	if err != nil {
+		return nil, err
+	}
+	e.Target.Type = targetType.String
+	e.Target.Name = targetName.String
+	e.Result = models.AuditResult(result)
+	e.Error = errText.String
+
+	if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+		return nil, fmt.Errorf("failed to parse audit changes: %w", err)
+	}
+	if err := json.Unmarshal([]byte(details), &e.Details); err != nil {
+		return nil, fmt.Errorf("failed to parse audit details: %w", err)
+	}
+	return e, nil
+}
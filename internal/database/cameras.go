@@ -10,11 +10,11 @@ import (
 
 // CameraRepository handles camera-specific database operations
 type CameraRepository struct {
-	db *sql.DB
+	db Querier
 }
 
 // NewCameraRepository creates a new camera repository
-func NewCameraRepository(db *sql.DB) *CameraRepository {
+func NewCameraRepository(db Querier) *CameraRepository {
 	return &CameraRepository{db: db}
 }
 
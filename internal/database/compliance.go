@@ -0,0 +1,264 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// ComplianceRepository handles compliance baselines, rule states and reports
+type ComplianceRepository struct {
+	db Querier
+}
+
+// NewComplianceRepository creates a new compliance repository
+func NewComplianceRepository(db Querier) *ComplianceRepository {
+	return &ComplianceRepository{db: db}
+}
+
+// CreateBaseline inserts a new baseline
+func (r *ComplianceRepository) CreateBaseline(baseline *models.ComplianceBaseline) error {
+	rules, err := json.Marshal(baseline.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rules: %w", err)
+	}
+
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO compliance_baselines (name, device_type, enabled, rules, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		baseline.Name, baseline.DeviceType, baseline.Enabled, string(rules), now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create baseline: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	baseline.ID = id
+	baseline.CreatedAt = now
+	baseline.UpdatedAt = now
+	return nil
+}
+
+// GetBaselineByID retrieves a baseline by ID
+func (r *ComplianceRepository) GetBaselineByID(id int64) (*models.ComplianceBaseline, error) {
+	row := r.db.QueryRow(`
+		SELECT id, name, device_type, enabled, rules, created_at, updated_at
+		FROM compliance_baselines WHERE id = ?`, id)
+
+	baseline, err := scanBaseline(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// GetAllBaselines retrieves all baselines
+func (r *ComplianceRepository) GetAllBaselines() ([]models.ComplianceBaseline, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, device_type, enabled, rules, created_at, updated_at
+		FROM compliance_baselines ORDER BY device_type, name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query baselines: %w", err)
+	}
+	defer rows.Close()
+
+	var baselines []models.ComplianceBaseline
+	for rows.Next() {
+		baseline, err := scanBaseline(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan baseline: %w", err)
+		}
+		baselines = append(baselines, *baseline)
+	}
+	return baselines, nil
+}
+
+// UpdateBaseline updates a baseline
+func (r *ComplianceRepository) UpdateBaseline(baseline *models.ComplianceBaseline) error {
+	rules, err := json.Marshal(baseline.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rules: %w", err)
+	}
+
+	baseline.UpdatedAt = time.Now()
+	_, err = r.db.Exec(`
+		UPDATE compliance_baselines SET name = ?, device_type = ?, enabled = ?, rules = ?, updated_at = ?
+		WHERE id = ?`,
+		baseline.Name, baseline.DeviceType, baseline.Enabled, string(rules), baseline.UpdatedAt, baseline.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update baseline: %w", err)
+	}
+	return nil
+}
+
+// DeleteBaseline removes a baseline and its rule states
+func (r *ComplianceRepository) DeleteBaseline(id int64) error {
+	_, err := r.db.Exec("DELETE FROM compliance_state WHERE baseline_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete compliance state: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM compliance_baselines WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete baseline: %w", err)
+	}
+	return nil
+}
+
+// UpdateRuleState stores the latest result of a rule for a device and returns
+// the previous pass state (nil if the rule was never checked before)
+func (r *ComplianceRepository) UpdateRuleState(deviceID, baselineID int64, ruleKey string, passed bool, actual string) (*bool, error) {
+	var previous sql.NullBool
+	err := r.db.QueryRow(`
+		SELECT passed FROM compliance_state
+		WHERE device_id = ? AND baseline_id = ? AND rule_key = ?`,
+		deviceID, baselineID, ruleKey,
+	).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get compliance state: %w", err)
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO compliance_state (device_id, baseline_id, rule_key, passed, actual, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(device_id, baseline_id, rule_key) DO UPDATE SET
+			passed = excluded.passed, actual = excluded.actual, checked_at = excluded.checked_at`,
+		deviceID, baselineID, ruleKey, passed, actual, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update compliance state: %w", err)
+	}
+
+	if !previous.Valid {
+		return nil, nil
+	}
+	return &previous.Bool, nil
+}
+
+// SaveReport stores a compliance report
+func (r *ComplianceRepository) SaveReport(report *models.ComplianceReport) error {
+	data, err := json.Marshal(report.Results)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO compliance_reports (started_at, finished_at, total, passed, failed, errors, results)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		report.StartedAt, report.FinishedAt, report.Total, report.Passed, report.Failed, report.Errors, string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save compliance report: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	report.ID = id
+	return nil
+}
+
+// GetReports retrieves report summaries (without per-device results), newest first
+func (r *ComplianceRepository) GetReports(limit int) ([]models.ComplianceReport, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, started_at, finished_at, total, passed, failed, errors
+		FROM compliance_reports ORDER BY started_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query compliance reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []models.ComplianceReport
+	for rows.Next() {
+		var rep models.ComplianceReport
+		err := rows.Scan(&rep.ID, &rep.StartedAt, &rep.FinishedAt, &rep.Total, &rep.Passed, &rep.Failed, &rep.Errors)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan compliance report: %w", err)
+		}
+		reports = append(reports, rep)
+	}
+	return reports, nil
+}
+
+// GetReportByID retrieves a full report including per-device results
+func (r *ComplianceRepository) GetReportByID(id int64) (*models.ComplianceReport, error) {
+	rep := &models.ComplianceReport{}
+	var results string
+	err := r.db.QueryRow(`
+		SELECT id, started_at, finished_at, total, passed, failed, errors, results
+		FROM compliance_reports WHERE id = ?`, id,
+	).Scan(&rep.ID, &rep.StartedAt, &rep.FinishedAt, &rep.Total, &rep.Passed, &rep.Failed, &rep.Errors, &results)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get compliance report: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(results), &rep.Results); err != nil {
+		return nil, fmt.Errorf("failed to parse compliance report: %w", err)
+	}
+	return rep, nil
+}
+
+// GetLatestReport retrieves the most recent full report
+func (r *ComplianceRepository) GetLatestReport() (*models.ComplianceReport, error) {
+	var id int64
+	err := r.db.QueryRow("SELECT id FROM compliance_reports ORDER BY started_at DESC LIMIT 1").Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest compliance report: %w", err)
+	}
+	return r.GetReportByID(id)
+}
+
+// DeleteOldReports keeps only the newest keep reports
+func (r *ComplianceRepository) DeleteOldReports(keep int) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM compliance_reports WHERE id NOT IN (
+			SELECT id FROM compliance_reports ORDER BY started_at DESC LIMIT ?
+		)`, keep)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old compliance reports: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBaseline(row rowScanner) (*models.ComplianceBaseline, error) {
+	b := &models.ComplianceBaseline{}
+	var rules string
+	err := row.Scan(&b.ID, &b.Name, &b.DeviceType, &b.Enabled, &rules, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if rules != "" {
+		if err := json.Unmarshal([]byte(rules), &b.Rules); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
@@ -11,16 +11,20 @@ import (
 
 // CredentialRepository handles credential database operations
 type CredentialRepository struct {
-	db *sql.DB
+	db Querier
 }
 
 // NewCredentialRepository creates a new credential repository
-func NewCredentialRepository(db *sql.DB) *CredentialRepository {
+func NewCredentialRepository(db Querier) *CredentialRepository {
 	return &CredentialRepository{db: db}
 }
 
 // Create inserts a new credential with encrypted password
 func (r *CredentialRepository) Create(cred *models.Credential) error {
+	if !cred.Type.Valid() {
+		return fmt.Errorf("invalid credential type: %s", cred.Type)
+	}
+
 	encryptedPassword, err := encryption.EncryptIfNotEmpty(cred.Password)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt password: %w", err)
@@ -177,6 +181,10 @@ func (r *CredentialRepository) GetByType(credType models.CredentialType) ([]mode
 
 // Update updates an existing credential
 func (r *CredentialRepository) Update(cred *models.Credential) error {
+	if !cred.Type.Valid() {
+		return fmt.Errorf("invalid credential type: %s", cred.Type)
+	}
+
 	encryptedUsername, err := encryption.EncryptIfNotEmpty(cred.Username)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt username: %w", err)
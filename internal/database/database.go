@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	_ "modernc.org/sqlite"
 )
@@ -15,8 +16,28 @@ type Database struct {
 
 var instance *Database
 
-// Initialize creates or opens the SQLite database
+// Querier is implemented by both *sql.DB and *sql.Tx, so repositories
+// can be used inside a transaction
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Initialize creates or opens the SQLite database and makes it the instance
+// returned by Get
 func Initialize(dataDir string) (*Database, error) {
+	d, err := Open(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	instance = d
+	return d, nil
+}
+
+// Open creates or opens the SQLite database in dataDir and runs the migrations,
+// without making it the instance returned by Get
+func Open(dataDir string) (*Database, error) {
 	// Ensure data directory exists
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
@@ -34,14 +55,15 @@ func Initialize(dataDir string) (*Database, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	instance = &Database{db: db}
+	d := &Database{db: db}
 
 	// Run migrations
-	if err := instance.Migrate(); err != nil {
+	if err := d.Migrate(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return instance, nil
+	return d, nil
 }
 
 // Get returns the database instance
@@ -54,54 +76,88 @@ func (d *Database) DB() *sql.DB {
 	return d.db
 }
 
-// Close closes the database connection
-func (d *Database) Close() error {
-	if d.db != nil {
-		return d.db.Close()
+// WithTx runs fn inside a transaction. The transaction is committed if fn
+// returns nil and rolled back otherwise.
+func (d *Database) WithTx(fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return nil
 }
 
-// Migrate runs all database migrations
-func (d *Database) Migrate() error {
-	migrations := []string{
-		migrationCredentials,
-		migrationDevices,
-		migrationSwitches,
-		migrationSwitchPorts,
-		migrationCameras,
-		migrationServers,
-		migrationEvents,
-		migrationSchemas,
-		migrationSchemaItems,
-		migrationSettings,
-		migrationStatusHistory,
+// Snapshot writes a consistent copy of the database to path with VACUUM INTO.
+// The copy is taken from a single read transaction, so it is safe while the
+// database is being written. path must not exist.
+func (d *Database) Snapshot(path string) error {
+	if _, err := d.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
 	}
+	return nil
+}
 
-	for _, migration := range migrations {
-		if _, err := d.db.Exec(migration); err != nil {
-			return fmt.Errorf("migration failed: %w", err)
-		}
+// OpenSnapshot opens a database file read-only, without running migrations
+func OpenSnapshot(path string) (*Database, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
 	}
+	return &Database{db: db}, nil
+}
 
-	// Run optional migrations that may fail (e.g., adding columns that already exist)
-	optionalMigrations := []string{
-		migrationAddManufacturer,
-		migrationSwitchesV3,
-		migrationSwitchPortsV2,
-		migrationSwitchesSFP,
-		migrationSwitchesUplink,
-		migrationServersUplink,
-		migrationSwitchesWriteCommunity,
+// CheckIntegrity runs the SQLite integrity check and returns the problems found
+func (d *Database) CheckIntegrity() error {
+	rows, err := d.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("failed to check integrity: %w", err)
 	}
-	for _, migration := range optionalMigrations {
-		d.db.Exec(migration) // Ignore errors for optional migrations
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return fmt.Errorf("failed to check integrity: %w", err)
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check integrity: %w", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("database is damaged: %s", strings.Join(problems, "; "))
 	}
+	return nil
+}
 
+// Close closes the database connection
+func (d *Database) Close() error {
+	if d.db != nil {
+		return d.db.Close()
+	}
 	return nil
 }
 
-// Migration SQL statements
+// Statements of the initial schema, see migrations. Tables created by older
+// versions may lack columns added later, migration 2 adds them.
 const migrationCredentials = `
 CREATE TABLE IF NOT EXISTS credentials (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -121,8 +177,11 @@ CREATE TABLE IF NOT EXISTS devices (
 	name TEXT NOT NULL,
 	ip_address TEXT NOT NULL,
 	type TEXT NOT NULL CHECK(type IN ('switch', 'server', 'camera')),
+	manufacturer TEXT DEFAULT '',
 	model TEXT DEFAULT '',
 	credential_id INTEGER REFERENCES credentials(id) ON DELETE SET NULL,
+	site_id INTEGER REFERENCES sites(id) ON DELETE SET NULL,
+	group_id INTEGER REFERENCES device_groups(id) ON DELETE SET NULL,
 	status TEXT DEFAULT 'unknown' CHECK(status IN ('online', 'offline', 'unknown')),
 	last_check DATETIME,
 	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -140,6 +199,9 @@ CREATE TABLE IF NOT EXISTS switches (
 	snmp_write_community TEXT DEFAULT '',
 	snmp_version TEXT DEFAULT 'v2c' CHECK(snmp_version IN ('v1', 'v2c', 'v3')),
 	port_count INTEGER DEFAULT 24,
+	sfp_port_count INTEGER DEFAULT 0,
+	uplink_switch_id INTEGER REFERENCES devices(id) ON DELETE SET NULL,
+	uplink_port_id INTEGER REFERENCES switch_ports(id) ON DELETE SET NULL,
 	snmpv3_user TEXT DEFAULT '',
 	snmpv3_security TEXT DEFAULT 'noAuthNoPriv' CHECK(snmpv3_security IN ('noAuthNoPriv', 'authNoPriv', 'authPriv')),
 	snmpv3_auth_proto TEXT DEFAULT '',
@@ -149,15 +211,6 @@ CREATE TABLE IF NOT EXISTS switches (
 );
 `
 
-const migrationSwitchesV3 = `
-ALTER TABLE switches ADD COLUMN snmpv3_user TEXT DEFAULT '';
-ALTER TABLE switches ADD COLUMN snmpv3_security TEXT DEFAULT 'noAuthNoPriv';
-ALTER TABLE switches ADD COLUMN snmpv3_auth_proto TEXT DEFAULT '';
-ALTER TABLE switches ADD COLUMN snmpv3_auth_pass TEXT DEFAULT '';
-ALTER TABLE switches ADD COLUMN snmpv3_priv_proto TEXT DEFAULT '';
-ALTER TABLE switches ADD COLUMN snmpv3_priv_pass TEXT DEFAULT '';
-`
-
 const migrationSwitchPorts = `
 CREATE TABLE IF NOT EXISTS switch_ports (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -166,7 +219,9 @@ CREATE TABLE IF NOT EXISTS switch_ports (
 	name TEXT DEFAULT '',
 	status TEXT DEFAULT 'unknown' CHECK(status IN ('up', 'down', 'unknown')),
 	speed TEXT DEFAULT '',
+	port_type TEXT DEFAULT 'copper' CHECK(port_type IN ('copper', 'sfp')),
 	linked_camera_id INTEGER REFERENCES devices(id) ON DELETE SET NULL,
+	linked_switch_id INTEGER REFERENCES devices(id) ON DELETE SET NULL,
 	UNIQUE(switch_id, port_number)
 );
 
@@ -187,10 +242,99 @@ const migrationServers = `
 CREATE TABLE IF NOT EXISTS servers (
 	device_id INTEGER PRIMARY KEY REFERENCES devices(id) ON DELETE CASCADE,
 	tcp_ports TEXT DEFAULT '[]',
-	use_snmp INTEGER DEFAULT 0
+	use_snmp INTEGER DEFAULT 0,
+	uplink_switch_id INTEGER REFERENCES devices(id) ON DELETE SET NULL,
+	uplink_port_id INTEGER REFERENCES switch_ports(id) ON DELETE SET NULL
 );
 `
 
+const migrationSNMPDevices = `
+CREATE TABLE IF NOT EXISTS snmp_devices (
+	device_id INTEGER PRIMARY KEY REFERENCES devices(id) ON DELETE CASCADE,
+	snmp_community TEXT DEFAULT '',
+	snmp_version TEXT DEFAULT 'v2c',
+	snmpv3_user TEXT DEFAULT '',
+	snmpv3_security TEXT DEFAULT 'noAuthNoPriv',
+	snmpv3_auth_proto TEXT DEFAULT '',
+	snmpv3_auth_pass TEXT DEFAULT '',
+	snmpv3_priv_proto TEXT DEFAULT '',
+	snmpv3_priv_pass TEXT DEFAULT '',
+	uplink_switch_id INTEGER REFERENCES devices(id) ON DELETE SET NULL,
+	uplink_port_id INTEGER REFERENCES switch_ports(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS nvrs (
+	device_id INTEGER PRIMARY KEY REFERENCES devices(id) ON DELETE CASCADE,
+	check_http INTEGER DEFAULT 0,
+	http_port INTEGER DEFAULT 80
+);
+`
+
+const migrationUPSDevices = `
+CREATE TABLE IF NOT EXISTS ups_devices (
+	device_id INTEGER PRIMARY KEY REFERENCES devices(id) ON DELETE CASCADE,
+	protocol TEXT DEFAULT 'snmp',
+	nut_port INTEGER DEFAULT 3493,
+	nut_ups_name TEXT DEFAULT 'ups'
+);
+`
+
+const migrationSwitchEnvironment = `
+CREATE TABLE IF NOT EXISTS switch_environment (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	device_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	temperature REAL,
+	poe_budget_w REAL,
+	poe_used_w REAL,
+	cpu_percent INTEGER,
+	memory_percent INTEGER,
+	fans_failed INTEGER DEFAULT 0,
+	psus_failed INTEGER DEFAULT 0,
+	checked_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_switch_environment_device ON switch_environment(device_id, checked_at);
+`
+
+const migrationPoEPower = `
+CREATE TABLE IF NOT EXISTS poe_power (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	switch_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	port_number INTEGER NOT NULL,
+	camera_id INTEGER REFERENCES devices(id) ON DELETE SET NULL,
+	power_w REAL NOT NULL,
+	checked_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_poe_power_port ON poe_power(switch_id, port_number, checked_at);
+CREATE INDEX IF NOT EXISTS idx_poe_power_camera ON poe_power(camera_id, checked_at);
+
+CREATE TABLE IF NOT EXISTS poe_energy_daily (
+	switch_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	port_number INTEGER NOT NULL,
+	day TEXT NOT NULL,
+	camera_id INTEGER REFERENCES devices(id) ON DELETE SET NULL,
+	energy_wh REAL DEFAULT 0,
+	max_power_w REAL DEFAULT 0,
+	PRIMARY KEY (switch_id, port_number, day)
+);
+CREATE INDEX IF NOT EXISTS idx_poe_energy_day ON poe_energy_daily(day);
+`
+
+const migrationPortHistory = `
+CREATE TABLE IF NOT EXISTS port_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	switch_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	port_number INTEGER NOT NULL,
+	field TEXT NOT NULL,
+	old_value TEXT DEFAULT '',
+	new_value TEXT DEFAULT '',
+	flap INTEGER DEFAULT 0,
+	source TEXT NOT NULL,
+	actor TEXT DEFAULT '',
+	changed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_port_history_port ON port_history(switch_id, port_number, changed_at);
+`
+
 const migrationEvents = `
 CREATE TABLE IF NOT EXISTS events (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -251,31 +395,280 @@ CREATE INDEX IF NOT EXISTS idx_status_history_created ON status_history(created_
 CREATE INDEX IF NOT EXISTS idx_status_history_device_created ON status_history(device_id, created_at DESC);
 `
 
-const migrationAddManufacturer = `
-ALTER TABLE devices ADD COLUMN manufacturer TEXT DEFAULT '';
+const migrationCompliance = `
+CREATE TABLE IF NOT EXISTS compliance_baselines (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	device_type TEXT NOT NULL,
+	enabled INTEGER DEFAULT 1,
+	rules TEXT NOT NULL DEFAULT '[]',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS compliance_state (
+	device_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	baseline_id INTEGER NOT NULL REFERENCES compliance_baselines(id) ON DELETE CASCADE,
+	rule_key TEXT NOT NULL,
+	passed INTEGER NOT NULL,
+	actual TEXT DEFAULT '',
+	checked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(device_id, baseline_id, rule_key)
+);
+
+CREATE TABLE IF NOT EXISTS compliance_reports (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at DATETIME NOT NULL,
+	finished_at DATETIME NOT NULL,
+	total INTEGER DEFAULT 0,
+	passed INTEGER DEFAULT 0,
+	failed INTEGER DEFAULT 0,
+	errors INTEGER DEFAULT 0,
+	results TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_compliance_reports_started ON compliance_reports(started_at DESC);
 `
 
-const migrationSwitchPortsV2 = `
-ALTER TABLE switch_ports ADD COLUMN port_type TEXT DEFAULT 'copper' CHECK(port_type IN ('copper', 'sfp'));
-ALTER TABLE switch_ports ADD COLUMN linked_switch_id INTEGER REFERENCES devices(id) ON DELETE SET NULL;
+const migrationStatusPeriods = `
+CREATE TABLE IF NOT EXISTS status_periods (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	device_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	status TEXT NOT NULL CHECK(status IN ('online', 'offline', 'unknown')),
+	started_at DATETIME NOT NULL,
+	ended_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_periods_device_ended ON status_periods(device_id, ended_at);
+`
+
+const migrationMaintenance = `
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	device_id INTEGER REFERENCES devices(id) ON DELETE CASCADE,
+	device_type TEXT DEFAULT '',
+	start_at DATETIME NOT NULL,
+	end_at DATETIME NOT NULL,
+	description TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_end ON maintenance_windows(end_at);
+`
+
+const migrationStatusRollups = `
+CREATE TABLE IF NOT EXISTS status_history_hourly (
+	device_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	period_start DATETIME NOT NULL,
+	checks INTEGER NOT NULL DEFAULT 0,
+	online_count INTEGER NOT NULL DEFAULT 0,
+	min_latency INTEGER DEFAULT 0,
+	avg_latency REAL DEFAULT 0,
+	max_latency INTEGER DEFAULT 0,
+	p95_latency INTEGER DEFAULT 0,
+	PRIMARY KEY(device_id, period_start)
+);
+
+CREATE TABLE IF NOT EXISTS status_history_daily (
+	device_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	period_start DATETIME NOT NULL,
+	checks INTEGER NOT NULL DEFAULT 0,
+	online_count INTEGER NOT NULL DEFAULT 0,
+	min_latency INTEGER DEFAULT 0,
+	avg_latency REAL DEFAULT 0,
+	max_latency INTEGER DEFAULT 0,
+	p95_latency INTEGER DEFAULT 0,
+	PRIMARY KEY(device_id, period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_history_hourly_start ON status_history_hourly(period_start);
+CREATE INDEX IF NOT EXISTS idx_status_history_daily_start ON status_history_daily(period_start);
+
+CREATE TABLE IF NOT EXISTS rollup_state (
+	tier TEXT PRIMARY KEY,
+	rolled_until DATETIME NOT NULL
+);
+`
+
+const migrationIncidents = `
+CREATE TABLE IF NOT EXISTS incidents (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	device_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	kind TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'open',
+	message TEXT DEFAULT '',
+	started_at DATETIME NOT NULL,
+	last_seen_at DATETIME NOT NULL,
+	ended_at DATETIME,
+	check_count INTEGER DEFAULT 1,
+	acknowledged_by TEXT DEFAULT '',
+	acknowledged_at DATETIME,
+	ack_comment TEXT DEFAULT '',
+	assigned_to TEXT DEFAULT '',
+	last_notified_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_device_status ON incidents(device_id, status);
+CREATE INDEX IF NOT EXISTS idx_incidents_started ON incidents(started_at);
+
+CREATE TABLE IF NOT EXISTS incident_notes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+	author TEXT DEFAULT '',
+	text TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_notes_incident ON incident_notes(incident_id);
+`
+
+const migrationEscalation = `
+CREATE TABLE IF NOT EXISTS oncall_schedules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	rotation_start DATETIME NOT NULL,
+	members TEXT NOT NULL DEFAULT '[]',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS oncall_overrides (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	schedule_id INTEGER NOT NULL REFERENCES oncall_schedules(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	email TEXT DEFAULT '',
+	start_at DATETIME NOT NULL,
+	end_at DATETIME NOT NULL,
+	reason TEXT DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_oncall_overrides_schedule ON oncall_overrides(schedule_id, end_at);
+
+CREATE TABLE IF NOT EXISTS escalation_policies (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	device_id INTEGER REFERENCES devices(id) ON DELETE CASCADE,
+	device_type TEXT DEFAULT '',
+	enabled INTEGER DEFAULT 1,
+	steps TEXT NOT NULL DEFAULT '[]',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS incident_escalations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+	policy_id INTEGER NOT NULL REFERENCES escalation_policies(id) ON DELETE CASCADE,
+	level INTEGER NOT NULL,
+	recipients TEXT DEFAULT '',
+	error TEXT DEFAULT '',
+	notified_at DATETIME NOT NULL,
+	UNIQUE(incident_id, policy_id, level)
+);
 `
 
-const migrationSwitchesSFP = `
-ALTER TABLE switches ADD COLUMN sfp_port_count INTEGER DEFAULT 0;
+const migrationGroups = `
+CREATE TABLE IF NOT EXISTS sites (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	address TEXT DEFAULT '',
+	latitude REAL,
+	longitude REAL,
+	description TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS device_groups (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	parent_id INTEGER REFERENCES device_groups(id) ON DELETE CASCADE,
+	description TEXT DEFAULT '',
+	settings TEXT NOT NULL DEFAULT '{}',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_groups_parent ON device_groups(parent_id);
+
+CREATE TABLE IF NOT EXISTS device_tags (
+	device_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	tag TEXT NOT NULL COLLATE NOCASE,
+	PRIMARY KEY(device_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_tags_tag ON device_tags(tag);
 `
 
-const migrationSwitchesUplink = `
-ALTER TABLE switches ADD COLUMN uplink_switch_id INTEGER REFERENCES devices(id) ON DELETE SET NULL;
-ALTER TABLE switches ADD COLUMN uplink_port_id INTEGER REFERENCES switch_ports(id) ON DELETE SET NULL;
+const migrationAssets = `
+CREATE TABLE IF NOT EXISTS device_assets (
+	device_id INTEGER PRIMARY KEY REFERENCES devices(id) ON DELETE CASCADE,
+	serial_number TEXT DEFAULT '',
+	mac_address TEXT DEFAULT '',
+	install_date TEXT DEFAULT '',
+	warranty_end TEXT DEFAULT '',
+	room TEXT DEFAULT '',
+	rack TEXT DEFAULT '',
+	rack_unit TEXT DEFAULT '',
+	notes TEXT DEFAULT '',
+	warranty_notice TEXT DEFAULT '',
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_assets_warranty ON device_assets(warranty_end);
+
+CREATE TABLE IF NOT EXISTS asset_fields (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	key TEXT NOT NULL UNIQUE,
+	label TEXT NOT NULL,
+	device_type TEXT DEFAULT '',
+	field_type TEXT NOT NULL DEFAULT 'text',
+	position INTEGER DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS asset_field_values (
+	device_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	field_id INTEGER NOT NULL REFERENCES asset_fields(id) ON DELETE CASCADE,
+	value TEXT NOT NULL,
+	PRIMARY KEY(device_id, field_id)
+);
 `
 
-const migrationServersUplink = `
-ALTER TABLE servers ADD COLUMN uplink_switch_id INTEGER REFERENCES devices(id) ON DELETE SET NULL;
-ALTER TABLE servers ADD COLUMN uplink_port_id INTEGER REFERENCES switch_ports(id) ON DELETE SET NULL;
+const migrationAudit = `
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME NOT NULL,
+	actor TEXT NOT NULL,
+	action TEXT NOT NULL,
+	target_type TEXT DEFAULT '',
+	target_id INTEGER,
+	target_name TEXT DEFAULT '',
+	changes TEXT NOT NULL DEFAULT '[]',
+	details TEXT NOT NULL DEFAULT 'null',
+	result TEXT NOT NULL,
+	error TEXT DEFAULT '',
+	prev_hash TEXT NOT NULL,
+	hash TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id);
 `
 
-const migrationSwitchesWriteCommunity = `
-ALTER TABLE switches ADD COLUMN snmp_write_community TEXT DEFAULT '';
+const migrationUsers = `
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	display_name TEXT DEFAULT '',
+	role TEXT NOT NULL CHECK(role IN ('viewer', 'operator', 'admin')),
+	password_hash TEXT NOT NULL,
+	disabled INTEGER DEFAULT 0,
+	last_login_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
 `
 
 // FixExistingPortTypes updates port_type for existing ports based on switch sfp_port_count
@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"netvisionmonitor/internal/models"
@@ -10,21 +11,27 @@ import (
 
 // DeviceRepository handles device database operations
 type DeviceRepository struct {
-	db *sql.DB
+	db Querier
 }
 
 // NewDeviceRepository creates a new device repository
-func NewDeviceRepository(db *sql.DB) *DeviceRepository {
+func NewDeviceRepository(db Querier) *DeviceRepository {
 	return &DeviceRepository{db: db}
 }
 
+const deviceColumns = `id, name, ip_address, type, COALESCE(manufacturer, ''), model, credential_id, site_id, group_id, status, last_check, created_at, updated_at`
+
 // Create inserts a new device
 func (r *DeviceRepository) Create(device *models.Device) error {
+	if !device.Type.Valid() {
+		return fmt.Errorf("invalid device type: %s", device.Type)
+	}
+
 	result, err := r.db.Exec(`
-		INSERT INTO devices (name, ip_address, type, manufacturer, model, credential_id, status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		INSERT INTO devices (name, ip_address, type, manufacturer, model, credential_id, site_id, group_id, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		device.Name, device.IPAddress, device.Type, device.Manufacturer, device.Model,
-		device.CredentialID, device.Status, time.Now(), time.Now(),
+		device.CredentialID, device.SiteID, device.GroupID, device.Status, time.Now(), time.Now(),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create device: %w", err)
@@ -40,28 +47,23 @@ func (r *DeviceRepository) Create(device *models.Device) error {
 
 // GetByID retrieves a device by ID
 func (r *DeviceRepository) GetByID(id int64) (*models.Device, error) {
-	device := &models.Device{}
-	err := r.db.QueryRow(`
-		SELECT id, name, ip_address, type, COALESCE(manufacturer, ''), model, credential_id, status, last_check, created_at, updated_at
-		FROM devices WHERE id = ?`, id,
-	).Scan(
-		&device.ID, &device.Name, &device.IPAddress, &device.Type, &device.Manufacturer, &device.Model,
-		&device.CredentialID, &device.Status, &device.LastCheck, &device.CreatedAt, &device.UpdatedAt,
-	)
+	device, err := scanDevice(r.db.QueryRow("SELECT "+deviceColumns+" FROM devices WHERE id = ?", id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get device: %w", err)
 	}
+	device.Tags, err = r.GetTags(id)
+	if err != nil {
+		return nil, err
+	}
 	return device, nil
 }
 
 // GetAll retrieves all devices
 func (r *DeviceRepository) GetAll() ([]models.Device, error) {
-	rows, err := r.db.Query(`
-		SELECT id, name, ip_address, type, COALESCE(manufacturer, ''), model, credential_id, status, last_check, created_at, updated_at
-		FROM devices ORDER BY name`)
+	rows, err := r.db.Query("SELECT " + deviceColumns + " FROM devices ORDER BY name")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query devices: %w", err)
 	}
@@ -69,15 +71,14 @@ func (r *DeviceRepository) GetAll() ([]models.Device, error) {
 
 	var devices []models.Device
 	for rows.Next() {
-		var d models.Device
-		err := rows.Scan(
-			&d.ID, &d.Name, &d.IPAddress, &d.Type, &d.Manufacturer, &d.Model,
-			&d.CredentialID, &d.Status, &d.LastCheck, &d.CreatedAt, &d.UpdatedAt,
-		)
+		d, err := scanDevice(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan device: %w", err)
 		}
-		devices = append(devices, d)
+		devices = append(devices, *d)
+	}
+	if err := r.loadTags(devices); err != nil {
+		return nil, err
 	}
 	return devices, nil
 }
@@ -87,6 +88,9 @@ type DeviceFilter struct {
 	Type       string
 	Status     string
 	Search     string
+	SiteID     *int64
+	GroupID    *int64 // Includes subgroups
+	Tag        string
 	Limit      int
 	Offset     int
 	SortBy     string
@@ -117,9 +121,19 @@ func (r *DeviceRepository) GetFiltered(filter DeviceFilter) (*DeviceListResult,
 		args = append(args, filter.Status)
 	}
 	if filter.Search != "" {
-		where += " AND (name LIKE ? OR ip_address LIKE ? OR model LIKE ?)"
+		where += ` AND (name LIKE ? OR ip_address LIKE ? OR model LIKE ?
+			OR id IN (SELECT device_id FROM device_tags WHERE tag LIKE ?)
+			OR id IN (SELECT device_id FROM device_assets WHERE serial_number LIKE ? OR mac_address LIKE ?
+				OR room LIKE ? OR rack LIKE ? OR notes LIKE ?)
+			OR id IN (SELECT device_id FROM asset_field_values WHERE value LIKE ?))`
 		searchTerm := "%" + filter.Search + "%"
-		args = append(args, searchTerm, searchTerm, searchTerm)
+		for i := 0; i < 10; i++ {
+			args = append(args, searchTerm)
+		}
+	}
+	if scope, scopeArgs := deviceScope("id", filter.SiteID, filter.GroupID, filter.Tag); scope != "" {
+		where += scope
+		args = append(args, scopeArgs...)
 	}
 
 	// Get total count
@@ -155,9 +169,9 @@ func (r *DeviceRepository) GetFiltered(filter DeviceFilter) (*DeviceListResult,
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, name, ip_address, type, COALESCE(manufacturer, ''), model, credential_id, status, last_check, created_at, updated_at
+		SELECT %s
 		FROM devices WHERE %s ORDER BY %s %s LIMIT ? OFFSET ?`,
-		where, sortBy, sortOrder)
+		deviceColumns, where, sortBy, sortOrder)
 
 	args = append(args, limit, offset)
 
@@ -169,15 +183,14 @@ func (r *DeviceRepository) GetFiltered(filter DeviceFilter) (*DeviceListResult,
 
 	var devices []models.Device
 	for rows.Next() {
-		var d models.Device
-		err := rows.Scan(
-			&d.ID, &d.Name, &d.IPAddress, &d.Type, &d.Manufacturer, &d.Model,
-			&d.CredentialID, &d.Status, &d.LastCheck, &d.CreatedAt, &d.UpdatedAt,
-		)
+		d, err := scanDevice(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan device: %w", err)
 		}
-		devices = append(devices, d)
+		devices = append(devices, *d)
+	}
+	if err := r.loadTags(devices); err != nil {
+		return nil, err
 	}
 
 	page := (offset / limit) + 1
@@ -194,9 +207,7 @@ func (r *DeviceRepository) GetFiltered(filter DeviceFilter) (*DeviceListResult,
 
 // GetByType retrieves devices by type
 func (r *DeviceRepository) GetByType(deviceType models.DeviceType) ([]models.Device, error) {
-	rows, err := r.db.Query(`
-		SELECT id, name, ip_address, type, COALESCE(manufacturer, ''), model, credential_id, status, last_check, created_at, updated_at
-		FROM devices WHERE type = ? ORDER BY name`, deviceType)
+	rows, err := r.db.Query("SELECT "+deviceColumns+" FROM devices WHERE type = ? ORDER BY name", deviceType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query devices: %w", err)
 	}
@@ -204,28 +215,31 @@ func (r *DeviceRepository) GetByType(deviceType models.DeviceType) ([]models.Dev
 
 	var devices []models.Device
 	for rows.Next() {
-		var d models.Device
-		err := rows.Scan(
-			&d.ID, &d.Name, &d.IPAddress, &d.Type, &d.Manufacturer, &d.Model,
-			&d.CredentialID, &d.Status, &d.LastCheck, &d.CreatedAt, &d.UpdatedAt,
-		)
+		d, err := scanDevice(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan device: %w", err)
 		}
-		devices = append(devices, d)
+		devices = append(devices, *d)
+	}
+	if err := r.loadTags(devices); err != nil {
+		return nil, err
 	}
 	return devices, nil
 }
 
 // Update updates an existing device
 func (r *DeviceRepository) Update(device *models.Device) error {
+	if !device.Type.Valid() {
+		return fmt.Errorf("invalid device type: %s", device.Type)
+	}
+
 	device.UpdatedAt = time.Now()
 	_, err := r.db.Exec(`
 		UPDATE devices SET name = ?, ip_address = ?, type = ?, manufacturer = ?, model = ?,
-		credential_id = ?, status = ?, updated_at = ?
+		credential_id = ?, site_id = ?, group_id = ?, status = ?, updated_at = ?
 		WHERE id = ?`,
 		device.Name, device.IPAddress, device.Type, device.Manufacturer, device.Model,
-		device.CredentialID, device.Status, device.UpdatedAt, device.ID,
+		device.CredentialID, device.SiteID, device.GroupID, device.Status, device.UpdatedAt, device.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update device: %w", err)
@@ -246,6 +260,139 @@ func (r *DeviceRepository) UpdateStatus(id int64, status models.DeviceStatus) er
 	return nil
 }
 
+// SetLocation assigns a device to a site and a group, nil to clear
+func (r *DeviceRepository) SetLocation(id int64, siteID, groupID *int64) error {
+	_, err := r.db.Exec("UPDATE devices SET site_id = ?, group_id = ?, updated_at = ? WHERE id = ?", siteID, groupID, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update device location: %w", err)
+	}
+	return nil
+}
+
+// GetTags returns the tags of a device in alphabetical order
+func (r *DeviceRepository) GetTags(id int64) ([]string, error) {
+	rows, err := r.db.Query("SELECT tag FROM device_tags WHERE device_id = ? ORDER BY tag", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query device tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan device tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// SetTags replaces the tags of a device
+func (r *DeviceRepository) SetTags(id int64, tags []string) error {
+	if _, err := r.db.Exec("DELETE FROM device_tags WHERE device_id = ?", id); err != nil {
+		return fmt.Errorf("failed to clear device tags: %w", err)
+	}
+	for _, tag := range tags {
+		if _, err := r.db.Exec("INSERT OR IGNORE INTO device_tags (device_id, tag) VALUES (?, ?)", id, tag); err != nil {
+			return fmt.Errorf("failed to add device tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetAllTags returns all tags in use with the number of devices carrying each
+func (r *DeviceRepository) GetAllTags() (map[string]int, error) {
+	rows, err := r.db.Query("SELECT tag, COUNT(*) FROM device_tags GROUP BY tag ORDER BY tag")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[string]int)
+	for rows.Next() {
+		var tag string
+		var count int
+		if err := rows.Scan(&tag, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags[tag] = count
+	}
+	return tags, nil
+}
+
+// loadTags fills tags of a list of devices
+func (r *DeviceRepository) loadTags(devices []models.Device) error {
+	if len(devices) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(devices))
+	placeholders := make([]string, len(devices))
+	args := make([]interface{}, len(devices))
+	for i := range devices {
+		devices[i].Tags = []string{}
+		index[devices[i].ID] = i
+		placeholders[i] = "?"
+		args[i] = devices[i].ID
+	}
+
+	rows, err := r.db.Query(
+		"SELECT device_id, tag FROM device_tags WHERE device_id IN ("+strings.Join(placeholders, ",")+") ORDER BY tag", args...)
+	if err != nil {
+		return fmt.Errorf("failed to query device tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return fmt.Errorf("failed to scan device tag: %w", err)
+		}
+		if i, ok := index[id]; ok {
+			devices[i].Tags = append(devices[i].Tags, tag)
+		}
+	}
+	return nil
+}
+
+// deviceScope returns an " AND ..." condition limiting idColumn to devices of a site,
+// of a group including its subgroups, or carrying a tag. Returns "" if no scope is set.
+func deviceScope(idColumn string, siteID, groupID *int64, tag string) (string, []interface{}) {
+	var where string
+	var args []interface{}
+
+	if siteID != nil {
+		where += " AND " + idColumn + " IN (SELECT id FROM devices WHERE site_id = ?)"
+		args = append(args, *siteID)
+	}
+	if groupID != nil {
+		where += " AND " + idColumn + ` IN (SELECT id FROM devices WHERE group_id IN (
+			WITH RECURSIVE subgroups(id) AS (
+				SELECT ? UNION SELECT g.id FROM device_groups g JOIN subgroups s ON g.parent_id = s.id
+			) SELECT id FROM subgroups))`
+		args = append(args, *groupID)
+	}
+	if tag != "" {
+		where += " AND " + idColumn + " IN (SELECT device_id FROM device_tags WHERE tag = ?)"
+		args = append(args, tag)
+	}
+	return where, args
+}
+
+func scanDevice(row rowScanner) (*models.Device, error) {
+	d := &models.Device{}
+	err := row.Scan(
+		&d.ID, &d.Name, &d.IPAddress, &d.Type, &d.Manufacturer, &d.Model,
+		&d.CredentialID, &d.SiteID, &d.GroupID, &d.Status, &d.LastCheck, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
 // Delete removes a device by ID
 func (r *DeviceRepository) Delete(id int64) error {
 	_, err := r.db.Exec("DELETE FROM devices WHERE id = ?", id)
@@ -297,7 +444,7 @@ func (r *DeviceRepository) GetStats() (map[string]int, error) {
 	stats["total"] = total
 
 	// Count by type
-	for _, t := range []models.DeviceType{models.DeviceTypeSwitch, models.DeviceTypeServer, models.DeviceTypeCamera} {
+	for _, t := range models.DeviceTypes {
 		count, err := r.CountByType(t)
 		if err != nil {
 			return nil, err
@@ -368,28 +515,17 @@ func (r *DeviceRepository) LinkCameraToPort(portID int64, cameraID *int64) error
 
 // CreateSwitchPorts creates initial ports for a switch
 func (r *DeviceRepository) CreateSwitchPorts(switchID int64, portCount int) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare(`
-		INSERT OR IGNORE INTO switch_ports (switch_id, port_number, name, status)
-		VALUES (?, ?, ?, 'unknown')`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
 	for i := 1; i <= portCount; i++ {
-		_, err := stmt.Exec(switchID, i, fmt.Sprintf("Port %d", i))
+		_, err := r.db.Exec(`
+			INSERT OR IGNORE INTO switch_ports (switch_id, port_number, name, status)
+			VALUES (?, ?, ?, 'unknown')`,
+			switchID, i, fmt.Sprintf("Port %d", i))
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 // UpdatePortStatus updates the status of a switch port
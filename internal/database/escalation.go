@@ -0,0 +1,372 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// EscalationRepository handles on-call schedules, escalation policies and the
+// escalation state of incidents
+type EscalationRepository struct {
+	db Querier
+}
+
+// NewEscalationRepository creates a new escalation repository
+func NewEscalationRepository(db Querier) *EscalationRepository {
+	return &EscalationRepository{db: db}
+}
+
+// CreateSchedule inserts a new on-call schedule. Overrides are managed separately.
+func (r *EscalationRepository) CreateSchedule(s *models.OnCallSchedule) error {
+	members, err := json.Marshal(s.Members)
+	if err != nil {
+		return fmt.Errorf("failed to marshal members: %w", err)
+	}
+
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO oncall_schedules (name, rotation_start, members, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		s.Name, s.RotationStart, string(members), now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create on-call schedule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	s.ID = id
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+// GetScheduleByID retrieves an on-call schedule with its overrides
+func (r *EscalationRepository) GetScheduleByID(id int64) (*models.OnCallSchedule, error) {
+	s, err := scanSchedule(r.db.QueryRow(`
+		SELECT id, name, rotation_start, members, created_at, updated_at
+		FROM oncall_schedules WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get on-call schedule: %w", err)
+	}
+
+	s.Overrides, err = r.GetOverrides(id)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetAllSchedules retrieves all on-call schedules with their overrides
+func (r *EscalationRepository) GetAllSchedules() ([]models.OnCallSchedule, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, rotation_start, members, created_at, updated_at
+		FROM oncall_schedules ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query on-call schedules: %w", err)
+	}
+
+	var schedules []models.OnCallSchedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan on-call schedule: %w", err)
+		}
+		schedules = append(schedules, *s)
+	}
+	rows.Close()
+
+	for i := range schedules {
+		schedules[i].Overrides, err = r.GetOverrides(schedules[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return schedules, nil
+}
+
+// UpdateSchedule updates an on-call schedule
+func (r *EscalationRepository) UpdateSchedule(s *models.OnCallSchedule) error {
+	members, err := json.Marshal(s.Members)
+	if err != nil {
+		return fmt.Errorf("failed to marshal members: %w", err)
+	}
+
+	s.UpdatedAt = time.Now()
+	_, err = r.db.Exec(`
+		UPDATE oncall_schedules SET name = ?, rotation_start = ?, members = ?, updated_at = ?
+		WHERE id = ?`,
+		s.Name, s.RotationStart, string(members), s.UpdatedAt, s.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update on-call schedule: %w", err)
+	}
+	return nil
+}
+
+// DeleteSchedule removes an on-call schedule and its overrides
+func (r *EscalationRepository) DeleteSchedule(id int64) error {
+	if _, err := r.db.Exec("DELETE FROM oncall_overrides WHERE schedule_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete on-call overrides: %w", err)
+	}
+	if _, err := r.db.Exec("DELETE FROM oncall_schedules WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete on-call schedule: %w", err)
+	}
+	return nil
+}
+
+// CreateOverride inserts a new on-call override
+func (r *EscalationRepository) CreateOverride(o *models.OnCallOverride) error {
+	result, err := r.db.Exec(`
+		INSERT INTO oncall_overrides (schedule_id, name, email, start_at, end_at, reason)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		o.ScheduleID, o.Name, o.Email, o.StartAt, o.EndAt, o.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create on-call override: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	o.ID = id
+	return nil
+}
+
+// GetOverrides retrieves overrides of a schedule ordered by creation
+func (r *EscalationRepository) GetOverrides(scheduleID int64) ([]models.OnCallOverride, error) {
+	rows, err := r.db.Query(`
+		SELECT id, schedule_id, name, email, start_at, end_at, reason
+		FROM oncall_overrides WHERE schedule_id = ? ORDER BY id`, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query on-call overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []models.OnCallOverride{}
+	for rows.Next() {
+		var o models.OnCallOverride
+		var email, reason sql.NullString
+		if err := rows.Scan(&o.ID, &o.ScheduleID, &o.Name, &email, &o.StartAt, &o.EndAt, &reason); err != nil {
+			return nil, fmt.Errorf("failed to scan on-call override: %w", err)
+		}
+		o.Email = email.String
+		o.Reason = reason.String
+		overrides = append(overrides, o)
+	}
+	return overrides, nil
+}
+
+// DeleteOverride removes an on-call override
+func (r *EscalationRepository) DeleteOverride(id int64) error {
+	if _, err := r.db.Exec("DELETE FROM oncall_overrides WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete on-call override: %w", err)
+	}
+	return nil
+}
+
+// DeleteOverridesEndedBefore removes overrides that ended before the given time
+func (r *EscalationRepository) DeleteOverridesEndedBefore(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM oncall_overrides WHERE end_at < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old on-call overrides: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// CreatePolicy inserts a new escalation policy
+func (r *EscalationRepository) CreatePolicy(p *models.EscalationPolicy) error {
+	steps, err := json.Marshal(p.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal steps: %w", err)
+	}
+
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO escalation_policies (name, device_id, device_type, enabled, steps, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		p.Name, p.DeviceID, p.DeviceType, p.Enabled, string(steps), now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create escalation policy: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	p.ID = id
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	return nil
+}
+
+// GetPolicyByID retrieves an escalation policy by ID
+func (r *EscalationRepository) GetPolicyByID(id int64) (*models.EscalationPolicy, error) {
+	p, err := scanPolicy(r.db.QueryRow(`
+		SELECT id, name, device_id, device_type, enabled, steps, created_at, updated_at
+		FROM escalation_policies WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation policy: %w", err)
+	}
+	return p, nil
+}
+
+// GetAllPolicies retrieves all escalation policies
+func (r *EscalationRepository) GetAllPolicies() ([]models.EscalationPolicy, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, device_id, device_type, enabled, steps, created_at, updated_at
+		FROM escalation_policies ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query escalation policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []models.EscalationPolicy
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan escalation policy: %w", err)
+		}
+		policies = append(policies, *p)
+	}
+	return policies, nil
+}
+
+// UpdatePolicy updates an escalation policy
+func (r *EscalationRepository) UpdatePolicy(p *models.EscalationPolicy) error {
+	steps, err := json.Marshal(p.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal steps: %w", err)
+	}
+
+	p.UpdatedAt = time.Now()
+	_, err = r.db.Exec(`
+		UPDATE escalation_policies SET name = ?, device_id = ?, device_type = ?, enabled = ?, steps = ?, updated_at = ?
+		WHERE id = ?`,
+		p.Name, p.DeviceID, p.DeviceType, p.Enabled, string(steps), p.UpdatedAt, p.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update escalation policy: %w", err)
+	}
+	return nil
+}
+
+// DeletePolicy removes an escalation policy and its escalation state
+func (r *EscalationRepository) DeletePolicy(id int64) error {
+	if _, err := r.db.Exec("DELETE FROM incident_escalations WHERE policy_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete incident escalations: %w", err)
+	}
+	if _, err := r.db.Exec("DELETE FROM escalation_policies WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete escalation policy: %w", err)
+	}
+	return nil
+}
+
+// RecordEscalation saves that a tier was notified about an incident. Returns false
+// if the tier had already been recorded.
+func (r *EscalationRepository) RecordEscalation(e *models.IncidentEscalation) (bool, error) {
+	result, err := r.db.Exec(`
+		INSERT OR IGNORE INTO incident_escalations (incident_id, policy_id, level, recipients, error, notified_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		e.IncidentID, e.PolicyID, e.Level, e.Recipients, e.Error, e.NotifiedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record escalation: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record escalation: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	e.ID, err = result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return true, nil
+}
+
+// UpdateEscalationResult stores the delivery outcome of a recorded escalation
+func (r *EscalationRepository) UpdateEscalationResult(id int64, recipients, deliveryErr string) error {
+	_, err := r.db.Exec("UPDATE incident_escalations SET recipients = ?, error = ? WHERE id = ?", recipients, deliveryErr, id)
+	if err != nil {
+		return fmt.Errorf("failed to update escalation: %w", err)
+	}
+	return nil
+}
+
+// GetEscalations retrieves the escalations of an incident in the order they happened
+func (r *EscalationRepository) GetEscalations(incidentID int64) ([]models.IncidentEscalation, error) {
+	rows, err := r.db.Query(`
+		SELECT id, incident_id, policy_id, level, recipients, error, notified_at
+		FROM incident_escalations WHERE incident_id = ? ORDER BY notified_at, level`, incidentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query incident escalations: %w", err)
+	}
+	defer rows.Close()
+
+	var escalations []models.IncidentEscalation
+	for rows.Next() {
+		var e models.IncidentEscalation
+		var recipients, deliveryErr sql.NullString
+		if err := rows.Scan(&e.ID, &e.IncidentID, &e.PolicyID, &e.Level, &recipients, &deliveryErr, &e.NotifiedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan incident escalation: %w", err)
+		}
+		e.Recipients = recipients.String
+		e.Error = deliveryErr.String
+		escalations = append(escalations, e)
+	}
+	return escalations, nil
+}
+
+func scanSchedule(row rowScanner) (*models.OnCallSchedule, error) {
+	s := &models.OnCallSchedule{}
+	var members string
+	if err := row.Scan(&s.ID, &s.Name, &s.RotationStart, &members, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if members != "" {
+		if err := json.Unmarshal([]byte(members), &s.Members); err != nil {
+			return nil, fmt.Errorf("failed to parse members: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func scanPolicy(row rowScanner) (*models.EscalationPolicy, error) {
+	p := &models.EscalationPolicy{}
+	var deviceID sql.NullInt64
+	var deviceType sql.NullString
+	var steps string
+	if err := row.Scan(&p.ID, &p.Name, &deviceID, &deviceType, &p.Enabled, &steps, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if deviceID.Valid {
+		p.DeviceID = &deviceID.Int64
+	}
+	p.DeviceType = models.DeviceType(deviceType.String)
+	if steps != "" {
+		if err := json.Unmarshal([]byte(steps), &p.Steps); err != nil {
+			return nil, fmt.Errorf("failed to parse steps: %w", err)
+		}
+	}
+	return p, nil
+}
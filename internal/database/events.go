@@ -11,11 +11,11 @@ import (
 
 // EventRepository handles event database operations
 type EventRepository struct {
-	db *sql.DB
+	db Querier
 }
 
 // NewEventRepository creates a new event repository
-func NewEventRepository(db *sql.DB) *EventRepository {
+func NewEventRepository(db Querier) *EventRepository {
 	return &EventRepository{db: db}
 }
 
@@ -80,6 +80,10 @@ func (r *EventRepository) GetFiltered(filter models.EventFilter) ([]models.Event
 		query += " AND created_at <= ?"
 		args = append(args, *filter.EndTime)
 	}
+	if scope, scopeArgs := deviceScope("device_id", filter.SiteID, filter.GroupID, filter.Tag); scope != "" {
+		query += scope
+		args = append(args, scopeArgs...)
+	}
 
 	query += " ORDER BY created_at DESC"
 
@@ -218,6 +222,10 @@ func (r *EventRepository) GetFilteredWithTotal(filter models.EventFilter, page,
 		whereClause += " AND created_at <= ?"
 		args = append(args, *filter.EndTime)
 	}
+	if scope, scopeArgs := deviceScope("device_id", filter.SiteID, filter.GroupID, filter.Tag); scope != "" {
+		whereClause += scope
+		args = append(args, scopeArgs...)
+	}
 
 	// Count total
 	var total int
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// SiteRepository handles sites
+type SiteRepository struct {
+	db Querier
+}
+
+// NewSiteRepository creates a new site repository
+func NewSiteRepository(db Querier) *SiteRepository {
+	return &SiteRepository{db: db}
+}
+
+// Create inserts a new site
+func (r *SiteRepository) Create(s *models.Site) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO sites (name, address, latitude, longitude, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.Name, s.Address, s.Latitude, s.Longitude, s.Description, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create site: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	s.ID = id
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+// GetByID retrieves a site by ID
+func (r *SiteRepository) GetByID(id int64) (*models.Site, error) {
+	s, err := scanSite(r.db.QueryRow(`
+		SELECT id, name, address, latitude, longitude, description, created_at, updated_at
+		FROM sites WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get site: %w", err)
+	}
+	return s, nil
+}
+
+// GetAll retrieves all sites ordered by name
+func (r *SiteRepository) GetAll() ([]models.Site, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, address, latitude, longitude, description, created_at, updated_at
+		FROM sites ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sites: %w", err)
+	}
+	defer rows.Close()
+
+	var sites []models.Site
+	for rows.Next() {
+		s, err := scanSite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan site: %w", err)
+		}
+		sites = append(sites, *s)
+	}
+	return sites, nil
+}
+
+// Update updates a site
+func (r *SiteRepository) Update(s *models.Site) error {
+	s.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		UPDATE sites SET name = ?, address = ?, latitude = ?, longitude = ?, description = ?, updated_at = ?
+		WHERE id = ?`,
+		s.Name, s.Address, s.Latitude, s.Longitude, s.Description, s.UpdatedAt, s.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update site: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a site. Its devices are left without a site.
+func (r *SiteRepository) Delete(id int64) error {
+	if _, err := r.db.Exec("UPDATE devices SET site_id = NULL WHERE site_id = ?", id); err != nil {
+		return fmt.Errorf("failed to detach devices from site: %w", err)
+	}
+	if _, err := r.db.Exec("DELETE FROM sites WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete site: %w", err)
+	}
+	return nil
+}
+
+func scanSite(row rowScanner) (*models.Site, error) {
+	s := &models.Site{}
+	var address, description sql.NullString
+	var lat, lon sql.NullFloat64
+	if err := row.Scan(&s.ID, &s.Name, &address, &lat, &lon, &description, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	s.Address = address.String
+	s.Description = description.String
+	if lat.Valid {
+		s.Latitude = &lat.Float64
+	}
+	if lon.Valid {
+		s.Longitude = &lon.Float64
+	}
+	return s, nil
+}
+
+// GroupRepository handles the device group tree
+type GroupRepository struct {
+	db Querier
+}
+
+// NewGroupRepository creates a new group repository
+func NewGroupRepository(db Querier) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+// Create inserts a new group
+func (r *GroupRepository) Create(g *models.DeviceGroup) error {
+	settings, err := json.Marshal(g.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal group settings: %w", err)
+	}
+
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO device_groups (name, parent_id, description, settings, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		g.Name, g.ParentID, g.Description, string(settings), now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create group: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	g.ID = id
+	g.CreatedAt = now
+	g.UpdatedAt = now
+	return nil
+}
+
+// GetByID retrieves a group by ID
+func (r *GroupRepository) GetByID(id int64) (*models.DeviceGroup, error) {
+	g, err := scanGroup(r.db.QueryRow(`
+		SELECT id, name, parent_id, description, settings, created_at, updated_at
+		FROM device_groups WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	return g, nil
+}
+
+// GetAll retrieves all groups ordered by name
+func (r *GroupRepository) GetAll() ([]models.DeviceGroup, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, parent_id, description, settings, created_at, updated_at
+		FROM device_groups ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []models.DeviceGroup
+	for rows.Next() {
+		g, err := scanGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		groups = append(groups, *g)
+	}
+	return groups, nil
+}
+
+// Update updates a group
+func (r *GroupRepository) Update(g *models.DeviceGroup) error {
+	settings, err := json.Marshal(g.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal group settings: %w", err)
+	}
+
+	g.UpdatedAt = time.Now()
+	_, err = r.db.Exec(`
+		UPDATE device_groups SET name = ?, parent_id = ?, description = ?, settings = ?, updated_at = ?
+		WHERE id = ?`,
+		g.Name, g.ParentID, g.Description, string(settings), g.UpdatedAt, g.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update group: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a group. Its subgroups and devices move to the parent group.
+func (r *GroupRepository) Delete(id int64) error {
+	var parentID sql.NullInt64
+	err := r.db.QueryRow("SELECT parent_id FROM device_groups WHERE id = ?", id).Scan(&parentID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+
+	var parent interface{}
+	if parentID.Valid {
+		parent = parentID.Int64
+	}
+	if _, err := r.db.Exec("UPDATE device_groups SET parent_id = ? WHERE parent_id = ?", parent, id); err != nil {
+		return fmt.Errorf("failed to move subgroups: %w", err)
+	}
+	if _, err := r.db.Exec("UPDATE devices SET group_id = ? WHERE group_id = ?", parent, id); err != nil {
+		return fmt.Errorf("failed to move group devices: %w", err)
+	}
+	if _, err := r.db.Exec("DELETE FROM device_groups WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	return nil
+}
+
+func scanGroup(row rowScanner) (*models.DeviceGroup, error) {
+	g := &models.DeviceGroup{}
+	var parentID sql.NullInt64
+	var description sql.NullString
+	var settings string
+	if err := row.Scan(&g.ID, &g.Name, &parentID, &description, &settings, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		g.ParentID = &parentID.Int64
+	}
+	g.Description = description.String
+	if settings != "" {
+		if err := json.Unmarshal([]byte(settings), &g.Settings); err != nil {
+			return nil, fmt.Errorf("failed to parse group settings: %w", err)
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,247 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// IncidentRepository handles incidents and their notes
+type IncidentRepository struct {
+	db Querier
+}
+
+// NewIncidentRepository creates a new incident repository
+func NewIncidentRepository(db Querier) *IncidentRepository {
+	return &IncidentRepository{db: db}
+}
+
+const incidentColumns = `
+	i.id, i.device_id, COALESCE(d.name, ''), i.kind, i.status, i.message, i.started_at, i.last_seen_at,
+	i.ended_at, i.check_count, i.acknowledged_by, i.acknowledged_at, i.ack_comment, i.assigned_to, i.last_notified_at
+	FROM incidents i LEFT JOIN devices d ON d.id = i.device_id`
+
+// Open creates a new open incident
+func (r *IncidentRepository) Open(inc *models.Incident) error {
+	result, err := r.db.Exec(`
+		INSERT INTO incidents (device_id, kind, status, message, started_at, last_seen_at, check_count)
+		VALUES (?, ?, ?, ?, ?, ?, 1)`,
+		inc.DeviceID, inc.Kind, models.IncidentStatusOpen, inc.Message, inc.StartedAt, inc.StartedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to open incident: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	inc.ID = id
+	inc.Status = models.IncidentStatusOpen
+	inc.LastSeenAt = inc.StartedAt
+	inc.CheckCount = 1
+	return nil
+}
+
+// GetByID retrieves an incident by ID
+func (r *IncidentRepository) GetByID(id int64) (*models.Incident, error) {
+	inc, err := scanIncident(r.db.QueryRow("SELECT "+incidentColumns+" WHERE i.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident: %w", err)
+	}
+	return inc, nil
+}
+
+// GetOpenByDevice retrieves the open incident of a device, if any
+func (r *IncidentRepository) GetOpenByDevice(deviceID int64) (*models.Incident, error) {
+	inc, err := scanIncident(r.db.QueryRow("SELECT "+incidentColumns+`
+		WHERE i.device_id = ? AND i.status = ?
+		ORDER BY i.started_at DESC LIMIT 1`, deviceID, models.IncidentStatusOpen))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open incident: %w", err)
+	}
+	return inc, nil
+}
+
+// GetOpen retrieves all open incidents, newest first
+func (r *IncidentRepository) GetOpen() ([]models.Incident, error) {
+	return r.query("SELECT "+incidentColumns+`
+		WHERE i.status = ? ORDER BY i.started_at DESC`, models.IncidentStatusOpen)
+}
+
+// GetClosed retrieves closed incidents, most recently closed first
+func (r *IncidentRepository) GetClosed(limit, offset int) ([]models.Incident, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return r.query("SELECT "+incidentColumns+`
+		WHERE i.status = ? ORDER BY i.ended_at DESC LIMIT ? OFFSET ?`, models.IncidentStatusClosed, limit, offset)
+}
+
+// Touch records another failing check of an open incident. The kind is updated
+// when the problem changes, e.g. a degraded device going offline.
+func (r *IncidentRepository) Touch(id int64, kind models.IncidentKind, message string, at time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE incidents SET kind = ?, message = ?, last_seen_at = ?, check_count = check_count + 1
+		WHERE id = ? AND status = ?`,
+		kind, message, at, id, models.IncidentStatusOpen,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update incident: %w", err)
+	}
+	return nil
+}
+
+// Close closes an open incident at the given time
+func (r *IncidentRepository) Close(id int64, at time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE incidents SET status = ?, ended_at = ? WHERE id = ? AND status = ?`,
+		models.IncidentStatusClosed, at, id, models.IncidentStatusOpen,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to close incident: %w", err)
+	}
+	return nil
+}
+
+// Acknowledge marks an incident as acknowledged by an operator
+func (r *IncidentRepository) Acknowledge(id int64, user, comment string, at time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE incidents SET acknowledged_by = ?, acknowledged_at = ?, ack_comment = ? WHERE id = ?`,
+		user, at, comment, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge incident: %w", err)
+	}
+	return nil
+}
+
+// Assign sets the operator responsible for an incident, empty to unassign
+func (r *IncidentRepository) Assign(id int64, user string) error {
+	_, err := r.db.Exec("UPDATE incidents SET assigned_to = ? WHERE id = ?", user, id)
+	if err != nil {
+		return fmt.Errorf("failed to assign incident: %w", err)
+	}
+	return nil
+}
+
+// SetNotified records when a notification about an incident was last sent
+func (r *IncidentRepository) SetNotified(id int64, at time.Time) error {
+	_, err := r.db.Exec("UPDATE incidents SET last_notified_at = ? WHERE id = ?", at, id)
+	if err != nil {
+		return fmt.Errorf("failed to update incident notification time: %w", err)
+	}
+	return nil
+}
+
+// AddNote attaches a note to an incident
+func (r *IncidentRepository) AddNote(note *models.IncidentNote) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO incident_notes (incident_id, author, text, created_at) VALUES (?, ?, ?, ?)`,
+		note.IncidentID, note.Author, note.Text, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add incident note: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	note.ID = id
+	note.CreatedAt = now
+	return nil
+}
+
+// GetNotes retrieves notes of an incident, oldest first
+func (r *IncidentRepository) GetNotes(incidentID int64) ([]models.IncidentNote, error) {
+	rows, err := r.db.Query(`
+		SELECT id, incident_id, author, text, created_at FROM incident_notes
+		WHERE incident_id = ? ORDER BY created_at, id`, incidentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query incident notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []models.IncidentNote
+	for rows.Next() {
+		var n models.IncidentNote
+		var author sql.NullString
+		if err := rows.Scan(&n.ID, &n.IncidentID, &author, &n.Text, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan incident note: %w", err)
+		}
+		n.Author = author.String
+		notes = append(notes, n)
+	}
+	return notes, nil
+}
+
+// DeleteClosedBefore removes incidents closed before the given time
+func (r *IncidentRepository) DeleteClosedBefore(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM incidents WHERE status = ? AND ended_at < ?", models.IncidentStatusClosed, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old incidents: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *IncidentRepository) query(query string, args ...interface{}) ([]models.Incident, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var incidents []models.Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, *inc)
+	}
+	return incidents, nil
+}
+
+func scanIncident(row rowScanner) (*models.Incident, error) {
+	inc := &models.Incident{}
+	var (
+		message, ackBy, ackComment, assignedTo sql.NullString
+		endedAt, ackAt, notifiedAt             sql.NullTime
+	)
+	err := row.Scan(&inc.ID, &inc.DeviceID, &inc.DeviceName, &inc.Kind, &inc.Status, &message, &inc.StartedAt, &inc.LastSeenAt,
+		&endedAt, &inc.CheckCount, &ackBy, &ackAt, &ackComment, &assignedTo, &notifiedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	inc.Message = message.String
+	inc.AcknowledgedBy = ackBy.String
+	inc.AckComment = ackComment.String
+	inc.AssignedTo = assignedTo.String
+	if endedAt.Valid {
+		inc.EndedAt = &endedAt.Time
+	}
+	if ackAt.Valid {
+		inc.AcknowledgedAt = &ackAt.Time
+	}
+	if notifiedAt.Valid {
+		inc.LastNotifiedAt = &notifiedAt.Time
+	}
+
+	end := time.Now()
+	if inc.EndedAt != nil {
+		end = *inc.EndedAt
+	}
+	inc.Duration = int64(end.Sub(inc.StartedAt).Seconds())
+	return inc, nil
+}
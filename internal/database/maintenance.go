@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// MaintenanceRepository handles maintenance windows
+type MaintenanceRepository struct {
+	db Querier
+}
+
+// NewMaintenanceRepository creates a new maintenance repository
+func NewMaintenanceRepository(db Querier) *MaintenanceRepository {
+	return &MaintenanceRepository{db: db}
+}
+
+// Create inserts a new maintenance window
+func (r *MaintenanceRepository) Create(w *models.MaintenanceWindow) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO maintenance_windows (name, device_id, device_type, start_at, end_at, description, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		w.Name, w.DeviceID, w.DeviceType, w.StartAt, w.EndAt, w.Description, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	w.ID = id
+	w.CreatedAt = now
+	return nil
+}
+
+// GetByID retrieves a maintenance window by ID
+func (r *MaintenanceRepository) GetByID(id int64) (*models.MaintenanceWindow, error) {
+	row := r.db.QueryRow(`
+		SELECT id, name, device_id, device_type, start_at, end_at, description, created_at
+		FROM maintenance_windows WHERE id = ?`, id)
+
+	w, err := scanMaintenanceWindow(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+	return w, nil
+}
+
+// GetAll retrieves all maintenance windows, newest first
+func (r *MaintenanceRepository) GetAll() ([]models.MaintenanceWindow, error) {
+	return r.query(`
+		SELECT id, name, device_id, device_type, start_at, end_at, description, created_at
+		FROM maintenance_windows ORDER BY start_at DESC`)
+}
+
+// GetOverlapping retrieves maintenance windows overlapping [start, end)
+func (r *MaintenanceRepository) GetOverlapping(start, end time.Time) ([]models.MaintenanceWindow, error) {
+	return r.query(`
+		SELECT id, name, device_id, device_type, start_at, end_at, description, created_at
+		FROM maintenance_windows WHERE end_at > ? AND start_at < ?
+		ORDER BY start_at`, start, end)
+}
+
+// Update updates a maintenance window
+func (r *MaintenanceRepository) Update(w *models.MaintenanceWindow) error {
+	_, err := r.db.Exec(`
+		UPDATE maintenance_windows SET name = ?, device_id = ?, device_type = ?, start_at = ?, end_at = ?, description = ?
+		WHERE id = ?`,
+		w.Name, w.DeviceID, w.DeviceType, w.StartAt, w.EndAt, w.Description, w.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update maintenance window: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a maintenance window
+func (r *MaintenanceRepository) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM maintenance_windows WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+	return nil
+}
+
+func (r *MaintenanceRepository) query(query string, args ...interface{}) ([]models.MaintenanceWindow, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []models.MaintenanceWindow
+	for rows.Next() {
+		w, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		windows = append(windows, *w)
+	}
+	return windows, nil
+}
+
+func scanMaintenanceWindow(row rowScanner) (*models.MaintenanceWindow, error) {
+	w := &models.MaintenanceWindow{}
+	var deviceID sql.NullInt64
+	var deviceType sql.NullString
+	var description sql.NullString
+	err := row.Scan(&w.ID, &w.Name, &deviceID, &deviceType, &w.StartAt, &w.EndAt, &description, &w.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if deviceID.Valid {
+		w.DeviceID = &deviceID.Int64
+	}
+	w.DeviceType = models.DeviceType(deviceType.String)
+	w.Description = description.String
+	return w, nil
+}
@@ -0,0 +1,483 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer version
+// of the application than this one
+var ErrSchemaTooNew = errors.New("database was created by a newer version of the application")
+
+// migration is a numbered schema change. Up and down run in a transaction with
+// foreign keys off, so tables can be rebuilt. Versions are consecutive from 1.
+type migration struct {
+	version int
+	name    string
+	up      func(q Querier) error
+	down    func(q Querier) error
+}
+
+// migrations lists all schema changes in order. Append new ones at the end and
+// never change a released migration.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "initial schema",
+		up: execStatements(
+			migrationCredentials, migrationDevices, migrationSwitches, migrationSwitchPorts,
+			migrationCameras, migrationServers, migrationEvents, migrationSchemas, migrationSchemaItems,
+			migrationSettings, migrationStatusHistory, migrationCompliance, migrationStatusPeriods,
+			migrationMaintenance, migrationStatusRollups, migrationIncidents, migrationEscalation,
+			migrationGroups, migrationAssets, migrationAudit, migrationUsers,
+		),
+		down: dropTables(
+			"users", "audit_log",
+			"asset_field_values", "asset_fields", "device_assets",
+			"device_tags", "device_groups", "sites",
+			"incident_escalations", "escalation_policies", "oncall_overrides", "oncall_schedules",
+			"incident_notes", "incidents",
+			"rollup_state", "status_history_daily", "status_history_hourly",
+			"maintenance_windows", "status_periods",
+			"compliance_reports", "compliance_state", "compliance_baselines",
+			"status_history", "settings", "schema_items", "schemas", "events",
+			"servers", "cameras", "switch_ports", "switches", "devices", "credentials",
+		),
+	},
+	{
+		// Databases created before versioned migrations may lack columns the
+		// initial schema has. They are part of that schema, so down keeps them.
+		version: 2,
+		name:    "columns of older databases",
+		up: func(q Querier) error {
+			for _, c := range legacyColumns {
+				if err := addColumn(q, c.table, c.column, c.definition); err != nil {
+					return err
+				}
+			}
+			return execStatements(`
+CREATE INDEX IF NOT EXISTS idx_devices_site ON devices(site_id);
+CREATE INDEX IF NOT EXISTS idx_devices_group ON devices(group_id);
+`)(q)
+		},
+		down: execStatements(`
+DROP INDEX IF EXISTS idx_devices_site;
+DROP INDEX IF EXISTS idx_devices_group;
+`),
+	},
+	{
+		// Device and credential types are validated by the application, so new
+		// types need no schema change
+		version: 3,
+		name:    "remove type CHECK constraints",
+		up: func(q Querier) error {
+			if err := rebuildTable(q, "devices", removeCheck("type")); err != nil {
+				return err
+			}
+			return rebuildTable(q, "credentials", removeCheck("type"))
+		},
+		down: func(q Querier) error {
+			deviceTypes := []string{"switch", "server", "camera"}
+			credentialTypes := []string{"snmp", "rtsp", "onvif", "ssh"}
+			if err := requireValues(q, "devices", "type", deviceTypes); err != nil {
+				return err
+			}
+			if err := requireValues(q, "credentials", "type", credentialTypes); err != nil {
+				return err
+			}
+			if err := rebuildTable(q, "devices", addCheck("type", deviceTypes)); err != nil {
+				return err
+			}
+			return rebuildTable(q, "credentials", addCheck("type", credentialTypes))
+		},
+	},
+	{
+		version: 4,
+		name:    "routers, access points, UPS units, NVRs and PDUs",
+		up:      execStatements(migrationSNMPDevices),
+		down:    dropTables("nvrs", "snmp_devices"),
+	},
+	{
+		version: 5,
+		name:    "UPS units read over NUT",
+		up:      execStatements(migrationUPSDevices),
+		down:    dropTables("ups_devices"),
+	},
+	{
+		version: 6,
+		name:    "switch environment",
+		up: func(q Querier) error {
+			if err := addColumn(q, "switches", "poe_budget_w", "REAL DEFAULT 0"); err != nil {
+				return err
+			}
+			return execStatements(migrationSwitchEnvironment)(q)
+		},
+		down: func(q Querier) error {
+			if err := dropTables("switch_environment")(q); err != nil {
+				return err
+			}
+			_, err := q.Exec("ALTER TABLE switches DROP COLUMN poe_budget_w")
+			return err
+		},
+	},
+	{
+		version: 7,
+		name:    "PoE power history and energy",
+		up:      execStatements(migrationPoEPower),
+		down:    dropTables("poe_energy_daily", "poe_power"),
+	},
+	{
+		version: 8,
+		name:    "switch port history",
+		up: func(q Querier) error {
+			for _, column := range []string{"duplex", "admin_status", "poe_status"} {
+				if err := addColumn(q, "switch_ports", column, "TEXT DEFAULT ''"); err != nil {
+					return err
+				}
+			}
+			return execStatements(migrationPortHistory)(q)
+		},
+		down: func(q Querier) error {
+			if err := dropTables("port_history")(q); err != nil {
+				return err
+			}
+			for _, column := range []string{"duplex", "admin_status", "poe_status"} {
+				if _, err := q.Exec("ALTER TABLE switch_ports DROP COLUMN " + column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// legacyColumns are the columns added to tables of the initial schema over time,
+// in the order older versions added them
+var legacyColumns = []struct {
+	table, column, definition string
+}{
+	{"devices", "manufacturer", "TEXT DEFAULT ''"},
+	{"switches", "snmpv3_user", "TEXT DEFAULT ''"},
+	{"switches", "snmpv3_security", "TEXT DEFAULT 'noAuthNoPriv'"},
+	{"switches", "snmpv3_auth_proto", "TEXT DEFAULT ''"},
+	{"switches", "snmpv3_auth_pass", "TEXT DEFAULT ''"},
+	{"switches", "snmpv3_priv_proto", "TEXT DEFAULT ''"},
+	{"switches", "snmpv3_priv_pass", "TEXT DEFAULT ''"},
+	{"switch_ports", "port_type", "TEXT DEFAULT 'copper' CHECK(port_type IN ('copper', 'sfp'))"},
+	{"switch_ports", "linked_switch_id", "INTEGER REFERENCES devices(id) ON DELETE SET NULL"},
+	{"switches", "sfp_port_count", "INTEGER DEFAULT 0"},
+	{"switches", "uplink_switch_id", "INTEGER REFERENCES devices(id) ON DELETE SET NULL"},
+	{"switches", "uplink_port_id", "INTEGER REFERENCES switch_ports(id) ON DELETE SET NULL"},
+	{"servers", "uplink_switch_id", "INTEGER REFERENCES devices(id) ON DELETE SET NULL"},
+	{"servers", "uplink_port_id", "INTEGER REFERENCES switch_ports(id) ON DELETE SET NULL"},
+	{"switches", "snmp_write_community", "TEXT DEFAULT ''"},
+	{"devices", "site_id", "INTEGER REFERENCES sites(id) ON DELETE SET NULL"},
+	{"devices", "group_id", "INTEGER REFERENCES device_groups(id) ON DELETE SET NULL"},
+}
+
+const migrationSchemaMigrations = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at DATETIME NOT NULL
+);
+`
+
+// LatestSchemaVersion returns the schema version this build migrates to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// Migrate applies all pending migrations. A database migrated by a newer version
+// is refused with ErrSchemaTooNew.
+func (d *Database) Migrate() error {
+	return d.MigrateTo(LatestSchemaVersion())
+}
+
+// MigrateTo migrates the database up or down to the given schema version. Each
+// migration runs in its own transaction and is recorded in schema_migrations.
+func (d *Database) MigrateTo(target int) error {
+	if target < 0 || target > LatestSchemaVersion() {
+		return fmt.Errorf("invalid schema version %d", target)
+	}
+
+	// Foreign keys can only be switched outside a transaction, so all
+	// migrations run on one connection
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, migrationSchemaMigrations); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	err = conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+	if current > LatestSchemaVersion() {
+		return fmt.Errorf("%w (schema version %d, supported up to %d)", ErrSchemaTooNew, current, LatestSchemaVersion())
+	}
+
+	var foreignKeys bool
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return fmt.Errorf("failed to get foreign key mode: %w", err)
+	}
+	if foreignKeys {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return fmt.Errorf("failed to disable foreign keys: %w", err)
+		}
+		defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+	}
+
+	for current < target {
+		m := migrations[current]
+		err := runMigration(ctx, conn, func(tx *sql.Tx) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				m.version, m.name, time.Now())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		current = m.version
+	}
+
+	for current > target {
+		m := migrations[current-1]
+		err := runMigration(ctx, conn, func(tx *sql.Tx) error {
+			if err := m.down(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("reverting migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		current = m.version - 1
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the schema version of the database, 0 for databases
+// created before versioned migrations
+func (d *Database) SchemaVersion() (int, error) {
+	var exists int
+	err := d.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'").Scan(&exists)
+	if err != nil || exists == 0 {
+		return 0, err
+	}
+
+	var version int
+	if err := d.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
+
+// runMigration runs fn in a transaction on the connection
+func runMigration(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execStatements returns a migration step running the statements in order
+func execStatements(statements ...string) func(q Querier) error {
+	return func(q Querier) error {
+		for _, statement := range statements {
+			if _, err := q.Exec(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// dropTables returns a migration step dropping the tables
+func dropTables(tables ...string) func(q Querier) error {
+	return func(q Querier) error {
+		for _, table := range tables {
+			if _, err := q.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+				return fmt.Errorf("failed to drop %s: %w", table, err)
+			}
+		}
+		return nil
+	}
+}
+
+// addColumn adds a column to a table unless it already exists
+func addColumn(q Querier, table, column, definition string) error {
+	var exists int
+	err := q.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to get columns of %s: %w", table, err)
+	}
+	if exists > 0 {
+		return nil
+	}
+	if _, err := q.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// removeCheck returns an edit of a CREATE TABLE statement that drops the
+// "CHECK(column IN (...))" constraint of a column
+func removeCheck(column string) func(createSQL string) (string, error) {
+	re := regexp.MustCompile(`\s*CHECK\s*\(\s*` + column + `\s+IN\s*\([^)]*\)\s*\)`)
+	return func(createSQL string) (string, error) {
+		return re.ReplaceAllString(createSQL, ""), nil
+	}
+}
+
+// addCheck returns an edit of a CREATE TABLE statement that restricts a
+// "column TEXT NOT NULL" column to the given values
+func addCheck(column string, values []string) func(createSQL string) (string, error) {
+	re := regexp.MustCompile(`([(,]\s*` + column + `\s+TEXT\s+NOT\s+NULL)(\s*[,)])`)
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + v + "'"
+	}
+	return func(createSQL string) (string, error) {
+		if !re.MatchString(createSQL) {
+			return "", fmt.Errorf("column %s not found", column)
+		}
+		return re.ReplaceAllString(createSQL, fmt.Sprintf("$1 CHECK(%s IN (%s))$2", column, strings.Join(quoted, ", "))), nil
+	}
+}
+
+// requireValues fails if rows of a table hold values of a column other than the
+// given ones, naming them, so a CHECK constraint added by a downgrade does not
+// fail on rows written by a newer version
+func requireValues(q Querier, table, column string, values []string) error {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	rows, err := q.Query(fmt.Sprintf("SELECT %s, COUNT(*) FROM %s WHERE %s NOT IN (%s) GROUP BY %s ORDER BY %s",
+		column, table, column, placeholders, column, column), args...)
+	if err != nil {
+		return fmt.Errorf("failed to check %s.%s: %w", table, column, err)
+	}
+	defer rows.Close()
+
+	var unsupported []string
+	for rows.Next() {
+		var value string
+		var count int
+		if err := rows.Scan(&value, &count); err != nil {
+			return fmt.Errorf("failed to check %s.%s: %w", table, column, err)
+		}
+		unsupported = append(unsupported, fmt.Sprintf("%s (%d)", value, count))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check %s.%s: %w", table, column, err)
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("%s has %s values this schema version does not support: %s; delete them before downgrading",
+			table, column, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// createTablePrefix matches the start of a CREATE TABLE statement up to the columns
+var createTablePrefix = regexp.MustCompile(`^CREATE TABLE\s+("?\w+"?)\s*\(`)
+
+// rebuildTable recreates a table from its CREATE TABLE statement changed by edit,
+// keeping rows, indexes, triggers and the AUTOINCREMENT counter. Used for changes
+// ALTER TABLE cannot make, such as dropping a constraint. Foreign keys must be
+// off, otherwise dropping the old table would cascade.
+func rebuildTable(q Querier, table string, edit func(createSQL string) (string, error)) error {
+	var createSQL string
+	err := q.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&createSQL)
+	if err != nil {
+		return fmt.Errorf("failed to get definition of %s: %w", table, err)
+	}
+	newSQL, err := edit(createSQL)
+	if err != nil {
+		return fmt.Errorf("failed to change definition of %s: %w", table, err)
+	}
+	if newSQL == createSQL {
+		return nil
+	}
+
+	temp := table + "_rebuild"
+	if !createTablePrefix.MatchString(newSQL) {
+		return fmt.Errorf("unexpected definition of %s", table)
+	}
+	newSQL = createTablePrefix.ReplaceAllString(newSQL, "CREATE TABLE "+temp+" (")
+
+	var related []string
+	rows, err := q.Query("SELECT sql FROM sqlite_master WHERE tbl_name = ? AND type IN ('index', 'trigger') AND sql IS NOT NULL", table)
+	if err != nil {
+		return fmt.Errorf("failed to get indexes of %s: %w", table, err)
+	}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to get indexes of %s: %w", table, err)
+		}
+		related = append(related, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get indexes of %s: %w", table, err)
+	}
+
+	// Dropping the table removes its AUTOINCREMENT counter, which can be higher
+	// than the largest remaining ID
+	var seq sql.NullInt64
+	if strings.Contains(strings.ToUpper(createSQL), "AUTOINCREMENT") {
+		err := q.QueryRow("SELECT seq FROM sqlite_sequence WHERE name = ?", table).Scan(&seq)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to get sequence of %s: %w", table, err)
+		}
+	}
+
+	statements := []string{
+		newSQL,
+		fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", temp, table),
+		"DROP TABLE " + table,
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", temp, table),
+	}
+	statements = append(statements, related...)
+	for _, statement := range statements {
+		if _, err := q.Exec(statement); err != nil {
+			return fmt.Errorf("failed to rebuild %s: %w", table, err)
+		}
+	}
+
+	if seq.Valid {
+		if _, err := q.Exec("DELETE FROM sqlite_sequence WHERE name = ?", table); err != nil {
+			return fmt.Errorf("failed to restore sequence of %s: %w", table, err)
+		}
+		if _, err := q.Exec("INSERT INTO sqlite_sequence (name, seq) VALUES (?, ?)", table, seq.Int64); err != nil {
+			return fmt.Errorf("failed to restore sequence of %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// schemaOf returns the definitions of all tables, indexes and triggers
+func schemaOf(t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+	rows, err := db.Query(`
+		SELECT type || ' ' || name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	schema := make(map[string]string)
+	for rows.Next() {
+		var name, def string
+		if err := rows.Scan(&name, &def); err != nil {
+			t.Fatal(err)
+		}
+		schema[name] = def
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func schemaVersion(t *testing.T, d *Database) int {
+	t.Helper()
+	version, err := d.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	d, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if v := schemaVersion(t, d); v != LatestSchemaVersion() {
+		t.Fatalf("schema version %d, want %d", v, LatestSchemaVersion())
+	}
+	latest := schemaOf(t, d.DB())
+
+	if err := d.MigrateTo(0); err != nil {
+		t.Fatal(err)
+	}
+	if v := schemaVersion(t, d); v != 0 {
+		t.Fatalf("schema version %d after downgrade, want 0", v)
+	}
+	if left := schemaOf(t, d.DB()); len(left) != 0 {
+		t.Fatalf("downgrade left %v", left)
+	}
+
+	if err := d.MigrateTo(LatestSchemaVersion()); err != nil {
+		t.Fatal(err)
+	}
+	if v := schemaVersion(t, d); v != LatestSchemaVersion() {
+		t.Fatalf("schema version %d, want %d", v, LatestSchemaVersion())
+	}
+	if again := schemaOf(t, d.DB()); !reflect.DeepEqual(latest, again) {
+		t.Fatalf("schema differs after downgrade and upgrade:\n%v\n%v", latest, again)
+	}
+}
+
+func TestMigrateDownRefusesNewDeviceTypes(t *testing.T) {
+	d, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	_, err = d.DB().Exec("INSERT INTO devices (name, ip_address, type) VALUES ('r1', '10.0.0.1', 'router'), ('u1', '10.0.0.2', 'ups')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.MigrateTo(2)
+	if err == nil || !strings.Contains(err.Error(), "router (1)") || !strings.Contains(err.Error(), "ups (1)") {
+		t.Fatalf("downgrade error %v, want one naming the device types", err)
+	}
+	if v := schemaVersion(t, d); v != 3 {
+		t.Fatalf("schema version %d after failed downgrade, want 3", v)
+	}
+
+	var count int
+	if err := d.DB().QueryRow("SELECT COUNT(*) FROM devices").Scan(&count); err != nil || count != 2 {
+		t.Fatalf("devices after failed downgrade: %d, %v", count, err)
+	}
+}
+
+// baselineSchema returns the initial schema as databases created before
+// versioned migrations have it, without the columns added later
+func baselineSchema() []string {
+	legacy := make(map[string]bool)
+	for _, c := range legacyColumns {
+		legacy[c.table+"."+c.column] = true
+	}
+
+	createTable := regexp.MustCompile(`^CREATE TABLE IF NOT EXISTS (\w+)`)
+	var statements []string
+	for _, stmt := range []string{
+		migrationCredentials, migrationDevices, migrationSwitches, migrationSwitchPorts,
+		migrationCameras, migrationServers, migrationEvents, migrationSchemas, migrationSchemaItems,
+		migrationSettings,
+	} {
+		var table string
+		var lines []string
+		for _, line := range strings.Split(stmt, "\n") {
+			if m := createTable.FindStringSubmatch(line); m != nil {
+				table = m[1]
+			}
+			if column, _, ok := strings.Cut(strings.TrimSpace(line), " "); ok && legacy[table+"."+column] {
+				continue
+			}
+			if strings.HasPrefix(line, ")") && len(lines) > 0 {
+				lines[len(lines)-1] = strings.TrimSuffix(lines[len(lines)-1], ",")
+			}
+			lines = append(lines, line)
+		}
+		statements = append(statements, strings.Join(lines, "\n"))
+	}
+	return statements
+}
+
+func TestMigrateBaselineSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	raw, err := sql.Open("sqlite", filepath.Join(dir, "netvision.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range baselineSchema() {
+		if _, err := raw.Exec(stmt); err != nil {
+			raw.Close()
+			t.Fatalf("baseline schema: %v\n%s", err, stmt)
+		}
+	}
+	for _, stmt := range []string{
+		"INSERT INTO credentials (name, type, username) VALUES ('cams', 'rtsp', 'enc')",
+		"INSERT INTO devices (name, ip_address, type, credential_id) VALUES ('sw1', '10.0.0.1', 'switch', NULL)",
+		"INSERT INTO devices (name, ip_address, type, credential_id) VALUES ('cam1', '10.0.0.2', 'camera', 1)",
+		"INSERT INTO switches (device_id, snmp_community, port_count) VALUES (1, 'public', 2)",
+		"INSERT INTO switch_ports (switch_id, port_number, name) VALUES (1, 1, 'Port 1')",
+		"INSERT INTO cameras (device_id, rtsp_url) VALUES (2, 'enc')",
+	} {
+		if _, err := raw.Exec(stmt); err != nil {
+			raw.Close()
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	raw.Close()
+
+	d, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if v := schemaVersion(t, d); v != LatestSchemaVersion() {
+		t.Fatalf("schema version %d, want %d", v, LatestSchemaVersion())
+	}
+	for _, c := range legacyColumns {
+		var exists int
+		err := d.DB().QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", c.table, c.column).Scan(&exists)
+		if err != nil || exists == 0 {
+			t.Errorf("column %s.%s missing after upgrade: %v", c.table, c.column, err)
+		}
+	}
+
+	var names []string
+	rows, err := d.DB().Query("SELECT name || ':' || type || ':' || COALESCE(credential_id, 0) FROM devices ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if got := fmt.Sprint(names); got != "[sw1:switch:0 cam1:camera:1]" {
+		t.Fatalf("devices after upgrade: %s", got)
+	}
+
+	var portType string
+	if err := d.DB().QueryRow("SELECT port_type FROM switch_ports WHERE switch_id = 1").Scan(&portType); err != nil || portType != "copper" {
+		t.Fatalf("port type after upgrade: %q, %v", portType, err)
+	}
+
+	// Types added after the initial schema are accepted once the CHECK is gone
+	if _, err := d.DB().Exec("INSERT INTO devices (name, ip_address, type) VALUES ('r1', '10.0.0.3', 'router')"); err != nil {
+		t.Fatalf("router after upgrade: %v", err)
+	}
+}
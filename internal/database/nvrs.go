@@ -0,0 +1,64 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"netvisionmonitor/internal/models"
+)
+
+// NVRRepository handles NVR-specific database operations
+type NVRRepository struct {
+	db Querier
+}
+
+// NewNVRRepository creates a new NVR repository
+func NewNVRRepository(db Querier) *NVRRepository {
+	return &NVRRepository{db: db}
+}
+
+// Create inserts NVR-specific data
+func (r *NVRRepository) Create(nvr *models.NVR) error {
+	_, err := r.db.Exec("INSERT INTO nvrs (device_id, check_http, http_port) VALUES (?, ?, ?)",
+		nvr.DeviceID, nvr.CheckHTTP, nvr.HTTPPort)
+	if err != nil {
+		return fmt.Errorf("failed to create NVR: %w", err)
+	}
+	return nil
+}
+
+// GetByDeviceID retrieves NVR data by device ID
+func (r *NVRRepository) GetByDeviceID(deviceID int64) (*models.NVR, error) {
+	nvr := &models.NVR{}
+	err := r.db.QueryRow(`
+		SELECT device_id, COALESCE(check_http, 0), COALESCE(http_port, 80)
+		FROM nvrs WHERE device_id = ?`, deviceID,
+	).Scan(&nvr.DeviceID, &nvr.CheckHTTP, &nvr.HTTPPort)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NVR: %w", err)
+	}
+	return nvr, nil
+}
+
+// Update updates NVR-specific data
+func (r *NVRRepository) Update(nvr *models.NVR) error {
+	_, err := r.db.Exec("UPDATE nvrs SET check_http = ?, http_port = ? WHERE device_id = ?",
+		nvr.CheckHTTP, nvr.HTTPPort, nvr.DeviceID)
+	if err != nil {
+		return fmt.Errorf("failed to update NVR: %w", err)
+	}
+	return nil
+}
+
+// Delete removes NVR data by device ID
+func (r *NVRRepository) Delete(deviceID int64) error {
+	_, err := r.db.Exec("DELETE FROM nvrs WHERE device_id = ?", deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete NVR: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// PoEPowerRepository stores the PoE power of switch ports and the energy they
+// delivered per day
+type PoEPowerRepository struct {
+	db Querier
+}
+
+// NewPoEPowerRepository creates a new PoE power repository
+func NewPoEPowerRepository(db Querier) *PoEPowerRepository {
+	return &PoEPowerRepository{db: db}
+}
+
+// Record saves a power sample
+func (r *PoEPowerRepository) Record(sample *models.PoEPowerSample) error {
+	_, err := r.db.Exec(`
+		INSERT INTO poe_power (switch_id, port_number, camera_id, power_w, checked_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		sample.SwitchID, sample.PortNumber, sample.CameraID, sample.PowerW, sample.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record PoE power: %w", err)
+	}
+	return nil
+}
+
+// AddEnergy adds energy delivered by a port to its total of a day
+func (r *PoEPowerRepository) AddEnergy(switchID int64, port int, cameraID *int64, day string, energyWh, powerW float64) error {
+	_, err := r.db.Exec(`
+		INSERT INTO poe_energy_daily (switch_id, port_number, day, camera_id, energy_wh, max_power_w)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(switch_id, port_number, day) DO UPDATE SET
+			camera_id = excluded.camera_id,
+			energy_wh = energy_wh + excluded.energy_wh,
+			max_power_w = MAX(max_power_w, excluded.max_power_w)`,
+		switchID, port, day, cameraID, energyWh, powerW,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add PoE energy: %w", err)
+	}
+	return nil
+}
+
+// GetPortHistory returns the power samples of a switch port since a time, oldest first
+func (r *PoEPowerRepository) GetPortHistory(switchID int64, port int, since time.Time) ([]models.PoEPowerSample, error) {
+	return r.query(`
+		SELECT switch_id, port_number, camera_id, power_w, checked_at
+		FROM poe_power
+		WHERE switch_id = ? AND port_number = ? AND checked_at >= ?
+		ORDER BY checked_at`, switchID, port, since)
+}
+
+// GetCameraHistory returns the power samples of the ports a camera was linked to
+// since a time, oldest first
+func (r *PoEPowerRepository) GetCameraHistory(cameraID int64, since time.Time) ([]models.PoEPowerSample, error) {
+	return r.query(`
+		SELECT switch_id, port_number, camera_id, power_w, checked_at
+		FROM poe_power
+		WHERE camera_id = ? AND checked_at >= ?
+		ORDER BY checked_at`, cameraID, since)
+}
+
+// GetDailyEnergy returns the energy of all ports for the days from first to last
+// inclusive, days formatted as 2006-01-02
+func (r *PoEPowerRepository) GetDailyEnergy(first, last string) ([]models.PortEnergyDay, error) {
+	rows, err := r.db.Query(`
+		SELECT switch_id, port_number, camera_id, day, energy_wh, max_power_w
+		FROM poe_energy_daily
+		WHERE day >= ? AND day <= ?
+		ORDER BY day, switch_id, port_number`, first, last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PoE energy: %w", err)
+	}
+	defer rows.Close()
+
+	var days []models.PortEnergyDay
+	for rows.Next() {
+		var d models.PortEnergyDay
+		var cameraID sql.NullInt64
+		if err := rows.Scan(&d.SwitchID, &d.PortNumber, &cameraID, &d.Day, &d.EnergyWh, &d.MaxPowerW); err != nil {
+			return nil, err
+		}
+		if cameraID.Valid {
+			d.CameraID = &cameraID.Int64
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// DeleteOlderThan removes power samples taken before a time
+func (r *PoEPowerRepository) DeleteOlderThan(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM poe_power WHERE checked_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteEnergyBefore removes daily energy of days before a day
+func (r *PoEPowerRepository) DeleteEnergyBefore(day string) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM poe_energy_daily WHERE day < ?", day)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *PoEPowerRepository) query(query string, args ...interface{}) ([]models.PoEPowerSample, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PoE power: %w", err)
+	}
+	defer rows.Close()
+
+	samples := []models.PoEPowerSample{}
+	for rows.Next() {
+		var s models.PoEPowerSample
+		var cameraID sql.NullInt64
+		if err := rows.Scan(&s.SwitchID, &s.PortNumber, &cameraID, &s.PowerW, &s.CheckedAt); err != nil {
+			return nil, err
+		}
+		if cameraID.Valid {
+			s.CameraID = &cameraID.Int64
+		}
+		samples = append(samples, s)
+	}
+	return samples, rows.Err()
+}
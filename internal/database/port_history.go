@@ -0,0 +1,130 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// PortHistoryRepository stores state transitions of switch ports
+type PortHistoryRepository struct {
+	db Querier
+}
+
+// NewPortHistoryRepository creates a new port history repository
+func NewPortHistoryRepository(db Querier) *PortHistoryRepository {
+	return &PortHistoryRepository{db: db}
+}
+
+// Record saves a port state change
+func (r *PortHistoryRepository) Record(change *models.PortStateChange) error {
+	if change.ChangedAt.IsZero() {
+		change.ChangedAt = time.Now()
+	}
+	result, err := r.db.Exec(`
+		INSERT INTO port_history (switch_id, port_number, field, old_value, new_value, flap, source, actor, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		change.SwitchID, change.PortNumber, change.Field, change.OldValue, change.NewValue,
+		change.Flap, change.Source, change.Actor, change.ChangedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record port change: %w", err)
+	}
+	change.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetPortHistory returns the latest state changes of a switch port, newest first
+func (r *PortHistoryRepository) GetPortHistory(switchID int64, port int, limit int) ([]models.PortStateChange, error) {
+	rows, err := r.db.Query(`
+		SELECT id, switch_id, port_number, field, COALESCE(old_value, ''), COALESCE(new_value, ''),
+			flap, source, COALESCE(actor, ''), changed_at
+		FROM port_history
+		WHERE switch_id = ? AND port_number = ?
+		ORDER BY changed_at DESC, id DESC
+		LIMIT ?`, switchID, port, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get port history: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []models.PortStateChange{}
+	for rows.Next() {
+		var c models.PortStateChange
+		if err := rows.Scan(&c.ID, &c.SwitchID, &c.PortNumber, &c.Field, &c.OldValue, &c.NewValue,
+			&c.Flap, &c.Source, &c.Actor, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// CountFlaps returns the number of link losses of a port since a time
+func (r *PortHistoryRepository) CountFlaps(switchID int64, port int, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM port_history
+		WHERE switch_id = ? AND port_number = ? AND flap = 1 AND changed_at >= ?`,
+		switchID, port, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count port flaps: %w", err)
+	}
+	return count, nil
+}
+
+// GetFlapCounters returns the link losses of the ports of a switch that lost
+// their link at least once, counted back from now
+func (r *PortHistoryRepository) GetFlapCounters(switchID int64, now time.Time) ([]models.PortFlapCounter, error) {
+	rows, err := r.db.Query(`
+		SELECT port_number,
+			SUM(CASE WHEN changed_at >= ? THEN 1 ELSE 0 END),
+			SUM(CASE WHEN changed_at >= ? THEN 1 ELSE 0 END),
+			SUM(CASE WHEN changed_at >= ? THEN 1 ELSE 0 END),
+			COUNT(*)
+		FROM port_history
+		WHERE switch_id = ? AND flap = 1
+		GROUP BY port_number
+		ORDER BY port_number`,
+		now.Add(-time.Hour), now.Add(-24*time.Hour), now.AddDate(0, 0, -7), switchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get port flaps: %w", err)
+	}
+	defer rows.Close()
+
+	counters := []models.PortFlapCounter{}
+	for rows.Next() {
+		c := models.PortFlapCounter{SwitchID: switchID}
+		if err := rows.Scan(&c.PortNumber, &c.LastHour, &c.LastDay, &c.LastWeek, &c.Total); err != nil {
+			return nil, err
+		}
+		counters = append(counters, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range counters {
+		var last time.Time
+		err := r.db.QueryRow(`
+			SELECT changed_at FROM port_history
+			WHERE switch_id = ? AND port_number = ? AND flap = 1
+			ORDER BY changed_at DESC LIMIT 1`, switchID, counters[i].PortNumber).Scan(&last)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get last port flap: %w", err)
+		}
+		counters[i].LastFlapAt = &last
+	}
+	return counters, nil
+}
+
+// DeleteOlderThan removes port changes made before a time
+func (r *PortHistoryRepository) DeleteOlderThan(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM port_history WHERE changed_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -10,11 +10,11 @@ import (
 
 // SchemaRepository handles schema database operations
 type SchemaRepository struct {
-	db *sql.DB
+	db Querier
 }
 
 // NewSchemaRepository creates a new schema repository
-func NewSchemaRepository(db *sql.DB) *SchemaRepository {
+func NewSchemaRepository(db Querier) *SchemaRepository {
 	return &SchemaRepository{db: db}
 }
 
@@ -106,11 +106,11 @@ func (r *SchemaRepository) Delete(id int64) error {
 
 // SchemaItemRepository handles schema item database operations
 type SchemaItemRepository struct {
-	db *sql.DB
+	db Querier
 }
 
 // NewSchemaItemRepository creates a new schema item repository
-func NewSchemaItemRepository(db *sql.DB) *SchemaItemRepository {
+func NewSchemaItemRepository(db Querier) *SchemaItemRepository {
 	return &SchemaItemRepository{db: db}
 }
 
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// secretColumns lists the columns holding values encrypted with the master key,
+// with the column identifying a row of the table
+var secretColumns = []struct {
+	table   string
+	key     string
+	columns []string
+}{
+	{"credentials", "id", []string{"username", "password"}},
+	{"switches", "device_id", []string{"snmp_community", "snmp_write_community", "snmpv3_auth_pass", "snmpv3_priv_pass"}},
+	{"cameras", "device_id", []string{"rtsp_url"}},
+	{"snmp_devices", "device_id", []string{"snmp_community", "snmpv3_auth_pass", "snmpv3_priv_pass"}},
+}
+
+// IsSecretColumn reports whether a column holds values encrypted with the master key
+func IsSecretColumn(table, column string) bool {
+	for _, t := range secretColumns {
+		if t.table == table {
+			for _, c := range t.columns {
+				if c == column {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// ReencryptSecrets passes every encrypted value, including the given settings, through
+// reencrypt and stores the result. Empty values are left alone. Any failure aborts
+// with an error, so it should run in a transaction. Returns the number of values
+// re-encrypted.
+func ReencryptSecrets(q Querier, settingKeys []string, reencrypt func(string) (string, error)) (int, error) {
+	count := 0
+	for _, t := range secretColumns {
+		n, err := reencryptTable(q, t.table, t.key, t.columns, reencrypt)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+
+	settings := NewSettingsRepository(q)
+	for _, key := range settingKeys {
+		value, err := settings.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if value == "" {
+			continue
+		}
+		value, err = reencrypt(value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-encrypt setting %s: %w", key, err)
+		}
+		if err := settings.Set(key, value); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// CheckSecrets passes every non-empty encrypted value, including the given
+// settings, to check and stops at the first error. Used to find out whether a
+// database belongs to a master key before it is put in place.
+func CheckSecrets(q Querier, settingKeys []string, check func(string) error) error {
+	for _, t := range secretColumns {
+		rows, err := q.Query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(t.columns, ", "), t.table))
+		if err != nil {
+			return fmt.Errorf("failed to query %s: %w", t.table, err)
+		}
+		for rows.Next() {
+			values := make([]sql.NullString, len(t.columns))
+			dest := make([]interface{}, len(values))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan %s: %w", t.table, err)
+			}
+			for i, v := range values {
+				if v.String == "" {
+					continue
+				}
+				if err := check(v.String); err != nil {
+					rows.Close()
+					return fmt.Errorf("%s.%s: %w", t.table, t.columns[i], err)
+				}
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to query %s: %w", t.table, err)
+		}
+	}
+
+	settings := NewSettingsRepository(q)
+	for _, key := range settingKeys {
+		value, err := settings.Get(key)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		if err := check(value); err != nil {
+			return fmt.Errorf("setting %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func reencryptTable(q Querier, table, key string, columns []string, reencrypt func(string) (string, error)) (int, error) {
+	type row struct {
+		id     int64
+		values []string
+	}
+
+	rows, err := q.Query(fmt.Sprintf("SELECT %s, %s FROM %s", key, strings.Join(columns, ", "), table))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	var all []row
+	for rows.Next() {
+		r := row{values: make([]string, len(columns))}
+		nulls := make([]sql.NullString, len(columns))
+		dest := []interface{}{&r.id}
+		for i := range nulls {
+			dest = append(dest, &nulls[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		for i, n := range nulls {
+			r.values[i] = n.String
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+
+	count := 0
+	for _, r := range all {
+		sets := make([]string, 0, len(columns))
+		args := make([]interface{}, 0, len(columns)+1)
+		for i, value := range r.values {
+			if value == "" {
+				continue
+			}
+			value, err := reencrypt(value)
+			if err != nil {
+				return 0, fmt.Errorf("failed to re-encrypt %s.%s of %d: %w", table, columns[i], r.id, err)
+			}
+			sets = append(sets, columns[i]+" = ?")
+			args = append(args, value)
+		}
+		if len(sets) == 0 {
+			continue
+		}
+		args = append(args, r.id)
+		query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?", table, strings.Join(sets, ", "), key)
+		if _, err := q.Exec(query, args...); err != nil {
+			return 0, fmt.Errorf("failed to update %s: %w", table, err)
+		}
+		count += len(sets)
+	}
+	return count, nil
+}
@@ -9,11 +9,11 @@ import (
 
 // ServerRepository handles server-specific database operations
 type ServerRepository struct {
-	db *sql.DB
+	db Querier
 }
 
 // NewServerRepository creates a new server repository
-func NewServerRepository(db *sql.DB) *ServerRepository {
+func NewServerRepository(db Querier) *ServerRepository {
 	return &ServerRepository{db: db}
 }
 
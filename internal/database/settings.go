@@ -8,11 +8,11 @@ import (
 
 // SettingsRepository handles settings database operations
 type SettingsRepository struct {
-	db *sql.DB
+	db Querier
 }
 
 // NewSettingsRepository creates a new settings repository
-func NewSettingsRepository(db *sql.DB) *SettingsRepository {
+func NewSettingsRepository(db Querier) *SettingsRepository {
 	return &SettingsRepository{db: db}
 }
 
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"netvisionmonitor/internal/encryption"
+	"netvisionmonitor/internal/models"
+)
+
+// SNMPDeviceRepository handles SNMP settings of routers, access points, UPS units,
+// NVRs and PDUs
+type SNMPDeviceRepository struct {
+	db Querier
+}
+
+// NewSNMPDeviceRepository creates a new SNMP device repository
+func NewSNMPDeviceRepository(db Querier) *SNMPDeviceRepository {
+	return &SNMPDeviceRepository{db: db}
+}
+
+// encryptSNMPSecrets encrypts the community and SNMPv3 passwords
+func encryptSNMPSecrets(dev *models.SNMPDevice) (community, authPass, privPass string, err error) {
+	if community, err = encryption.EncryptIfNotEmpty(dev.SNMPCommunity); err != nil {
+		return "", "", "", fmt.Errorf("failed to encrypt SNMP community: %w", err)
+	}
+	if authPass, err = encryption.EncryptIfNotEmpty(dev.SNMPv3AuthPass); err != nil {
+		return "", "", "", fmt.Errorf("failed to encrypt SNMPv3 auth password: %w", err)
+	}
+	if privPass, err = encryption.EncryptIfNotEmpty(dev.SNMPv3PrivPass); err != nil {
+		return "", "", "", fmt.Errorf("failed to encrypt SNMPv3 priv password: %w", err)
+	}
+	return community, authPass, privPass, nil
+}
+
+// Create inserts SNMP settings of a device
+func (r *SNMPDeviceRepository) Create(dev *models.SNMPDevice) error {
+	community, authPass, privPass, err := encryptSNMPSecrets(dev)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO snmp_devices (device_id, snmp_community, snmp_version, snmpv3_user, snmpv3_security,
+			snmpv3_auth_proto, snmpv3_auth_pass, snmpv3_priv_proto, snmpv3_priv_pass, uplink_switch_id, uplink_port_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		dev.DeviceID, community, dev.SNMPVersion, dev.SNMPv3User, dev.SNMPv3Security,
+		dev.SNMPv3AuthProto, authPass, dev.SNMPv3PrivProto, privPass, dev.UplinkSwitchID, dev.UplinkPortID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create SNMP settings: %w", err)
+	}
+	return nil
+}
+
+// GetByDeviceID retrieves SNMP settings by device ID
+func (r *SNMPDeviceRepository) GetByDeviceID(deviceID int64) (*models.SNMPDevice, error) {
+	dev := &models.SNMPDevice{}
+	var community, authPass, privPass string
+	var uplinkSwitchID, uplinkPortID sql.NullInt64
+
+	err := r.db.QueryRow(`
+		SELECT device_id, COALESCE(snmp_community, ''), COALESCE(snmp_version, 'v2c'),
+			COALESCE(snmpv3_user, ''), COALESCE(snmpv3_security, 'noAuthNoPriv'),
+			COALESCE(snmpv3_auth_proto, ''), COALESCE(snmpv3_auth_pass, ''),
+			COALESCE(snmpv3_priv_proto, ''), COALESCE(snmpv3_priv_pass, ''),
+			uplink_switch_id, uplink_port_id
+		FROM snmp_devices WHERE device_id = ?`, deviceID,
+	).Scan(&dev.DeviceID, &community, &dev.SNMPVersion, &dev.SNMPv3User, &dev.SNMPv3Security,
+		&dev.SNMPv3AuthProto, &authPass, &dev.SNMPv3PrivProto, &privPass, &uplinkSwitchID, &uplinkPortID)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SNMP settings: %w", err)
+	}
+
+	if dev.SNMPCommunity, err = encryption.DecryptIfNotEmpty(community); err != nil {
+		return nil, fmt.Errorf("failed to decrypt SNMP community: %w", err)
+	}
+	if dev.SNMPv3AuthPass, err = encryption.DecryptIfNotEmpty(authPass); err != nil {
+		return nil, fmt.Errorf("failed to decrypt SNMPv3 auth password: %w", err)
+	}
+	if dev.SNMPv3PrivPass, err = encryption.DecryptIfNotEmpty(privPass); err != nil {
+		return nil, fmt.Errorf("failed to decrypt SNMPv3 priv password: %w", err)
+	}
+
+	if uplinkSwitchID.Valid {
+		dev.UplinkSwitchID = &uplinkSwitchID.Int64
+	}
+	if uplinkPortID.Valid {
+		dev.UplinkPortID = &uplinkPortID.Int64
+	}
+
+	return dev, nil
+}
+
+// Update updates SNMP settings of a device
+func (r *SNMPDeviceRepository) Update(dev *models.SNMPDevice) error {
+	community, authPass, privPass, err := encryptSNMPSecrets(dev)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		UPDATE snmp_devices SET snmp_community = ?, snmp_version = ?, snmpv3_user = ?, snmpv3_security = ?,
+			snmpv3_auth_proto = ?, snmpv3_auth_pass = ?, snmpv3_priv_proto = ?, snmpv3_priv_pass = ?,
+			uplink_switch_id = ?, uplink_port_id = ?
+		WHERE device_id = ?`,
+		community, dev.SNMPVersion, dev.SNMPv3User, dev.SNMPv3Security,
+		dev.SNMPv3AuthProto, authPass, dev.SNMPv3PrivProto, privPass,
+		dev.UplinkSwitchID, dev.UplinkPortID, dev.DeviceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update SNMP settings: %w", err)
+	}
+	return nil
+}
+
+// Delete removes SNMP settings by device ID
+func (r *SNMPDeviceRepository) Delete(deviceID int64) error {
+	_, err := r.db.Exec("DELETE FROM snmp_devices WHERE device_id = ?", deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete SNMP settings: %w", err)
+	}
+	return nil
+}
@@ -3,16 +3,18 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"math"
 	"time"
 
 	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/sla"
 )
 
 type StatusHistoryRepository struct {
-	db *sql.DB
+	db Querier
 }
 
-func NewStatusHistoryRepository(db *sql.DB) *StatusHistoryRepository {
+func NewStatusHistoryRepository(db Querier) *StatusHistoryRepository {
 	return &StatusHistoryRepository{db: db}
 }
 
@@ -57,7 +59,8 @@ func (r *StatusHistoryRepository) GetHistory(deviceID int64, limit int) ([]model
 	return history, nil
 }
 
-// GetLatencyPoints returns latency data points for graphing
+// GetLatencyPoints returns latency data points for graphing. Long ranges are served
+// from hourly or daily rollups with one point per period.
 func (r *StatusHistoryRepository) GetLatencyPoints(deviceID int64, hours int) ([]models.LatencyPoint, error) {
 	if hours <= 0 {
 		hours = 24
@@ -65,6 +68,30 @@ func (r *StatusHistoryRepository) GetLatencyPoints(deviceID int64, hours int) ([
 
 	since := time.Now().Add(-time.Duration(hours) * time.Hour)
 
+	tier, err := r.pickTier(deviceID, since)
+	if err != nil {
+		return nil, err
+	}
+	if tier != "" {
+		aggregates, err := r.GetAggregates(deviceID, tier, since)
+		if err != nil {
+			return nil, err
+		}
+		points := make([]models.LatencyPoint, 0, len(aggregates))
+		for _, a := range aggregates {
+			status := string(models.DeviceStatusOffline)
+			if a.OnlineCount*2 >= a.Checks {
+				status = string(models.DeviceStatusOnline)
+			}
+			points = append(points, models.LatencyPoint{
+				Timestamp: a.PeriodStart,
+				Latency:   int64(math.Round(a.AvgLatency)),
+				Status:    status,
+			})
+		}
+		return points, nil
+	}
+
 	rows, err := r.db.Query(`
 		SELECT created_at, latency, status
 		FROM status_history
@@ -89,30 +116,78 @@ func (r *StatusHistoryRepository) GetLatencyPoints(deviceID int64, hours int) ([
 
 // GetStats returns aggregated statistics for a device
 func (r *StatusHistoryRepository) GetStats(deviceID int64) (*models.DeviceStats, error) {
+	return r.GetStatsSince(deviceID, time.Time{})
+}
+
+// GetStatsSince returns aggregated statistics for a device with totals, uptime
+// and latency limited to checks made after since
+func (r *StatusHistoryRepository) GetStatsSince(deviceID int64, since time.Time) (*models.DeviceStats, error) {
 	stats := &models.DeviceStats{DeviceID: deviceID}
 
-	// Get totals
-	err := r.db.QueryRow(`
+	// Get totals from daily and hourly rollups, then raw history not rolled up yet
+	dailyUntil, err := r.RolledUntil(RollupDaily)
+	if err != nil {
+		return nil, err
+	}
+	hourlyUntil, err := r.RolledUntil(RollupHourly)
+	if err != nil {
+		return nil, err
+	}
+
+	hourlyFrom := latest(since, dailyUntil)
+	rawFrom := latest(hourlyFrom, hourlyUntil)
+
+	var sources []statusTotals
+	if dailyUntil.After(since) {
+		t, err := r.rollupTotals(deviceID, RollupDaily, since, dailyUntil)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, t)
+	}
+	if hourlyUntil.After(hourlyFrom) {
+		t, err := r.rollupTotals(deviceID, RollupHourly, hourlyFrom, hourlyUntil)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, t)
+	}
+
+	var raw statusTotals
+	err = r.db.QueryRow(`
 		SELECT
 			COUNT(*) as total,
-			SUM(CASE WHEN status = 'online' THEN 1 ELSE 0 END) as online,
-			SUM(CASE WHEN status = 'offline' THEN 1 ELSE 0 END) as offline,
-			COALESCE(AVG(CASE WHEN status = 'online' THEN latency END), 0) as avg_latency,
+			COALESCE(SUM(CASE WHEN status = 'online' THEN 1 ELSE 0 END), 0) as online,
+			COALESCE(SUM(CASE WHEN status = 'offline' THEN 1 ELSE 0 END), 0) as offline,
+			COALESCE(SUM(CASE WHEN status = 'online' THEN latency END), 0) as latency_sum,
 			COALESCE(MIN(CASE WHEN status = 'online' THEN latency END), 0) as min_latency,
 			COALESCE(MAX(CASE WHEN status = 'online' THEN latency END), 0) as max_latency
 		FROM status_history
-		WHERE device_id = ?
-	`, deviceID).Scan(
-		&stats.TotalChecks,
-		&stats.OnlineCount,
-		&stats.OfflineCount,
-		&stats.AvgLatency,
-		&stats.MinLatency,
-		&stats.MaxLatency,
-	)
+		WHERE device_id = ? AND created_at >= ?
+	`, deviceID, rawFrom).Scan(&raw.checks, &raw.online, &raw.offline, &raw.latencySum, &raw.minLatency, &raw.maxLatency)
 	if err != nil {
 		return nil, err
 	}
+	sources = append(sources, raw)
+
+	var latencySum float64
+	for _, t := range sources {
+		if t.online > 0 {
+			if stats.OnlineCount == 0 || t.minLatency < stats.MinLatency {
+				stats.MinLatency = t.minLatency
+			}
+			if t.maxLatency > stats.MaxLatency {
+				stats.MaxLatency = t.maxLatency
+			}
+		}
+		stats.TotalChecks += t.checks
+		stats.OnlineCount += t.online
+		stats.OfflineCount += t.offline
+		latencySum += t.latencySum
+	}
+	if stats.OnlineCount > 0 {
+		stats.AvgLatency = latencySum / float64(stats.OnlineCount)
+	}
 
 	// Calculate uptime
 	if stats.TotalChecks > 0 {
@@ -178,61 +253,88 @@ func (r *StatusHistoryRepository) GetStats(deviceID int64) (*models.DeviceStats,
 	return stats, nil
 }
 
-// GetUptimeByPeriod returns uptime grouped by time period
+// GetUptimeByPeriod returns uptime grouped by time period. The number of checks
+// comes from hourly rollups for hours and daily rollups for days and weeks; uptime
+// is the share of monitored time the device was online, taken from status periods.
+// Periods older than their retention are gone, such buckets fall back to the share
+// of successful checks.
 func (r *StatusHistoryRepository) GetUptimeByPeriod(deviceID int64, period string, count int) ([]models.UptimePoint, error) {
-	var format string
+	if count <= 0 {
+		count = 30
+	}
+
+	now := time.Now()
+	var (
+		tier   RollupTier
+		since  time.Time
+		bucket func(time.Time) (time.Time, time.Time)
+		label  func(time.Time) string
+	)
 	switch period {
 	case "hour":
-		format = "%Y-%m-%d %H:00"
-	case "day":
-		format = "%Y-%m-%d"
+		tier = RollupHourly
+		since = tier.Start(now).Add(-time.Duration(count-1) * time.Hour)
+		bucket = func(t time.Time) (time.Time, time.Time) { return t, t.Add(time.Hour) }
+		label = func(t time.Time) string { return t.Format("2006-01-02 15:00") }
 	case "week":
-		format = "%Y-W%W"
+		tier = RollupDaily
+		since = weekStart(now).AddDate(0, 0, -7*(count-1))
+		bucket = func(t time.Time) (time.Time, time.Time) {
+			start := weekStart(t)
+			return start, start.AddDate(0, 0, 7)
+		}
+		label = func(t time.Time) string {
+			// Monday-based week number, as in strftime %W
+			return fmt.Sprintf("%d-W%02d", t.Year(), (t.YearDay()+6-(int(t.Weekday())+6)%7)/7)
+		}
 	default:
-		format = "%Y-%m-%d"
 		period = "day"
+		tier = RollupDaily
+		since = tier.Start(now).AddDate(0, 0, -(count - 1))
+		bucket = func(t time.Time) (time.Time, time.Time) { return t, t.AddDate(0, 0, 1) }
+		label = func(t time.Time) string { return t.Format("2006-01-02") }
 	}
 
-	if count <= 0 {
-		count = 30
+	aggregates, err := r.GetAggregates(deviceID, tier, since)
+	if err != nil {
+		return nil, err
 	}
-
-	rows, err := r.db.Query(`
-		SELECT
-			strftime(?, created_at) as period_start,
-			COUNT(*) as total,
-			SUM(CASE WHEN status = 'online' THEN 1 ELSE 0 END) as online
-		FROM status_history
-		WHERE device_id = ?
-		GROUP BY strftime(?, created_at)
-		ORDER BY period_start DESC
-		LIMIT ?
-	`, format, deviceID, format, count)
+	periods, err := NewStatusPeriodRepository(r.db).GetPeriods(deviceID, since, now)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var points []models.UptimePoint
-	for rows.Next() {
-		var p models.UptimePoint
-		var total, online int64
-		if err := rows.Scan(&p.PeriodStart, &total, &online); err != nil {
-			return nil, err
-		}
-		p.Period = period
-		p.TotalChecks = total
-		if total > 0 {
-			p.UptimePercent = float64(online) / float64(total) * 100
+	var (
+		points []models.UptimePoint
+		starts []time.Time
+		online []int64
+	)
+	for _, a := range aggregates {
+		key := label(a.PeriodStart)
+		if n := len(points); n == 0 || points[n-1].PeriodStart != key {
+			points = append(points, models.UptimePoint{Period: period, PeriodStart: key})
+			starts = append(starts, a.PeriodStart)
+			online = append(online, 0)
 		}
-		points = append(points, p)
+		points[len(points)-1].TotalChecks += a.Checks
+		online[len(online)-1] += a.OnlineCount
 	}
 
-	// Reverse to get chronological order
-	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
-		points[i], points[j] = points[j], points[i]
+	for i := range points {
+		start, end := bucket(starts[i])
+		if end.After(now) {
+			end = now
+		}
+		if availability, _ := sla.Compute(periods, start, end, nil); availability.Monitored > 0 {
+			points[i].UptimePercent = availability.AvailabilityPercent
+		} else if points[i].TotalChecks > 0 {
+			points[i].UptimePercent = float64(online[i]) / float64(points[i].TotalChecks) * 100
+		}
 	}
 
+	if len(points) > count {
+		points = points[len(points)-count:]
+	}
 	return points, nil
 }
 
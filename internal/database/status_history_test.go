@@ -0,0 +1,69 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// createTestDevice adds a device to refer history to
+func createTestDevice(t *testing.T, d *Database) int64 {
+	t.Helper()
+	device := &models.Device{Name: "sw1", IPAddress: "10.0.0.1", Type: models.DeviceTypeSwitch, Status: models.DeviceStatusUnknown}
+	if err := NewDeviceRepository(d.DB()).Create(device); err != nil {
+		t.Fatal(err)
+	}
+	return device.ID
+}
+
+// recordCheck stores a check result at the given time as the monitor does
+func recordCheck(t *testing.T, d *Database, deviceID int64, status string, at time.Time) {
+	t.Helper()
+	_, err := d.DB().Exec("INSERT INTO status_history (device_id, status, latency, created_at) VALUES (?, ?, 1, ?)", deviceID, status, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewStatusPeriodRepository(d.DB()).Extend(deviceID, status, at, 15*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetUptimeByPeriodWeightsByTime(t *testing.T) {
+	d, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	deviceID := createTestDevice(t, d)
+
+	// Online for an hour checked every minute, then offline for two hours
+	// checked every ten minutes: 61 of 73 checks are online, but the device
+	// was up only a third of the time
+	yesterday := RollupDaily.Start(time.Now()).AddDate(0, 0, -1)
+	at := yesterday.Add(10 * time.Hour)
+	for i := 0; i < 60; i++ {
+		recordCheck(t, d, deviceID, "online", at)
+		at = at.Add(time.Minute)
+	}
+	for i := 0; i < 12; i++ {
+		recordCheck(t, d, deviceID, "offline", at)
+		at = at.Add(10 * time.Minute)
+	}
+	recordCheck(t, d, deviceID, "online", at)
+
+	points, err := NewStatusHistoryRepository(d.DB()).GetUptimeByPeriod(deviceID, "day", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || points[0].PeriodStart != yesterday.Format("2006-01-02") {
+		t.Fatalf("points %+v, want one for %s", points, yesterday.Format("2006-01-02"))
+	}
+	if points[0].TotalChecks != 73 {
+		t.Errorf("total checks %d, want 73", points[0].TotalChecks)
+	}
+	if want := 100.0 / 3; math.Abs(points[0].UptimePercent-want) > 0.01 {
+		t.Errorf("uptime %.2f%%, want %.2f%% (by checks it would be %.2f%%)", points[0].UptimePercent, want, 61.0/73*100)
+	}
+}
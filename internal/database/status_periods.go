@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// StatusPeriodRepository stores continuous periods of device status.
+// Unlike status_history it keeps one row per status change, so availability
+// can be computed by time rather than by the number of checks.
+type StatusPeriodRepository struct {
+	db Querier
+}
+
+// NewStatusPeriodRepository creates a new status period repository
+func NewStatusPeriodRepository(db Querier) *StatusPeriodRepository {
+	return &StatusPeriodRepository{db: db}
+}
+
+// Extend records a check result. The latest period is extended if the status is
+// unchanged; otherwise a new period is started. If the previous check is older
+// than maxGap, the time in between is left uncovered (no data).
+func (r *StatusPeriodRepository) Extend(deviceID int64, status string, at time.Time, maxGap time.Duration) error {
+	var (
+		id         int64
+		lastStatus string
+		endedAt    time.Time
+	)
+	err := r.db.QueryRow(`
+		SELECT id, status, ended_at FROM status_periods
+		WHERE device_id = ?
+		ORDER BY ended_at DESC LIMIT 1
+	`, deviceID).Scan(&id, &lastStatus, &endedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get status period: %w", err)
+	}
+
+	continuous := err == nil && at.Sub(endedAt) <= maxGap && !at.Before(endedAt)
+	if continuous {
+		// The previous status lasted until this check
+		if _, err := r.db.Exec("UPDATE status_periods SET ended_at = ? WHERE id = ?", at, id); err != nil {
+			return fmt.Errorf("failed to update status period: %w", err)
+		}
+		if lastStatus == status {
+			return nil
+		}
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO status_periods (device_id, status, started_at, ended_at)
+		VALUES (?, ?, ?, ?)
+	`, deviceID, status, at, at)
+	if err != nil {
+		return fmt.Errorf("failed to create status period: %w", err)
+	}
+	return nil
+}
+
+// GetPeriods returns periods of a device overlapping [start, end), oldest first
+func (r *StatusPeriodRepository) GetPeriods(deviceID int64, start, end time.Time) ([]models.StatusPeriod, error) {
+	rows, err := r.db.Query(`
+		SELECT id, device_id, status, started_at, ended_at
+		FROM status_periods
+		WHERE device_id = ? AND ended_at >= ? AND started_at < ?
+		ORDER BY started_at ASC
+	`, deviceID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status periods: %w", err)
+	}
+	defer rows.Close()
+
+	var periods []models.StatusPeriod
+	for rows.Next() {
+		var p models.StatusPeriod
+		if err := rows.Scan(&p.ID, &p.DeviceID, &p.Status, &p.StartedAt, &p.EndedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status period: %w", err)
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+// Backfill builds periods from status_history if no periods were recorded yet.
+// Returns the number of periods created.
+func (r *StatusPeriodRepository) Backfill(maxGap time.Duration) (int, error) {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM status_periods").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count status periods: %w", err)
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT device_id, status, created_at FROM status_history
+		ORDER BY device_id, created_at
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query status history: %w", err)
+	}
+
+	var (
+		periods []models.StatusPeriod
+		current *models.StatusPeriod
+	)
+	for rows.Next() {
+		var (
+			deviceID int64
+			status   string
+			at       time.Time
+		)
+		if err := rows.Scan(&deviceID, &status, &at); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan status history: %w", err)
+		}
+
+		if current != nil && current.DeviceID == deviceID && at.Sub(current.EndedAt) <= maxGap {
+			current.EndedAt = at
+			if current.Status == status {
+				continue
+			}
+		}
+		periods = append(periods, models.StatusPeriod{DeviceID: deviceID, Status: status, StartedAt: at, EndedAt: at})
+		current = &periods[len(periods)-1]
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("failed to read status history: %w", err)
+	}
+	rows.Close()
+
+	for _, p := range periods {
+		_, err := r.db.Exec(`
+			INSERT INTO status_periods (device_id, status, started_at, ended_at)
+			VALUES (?, ?, ?, ?)
+		`, p.DeviceID, p.Status, p.StartedAt, p.EndedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create status period: %w", err)
+		}
+	}
+	return len(periods), nil
+}
+
+// DeleteOlderThan removes periods that ended before the given time
+func (r *StatusPeriodRepository) DeleteOlderThan(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM status_periods WHERE ended_at < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old status periods: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusPeriodsBackfill(t *testing.T) {
+	d, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	deviceID := createTestDevice(t, d)
+
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	for _, check := range []struct {
+		status string
+		after  time.Duration
+	}{
+		{"online", 0}, {"online", time.Minute}, {"offline", 2 * time.Minute},
+		// The monitor was stopped, the gap stays uncovered
+		{"offline", time.Hour}, {"online", time.Hour + time.Minute},
+	} {
+		_, err := d.DB().Exec("INSERT INTO status_history (device_id, status, latency, created_at) VALUES (?, ?, 1, ?)",
+			deviceID, check.status, start.Add(check.after))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := NewStatusPeriodRepository(d.DB())
+	created, err := repo.Backfill(5 * time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != 4 {
+		t.Errorf("created %d periods, want 4", created)
+	}
+
+	periods, err := repo.GetPeriods(deviceID, start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		status     string
+		start, end time.Duration
+	}{
+		{"online", 0, 2 * time.Minute},
+		{"offline", 2 * time.Minute, 2 * time.Minute},
+		{"offline", time.Hour, time.Hour + time.Minute},
+		{"online", time.Hour + time.Minute, time.Hour + time.Minute},
+	}
+	if len(periods) != len(want) {
+		t.Fatalf("got %d periods, want %d: %+v", len(periods), len(want), periods)
+	}
+	for i, w := range want {
+		p := periods[i]
+		if p.Status != w.status || !p.StartedAt.Equal(start.Add(w.start)) || !p.EndedAt.Equal(start.Add(w.end)) {
+			t.Errorf("period %d: %s %v - %v, want %s %v - %v", i, p.Status, p.StartedAt, p.EndedAt,
+				w.status, start.Add(w.start), start.Add(w.end))
+		}
+	}
+
+	// Periods are built only once
+	if created, err := repo.Backfill(5 * time.Minute); err != nil || created != 0 {
+		t.Errorf("second backfill created %d, %v", created, err)
+	}
+}
+
+func TestStatusPeriodsDeleteOlderThan(t *testing.T) {
+	d, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	deviceID := createTestDevice(t, d)
+
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	repo := NewStatusPeriodRepository(d.DB())
+	for _, at := range []time.Time{start, start.Add(time.Hour), start.Add(2 * time.Hour)} {
+		if err := repo.Extend(deviceID, "online", at, 90*time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Extend(deviceID, "offline", start.Add(5*time.Hour), 90*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// A period that is still running at the cutoff is kept whole
+	deleted, err := repo.DeleteOlderThan(start.Add(90 * time.Minute))
+	if err != nil || deleted != 0 {
+		t.Fatalf("deleted %d, %v; want 0", deleted, err)
+	}
+	deleted, err = repo.DeleteOlderThan(start.Add(3 * time.Hour))
+	if err != nil || deleted != 1 {
+		t.Fatalf("deleted %d, %v; want 1", deleted, err)
+	}
+	periods, err := repo.GetPeriods(deviceID, start, start.Add(6*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(periods) != 1 || periods[0].Status != "offline" {
+		t.Errorf("periods after delete: %+v", periods)
+	}
+}
@@ -0,0 +1,385 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// RollupTier is a level of status history aggregation
+type RollupTier string
+
+const (
+	RollupHourly RollupTier = "hourly"
+	RollupDaily  RollupTier = "daily"
+)
+
+// table returns the aggregate table of a tier
+func (t RollupTier) table() string {
+	if t == RollupDaily {
+		return "status_history_daily"
+	}
+	return "status_history_hourly"
+}
+
+// Start truncates a time to the start of its period in local time
+func (t RollupTier) Start(at time.Time) time.Time {
+	at = at.Local()
+	if t == RollupDaily {
+		return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.Local)
+	}
+	return time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), 0, 0, 0, time.Local)
+}
+
+// Next returns the start of the following period
+func (t RollupTier) Next(start time.Time) time.Time {
+	if t == RollupDaily {
+		return start.AddDate(0, 0, 1)
+	}
+	return start.Add(time.Hour)
+}
+
+// RolledUntil returns the time up to which raw history has been rolled up into a tier.
+// Zero time means nothing has been rolled up yet.
+func (r *StatusHistoryRepository) RolledUntil(tier RollupTier) (time.Time, error) {
+	var until time.Time
+	err := r.db.QueryRow("SELECT rolled_until FROM rollup_state WHERE tier = ?", string(tier)).Scan(&until)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get rollup state: %w", err)
+	}
+	return until, nil
+}
+
+// NextRollupPeriod returns the start of the next complete period that has not been
+// rolled up yet, or false if there is nothing to roll up before now
+func (r *StatusHistoryRepository) NextRollupPeriod(tier RollupTier, now time.Time) (time.Time, bool, error) {
+	start, err := r.RolledUntil(tier)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if start.IsZero() {
+		var oldest time.Time
+		err := r.db.QueryRow("SELECT created_at FROM status_history ORDER BY created_at ASC LIMIT 1").Scan(&oldest)
+		if err == sql.ErrNoRows {
+			return time.Time{}, false, nil
+		}
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to get oldest status history: %w", err)
+		}
+		start = tier.Start(oldest)
+	}
+
+	if tier.Next(start).After(tier.Start(now)) {
+		return time.Time{}, false, nil
+	}
+	return start, true, nil
+}
+
+// RollupPeriod aggregates raw history of a period into the tier table and advances
+// the rollup state. Returns the number of device aggregates written.
+func (r *StatusHistoryRepository) RollupPeriod(tier RollupTier, start time.Time) (int, error) {
+	end := tier.Next(start)
+
+	aggregates, err := r.aggregateRaw(nil, tier, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	// Raw history may already be gone for old periods; daily figures can then be
+	// derived from hourly aggregates
+	if len(aggregates) == 0 && tier == RollupDaily {
+		aggregates, err = r.aggregateHourly(start, end)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, a := range aggregates {
+		_, err := r.db.Exec(`
+			INSERT OR REPLACE INTO `+tier.table()+` (device_id, period_start, checks, online_count, min_latency, avg_latency, max_latency, p95_latency)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, a.DeviceID, a.PeriodStart, a.Checks, a.OnlineCount, a.MinLatency, a.AvgLatency, a.MaxLatency, a.P95Latency)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save status aggregate: %w", err)
+		}
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO rollup_state (tier, rolled_until) VALUES (?, ?)
+		ON CONFLICT(tier) DO UPDATE SET rolled_until = excluded.rolled_until
+	`, string(tier), end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update rollup state: %w", err)
+	}
+
+	return len(aggregates), nil
+}
+
+// DeleteRollupsOlderThan removes aggregates of a tier older than the given time
+func (r *StatusHistoryRepository) DeleteRollupsOlderThan(tier RollupTier, before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM "+tier.table()+" WHERE period_start < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old status aggregates: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetAggregates returns per-period aggregates of a device since the given time,
+// combining rolled-up data with raw history not rolled up yet
+func (r *StatusHistoryRepository) GetAggregates(deviceID int64, tier RollupTier, since time.Time) ([]models.StatusAggregate, error) {
+	since = tier.Start(since)
+	until, err := r.RolledUntil(tier)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.StatusAggregate
+	if until.After(since) {
+		rows, err := r.db.Query(`
+			SELECT device_id, period_start, checks, online_count, min_latency, avg_latency, max_latency, p95_latency
+			FROM `+tier.table()+`
+			WHERE device_id = ? AND period_start >= ? AND period_start < ?
+			ORDER BY period_start ASC
+		`, deviceID, since, until)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query status aggregates: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			a, err := scanStatusAggregate(rows)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan status aggregate: %w", err)
+			}
+			result = append(result, a)
+		}
+	}
+
+	tailStart := since
+	if until.After(tailStart) {
+		tailStart = until
+	}
+	tail, err := r.aggregateRaw(&deviceID, tier, tailStart, time.Now().Add(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(result, tail...), nil
+}
+
+// pickTier selects the finest tier suitable for the requested range whose data reaches
+// back to its start. If no tier covers the whole range, the one with the oldest data is
+// used. Returns "" for raw history.
+func (r *StatusHistoryRepository) pickTier(deviceID int64, since time.Time) (RollupTier, error) {
+	span := time.Since(since)
+
+	tiers := []struct {
+		tier    RollupTier
+		query   string
+		maxSpan time.Duration
+	}{
+		{"", "SELECT created_at FROM status_history WHERE device_id = ? ORDER BY created_at ASC LIMIT 1", rawHistoryMaxSpan},
+		{RollupHourly, "SELECT period_start FROM status_history_hourly WHERE device_id = ? ORDER BY period_start ASC LIMIT 1", hourlyHistoryMaxSpan},
+		{RollupDaily, "SELECT period_start FROM status_history_daily WHERE device_id = ? ORDER BY period_start ASC LIMIT 1", 0},
+	}
+
+	var (
+		best       RollupTier
+		bestOldest time.Time
+	)
+	for _, t := range tiers {
+		var oldest time.Time
+		err := r.db.QueryRow(t.query, deviceID).Scan(&oldest)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to check history coverage: %w", err)
+		}
+		if !oldest.After(since) && (t.maxSpan == 0 || span <= t.maxSpan) {
+			return t.tier, nil
+		}
+		if bestOldest.IsZero() || oldest.Before(bestOldest) {
+			best, bestOldest = t.tier, oldest
+		}
+	}
+	return best, nil
+}
+
+// Longest ranges served from finer tiers; longer ranges use coarser data
+const (
+	rawHistoryMaxSpan    = 48 * time.Hour
+	hourlyHistoryMaxSpan = 62 * 24 * time.Hour
+)
+
+// aggregateRaw computes aggregates from raw history in [start, end), optionally for one device
+func (r *StatusHistoryRepository) aggregateRaw(deviceID *int64, tier RollupTier, start, end time.Time) ([]models.StatusAggregate, error) {
+	query := `
+		SELECT device_id, status, latency, created_at FROM status_history
+		WHERE created_at >= ? AND created_at < ?`
+	args := []interface{}{start, end}
+	if deviceID != nil {
+		query += " AND device_id = ?"
+		args = append(args, *deviceID)
+	}
+	query += " ORDER BY device_id, created_at"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+	defer rows.Close()
+
+	type key struct {
+		deviceID int64
+		start    time.Time
+	}
+	var (
+		order     []key
+		checks    = make(map[key]int64)
+		latencies = make(map[key][]int64)
+	)
+	for rows.Next() {
+		var (
+			id      int64
+			status  string
+			latency int64
+			at      time.Time
+		)
+		if err := rows.Scan(&id, &status, &latency, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan status history: %w", err)
+		}
+
+		k := key{id, tier.Start(at)}
+		if _, ok := checks[k]; !ok {
+			order = append(order, k)
+		}
+		checks[k]++
+		if status == string(models.DeviceStatusOnline) {
+			latencies[k] = append(latencies[k], latency)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read status history: %w", err)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].deviceID != order[j].deviceID {
+			return order[i].deviceID < order[j].deviceID
+		}
+		return order[i].start.Before(order[j].start)
+	})
+
+	result := make([]models.StatusAggregate, 0, len(order))
+	for _, k := range order {
+		a := models.StatusAggregate{DeviceID: k.deviceID, PeriodStart: k.start, Checks: checks[k]}
+		fillLatencyStats(&a, latencies[k])
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// aggregateHourly combines hourly aggregates of all devices in [start, end) into one
+// aggregate per device. The p95 latency is approximated by the highest hourly p95.
+func (r *StatusHistoryRepository) aggregateHourly(start, end time.Time) ([]models.StatusAggregate, error) {
+	rows, err := r.db.Query(`
+		SELECT device_id,
+			SUM(checks), SUM(online_count),
+			COALESCE(MIN(CASE WHEN online_count > 0 THEN min_latency END), 0),
+			COALESCE(SUM(avg_latency * online_count) / NULLIF(SUM(online_count), 0), 0),
+			COALESCE(MAX(max_latency), 0),
+			COALESCE(MAX(p95_latency), 0)
+		FROM status_history_hourly
+		WHERE period_start >= ? AND period_start < ?
+		GROUP BY device_id
+	`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hourly aggregates: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.StatusAggregate
+	for rows.Next() {
+		a := models.StatusAggregate{PeriodStart: start}
+		if err := rows.Scan(&a.DeviceID, &a.Checks, &a.OnlineCount, &a.MinLatency, &a.AvgLatency, &a.MaxLatency, &a.P95Latency); err != nil {
+			return nil, fmt.Errorf("failed to scan hourly aggregate: %w", err)
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// statusTotals are check counts and latency figures of a range of history
+type statusTotals struct {
+	checks, online, offline int64
+	latencySum              float64 // Sum of successful check latencies
+	minLatency, maxLatency  int64
+}
+
+// rollupTotals sums aggregates of a tier for a device with periods in [start, end)
+func (r *StatusHistoryRepository) rollupTotals(deviceID int64, tier RollupTier, start, end time.Time) (statusTotals, error) {
+	var t statusTotals
+	err := r.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(checks), 0),
+			COALESCE(SUM(online_count), 0),
+			COALESCE(SUM(checks - online_count), 0),
+			COALESCE(SUM(avg_latency * online_count), 0),
+			COALESCE(MIN(CASE WHEN online_count > 0 THEN min_latency END), 0),
+			COALESCE(MAX(max_latency), 0)
+		FROM `+tier.table()+`
+		WHERE device_id = ? AND period_start >= ? AND period_start < ?
+	`, deviceID, start, end).Scan(&t.checks, &t.online, &t.offline, &t.latencySum, &t.minLatency, &t.maxLatency)
+	if err != nil {
+		return t, fmt.Errorf("failed to sum status aggregates: %w", err)
+	}
+	return t, nil
+}
+
+// latest returns the later of two times
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// weekStart returns midnight of the Monday of the week containing t
+func weekStart(t time.Time) time.Time {
+	day := RollupDaily.Start(t)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// fillLatencyStats sets online count and latency statistics from successful check latencies
+func fillLatencyStats(a *models.StatusAggregate, latencies []int64) {
+	a.OnlineCount = int64(len(latencies))
+	if len(latencies) == 0 {
+		return
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var sum int64
+	for _, l := range latencies {
+		sum += l
+	}
+	a.MinLatency = latencies[0]
+	a.MaxLatency = latencies[len(latencies)-1]
+	a.AvgLatency = float64(sum) / float64(len(latencies))
+
+	// Nearest-rank percentile
+	rank := (95*len(latencies) + 99) / 100
+	a.P95Latency = latencies[rank-1]
+}
+
+func scanStatusAggregate(row rowScanner) (models.StatusAggregate, error) {
+	var a models.StatusAggregate
+	err := row.Scan(&a.DeviceID, &a.PeriodStart, &a.Checks, &a.OnlineCount, &a.MinLatency, &a.AvgLatency, &a.MaxLatency, &a.P95Latency)
+	return a, err
+}
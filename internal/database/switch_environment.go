@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// SwitchEnvironmentRepository stores the environment readings of switches
+type SwitchEnvironmentRepository struct {
+	db Querier
+}
+
+// NewSwitchEnvironmentRepository creates a new switch environment repository
+func NewSwitchEnvironmentRepository(db Querier) *SwitchEnvironmentRepository {
+	return &SwitchEnvironmentRepository{db: db}
+}
+
+// Record saves a reading
+func (r *SwitchEnvironmentRepository) Record(sample *models.EnvironmentSample) error {
+	result, err := r.db.Exec(`
+		INSERT INTO switch_environment (device_id, temperature, poe_budget_w, poe_used_w, cpu_percent, memory_percent,
+			fans_failed, psus_failed, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sample.DeviceID, sample.Temperature, sample.PoEBudgetW, sample.PoEUsedW, sample.CPUPercent, sample.MemoryPercent,
+		sample.FansFailed, sample.PSUsFailed, sample.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record switch environment: %w", err)
+	}
+	sample.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetLatest returns the last reading of a switch, nil if there is none
+func (r *SwitchEnvironmentRepository) GetLatest(deviceID int64) (*models.EnvironmentSample, error) {
+	samples, err := r.query(`
+		SELECT id, device_id, temperature, poe_budget_w, poe_used_w, cpu_percent, memory_percent,
+			fans_failed, psus_failed, checked_at
+		FROM switch_environment
+		WHERE device_id = ?
+		ORDER BY checked_at DESC, id DESC
+		LIMIT 1`, deviceID)
+	if err != nil || len(samples) == 0 {
+		return nil, err
+	}
+	return &samples[0], nil
+}
+
+// GetSince returns the readings of a switch since a time, oldest first
+func (r *SwitchEnvironmentRepository) GetSince(deviceID int64, since time.Time) ([]models.EnvironmentSample, error) {
+	return r.query(`
+		SELECT id, device_id, temperature, poe_budget_w, poe_used_w, cpu_percent, memory_percent,
+			fans_failed, psus_failed, checked_at
+		FROM switch_environment
+		WHERE device_id = ? AND checked_at >= ?
+		ORDER BY checked_at, id`, deviceID, since)
+}
+
+// DeleteOlderThan removes old readings
+func (r *SwitchEnvironmentRepository) DeleteOlderThan(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM switch_environment WHERE checked_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *SwitchEnvironmentRepository) query(query string, args ...interface{}) ([]models.EnvironmentSample, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get switch environment: %w", err)
+	}
+	defer rows.Close()
+
+	samples := []models.EnvironmentSample{}
+	for rows.Next() {
+		var s models.EnvironmentSample
+		var temperature, budget, used sql.NullFloat64
+		var cpu, memory sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.DeviceID, &temperature, &budget, &used, &cpu, &memory,
+			&s.FansFailed, &s.PSUsFailed, &s.CheckedAt); err != nil {
+			return nil, err
+		}
+		s.Temperature = nullFloat(temperature)
+		s.PoEBudgetW = nullFloat(budget)
+		s.PoEUsedW = nullFloat(used)
+		s.CPUPercent = nullInt(cpu)
+		s.MemoryPercent = nullInt(memory)
+		samples = append(samples, s)
+	}
+	return samples, rows.Err()
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+func nullInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
@@ -10,11 +10,11 @@ import (
 
 // SwitchRepository handles switch-specific database operations
 type SwitchRepository struct {
-	db *sql.DB
+	db Querier
 }
 
 // NewSwitchRepository creates a new switch repository
-func NewSwitchRepository(db *sql.DB) *SwitchRepository {
+func NewSwitchRepository(db Querier) *SwitchRepository {
 	return &SwitchRepository{db: db}
 }
 
@@ -43,11 +43,11 @@ func (r *SwitchRepository) Create(sw *models.Switch) error {
 	_, err = r.db.Exec(`
 		INSERT INTO switches (device_id, snmp_community, snmp_write_community, snmp_version, port_count, sfp_port_count,
 			snmpv3_user, snmpv3_security, snmpv3_auth_proto, snmpv3_auth_pass, snmpv3_priv_proto, snmpv3_priv_pass,
-			uplink_switch_id, uplink_port_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			uplink_switch_id, uplink_port_id, poe_budget_w)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		sw.DeviceID, encryptedCommunity, encryptedWriteCommunity, sw.SNMPVersion, sw.PortCount, sw.SFPPortCount,
 		sw.SNMPv3User, sw.SNMPv3Security, sw.SNMPv3AuthProto, encryptedAuthPass, sw.SNMPv3PrivProto, encryptedPrivPass,
-		sw.UplinkSwitchID, sw.UplinkPortID,
+		sw.UplinkSwitchID, sw.UplinkPortID, sw.PoEBudgetW,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create switch: %w", err)
@@ -85,11 +85,11 @@ func (r *SwitchRepository) GetByDeviceID(deviceID int64) (*models.Switch, error)
 			COALESCE(snmpv3_user, ''), COALESCE(snmpv3_security, 'noAuthNoPriv'),
 			COALESCE(snmpv3_auth_proto, ''), COALESCE(snmpv3_auth_pass, ''),
 			COALESCE(snmpv3_priv_proto, ''), COALESCE(snmpv3_priv_pass, ''),
-			uplink_switch_id, uplink_port_id
+			uplink_switch_id, uplink_port_id, COALESCE(poe_budget_w, 0)
 		FROM switches WHERE device_id = ?`, deviceID,
 	).Scan(&sw.DeviceID, &encryptedCommunity, &encryptedWriteCommunity, &sw.SNMPVersion, &sw.PortCount, &sw.SFPPortCount,
 		&snmpv3User, &snmpv3Security, &snmpv3AuthProto, &encryptedAuthPass, &snmpv3PrivProto, &encryptedPrivPass,
-		&uplinkSwitchID, &uplinkPortID)
+		&uplinkSwitchID, &uplinkPortID, &sw.PoEBudgetW)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -173,12 +173,12 @@ func (r *SwitchRepository) Update(sw *models.Switch) error {
 		UPDATE switches SET snmp_community = ?, snmp_write_community = ?, snmp_version = ?, port_count = ?, sfp_port_count = ?,
 			snmpv3_user = ?, snmpv3_security = ?, snmpv3_auth_proto = ?, snmpv3_auth_pass = ?,
 			snmpv3_priv_proto = ?, snmpv3_priv_pass = ?,
-			uplink_switch_id = ?, uplink_port_id = ?
+			uplink_switch_id = ?, uplink_port_id = ?, poe_budget_w = ?
 		WHERE device_id = ?`,
 		encryptedCommunity, encryptedWriteCommunity, sw.SNMPVersion, sw.PortCount, sw.SFPPortCount,
 		sw.SNMPv3User, sw.SNMPv3Security, sw.SNMPv3AuthProto, encryptedAuthPass,
 		sw.SNMPv3PrivProto, encryptedPrivPass,
-		sw.UplinkSwitchID, sw.UplinkPortID, sw.DeviceID,
+		sw.UplinkSwitchID, sw.UplinkPortID, sw.PoEBudgetW, sw.DeviceID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update switch: %w", err)
@@ -259,6 +259,7 @@ func (r *SwitchRepository) Delete(deviceID int64) error {
 func (r *SwitchRepository) GetPorts(switchID int64) ([]models.SwitchPort, error) {
 	rows, err := r.db.Query(`
 		SELECT id, switch_id, port_number, name, status, COALESCE(speed, ''),
+			COALESCE(duplex, ''), COALESCE(admin_status, ''), COALESCE(poe_status, ''),
 			COALESCE(port_type, 'copper'), linked_camera_id, linked_switch_id
 		FROM switch_ports WHERE switch_id = ? ORDER BY port_number`, switchID)
 	if err != nil {
@@ -271,7 +272,7 @@ func (r *SwitchRepository) GetPorts(switchID int64) ([]models.SwitchPort, error)
 		var p models.SwitchPort
 		var linkedCameraID, linkedSwitchID sql.NullInt64
 		err := rows.Scan(&p.ID, &p.SwitchID, &p.PortNumber, &p.Name, &p.Status, &p.Speed,
-			&p.PortType, &linkedCameraID, &linkedSwitchID)
+			&p.Duplex, &p.AdminStatus, &p.PoEStatus, &p.PortType, &linkedCameraID, &linkedSwitchID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan port: %w", err)
 		}
@@ -301,12 +302,23 @@ func (r *SwitchRepository) UpdatePort(port *models.SwitchPort) error {
 	return nil
 }
 
-// UpdatePortStatus updates port status
-func (r *SwitchRepository) UpdatePortStatus(portID int64, status string) error {
-	_, err := r.db.Exec("UPDATE switch_ports SET status = ? WHERE id = ?", status, portID)
+// UpdatePortState updates the link, speed, duplex, admin and PoE state of a port
+// and records the changes made to it in the port history
+func (r *SwitchRepository) UpdatePortState(port *models.SwitchPort, changes []models.PortStateChange) error {
+	_, err := r.db.Exec(`
+		UPDATE switch_ports SET status = ?, speed = ?, duplex = ?, admin_status = ?, poe_status = ?
+		WHERE id = ?`,
+		port.Status, port.Speed, port.Duplex, port.AdminStatus, port.PoEStatus, port.ID,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to update port status: %w", err)
 	}
+	history := NewPortHistoryRepository(r.db)
+	for i := range changes {
+		if err := history.Record(&changes[i]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
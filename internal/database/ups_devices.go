@@ -0,0 +1,64 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"netvisionmonitor/internal/models"
+)
+
+// UPSRepository handles UPS-specific database operations
+type UPSRepository struct {
+	db Querier
+}
+
+// NewUPSRepository creates a new UPS repository
+func NewUPSRepository(db Querier) *UPSRepository {
+	return &UPSRepository{db: db}
+}
+
+// Create inserts UPS-specific data
+func (r *UPSRepository) Create(ups *models.UPS) error {
+	_, err := r.db.Exec("INSERT INTO ups_devices (device_id, protocol, nut_port, nut_ups_name) VALUES (?, ?, ?, ?)",
+		ups.DeviceID, ups.Protocol, ups.NUTPort, ups.NUTUPSName)
+	if err != nil {
+		return fmt.Errorf("failed to create UPS: %w", err)
+	}
+	return nil
+}
+
+// GetByDeviceID retrieves UPS data by device ID
+func (r *UPSRepository) GetByDeviceID(deviceID int64) (*models.UPS, error) {
+	ups := &models.UPS{}
+	err := r.db.QueryRow(`
+		SELECT device_id, COALESCE(protocol, 'snmp'), COALESCE(nut_port, 3493), COALESCE(nut_ups_name, 'ups')
+		FROM ups_devices WHERE device_id = ?`, deviceID,
+	).Scan(&ups.DeviceID, &ups.Protocol, &ups.NUTPort, &ups.NUTUPSName)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get UPS: %w", err)
+	}
+	return ups, nil
+}
+
+// Update updates UPS-specific data
+func (r *UPSRepository) Update(ups *models.UPS) error {
+	_, err := r.db.Exec("UPDATE ups_devices SET protocol = ?, nut_port = ?, nut_ups_name = ? WHERE device_id = ?",
+		ups.Protocol, ups.NUTPort, ups.NUTUPSName, ups.DeviceID)
+	if err != nil {
+		return fmt.Errorf("failed to update UPS: %w", err)
+	}
+	return nil
+}
+
+// Delete removes UPS data by device ID
+func (r *UPSRepository) Delete(deviceID int64) error {
+	_, err := r.db.Exec("DELETE FROM ups_devices WHERE device_id = ?", deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete UPS: %w", err)
+	}
+	return nil
+}
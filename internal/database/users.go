@@ -0,0 +1,165 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// UserRepository handles user accounts
+type UserRepository struct {
+	db Querier
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db Querier) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+const userColumns = `id, username, display_name, role, password_hash, disabled, last_login_at,
+	created_at, updated_at`
+
+// Create inserts a new user. PasswordHash must already be set.
+func (r *UserRepository) Create(u *models.User) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO users (username, display_name, role, password_hash, disabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		u.Username, u.DisplayName, string(u.Role), u.PasswordHash, u.Disabled, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	u.ID = id
+	u.CreatedAt = now
+	u.UpdatedAt = now
+	return nil
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(id int64) (*models.User, error) {
+	u, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return u, nil
+}
+
+// GetByUsername retrieves a user by username, case-insensitively
+func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
+	u, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE username = ?", username))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return u, nil
+}
+
+// GetAll retrieves all users ordered by username
+func (r *UserRepository) GetAll() ([]models.User, error) {
+	rows, err := r.db.Query("SELECT " + userColumns + " FROM users ORDER BY username")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+// Count returns the number of users
+func (r *UserRepository) Count() (int, error) {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+// CountActiveAdmins returns the number of enabled admin accounts
+func (r *UserRepository) CountActiveAdmins() (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE role = ? AND disabled = 0",
+		string(models.RoleAdmin)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count admins: %w", err)
+	}
+	return count, nil
+}
+
+// Update updates the name, role and state of a user. The password is not changed.
+func (r *UserRepository) Update(u *models.User) error {
+	u.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		UPDATE users SET username = ?, display_name = ?, role = ?, disabled = ?, updated_at = ?
+		WHERE id = ?`,
+		u.Username, u.DisplayName, string(u.Role), u.Disabled, u.UpdatedAt, u.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// SetPassword replaces the password hash of a user
+func (r *UserRepository) SetPassword(id int64, hash string) error {
+	_, err := r.db.Exec("UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?",
+		hash, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+	return nil
+}
+
+// SetLastLogin records the time of a successful login
+func (r *UserRepository) SetLastLogin(id int64, t time.Time) error {
+	if _, err := r.db.Exec("UPDATE users SET last_login_at = ? WHERE id = ?", t, id); err != nil {
+		return fmt.Errorf("failed to set last login: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a user
+func (r *UserRepository) Delete(id int64) error {
+	if _, err := r.db.Exec("DELETE FROM users WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	u := &models.User{}
+	var role string
+	var displayName sql.NullString
+	var lastLogin sql.NullTime
+	err := row.Scan(&u.ID, &u.Username, &displayName, &role, &u.PasswordHash, &u.Disabled,
+		&lastLogin, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	u.DisplayName = displayName.String
+	u.Role = models.Role(role)
+	if lastLogin.Valid {
+		u.LastLoginAt = &lastLogin.Time
+	}
+	return u, nil
+}
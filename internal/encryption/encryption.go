@@ -10,16 +10,25 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
-var masterKey []byte
+var (
+	keyMu     sync.RWMutex
+	masterKey []byte
+)
 
-// Initialize loads or generates the master encryption key
+// Initialize loads or generates the master encryption key. A key file protected by
+// a passphrase is not loaded; ErrPassphraseRequired is returned and the key must be
+// loaded with Unlock.
 func Initialize(dataDir string) error {
-	keyPath := filepath.Join(dataDir, ".key")
+	keyPath := filepath.Join(dataDir, keyFileName)
 
 	// Try to load existing key
 	if data, err := os.ReadFile(keyPath); err == nil {
+		if isWrappedKey(data) {
+			return ErrPassphraseRequired
+		}
 		key, err := hex.DecodeString(string(data))
 		if err != nil {
 			return fmt.Errorf("failed to decode master key: %w", err)
@@ -27,14 +36,14 @@ func Initialize(dataDir string) error {
 		if len(key) != 32 {
 			return fmt.Errorf("invalid master key length")
 		}
-		masterKey = key
+		setMasterKey(key, nil)
 		return nil
 	}
 
 	// Generate new key
-	masterKey = make([]byte, 32) // AES-256
-	if _, err := io.ReadFull(rand.Reader, masterKey); err != nil {
-		return fmt.Errorf("failed to generate master key: %w", err)
+	key, err := generateKey()
+	if err != nil {
+		return err
 	}
 
 	// Ensure directory exists
@@ -43,20 +52,51 @@ func Initialize(dataDir string) error {
 	}
 
 	// Save key (hex encoded)
-	if err := os.WriteFile(keyPath, []byte(hex.EncodeToString(masterKey)), 0600); err != nil {
+	if err := os.WriteFile(keyPath, []byte(hex.EncodeToString(key)), 0600); err != nil {
 		return fmt.Errorf("failed to save master key: %w", err)
 	}
 
+	setMasterKey(key, nil)
 	return nil
 }
 
+// IsInitialized reports whether the master key is loaded
+func IsInitialized() bool {
+	return len(currentKey()) != 0
+}
+
+func currentKey() []byte {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return masterKey
+}
+
+func setMasterKey(key []byte, kek *keyEncryptionKey) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	masterKey = key
+	wrapKey = kek
+}
+
+func generateKey() ([]byte, error) {
+	key := make([]byte, 32) // AES-256
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+	return key, nil
+}
+
 // Encrypt encrypts plaintext using AES-GCM
 func Encrypt(plaintext string) (string, error) {
-	if len(masterKey) == 0 {
+	return encryptWithKey(currentKey(), plaintext)
+}
+
+func encryptWithKey(key []byte, plaintext string) (string, error) {
+	if len(key) == 0 {
 		return "", fmt.Errorf("encryption not initialized")
 	}
 
-	block, err := aes.NewCipher(masterKey)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -77,7 +117,11 @@ func Encrypt(plaintext string) (string, error) {
 
 // Decrypt decrypts ciphertext using AES-GCM
 func Decrypt(ciphertext string) (string, error) {
-	if len(masterKey) == 0 {
+	return decryptWithKey(currentKey(), ciphertext)
+}
+
+func decryptWithKey(key []byte, ciphertext string) (string, error) {
+	if len(key) == 0 {
 		return "", fmt.Errorf("encryption not initialized")
 	}
 
@@ -86,7 +130,7 @@ func Decrypt(ciphertext string) (string, error) {
 		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
 	}
 
-	block, err := aes.NewCipher(masterKey)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -0,0 +1,143 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	envelopeFormat  = "netvisionmonitor-sealed"
+	envelopeVersion = 1
+
+	// Limits of the scrypt cost accepted from a file, so a crafted file cannot
+	// make Open allocate gigabytes
+	maxScryptN = 1 << 20
+	maxScryptR = 16
+	maxScryptP = 16
+)
+
+// ErrEnvelopeAuth is returned when a sealed envelope fails authentication. A wrong
+// passphrase and a modified file cannot be told apart.
+var ErrEnvelopeAuth = errors.New("wrong passphrase or the file is damaged")
+
+// envelope is a self-describing container for data sealed with a passphrase. The
+// header is authenticated together with the data by AES-GCM, so any change to it
+// is detected as well.
+type envelope struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	Kind    string `json:"kind"` // What the sealed data is, e.g. a configuration backup
+	KDF     string `json:"kdf"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Salt    string `json:"salt"`
+	Nonce   string `json:"nonce"`
+	Data    string `json:"data"`
+}
+
+func (e *envelope) additionalData() []byte {
+	return fmt.Appendf(nil, "%s:%d:%s:%s:%d:%d:%d:%s",
+		e.Format, e.Version, e.Kind, e.KDF, e.N, e.R, e.P, e.Salt)
+}
+
+// Seal encrypts data with a key derived from the passphrase and returns the
+// envelope as JSON. kind names the content and is checked by Open.
+func Seal(data []byte, kind, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase required")
+	}
+	kek, err := newKeyEncryptionKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	aesGCM, err := kek.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aesGCM.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	e := &envelope{
+		Format:  envelopeFormat,
+		Version: envelopeVersion,
+		Kind:    kind,
+		KDF:     kdfScrypt,
+		N:       kek.n,
+		R:       kek.r,
+		P:       kek.p,
+		Salt:    base64.StdEncoding.EncodeToString(kek.salt),
+		Nonce:   base64.StdEncoding.EncodeToString(nonce),
+	}
+	e.Data = base64.StdEncoding.EncodeToString(aesGCM.Seal(nil, nonce, data, e.additionalData()))
+	return json.MarshalIndent(e, "", "  ")
+}
+
+// IsSealed reports whether data is an envelope written by Seal
+func IsSealed(data []byte) bool {
+	var header struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &header) == nil && header.Format == envelopeFormat
+}
+
+// Open authenticates and decrypts an envelope written by Seal with content of the
+// given kind
+func Open(sealed []byte, kind, passphrase string) ([]byte, error) {
+	var e envelope
+	if err := json.Unmarshal(sealed, &e); err != nil {
+		return nil, fmt.Errorf("failed to parse envelope: %w", err)
+	}
+	if e.Format != envelopeFormat {
+		return nil, fmt.Errorf("not an encrypted file")
+	}
+	if e.Version > envelopeVersion {
+		return nil, fmt.Errorf("file was encrypted by a newer version (format %d)", e.Version)
+	}
+	if e.Kind != kind {
+		return nil, fmt.Errorf("file contains %q, expected %q", e.Kind, kind)
+	}
+	if e.KDF != kdfScrypt {
+		return nil, fmt.Errorf("unsupported key derivation %q", e.KDF)
+	}
+	if e.N > maxScryptN || e.R > maxScryptR || e.P > maxScryptP {
+		return nil, fmt.Errorf("key derivation parameters are too large")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(e.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode salt: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(e.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode nonce: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(e.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+
+	kek, err := deriveKey(passphrase, salt, e.N, e.R, e.P)
+	if err != nil {
+		return nil, err
+	}
+	aesGCM, err := kek.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aesGCM.NonceSize() {
+		return nil, ErrEnvelopeAuth
+	}
+
+	data, err := aesGCM.Open(nil, nonce, ciphertext, e.additionalData())
+	if err != nil {
+		return nil, ErrEnvelopeAuth
+	}
+	return data, nil
+}
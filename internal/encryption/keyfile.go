@@ -0,0 +1,395 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keyFileName       = ".key"
+	pendingKeyFileExt = ".new"
+
+	keyFileVersion = 1
+	kdfScrypt      = "scrypt"
+
+	// scrypt cost of new key files, about 32 MB of memory and a fraction of a second
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	ErrPassphraseRequired = errors.New("master key is protected by a passphrase")
+	ErrWrongPassphrase    = errors.New("wrong passphrase")
+)
+
+// wrapKey is the key derived from the passphrase of the loaded master key, nil if
+// the master key is stored unprotected. Guarded by keyMu.
+var wrapKey *keyEncryptionKey
+
+// keyEncryptionKey is a key derived from a passphrase. It is kept instead of the
+// passphrase so the master key can be wrapped again during key rotation.
+type keyEncryptionKey struct {
+	key  []byte
+	salt []byte
+	n    int
+	r    int
+	p    int
+}
+
+// wrappedKeyFile is the content of a key file protected by a passphrase. The master
+// key is sealed with AES-GCM under a key derived from the passphrase with scrypt.
+type wrappedKeyFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Salt    string `json:"salt"`
+	Key     string `json:"key"` // Nonce followed by the sealed master key
+}
+
+// additionalData binds the KDF parameters to the sealed key so they cannot be
+// changed without the passphrase
+func (f *wrappedKeyFile) additionalData() []byte {
+	return fmt.Appendf(nil, "netvisionmonitor-key:%d:%s:%d:%d:%d", f.Version, f.KDF, f.N, f.R, f.P)
+}
+
+func isWrappedKey(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+func deriveKey(passphrase string, salt []byte, n, r, p int) (*keyEncryptionKey, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return &keyEncryptionKey{key: key, salt: salt, n: n, r: r, p: p}, nil
+}
+
+func newKeyEncryptionKey(passphrase string) (*keyEncryptionKey, error) {
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return deriveKey(passphrase, salt, scryptN, scryptR, scryptP)
+}
+
+func (k *keyEncryptionKey) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return aesGCM, nil
+}
+
+// wrap seals a master key into the content of a key file
+func (k *keyEncryptionKey) wrap(key []byte) ([]byte, error) {
+	aesGCM, err := k.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aesGCM.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	f := &wrappedKeyFile{
+		Version: keyFileVersion,
+		KDF:     kdfScrypt,
+		N:       k.n,
+		R:       k.r,
+		P:       k.p,
+		Salt:    base64.StdEncoding.EncodeToString(k.salt),
+	}
+	f.Key = base64.StdEncoding.EncodeToString(aesGCM.Seal(nonce, nonce, key, f.additionalData()))
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// unwrap opens the master key of a key file sealed with this key
+func (k *keyEncryptionKey) unwrap(f *wrappedKeyFile) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(f.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode master key: %w", err)
+	}
+	aesGCM, err := k.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aesGCM.NonceSize() {
+		return nil, fmt.Errorf("invalid master key length")
+	}
+	nonce, sealed := data[:aesGCM.NonceSize()], data[aesGCM.NonceSize():]
+	key, err := aesGCM.Open(nil, nonce, sealed, f.additionalData())
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid master key length")
+	}
+	return key, nil
+}
+
+func parseWrappedKey(data []byte) (*wrappedKeyFile, error) {
+	var f wrappedKeyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+	if f.Version != keyFileVersion {
+		return nil, fmt.Errorf("unsupported key file version %d", f.Version)
+	}
+	if f.KDF != kdfScrypt {
+		return nil, fmt.Errorf("unsupported key derivation %q", f.KDF)
+	}
+	if f.N > maxScryptN || f.R > maxScryptR || f.P > maxScryptP {
+		return nil, fmt.Errorf("key derivation parameters are too large")
+	}
+	return &f, nil
+}
+
+// openKeyFile derives the key from the passphrase and opens a protected key file
+func openKeyFile(path, passphrase string) ([]byte, *keyEncryptionKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if !isWrappedKey(data) {
+		return nil, nil, fmt.Errorf("master key is not protected by a passphrase")
+	}
+	f, err := parseWrappedKey(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	salt, err := base64.StdEncoding.DecodeString(f.Salt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode salt: %w", err)
+	}
+
+	kek, err := deriveKey(passphrase, salt, f.N, f.R, f.P)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := kek.unwrap(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, kek, nil
+}
+
+// encodeKey returns the key file content of a master key, sealed if kek is set
+func encodeKey(key []byte, kek *keyEncryptionKey) ([]byte, error) {
+	if kek == nil {
+		return []byte(hex.EncodeToString(key)), nil
+	}
+	return kek.wrap(key)
+}
+
+// decodeKey reads key file content written by encodeKey with the same kek
+func decodeKey(data []byte, kek *keyEncryptionKey) ([]byte, error) {
+	if !isWrappedKey(data) {
+		key, err := hex.DecodeString(string(bytes.TrimSpace(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode master key: %w", err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("invalid master key length")
+		}
+		return key, nil
+	}
+	if kek == nil {
+		return nil, ErrPassphraseRequired
+	}
+	f, err := parseWrappedKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return kek.unwrap(f)
+}
+
+// writeKeyFile replaces a key file atomically, so a crash never leaves a partial key
+func writeKeyFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to save master key: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save master key: %w", err)
+	}
+	return nil
+}
+
+// Unlock loads a master key protected by a passphrase
+func Unlock(dataDir, passphrase string) error {
+	key, kek, err := openKeyFile(filepath.Join(dataDir, keyFileName), passphrase)
+	if err != nil {
+		return err
+	}
+	setMasterKey(key, kek)
+	return nil
+}
+
+// VerifyPassphrase checks the passphrase of the protected key file without loading it
+func VerifyPassphrase(dataDir, passphrase string) error {
+	_, _, err := openKeyFile(filepath.Join(dataDir, keyFileName), passphrase)
+	return err
+}
+
+// IsProtected reports whether the loaded master key is protected by a passphrase
+func IsProtected() bool {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return wrapKey != nil
+}
+
+// SetPassphrase protects the loaded master key with a passphrase, or stores it
+// unprotected if the passphrase is empty. The master key itself does not change,
+// so encrypted values stay readable.
+func SetPassphrase(dataDir, passphrase string) error {
+	key := currentKey()
+	if len(key) == 0 {
+		return fmt.Errorf("encryption not initialized")
+	}
+
+	var kek *keyEncryptionKey
+	if passphrase != "" {
+		var err error
+		if kek, err = newKeyEncryptionKey(passphrase); err != nil {
+			return err
+		}
+	}
+	data, err := encodeKey(key, kek)
+	if err != nil {
+		return err
+	}
+	if err := writeKeyFile(filepath.Join(dataDir, keyFileName), data); err != nil {
+		return err
+	}
+	setMasterKey(key, kek)
+	return nil
+}
+
+// Rotation replaces the master key with a new one. Stored values are re-encrypted
+// with Reencrypt, then Commit makes the new key current. Until then the new key is
+// kept in a pending key file next to the current one, protected the same way, so
+// that an interrupted rotation can be finished by ResolvePendingRotation.
+type Rotation struct {
+	dataDir string
+	oldKey  []byte
+	newKey  []byte
+	kek     *keyEncryptionKey
+}
+
+// BeginRotation generates a new master key and saves it as pending
+func BeginRotation(dataDir string) (*Rotation, error) {
+	keyMu.RLock()
+	oldKey, kek := masterKey, wrapKey
+	keyMu.RUnlock()
+	if len(oldKey) == 0 {
+		return nil, fmt.Errorf("encryption not initialized")
+	}
+
+	newKey, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	data, err := encodeKey(newKey, kek)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeKeyFile(pendingKeyPath(dataDir), data); err != nil {
+		return nil, err
+	}
+	return &Rotation{dataDir: dataDir, oldKey: oldKey, newKey: newKey, kek: kek}, nil
+}
+
+// Reencrypt decrypts a value with the current master key and encrypts it with the
+// new one. Empty values stay empty.
+func (r *Rotation) Reencrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	plaintext, err := decryptWithKey(r.oldKey, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return encryptWithKey(r.newKey, plaintext)
+}
+
+// Encrypt encrypts a value with the new master key
+func (r *Rotation) Encrypt(plaintext string) (string, error) {
+	return encryptWithKey(r.newKey, plaintext)
+}
+
+// Commit makes the new master key current and replaces the key file
+func (r *Rotation) Commit() error {
+	setMasterKey(r.newKey, r.kek)
+	if err := os.Rename(pendingKeyPath(r.dataDir), filepath.Join(r.dataDir, keyFileName)); err != nil {
+		return fmt.Errorf("failed to replace master key: %w", err)
+	}
+	return nil
+}
+
+// Abort discards the new master key
+func (r *Rotation) Abort() {
+	os.Remove(pendingKeyPath(r.dataDir))
+}
+
+// ResolvePendingRotation finishes or discards a rotation that was interrupted
+// between storing the re-encrypted values and replacing the key file. check is a
+// value encrypted in the same transaction as the re-encrypted values, empty if no
+// rotation has stored one yet; the key that decrypts it is kept. Returns true if
+// the pending key was made current.
+func ResolvePendingRotation(dataDir, check string) (bool, error) {
+	pendingPath := pendingKeyPath(dataDir)
+	data, err := os.ReadFile(pendingPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read pending key file: %w", err)
+	}
+
+	if check != "" {
+		if _, err := Decrypt(check); err != nil {
+			keyMu.RLock()
+			kek := wrapKey
+			keyMu.RUnlock()
+
+			newKey, err := decodeKey(data, kek)
+			if err != nil {
+				return false, fmt.Errorf("failed to load pending master key: %w", err)
+			}
+			if _, err := decryptWithKey(newKey, check); err != nil {
+				return false, fmt.Errorf("stored values match neither the current nor the pending master key")
+			}
+			r := &Rotation{dataDir: dataDir, newKey: newKey, kek: kek}
+			return true, r.Commit()
+		}
+	}
+
+	if err := os.Remove(pendingPath); err != nil {
+		return false, fmt.Errorf("failed to remove pending key file: %w", err)
+	}
+	return false, nil
+}
+
+func pendingKeyPath(dataDir string) string {
+	return filepath.Join(dataDir, keyFileName+pendingKeyFileExt)
+}
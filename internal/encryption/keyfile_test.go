@@ -0,0 +1,79 @@
+package encryption
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// protectedKeyDir returns a data directory with a master key protected by passphrase
+func protectedKeyDir(t *testing.T, passphrase string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := Initialize(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPassphrase(dir, passphrase); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestUnlock(t *testing.T) {
+	dir := protectedKeyDir(t, "correct horse")
+	encrypted, err := Encrypt("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Initialize(dir); err != ErrPassphraseRequired {
+		t.Fatalf("initialize: %v, want %v", err, ErrPassphraseRequired)
+	}
+	if err := Unlock(dir, "wrong horse"); err != ErrWrongPassphrase {
+		t.Fatalf("unlock with a wrong passphrase: %v, want %v", err, ErrWrongPassphrase)
+	}
+	if err := Unlock(dir, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := Decrypt(encrypted); err != nil || plain != "secret" {
+		t.Fatalf("decrypt after unlock: %q, %v", plain, err)
+	}
+}
+
+func TestUnlockRejectsLargeScryptCost(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		n, r, p int
+	}{
+		{"n", 1 << 30, scryptR, scryptP},
+		{"r", scryptN, 1 << 20, scryptP},
+		{"p", scryptN, scryptR, 1 << 20},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := protectedKeyDir(t, "correct horse")
+			path := filepath.Join(dir, keyFileName)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var f wrappedKeyFile
+			if err := json.Unmarshal(data, &f); err != nil {
+				t.Fatal(err)
+			}
+			f.N, f.R, f.P = tc.n, tc.r, tc.p
+			if data, err = json.Marshal(f); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			err = Unlock(dir, "correct horse")
+			if err == nil || !strings.Contains(err.Error(), "too large") {
+				t.Fatalf("unlock: %v, want an error about the key derivation parameters", err)
+			}
+		})
+	}
+}
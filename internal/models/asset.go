@@ -0,0 +1,65 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// AssetDateLayout is the format of asset dates (install date, warranty end)
+const AssetDateLayout = "2006-01-02"
+
+// AssetFieldType is the value type of a custom asset field
+type AssetFieldType string
+
+const (
+	AssetFieldText   AssetFieldType = "text"
+	AssetFieldNumber AssetFieldType = "number"
+	AssetFieldDate   AssetFieldType = "date"
+)
+
+// AssetField defines a custom attribute of devices of a type, or of all devices
+// if DeviceType is empty. Keys are unique across all fields.
+type AssetField struct {
+	ID         int64          `json:"id"`
+	Key        string         `json:"key"`
+	Label      string         `json:"label"`
+	DeviceType DeviceType     `json:"device_type,omitempty"`
+	FieldType  AssetFieldType `json:"field_type"`
+	Position   int            `json:"position"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// AppliesTo reports whether the field is defined for a device type
+func (f *AssetField) AppliesTo(deviceType DeviceType) bool {
+	return f.DeviceType == "" || f.DeviceType == deviceType
+}
+
+// DeviceAsset contains asset management data of a device. Dates use AssetDateLayout,
+// custom field values are keyed by AssetField.Key.
+type DeviceAsset struct {
+	DeviceID     int64             `json:"device_id"`
+	SerialNumber string            `json:"serial_number"`
+	MACAddress   string            `json:"mac_address"`
+	InstallDate  string            `json:"install_date"`
+	WarrantyEnd  string            `json:"warranty_end"`
+	Room         string            `json:"room"`
+	Rack         string            `json:"rack"`
+	RackUnit     string            `json:"rack_unit"`
+	Notes        string            `json:"notes"`
+	Custom       map[string]string `json:"custom"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// WarrantyDaysLeft returns the number of days from the date of now until the
+// warranty ends, negative if it has already ended
+func (a *DeviceAsset) WarrantyDaysLeft(now time.Time) (int, bool) {
+	if a.WarrantyEnd == "" {
+		return 0, false
+	}
+	end, err := time.ParseInLocation(AssetDateLayout, a.WarrantyEnd, now.Location())
+	if err != nil {
+		return 0, false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return int(math.Round(end.Sub(today).Hours() / 24)), true
+}
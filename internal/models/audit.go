@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// AuditResult is the outcome of an audited action
+type AuditResult string
+
+const (
+	AuditResultSuccess AuditResult = "success"
+	AuditResultError   AuditResult = "error"
+)
+
+// AuditTarget identifies the object an audited action was applied to
+type AuditTarget struct {
+	Type string `json:"type"` // "device", "credential", "settings", ...
+	ID   *int64 `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// AuditChange is a single changed field. Secrets are masked.
+type AuditChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEntry is a record of the audit log. Every entry holds the hash of the
+// previous one so that removed or modified entries can be detected.
+type AuditEntry struct {
+	ID        int64                  `json:"id"`
+	Timestamp time.Time              `json:"timestamp"`
+	Actor     string                 `json:"actor"`
+	Action    string                 `json:"action"` // "device.update", "switch.poe", ...
+	Target    AuditTarget            `json:"target"`
+	Changes   []AuditChange          `json:"changes"`
+	Details   map[string]interface{} `json:"details,omitempty"` // Parameters of actions without stored state
+	Result    AuditResult            `json:"result"`
+	Error     string                 `json:"error,omitempty"`
+	PrevHash  string                 `json:"prev_hash"`
+	Hash      string                 `json:"hash"`
+}
+
+// AuditVerification is the result of checking the audit log hash chain
+type AuditVerification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	BrokenAt *int64 `json:"broken_at,omitempty"` // First entry that does not match the chain
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AuditFilter defines filter options for audit log queries
+type AuditFilter struct {
+	Actor      string     `json:"actor,omitempty"`
+	Action     string     `json:"action,omitempty"` // Exact action or prefix ending with "." (e.g. "device.")
+	TargetType string     `json:"target_type,omitempty"`
+	TargetID   *int64     `json:"target_id,omitempty"`
+	Result     string     `json:"result,omitempty"`
+	StartTime  *time.Time `json:"start_time,omitempty"`
+	EndTime    *time.Time `json:"end_time,omitempty"`
+	Limit      int        `json:"limit"`
+	Offset     int        `json:"offset"`
+}
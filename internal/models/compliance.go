@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// ComplianceOperator defines how a rule compares the actual value
+type ComplianceOperator string
+
+const (
+	ComplianceOpEqual        ComplianceOperator = "eq"
+	ComplianceOpNotEqual     ComplianceOperator = "ne"
+	ComplianceOpLess         ComplianceOperator = "lt"
+	ComplianceOpLessEqual    ComplianceOperator = "lte"
+	ComplianceOpGreater      ComplianceOperator = "gt"
+	ComplianceOpGreaterEqual ComplianceOperator = "gte"
+	ComplianceOpContains     ComplianceOperator = "contains"
+	ComplianceOpIn           ComplianceOperator = "in" // Comma-separated list
+)
+
+// Compliance check keys for cameras
+const (
+	ComplianceCheckFirmware         = "firmware"
+	ComplianceCheckManufacturer     = "manufacturer"
+	ComplianceCheckModel            = "model"
+	ComplianceCheckMainEncoding     = "main_stream.encoding"
+	ComplianceCheckMainBitrate      = "main_stream.bitrate" // kbps
+	ComplianceCheckMainFrameRate    = "main_stream.frame_rate"
+	ComplianceCheckMainResolution   = "main_stream.resolution" // "1920x1080"
+	ComplianceCheckMainGovLength    = "main_stream.gov_length"
+	ComplianceCheckSubEncoding      = "sub_stream.encoding"
+	ComplianceCheckSubBitrate       = "sub_stream.bitrate"
+	ComplianceCheckSubFrameRate     = "sub_stream.frame_rate"
+	ComplianceCheckSubResolution    = "sub_stream.resolution"
+	ComplianceCheckNTPServer        = "ntp.server"
+	ComplianceCheckNTPFromDHCP      = "ntp.from_dhcp"
+	ComplianceCheckDateTimeType     = "time.mode"  // "NTP" or "Manual"
+	ComplianceCheckClockDrift       = "time.drift" // seconds, absolute
+	ComplianceCheckSNMPVersion      = "snmp.version"
+	ComplianceCheckAutoRestartMode  = "autorestart.mode"    // Checked on every copper port
+	ComplianceCheckAutoRestartPing  = "autorestart.ping_ip" // Checked on every copper port
+	ComplianceCheckUplinkConfigured = "uplink.configured"
+)
+
+// ComplianceRule is a single expected setting of a baseline
+type ComplianceRule struct {
+	Check    string             `json:"check"`
+	Operator ComplianceOperator `json:"operator"`
+	Value    string             `json:"value"`
+}
+
+// ComplianceBaseline defines expected settings for devices of one type
+type ComplianceBaseline struct {
+	ID         int64            `json:"id"`
+	Name       string           `json:"name"`
+	DeviceType DeviceType       `json:"device_type"`
+	Enabled    bool             `json:"enabled"`
+	Rules      []ComplianceRule `json:"rules"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// ComplianceRuleResult contains the outcome of a single rule for a device
+type ComplianceRuleResult struct {
+	ComplianceRule
+	Actual string `json:"actual"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// DeviceComplianceResult contains audit results for a device against a baseline
+type DeviceComplianceResult struct {
+	DeviceID     int64                  `json:"device_id"`
+	DeviceName   string                 `json:"device_name"`
+	IPAddress    string                 `json:"ip_address"`
+	DeviceType   DeviceType             `json:"device_type"`
+	BaselineID   int64                  `json:"baseline_id"`
+	BaselineName string                 `json:"baseline_name"`
+	Passed       bool                   `json:"passed"`
+	Error        string                 `json:"error,omitempty"` // Device could not be audited
+	Rules        []ComplianceRuleResult `json:"rules"`
+}
+
+// ComplianceReport contains results of a fleet-wide compliance audit
+type ComplianceReport struct {
+	ID         int64                    `json:"id"`
+	StartedAt  time.Time                `json:"started_at"`
+	FinishedAt time.Time                `json:"finished_at"`
+	Total      int                      `json:"total"`
+	Passed     int                      `json:"passed"`
+	Failed     int                      `json:"failed"`
+	Errors     int                      `json:"errors"`
+	Results    []DeviceComplianceResult `json:"results,omitempty"`
+}
@@ -11,6 +11,15 @@ const (
 	CredentialTypeSSH   CredentialType = "ssh"
 )
 
+// Valid reports whether the credential type is known
+func (t CredentialType) Valid() bool {
+	switch t {
+	case CredentialTypeSNMP, CredentialTypeRTSP, CredentialTypeONVIF, CredentialTypeSSH:
+		return true
+	}
+	return false
+}
+
 type Credential struct {
 	ID        int64          `json:"id"`
 	Name      string         `json:"name"`
@@ -1,15 +1,45 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type DeviceType string
 
 const (
-	DeviceTypeSwitch DeviceType = "switch"
-	DeviceTypeServer DeviceType = "server"
-	DeviceTypeCamera DeviceType = "camera"
+	DeviceTypeSwitch      DeviceType = "switch"
+	DeviceTypeServer      DeviceType = "server"
+	DeviceTypeCamera      DeviceType = "camera"
+	DeviceTypeRouter      DeviceType = "router"
+	DeviceTypeAccessPoint DeviceType = "access_point"
+	DeviceTypeUPS         DeviceType = "ups"
+	DeviceTypeNVR         DeviceType = "nvr"
+	DeviceTypePDU         DeviceType = "pdu"
+	DeviceTypeHost        DeviceType = "host" // Checked by ping only
 )
 
+// DeviceTypes lists all device types in display order
+var DeviceTypes = []DeviceType{
+	DeviceTypeSwitch, DeviceTypeServer, DeviceTypeCamera, DeviceTypeRouter,
+	DeviceTypeAccessPoint, DeviceTypeUPS, DeviceTypeNVR, DeviceTypePDU, DeviceTypeHost,
+}
+
+// Valid reports whether the device type is known
+func (t DeviceType) Valid() bool {
+	return slices.Contains(DeviceTypes, t)
+}
+
+// UsesSNMPDevice reports whether devices of the type are checked over SNMP with
+// the settings in SNMPDevice. Switches keep their own SNMP settings.
+func (t DeviceType) UsesSNMPDevice() bool {
+	switch t {
+	case DeviceTypeRouter, DeviceTypeAccessPoint, DeviceTypeUPS, DeviceTypeNVR, DeviceTypePDU:
+		return true
+	}
+	return false
+}
+
 type DeviceStatus string
 
 const (
@@ -26,6 +56,9 @@ type Device struct {
 	Manufacturer string       `json:"manufacturer"`
 	Model        string       `json:"model"`
 	CredentialID *int64       `json:"credential_id,omitempty"`
+	SiteID       *int64       `json:"site_id,omitempty"`
+	GroupID      *int64       `json:"group_id,omitempty"`
+	Tags         []string     `json:"tags"`
 	Status       DeviceStatus `json:"status"`
 	LastCheck    *time.Time   `json:"last_check,omitempty"`
 	CreatedAt    time.Time    `json:"created_at"`
@@ -33,12 +66,13 @@ type Device struct {
 }
 
 type Switch struct {
-	DeviceID           int64  `json:"device_id"`
-	SNMPCommunity      string `json:"snmp_community"`
-	SNMPWriteCommunity string `json:"snmp_write_community,omitempty"` // Write community for SET operations
-	SNMPVersion        string `json:"snmp_version"`                   // v1, v2c, v3
-	PortCount          int    `json:"port_count"`
-	SFPPortCount       int    `json:"sfp_port_count"` // Number of SFP ports (last N ports)
+	DeviceID           int64   `json:"device_id"`
+	SNMPCommunity      string  `json:"snmp_community"`
+	SNMPWriteCommunity string  `json:"snmp_write_community,omitempty"` // Write community for SET operations
+	SNMPVersion        string  `json:"snmp_version"`                   // v1, v2c, v3
+	PortCount          int     `json:"port_count"`
+	SFPPortCount       int     `json:"sfp_port_count"`         // Number of SFP ports (last N ports)
+	PoEBudgetW         float64 `json:"poe_budget_w,omitempty"` // Overrides the PoE budget the switch reports, 0 = reported
 	// SNMPv3 settings
 	SNMPv3User       string `json:"snmpv3_user,omitempty"`
 	SNMPv3Security   string `json:"snmpv3_security,omitempty"`   // noAuthNoPriv, authNoPriv, authPriv
@@ -58,9 +92,12 @@ type SwitchPort struct {
 	Name           string `json:"name"`
 	Status         string `json:"status"`
 	Speed          string `json:"speed"`
-	PortType       string `json:"port_type"`                   // "copper" or "sfp"
-	LinkedCameraID *int64 `json:"linked_camera_id,omitempty"`  // Only for copper ports
-	LinkedSwitchID *int64 `json:"linked_switch_id,omitempty"`  // Only for SFP ports (uplink)
+	Duplex         string `json:"duplex,omitempty"`           // "full" or "half", empty if not reported
+	AdminStatus    string `json:"admin_status,omitempty"`     // "up" or "down", empty if not reported
+	PoEStatus      string `json:"poe_status,omitempty"`       // "on", "off" or "fault", empty if not reported
+	PortType       string `json:"port_type"`                  // "copper" or "sfp"
+	LinkedCameraID *int64 `json:"linked_camera_id,omitempty"` // Only for copper ports
+	LinkedSwitchID *int64 `json:"linked_switch_id,omitempty"` // Only for SFP ports (uplink)
 }
 
 type Camera struct {
@@ -80,12 +117,46 @@ type Server struct {
 	UplinkPortID   *int64 `json:"uplink_port_id,omitempty"`   // SFP port ID on parent switch
 }
 
+// SNMPDevice holds SNMP settings of routers, access points, UPS units, NVRs and PDUs
+type SNMPDevice struct {
+	DeviceID        int64  `json:"device_id"`
+	SNMPCommunity   string `json:"snmp_community"`
+	SNMPVersion     string `json:"snmp_version"` // v1, v2c, v3
+	SNMPv3User      string `json:"snmpv3_user,omitempty"`
+	SNMPv3Security  string `json:"snmpv3_security,omitempty"`
+	SNMPv3AuthProto string `json:"snmpv3_auth_proto,omitempty"`
+	SNMPv3AuthPass  string `json:"snmpv3_auth_pass,omitempty"`
+	SNMPv3PrivProto string `json:"snmpv3_priv_proto,omitempty"`
+	SNMPv3PrivPass  string `json:"snmpv3_priv_pass,omitempty"`
+	// Uplink settings
+	UplinkSwitchID *int64 `json:"uplink_switch_id,omitempty"` // Parent switch ID
+	UplinkPortID   *int64 `json:"uplink_port_id,omitempty"`   // Port ID on parent switch
+}
+
+// NVR holds settings of NVRs and DVRs in addition to SNMPDevice
+type NVR struct {
+	DeviceID  int64 `json:"device_id"`
+	CheckHTTP bool  `json:"check_http"` // Read disk status over HTTP (ISAPI) with the device credential
+	HTTPPort  int   `json:"http_port"`
+}
+
+// UPS holds settings of UPS units in addition to SNMPDevice
+type UPS struct {
+	DeviceID   int64  `json:"device_id"`
+	Protocol   string `json:"protocol"`     // "snmp" (UPS-MIB) or "nut" (Network UPS Tools upsd)
+	NUTPort    int    `json:"nut_port"`     // upsd TCP port, 3493 by default
+	NUTUPSName string `json:"nut_ups_name"` // UPS name configured in upsd
+}
+
 // DeviceWithDetails combines device with its type-specific details
 type DeviceWithDetails struct {
 	Device
 	Switch *Switch      `json:"switch,omitempty"`
 	Camera *Camera      `json:"camera,omitempty"`
 	Server *Server      `json:"server,omitempty"`
+	SNMP   *SNMPDevice  `json:"snmp,omitempty"`
+	NVR    *NVR         `json:"nvr,omitempty"`
+	UPS    *UPS         `json:"ups,omitempty"`
 	Ports  []SwitchPort `json:"ports,omitempty"`
 }
 
@@ -0,0 +1,188 @@
+package models
+
+import "time"
+
+// InterfaceStatus is the state of a network interface from IF-MIB
+type InterfaceStatus struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	AdminStatus string `json:"admin_status"` // "up", "down", "testing"
+	OperStatus  string `json:"oper_status"`  // "up", "down", "unknown"
+	SpeedMbps   int64  `json:"speed_mbps"`
+	Wireless    bool   `json:"wireless"` // IEEE 802.11 radio
+}
+
+// BGPPeer is the state of a BGP session from BGP4-MIB
+type BGPPeer struct {
+	Address  string `json:"address"`
+	RemoteAS int64  `json:"remote_as"`
+	State    string `json:"state"` // "idle", "connect", "active", "opensent", "openconfirm", "established"
+}
+
+// Established reports whether the BGP session is up
+func (p BGPPeer) Established() bool {
+	return p.State == "established"
+}
+
+// RouterStatus contains the state of a router
+type RouterStatus struct {
+	Interfaces []InterfaceStatus `json:"interfaces"`
+	BGPPeers   []BGPPeer         `json:"bgp_peers"`
+}
+
+// AccessPointStatus contains the state of a wireless access point
+type AccessPointStatus struct {
+	Interfaces []InterfaceStatus `json:"interfaces"`
+	Clients    *int              `json:"clients,omitempty"` // Associated clients, if the vendor MIB reports them
+}
+
+// UPSStatus contains the state of a UPS from UPS-MIB (RFC 1628) or NUT
+type UPSStatus struct {
+	BatteryStatus    string     `json:"battery_status"` // "normal", "low", "depleted", "unknown"
+	OutputSource     string     `json:"output_source"`  // "normal", "battery", "bypass", "booster", "reducer", "none", "other"
+	OnBattery        bool       `json:"on_battery"`
+	BatteryLow       bool       `json:"battery_low"`
+	Charge           int        `json:"charge"`             // Percent
+	RuntimeMinutes   int        `json:"runtime_minutes"`    // Estimated runtime on battery
+	SecondsOnBattery int        `json:"seconds_on_battery"` // Zero when on mains or unknown
+	Load             int        `json:"load"`               // Percent of rated output
+	InputVoltage     *float64   `json:"input_voltage,omitempty"`
+	SelfTest         string     `json:"self_test,omitempty"` // "passed", "warning", "failed", "aborted", "in_progress", "none"
+	Temperature      *int       `json:"temperature,omitempty"`
+	ShutdownAt       *time.Time `json:"shutdown_at,omitempty"` // Predicted from the runtime while on battery
+	Source           string     `json:"source"`                // "snmp" or "nut"
+}
+
+// DiskStatus is the state of a recording disk of an NVR
+type DiskStatus struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"` // "ok", "warning", "failed", "unknown"
+	Detail     string `json:"detail,omitempty"`
+	CapacityMB int64  `json:"capacity_mb,omitempty"`
+	FreeMB     int64  `json:"free_mb,omitempty"`
+}
+
+// NVRStatus contains the state of an NVR or DVR
+type NVRStatus struct {
+	Disks  []DiskStatus `json:"disks"`
+	Source string       `json:"source"` // "snmp" or "http"
+}
+
+// OutletStatus is the state of a PDU outlet
+type OutletStatus struct {
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+	State  string `json:"state"` // "on", "off", "unknown"
+}
+
+// PDUStatus contains the state of a PDU
+type PDUStatus struct {
+	Outlets  []OutletStatus `json:"outlets"`
+	CurrentA *float64       `json:"current_a,omitempty"` // Total load current
+}
+
+// DeviceHealth contains the type-specific state of a router, access point, UPS,
+// NVR or PDU, read from the device
+type DeviceHealth struct {
+	DeviceID    int64              `json:"device_id"`
+	Type        DeviceType         `json:"type"`
+	Router      *RouterStatus      `json:"router,omitempty"`
+	AccessPoint *AccessPointStatus `json:"access_point,omitempty"`
+	UPS         *UPSStatus         `json:"ups,omitempty"`
+	NVR         *NVRStatus         `json:"nvr,omitempty"`
+	PDU         *PDUStatus         `json:"pdu,omitempty"`
+	CheckedAt   time.Time          `json:"checked_at"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// SensorReading is a value of an ENTITY-SENSOR-MIB or vendor sensor
+type SensorReading struct {
+	Name   string  `json:"name"`
+	Type   string  `json:"type"` // "temperature", "fan", "voltage", "current", "power", "other"
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit"`   // "C", "rpm", "V", "A", "W"
+	Status string  `json:"status"` // "ok", "unavailable", "failed"
+}
+
+// ComponentStatus is the state of a fan or power supply
+type ComponentStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "ok", "warning", "failed", "not_present", "unknown"
+}
+
+// Failed reports whether the component needs attention
+func (c ComponentStatus) Failed() bool {
+	return c.Status == "warning" || c.Status == "failed"
+}
+
+// PortPower is the PoE power a switch port delivers
+type PortPower struct {
+	Port   int     `json:"port"`
+	PowerW float64 `json:"power_w"`
+}
+
+// SwitchEnvironment contains the health of a switch: temperature, fans, power
+// supplies, PoE budget and CPU/memory load. What the switch does not report is
+// nil or empty.
+type SwitchEnvironment struct {
+	DeviceID      int64             `json:"device_id"`
+	Temperature   *float64          `json:"temperature,omitempty"` // Hottest temperature sensor, °C
+	Sensors       []SensorReading   `json:"sensors"`
+	Fans          []ComponentStatus `json:"fans"`
+	PSUs          []ComponentStatus `json:"psus"`
+	PoEBudgetW    *float64          `json:"poe_budget_w,omitempty"` // Reported by the switch or set for it
+	PoEUsedW      *float64          `json:"poe_used_w,omitempty"`
+	PoEPorts      []PortPower       `json:"poe_ports"` // Per port, if the switch reports it
+	CPUPercent    *int              `json:"cpu_percent,omitempty"`
+	MemoryPercent *int              `json:"memory_percent,omitempty"`
+	CheckedAt     time.Time         `json:"checked_at"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// PoEUsedPercent returns the share of the PoE budget in use, nil if either is unknown
+func (e *SwitchEnvironment) PoEUsedPercent() *float64 {
+	if e.PoEBudgetW == nil || e.PoEUsedW == nil || *e.PoEBudgetW <= 0 {
+		return nil
+	}
+	percent := *e.PoEUsedW / *e.PoEBudgetW * 100
+	return &percent
+}
+
+// EnvironmentSample is a stored reading of the environment of a switch
+type EnvironmentSample struct {
+	ID            int64     `json:"id"`
+	DeviceID      int64     `json:"device_id"`
+	Temperature   *float64  `json:"temperature,omitempty"`
+	PoEBudgetW    *float64  `json:"poe_budget_w,omitempty"`
+	PoEUsedW      *float64  `json:"poe_used_w,omitempty"`
+	CPUPercent    *int      `json:"cpu_percent,omitempty"`
+	MemoryPercent *int      `json:"memory_percent,omitempty"`
+	FansFailed    int       `json:"fans_failed"`
+	PSUsFailed    int       `json:"psus_failed"`
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+// Sample returns the stored form of the environment
+func (e *SwitchEnvironment) Sample() *EnvironmentSample {
+	sample := &EnvironmentSample{
+		DeviceID:      e.DeviceID,
+		Temperature:   e.Temperature,
+		PoEBudgetW:    e.PoEBudgetW,
+		PoEUsedW:      e.PoEUsedW,
+		CPUPercent:    e.CPUPercent,
+		MemoryPercent: e.MemoryPercent,
+		CheckedAt:     e.CheckedAt,
+	}
+	for _, fan := range e.Fans {
+		if fan.Failed() {
+			sample.FansFailed++
+		}
+	}
+	for _, psu := range e.PSUs {
+		if psu.Failed() {
+			sample.PSUsFailed++
+		}
+	}
+	return sample
+}
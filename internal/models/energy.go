@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// PoEPowerSample is the PoE power a switch port delivered at a time
+type PoEPowerSample struct {
+	SwitchID   int64     `json:"switch_id"`
+	PortNumber int       `json:"port_number"`
+	CameraID   *int64    `json:"camera_id,omitempty"` // Camera linked to the port when sampled
+	PowerW     float64   `json:"power_w"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// PortEnergyDay is the PoE energy a switch port delivered on a day
+type PortEnergyDay struct {
+	SwitchID   int64   `json:"switch_id"`
+	PortNumber int     `json:"port_number"`
+	CameraID   *int64  `json:"camera_id,omitempty"` // Camera last linked to the port that day
+	Day        string  `json:"day"`                 // 2006-01-02, local time
+	EnergyWh   float64 `json:"energy_wh"`
+	MaxPowerW  float64 `json:"max_power_w"`
+}
+
+// EnergyDay is the energy used on a day
+type EnergyDay struct {
+	Day       string  `json:"day"`
+	EnergyKWh float64 `json:"energy_kwh"`
+}
+
+// EnergyUsage is the PoE energy of a camera, a switch or a site over a period
+type EnergyUsage struct {
+	Key       string      `json:"key"`  // "camera:1", "switch:2", "site:3"
+	Kind      string      `json:"kind"` // "camera", "switch", "site"
+	ID        int64       `json:"id"`
+	Name      string      `json:"name"`
+	Days      []EnergyDay `json:"days"`
+	TotalKWh  float64     `json:"total_kwh"`
+	MaxPowerW float64     `json:"max_power_w"`
+}
+
+// EnergyReport is the PoE energy of cameras, switches and sites over a period
+type EnergyReport struct {
+	Period      string        `json:"period"`
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	TotalKWh    float64       `json:"total_kwh"`
+	Cameras     []EnergyUsage `json:"cameras"`
+	Switches    []EnergyUsage `json:"switches"`
+	Sites       []EnergyUsage `json:"sites"`
+}
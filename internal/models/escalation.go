@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// OnCallMember is a person taking part in an on-call rotation
+type OnCallMember struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// OnCallOverride replaces the rotation with another person for a period
+type OnCallOverride struct {
+	ID         int64     `json:"id"`
+	ScheduleID int64     `json:"schedule_id"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	StartAt    time.Time `json:"start_at"`
+	EndAt      time.Time `json:"end_at"`
+	Reason     string    `json:"reason"`
+}
+
+// OnCallSchedule is a weekly rotation of members. The first member is on call for
+// the week starting at RotationStart, then duty passes to the next member every
+// seven days at the same weekday and time.
+type OnCallSchedule struct {
+	ID            int64            `json:"id"`
+	Name          string           `json:"name"`
+	RotationStart time.Time        `json:"rotation_start"`
+	Members       []OnCallMember   `json:"members"`
+	Overrides     []OnCallOverride `json:"overrides"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// OnCallAt returns who is on call at the given time, taking overrides into account.
+// Returns nil if the schedule has no members.
+func (s *OnCallSchedule) OnCallAt(at time.Time) *OnCallMember {
+	// Later overrides win over earlier ones
+	for i := len(s.Overrides) - 1; i >= 0; i-- {
+		o := s.Overrides[i]
+		if !at.Before(o.StartAt) && at.Before(o.EndAt) {
+			return &OnCallMember{Name: o.Name, Email: o.Email}
+		}
+	}
+
+	if len(s.Members) == 0 {
+		return nil
+	}
+	const weekLength = 7 * 24 * time.Hour
+	elapsed := at.Sub(s.RotationStart)
+	week := int(elapsed / weekLength)
+	if elapsed < 0 && elapsed%weekLength != 0 {
+		week--
+	}
+	n := len(s.Members)
+	return &s.Members[((week%n)+n)%n]
+}
+
+// EscalationStep is a tier of an escalation chain. It is notified when an incident
+// stays unacknowledged for DelayMinutes after it opened.
+type EscalationStep struct {
+	DelayMinutes int    `json:"delay_minutes"`
+	ScheduleID   *int64 `json:"schedule_id,omitempty"` // Notify whoever is on call
+	Contacts     string `json:"contacts"`              // Comma-separated e-mail addresses
+}
+
+// EscalationPolicy is a chain of tiers notified about unacknowledged offline incidents.
+// It applies to a single device, to all devices of a type, or to all devices.
+type EscalationPolicy struct {
+	ID         int64            `json:"id"`
+	Name       string           `json:"name"`
+	DeviceID   *int64           `json:"device_id,omitempty"`
+	DeviceType DeviceType       `json:"device_type,omitempty"`
+	Enabled    bool             `json:"enabled"`
+	Steps      []EscalationStep `json:"steps"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// AppliesTo reports whether the policy covers a device
+func (p *EscalationPolicy) AppliesTo(deviceID int64, deviceType DeviceType) bool {
+	if p.DeviceID != nil {
+		return *p.DeviceID == deviceID
+	}
+	return p.DeviceType == "" || p.DeviceType == deviceType
+}
+
+// IncidentEscalation records that a tier of a policy was notified about an incident
+type IncidentEscalation struct {
+	ID         int64     `json:"id"`
+	IncidentID int64     `json:"incident_id"`
+	PolicyID   int64     `json:"policy_id"`
+	Level      int       `json:"level"` // Index of the step, starting at 1
+	Recipients string    `json:"recipients"`
+	Error      string    `json:"error,omitempty"` // Delivery error, if any
+	NotifiedAt time.Time `json:"notified_at"`
+}
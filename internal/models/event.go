@@ -23,6 +23,43 @@ const (
 	EventTypeMonitoringError   EventType = "monitoring_error"
 	EventTypeSystemStart       EventType = "system_start"
 	EventTypeSystemStop        EventType = "system_stop"
+	EventTypeClockDrift        EventType = "clock_drift"
+	EventTypeComplianceDrift   EventType = "compliance_drift"
+	EventTypeComplianceOK      EventType = "compliance_ok"
+	EventTypeDevicesImported   EventType = "devices_imported"
+	EventTypeLatencyNormal     EventType = "latency_normal"
+	EventTypeIncidentEscalated EventType = "incident_escalated"
+	EventTypeWarrantyExpiring  EventType = "warranty_expiring"
+	EventTypeWarrantyExpired   EventType = "warranty_expired"
+	EventTypeBackupFailed      EventType = "backup_failed"
+	EventTypeInterfaceDown     EventType = "interface_down"
+	EventTypeInterfaceUp       EventType = "interface_up"
+	EventTypeBGPPeerDown       EventType = "bgp_peer_down"
+	EventTypeBGPPeerUp         EventType = "bgp_peer_up"
+	EventTypeUPSOnBattery      EventType = "ups_on_battery"
+	EventTypeUPSOnMains        EventType = "ups_on_mains"
+	EventTypeUPSBatteryLow     EventType = "ups_battery_low"
+	EventTypeUPSSelfTestFailed EventType = "ups_selftest_failed"
+	EventTypeDiskFailed        EventType = "disk_failed"
+	EventTypeDiskOK            EventType = "disk_ok"
+	EventTypeOutletOff         EventType = "outlet_off"
+	EventTypeOutletOn          EventType = "outlet_on"
+	EventTypeTemperatureHigh   EventType = "temperature_high"
+	EventTypeTemperatureOK     EventType = "temperature_normal"
+	EventTypePoEBudgetHigh     EventType = "poe_budget_high"
+	EventTypePoEBudgetOK       EventType = "poe_budget_normal"
+	EventTypeCPUHigh           EventType = "cpu_high"
+	EventTypeCPUOK             EventType = "cpu_normal"
+	EventTypeMemoryHigh        EventType = "memory_high"
+	EventTypeMemoryOK          EventType = "memory_normal"
+	EventTypeFanFailed         EventType = "fan_failed"
+	EventTypeFanOK             EventType = "fan_ok"
+	EventTypePSUFailed         EventType = "psu_failed"
+	EventTypePSUOK             EventType = "psu_ok"
+	EventTypePoEPowerDrop      EventType = "poe_power_drop"
+	EventTypePoEPowerSpike     EventType = "poe_power_spike"
+	EventTypePoEPowerOK        EventType = "poe_power_normal"
+	EventTypePortFlapping      EventType = "port_flapping"
 )
 
 type Event struct {
@@ -40,6 +77,9 @@ type EventFilter struct {
 	Level     *EventLevel `json:"level,omitempty"`
 	StartTime *time.Time  `json:"start_time,omitempty"`
 	EndTime   *time.Time  `json:"end_time,omitempty"`
+	SiteID    *int64      `json:"site_id,omitempty"`
+	GroupID   *int64      `json:"group_id,omitempty"` // Includes subgroups
+	Tag       string      `json:"tag,omitempty"`
 	Limit     int         `json:"limit"`
 	Offset    int         `json:"offset"`
 }
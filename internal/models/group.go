@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Site is a physical location devices are installed at
+type Site struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Address     string    `json:"address"`
+	Latitude    *float64  `json:"latitude,omitempty"`
+	Longitude   *float64  `json:"longitude,omitempty"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DeviceGroup is a node of the device group tree. A device belongs to at most one
+// group and is also a member of all its ancestors.
+type DeviceGroup struct {
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	ParentID    *int64        `json:"parent_id,omitempty"`
+	Description string        `json:"description"`
+	Settings    GroupSettings `json:"settings"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// GroupSettings override application settings for devices of a group and its
+// subgroups. Unset values are inherited from the parent group, then from the
+// application settings.
+type GroupSettings struct {
+	MonitoringInterval int    `json:"monitoring_interval,omitempty"` // seconds, 0 = inherit
+	NotifyOnOffline    *bool  `json:"notify_on_offline,omitempty"`
+	NotifyOnOnline     *bool  `json:"notify_on_online,omitempty"`
+	NotifyEmails       string `json:"notify_emails,omitempty"` // Comma-separated addresses status changes are mailed to
+	EscalationPolicyID *int64 `json:"escalation_policy_id,omitempty"`
+}
+
+// GroupStatus is the aggregate status of the devices in a group and its subgroups
+type GroupStatus struct {
+	GroupID  int64  `json:"group_id"`
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id,omitempty"`
+	Total    int    `json:"total"`
+	Online   int    `json:"online"`
+	Offline  int    `json:"offline"`
+	Unknown  int    `json:"unknown"`
+	Status   string `json:"status"` // "online", "degraded" (some offline), "offline" (all offline), "unknown"
+}
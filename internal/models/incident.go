@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// IncidentKind describes the problem an incident tracks
+type IncidentKind string
+
+const (
+	IncidentKindOffline  IncidentKind = "offline"
+	IncidentKindDegraded IncidentKind = "degraded" // Online, but responding slower than the threshold
+)
+
+// IncidentStatus is the lifecycle state of an incident
+type IncidentStatus string
+
+const (
+	IncidentStatusOpen   IncidentStatus = "open"
+	IncidentStatusClosed IncidentStatus = "closed"
+)
+
+// Incident is an ongoing or past problem with a device. It opens when the device goes
+// offline or degraded, is updated on every failing check and closes on recovery.
+type Incident struct {
+	ID             int64          `json:"id"`
+	DeviceID       int64          `json:"device_id"`
+	DeviceName     string         `json:"device_name"`
+	Kind           IncidentKind   `json:"kind"`
+	Status         IncidentStatus `json:"status"`
+	Message        string         `json:"message"`
+	StartedAt      time.Time      `json:"started_at"`
+	LastSeenAt     time.Time      `json:"last_seen_at"` // Last failing check
+	EndedAt        *time.Time     `json:"ended_at,omitempty"`
+	Duration       int64          `json:"duration"` // seconds, up to now for open incidents
+	CheckCount     int64          `json:"check_count"`
+	AcknowledgedBy string         `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time     `json:"acknowledged_at,omitempty"`
+	AckComment     string         `json:"ack_comment,omitempty"`
+	AssignedTo     string         `json:"assigned_to,omitempty"`
+	LastNotifiedAt *time.Time     `json:"last_notified_at,omitempty"`
+}
+
+// Acknowledged reports whether an operator has acknowledged the incident
+func (i *Incident) Acknowledged() bool {
+	return i.AcknowledgedAt != nil
+}
+
+// IncidentNote is an operator note attached to an incident
+type IncidentNote struct {
+	ID         int64     `json:"id"`
+	IncidentID int64     `json:"incident_id"`
+	Author     string    `json:"author"`
+	Text       string    `json:"text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
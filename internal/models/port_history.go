@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Port state fields tracked in port history
+const (
+	PortFieldStatus = "status" // Link up/down
+	PortFieldSpeed  = "speed"  // Link speed as displayed
+	PortFieldDuplex = "duplex"
+	PortFieldAdmin  = "admin" // Port enabled/disabled
+	PortFieldPoE    = "poe"   // PoE power delivered
+)
+
+// Sources of port state changes
+const (
+	PortChangeMonitoring = "monitoring" // Seen by monitoring, made by the switch or outside the app
+	PortChangeUser       = "user"       // Made by a user of the app
+)
+
+// PortStateChange is a transition of a switch port state field
+type PortStateChange struct {
+	ID         int64     `json:"id"`
+	SwitchID   int64     `json:"switch_id"`
+	PortNumber int       `json:"port_number"`
+	Field      string    `json:"field"`
+	OldValue   string    `json:"old_value"`
+	NewValue   string    `json:"new_value"`
+	Flap       bool      `json:"flap"`            // Link went down while the port was enabled
+	Source     string    `json:"source"`          // "monitoring" or "user"
+	Actor      string    `json:"actor,omitempty"` // User who made the change
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// PortFlapCounter counts link losses of a switch port that was not disabled
+type PortFlapCounter struct {
+	SwitchID   int64      `json:"switch_id"`
+	PortNumber int        `json:"port_number"`
+	LastHour   int        `json:"last_hour"`
+	LastDay    int        `json:"last_day"`
+	LastWeek   int        `json:"last_week"`
+	Total      int        `json:"total"` // Within history retention
+	LastFlapAt *time.Time `json:"last_flap_at,omitempty"`
+}
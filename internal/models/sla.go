@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// StatusPeriod is a continuous period during which a device had the same status
+type StatusPeriod struct {
+	ID        int64     `json:"id"`
+	DeviceID  int64     `json:"device_id"`
+	Status    string    `json:"status"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+}
+
+// MaintenanceWindow is a planned period excluded from availability calculations.
+// It applies to a single device, to all devices of a type, or to all devices.
+type MaintenanceWindow struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	DeviceID    *int64     `json:"device_id,omitempty"`
+	DeviceType  DeviceType `json:"device_type,omitempty"`
+	StartAt     time.Time  `json:"start_at"`
+	EndAt       time.Time  `json:"end_at"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// AppliesTo reports whether the window covers a device
+func (w *MaintenanceWindow) AppliesTo(deviceID int64, deviceType DeviceType) bool {
+	if w.DeviceID != nil {
+		return *w.DeviceID == deviceID
+	}
+	return w.DeviceType == "" || w.DeviceType == deviceType
+}
+
+// SLAOutage is a period during which a device was offline outside maintenance
+type SLAOutage struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration int64     `json:"duration"` // seconds, excluding maintenance
+}
+
+// SLAAvailability contains time-weighted availability figures. Durations are in seconds.
+type SLAAvailability struct {
+	AvailabilityPercent float64 `json:"availability_percent"`
+	Monitored           int64   `json:"monitored"` // Time with known status outside maintenance
+	Uptime              int64   `json:"uptime"`
+	Downtime            int64   `json:"downtime"`
+	Maintenance         int64   `json:"maintenance"`
+	NoData              int64   `json:"no_data"` // Time without checks (monitoring stopped)
+	OutageCount         int     `json:"outage_count"`
+	MTTR                int64   `json:"mttr"` // Mean time to repair
+	MTBF                int64   `json:"mtbf"` // Mean time between failures, 0 if there were no outages
+}
+
+// SLADeviceReport contains availability of a single device
+type SLADeviceReport struct {
+	SLAAvailability
+	DeviceID   int64       `json:"device_id"`
+	DeviceName string      `json:"device_name"`
+	DeviceType DeviceType  `json:"device_type"`
+	IPAddress  string      `json:"ip_address"`
+	MeetsSLA   bool        `json:"meets_sla"`
+	Outages    []SLAOutage `json:"outages"`
+}
+
+// SLAGroupReport contains combined availability of a set of devices
+type SLAGroupReport struct {
+	SLAAvailability
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	DeviceCount int    `json:"device_count"`
+	MeetsSLA    bool   `json:"meets_sla"`
+}
+
+// SLAReport contains availability of all devices for a period
+type SLAReport struct {
+	Period      string            `json:"period"` // "2006-01"
+	Start       time.Time         `json:"start"`
+	End         time.Time         `json:"end"`
+	Target      float64           `json:"target"` // SLA target, percent
+	GeneratedAt time.Time         `json:"generated_at"`
+	Overall     SLAGroupReport    `json:"overall"`
+	ByType      []SLAGroupReport  `json:"by_type"`
+	ByGroup     []SLAGroupReport  `json:"by_group"`
+	Devices     []SLADeviceReport `json:"devices"`
+}
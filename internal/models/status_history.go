@@ -41,3 +41,16 @@ type UptimePoint struct {
 	UptimePercent float64 `json:"uptime_percent"`
 	TotalChecks   int64   `json:"total_checks"`
 }
+
+// StatusAggregate contains status history rolled up over an hour or a day.
+// Latency statistics only include successful checks.
+type StatusAggregate struct {
+	DeviceID    int64     `json:"device_id"`
+	PeriodStart time.Time `json:"period_start"`
+	Checks      int64     `json:"checks"`
+	OnlineCount int64     `json:"online_count"`
+	MinLatency  int64     `json:"min_latency"`
+	AvgLatency  float64   `json:"avg_latency"`
+	MaxLatency  int64     `json:"max_latency"`
+	P95Latency  int64     `json:"p95_latency"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Role defines what a user is allowed to do
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // Sees status, events and reports
+	RoleOperator Role = "operator" // Also acknowledges incidents and controls PoE, ports and cameras
+	RoleAdmin    Role = "admin"    // Also edits devices, credentials, settings and users
+)
+
+var roleRank = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// Valid reports whether the role is known
+func (r Role) Valid() bool {
+	return roleRank[r] > 0
+}
+
+// Allows reports whether the role grants everything the required role does
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRank[r] >= roleRank[required]
+}
+
+// User is a local account of the application
+type User struct {
+	ID           int64      `json:"id"`
+	Username     string     `json:"username"`
+	DisplayName  string     `json:"display_name"`
+	Role         Role       `json:"role"`
+	PasswordHash string     `json:"-"`
+	Disabled     bool       `json:"disabled"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Name returns the display name, or the username if it is not set
+func (u *User) Name() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return u.Username
+}
+
+// SessionInfo describes the current session for the frontend
+type SessionInfo struct {
+	KeyLocked      bool       `json:"key_locked"`     // The master key waits for its passphrase
+	SetupRequired  bool       `json:"setup_required"` // No users exist yet, the first admin must be created
+	LoggedIn       bool       `json:"logged_in"`
+	Locked         bool       `json:"locked"` // Logged in, but the password must be entered again
+	User           *User      `json:"user,omitempty"`
+	TimeoutMinutes int        `json:"timeout_minutes"`      // Idle time before the session locks, 0 = never
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"` // When the session locks without activity
+}
@@ -0,0 +1,69 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/logger"
+	"netvisionmonitor/internal/models"
+	mib "netvisionmonitor/internal/snmp"
+)
+
+// ReadSwitchEnvironment reads temperature, fans, power supplies, PoE budget and
+// CPU/memory load of a switch. A PoE budget set for the switch replaces the one
+// it reports.
+func (m *Monitor) ReadSwitchEnvironment(ctx context.Context, device models.Device) (*models.SwitchEnvironment, error) {
+	if device.Type != models.DeviceTypeSwitch {
+		return nil, fmt.Errorf("device type %s has no environment data", device.Type)
+	}
+
+	sw, err := database.NewSwitchRepository(m.db.DB()).GetByDeviceID(device.ID)
+	if err != nil {
+		return nil, err
+	}
+	if sw == nil {
+		return nil, fmt.Errorf("switch settings not found")
+	}
+
+	community := sw.SNMPCommunity
+	if community == "" {
+		community = "public"
+	}
+	client := mib.NewClientAuto(device.IPAddress, sw.SNMPVersion, community,
+		sw.SNMPv3User, sw.SNMPv3Security, sw.SNMPv3AuthProto, sw.SNMPv3AuthPass,
+		sw.SNMPv3PrivProto, sw.SNMPv3PrivPass)
+	client.SetTimeout(m.snmpTimeout)
+
+	env, err := client.GetSwitchEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	env.DeviceID = device.ID
+	env.CheckedAt = time.Now()
+	if sw.PoEBudgetW > 0 {
+		budget := sw.PoEBudgetW
+		env.PoEBudgetW = &budget
+	}
+	return env, nil
+}
+
+// updateEnvironment reads the environment of a switch and passes it on
+func (m *Monitor) updateEnvironment(device models.Device) {
+	if m.onEnvironment == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	env, err := m.ReadSwitchEnvironment(ctx, device)
+	if err != nil {
+		logger.Debug("Failed to read environment of switch %d: %v", device.ID, err)
+		return
+	}
+
+	m.onEnvironment(device, env)
+}
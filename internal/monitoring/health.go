@@ -0,0 +1,365 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/logger"
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/monitoring/nut"
+	"netvisionmonitor/internal/monitoring/nvr"
+	"netvisionmonitor/internal/monitoring/snmp"
+	mib "netvisionmonitor/internal/snmp"
+)
+
+// checkSNMPDevice checks a router, access point, UPS, NVR or PDU via SNMP, falls
+// back to ping if SNMP fails. The type-specific state is read in the background.
+func (m *Monitor) checkSNMPDevice(ctx context.Context, device models.Device) error {
+	if m.nutSettings(device) != nil {
+		return m.checkNUT(ctx, device)
+	}
+
+	settings, err := database.NewSNMPDeviceRepository(m.db.DB()).GetByDeviceID(device.ID)
+	if err != nil || settings == nil {
+		return m.checkPing(ctx, device)
+	}
+
+	var client *snmp.Client
+	if settings.SNMPVersion == "v3" {
+		client = snmp.NewClientV3(device.IPAddress, settings.SNMPv3User, settings.SNMPv3Security,
+			settings.SNMPv3AuthProto, settings.SNMPv3AuthPass, settings.SNMPv3PrivProto, settings.SNMPv3PrivPass,
+			m.snmpTimeout)
+	} else {
+		client = snmp.NewClient(device.IPAddress, snmpCommunity(settings), settings.SNMPVersion, m.snmpTimeout)
+	}
+
+	available, _, err := client.CheckAvailability(ctx)
+	if err != nil || !available {
+		logger.Debug("SNMP check failed for %s, falling back to ping: %v", device.IPAddress, err)
+		if err := m.checkPing(ctx, device); err != nil {
+			return err
+		}
+		// NVR disks can still be read over HTTP
+		if device.Type != models.DeviceTypeNVR {
+			return nil
+		}
+	}
+
+	go m.updateHealth(device)
+
+	return nil
+}
+
+// checkNUT checks a UPS via upsd, falls back to ping if upsd does not answer.
+// The state read for the check is recorded as the UPS health.
+func (m *Monitor) checkNUT(ctx context.Context, device models.Device) error {
+	health, err := m.ReadHealth(ctx, device)
+	if err != nil {
+		logger.Debug("NUT check failed for %s, falling back to ping: %v", device.IPAddress, err)
+		return m.checkPing(ctx, device)
+	}
+
+	m.recordHealth(device, health)
+
+	return nil
+}
+
+// nutSettings returns the settings of a UPS read over NUT, nil for other devices
+func (m *Monitor) nutSettings(device models.Device) *models.UPS {
+	if device.Type != models.DeviceTypeUPS {
+		return nil
+	}
+	ups, err := database.NewUPSRepository(m.db.DB()).GetByDeviceID(device.ID)
+	if err != nil || ups == nil || ups.Protocol != "nut" {
+		return nil
+	}
+	return ups
+}
+
+// ReadHealth reads the type-specific state of a router, access point, UPS, NVR
+// or PDU from the device
+func (m *Monitor) ReadHealth(ctx context.Context, device models.Device) (*models.DeviceHealth, error) {
+	if !device.Type.UsesSNMPDevice() {
+		return nil, fmt.Errorf("device type %s has no health data", device.Type)
+	}
+
+	health := &models.DeviceHealth{
+		DeviceID:  device.ID,
+		Type:      device.Type,
+		CheckedAt: time.Now(),
+	}
+
+	var err error
+	if ups := m.nutSettings(device); ups != nil {
+		health.UPS, err = m.readNUTStatus(ctx, device, ups)
+	} else {
+		err = m.readSNMPHealth(ctx, device, health)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if health.UPS != nil {
+		predictShutdown(health.UPS, health.CheckedAt)
+	}
+
+	return health, nil
+}
+
+// readSNMPHealth reads the type-specific state of a device into health, over
+// SNMP unless NVR disks are read over HTTP
+func (m *Monitor) readSNMPHealth(ctx context.Context, device models.Device, health *models.DeviceHealth) error {
+	settings, err := database.NewSNMPDeviceRepository(m.db.DB()).GetByDeviceID(device.ID)
+	if err != nil {
+		return err
+	}
+	if settings == nil {
+		return fmt.Errorf("SNMP settings not found")
+	}
+
+	client := mib.NewClientAuto(device.IPAddress, settings.SNMPVersion, snmpCommunity(settings),
+		settings.SNMPv3User, settings.SNMPv3Security, settings.SNMPv3AuthProto, settings.SNMPv3AuthPass,
+		settings.SNMPv3PrivProto, settings.SNMPv3PrivPass)
+	client.SetTimeout(m.snmpTimeout)
+
+	switch device.Type {
+	case models.DeviceTypeRouter:
+		health.Router, err = client.GetRouterStatus()
+	case models.DeviceTypeAccessPoint:
+		health.AccessPoint, err = client.GetAccessPointStatus()
+	case models.DeviceTypeUPS:
+		health.UPS, err = client.GetUPSStatus()
+	case models.DeviceTypeNVR:
+		health.NVR, err = m.readNVRStatus(ctx, device, client)
+	case models.DeviceTypePDU:
+		health.PDU, err = client.GetPDUStatus()
+	}
+	return err
+}
+
+// readNUTStatus reads a UPS from upsd, with the device credential if one is set
+func (m *Monitor) readNUTStatus(ctx context.Context, device models.Device, ups *models.UPS) (*models.UPSStatus, error) {
+	username, password := m.deviceLogin(device)
+	return nut.NewClient(m.snmpTimeout).GetStatus(ctx, device.IPAddress, ups.NUTPort, ups.NUTUPSName, username, password)
+}
+
+// predictShutdown sets when a UPS on battery is expected to shut down
+func predictShutdown(status *models.UPSStatus, now time.Time) {
+	if !status.OnBattery || status.RuntimeMinutes <= 0 {
+		return
+	}
+	shutdownAt := now.Add(time.Duration(status.RuntimeMinutes) * time.Minute)
+	status.ShutdownAt = &shutdownAt
+}
+
+// deviceLogin returns the username and password of the device credential
+func (m *Monitor) deviceLogin(device models.Device) (username, password string) {
+	if device.CredentialID == nil {
+		return "", ""
+	}
+	cred, err := database.NewCredentialRepository(m.db.DB()).GetByIDWithPassword(*device.CredentialID)
+	if err != nil || cred == nil {
+		return "", ""
+	}
+	return cred.Username, cred.Password
+}
+
+// readNVRStatus reads recorder disks over HTTP if enabled for the NVR, otherwise
+// from HOST-RESOURCES-MIB
+func (m *Monitor) readNVRStatus(ctx context.Context, device models.Device, client *mib.Client) (*models.NVRStatus, error) {
+	settings, err := database.NewNVRRepository(m.db.DB()).GetByDeviceID(device.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings == nil || !settings.CheckHTTP {
+		disks, err := client.GetDisks()
+		if err != nil {
+			return nil, err
+		}
+		return &models.NVRStatus{Disks: disks, Source: "snmp"}, nil
+	}
+
+	username, password := m.deviceLogin(device)
+	disks, err := nvr.NewClient(m.snmpTimeout).GetDisks(ctx, device.IPAddress, settings.HTTPPort, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return &models.NVRStatus{Disks: disks, Source: "http"}, nil
+}
+
+// updateHealth reads the type-specific state of a device and emits events for
+// what changed since the last check
+func (m *Monitor) updateHealth(device models.Device) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	health, err := m.ReadHealth(ctx, device)
+	if err != nil {
+		logger.Debug("Failed to read health of device %d: %v", device.ID, err)
+		return
+	}
+
+	m.recordHealth(device, health)
+}
+
+// recordHealth stores the state of a device and emits events for what changed
+// since the last check
+func (m *Monitor) recordHealth(device models.Device, health *models.DeviceHealth) {
+	m.healthMu.Lock()
+	if m.health == nil {
+		m.health = make(map[int64]*models.DeviceHealth)
+	}
+	previous := m.health[device.ID]
+	m.health[device.ID] = health
+	m.healthMu.Unlock()
+
+	if previous == nil || m.onEvent == nil {
+		return
+	}
+	for _, event := range healthEvents(device, previous, health) {
+		m.onEvent(event)
+	}
+}
+
+// snmpCommunity returns the community of SNMP settings, "public" if none is set
+func snmpCommunity(settings *models.SNMPDevice) string {
+	if settings.SNMPCommunity == "" {
+		return "public"
+	}
+	return settings.SNMPCommunity
+}
+
+// healthEvents compares two states of a device and returns events for the changes
+func healthEvents(device models.Device, previous, current *models.DeviceHealth) []*models.Event {
+	var events []*models.Event
+	add := func(eventType models.EventType, level models.EventLevel, format string, args ...interface{}) {
+		deviceID := device.ID
+		events = append(events, &models.Event{
+			DeviceID: &deviceID,
+			Type:     eventType,
+			Level:    level,
+			Message:  device.Name + ": " + fmt.Sprintf(format, args...),
+		})
+	}
+
+	interfaceEvents := func(old, cur []models.InterfaceStatus) {
+		before := make(map[int]models.InterfaceStatus, len(old))
+		for _, iface := range old {
+			before[iface.Index] = iface
+		}
+		for _, iface := range cur {
+			prev, ok := before[iface.Index]
+			if !ok || iface.AdminStatus == "down" || prev.OperStatus == iface.OperStatus {
+				continue
+			}
+			switch {
+			case prev.OperStatus == "up" && iface.OperStatus == "down":
+				add(models.EventTypeInterfaceDown, models.EventLevelWarn, "interface %s is down", iface.Name)
+			case prev.OperStatus == "down" && iface.OperStatus == "up":
+				add(models.EventTypeInterfaceUp, models.EventLevelInfo, "interface %s is up", iface.Name)
+			}
+		}
+	}
+
+	switch {
+	case previous.Router != nil && current.Router != nil:
+		interfaceEvents(previous.Router.Interfaces, current.Router.Interfaces)
+
+		before := make(map[string]models.BGPPeer, len(previous.Router.BGPPeers))
+		for _, peer := range previous.Router.BGPPeers {
+			before[peer.Address] = peer
+		}
+		for _, peer := range current.Router.BGPPeers {
+			prev, ok := before[peer.Address]
+			if !ok || prev.Established() == peer.Established() {
+				continue
+			}
+			if peer.Established() {
+				add(models.EventTypeBGPPeerUp, models.EventLevelInfo, "BGP session with %s (AS %d) is established", peer.Address, peer.RemoteAS)
+			} else {
+				add(models.EventTypeBGPPeerDown, models.EventLevelError, "BGP session with %s (AS %d) is down, state %s", peer.Address, peer.RemoteAS, peer.State)
+			}
+		}
+
+	case previous.AccessPoint != nil && current.AccessPoint != nil:
+		interfaceEvents(previous.AccessPoint.Interfaces, current.AccessPoint.Interfaces)
+
+	case previous.UPS != nil && current.UPS != nil:
+		prev, cur := previous.UPS, current.UPS
+		if !prev.OnBattery && cur.OnBattery {
+			add(models.EventTypeUPSOnBattery, models.EventLevelError, "UPS is on battery, charge %d%%, runtime %d min%s", cur.Charge, cur.RuntimeMinutes, shutdownNote(cur))
+		}
+		if prev.OnBattery && !cur.OnBattery {
+			add(models.EventTypeUPSOnMains, models.EventLevelInfo, "UPS is back on mains power, charge %d%%", cur.Charge)
+		}
+		if !prev.BatteryLow && cur.BatteryLow {
+			add(models.EventTypeUPSBatteryLow, models.EventLevelError, "UPS battery is %s, charge %d%%, runtime %d min%s", cur.BatteryStatus, cur.Charge, cur.RuntimeMinutes, shutdownNote(cur))
+		}
+		if prev.SelfTest != "failed" && cur.SelfTest == "failed" {
+			add(models.EventTypeUPSSelfTestFailed, models.EventLevelError, "UPS self-test failed")
+		}
+
+	case previous.NVR != nil && current.NVR != nil:
+		before := make(map[string]models.DiskStatus, len(previous.NVR.Disks))
+		for _, disk := range previous.NVR.Disks {
+			before[disk.ID] = disk
+		}
+		for _, disk := range current.NVR.Disks {
+			prev, ok := before[disk.ID]
+			if !ok || prev.Status == disk.Status {
+				continue
+			}
+			switch {
+			case disk.Status == "failed" || disk.Status == "warning":
+				add(models.EventTypeDiskFailed, models.EventLevelError, "disk %s is %s", disk.Name, diskState(disk))
+			case disk.Status == "ok":
+				add(models.EventTypeDiskOK, models.EventLevelInfo, "disk %s is ok", disk.Name)
+			}
+		}
+
+	case previous.PDU != nil && current.PDU != nil:
+		before := make(map[int]models.OutletStatus, len(previous.PDU.Outlets))
+		for _, outlet := range previous.PDU.Outlets {
+			before[outlet.Number] = outlet
+		}
+		for _, outlet := range current.PDU.Outlets {
+			prev, ok := before[outlet.Number]
+			if !ok || prev.State == outlet.State {
+				continue
+			}
+			switch outlet.State {
+			case "off":
+				add(models.EventTypeOutletOff, models.EventLevelWarn, "outlet %s is off", outletName(outlet))
+			case "on":
+				add(models.EventTypeOutletOn, models.EventLevelInfo, "outlet %s is on", outletName(outlet))
+			}
+		}
+	}
+
+	return events
+}
+
+// shutdownNote describes the predicted shutdown time of a UPS on battery
+func shutdownNote(status *models.UPSStatus) string {
+	if status.ShutdownAt == nil {
+		return ""
+	}
+	return ", expected shutdown at " + status.ShutdownAt.Format("15:04")
+}
+
+func diskState(disk models.DiskStatus) string {
+	if disk.Detail != "" {
+		return disk.Detail
+	}
+	return disk.Status
+}
+
+func outletName(outlet models.OutletStatus) string {
+	if outlet.Name != "" {
+		return fmt.Sprintf("%d (%s)", outlet.Number, outlet.Name)
+	}
+	return fmt.Sprint(outlet.Number)
+}
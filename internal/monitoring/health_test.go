@@ -0,0 +1,124 @@
+package monitoring
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"netvisionmonitor/internal/database"
+	"netvisionmonitor/internal/models"
+)
+
+// fakeUPSD answers LIST VAR for the UPS "rack" with the current variables
+type fakeUPSD struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	vars map[string]string
+}
+
+func (f *fakeUPSD) set(vars map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.vars = vars
+}
+
+func (f *fakeUPSD) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				switch strings.TrimSpace(line) {
+				case `LIST VAR "rack"`:
+					f.mu.Lock()
+					fmt.Fprint(conn, "BEGIN LIST VAR rack\n")
+					for k, v := range f.vars {
+						fmt.Fprintf(conn, "VAR rack %s \"%s\"\n", k, v)
+					}
+					fmt.Fprint(conn, "END LIST VAR rack\n")
+					f.mu.Unlock()
+				case "LOGOUT":
+					fmt.Fprint(conn, "OK Goodbye\n")
+					return
+				default:
+					fmt.Fprint(conn, "ERR UNKNOWN-COMMAND\n")
+				}
+			}
+		}()
+	}
+}
+
+func TestReadHealthPredictsShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	upsd := &fakeUPSD{ln: ln}
+	go upsd.serve()
+
+	db, err := database.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	device := &models.Device{Name: "ups1", IPAddress: "127.0.0.1", Type: models.DeviceTypeUPS, Status: models.DeviceStatusUnknown}
+	if err := database.NewDeviceRepository(db.DB()).Create(device); err != nil {
+		t.Fatal(err)
+	}
+	ups := &models.UPS{DeviceID: device.ID, Protocol: "nut", NUTPort: ln.Addr().(*net.TCPAddr).Port, NUTUPSName: "rack"}
+	if err := database.NewUPSRepository(db.DB()).Create(ups); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMonitor(db, DefaultConfig())
+	read := func() *models.UPSStatus {
+		t.Helper()
+		health, err := m.ReadHealth(context.Background(), *device)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if health.UPS == nil {
+			t.Fatal("no UPS status")
+		}
+		if health.UPS.ShutdownAt != nil && !health.UPS.ShutdownAt.Equal(health.CheckedAt.Add(time.Duration(health.UPS.RuntimeMinutes)*time.Minute)) {
+			t.Fatalf("shutdown at %v, checked at %v with %d min runtime", health.UPS.ShutdownAt, health.CheckedAt, health.UPS.RuntimeMinutes)
+		}
+		return health.UPS
+	}
+
+	upsd.set(map[string]string{"ups.status": "OL CHRG", "battery.runtime": "1800"})
+	if status := read(); status.ShutdownAt != nil {
+		t.Errorf("shutdown predicted on mains: %v", status.ShutdownAt)
+	}
+
+	upsd.set(map[string]string{"ups.status": "OB DISCHRG", "battery.runtime": "1200"})
+	if status := read(); status.ShutdownAt == nil || status.RuntimeMinutes != 20 {
+		t.Errorf("on battery: shutdown at %v, runtime %d min", status.ShutdownAt, status.RuntimeMinutes)
+	}
+
+	upsd.set(map[string]string{"ups.status": "OB DISCHRG LB", "battery.runtime": "300"})
+	if status := read(); status.ShutdownAt == nil || status.RuntimeMinutes != 5 || !status.BatteryLow {
+		t.Errorf("low battery: shutdown at %v, runtime %d min, low %v", status.ShutdownAt, status.RuntimeMinutes, status.BatteryLow)
+	}
+
+	// Without a runtime estimate there is nothing to predict from
+	upsd.set(map[string]string{"ups.status": "OB DISCHRG"})
+	if status := read(); status.ShutdownAt != nil {
+		t.Errorf("shutdown predicted without runtime: %v", status.ShutdownAt)
+	}
+}
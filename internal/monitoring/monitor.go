@@ -2,6 +2,7 @@ package monitoring
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -31,8 +32,29 @@ type Monitor struct {
 	// Callbacks
 	onStatusChange func(deviceID int64, oldStatus, newStatus string)
 	onEvent        func(event *models.Event)
+	onResult       func(device models.Device, result Result)
+	onEnvironment  func(device models.Device, env *models.SwitchEnvironment)
+	onPortChanges  func(device models.Device, changes []models.PortStateChange)
+
+	// Per-device scheduling
+	intervalFunc IntervalFunc
+	scheduleMu   sync.Mutex
+	nextCheck    map[int64]time.Time
+	intervals    map[int64]time.Duration
+
+	// Last type-specific state of routers, access points, UPS units, NVRs and
+	// PDUs, compared with the next one to create events
+	healthMu sync.Mutex
+	health   map[int64]*models.DeviceHealth
 }
 
+// IntervalFunc returns check intervals of devices that differ from the monitoring
+// interval, keyed by device ID
+type IntervalFunc func(devices []models.Device) map[int64]time.Duration
+
+// scheduleTick is how often the monitor looks for devices due for a check
+const scheduleTick = 5 * time.Second
+
 // Config holds monitor configuration
 type Config struct {
 	Interval     time.Duration
@@ -79,6 +101,29 @@ func (m *Monitor) SetEventHandler(handler func(event *models.Event)) {
 	m.onEvent = handler
 }
 
+// SetResultHandler sets callback for every check result, called after the
+// result has been recorded
+func (m *Monitor) SetResultHandler(handler func(device models.Device, result Result)) {
+	m.onResult = handler
+}
+
+// SetEnvironmentHandler sets callback for the environment of switches, read in
+// the background after each successful SNMP check
+func (m *Monitor) SetEnvironmentHandler(handler func(device models.Device, env *models.SwitchEnvironment)) {
+	m.onEnvironment = handler
+}
+
+// SetPortChangesHandler sets callback for the port state changes of a switch,
+// called after they are recorded in the port history
+func (m *Monitor) SetPortChangesHandler(handler func(device models.Device, changes []models.PortStateChange)) {
+	m.onPortChanges = handler
+}
+
+// SetIntervalFunc sets the function providing per-device check intervals
+func (m *Monitor) SetIntervalFunc(fn IntervalFunc) {
+	m.intervalFunc = fn
+}
+
 // Start begins the monitoring cycle
 func (m *Monitor) Start() {
 	m.mu.Lock()
@@ -128,6 +173,35 @@ func (m *Monitor) SetInterval(interval time.Duration) {
 	m.mu.Unlock()
 }
 
+// PeriodGap returns the longest gap between two checks that is still treated as
+// continuous monitoring. Longer gaps mean monitoring was stopped.
+func (m *Monitor) PeriodGap() time.Duration {
+	m.mu.RLock()
+	interval := m.interval
+	m.mu.RUnlock()
+
+	return periodGap(interval)
+}
+
+// devicePeriodGap returns the period gap for the check interval of a device
+func (m *Monitor) devicePeriodGap(deviceID int64) time.Duration {
+	m.scheduleMu.Lock()
+	interval, ok := m.intervals[deviceID]
+	m.scheduleMu.Unlock()
+	if !ok {
+		return m.PeriodGap()
+	}
+	return periodGap(interval)
+}
+
+func periodGap(interval time.Duration) time.Duration {
+	gap := 3 * interval
+	if gap < 2*time.Minute {
+		gap = 2 * time.Minute
+	}
+	return gap
+}
+
 // RunOnce performs a single monitoring cycle
 func (m *Monitor) RunOnce() {
 	m.mu.RLock()
@@ -161,13 +235,20 @@ func (m *Monitor) monitorLoop() {
 	// Initial check
 	m.checkAllDevices()
 
-	ticker := time.NewTicker(m.interval)
+	m.mu.RLock()
+	tick := scheduleTick
+	if m.interval < tick {
+		tick = m.interval
+	}
+	m.mu.RUnlock()
+
+	ticker := time.NewTicker(tick)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			m.checkAllDevices()
+			m.checkDevices(false)
 		case <-m.ctx.Done():
 			return
 		}
@@ -176,6 +257,12 @@ func (m *Monitor) monitorLoop() {
 
 // checkAllDevices submits monitoring tasks for all devices
 func (m *Monitor) checkAllDevices() {
+	m.checkDevices(true)
+}
+
+// checkDevices submits monitoring tasks for devices whose check interval has
+// elapsed, or for all devices
+func (m *Monitor) checkDevices(all bool) {
 	deviceRepo := database.NewDeviceRepository(m.db.DB())
 
 	devices, err := deviceRepo.GetAll()
@@ -184,9 +271,45 @@ func (m *Monitor) checkAllDevices() {
 		return
 	}
 
-	logger.Debug("Checking %d devices", len(devices))
+	m.mu.RLock()
+	defaultInterval := m.interval
+	m.mu.RUnlock()
+
+	var overrides map[int64]time.Duration
+	if m.intervalFunc != nil {
+		overrides = m.intervalFunc(devices)
+	}
 
+	now := time.Now()
+	var due []models.Device
+
+	m.scheduleMu.Lock()
+	nextCheck := make(map[int64]time.Time, len(devices))
+	intervals := make(map[int64]time.Duration, len(devices))
 	for _, device := range devices {
+		interval := defaultInterval
+		if override, ok := overrides[device.ID]; ok && override > 0 {
+			interval = override
+		}
+		intervals[device.ID] = interval
+
+		// Half a tick of tolerance keeps checks from slipping to the next tick
+		next, scheduled := m.nextCheck[device.ID]
+		if all || !scheduled || !now.Add(scheduleTick/2).Before(next) {
+			due = append(due, device)
+			next = now.Add(interval)
+		}
+		nextCheck[device.ID] = next
+	}
+	m.nextCheck = nextCheck
+	m.intervals = intervals
+	m.scheduleMu.Unlock()
+
+	if len(due) > 0 {
+		logger.Debug("Checking %d of %d devices", len(due), len(devices))
+	}
+
+	for _, device := range due {
 		task := m.createTask(device)
 		m.pool.Submit(task)
 	}
@@ -213,6 +336,9 @@ func (m *Monitor) checkDevice(ctx context.Context, device models.Device) error {
 		return m.checkServer(ctx, device)
 	case models.DeviceTypeCamera:
 		return m.checkCamera(ctx, device)
+	case models.DeviceTypeRouter, models.DeviceTypeAccessPoint, models.DeviceTypeUPS,
+		models.DeviceTypeNVR, models.DeviceTypePDU:
+		return m.checkSNMPDevice(ctx, device)
 	default:
 		return m.checkPing(ctx, device)
 	}
@@ -261,14 +387,17 @@ func (m *Monitor) checkSwitch(ctx context.Context, device models.Device) error {
 	}
 
 	// Optionally update port statuses
-	go m.updatePortStatuses(ctx, device.ID, client, sw.PortCount)
+	go m.updatePortStatuses(ctx, device, client, sw.PortCount)
+	go m.updateEnvironment(device)
 
 	return nil
 }
 
-// updatePortStatuses updates switch port statuses
-func (m *Monitor) updatePortStatuses(ctx context.Context, deviceID int64, client *snmp.Client, portCount int) {
-	interfaces, err := client.GetAllInterfaceStatuses(ctx, portCount)
+// updatePortStatuses updates switch port states, records their changes in the
+// port history and emits events for link changes
+func (m *Monitor) updatePortStatuses(ctx context.Context, device models.Device, client *snmp.Client, portCount int) {
+	deviceID := device.ID
+	states, err := client.GetPortStates(ctx, portCount)
 	if err != nil {
 		logger.Debug("Failed to get interface statuses for device %d: %v", deviceID, err)
 		return
@@ -280,38 +409,75 @@ func (m *Monitor) updatePortStatuses(ctx context.Context, deviceID int64, client
 		return
 	}
 
-	// Create a map of port numbers to interface statuses
-	statusMap := make(map[int]string)
-	for _, iface := range interfaces {
-		statusMap[iface.Index] = iface.Status
+	// Create a map of port numbers to port states
+	stateMap := make(map[int]snmp.PortState)
+	for _, state := range states {
+		stateMap[state.Index] = state
 	}
 
-	// Update port statuses
+	// Update port states
+	type portUpdate struct {
+		port      models.SwitchPort
+		oldStatus string
+		changes   []models.PortStateChange
+	}
+	now := time.Now()
+	var updates []portUpdate
+	var allChanges []models.PortStateChange
 	for _, port := range ports {
-		if status, ok := statusMap[port.PortNumber]; ok {
-			if port.Status != status {
-				// Status changed
-				oldStatus := port.Status
-				switchRepo.UpdatePortStatus(port.ID, status)
-
-				// Emit event
-				if m.onEvent != nil {
-					eventType := models.EventTypePortUp
-					level := models.EventLevelInfo
-					if status == "down" {
-						eventType = models.EventTypePortDown
-						level = models.EventLevelWarn
-					}
-
-					m.onEvent(&models.Event{
-						DeviceID: &deviceID,
-						Type:     eventType,
-						Level:    level,
-						Message:  fmt.Sprintf("Port %d changed from %s to %s", port.PortNumber, oldStatus, status),
-					})
-				}
+		state, ok := stateMap[port.PortNumber]
+		if !ok {
+			continue
+		}
+		before := port
+		changes := applyPortState(&port, state, now)
+		if port == before {
+			continue
+		}
+		updates = append(updates, portUpdate{port: port, oldStatus: before.Status, changes: changes})
+		allChanges = append(allChanges, changes...)
+	}
+	if len(updates) == 0 {
+		return
+	}
+
+	err = m.db.WithTx(func(tx *sql.Tx) error {
+		repo := database.NewSwitchRepository(tx)
+		for i := range updates {
+			if err := repo.UpdatePortState(&updates[i].port, updates[i].changes); err != nil {
+				return err
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to update port states of switch %d: %v", deviceID, err)
+		return
+	}
+
+	// Emit events for link changes
+	for _, u := range updates {
+		if m.onEvent == nil || u.oldStatus == u.port.Status {
+			continue
+		}
+
+		eventType := models.EventTypePortUp
+		level := models.EventLevelInfo
+		if u.port.Status == "down" {
+			eventType = models.EventTypePortDown
+			level = models.EventLevelWarn
+		}
+
+		m.onEvent(&models.Event{
+			DeviceID: &deviceID,
+			Type:     eventType,
+			Level:    level,
+			Message:  fmt.Sprintf("Port %d changed from %s to %s", u.port.PortNumber, u.oldStatus, u.port.Status),
+		})
+	}
+
+	if m.onPortChanges != nil && len(allChanges) > 0 {
+		m.onPortChanges(device, allChanges)
 	}
 }
 
@@ -429,6 +595,16 @@ func (m *Monitor) handleResult(result Result) {
 	latencyMs := result.Latency.Milliseconds()
 	historyRepo.Record(result.DeviceID, newStatus, latencyMs)
 
+	// Extend the current status period used for time-weighted availability
+	periodRepo := database.NewStatusPeriodRepository(m.db.DB())
+	if err := periodRepo.Extend(result.DeviceID, newStatus, time.Now(), m.devicePeriodGap(result.DeviceID)); err != nil {
+		logger.Warn("Failed to record status period: %v", err)
+	}
+
+	if m.onResult != nil {
+		m.onResult(*device, result)
+	}
+
 	// Check for status change
 	if oldStatus != newStatus && oldStatus != "unknown" {
 		if m.onStatusChange != nil {
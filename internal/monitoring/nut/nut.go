@@ -0,0 +1,235 @@
+package nut
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// DefaultPort is the TCP port of upsd
+const DefaultPort = 3493
+
+// Client reads UPS variables from a Network UPS Tools server (upsd)
+type Client struct {
+	Timeout time.Duration
+}
+
+// NewClient creates a new NUT client
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		Timeout: timeout,
+	}
+}
+
+// GetStatus reads the state of a UPS served by upsd. The credential is only
+// sent if set, upsd allows listing variables without login by default.
+func (c *Client) GetStatus(ctx context.Context, host string, port int, upsName, username, password string) (*models.UPSStatus, error) {
+	vars, err := c.ListVars(ctx, host, port, upsName, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return statusFromVars(vars), nil
+}
+
+// ListVars returns all variables of a UPS (LIST VAR)
+func (c *Client) ListVars(ctx context.Context, host string, port int, upsName, username, password string) (map[string]string, error) {
+	if port <= 0 {
+		port = DefaultPort
+	}
+	if upsName == "" {
+		upsName = "ups"
+	}
+
+	dialer := net.Dialer{Timeout: c.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to upsd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else if c.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+
+	reader := bufio.NewReader(conn)
+	command := func(line string) (string, error) {
+		if _, err := fmt.Fprintf(conn, "%s\n", line); err != nil {
+			return "", fmt.Errorf("failed to send to upsd: %w", err)
+		}
+		return readLine(reader)
+	}
+
+	if username != "" {
+		for _, line := range []string{"USERNAME " + quote(username), "PASSWORD " + quote(password)} {
+			reply, err := command(line)
+			if err != nil {
+				return nil, err
+			}
+			if reply != "OK" {
+				return nil, replyError(reply)
+			}
+		}
+	}
+
+	reply, err := command("LIST VAR " + quote(upsName))
+	if err != nil {
+		return nil, err
+	}
+	if reply != "BEGIN LIST VAR "+upsName {
+		return nil, replyError(reply)
+	}
+
+	vars := make(map[string]string)
+	prefix := "VAR " + upsName + " "
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if line == "END LIST VAR "+upsName {
+			break
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, prefix), " ")
+		if !ok || !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		vars[name] = unquote(value)
+	}
+
+	fmt.Fprintf(conn, "LOGOUT\n")
+	return vars, nil
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read from upsd: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// replyError turns an upsd reply into an error, e.g. "ERR UNKNOWN-UPS"
+func replyError(reply string) error {
+	if code, ok := strings.CutPrefix(reply, "ERR "); ok {
+		return fmt.Errorf("upsd error: %s", code)
+	}
+	return fmt.Errorf("unexpected upsd reply: %q", reply)
+}
+
+// quote quotes a command argument
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// unquote decodes a quoted value of a VAR line
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range s[1 : len(s)-1] {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// statusFromVars maps standard NUT variables to a UPS status
+func statusFromVars(vars map[string]string) *models.UPSStatus {
+	status := &models.UPSStatus{
+		BatteryStatus: "unknown",
+		OutputSource:  "unknown",
+		Source:        "nut",
+	}
+
+	// ups.status is a list of flags such as "OL CHRG" or "OB DISCHRG LB"
+	flags := make(map[string]bool)
+	for _, flag := range strings.Fields(vars["ups.status"]) {
+		flags[flag] = true
+	}
+	switch {
+	case flags["OFF"]:
+		status.OutputSource = "none"
+	case flags["BYPASS"]:
+		status.OutputSource = "bypass"
+	case flags["OB"]:
+		status.OutputSource = "battery"
+	case flags["BOOST"]:
+		status.OutputSource = "booster"
+	case flags["TRIM"]:
+		status.OutputSource = "reducer"
+	case flags["OL"]:
+		status.OutputSource = "normal"
+	}
+	status.OnBattery = flags["OB"]
+	status.BatteryLow = flags["LB"]
+	switch {
+	case flags["LB"]:
+		status.BatteryStatus = "low"
+	case len(flags) > 0:
+		status.BatteryStatus = "normal"
+	}
+
+	if v, ok := number(vars["battery.charge"]); ok {
+		status.Charge = int(v)
+	}
+	if v, ok := number(vars["battery.runtime"]); ok {
+		status.RuntimeMinutes = int(v) / 60
+	}
+	if v, ok := number(vars["ups.load"]); ok {
+		status.Load = int(v)
+	}
+	if v, ok := number(vars["input.voltage"]); ok {
+		status.InputVoltage = &v
+	}
+	for _, name := range []string{"battery.temperature", "ups.temperature"} {
+		if v, ok := number(vars[name]); ok {
+			temperature := int(v)
+			status.Temperature = &temperature
+			break
+		}
+	}
+	status.SelfTest = selfTestResult(vars["ups.test.result"])
+
+	return status
+}
+
+// selfTestResult maps the free-form ups.test.result text of NUT drivers
+func selfTestResult(result string) string {
+	result = strings.ToLower(result)
+	switch {
+	case result == "":
+		return ""
+	case strings.Contains(result, "no test"):
+		return "none"
+	case strings.Contains(result, "progress"):
+		return "in_progress"
+	case strings.Contains(result, "abort"):
+		return "aborted"
+	case strings.Contains(result, "warning"):
+		return "warning"
+	case strings.Contains(result, "fail"), strings.Contains(result, "error"):
+		return "failed"
+	case strings.Contains(result, "pass"):
+		return "passed"
+	}
+	return ""
+}
+
+func number(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v, err == nil
+}
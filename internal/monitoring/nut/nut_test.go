@@ -0,0 +1,194 @@
+package nut
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUPSD is a upsd serving the variables of one UPS
+type fakeUPSD struct {
+	ln       net.Listener
+	upsName  string
+	password string // Required if set
+
+	mu   sync.Mutex
+	vars map[string]string
+}
+
+func startFakeUPSD(t *testing.T, upsName, password string, vars map[string]string) *fakeUPSD {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeUPSD{ln: ln, upsName: upsName, password: password, vars: vars}
+	t.Cleanup(func() { ln.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeUPSD) port() int {
+	return f.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeUPSD) setVar(name, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.vars[name] = value
+}
+
+func (f *fakeUPSD) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeUPSD) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	loggedIn := f.password == ""
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		f.mu.Lock()
+		vars := make(map[string]string, len(f.vars))
+		for k, v := range f.vars {
+			vars[k] = v
+		}
+		f.mu.Unlock()
+
+		switch {
+		case strings.HasPrefix(line, "USERNAME "):
+			fmt.Fprint(conn, "OK\n")
+		case strings.HasPrefix(line, "PASSWORD "):
+			if line != "PASSWORD "+quote(f.password) {
+				fmt.Fprint(conn, "ERR ACCESS-DENIED\n")
+				continue
+			}
+			loggedIn = true
+			fmt.Fprint(conn, "OK\n")
+		case line == "LIST VAR "+quote(f.upsName):
+			if !loggedIn {
+				fmt.Fprint(conn, "ERR ACCESS-DENIED\n")
+				continue
+			}
+			fmt.Fprintf(conn, "BEGIN LIST VAR %s\n", f.upsName)
+			for k, v := range vars {
+				fmt.Fprintf(conn, "VAR %s %s %s\n", f.upsName, k, quote(v))
+			}
+			fmt.Fprintf(conn, "END LIST VAR %s\n", f.upsName)
+		case strings.HasPrefix(line, "LIST VAR "):
+			fmt.Fprint(conn, "ERR UNKNOWN-UPS\n")
+		case line == "LOGOUT":
+			fmt.Fprint(conn, "OK Goodbye\n")
+			return
+		default:
+			fmt.Fprint(conn, "ERR UNKNOWN-COMMAND\n")
+		}
+	}
+}
+
+func testVars() map[string]string {
+	return map[string]string{
+		"ups.status":          "OL CHRG",
+		"battery.charge":      "100",
+		"battery.runtime":     "1800",
+		"ups.load":            "23",
+		"input.voltage":       "229.5",
+		"battery.temperature": "31.2",
+		"ups.test.result":     "Done and passed",
+		"device.mfr":          `APC "Back-UPS"`,
+	}
+}
+
+func TestListVars(t *testing.T) {
+	f := startFakeUPSD(t, "rack", "", testVars())
+
+	vars, err := NewClient(2*time.Second).ListVars(context.Background(), "127.0.0.1", f.port(), "rack", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vars) != len(testVars()) {
+		t.Errorf("got %d variables, want %d: %v", len(vars), len(testVars()), vars)
+	}
+	for name, want := range testVars() {
+		if vars[name] != want {
+			t.Errorf("%s = %q, want %q", name, vars[name], want)
+		}
+	}
+}
+
+func TestListVarsLogin(t *testing.T) {
+	f := startFakeUPSD(t, "rack", `pa"ss`, testVars())
+
+	client := NewClient(2 * time.Second)
+	if _, err := client.ListVars(context.Background(), "127.0.0.1", f.port(), "rack", "monitor", `pa"ss`); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := client.ListVars(context.Background(), "127.0.0.1", f.port(), "rack", "monitor", "wrong")
+	if err == nil || !strings.Contains(err.Error(), "ACCESS-DENIED") {
+		t.Fatalf("error %v, want ACCESS-DENIED", err)
+	}
+}
+
+func TestListVarsError(t *testing.T) {
+	f := startFakeUPSD(t, "rack", "", testVars())
+
+	_, err := NewClient(2*time.Second).ListVars(context.Background(), "127.0.0.1", f.port(), "other", "", "")
+	if err == nil || err.Error() != "upsd error: UNKNOWN-UPS" {
+		t.Fatalf("error %v, want upsd error: UNKNOWN-UPS", err)
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	f := startFakeUPSD(t, "rack", "", testVars())
+	client := NewClient(2 * time.Second)
+
+	status, err := client.GetStatus(context.Background(), "127.0.0.1", f.port(), "rack", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.OnBattery || status.BatteryLow || status.OutputSource != "normal" || status.BatteryStatus != "normal" {
+		t.Errorf("on line: %+v", status)
+	}
+	if status.Charge != 100 || status.RuntimeMinutes != 30 || status.Load != 23 || status.SelfTest != "passed" {
+		t.Errorf("values: %+v", status)
+	}
+	if status.InputVoltage == nil || *status.InputVoltage != 229.5 || status.Temperature == nil || *status.Temperature != 31 {
+		t.Errorf("input voltage %v, temperature %v", status.InputVoltage, status.Temperature)
+	}
+
+	f.setVar("ups.status", "OB DISCHRG")
+	f.setVar("battery.runtime", "1200")
+	status, err = client.GetStatus(context.Background(), "127.0.0.1", f.port(), "rack", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.OnBattery || status.BatteryLow || status.OutputSource != "battery" || status.RuntimeMinutes != 20 {
+		t.Errorf("on battery: %+v", status)
+	}
+
+	f.setVar("ups.status", "OB DISCHRG LB")
+	status, err = client.GetStatus(context.Background(), "127.0.0.1", f.port(), "rack", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.OnBattery || !status.BatteryLow || status.BatteryStatus != "low" {
+		t.Errorf("low battery: %+v", status)
+	}
+}
@@ -0,0 +1,177 @@
+package nvr
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// storagePath is the ISAPI resource listing the disks of Hikvision compatible recorders
+const storagePath = "/ISAPI/ContentMgmt/Storage"
+
+// Client reads recorder state over HTTP
+type Client struct {
+	Timeout time.Duration
+}
+
+// NewClient creates a new NVR client
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		Timeout: timeout,
+	}
+}
+
+// storage is the ISAPI storage document
+type storage struct {
+	HDDs []struct {
+		ID        string `xml:"id"`
+		Name      string `xml:"hddName"`
+		Status    string `xml:"status"`
+		Capacity  int64  `xml:"capacity"`  // MB
+		FreeSpace int64  `xml:"freeSpace"` // MB
+	} `xml:"hddList>hdd"`
+}
+
+// GetDisks reads the recording disks over ISAPI
+func (c *Client) GetDisks(ctx context.Context, host string, port int, username, password string) ([]models.DiskStatus, error) {
+	if port <= 0 {
+		port = 80
+	}
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(port)) + storagePath
+
+	body, err := c.get(ctx, url, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc storage
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid storage response: %w", err)
+	}
+
+	disks := make([]models.DiskStatus, 0, len(doc.HDDs))
+	for _, hdd := range doc.HDDs {
+		name := hdd.Name
+		if name == "" {
+			name = "HDD " + hdd.ID
+		}
+		disks = append(disks, models.DiskStatus{
+			ID:         hdd.ID,
+			Name:       name,
+			Status:     diskStatus(hdd.Status),
+			Detail:     hdd.Status,
+			CapacityMB: hdd.Capacity,
+			FreeMB:     hdd.FreeSpace,
+		})
+	}
+	return disks, nil
+}
+
+// diskStatus maps ISAPI disk states
+func diskStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "ok", "idle", "sleeping":
+		return "ok"
+	case "unformatted", "uninitialized", "formating", "reparing", "mismatch", "smartfailed":
+		return "warning"
+	case "error", "offline", "notexist", "abnormal":
+		return "failed"
+	}
+	return "unknown"
+}
+
+// get performs a GET request, answering basic and digest challenges
+func (c *Client) get(ctx context.Context, url, username, password string) ([]byte, error) {
+	client := &http.Client{Timeout: c.Timeout}
+
+	resp, err := c.do(ctx, client, url, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && username != "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		var authorization string
+		switch {
+		case strings.HasPrefix(strings.ToLower(challenge), "digest"):
+			authorization = digestAuthorization(challenge, storagePath, username, password)
+		default:
+			authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+		}
+		if resp, err = c.do(ctx, client, url, authorization); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, storagePath)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func (c *Client) do(ctx context.Context, client *http.Client, url, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// digestAuthorization answers an HTTP digest challenge (RFC 7616) with MD5
+func digestAuthorization(challenge, uri, username, password string) string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(challenge[len("digest"):], ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			params[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+
+	hash := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	ha1 := hash(username + ":" + params["realm"] + ":" + password)
+	ha2 := hash("GET:" + uri)
+
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s"`,
+		username, params["realm"], params["nonce"], uri)
+	if qop := params["qop"]; qop != "" {
+		cnonce := make([]byte, 8)
+		rand.Read(cnonce)
+		nc := "00000001"
+		cn := hex.EncodeToString(cnonce)
+		response := hash(ha1 + ":" + params["nonce"] + ":" + nc + ":" + cn + ":auth:" + ha2)
+		header += fmt.Sprintf(`, qop=auth, nc=%s, cnonce="%s", response="%s"`, nc, cn, response)
+	} else {
+		header += fmt.Sprintf(`, response="%s"`, hash(ha1+":"+params["nonce"]+":"+ha2))
+	}
+	if opaque := params["opaque"]; opaque != "" {
+		header += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+	if algorithm := params["algorithm"]; algorithm != "" {
+		header += ", algorithm=" + algorithm
+	}
+	return header
+}
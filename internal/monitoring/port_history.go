@@ -0,0 +1,47 @@
+package monitoring
+
+import (
+	"time"
+
+	"netvisionmonitor/internal/models"
+	"netvisionmonitor/internal/monitoring/snmp"
+	mib "netvisionmonitor/internal/snmp"
+)
+
+// applyPortState sets the state read from a switch on a port and returns the
+// changes made to it. Values the switch does not report are kept, and a port
+// seen for the first time has no changes.
+func applyPortState(port *models.SwitchPort, state snmp.PortState, now time.Time) []models.PortStateChange {
+	var changes []models.PortStateChange
+	set := func(field string, current *string, value string, flap bool) {
+		if value == "" || value == "unknown" || *current == value {
+			return
+		}
+		if *current != "" && *current != "unknown" {
+			changes = append(changes, models.PortStateChange{
+				SwitchID:   port.SwitchID,
+				PortNumber: port.PortNumber,
+				Field:      field,
+				OldValue:   *current,
+				NewValue:   value,
+				Flap:       flap,
+				Source:     models.PortChangeMonitoring,
+				ChangedAt:  now,
+			})
+		}
+		*current = value
+	}
+
+	set(models.PortFieldAdmin, &port.AdminStatus, state.Admin, false)
+	// A link lost while the port is enabled is a flap, not an admin action
+	set(models.PortFieldStatus, &port.Status, state.Status, state.Status == "down" && port.AdminStatus != "down")
+	// Speed and duplex of a port without link are meaningless
+	if state.Status == "up" {
+		if state.Speed > 0 {
+			set(models.PortFieldSpeed, &port.Speed, mib.FormatSpeed(int64(state.Speed)), false)
+		}
+		set(models.PortFieldDuplex, &port.Duplex, state.Duplex, false)
+	}
+	set(models.PortFieldPoE, &port.PoEStatus, state.PoE, false)
+	return changes
+}
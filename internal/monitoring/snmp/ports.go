@@ -0,0 +1,131 @@
+package snmp
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// Port state OIDs besides the operational status
+const (
+	OIDIfAdminStatus        = ".1.3.6.1.2.1.2.2.1.7"             // Interface admin status
+	OIDDot3StatsDuplex      = ".1.3.6.1.2.1.10.7.2.1.19"         // EtherLike-MIB duplex status
+	OIDPethPortDetection    = ".1.3.6.1.2.1.105.1.1.1.6.1"       // POWER-ETHERNET-MIB detection status, PSE group 1
+	OIDTFortisPoEPortStatus = ".1.3.6.1.4.1.42019.3.2.2.5.1.1.2" // TFortis PoE status: up(1), down(2)
+)
+
+// PortState is the link, admin, speed, duplex and PoE state of a switch port.
+// Fields the switch does not report are empty.
+type PortState struct {
+	Index  int
+	Status string // "up", "down", "unknown"
+	Admin  string // "up", "down"
+	Speed  uint64 // bits per second
+	Duplex string // "full", "half"
+	PoE    string // "on", "off", "fault"
+}
+
+// GetPortStates reads the state of ports up to maxPorts. Only the operational
+// status is required, the other columns are read if the switch has them.
+func (c *Client) GetPortStates(ctx context.Context, maxPorts int) ([]PortState, error) {
+	snmp, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer snmp.Conn.Close()
+
+	states := make(map[int]*PortState)
+	err = walkPortColumn(ctx, snmp, OIDIfOperStatus, maxPorts, func(port int, value *big.Int) {
+		status := "unknown"
+		switch value.Int64() {
+		case IfStatusUp:
+			status = "up"
+		case IfStatusDown:
+			status = "down"
+		}
+		states[port] = &PortState{Index: port, Status: status}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SNMP walk failed: %w", err)
+	}
+
+	// optional walks a column of ports already found, ignoring errors
+	optional := func(column string, fn func(state *PortState, value *big.Int)) {
+		walkPortColumn(ctx, snmp, column, maxPorts, func(port int, value *big.Int) {
+			if state := states[port]; state != nil {
+				fn(state, value)
+			}
+		})
+	}
+	optional(OIDIfAdminStatus, func(state *PortState, value *big.Int) {
+		switch value.Int64() {
+		case IfStatusUp:
+			state.Admin = "up"
+		case IfStatusDown:
+			state.Admin = "down"
+		}
+	})
+	optional(OIDIfSpeed, func(state *PortState, value *big.Int) {
+		state.Speed = value.Uint64()
+	})
+	optional(OIDDot3StatsDuplex, func(state *PortState, value *big.Int) {
+		switch value.Int64() {
+		case 2:
+			state.Duplex = "half"
+		case 3:
+			state.Duplex = "full"
+		}
+	})
+	optional(OIDPethPortDetection, func(state *PortState, value *big.Int) {
+		switch value.Int64() {
+		case 1, 2: // disabled, searching
+			state.PoE = "off"
+		case 3: // deliveringPower
+			state.PoE = "on"
+		case 4, 6: // fault, otherFault
+			state.PoE = "fault"
+		}
+	})
+	optional(OIDTFortisPoEPortStatus, func(state *PortState, value *big.Int) {
+		if state.PoE != "" {
+			return
+		}
+		switch value.Int64() {
+		case 1:
+			state.PoE = "on"
+		case 2:
+			state.PoE = "off"
+		}
+	})
+
+	result := make([]PortState, 0, len(states))
+	for _, state := range states {
+		result = append(result, *state)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+	return result, nil
+}
+
+// walkPortColumn walks a table column indexed by port number
+func walkPortColumn(ctx context.Context, snmp *gosnmp.GoSNMP, column string, maxPorts int, fn func(port int, value *big.Int)) error {
+	return snmp.Walk(column, func(pdu gosnmp.SnmpPDU) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		var port int
+		if _, err := fmt.Sscanf(pdu.Name, column+".%d", &port); err != nil {
+			return nil
+		}
+		if maxPorts > 0 && port > maxPorts {
+			return nil
+		}
+		fn(port, gosnmp.ToBigInt(pdu.Value))
+		return nil
+	})
+}
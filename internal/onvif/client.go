@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,6 +23,31 @@ type Client struct {
 	Username string
 	Password string
 	Timeout  time.Duration
+
+	media2Once sync.Once
+	media2Path string // Media2 service path, empty if not supported
+
+	ptzMu   sync.Mutex
+	ptzPath string // PTZ service path, empty until resolved
+
+	clockMu     sync.Mutex
+	clockOffset time.Duration // camera clock minus host clock
+}
+
+// StatusError is returned for a response other than 200 OK. Cameras answer
+// SOAP Faults with 400 or 500 and the fault in the body.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ONVIF error: status %d, body: %s", e.StatusCode, e.Body)
+}
+
+// IsFault reports whether the response is a SOAP Fault
+func (e *StatusError) IsFault() bool {
+	return strings.Contains(e.Body, "Fault>")
 }
 
 // DeviceInfo contains camera device information
@@ -35,8 +61,12 @@ type DeviceInfo struct {
 
 // MediaProfile represents an ONVIF media profile
 type MediaProfile struct {
-	Token string `json:"token"`
-	Name  string `json:"name"`
+	Token             string `json:"token"`
+	Name              string `json:"name"`
+	VideoEncoderToken string `json:"video_encoder_token,omitempty"`
+	Encoding          string `json:"encoding,omitempty"` // "H264", "H265", "JPEG", "MPEG4"
+	Width             int    `json:"width,omitempty"`
+	Height            int    `json:"height,omitempty"`
 }
 
 // StreamURI contains stream URL information
@@ -81,6 +111,22 @@ func createPasswordDigest(password, nonce string, created time.Time) string {
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
+// SetClockOffset sets the difference between the camera and the host clock.
+// WS-Security timestamps are shifted by it, since cameras reject digests
+// created too far from their own time.
+func (c *Client) SetClockOffset(offset time.Duration) {
+	c.clockMu.Lock()
+	defer c.clockMu.Unlock()
+	c.clockOffset = offset
+}
+
+// ClockOffset returns the difference between the camera and the host clock
+func (c *Client) ClockOffset() time.Duration {
+	c.clockMu.Lock()
+	defer c.clockMu.Unlock()
+	return c.clockOffset
+}
+
 // createSecurityHeader creates WS-Security SOAP header
 func (c *Client) createSecurityHeader() string {
 	if c.Username == "" {
@@ -92,7 +138,7 @@ func (c *Client) createSecurityHeader() string {
 		nonce[i] = byte(i * 17)
 	}
 	nonceB64 := base64.StdEncoding.EncodeToString(nonce)
-	created := time.Now().UTC()
+	created := time.Now().Add(c.ClockOffset()).UTC()
 	createdStr := created.Format("2006-01-02T15:04:05Z")
 	digest := createPasswordDigest(c.Password, nonceB64, created)
 
@@ -119,6 +165,8 @@ func (c *Client) createSOAPEnvelope(body string) string {
 <s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"
 	xmlns:tds="http://www.onvif.org/ver10/device/wsdl"
 	xmlns:trt="http://www.onvif.org/ver10/media/wsdl"
+	xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"
+	xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"
 	xmlns:tt="http://www.onvif.org/ver10/schema">
 	<s:Header>%s</s:Header>
 	<s:Body>%s</s:Body>
@@ -149,7 +197,7 @@ func (c *Client) doRequest(ctx context.Context, endpoint, body string) ([]byte,
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("ONVIF error: status %d, body: %s", resp.StatusCode, string(data))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(data)}
 	}
 
 	return data, nil
@@ -197,8 +245,16 @@ var mediaServiceEndpoints = []string{
 	"/media",
 }
 
-// GetProfiles retrieves media profiles
+// GetProfiles retrieves media profiles.
+// Media2 is preferred when available, since H.265 profiles are only exposed there.
 func (c *Client) GetProfiles(ctx context.Context) ([]MediaProfile, error) {
+	if c.hasMedia2(ctx) {
+		profiles, err := c.getProfilesMedia2(ctx)
+		if err == nil {
+			return profiles, nil
+		}
+	}
+
 	body := `<trt:GetProfiles/>`
 
 	var data []byte
@@ -218,8 +274,14 @@ func (c *Client) GetProfiles(ctx context.Context) ([]MediaProfile, error) {
 
 	// Parse response
 	type Profile struct {
-		Token string `xml:"token,attr"`
-		Name  string `xml:"Name"`
+		Token        string `xml:"token,attr"`
+		Name         string `xml:"Name"`
+		VideoEncoder struct {
+			Token    string `xml:"token,attr"`
+			Encoding string `xml:"Encoding"`
+			Width    int    `xml:"Resolution>Width"`
+			Height   int    `xml:"Resolution>Height"`
+		} `xml:"VideoEncoderConfiguration"`
 	}
 	type GetProfilesResponse struct {
 		Profiles []Profile `xml:"Body>GetProfilesResponse>Profiles"`
@@ -233,16 +295,28 @@ func (c *Client) GetProfiles(ctx context.Context) ([]MediaProfile, error) {
 	profiles := make([]MediaProfile, len(resp.Profiles))
 	for i, p := range resp.Profiles {
 		profiles[i] = MediaProfile{
-			Token: p.Token,
-			Name:  p.Name,
+			Token:             p.Token,
+			Name:              p.Name,
+			VideoEncoderToken: p.VideoEncoder.Token,
+			Encoding:          p.VideoEncoder.Encoding,
+			Width:             p.VideoEncoder.Width,
+			Height:            p.VideoEncoder.Height,
 		}
 	}
 
 	return profiles, nil
 }
 
-// GetStreamURI retrieves RTSP stream URI for a profile
+// GetStreamURI retrieves RTSP stream URI for a profile.
+// Media2 is tried first, falling back to Media1.
 func (c *Client) GetStreamURI(ctx context.Context, profileToken string) (string, error) {
+	if c.hasMedia2(ctx) {
+		uri, err := c.getStreamURIMedia2(ctx, profileToken)
+		if err == nil && uri != "" {
+			return uri, nil
+		}
+	}
+
 	body := fmt.Sprintf(`
 	<trt:GetStreamUri>
 		<trt:StreamSetup>
@@ -282,8 +356,16 @@ func (c *Client) GetStreamURI(ctx context.Context, profileToken string) (string,
 	return resp.Uri, nil
 }
 
-// GetSnapshotURI retrieves snapshot URI for a profile
+// GetSnapshotURI retrieves snapshot URI for a profile.
+// Media2 is tried first, falling back to Media1.
 func (c *Client) GetSnapshotURI(ctx context.Context, profileToken string) (string, error) {
+	if c.hasMedia2(ctx) {
+		uri, err := c.getSnapshotURIMedia2(ctx, profileToken)
+		if err == nil && uri != "" {
+			return uri, nil
+		}
+	}
+
 	body := fmt.Sprintf(`<trt:GetSnapshotUri>
 		<trt:ProfileToken>%s</trt:ProfileToken>
 	</trt:GetSnapshotUri>`, profileToken)
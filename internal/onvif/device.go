@@ -0,0 +1,341 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// deviceServiceEndpoint is the standard ONVIF device management endpoint
+const deviceServiceEndpoint = "/onvif/device_service"
+
+// Date/time configuration modes
+const (
+	DateTimeTypeNTP    = "NTP"
+	DateTimeTypeManual = "Manual"
+)
+
+// SystemDateTime contains camera clock configuration and current time
+type SystemDateTime struct {
+	DateTimeType    string    `json:"date_time_type"` // "NTP" or "Manual"
+	DaylightSavings bool      `json:"daylight_savings"`
+	TimeZone        string    `json:"time_zone"` // POSIX TZ string
+	UTCTime         time.Time `json:"utc_time"`
+}
+
+// NTPInfo contains NTP configuration
+type NTPInfo struct {
+	FromDHCP        bool     `json:"from_dhcp"`
+	Servers         []string `json:"servers"`
+	ServersFromDHCP []string `json:"servers_from_dhcp,omitempty"`
+}
+
+// NetworkInterface contains network interface configuration
+type NetworkInterface struct {
+	Token         string   `json:"token"`
+	Name          string   `json:"name"`
+	Enabled       bool     `json:"enabled"`
+	HwAddress     string   `json:"hw_address"`
+	MTU           int      `json:"mtu"`
+	IPv4Enabled   bool     `json:"ipv4_enabled"`
+	DHCP          bool     `json:"dhcp"`
+	IPv4Addresses []string `json:"ipv4_addresses"` // CIDR notation
+}
+
+// User contains an ONVIF user account
+type User struct {
+	Username  string `json:"username"`
+	UserLevel string `json:"user_level"` // "Administrator", "Operator", "User", "Anonymous"
+}
+
+// onvifDateTimeXML is used to parse tt:DateTime elements
+type onvifDateTimeXML struct {
+	Year   int `xml:"Date>Year"`
+	Month  int `xml:"Date>Month"`
+	Day    int `xml:"Date>Day"`
+	Hour   int `xml:"Time>Hour"`
+	Minute int `xml:"Time>Minute"`
+	Second int `xml:"Time>Second"`
+}
+
+// GetSystemDateAndTime retrieves camera clock settings and current UTC time.
+// The difference to the host clock is kept for the WS-Security timestamps of
+// later requests.
+func (c *Client) GetSystemDateAndTime(ctx context.Context) (*SystemDateTime, error) {
+	body := `<tds:GetSystemDateAndTime/>`
+
+	data, err := c.doRequest(ctx, deviceServiceEndpoint, body)
+	if err != nil && c.Username != "" {
+		// GetSystemDateAndTime must be accessible without authentication,
+		// and a drifting clock may itself break the digest check
+		noAuthClient := &Client{
+			Address: c.Address,
+			Timeout: c.Timeout,
+		}
+		data, err = noAuthClient.doRequest(ctx, deviceServiceEndpoint, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	type GetSystemDateAndTimeResponse struct {
+		DateTimeType    string            `xml:"Body>GetSystemDateAndTimeResponse>SystemDateAndTime>DateTimeType"`
+		DaylightSavings bool              `xml:"Body>GetSystemDateAndTimeResponse>SystemDateAndTime>DaylightSavings"`
+		TZ              string            `xml:"Body>GetSystemDateAndTimeResponse>SystemDateAndTime>TimeZone>TZ"`
+		UTC             *onvifDateTimeXML `xml:"Body>GetSystemDateAndTimeResponse>SystemDateAndTime>UTCDateTime"`
+	}
+
+	var resp GetSystemDateAndTimeResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse date and time: %w", err)
+	}
+
+	if resp.UTC == nil || resp.UTC.Year == 0 {
+		return nil, fmt.Errorf("camera did not report UTC time")
+	}
+
+	utc := time.Date(resp.UTC.Year, time.Month(resp.UTC.Month), resp.UTC.Day,
+		resp.UTC.Hour, resp.UTC.Minute, resp.UTC.Second, 0, time.UTC)
+	c.SetClockOffset(utc.Sub(time.Now()).Round(time.Second))
+
+	return &SystemDateTime{
+		DateTimeType:    resp.DateTimeType,
+		DaylightSavings: resp.DaylightSavings,
+		TimeZone:        resp.TZ,
+		UTCTime:         utc,
+	}, nil
+}
+
+// SetSystemDateAndTime configures the camera clock. In Manual mode utcTime is
+// written to the camera; in NTP mode it is ignored. An empty timeZone leaves
+// the camera time zone unchanged.
+func (c *Client) SetSystemDateAndTime(ctx context.Context, dateTimeType, timeZone string, daylightSavings bool, utcTime time.Time) error {
+	if dateTimeType != DateTimeTypeNTP && dateTimeType != DateTimeTypeManual {
+		return fmt.Errorf("invalid date/time type: %s", dateTimeType)
+	}
+
+	tz := ""
+	if timeZone != "" {
+		tz = fmt.Sprintf("<tds:TimeZone><tt:TZ>%s</tt:TZ></tds:TimeZone>", escapeXML(timeZone))
+	}
+
+	utc := ""
+	if dateTimeType == DateTimeTypeManual {
+		t := utcTime.UTC()
+		utc = fmt.Sprintf(`<tds:UTCDateTime>
+			<tt:Date><tt:Year>%d</tt:Year><tt:Month>%d</tt:Month><tt:Day>%d</tt:Day></tt:Date>
+			<tt:Time><tt:Hour>%d</tt:Hour><tt:Minute>%d</tt:Minute><tt:Second>%d</tt:Second></tt:Time>
+		</tds:UTCDateTime>`, t.Year(), int(t.Month()), t.Day(), t.Hour(), t.Minute(), t.Second())
+	}
+
+	body := fmt.Sprintf(`<tds:SetSystemDateAndTime>
+		<tds:DateTimeType>%s</tds:DateTimeType>
+		<tds:DaylightSavings>%t</tds:DaylightSavings>
+		%s
+		%s
+	</tds:SetSystemDateAndTime>`, dateTimeType, daylightSavings, tz, utc)
+
+	_, err := c.doRequest(ctx, deviceServiceEndpoint, body)
+	return err
+}
+
+// SystemReboot reboots the camera and returns the device's reboot message
+func (c *Client) SystemReboot(ctx context.Context) (string, error) {
+	body := `<tds:SystemReboot/>`
+
+	data, err := c.doRequest(ctx, deviceServiceEndpoint, body)
+	if err != nil {
+		return "", err
+	}
+
+	// Parse response
+	type SystemRebootResponse struct {
+		Message string `xml:"Body>SystemRebootResponse>Message"`
+	}
+
+	var resp SystemRebootResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse reboot response: %w", err)
+	}
+
+	return resp.Message, nil
+}
+
+// GetNetworkInterfaces retrieves network interface configuration
+func (c *Client) GetNetworkInterfaces(ctx context.Context) ([]NetworkInterface, error) {
+	body := `<tds:GetNetworkInterfaces/>`
+
+	data, err := c.doRequest(ctx, deviceServiceEndpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	type PrefixedAddress struct {
+		Address      string `xml:"Address"`
+		PrefixLength int    `xml:"PrefixLength"`
+	}
+	type Interface struct {
+		Token     string `xml:"token,attr"`
+		Enabled   bool   `xml:"Enabled"`
+		Name      string `xml:"Info>Name"`
+		HwAddress string `xml:"Info>HwAddress"`
+		MTU       int    `xml:"Info>MTU"`
+		IPv4      struct {
+			Enabled  bool              `xml:"Enabled"`
+			DHCP     bool              `xml:"Config>DHCP"`
+			Manual   []PrefixedAddress `xml:"Config>Manual"`
+			FromDHCP *PrefixedAddress  `xml:"Config>FromDHCP"`
+		} `xml:"IPv4"`
+	}
+	type GetNetworkInterfacesResponse struct {
+		Interfaces []Interface `xml:"Body>GetNetworkInterfacesResponse>NetworkInterfaces"`
+	}
+
+	var resp GetNetworkInterfacesResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse network interfaces: %w", err)
+	}
+
+	interfaces := make([]NetworkInterface, len(resp.Interfaces))
+	for i, iface := range resp.Interfaces {
+		ni := NetworkInterface{
+			Token:         iface.Token,
+			Name:          iface.Name,
+			Enabled:       iface.Enabled,
+			HwAddress:     iface.HwAddress,
+			MTU:           iface.MTU,
+			IPv4Enabled:   iface.IPv4.Enabled,
+			DHCP:          iface.IPv4.DHCP,
+			IPv4Addresses: []string{},
+		}
+		for _, addr := range iface.IPv4.Manual {
+			ni.IPv4Addresses = append(ni.IPv4Addresses, fmt.Sprintf("%s/%d", addr.Address, addr.PrefixLength))
+		}
+		if iface.IPv4.FromDHCP != nil && iface.IPv4.FromDHCP.Address != "" {
+			ni.IPv4Addresses = append(ni.IPv4Addresses,
+				fmt.Sprintf("%s/%d", iface.IPv4.FromDHCP.Address, iface.IPv4.FromDHCP.PrefixLength))
+		}
+		interfaces[i] = ni
+	}
+
+	return interfaces, nil
+}
+
+// GetNTP retrieves NTP configuration
+func (c *Client) GetNTP(ctx context.Context) (*NTPInfo, error) {
+	body := `<tds:GetNTP/>`
+
+	data, err := c.doRequest(ctx, deviceServiceEndpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	type NetworkHost struct {
+		Type        string `xml:"Type"`
+		IPv4Address string `xml:"IPv4Address"`
+		IPv6Address string `xml:"IPv6Address"`
+		DNSname     string `xml:"DNSname"`
+	}
+	type GetNTPResponse struct {
+		FromDHCP    bool          `xml:"Body>GetNTPResponse>NTPInformation>FromDHCP"`
+		NTPManual   []NetworkHost `xml:"Body>GetNTPResponse>NTPInformation>NTPManual"`
+		NTPFromDHCP []NetworkHost `xml:"Body>GetNTPResponse>NTPInformation>NTPFromDHCP"`
+	}
+
+	var resp GetNTPResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse NTP info: %w", err)
+	}
+
+	hostString := func(h NetworkHost) string {
+		switch {
+		case h.IPv4Address != "":
+			return h.IPv4Address
+		case h.IPv6Address != "":
+			return h.IPv6Address
+		default:
+			return h.DNSname
+		}
+	}
+
+	info := &NTPInfo{FromDHCP: resp.FromDHCP, Servers: []string{}}
+	for _, h := range resp.NTPManual {
+		if s := hostString(h); s != "" {
+			info.Servers = append(info.Servers, s)
+		}
+	}
+	for _, h := range resp.NTPFromDHCP {
+		if s := hostString(h); s != "" {
+			info.ServersFromDHCP = append(info.ServersFromDHCP, s)
+		}
+	}
+
+	return info, nil
+}
+
+// SetNTP configures NTP servers. Servers may be IPv4/IPv6 addresses or DNS names.
+func (c *Client) SetNTP(ctx context.Context, fromDHCP bool, servers []string) error {
+	var manual strings.Builder
+	if !fromDHCP {
+		for _, server := range servers {
+			server = strings.TrimSpace(server)
+			if server == "" {
+				continue
+			}
+			ip := net.ParseIP(server)
+			switch {
+			case ip != nil && ip.To4() != nil:
+				fmt.Fprintf(&manual, "<tds:NTPManual><tt:Type>IPv4</tt:Type><tt:IPv4Address>%s</tt:IPv4Address></tds:NTPManual>", server)
+			case ip != nil:
+				fmt.Fprintf(&manual, "<tds:NTPManual><tt:Type>IPv6</tt:Type><tt:IPv6Address>%s</tt:IPv6Address></tds:NTPManual>", server)
+			default:
+				fmt.Fprintf(&manual, "<tds:NTPManual><tt:Type>DNS</tt:Type><tt:DNSname>%s</tt:DNSname></tds:NTPManual>", escapeXML(server))
+			}
+		}
+	}
+
+	body := fmt.Sprintf(`<tds:SetNTP>
+		<tds:FromDHCP>%t</tds:FromDHCP>
+		%s
+	</tds:SetNTP>`, fromDHCP, manual.String())
+
+	_, err := c.doRequest(ctx, deviceServiceEndpoint, body)
+	return err
+}
+
+// GetUsers retrieves the list of ONVIF user accounts
+func (c *Client) GetUsers(ctx context.Context) ([]User, error) {
+	body := `<tds:GetUsers/>`
+
+	data, err := c.doRequest(ctx, deviceServiceEndpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	type UserXML struct {
+		Username  string `xml:"Username"`
+		UserLevel string `xml:"UserLevel"`
+	}
+	type GetUsersResponse struct {
+		Users []UserXML `xml:"Body>GetUsersResponse>User"`
+	}
+
+	var resp GetUsersResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse users: %w", err)
+	}
+
+	users := make([]User, len(resp.Users))
+	for i, u := range resp.Users {
+		users[i] = User{Username: u.Username, UserLevel: u.UserLevel}
+	}
+
+	return users, nil
+}
@@ -0,0 +1,489 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// media2Namespace identifies the ONVIF Media2 service in GetServices responses
+const media2Namespace = "http://www.onvif.org/ver20/media/wsdl"
+
+// media2ServiceEndpoints contains common ONVIF Media2 service endpoints,
+// used when the camera does not answer GetServices
+var media2ServiceEndpoints = []string{
+	"/onvif/media2_service",
+	"/onvif/Media2",
+	"/onvif/media2",
+	"/onvif/services/media2",
+}
+
+// VideoEncoderConfiguration contains video encoder settings
+type VideoEncoderConfiguration struct {
+	Token            string  `json:"token"`
+	Name             string  `json:"name"`
+	UseCount         int     `json:"use_count"`
+	Encoding         string  `json:"encoding"` // "H264", "H265", "JPEG", "MPEG4"
+	Width            int     `json:"width"`
+	Height           int     `json:"height"`
+	Quality          float64 `json:"quality"`
+	FrameRate        int     `json:"frame_rate"`
+	BitrateLimit     int     `json:"bitrate_limit"` // kbps
+	EncodingInterval int     `json:"encoding_interval,omitempty"`
+	GovLength        int     `json:"gov_length"`
+	Profile          string  `json:"profile,omitempty"`           // "Main", "High", "Baseline"
+	ConstantBitRate  *bool   `json:"constant_bit_rate,omitempty"` // Media2 only, nil if not reported
+	Media2           bool    `json:"media2"`                      // Read via Media2 service
+
+	// Media1 settings that must be sent back unchanged
+	multicast      multicastXML
+	sessionTimeout string
+}
+
+// multicastXML holds Media1 multicast settings
+type multicastXML struct {
+	Address   string `xml:"Address>IPv4Address"`
+	Port      int    `xml:"Port"`
+	TTL       int    `xml:"TTL"`
+	AutoStart bool   `xml:"AutoStart"`
+}
+
+// HasMedia2 reports whether the camera exposes the Media2 service
+func (c *Client) HasMedia2(ctx context.Context) bool {
+	return c.hasMedia2(ctx)
+}
+
+// hasMedia2 detects the Media2 service once per client
+func (c *Client) hasMedia2(ctx context.Context) bool {
+	c.media2Once.Do(func() {
+		c.media2Path = c.detectMedia2(ctx)
+	})
+	return c.media2Path != ""
+}
+
+// detectMedia2 returns the Media2 service path or empty string
+func (c *Client) detectMedia2(ctx context.Context) string {
+	if paths, err := c.servicePaths(ctx); err == nil {
+		// GetServices answered, with or without Media2
+		return paths[media2Namespace]
+	}
+
+	// GetServices not supported, probe known endpoints
+	for _, endpoint := range media2ServiceEndpoints {
+		if _, err := c.doRequest(ctx, endpoint, `<tr2:GetProfiles/>`); err == nil {
+			return endpoint
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return ""
+}
+
+// servicePaths returns the service paths a camera reports in GetServices, keyed
+// by namespace
+func (c *Client) servicePaths(ctx context.Context) (map[string]string, error) {
+	body := `<tds:GetServices>
+		<tds:IncludeCapability>false</tds:IncludeCapability>
+	</tds:GetServices>`
+
+	data, err := c.doRequest(ctx, deviceServiceEndpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	type Service struct {
+		Namespace string `xml:"Namespace"`
+		XAddr     string `xml:"XAddr"`
+	}
+	type GetServicesResponse struct {
+		Services []Service `xml:"Body>GetServicesResponse>Service"`
+	}
+
+	var resp GetServicesResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse services: %w", err)
+	}
+
+	paths := make(map[string]string, len(resp.Services))
+	for _, s := range resp.Services {
+		if path := xaddrPath(s.XAddr); path != "" {
+			paths[strings.TrimSpace(s.Namespace)] = path
+		}
+	}
+	return paths, nil
+}
+
+// xaddrPath returns the path of a service address. Only the path is used: the
+// address may be unreachable from here.
+func xaddrPath(xaddr string) string {
+	parsed, err := url.Parse(strings.TrimSpace(xaddr))
+	if err != nil {
+		return ""
+	}
+	return parsed.Path
+}
+
+// doMediaRequest tries all known Media1 service endpoints
+func (c *Client) doMediaRequest(ctx context.Context, body string) ([]byte, error) {
+	var data []byte
+	var err error
+
+	for _, endpoint := range mediaServiceEndpoints {
+		data, err = c.doRequest(ctx, endpoint, body)
+		if err == nil {
+			return data, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, err
+}
+
+// doMedia2Request sends a request to the detected Media2 service
+func (c *Client) doMedia2Request(ctx context.Context, body string) ([]byte, error) {
+	if !c.hasMedia2(ctx) {
+		return nil, fmt.Errorf("Media2 service not supported")
+	}
+	return c.doRequest(ctx, c.media2Path, body)
+}
+
+// getProfilesMedia2 retrieves media profiles via Media2
+func (c *Client) getProfilesMedia2(ctx context.Context) ([]MediaProfile, error) {
+	body := `<tr2:GetProfiles>
+		<tr2:Type>VideoEncoder</tr2:Type>
+	</tr2:GetProfiles>`
+
+	data, err := c.doMedia2Request(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	type Profile struct {
+		Token        string `xml:"token,attr"`
+		Name         string `xml:"Name"`
+		VideoEncoder struct {
+			Token    string `xml:"token,attr"`
+			Encoding string `xml:"Encoding"`
+			Width    int    `xml:"Resolution>Width"`
+			Height   int    `xml:"Resolution>Height"`
+		} `xml:"Configurations>VideoEncoder"`
+	}
+	type GetProfilesResponse struct {
+		Profiles []Profile `xml:"Body>GetProfilesResponse>Profiles"`
+	}
+
+	var resp GetProfilesResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse Media2 profiles: %w", err)
+	}
+
+	profiles := make([]MediaProfile, len(resp.Profiles))
+	for i, p := range resp.Profiles {
+		profiles[i] = MediaProfile{
+			Token:             p.Token,
+			Name:              p.Name,
+			VideoEncoderToken: p.VideoEncoder.Token,
+			Encoding:          p.VideoEncoder.Encoding,
+			Width:             p.VideoEncoder.Width,
+			Height:            p.VideoEncoder.Height,
+		}
+	}
+
+	return profiles, nil
+}
+
+// getStreamURIMedia2 retrieves RTSP stream URI via Media2
+func (c *Client) getStreamURIMedia2(ctx context.Context, profileToken string) (string, error) {
+	body := fmt.Sprintf(`<tr2:GetStreamUri>
+		<tr2:Protocol>RTSP</tr2:Protocol>
+		<tr2:ProfileToken>%s</tr2:ProfileToken>
+	</tr2:GetStreamUri>`, escapeXML(profileToken))
+
+	data, err := c.doMedia2Request(ctx, body)
+	if err != nil {
+		return "", err
+	}
+
+	// Parse response
+	type GetStreamUriResponse struct {
+		Uri string `xml:"Body>GetStreamUriResponse>Uri"`
+	}
+
+	var resp GetStreamUriResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse stream URI: %w", err)
+	}
+
+	return strings.TrimSpace(resp.Uri), nil
+}
+
+// getSnapshotURIMedia2 retrieves snapshot URI via Media2
+func (c *Client) getSnapshotURIMedia2(ctx context.Context, profileToken string) (string, error) {
+	body := fmt.Sprintf(`<tr2:GetSnapshotUri>
+		<tr2:ProfileToken>%s</tr2:ProfileToken>
+	</tr2:GetSnapshotUri>`, escapeXML(profileToken))
+
+	data, err := c.doMedia2Request(ctx, body)
+	if err != nil {
+		return "", err
+	}
+
+	// Parse response
+	type GetSnapshotUriResponse struct {
+		Uri string `xml:"Body>GetSnapshotUriResponse>Uri"`
+	}
+
+	var resp GetSnapshotUriResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse snapshot URI: %w", err)
+	}
+
+	return strings.TrimSpace(resp.Uri), nil
+}
+
+// GetVideoEncoderConfigurations retrieves all video encoder configurations.
+// Media2 is preferred when available, falling back to Media1.
+func (c *Client) GetVideoEncoderConfigurations(ctx context.Context) ([]VideoEncoderConfiguration, error) {
+	if c.hasMedia2(ctx) {
+		configs, err := c.getVideoEncoderConfigurationsMedia2(ctx)
+		if err == nil {
+			return configs, nil
+		}
+	}
+
+	data, err := c.doMediaRequest(ctx, `<trt:GetVideoEncoderConfigurations/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	type Configuration struct {
+		Token            string       `xml:"token,attr"`
+		Name             string       `xml:"Name"`
+		UseCount         int          `xml:"UseCount"`
+		Encoding         string       `xml:"Encoding"`
+		Width            int          `xml:"Resolution>Width"`
+		Height           int          `xml:"Resolution>Height"`
+		Quality          float64      `xml:"Quality"`
+		FrameRate        int          `xml:"RateControl>FrameRateLimit"`
+		EncodingInterval int          `xml:"RateControl>EncodingInterval"`
+		BitrateLimit     int          `xml:"RateControl>BitrateLimit"`
+		H264GovLength    int          `xml:"H264>GovLength"`
+		H264Profile      string       `xml:"H264>H264Profile"`
+		MPEG4GovLength   int          `xml:"MPEG4>GovLength"`
+		MPEG4Profile     string       `xml:"MPEG4>Mpeg4Profile"`
+		Multicast        multicastXML `xml:"Multicast"`
+		SessionTimeout   string       `xml:"SessionTimeout"`
+	}
+	type GetVideoEncoderConfigurationsResponse struct {
+		Configurations []Configuration `xml:"Body>GetVideoEncoderConfigurationsResponse>Configurations"`
+	}
+
+	var resp GetVideoEncoderConfigurationsResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse encoder configurations: %w", err)
+	}
+
+	configs := make([]VideoEncoderConfiguration, len(resp.Configurations))
+	for i, cfg := range resp.Configurations {
+		configs[i] = VideoEncoderConfiguration{
+			Token:            cfg.Token,
+			Name:             cfg.Name,
+			UseCount:         cfg.UseCount,
+			Encoding:         cfg.Encoding,
+			Width:            cfg.Width,
+			Height:           cfg.Height,
+			Quality:          cfg.Quality,
+			FrameRate:        cfg.FrameRate,
+			BitrateLimit:     cfg.BitrateLimit,
+			EncodingInterval: cfg.EncodingInterval,
+			GovLength:        cfg.H264GovLength,
+			Profile:          cfg.H264Profile,
+			multicast:        cfg.Multicast,
+			sessionTimeout:   cfg.SessionTimeout,
+		}
+		if cfg.Encoding == "MPEG4" {
+			configs[i].GovLength = cfg.MPEG4GovLength
+			configs[i].Profile = cfg.MPEG4Profile
+		}
+	}
+
+	return configs, nil
+}
+
+// getVideoEncoderConfigurationsMedia2 retrieves encoder configurations via Media2
+func (c *Client) getVideoEncoderConfigurationsMedia2(ctx context.Context) ([]VideoEncoderConfiguration, error) {
+	data, err := c.doMedia2Request(ctx, `<tr2:GetVideoEncoderConfigurations/>`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	type Configuration struct {
+		Token       string `xml:"token,attr"`
+		GovLength   int    `xml:"GovLength,attr"`
+		Profile     string `xml:"Profile,attr"`
+		Name        string `xml:"Name"`
+		UseCount    int    `xml:"UseCount"`
+		Encoding    string `xml:"Encoding"`
+		Width       int    `xml:"Resolution>Width"`
+		Height      int    `xml:"Resolution>Height"`
+		RateControl struct {
+			ConstantBitRate *bool   `xml:"ConstantBitRate,attr"`
+			FrameRateLimit  float64 `xml:"FrameRateLimit"`
+			BitrateLimit    int     `xml:"BitrateLimit"`
+		} `xml:"RateControl"`
+		Quality float64 `xml:"Quality"`
+	}
+	type GetVideoEncoderConfigurationsResponse struct {
+		Configurations []Configuration `xml:"Body>GetVideoEncoderConfigurationsResponse>Configurations"`
+	}
+
+	var resp GetVideoEncoderConfigurationsResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse encoder configurations: %w", err)
+	}
+
+	configs := make([]VideoEncoderConfiguration, len(resp.Configurations))
+	for i, cfg := range resp.Configurations {
+		configs[i] = VideoEncoderConfiguration{
+			Token:           cfg.Token,
+			Name:            cfg.Name,
+			UseCount:        cfg.UseCount,
+			Encoding:        cfg.Encoding,
+			Width:           cfg.Width,
+			Height:          cfg.Height,
+			Quality:         cfg.Quality,
+			FrameRate:       int(cfg.RateControl.FrameRateLimit + 0.5),
+			BitrateLimit:    cfg.RateControl.BitrateLimit,
+			GovLength:       cfg.GovLength,
+			Profile:         cfg.Profile,
+			ConstantBitRate: cfg.RateControl.ConstantBitRate,
+			Media2:          true,
+		}
+	}
+
+	return configs, nil
+}
+
+// SetVideoEncoderConfiguration writes a video encoder configuration.
+// Configurations read via Media2 are written via Media2; H.265 requires Media2.
+func (c *Client) SetVideoEncoderConfiguration(ctx context.Context, cfg VideoEncoderConfiguration) error {
+	if cfg.Token == "" {
+		return fmt.Errorf("encoder configuration token is required")
+	}
+
+	if cfg.Media2 || (cfg.Encoding == "H265" && c.hasMedia2(ctx)) {
+		return c.setVideoEncoderConfigurationMedia2(ctx, cfg)
+	}
+	if cfg.Encoding == "H265" {
+		return fmt.Errorf("H265 requires Media2 service support")
+	}
+
+	codec := ""
+	switch cfg.Encoding {
+	case "H264":
+		profile := cfg.Profile
+		if profile == "" {
+			profile = "Main"
+		}
+		codec = fmt.Sprintf("<tt:H264><tt:GovLength>%d</tt:GovLength><tt:H264Profile>%s</tt:H264Profile></tt:H264>",
+			cfg.GovLength, escapeXML(profile))
+	case "MPEG4":
+		profile := cfg.Profile
+		if profile == "" {
+			profile = "SP"
+		}
+		codec = fmt.Sprintf("<tt:MPEG4><tt:GovLength>%d</tt:GovLength><tt:Mpeg4Profile>%s</tt:Mpeg4Profile></tt:MPEG4>",
+			cfg.GovLength, escapeXML(profile))
+	}
+
+	multicastAddr := cfg.multicast.Address
+	if multicastAddr == "" {
+		multicastAddr = "0.0.0.0"
+	}
+	sessionTimeout := cfg.sessionTimeout
+	if sessionTimeout == "" {
+		sessionTimeout = "PT60S"
+	}
+	encodingInterval := cfg.EncodingInterval
+	if encodingInterval <= 0 {
+		encodingInterval = 1
+	}
+
+	body := fmt.Sprintf(`<trt:SetVideoEncoderConfiguration>
+		<trt:Configuration token="%s">
+			<tt:Name>%s</tt:Name>
+			<tt:UseCount>%d</tt:UseCount>
+			<tt:Encoding>%s</tt:Encoding>
+			<tt:Resolution><tt:Width>%d</tt:Width><tt:Height>%d</tt:Height></tt:Resolution>
+			<tt:Quality>%s</tt:Quality>
+			<tt:RateControl>
+				<tt:FrameRateLimit>%d</tt:FrameRateLimit>
+				<tt:EncodingInterval>%d</tt:EncodingInterval>
+				<tt:BitrateLimit>%d</tt:BitrateLimit>
+			</tt:RateControl>
+			%s
+			<tt:Multicast>
+				<tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>%s</tt:IPv4Address></tt:Address>
+				<tt:Port>%d</tt:Port>
+				<tt:TTL>%d</tt:TTL>
+				<tt:AutoStart>%t</tt:AutoStart>
+			</tt:Multicast>
+			<tt:SessionTimeout>%s</tt:SessionTimeout>
+		</trt:Configuration>
+		<trt:ForcePersistence>true</trt:ForcePersistence>
+	</trt:SetVideoEncoderConfiguration>`,
+		escapeXML(cfg.Token), escapeXML(cfg.Name), cfg.UseCount, escapeXML(cfg.Encoding),
+		cfg.Width, cfg.Height, formatFloat(cfg.Quality),
+		cfg.FrameRate, encodingInterval, cfg.BitrateLimit,
+		codec,
+		escapeXML(multicastAddr), cfg.multicast.Port, cfg.multicast.TTL, cfg.multicast.AutoStart,
+		escapeXML(sessionTimeout))
+
+	_, err := c.doMediaRequest(ctx, body)
+	return err
+}
+
+// setVideoEncoderConfigurationMedia2 writes an encoder configuration via Media2
+func (c *Client) setVideoEncoderConfigurationMedia2(ctx context.Context, cfg VideoEncoderConfiguration) error {
+	attrs := ""
+	if cfg.GovLength > 0 {
+		attrs += fmt.Sprintf(` GovLength="%d"`, cfg.GovLength)
+	}
+	if cfg.Profile != "" {
+		attrs += fmt.Sprintf(` Profile="%s"`, escapeXML(cfg.Profile))
+	}
+
+	rateAttrs := ""
+	if cfg.ConstantBitRate != nil {
+		rateAttrs = fmt.Sprintf(` ConstantBitRate="%t"`, *cfg.ConstantBitRate)
+	}
+
+	body := fmt.Sprintf(`<tr2:SetVideoEncoderConfiguration>
+		<tr2:Configuration token="%s"%s>
+			<tt:Name>%s</tt:Name>
+			<tt:UseCount>%d</tt:UseCount>
+			<tt:Encoding>%s</tt:Encoding>
+			<tt:Resolution><tt:Width>%d</tt:Width><tt:Height>%d</tt:Height></tt:Resolution>
+			<tt:RateControl%s>
+				<tt:FrameRateLimit>%d</tt:FrameRateLimit>
+				<tt:BitrateLimit>%d</tt:BitrateLimit>
+			</tt:RateControl>
+			<tt:Quality>%s</tt:Quality>
+		</tr2:Configuration>
+	</tr2:SetVideoEncoderConfiguration>`,
+		escapeXML(cfg.Token), attrs, escapeXML(cfg.Name), cfg.UseCount, escapeXML(cfg.Encoding),
+		cfg.Width, cfg.Height, rateAttrs, cfg.FrameRate, cfg.BitrateLimit,
+		formatFloat(cfg.Quality))
+
+	_, err := c.doMedia2Request(ctx, body)
+	return err
+}
@@ -0,0 +1,364 @@
+package onvif
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ptzServiceEndpoints contains common ONVIF PTZ service endpoints
+var ptzServiceEndpoints = []string{
+	"/onvif/ptz_service",
+	"/onvif/PTZ",
+	"/onvif/ptz",
+	"/onvif/services/ptz",
+	"/onvif/device_service",
+	"/PTZ",
+	"/ptz",
+}
+
+// PTZVector represents pan/tilt/zoom coordinates or speeds
+type PTZVector struct {
+	Pan  float64 `json:"pan"`
+	Tilt float64 `json:"tilt"`
+	Zoom float64 `json:"zoom"`
+}
+
+// PTZPreset represents a stored PTZ preset position
+type PTZPreset struct {
+	Token    string     `json:"token"`
+	Name     string     `json:"name"`
+	Position *PTZVector `json:"position,omitempty"`
+}
+
+// PTZStatus contains the current PTZ position and movement state
+type PTZStatus struct {
+	Position     PTZVector `json:"position"`
+	PanTiltState string    `json:"pan_tilt_state"` // "IDLE", "MOVING", "UNKNOWN"
+	ZoomState    string    `json:"zoom_state"`
+	Error        string    `json:"error,omitempty"`
+	UTCTime      string    `json:"utc_time,omitempty"`
+}
+
+// ptzVectorXML is used to parse PanTilt/Zoom elements in PTZ responses
+type ptzVectorXML struct {
+	PanTilt struct {
+		X string `xml:"x,attr"`
+		Y string `xml:"y,attr"`
+	} `xml:"PanTilt"`
+	Zoom struct {
+		X string `xml:"x,attr"`
+	} `xml:"Zoom"`
+}
+
+func (v ptzVectorXML) toVector() PTZVector {
+	pan, _ := strconv.ParseFloat(v.PanTilt.X, 64)
+	tilt, _ := strconv.ParseFloat(v.PanTilt.Y, 64)
+	zoom, _ := strconv.ParseFloat(v.Zoom.X, 64)
+	return PTZVector{Pan: pan, Tilt: tilt, Zoom: zoom}
+}
+
+// ptzNamespace identifies the ONVIF PTZ service in GetServices responses
+const ptzNamespace = "http://www.onvif.org/ver20/ptz/wsdl"
+
+// SetPTZPath sets a PTZ service path resolved earlier for the camera
+func (c *Client) SetPTZPath(path string) {
+	c.ptzMu.Lock()
+	c.ptzPath = path
+	c.ptzMu.Unlock()
+}
+
+// ResolvePTZPath returns the PTZ service path the camera advertises in
+// GetServices or GetCapabilities, empty if it advertises none. It is cached
+// once found.
+func (c *Client) ResolvePTZPath(ctx context.Context) string {
+	c.ptzMu.Lock()
+	defer c.ptzMu.Unlock()
+	if c.ptzPath != "" {
+		return c.ptzPath
+	}
+
+	if paths, err := c.servicePaths(ctx); err == nil {
+		c.ptzPath = paths[ptzNamespace]
+	}
+	if c.ptzPath == "" {
+		body := `<tds:GetCapabilities>
+		<tds:Category>PTZ</tds:Category>
+	</tds:GetCapabilities>`
+		if data, err := c.doRequest(ctx, deviceServiceEndpoint, body); err == nil {
+			var resp struct {
+				XAddr string `xml:"Body>GetCapabilitiesResponse>Capabilities>PTZ>XAddr"`
+			}
+			if xml.Unmarshal(data, &resp) == nil {
+				c.ptzPath = xaddrPath(resp.XAddr)
+			}
+		}
+	}
+	return c.ptzPath
+}
+
+// doPTZRequest sends a request to the PTZ service. Without an advertised
+// service address the known endpoints are tried, moving on only while the
+// endpoint is unreachable or missing: a SOAP Fault is the camera's answer and a
+// move must not be sent twice.
+func (c *Client) doPTZRequest(ctx context.Context, body string) ([]byte, error) {
+	if path := c.ResolvePTZPath(ctx); path != "" {
+		data, err := c.doRequest(ctx, path, body)
+		if err != nil {
+			return nil, fmt.Errorf("PTZ request failed: %w", err)
+		}
+		return data, nil
+	}
+
+	var err error
+	for _, endpoint := range ptzServiceEndpoints {
+		var data []byte
+		data, err = c.doRequest(ctx, endpoint, body)
+		if err == nil {
+			c.SetPTZPath(endpoint)
+			return data, nil
+		}
+		if ctx.Err() != nil || !endpointMissing(err) {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("PTZ request failed: %w", err)
+}
+
+// endpointMissing reports whether a request failed because the endpoint could
+// not be reached or does not exist, rather than being answered by the camera
+func endpointMissing(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	return statusErr.StatusCode == http.StatusNotFound && !statusErr.IsFault()
+}
+
+// ContinuousMove starts moving the camera with the given velocity (-1.0 .. 1.0).
+// A timeout of zero leaves the movement running until Stop is called.
+func (c *Client) ContinuousMove(ctx context.Context, profileToken string, velocity PTZVector, timeoutSec float64) error {
+	timeout := ""
+	if timeoutSec > 0 {
+		timeout = fmt.Sprintf("<tptz:Timeout>PT%sS</tptz:Timeout>", formatFloat(timeoutSec))
+	}
+
+	body := fmt.Sprintf(`<tptz:ContinuousMove>
+		<tptz:ProfileToken>%s</tptz:ProfileToken>
+		<tptz:Velocity>%s</tptz:Velocity>
+		%s
+	</tptz:ContinuousMove>`, escapeXML(profileToken), vectorXML(velocity), timeout)
+
+	_, err := c.doPTZRequest(ctx, body)
+	return err
+}
+
+// RelativeMove moves the camera by the given translation relative to its current position.
+// Speed is optional.
+func (c *Client) RelativeMove(ctx context.Context, profileToken string, translation PTZVector, speed *PTZVector) error {
+	body := fmt.Sprintf(`<tptz:RelativeMove>
+		<tptz:ProfileToken>%s</tptz:ProfileToken>
+		<tptz:Translation>%s</tptz:Translation>
+		%s
+	</tptz:RelativeMove>`, escapeXML(profileToken), vectorXML(translation), speedXML(speed))
+
+	_, err := c.doPTZRequest(ctx, body)
+	return err
+}
+
+// AbsoluteMove moves the camera to an absolute position. Speed is optional.
+func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position PTZVector, speed *PTZVector) error {
+	body := fmt.Sprintf(`<tptz:AbsoluteMove>
+		<tptz:ProfileToken>%s</tptz:ProfileToken>
+		<tptz:Position>%s</tptz:Position>
+		%s
+	</tptz:AbsoluteMove>`, escapeXML(profileToken), vectorXML(position), speedXML(speed))
+
+	_, err := c.doPTZRequest(ctx, body)
+	return err
+}
+
+// Stop stops any ongoing pan/tilt and/or zoom movement
+func (c *Client) Stop(ctx context.Context, profileToken string, panTilt, zoom bool) error {
+	body := fmt.Sprintf(`<tptz:Stop>
+		<tptz:ProfileToken>%s</tptz:ProfileToken>
+		<tptz:PanTilt>%t</tptz:PanTilt>
+		<tptz:Zoom>%t</tptz:Zoom>
+	</tptz:Stop>`, escapeXML(profileToken), panTilt, zoom)
+
+	_, err := c.doPTZRequest(ctx, body)
+	return err
+}
+
+// GetPresets retrieves all PTZ presets for a profile
+func (c *Client) GetPresets(ctx context.Context, profileToken string) ([]PTZPreset, error) {
+	body := fmt.Sprintf(`<tptz:GetPresets>
+		<tptz:ProfileToken>%s</tptz:ProfileToken>
+	</tptz:GetPresets>`, escapeXML(profileToken))
+
+	data, err := c.doPTZRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	type Preset struct {
+		Token    string        `xml:"token,attr"`
+		Name     string        `xml:"Name"`
+		Position *ptzVectorXML `xml:"PTZPosition"`
+	}
+	type GetPresetsResponse struct {
+		Presets []Preset `xml:"Body>GetPresetsResponse>Preset"`
+	}
+
+	var resp GetPresetsResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse presets: %w", err)
+	}
+
+	presets := make([]PTZPreset, len(resp.Presets))
+	for i, p := range resp.Presets {
+		presets[i] = PTZPreset{
+			Token: p.Token,
+			Name:  p.Name,
+		}
+		if p.Position != nil {
+			pos := p.Position.toVector()
+			presets[i].Position = &pos
+		}
+	}
+
+	return presets, nil
+}
+
+// GotoPreset moves the camera to a stored preset. Speed is optional.
+func (c *Client) GotoPreset(ctx context.Context, profileToken, presetToken string, speed *PTZVector) error {
+	body := fmt.Sprintf(`<tptz:GotoPreset>
+		<tptz:ProfileToken>%s</tptz:ProfileToken>
+		<tptz:PresetToken>%s</tptz:PresetToken>
+		%s
+	</tptz:GotoPreset>`, escapeXML(profileToken), escapeXML(presetToken), speedXML(speed))
+
+	_, err := c.doPTZRequest(ctx, body)
+	return err
+}
+
+// SetPreset stores the current position as a preset and returns its token.
+// If presetToken is set, the existing preset is overwritten.
+func (c *Client) SetPreset(ctx context.Context, profileToken, presetName, presetToken string) (string, error) {
+	name := ""
+	if presetName != "" {
+		name = fmt.Sprintf("<tptz:PresetName>%s</tptz:PresetName>", escapeXML(presetName))
+	}
+	token := ""
+	if presetToken != "" {
+		token = fmt.Sprintf("<tptz:PresetToken>%s</tptz:PresetToken>", escapeXML(presetToken))
+	}
+
+	body := fmt.Sprintf(`<tptz:SetPreset>
+		<tptz:ProfileToken>%s</tptz:ProfileToken>
+		%s
+		%s
+	</tptz:SetPreset>`, escapeXML(profileToken), name, token)
+
+	data, err := c.doPTZRequest(ctx, body)
+	if err != nil {
+		return "", err
+	}
+
+	// Parse response
+	type SetPresetResponse struct {
+		PresetToken string `xml:"Body>SetPresetResponse>PresetToken"`
+	}
+
+	var resp SetPresetResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse preset token: %w", err)
+	}
+
+	return resp.PresetToken, nil
+}
+
+// RemovePreset deletes a stored preset
+func (c *Client) RemovePreset(ctx context.Context, profileToken, presetToken string) error {
+	body := fmt.Sprintf(`<tptz:RemovePreset>
+		<tptz:ProfileToken>%s</tptz:ProfileToken>
+		<tptz:PresetToken>%s</tptz:PresetToken>
+	</tptz:RemovePreset>`, escapeXML(profileToken), escapeXML(presetToken))
+
+	_, err := c.doPTZRequest(ctx, body)
+	return err
+}
+
+// GetStatus retrieves the current PTZ position and movement state
+func (c *Client) GetStatus(ctx context.Context, profileToken string) (*PTZStatus, error) {
+	body := fmt.Sprintf(`<tptz:GetStatus>
+		<tptz:ProfileToken>%s</tptz:ProfileToken>
+	</tptz:GetStatus>`, escapeXML(profileToken))
+
+	data, err := c.doPTZRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	type GetStatusResponse struct {
+		Position  ptzVectorXML `xml:"Body>GetStatusResponse>PTZStatus>Position"`
+		MovePT    string       `xml:"Body>GetStatusResponse>PTZStatus>MoveStatus>PanTilt"`
+		MoveZoom  string       `xml:"Body>GetStatusResponse>PTZStatus>MoveStatus>Zoom"`
+		StatusErr string       `xml:"Body>GetStatusResponse>PTZStatus>Error"`
+		UTCTime   string       `xml:"Body>GetStatusResponse>PTZStatus>UtcTime"`
+	}
+
+	var resp GetStatusResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse PTZ status: %w", err)
+	}
+
+	status := &PTZStatus{
+		Position:     resp.Position.toVector(),
+		PanTiltState: strings.TrimSpace(resp.MovePT),
+		ZoomState:    strings.TrimSpace(resp.MoveZoom),
+		Error:        strings.TrimSpace(resp.StatusErr),
+		UTCTime:      strings.TrimSpace(resp.UTCTime),
+	}
+	if status.PanTiltState == "" {
+		status.PanTiltState = "UNKNOWN"
+	}
+	if status.ZoomState == "" {
+		status.ZoomState = "UNKNOWN"
+	}
+
+	return status, nil
+}
+
+// vectorXML renders a PTZ vector as PanTilt and Zoom elements
+func vectorXML(v PTZVector) string {
+	return fmt.Sprintf(`<tt:PanTilt x="%s" y="%s"/><tt:Zoom x="%s"/>`,
+		formatFloat(v.Pan), formatFloat(v.Tilt), formatFloat(v.Zoom))
+}
+
+// speedXML renders an optional Speed element
+func speedXML(speed *PTZVector) string {
+	if speed == nil {
+		return ""
+	}
+	return fmt.Sprintf("<tptz:Speed>%s</tptz:Speed>", vectorXML(*speed))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// escapeXML escapes a string for use in SOAP element text
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
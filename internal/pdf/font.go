@@ -0,0 +1,254 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// trueTypeFont holds metrics of a TrueType font needed to embed it
+type trueTypeFont struct {
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	bbox       [4]int
+	advances   []int // Advance width per glyph in font units
+	glyphs     map[rune]uint16
+}
+
+// systemFontPaths lists fonts with Cyrillic glyphs that are usually installed
+func systemFontPaths() []string {
+	if runtime.GOOS == "windows" {
+		dir := filepath.Join(os.Getenv("WINDIR"), "Fonts")
+		if os.Getenv("WINDIR") == "" {
+			dir = `C:\Windows\Fonts`
+		}
+		return []string{
+			filepath.Join(dir, "arial.ttf"),
+			filepath.Join(dir, "tahoma.ttf"),
+			filepath.Join(dir, "segoeui.ttf"),
+			filepath.Join(dir, "verdana.ttf"),
+		}
+	}
+	return []string{
+		"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+		"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+		"/usr/share/fonts/TTF/DejaVuSans.ttf",
+		"/usr/share/fonts/truetype/liberation/LiberationSans-Regular.ttf",
+		"/Library/Fonts/Arial.ttf",
+		"/System/Library/Fonts/Supplemental/Arial.ttf",
+	}
+}
+
+// parseTrueType reads the tables needed for embedding from a TrueType font file
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("font file too short")
+	}
+	version := binary.BigEndian.Uint32(data)
+	if version != 0x00010000 && version != 0x74727565 { // 1.0 or 'true'
+		return nil, fmt.Errorf("unsupported font format (only TrueType outlines are supported)")
+	}
+
+	tables := make(map[string][]byte)
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := 12 + i*16
+		if rec+16 > len(data) {
+			return nil, fmt.Errorf("font table directory is truncated")
+		}
+		tag := string(data[rec : rec+4])
+		offset := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("font table %q is out of bounds", tag)
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap", "glyf"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("font table %q not found", tag)
+		}
+	}
+
+	f := &trueTypeFont{data: data}
+
+	head := tables["head"]
+	if len(head) < 54 {
+		return nil, fmt.Errorf("font head table is truncated")
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, fmt.Errorf("invalid unitsPerEm")
+	}
+	for i := 0; i < 4; i++ {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+i*2:])))
+	}
+
+	hhea := tables["hhea"]
+	if len(hhea) < 36 {
+		return nil, fmt.Errorf("font hhea table is truncated")
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	maxp := tables["maxp"]
+	if len(maxp) < 6 {
+		return nil, fmt.Errorf("font maxp table is truncated")
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+
+	hmtx := tables["hmtx"]
+	if numHMetrics == 0 || len(hmtx) < numHMetrics*4 {
+		return nil, fmt.Errorf("font hmtx table is truncated")
+	}
+	f.advances = make([]int, numGlyphs)
+	for i := 0; i < numGlyphs; i++ {
+		if i < numHMetrics {
+			f.advances[i] = int(binary.BigEndian.Uint16(hmtx[i*4:]))
+		} else {
+			f.advances[i] = f.advances[numHMetrics-1]
+		}
+	}
+
+	glyphs, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.glyphs = glyphs
+
+	return f, nil
+}
+
+// parseCmap reads the Unicode character to glyph mapping
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("font cmap table is truncated")
+	}
+
+	// Prefer full Unicode (format 12), then BMP (format 4)
+	var format4, format12 []byte
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numTables; i++ {
+		rec := 4 + i*8
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if offset+4 > len(cmap) {
+			continue
+		}
+		if platform != 0 && !(platform == 3 && (encoding == 1 || encoding == 10)) {
+			continue
+		}
+
+		sub := cmap[offset:]
+		switch binary.BigEndian.Uint16(sub) {
+		case 4:
+			format4 = sub
+		case 12:
+			format12 = sub
+		}
+	}
+
+	switch {
+	case format12 != nil:
+		return parseCmapFormat12(format12)
+	case format4 != nil:
+		return parseCmapFormat4(format4)
+	}
+	return nil, fmt.Errorf("font has no Unicode cmap")
+}
+
+func parseCmapFormat4(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 14 {
+		return nil, fmt.Errorf("cmap format 4 is truncated")
+	}
+	segCount := int(binary.BigEndian.Uint16(sub[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	idDeltas := startCodes + segCount*2
+	idRangeOffsets := idDeltas + segCount*2
+	if idRangeOffsets+segCount*2 > len(sub) {
+		return nil, fmt.Errorf("cmap format 4 is truncated")
+	}
+
+	glyphs := make(map[rune]uint16)
+	for s := 0; s < segCount; s++ {
+		end := int(binary.BigEndian.Uint16(sub[endCodes+s*2:]))
+		start := int(binary.BigEndian.Uint16(sub[startCodes+s*2:]))
+		delta := binary.BigEndian.Uint16(sub[idDeltas+s*2:])
+		rangeOffsetPos := idRangeOffsets + s*2
+		rangeOffset := int(binary.BigEndian.Uint16(sub[rangeOffsetPos:]))
+
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			var gid uint16
+			if rangeOffset == 0 {
+				gid = uint16(c) + delta
+			} else {
+				pos := rangeOffsetPos + rangeOffset + (c-start)*2
+				if pos+2 > len(sub) {
+					continue
+				}
+				gid = binary.BigEndian.Uint16(sub[pos:])
+				if gid != 0 {
+					gid += delta
+				}
+			}
+			if gid != 0 {
+				glyphs[rune(c)] = gid
+			}
+		}
+	}
+	return glyphs, nil
+}
+
+func parseCmapFormat12(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 16 {
+		return nil, fmt.Errorf("cmap format 12 is truncated")
+	}
+	numGroups := int(binary.BigEndian.Uint32(sub[12:]))
+	if 16+numGroups*12 > len(sub) {
+		return nil, fmt.Errorf("cmap format 12 is truncated")
+	}
+
+	glyphs := make(map[rune]uint16)
+	for g := 0; g < numGroups; g++ {
+		rec := 16 + g*12
+		start := binary.BigEndian.Uint32(sub[rec:])
+		end := binary.BigEndian.Uint32(sub[rec+4:])
+		startGlyph := binary.BigEndian.Uint32(sub[rec+8:])
+		if end > 0x10FFFF || end < start {
+			continue
+		}
+		for c := start; c <= end; c++ {
+			glyphs[rune(c)] = uint16(startGlyph + (c - start))
+		}
+	}
+	return glyphs, nil
+}
+
+// glyph returns the glyph ID for a character, 0 (.notdef) if missing
+func (f *trueTypeFont) glyph(r rune) uint16 {
+	return f.glyphs[r]
+}
+
+// advance returns the advance width of a glyph in 1/1000 of the font size
+func (f *trueTypeFont) advance(gid uint16) float64 {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return float64(f.advances[gid]) * 1000 / float64(f.unitsPerEm)
+}
+
+// scale converts font units to 1/1000 of the font size
+func (f *trueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
@@ -0,0 +1,472 @@
+// Package pdf implements a minimal PDF writer for printable tabular reports.
+// Text is drawn with an embedded TrueType font so that Cyrillic is rendered
+// correctly; if no font can be loaded the built-in Helvetica is used.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A4 page size in points
+const (
+	a4Width  = 595.28
+	a4Height = 841.89
+)
+
+// Page margin in points
+const margin = 36
+
+// Document is a PDF document being built page by page.
+// Coordinates passed to drawing methods have their origin at the top-left corner.
+type Document struct {
+	width, height float64
+	font          *trueTypeFont
+	pages         []*bytes.Buffer
+	page          *bytes.Buffer
+	used          map[uint16]rune // Glyphs used, for widths and ToUnicode
+	y             float64         // Layout cursor used by Heading and Table
+	footer        string
+}
+
+// New creates an A4 document, landscape if requested
+func New(landscape bool) *Document {
+	d := &Document{width: a4Width, height: a4Height, used: make(map[uint16]rune)}
+	if landscape {
+		d.width, d.height = d.height, d.width
+	}
+	return d
+}
+
+// LoadFont embeds a TrueType font from a file
+func (d *Document) LoadFont(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read font: %w", err)
+	}
+	font, err := parseTrueType(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse font %s: %w", path, err)
+	}
+	d.font = font
+	return nil
+}
+
+// LoadSystemFont embeds the first available system font with Cyrillic glyphs
+func (d *Document) LoadSystemFont() error {
+	var lastErr error
+	for _, path := range systemFontPaths() {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := d.LoadFont(path); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return fmt.Errorf("no suitable system font found")
+}
+
+// SetFooter sets text printed at the bottom of every page next to the page number
+func (d *Document) SetFooter(text string) {
+	d.footer = text
+}
+
+// PageSize returns the page width and height in points
+func (d *Document) PageSize() (float64, float64) {
+	return d.width, d.height
+}
+
+// AddPage starts a new page and resets the layout cursor to the top margin
+func (d *Document) AddPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = margin
+}
+
+// Text draws a single line of text with its baseline at y
+func (d *Document) Text(x, y, size float64, s string) {
+	if d.page == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.page, "BT /F1 %s Tf %s %s Td %s Tj ET\n", num(size), num(x), num(d.height-y), d.encode(s))
+}
+
+// TextWidth returns the width of a string in points
+func (d *Document) TextWidth(s string, size float64) float64 {
+	var w float64
+	for _, r := range s {
+		if d.font != nil {
+			w += d.font.advance(d.font.glyph(r))
+		} else {
+			w += helveticaWidth(r)
+		}
+	}
+	return w * size / 1000
+}
+
+// Line draws a line of the given gray level (0 black, 1 white)
+func (d *Document) Line(x1, y1, x2, y2, gray float64) {
+	if d.page == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.page, "%s G 0.5 w %s %s m %s %s l S\n", num(gray), num(x1), num(d.height-y1), num(x2), num(d.height-y2))
+}
+
+// FillRect fills a rectangle with a gray level (0 black, 1 white)
+func (d *Document) FillRect(x, y, w, h, gray float64) {
+	if d.page == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.page, "%s g %s %s %s %s re f 0 g\n", num(gray), num(x), num(d.height-y-h), num(w), num(h))
+}
+
+// Heading draws a line of text at the layout cursor and moves the cursor down
+func (d *Document) Heading(s string, size float64) {
+	if d.page == nil || d.y+size*1.6 > d.height-margin {
+		d.AddPage()
+	}
+	d.Text(margin, d.y+size, size, s)
+	d.y += size * 1.6
+}
+
+// Space moves the layout cursor down
+func (d *Document) Space(h float64) {
+	d.y += h
+}
+
+// Table draws a table at the layout cursor, breaking it across pages and repeating
+// the header. widths are relative column weights; nil means equal widths.
+func (d *Document) Table(columns []string, widths []float64, rows [][]string) {
+	const (
+		fontSize   = 8
+		rowHeight  = 14
+		cellMargin = 3
+	)
+
+	if d.page == nil {
+		d.AddPage()
+	}
+
+	available := d.width - 2*margin
+	colWidths := make([]float64, len(columns))
+	var total float64
+	for i := range columns {
+		w := 1.0
+		if i < len(widths) && widths[i] > 0 {
+			w = widths[i]
+		}
+		colWidths[i] = w
+		total += w
+	}
+	for i := range colWidths {
+		colWidths[i] = colWidths[i] / total * available
+	}
+
+	drawRow := func(cells []string, header bool) {
+		if header {
+			d.FillRect(margin, d.y, available, rowHeight, 0.88)
+		}
+		x := float64(margin)
+		for i, w := range colWidths {
+			if i < len(cells) {
+				text := d.fit(cells[i], fontSize, w-2*cellMargin)
+				d.Text(x+cellMargin, d.y+rowHeight-4, fontSize, text)
+			}
+			x += w
+		}
+		d.y += rowHeight
+		d.Line(margin, d.y, margin+available, d.y, 0.75)
+	}
+
+	header := func() {
+		d.Line(margin, d.y, margin+available, d.y, 0.75)
+		drawRow(columns, true)
+	}
+
+	if d.y+2*rowHeight > d.height-margin {
+		d.AddPage()
+	}
+	header()
+	for _, row := range rows {
+		if d.y+rowHeight > d.height-margin {
+			d.AddPage()
+			header()
+		}
+		drawRow(row, false)
+	}
+}
+
+// fit truncates a string with an ellipsis so that it fits into width points
+func (d *Document) fit(s string, size, width float64) string {
+	if d.TextWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimRight(string(runes), " ") + "…"
+		if d.TextWidth(candidate, size) <= width {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// encode converts a string to a PDF string operand for the current font
+func (d *Document) encode(s string) string {
+	if d.font == nil {
+		var b strings.Builder
+		b.WriteByte('(')
+		for _, r := range s {
+			c := winAnsi(r)
+			if c == '(' || c == ')' || c == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+		}
+		b.WriteByte(')')
+		return b.String()
+	}
+
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		gid := d.font.glyph(r)
+		if _, ok := d.used[gid]; !ok && gid != 0 {
+			d.used[gid] = r
+		}
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// WriteFile writes the document to a file
+func (d *Document) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create pdf: %w", err)
+	}
+	if _, err := d.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteTo writes the document as PDF
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// Page footers are added last so the total page count is known
+	for i, page := range d.pages {
+		d.page = page
+		label := fmt.Sprintf("%d / %d", i+1, len(d.pages))
+		size := 7.0
+		d.Text(d.width-margin-d.TextWidth(label, size), d.height-margin/2, size, label)
+		if d.footer != "" {
+			d.Text(margin, d.height-margin/2, size, d.footer)
+		}
+	}
+
+	pw := &pdfWriter{}
+	pw.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// Object numbers: 1 catalog, 2 page tree, 3 font, then fonts' parts and pages
+	const catalogID, pagesID, fontID = 1, 2, 3
+	next := 4
+
+	var fontObjects []func()
+	if d.font != nil {
+		cidFontID, descriptorID, fileID, toUnicodeID := next, next+1, next+2, next+3
+		next += 4
+		fontObjects = append(fontObjects, func() {
+			pw.object(fontID, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /ReportFont /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", cidFontID, toUnicodeID))
+			pw.object(cidFontID, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /ReportFont /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW 1000 /W %s /CIDToGIDMap /Identity >>", descriptorID, d.widthsArray()))
+			f := d.font
+			pw.object(descriptorID, fmt.Sprintf("<< /Type /FontDescriptor /FontName /ReportFont /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+				f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]), f.scale(f.ascent), f.scale(f.descent), f.scale(f.ascent), fileID))
+			pw.stream(fileID, fmt.Sprintf("/Length1 %d", len(f.data)), f.data)
+			pw.stream(toUnicodeID, "", []byte(d.toUnicodeCMap()))
+		})
+	} else {
+		fontObjects = append(fontObjects, func() {
+			pw.object(fontID, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+		})
+	}
+
+	pageIDs := make([]int, len(d.pages))
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageIDs[i] = next
+		kids[i] = fmt.Sprintf("%d 0 R", next)
+		next += 2 // Page and its content stream
+	}
+
+	pw.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	pw.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, obj := range fontObjects {
+		obj()
+	}
+	for i, page := range d.pages {
+		pw.object(pageIDs[i], fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesID, num(d.width), num(d.height), fontID, pageIDs[i]+1))
+		pw.stream(pageIDs[i]+1, "", page.Bytes())
+	}
+
+	pw.finish(catalogID, next, time.Now())
+
+	n, err := w.Write(pw.buf.Bytes())
+	if err != nil {
+		return int64(n), fmt.Errorf("failed to write pdf: %w", err)
+	}
+	return int64(n), nil
+}
+
+// widthsArray builds the /W array for all used glyphs
+func (d *Document) widthsArray() string {
+	gids := d.usedGlyphs()
+	var b strings.Builder
+	b.WriteByte('[')
+	for _, gid := range gids {
+		fmt.Fprintf(&b, "%d [%d] ", gid, int(d.font.advance(gid)+0.5))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// toUnicodeCMap maps used glyphs back to characters so text can be copied and searched
+func (d *Document) toUnicodeCMap() string {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	gids := d.usedGlyphs()
+	for start := 0; start < len(gids); start += 100 {
+		end := start + 100
+		if end > len(gids) {
+			end = len(gids)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			r := d.used[gid]
+			var utf16 string
+			if r > 0xFFFF {
+				r -= 0x10000
+				utf16 = fmt.Sprintf("%04X%04X", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+			} else {
+				utf16 = fmt.Sprintf("%04X", r)
+			}
+			fmt.Fprintf(&b, "<%04X> <%s>\n", gid, utf16)
+		}
+		b.WriteString("endbfchar\n")
+	}
+
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.String()
+}
+
+func (d *Document) usedGlyphs() []uint16 {
+	gids := make([]uint16, 0, len(d.used))
+	for gid := range d.used {
+		gids = append(gids, gid)
+	}
+	sort.Slice(gids, func(i, j int) bool { return gids[i] < gids[j] })
+	return gids
+}
+
+// pdfWriter serialises objects and tracks their offsets for the xref table
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *pdfWriter) object(id int, body string) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream writes a Flate-compressed stream object; extra is added to the stream dictionary
+func (w *pdfWriter) stream(id int, extra string, data []byte) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode %s>>\nstream\n", id, compressed.Len(), extra)
+	w.buf.Write(compressed.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func (w *pdfWriter) finish(rootID, size int, created time.Time) {
+	infoID := size
+	w.object(infoID, fmt.Sprintf("<< /Producer (NetVisionMonitor) /CreationDate (D:%s) >>", created.Format("20060102150405")))
+	size++
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for id := 1; id < size; id++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[id])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, rootID, infoID, xref)
+}
+
+// num formats a coordinate with at most two decimals
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" {
+		return "0"
+	}
+	return s
+}
+
+// winAnsi maps a character to WinAnsiEncoding for the Helvetica fallback
+func winAnsi(r rune) byte {
+	switch {
+	case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+		return byte(r)
+	case r == '…':
+		return 0x85
+	case r == '–':
+		return 0x96
+	case r == '—':
+		return 0x97
+	}
+	return '?'
+}
+
+// helveticaWidth approximates Helvetica glyph widths in 1/1000 of the font size
+func helveticaWidth(r rune) float64 {
+	switch {
+	case r == ' ' || r == '.' || r == ',' || r == ':' || r == ';' || r == 'i' || r == 'l' || r == 'j' || r == '|':
+		return 278
+	case r >= 'A' && r <= 'Z', r == 'm', r == 'w':
+		return 667
+	case r >= '0' && r <= '9':
+		return 556
+	}
+	return 556
+}
@@ -0,0 +1,154 @@
+// Package sla computes time-weighted availability from device status periods.
+package sla
+
+import (
+	"sort"
+	"time"
+
+	"netvisionmonitor/internal/models"
+)
+
+// Interval is a half-open time range [Start, End)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns the length of the interval
+func (i Interval) Duration() time.Duration {
+	if i.End.Before(i.Start) {
+		return 0
+	}
+	return i.End.Sub(i.Start)
+}
+
+// Merge sorts intervals, clips them to [start, end) and merges overlapping ones
+func Merge(intervals []Interval, start, end time.Time) []Interval {
+	var clipped []Interval
+	for _, iv := range intervals {
+		c, ok := clip(iv, start, end)
+		if ok {
+			clipped = append(clipped, c)
+		}
+	}
+	sort.Slice(clipped, func(i, j int) bool { return clipped[i].Start.Before(clipped[j].Start) })
+
+	var merged []Interval
+	for _, iv := range clipped {
+		if n := len(merged); n > 0 && !iv.Start.After(merged[n-1].End) {
+			if iv.End.After(merged[n-1].End) {
+				merged[n-1].End = iv.End
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// Compute calculates availability for [start, end). Periods must be sorted by start
+// time. Time covered by maintenance is excluded from both uptime and downtime, and
+// outages are reported without their maintenance part.
+func Compute(periods []models.StatusPeriod, start, end time.Time, maintenance []Interval) (models.SLAAvailability, []models.SLAOutage) {
+	var result models.SLAAvailability
+	var outages []models.SLAOutage
+	if !end.After(start) {
+		return result, nil
+	}
+
+	maintenance = Merge(maintenance, start, end)
+	var maintenanceTotal time.Duration
+	for _, m := range maintenance {
+		maintenanceTotal += m.Duration()
+	}
+
+	var up, down time.Duration
+	for _, p := range periods {
+		seg, ok := clip(Interval{p.StartedAt, p.EndedAt}, start, end)
+		if !ok {
+			continue
+		}
+		effective := seg.Duration() - overlap(seg, maintenance)
+
+		switch p.Status {
+		case string(models.DeviceStatusOnline):
+			up += effective
+		case string(models.DeviceStatusOffline):
+			down += effective
+			if effective > 0 {
+				outages = append(outages, models.SLAOutage{
+					Start:    seg.Start,
+					End:      seg.End,
+					Duration: int64(effective.Seconds()),
+				})
+			}
+		}
+	}
+
+	monitored := up + down
+	noData := end.Sub(start) - maintenanceTotal - monitored
+	if noData < 0 {
+		noData = 0
+	}
+
+	result = models.SLAAvailability{
+		Monitored:   int64(monitored.Seconds()),
+		Uptime:      int64(up.Seconds()),
+		Downtime:    int64(down.Seconds()),
+		Maintenance: int64(maintenanceTotal.Seconds()),
+		NoData:      int64(noData.Seconds()),
+		OutageCount: len(outages),
+	}
+	finish(&result)
+	return result, outages
+}
+
+// Combine sums availability of several devices into a single figure
+func Combine(items []models.SLAAvailability) models.SLAAvailability {
+	var result models.SLAAvailability
+	for _, it := range items {
+		result.Monitored += it.Monitored
+		result.Uptime += it.Uptime
+		result.Downtime += it.Downtime
+		result.Maintenance += it.Maintenance
+		result.NoData += it.NoData
+		result.OutageCount += it.OutageCount
+	}
+	finish(&result)
+	return result
+}
+
+// finish calculates availability percent, MTTR and MTBF from the totals
+func finish(a *models.SLAAvailability) {
+	a.AvailabilityPercent = 0
+	a.MTTR = 0
+	a.MTBF = 0
+	if a.Monitored > 0 {
+		a.AvailabilityPercent = float64(a.Uptime) / float64(a.Monitored) * 100
+	}
+	if a.OutageCount > 0 {
+		a.MTTR = a.Downtime / int64(a.OutageCount)
+		a.MTBF = a.Uptime / int64(a.OutageCount)
+	}
+}
+
+func clip(iv Interval, start, end time.Time) (Interval, bool) {
+	if iv.Start.Before(start) {
+		iv.Start = start
+	}
+	if iv.End.After(end) {
+		iv.End = end
+	}
+	return iv, iv.End.After(iv.Start)
+}
+
+// overlap returns how much of seg is covered by merged intervals
+func overlap(seg Interval, merged []Interval) time.Duration {
+	var total time.Duration
+	for _, m := range merged {
+		if c, ok := clip(m, seg.Start, seg.End); ok {
+			total += c.Duration()
+		}
+	}
+	return total
+}
@@ -68,6 +68,26 @@ func NewClientV3(target, user string, security SNMPv3Security) *Client {
 	}
 }
 
+// NewClientAuto creates a client for the SNMP version, with SNMPv3 settings used
+// only for v3
+func NewClientAuto(target, version, community, v3User, v3Security, v3AuthProto, v3AuthPass, v3PrivProto, v3PrivPass string) *Client {
+	if version == "v3" {
+		client := NewClientV3(target, v3User, SNMPv3Security(v3Security))
+		if v3AuthProto != "" {
+			client.SetV3Auth(v3AuthProto, v3AuthPass)
+		}
+		if v3PrivProto != "" {
+			client.SetV3Priv(v3PrivProto, v3PrivPass)
+		}
+		return client
+	}
+	client := NewClient(target, community)
+	if version == "v1" {
+		client.SetVersion(SNMPv1)
+	}
+	return client
+}
+
 // SetPort sets custom SNMP port
 func (c *Client) SetPort(port uint16) {
 	c.port = port
@@ -0,0 +1,324 @@
+package snmp
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"netvisionmonitor/internal/models"
+)
+
+// ENTITY-SENSOR-MIB (RFC 3433) and ENTITY-MIB OIDs
+const (
+	OIDentPhySensorType       = ".1.3.6.1.2.1.99.1.1.1.1"
+	OIDentPhySensorScale      = ".1.3.6.1.2.1.99.1.1.1.2"
+	OIDentPhySensorPrecision  = ".1.3.6.1.2.1.99.1.1.1.3"
+	OIDentPhySensorValue      = ".1.3.6.1.2.1.99.1.1.1.4"
+	OIDentPhySensorOperStatus = ".1.3.6.1.2.1.99.1.1.1.5"
+	OIDentPhysicalName        = ".1.3.6.1.2.1.47.1.1.1.1.7"
+)
+
+// CISCO-ENVMON-MIB, CISCO-PROCESS-MIB and CISCO-MEMORY-POOL-MIB OIDs
+const (
+	OIDciscoEnvMonTemperatureDescr = ".1.3.6.1.4.1.9.9.13.1.3.1.2"
+	OIDciscoEnvMonTemperatureValue = ".1.3.6.1.4.1.9.9.13.1.3.1.3" // °C
+	OIDciscoEnvMonTemperatureState = ".1.3.6.1.4.1.9.9.13.1.3.1.6"
+	OIDciscoEnvMonFanDescr         = ".1.3.6.1.4.1.9.9.13.1.4.1.2"
+	OIDciscoEnvMonFanState         = ".1.3.6.1.4.1.9.9.13.1.4.1.3"
+	OIDciscoEnvMonSupplyDescr      = ".1.3.6.1.4.1.9.9.13.1.5.1.2"
+	OIDciscoEnvMonSupplyState      = ".1.3.6.1.4.1.9.9.13.1.5.1.3"
+	OIDcpmCPUTotal5minRev          = ".1.3.6.1.4.1.9.9.109.1.1.1.1.8"
+	OIDciscoMemoryPoolUsed         = ".1.3.6.1.4.1.9.9.48.1.1.1.5"
+	OIDciscoMemoryPoolFree         = ".1.3.6.1.4.1.9.9.48.1.1.1.6"
+)
+
+// HOST-RESOURCES-MIB OIDs, implemented by many Linux-based switches
+const (
+	OIDhrProcessorLoad = ".1.3.6.1.2.1.25.3.3.1.2"
+	OIDhrStorageType   = ".1.3.6.1.2.1.25.2.3.1.2"
+	OIDhrStorageSize   = ".1.3.6.1.2.1.25.2.3.1.5"
+	OIDhrStorageUsed   = ".1.3.6.1.2.1.25.2.3.1.6"
+	OIDhrStorageRAM    = ".1.3.6.1.2.1.25.2.1.2" // hrStorageType of physical memory
+)
+
+// POWER-ETHERNET-MIB (RFC 3621) OIDs, per PSE group
+const (
+	OIDpethMainPsePower            = ".1.3.6.1.2.1.105.1.3.1.1.2" // Budget, watts
+	OIDpethMainPseConsumptionPower = ".1.3.6.1.2.1.105.1.3.1.1.4" // Watts
+)
+
+// GetSwitchEnvironment reads temperature, fan, power supply, PoE budget and
+// CPU/memory state of a switch from the standard MIBs, the Cisco MIBs and the
+// TFortis PoE table, whichever the switch implements
+func (c *Client) GetSwitchEnvironment() (*models.SwitchEnvironment, error) {
+	// The first walk tells whether the switch answers at all
+	sensorTypes, err := c.walkColumn(OIDentPhySensorType)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &models.SwitchEnvironment{
+		Sensors:  []models.SensorReading{},
+		Fans:     []models.ComponentStatus{},
+		PSUs:     []models.ComponentStatus{},
+		PoEPorts: []models.PortPower{},
+	}
+	c.readEntitySensors(env, sensorTypes)
+	c.readCiscoEnvironment(env)
+	c.readPoEPower(env)
+	c.readCPUAndMemory(env)
+
+	for _, sensor := range env.Sensors {
+		if sensor.Type == "temperature" && sensor.Status == "ok" &&
+			(env.Temperature == nil || sensor.Value > *env.Temperature) {
+			temperature := sensor.Value
+			env.Temperature = &temperature
+		}
+	}
+
+	if len(env.Sensors) == 0 && len(env.Fans) == 0 && len(env.PSUs) == 0 &&
+		env.PoEUsedW == nil && env.CPUPercent == nil && env.MemoryPercent == nil {
+		return nil, fmt.Errorf("device reports no environment data")
+	}
+	return env, nil
+}
+
+// readEntitySensors reads ENTITY-SENSOR-MIB sensors. Fan speed sensors also
+// count as fans.
+func (c *Client) readEntitySensors(env *models.SwitchEnvironment, types map[string]interface{}) {
+	if len(types) == 0 {
+		return
+	}
+	scales := c.walkOptional(OIDentPhySensorScale)
+	precisions := c.walkOptional(OIDentPhySensorPrecision)
+	values := c.walkOptional(OIDentPhySensorValue)
+	statuses := c.walkOptional(OIDentPhySensorOperStatus)
+	names := c.walkOptional(OIDentPhysicalName)
+
+	indexes := slices.Collect(maps.Keys(types))
+	sortIndexes(indexes)
+	for _, index := range indexes {
+		sensorType, _ := toInt64(types[index])
+		raw, ok := toInt64(values[index])
+		if !ok {
+			continue
+		}
+		scale, ok := toInt64(scales[index])
+		if !ok {
+			scale = 9 // units
+		}
+		precision, _ := toInt64(precisions[index])
+
+		sensor := models.SensorReading{
+			Name:  toString(names[index]),
+			Value: float64(raw) * math.Pow10(int(scale-9)*3-int(precision)),
+		}
+		if sensor.Name == "" {
+			sensor.Name = "Sensor " + index
+		}
+		sensor.Type, sensor.Unit = decodeSensorType(sensorType)
+		switch status, _ := toInt64(statuses[index]); status {
+		case 1:
+			sensor.Status = "ok"
+		case 3:
+			sensor.Status = "failed"
+		default:
+			sensor.Status = "unavailable"
+		}
+		env.Sensors = append(env.Sensors, sensor)
+
+		if sensor.Type == "fan" {
+			fan := models.ComponentStatus{Name: sensor.Name, Status: sensor.Status}
+			if fan.Status == "unavailable" {
+				fan.Status = "unknown"
+			}
+			env.Fans = append(env.Fans, fan)
+		}
+	}
+}
+
+// decodeSensorType maps entPhySensorType to a sensor type and unit
+func decodeSensorType(sensorType int64) (string, string) {
+	switch sensorType {
+	case 3, 4: // voltsAC, voltsDC
+		return "voltage", "V"
+	case 5:
+		return "current", "A"
+	case 6:
+		return "power", "W"
+	case 8:
+		return "temperature", "C"
+	case 10:
+		return "fan", "rpm"
+	}
+	return "other", ""
+}
+
+// readCiscoEnvironment reads temperatures, fans and power supplies from
+// CISCO-ENVMON-MIB
+func (c *Client) readCiscoEnvironment(env *models.SwitchEnvironment) {
+	temperatures := c.walkOptional(OIDciscoEnvMonTemperatureValue)
+	if len(temperatures) > 0 {
+		descrs := c.walkOptional(OIDciscoEnvMonTemperatureDescr)
+		states := c.walkOptional(OIDciscoEnvMonTemperatureState)
+		indexes := slices.Collect(maps.Keys(temperatures))
+		sortIndexes(indexes)
+		for _, index := range indexes {
+			v, ok := toInt64(temperatures[index])
+			if !ok {
+				continue
+			}
+			sensor := models.SensorReading{
+				Name:   toString(descrs[index]),
+				Type:   "temperature",
+				Value:  float64(v),
+				Unit:   "C",
+				Status: "ok",
+			}
+			if state, ok := toInt64(states[index]); ok && decodeEnvMonState(state) != "ok" {
+				sensor.Status = "failed"
+			}
+			env.Sensors = append(env.Sensors, sensor)
+		}
+	}
+
+	env.Fans = append(env.Fans, c.readEnvMonComponents(OIDciscoEnvMonFanState, OIDciscoEnvMonFanDescr, "Fan")...)
+	env.PSUs = append(env.PSUs, c.readEnvMonComponents(OIDciscoEnvMonSupplyState, OIDciscoEnvMonSupplyDescr, "PSU")...)
+}
+
+// readEnvMonComponents reads the fan or power supply table of CISCO-ENVMON-MIB
+func (c *Client) readEnvMonComponents(stateColumn, descrColumn, kind string) []models.ComponentStatus {
+	states := c.walkOptional(stateColumn)
+	if len(states) == 0 {
+		return nil
+	}
+	descrs := c.walkOptional(descrColumn)
+
+	indexes := slices.Collect(maps.Keys(states))
+	sortIndexes(indexes)
+	components := make([]models.ComponentStatus, 0, len(indexes))
+	for _, index := range indexes {
+		component := models.ComponentStatus{Name: toString(descrs[index]), Status: "unknown"}
+		if component.Name == "" {
+			component.Name = kind + " " + index
+		}
+		if state, ok := toInt64(states[index]); ok {
+			component.Status = decodeEnvMonState(state)
+		}
+		components = append(components, component)
+	}
+	return components
+}
+
+// decodeEnvMonState maps CiscoEnvMonState
+func decodeEnvMonState(state int64) string {
+	switch state {
+	case 1:
+		return "ok"
+	case 2:
+		return "warning"
+	case 3, 4, 6: // critical, shutdown, notFunctioning
+		return "failed"
+	case 5:
+		return "not_present"
+	}
+	return "unknown"
+}
+
+// readPoEPower reads the PoE budget and consumption of all PSE groups from
+// POWER-ETHERNET-MIB and the power per port from the TFortis PoE table. Without
+// POWER-ETHERNET-MIB the sum of the ports is the consumption.
+func (c *Client) readPoEPower(env *models.SwitchEnvironment) {
+	var budget, used float64
+	var budgetOK, usedOK bool
+	for _, value := range c.walkOptional(OIDpethMainPsePower) {
+		if v, ok := toInt64(value); ok {
+			budget += float64(v)
+			budgetOK = true
+		}
+	}
+	for _, value := range c.walkOptional(OIDpethMainPseConsumptionPower) {
+		if v, ok := toInt64(value); ok {
+			used += float64(v)
+			usedOK = true
+		}
+	}
+
+	pseReported := usedOK
+	ports := c.walkOptional(OIDPoEPowerBase)
+	indexes := slices.Collect(maps.Keys(ports))
+	sortIndexes(indexes)
+	for _, index := range indexes {
+		port, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		v, ok := toInt64(ports[index])
+		if !ok {
+			continue
+		}
+		power := float64(v) / 1000
+		env.PoEPorts = append(env.PoEPorts, models.PortPower{Port: port, PowerW: power})
+		if !pseReported {
+			used += power
+			usedOK = true
+		}
+	}
+
+	if budgetOK && budget > 0 {
+		env.PoEBudgetW = &budget
+	}
+	if usedOK {
+		env.PoEUsedW = &used
+	}
+}
+
+// readCPUAndMemory reads CPU and memory load from the Cisco MIBs, or from
+// HOST-RESOURCES-MIB if the switch is not a Cisco
+func (c *Client) readCPUAndMemory(env *models.SwitchEnvironment) {
+	// The busiest CPU decides
+	for _, column := range []string{OIDcpmCPUTotal5minRev, OIDhrProcessorLoad} {
+		for _, value := range c.walkOptional(column) {
+			if v, ok := toInt64(value); ok && (env.CPUPercent == nil || int(v) > *env.CPUPercent) {
+				load := int(v)
+				env.CPUPercent = &load
+			}
+		}
+		if env.CPUPercent != nil {
+			break
+		}
+	}
+
+	used := c.walkOptional(OIDciscoMemoryPoolUsed)
+	free := c.walkOptional(OIDciscoMemoryPoolFree)
+	// Pool 1 is the processor memory
+	if u, ok := toInt64(used["1"]); ok {
+		if f, ok := toInt64(free["1"]); ok && u+f > 0 {
+			percent := int(u * 100 / (u + f))
+			env.MemoryPercent = &percent
+			return
+		}
+	}
+
+	types := c.walkOptional(OIDhrStorageType)
+	if len(types) == 0 {
+		return
+	}
+	sizes := c.walkOptional(OIDhrStorageSize)
+	usedUnits := c.walkOptional(OIDhrStorageUsed)
+	for index, value := range types {
+		if strings.TrimPrefix(toString(value), ".") != strings.TrimPrefix(OIDhrStorageRAM, ".") {
+			continue
+		}
+		size, sizeOK := toInt64(sizes[index])
+		u, usedOK := toInt64(usedUnits[index])
+		if sizeOK && usedOK && size > 0 {
+			percent := int(u * 100 / size)
+			env.MemoryPercent = &percent
+			return
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package snmp
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// walkColumn walks a table column and returns its values by row index, the part
+// of the OID after the column
+func (c *Client) walkColumn(column string) (map[string]interface{}, error) {
+	values, err := c.Walk(column)
+	if err != nil {
+		return nil, err
+	}
+	rows := make(map[string]interface{}, len(values))
+	for oid, value := range values {
+		if index, ok := strings.CutPrefix(oid, column+"."); ok {
+			rows[index] = value
+		}
+	}
+	return rows, nil
+}
+
+// walkOptional walks a table column that not every agent implements, an agent
+// without it returns no rows
+func (c *Client) walkOptional(column string) map[string]interface{} {
+	rows, err := c.walkColumn(column)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	return rows
+}
+
+// toInt64 converts a decoded numeric value. Missing values (noSuchObject,
+// noSuchInstance) are not ok.
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case uint:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// toString converts a decoded value to a string, missing values give ""
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimRight(v, "\x00")
+	case []byte:
+		return strings.TrimRight(string(v), "\x00")
+	}
+	return fmt.Sprint(value)
+}
+
+// sortIndexes sorts row indexes, such as 1.2 and 10.1, in numeric order
+func sortIndexes(indexes []string) {
+	slices.SortFunc(indexes, func(a, b string) int {
+		as, bs := strings.Split(a, "."), strings.Split(b, ".")
+		for i := 0; i < len(as) && i < len(bs); i++ {
+			an, aerr := strconv.Atoi(as[i])
+			bn, berr := strconv.Atoi(bs[i])
+			if aerr != nil || berr != nil {
+				if c := strings.Compare(as[i], bs[i]); c != 0 {
+					return c
+				}
+				continue
+			}
+			if an != bn {
+				return an - bn
+			}
+		}
+		return len(as) - len(bs)
+	})
+}
@@ -0,0 +1,66 @@
+package snmp
+
+import (
+	"fmt"
+	"strings"
+
+	"netvisionmonitor/internal/models"
+)
+
+// HOST-RESOURCES-MIB OIDs
+const (
+	OIDhrDeviceType          = ".1.3.6.1.2.1.25.3.2.1.2"
+	OIDhrDeviceDescr         = ".1.3.6.1.2.1.25.3.2.1.3"
+	OIDhrDeviceStatus        = ".1.3.6.1.2.1.25.3.2.1.5"
+	OIDhrDiskStorageCapacity = ".1.3.6.1.2.1.25.3.6.1.4" // KB
+	OIDhrDeviceDiskStorage   = ".1.3.6.1.2.1.25.3.1.6"   // hrDeviceType of disks
+)
+
+// GetDisks reads the disks of an NVR from the HOST-RESOURCES-MIB device table
+func (c *Client) GetDisks() ([]models.DiskStatus, error) {
+	types, err := c.walkColumn(OIDhrDeviceType)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []string
+	for index, value := range types {
+		if "."+strings.TrimPrefix(toString(value), ".") == OIDhrDeviceDiskStorage {
+			indexes = append(indexes, index)
+		}
+	}
+	if len(indexes) == 0 {
+		if len(types) == 0 {
+			return nil, fmt.Errorf("device does not support HOST-RESOURCES-MIB")
+		}
+		return []models.DiskStatus{}, nil
+	}
+	sortIndexes(indexes)
+
+	descr := c.walkOptional(OIDhrDeviceDescr)
+	statuses := c.walkOptional(OIDhrDeviceStatus)
+	capacities := c.walkOptional(OIDhrDiskStorageCapacity)
+
+	disks := make([]models.DiskStatus, 0, len(indexes))
+	for _, index := range indexes {
+		disk := models.DiskStatus{
+			ID:     index,
+			Name:   toString(descr[index]),
+			Status: "unknown",
+		}
+		if v, ok := toInt64(statuses[index]); ok {
+			switch v {
+			case 2:
+				disk.Status = "ok"
+			case 3, 4:
+				disk.Status = "warning"
+			case 5:
+				disk.Status = "failed"
+			}
+		}
+		if v, ok := toInt64(capacities[index]); ok {
+			disk.CapacityMB = v / 1024
+		}
+		disks = append(disks, disk)
+	}
+	return disks, nil
+}
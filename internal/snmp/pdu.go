@@ -0,0 +1,63 @@
+package snmp
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"netvisionmonitor/internal/models"
+)
+
+// APC PowerNet-MIB OIDs, also implemented by many other PDUs
+const (
+	OIDrPDUOutletStatusName  = ".1.3.6.1.4.1.318.1.1.12.3.5.1.1.2"
+	OIDrPDUOutletStatusState = ".1.3.6.1.4.1.318.1.1.12.3.5.1.1.4"
+	OIDrPDULoadStatusLoad    = ".1.3.6.1.4.1.318.1.1.12.2.3.1.1.2" // Tenths of amps
+)
+
+// GetPDUStatus reads outlet states and the load current of a PDU
+func (c *Client) GetPDUStatus() (*models.PDUStatus, error) {
+	states, err := c.walkColumn(OIDrPDUOutletStatusState)
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, fmt.Errorf("device does not support the PowerNet PDU MIB")
+	}
+	names := c.walkOptional(OIDrPDUOutletStatusName)
+
+	indexes := slices.Collect(maps.Keys(states))
+	sortIndexes(indexes)
+
+	status := &models.PDUStatus{Outlets: make([]models.OutletStatus, 0, len(indexes))}
+	for _, index := range indexes {
+		number, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		outlet := models.OutletStatus{
+			Number: number,
+			Name:   toString(names[index]),
+			State:  "unknown",
+		}
+		if v, ok := toInt64(states[index]); ok {
+			switch v {
+			case 1:
+				outlet.State = "on"
+			case 2:
+				outlet.State = "off"
+			}
+		}
+		status.Outlets = append(status.Outlets, outlet)
+	}
+
+	// The first load row is the total of single-phase PDUs
+	loads := c.walkOptional(OIDrPDULoadStatusLoad)
+	if v, ok := toInt64(loads["1"]); ok {
+		current := float64(v) / 10
+		status.CurrentA = &current
+	}
+
+	return status, nil
+}
@@ -0,0 +1,147 @@
+package snmp
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"netvisionmonitor/internal/models"
+)
+
+// IF-MIB and BGP4-MIB OIDs
+const (
+	OIDifType       = ".1.3.6.1.2.1.2.2.1.3"
+	OIDifName       = ".1.3.6.1.2.1.31.1.1.1.1"
+	OIDifHighSpeed  = ".1.3.6.1.2.1.31.1.1.1.15" // Mbit/s
+	OIDbgpPeerState = ".1.3.6.1.2.1.15.3.1.2"
+	OIDbgpPeerAS    = ".1.3.6.1.2.1.15.3.1.9"
+
+	// Associated wireless clients in vendor MIBs, summed over radios
+	OIDMikroTikWlClients = ".1.3.6.1.4.1.14988.1.1.1.3.1.6" // mtxrWlApClientCount
+	OIDUniFiVapStations  = ".1.3.6.1.4.1.41112.1.6.1.2.1.8" // unifiVapNumStations
+
+	ifTypeIEEE80211 = 71
+)
+
+// GetInterfaces reads the interface table
+func (c *Client) GetInterfaces() ([]models.InterfaceStatus, error) {
+	oper, err := c.walkColumn(OIDifOperStatus)
+	if err != nil {
+		return nil, err
+	}
+	admin := c.walkOptional(OIDifAdminStatus)
+	descr := c.walkOptional(OIDifDescr)
+	names := c.walkOptional(OIDifName)
+	types := c.walkOptional(OIDifType)
+	speeds := c.walkOptional(OIDifSpeed)
+	highSpeeds := c.walkOptional(OIDifHighSpeed)
+
+	indexes := slices.Collect(maps.Keys(oper))
+	sortIndexes(indexes)
+
+	interfaces := make([]models.InterfaceStatus, 0, len(indexes))
+	for _, index := range indexes {
+		n, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		iface := models.InterfaceStatus{
+			Index:       n,
+			Name:        toString(names[index]),
+			AdminStatus: decodePortStatus(admin[index]),
+			OperStatus:  decodePortStatus(oper[index]),
+		}
+		if iface.Name == "" {
+			iface.Name = toString(descr[index])
+		}
+		if t, ok := toInt64(types[index]); ok {
+			iface.Wireless = t == ifTypeIEEE80211
+		}
+		if speed, ok := toInt64(highSpeeds[index]); ok && speed > 0 {
+			iface.SpeedMbps = speed
+		} else if speed, ok := toInt64(speeds[index]); ok {
+			iface.SpeedMbps = speed / 1000000
+		}
+		interfaces = append(interfaces, iface)
+	}
+	return interfaces, nil
+}
+
+// GetBGPPeers reads BGP sessions from BGP4-MIB. Routers without BGP return none.
+func (c *Client) GetBGPPeers() []models.BGPPeer {
+	states := c.walkOptional(OIDbgpPeerState)
+	remoteAS := c.walkOptional(OIDbgpPeerAS)
+
+	addresses := slices.Collect(maps.Keys(states))
+	sortIndexes(addresses)
+
+	peers := make([]models.BGPPeer, 0, len(addresses))
+	for _, address := range addresses {
+		peer := models.BGPPeer{
+			Address: address,
+			State:   decodeBGPState(states[address]),
+		}
+		peer.RemoteAS, _ = toInt64(remoteAS[address])
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+// GetRouterStatus reads interfaces and BGP sessions of a router
+func (c *Client) GetRouterStatus() (*models.RouterStatus, error) {
+	interfaces, err := c.GetInterfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interfaces: %w", err)
+	}
+	return &models.RouterStatus{
+		Interfaces: interfaces,
+		BGPPeers:   c.GetBGPPeers(),
+	}, nil
+}
+
+// GetAccessPointStatus reads interfaces of an access point and, from MikroTik
+// and UniFi MIBs, the number of associated clients
+func (c *Client) GetAccessPointStatus() (*models.AccessPointStatus, error) {
+	interfaces, err := c.GetInterfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interfaces: %w", err)
+	}
+	status := &models.AccessPointStatus{Interfaces: interfaces}
+
+	for _, column := range []string{OIDMikroTikWlClients, OIDUniFiVapStations} {
+		rows := c.walkOptional(column)
+		if len(rows) == 0 {
+			continue
+		}
+		clients := 0
+		for _, value := range rows {
+			if n, ok := toInt64(value); ok {
+				clients += int(n)
+			}
+		}
+		status.Clients = &clients
+		break
+	}
+	return status, nil
+}
+
+func decodeBGPState(value interface{}) string {
+	if v, ok := toInt64(value); ok {
+		switch v {
+		case 1:
+			return "idle"
+		case 2:
+			return "connect"
+		case 3:
+			return "active"
+		case 4:
+			return "opensent"
+		case 5:
+			return "openconfirm"
+		case 6:
+			return "established"
+		}
+	}
+	return "unknown"
+}
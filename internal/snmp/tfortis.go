@@ -134,28 +134,16 @@ func NewTFortisClient(ipAddress, community string) *TFortisClient {
 
 // NewTFortisClientV3 creates a new TFortis SNMP client with v3 support
 func NewTFortisClientV3(ipAddress, user, security, authProto, authPass, privProto, privPass string) *TFortisClient {
-	client := NewClientV3(ipAddress, user, SNMPv3Security(security))
-	if authProto != "" {
-		client.SetV3Auth(authProto, authPass)
-	}
-	if privProto != "" {
-		client.SetV3Priv(privProto, privPass)
-	}
 	return &TFortisClient{
-		client: client,
+		client: NewClientAuto(ipAddress, "v3", "", user, security, authProto, authPass, privProto, privPass),
 	}
 }
 
 // NewTFortisClientAuto creates TFortis client based on version
 func NewTFortisClientAuto(ipAddress, version, community, v3User, v3Security, v3AuthProto, v3AuthPass, v3PrivProto, v3PrivPass string) *TFortisClient {
-	if version == "v3" {
-		return NewTFortisClientV3(ipAddress, v3User, v3Security, v3AuthProto, v3AuthPass, v3PrivProto, v3PrivPass)
-	}
-	client := NewClient(ipAddress, community)
-	if version == "v1" {
-		client.SetVersion(SNMPv1)
+	return &TFortisClient{
+		client: NewClientAuto(ipAddress, version, community, v3User, v3Security, v3AuthProto, v3AuthPass, v3PrivProto, v3PrivPass),
 	}
-	return &TFortisClient{client: client}
 }
 
 // GetSystemInfo retrieves system information
@@ -203,7 +191,7 @@ func (t *TFortisClient) GetPortInfo(portNum int) (*PortInfo, error) {
 	if err == nil {
 		if s, ok := speed.(uint); ok {
 			info.Speed = int64(s)
-			info.SpeedStr = formatSpeed(int64(s))
+			info.SpeedStr = FormatSpeed(int64(s))
 		}
 	}
 
@@ -503,7 +491,8 @@ func decodeAutoRestartMode(mode int) string {
 	}
 }
 
-func formatSpeed(bps int64) string {
+// FormatSpeed formats a link speed in bits per second for display
+func FormatSpeed(bps int64) string {
 	if bps >= 1000000000 {
 		return fmt.Sprintf("%d Гбит/с", bps/1000000000)
 	}
@@ -0,0 +1,129 @@
+package snmp
+
+import (
+	"fmt"
+
+	"netvisionmonitor/internal/models"
+)
+
+// UPS-MIB (RFC 1628) OIDs
+const (
+	OIDupsBatteryStatus         = ".1.3.6.1.2.1.33.1.2.1.0"
+	OIDupsSecondsOnBattery      = ".1.3.6.1.2.1.33.1.2.2.0"
+	OIDupsEstimatedMinutes      = ".1.3.6.1.2.1.33.1.2.3.0"
+	OIDupsEstimatedCharge       = ".1.3.6.1.2.1.33.1.2.4.0"
+	OIDupsBatteryTemperature    = ".1.3.6.1.2.1.33.1.2.7.0"
+	OIDupsInputVoltageBase      = ".1.3.6.1.2.1.33.1.3.3.1.3" // Per input line, RMS volts
+	OIDupsOutputSource          = ".1.3.6.1.2.1.33.1.4.1.0"
+	OIDupsOutputPercentLoadBase = ".1.3.6.1.2.1.33.1.4.4.1.5" // Per output line
+	OIDupsTestResultsSummary    = ".1.3.6.1.2.1.33.1.7.3.0"
+)
+
+// GetUPSStatus reads battery and output state of a UPS from UPS-MIB
+func (c *Client) GetUPSStatus() (*models.UPSStatus, error) {
+	values, err := c.GetMultiple([]string{
+		OIDupsBatteryStatus, OIDupsSecondsOnBattery, OIDupsEstimatedMinutes,
+		OIDupsEstimatedCharge, OIDupsBatteryTemperature, OIDupsOutputSource,
+		OIDupsTestResultsSummary,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	battery, batteryOK := toInt64(values[OIDupsBatteryStatus])
+	source, sourceOK := toInt64(values[OIDupsOutputSource])
+	if !batteryOK && !sourceOK {
+		return nil, fmt.Errorf("device does not support UPS-MIB")
+	}
+
+	status := &models.UPSStatus{
+		BatteryStatus: decodeUPSBatteryStatus(battery),
+		OutputSource:  decodeUPSOutputSource(source),
+		Source:        "snmp",
+	}
+	status.OnBattery = status.OutputSource == "battery"
+	status.BatteryLow = status.BatteryStatus == "low" || status.BatteryStatus == "depleted"
+
+	if v, ok := toInt64(values[OIDupsEstimatedCharge]); ok {
+		status.Charge = int(v)
+	}
+	if v, ok := toInt64(values[OIDupsEstimatedMinutes]); ok {
+		status.RuntimeMinutes = int(v)
+	}
+	if v, ok := toInt64(values[OIDupsSecondsOnBattery]); ok {
+		status.SecondsOnBattery = int(v)
+	}
+	if v, ok := toInt64(values[OIDupsBatteryTemperature]); ok {
+		temperature := int(v)
+		status.Temperature = &temperature
+	}
+	if v, ok := toInt64(values[OIDupsTestResultsSummary]); ok {
+		status.SelfTest = decodeUPSTestResult(v)
+	}
+
+	// The first input line is reported, three-phase units list one per phase
+	voltages := c.walkOptional(OIDupsInputVoltageBase)
+	if v, ok := toInt64(voltages["1"]); ok {
+		voltage := float64(v)
+		status.InputVoltage = &voltage
+	}
+
+	// The most loaded output line decides
+	for _, value := range c.walkOptional(OIDupsOutputPercentLoadBase) {
+		if v, ok := toInt64(value); ok && int(v) > status.Load {
+			status.Load = int(v)
+		}
+	}
+
+	return status, nil
+}
+
+func decodeUPSBatteryStatus(v int64) string {
+	switch v {
+	case 2:
+		return "normal"
+	case 3:
+		return "low"
+	case 4:
+		return "depleted"
+	}
+	return "unknown"
+}
+
+func decodeUPSTestResult(v int64) string {
+	switch v {
+	case 1:
+		return "passed"
+	case 2:
+		return "warning"
+	case 3:
+		return "failed"
+	case 4:
+		return "aborted"
+	case 5:
+		return "in_progress"
+	case 6:
+		return "none"
+	}
+	return ""
+}
+
+func decodeUPSOutputSource(v int64) string {
+	switch v {
+	case 1:
+		return "other"
+	case 2:
+		return "none"
+	case 3:
+		return "normal"
+	case 4:
+		return "bypass"
+	case 5:
+		return "battery"
+	case 6:
+		return "booster"
+	case 7:
+		return "reducer"
+	}
+	return "unknown"
+}
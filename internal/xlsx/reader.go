@@ -0,0 +1,207 @@
+// Package xlsx implements minimal reading and writing of Office Open XML
+// spreadsheets: a single sheet of plain string and number cells.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ReadFile reads all rows of the first worksheet of an XLSX file
+func ReadFile(filename string) ([][]string, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx: %w", err)
+	}
+	defer zr.Close()
+
+	return readRows(&zr.Reader)
+}
+
+// Read reads all rows of the first worksheet from an XLSX archive in memory
+func Read(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx: %w", err)
+	}
+	return readRows(zr)
+}
+
+func readRows(zr *zip.Reader) ([][]string, error) {
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	shared, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, err
+	}
+
+	sheetPath := firstSheetPath(files)
+	sheetFile := files[sheetPath]
+	if sheetFile == nil {
+		return nil, fmt.Errorf("worksheet not found in xlsx")
+	}
+
+	return readSheet(sheetFile, shared)
+}
+
+// firstSheetPath resolves the first worksheet through the workbook relationships
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	type workbook struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	type relationships struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	var wb workbook
+	if err := decodeZipXML(files["xl/workbook.xml"], &wb); err != nil || len(wb.Sheets) == 0 {
+		return fallback
+	}
+	var rels relationships
+	if err := decodeZipXML(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return fallback
+	}
+
+	for _, rel := range rels.Rels {
+		if rel.ID != wb.Sheets[0].RID {
+			continue
+		}
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			return strings.TrimPrefix(target, "/")
+		}
+		return path.Join("xl", target)
+	}
+	return fallback
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	type sst struct {
+		Items []struct {
+			T    string `xml:"t"`
+			Runs []struct {
+				T string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+
+	var s sst
+	if err := decodeZipXML(f, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse shared strings: %w", err)
+	}
+
+	strs := make([]string, len(s.Items))
+	for i, item := range s.Items {
+		if len(item.Runs) == 0 {
+			strs[i] = item.T
+			continue
+		}
+		var b strings.Builder
+		for _, r := range item.Runs {
+			b.WriteString(r.T)
+		}
+		strs[i] = b.String()
+	}
+	return strs, nil
+}
+
+func readSheet(f *zip.File, shared []string) ([][]string, error) {
+	type cell struct {
+		Ref    string `xml:"r,attr"`
+		Type   string `xml:"t,attr"`
+		Value  string `xml:"v"`
+		Inline string `xml:"is>t"`
+	}
+	type worksheet struct {
+		Rows []struct {
+			Index int    `xml:"r,attr"`
+			Cells []cell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+
+	var ws worksheet
+	if err := decodeZipXML(f, &ws); err != nil {
+		return nil, fmt.Errorf("failed to parse worksheet: %w", err)
+	}
+
+	var rows [][]string
+	for i, r := range ws.Rows {
+		rowIndex := r.Index - 1
+		if rowIndex < 0 {
+			rowIndex = i
+		}
+		// Keep row positions, filling skipped rows with empty ones
+		for len(rows) < rowIndex {
+			rows = append(rows, nil)
+		}
+
+		var row []string
+		for j, c := range r.Cells {
+			col := j
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+
+			switch c.Type {
+			case "s":
+				var idx int
+				fmt.Sscanf(c.Value, "%d", &idx)
+				if idx >= 0 && idx < len(shared) {
+					row[col] = shared[idx]
+				}
+			case "inlineStr":
+				row[col] = c.Inline
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// columnIndex converts a cell reference like "AB12" to a zero-based column index
+func columnIndex(ref string) int {
+	idx := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		idx = idx*26 + int(ch-'A'+1)
+	}
+	return idx - 1
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	if f == nil {
+		return fmt.Errorf("file not found")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
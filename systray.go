@@ -1,10 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
+	"netvisionmonitor/internal/database"
 	"netvisionmonitor/internal/logger"
+	"netvisionmonitor/internal/models"
 
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -39,12 +42,11 @@ func (a *App) GetTrayStatus() map[string]interface{} {
 	}
 
 	if a.monitor != nil {
-		monStatus := a.GetMonitoringStatus()
-		status["monitoring"] = monStatus.Running
+		status["monitoring"] = a.monitor.IsRunning()
 
 		// Count online/offline devices
 		if a.db != nil {
-			devices, err := a.GetDevices()
+			devices, err := database.NewDeviceRepository(a.db.DB()).GetAll()
 			if err == nil {
 				online := 0
 				offline := 0
@@ -71,8 +73,7 @@ func (a *App) ShouldMinimizeToTray() bool {
 		return true // Default behavior
 	}
 
-	settings := a.GetSettings()
-	return settings.MinimizeToTray
+	return a.appSettings().MinimizeToTray
 }
 
 // GetMinimizeToTray returns the minimize to tray setting
@@ -80,15 +81,19 @@ func (a *App) GetMinimizeToTray() bool {
 	return a.ShouldMinimizeToTray()
 }
 
-// SetMinimizeToTray updates the minimize to tray setting
+// SetMinimizeToTray updates the minimize to tray setting. It is a preference of
+// the desktop, so any signed in user may change it.
 func (a *App) SetMinimizeToTray(enable bool) error {
-	settings, err := a.GetAppSettings()
-	if err != nil {
+	if err := a.authorize(models.RoleViewer); err != nil {
 		return err
 	}
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
 
+	settings := a.appSettings()
 	settings.MinimizeToTray = enable
-	return a.SaveAppSettings(settings)
+	return database.NewSettingsRepository(a.db.DB()).SetJSON("app_settings", settings)
 }
 
 // IsStartedMinimized checks if app was started with --minimized flag